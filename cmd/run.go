@@ -11,9 +11,30 @@ import (
 
 	"github.com/bjw-s-labs/bookshift/pkg/config"
 	"github.com/bjw-s-labs/bookshift/pkg/kobo"
+	"github.com/bjw-s-labs/bookshift/pkg/syncer/bookmanager"
+	"github.com/bjw-s-labs/bookshift/pkg/syncer/calibre"
+	"github.com/bjw-s-labs/bookshift/pkg/syncer/calibredevice"
+	"github.com/bjw-s-labs/bookshift/pkg/syncer/calibrelib"
+	"github.com/bjw-s-labs/bookshift/pkg/syncer/feed"
+	"github.com/bjw-s-labs/bookshift/pkg/syncer/ftp"
+	"github.com/bjw-s-labs/bookshift/pkg/syncer/httpindex"
 	"github.com/bjw-s-labs/bookshift/pkg/syncer/imap"
+	"github.com/bjw-s-labs/bookshift/pkg/syncer/jmap"
+	"github.com/bjw-s-labs/bookshift/pkg/syncer/libraryserver"
+	"github.com/bjw-s-labs/bookshift/pkg/syncer/local"
+	"github.com/bjw-s-labs/bookshift/pkg/syncer/maildir"
+	"github.com/bjw-s-labs/bookshift/pkg/syncer/mbox"
 	"github.com/bjw-s-labs/bookshift/pkg/syncer/nfs"
+	"github.com/bjw-s-labs/bookshift/pkg/syncer/opds"
+	"github.com/bjw-s-labs/bookshift/pkg/syncer/plugin"
+	"github.com/bjw-s-labs/bookshift/pkg/syncer/pop3"
+	"github.com/bjw-s-labs/bookshift/pkg/syncer/publicdomain"
+	"github.com/bjw-s-labs/bookshift/pkg/syncer/rclone"
+	"github.com/bjw-s-labs/bookshift/pkg/syncer/s3"
+	"github.com/bjw-s-labs/bookshift/pkg/syncer/sftp"
 	"github.com/bjw-s-labs/bookshift/pkg/syncer/smb"
+	"github.com/bjw-s-labs/bookshift/pkg/syncer/wallabag"
+	"github.com/bjw-s-labs/bookshift/pkg/syncer/webdav"
 	"github.com/bjw-s-labs/bookshift/pkg/util"
 )
 
@@ -105,6 +126,321 @@ func (r *RunCommand) Run(cfg *config.Config, logger *slog.Logger) error {
 				if err := doImap(ctx, cfgImap, cfg.TargetFolder, cfg.ValidExtensions, cfg.OverwriteExistingFiles); err != nil {
 					logger.Error("failed to sync from IMAP server", "error", err)
 				}
+
+			case "webdav":
+				cfgWebdav, ok := src.Config.(*config.WebdavConfig)
+				if !ok {
+					logger.Error("invalid configuration type for WebDAV source")
+					return
+				}
+				if cfgWebdav.TimeoutSeconds > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, time.Duration(cfgWebdav.TimeoutSeconds)*time.Second)
+					defer cancel()
+				}
+				if err := doWebdav(ctx, cfgWebdav, cfg.TargetFolder, cfg.ValidExtensions, cfg.OverwriteExistingFiles); err != nil {
+					logger.Error("failed to sync from WebDAV server", "error", err)
+				}
+
+			case "sftp":
+				cfgSftp, ok := src.Config.(*config.SftpConfig)
+				if !ok {
+					logger.Error("invalid configuration type for SFTP source")
+					return
+				}
+				if cfgSftp.TimeoutSeconds > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, time.Duration(cfgSftp.TimeoutSeconds)*time.Second)
+					defer cancel()
+				}
+				if err := doSftp(ctx, cfgSftp, cfg.TargetFolder, cfg.ValidExtensions, cfg.OverwriteExistingFiles); err != nil {
+					logger.Error("failed to sync from SFTP server", "error", err)
+				}
+
+			case "ftp":
+				cfgFtp, ok := src.Config.(*config.FtpConfig)
+				if !ok {
+					logger.Error("invalid configuration type for FTP source")
+					return
+				}
+				if cfgFtp.TimeoutSeconds > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, time.Duration(cfgFtp.TimeoutSeconds)*time.Second)
+					defer cancel()
+				}
+				if err := doFtp(ctx, cfgFtp, cfg.TargetFolder, cfg.ValidExtensions, cfg.OverwriteExistingFiles); err != nil {
+					logger.Error("failed to sync from FTP server", "error", err)
+				}
+
+			case "opds":
+				cfgOpds, ok := src.Config.(*config.OpdsConfig)
+				if !ok {
+					logger.Error("invalid configuration type for OPDS source")
+					return
+				}
+				if cfgOpds.TimeoutSeconds > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, time.Duration(cfgOpds.TimeoutSeconds)*time.Second)
+					defer cancel()
+				}
+				if err := doOpds(ctx, cfgOpds, cfg.TargetFolder, cfg.ValidExtensions, cfg.OverwriteExistingFiles); err != nil {
+					logger.Error("failed to sync from OPDS catalog", "error", err)
+				}
+
+			case "s3":
+				cfgS3, ok := src.Config.(*config.S3Config)
+				if !ok {
+					logger.Error("invalid configuration type for S3 source")
+					return
+				}
+				if cfgS3.TimeoutSeconds > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, time.Duration(cfgS3.TimeoutSeconds)*time.Second)
+					defer cancel()
+				}
+				if err := doS3(ctx, cfgS3, cfg.TargetFolder, cfg.ValidExtensions, cfg.OverwriteExistingFiles); err != nil {
+					logger.Error("failed to sync from S3 bucket", "error", err)
+				}
+
+			case "calibre":
+				cfgCalibre, ok := src.Config.(*config.CalibreConfig)
+				if !ok {
+					logger.Error("invalid configuration type for Calibre source")
+					return
+				}
+				if cfgCalibre.TimeoutSeconds > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, time.Duration(cfgCalibre.TimeoutSeconds)*time.Second)
+					defer cancel()
+				}
+				if err := doCalibre(ctx, cfgCalibre, cfg.TargetFolder, cfg.ValidExtensions, cfg.OverwriteExistingFiles); err != nil {
+					logger.Error("failed to sync from Calibre server", "error", err)
+				}
+
+			case "local":
+				cfgLocal, ok := src.Config.(*config.LocalConfig)
+				if !ok {
+					logger.Error("invalid configuration type for local folder source")
+					return
+				}
+				if cfgLocal.TimeoutSeconds > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, time.Duration(cfgLocal.TimeoutSeconds)*time.Second)
+					defer cancel()
+				}
+				if err := doLocal(ctx, cfgLocal, cfg.TargetFolder, cfg.ValidExtensions, cfg.OverwriteExistingFiles); err != nil {
+					logger.Error("failed to sync from local folder", "error", err)
+				}
+
+			case "http":
+				cfgHttp, ok := src.Config.(*config.HttpConfig)
+				if !ok {
+					logger.Error("invalid configuration type for HTTP source")
+					return
+				}
+				if cfgHttp.TimeoutSeconds > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, time.Duration(cfgHttp.TimeoutSeconds)*time.Second)
+					defer cancel()
+				}
+				if err := doHttp(ctx, cfgHttp, cfg.TargetFolder, cfg.ValidExtensions, cfg.OverwriteExistingFiles); err != nil {
+					logger.Error("failed to sync from web server", "error", err)
+				}
+
+			case "feed":
+				cfgFeed, ok := src.Config.(*config.FeedConfig)
+				if !ok {
+					logger.Error("invalid configuration type for feed source")
+					return
+				}
+				if cfgFeed.TimeoutSeconds > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, time.Duration(cfgFeed.TimeoutSeconds)*time.Second)
+					defer cancel()
+				}
+				if err := doFeed(ctx, cfgFeed, cfg.TargetFolder, cfg.ValidExtensions, cfg.OverwriteExistingFiles); err != nil {
+					logger.Error("failed to sync from feed", "error", err)
+				}
+
+			case "pop3":
+				cfgPop3, ok := src.Config.(*config.Pop3Config)
+				if !ok {
+					logger.Error("invalid configuration type for POP3 source")
+					return
+				}
+				if cfgPop3.TimeoutSeconds > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, time.Duration(cfgPop3.TimeoutSeconds)*time.Second)
+					defer cancel()
+				}
+				if err := doPop3(ctx, cfgPop3, cfg.TargetFolder, cfg.ValidExtensions, cfg.OverwriteExistingFiles); err != nil {
+					logger.Error("failed to sync from POP3 server", "error", err)
+				}
+
+			case "jmap":
+				cfgJmap, ok := src.Config.(*config.JmapConfig)
+				if !ok {
+					logger.Error("invalid configuration type for JMAP source")
+					return
+				}
+				if cfgJmap.TimeoutSeconds > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, time.Duration(cfgJmap.TimeoutSeconds)*time.Second)
+					defer cancel()
+				}
+				if err := doJmap(ctx, cfgJmap, cfg.TargetFolder, cfg.ValidExtensions, cfg.OverwriteExistingFiles); err != nil {
+					logger.Error("failed to sync from JMAP server", "error", err)
+				}
+
+			case "maildir":
+				cfgMaildir, ok := src.Config.(*config.MaildirConfig)
+				if !ok {
+					logger.Error("invalid configuration type for Maildir source")
+					return
+				}
+				if cfgMaildir.TimeoutSeconds > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, time.Duration(cfgMaildir.TimeoutSeconds)*time.Second)
+					defer cancel()
+				}
+				if err := doMaildir(ctx, cfgMaildir, cfg.TargetFolder, cfg.ValidExtensions, cfg.OverwriteExistingFiles); err != nil {
+					logger.Error("failed to sync from Maildir", "error", err)
+				}
+
+			case "mbox":
+				cfgMbox, ok := src.Config.(*config.MboxConfig)
+				if !ok {
+					logger.Error("invalid configuration type for mbox source")
+					return
+				}
+				if cfgMbox.TimeoutSeconds > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, time.Duration(cfgMbox.TimeoutSeconds)*time.Second)
+					defer cancel()
+				}
+				if err := doMbox(ctx, cfgMbox, cfg.TargetFolder, cfg.ValidExtensions, cfg.OverwriteExistingFiles); err != nil {
+					logger.Error("failed to sync from mbox file", "error", err)
+				}
+
+			case "calibre_library":
+				cfgCalibreLibrary, ok := src.Config.(*config.CalibreLibraryConfig)
+				if !ok {
+					logger.Error("invalid configuration type for Calibre library source")
+					return
+				}
+				if cfgCalibreLibrary.TimeoutSeconds > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, time.Duration(cfgCalibreLibrary.TimeoutSeconds)*time.Second)
+					defer cancel()
+				}
+				if err := doCalibreLibrary(ctx, cfgCalibreLibrary, cfg.TargetFolder, cfg.ValidExtensions, cfg.OverwriteExistingFiles); err != nil {
+					logger.Error("failed to sync from Calibre library", "error", err)
+				}
+
+			case "library_server":
+				cfgLibraryServer, ok := src.Config.(*config.LibraryServerConfig)
+				if !ok {
+					logger.Error("invalid configuration type for library server source")
+					return
+				}
+				if cfgLibraryServer.TimeoutSeconds > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, time.Duration(cfgLibraryServer.TimeoutSeconds)*time.Second)
+					defer cancel()
+				}
+				if err := doLibraryServer(ctx, cfgLibraryServer, cfg.TargetFolder, cfg.ValidExtensions, cfg.OverwriteExistingFiles); err != nil {
+					logger.Error("failed to sync from library server", "error", err)
+				}
+
+			case "book_manager":
+				cfgBookManager, ok := src.Config.(*config.BookManagerConfig)
+				if !ok {
+					logger.Error("invalid configuration type for book manager source")
+					return
+				}
+				if cfgBookManager.TimeoutSeconds > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, time.Duration(cfgBookManager.TimeoutSeconds)*time.Second)
+					defer cancel()
+				}
+				if err := doBookManager(ctx, cfgBookManager, cfg.TargetFolder, cfg.ValidExtensions, cfg.OverwriteExistingFiles); err != nil {
+					logger.Error("failed to sync from book manager", "error", err)
+				}
+
+			case "calibre_device":
+				cfgCalibreDevice, ok := src.Config.(*config.CalibreDeviceConfig)
+				if !ok {
+					logger.Error("invalid configuration type for Calibre wireless device source")
+					return
+				}
+				if cfgCalibreDevice.TimeoutSeconds > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, time.Duration(cfgCalibreDevice.TimeoutSeconds)*time.Second)
+					defer cancel()
+				}
+				if err := doCalibreDevice(ctx, cfgCalibreDevice, cfg.TargetFolder, cfg.ValidExtensions, cfg.OverwriteExistingFiles); err != nil {
+					logger.Error("failed to sync from Calibre wireless device", "error", err)
+				}
+
+			case "exec":
+				cfgExec, ok := src.Config.(*config.ExecConfig)
+				if !ok {
+					logger.Error("invalid configuration type for exec plugin source")
+					return
+				}
+				if cfgExec.TimeoutSeconds > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, time.Duration(cfgExec.TimeoutSeconds)*time.Second)
+					defer cancel()
+				}
+				if err := doExec(ctx, cfgExec, cfg.TargetFolder, cfg.ValidExtensions, cfg.OverwriteExistingFiles); err != nil {
+					logger.Error("failed to sync from exec plugin", "error", err)
+				}
+
+			case "wallabag":
+				cfgWallabag, ok := src.Config.(*config.WallabagConfig)
+				if !ok {
+					logger.Error("invalid configuration type for Wallabag source")
+					return
+				}
+				if cfgWallabag.TimeoutSeconds > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, time.Duration(cfgWallabag.TimeoutSeconds)*time.Second)
+					defer cancel()
+				}
+				if err := doWallabag(ctx, cfgWallabag, cfg.TargetFolder, cfg.ValidExtensions, cfg.OverwriteExistingFiles); err != nil {
+					logger.Error("failed to sync from Wallabag", "error", err)
+				}
+
+			case "public_domain":
+				cfgPublicDomain, ok := src.Config.(*config.PublicDomainConfig)
+				if !ok {
+					logger.Error("invalid configuration type for public domain catalog source")
+					return
+				}
+				if cfgPublicDomain.TimeoutSeconds > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, time.Duration(cfgPublicDomain.TimeoutSeconds)*time.Second)
+					defer cancel()
+				}
+				if err := doPublicDomain(ctx, cfgPublicDomain, cfg.TargetFolder, cfg.ValidExtensions, cfg.OverwriteExistingFiles); err != nil {
+					logger.Error("failed to sync from public domain catalog", "error", err)
+				}
+
+			case "rclone":
+				cfgRclone, ok := src.Config.(*config.RcloneConfig)
+				if !ok {
+					logger.Error("invalid configuration type for rclone source")
+					return
+				}
+				if cfgRclone.TimeoutSeconds > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, time.Duration(cfgRclone.TimeoutSeconds)*time.Second)
+					defer cancel()
+				}
+				if err := doRclone(ctx, cfgRclone, cfg.TargetFolder, cfg.ValidExtensions, cfg.OverwriteExistingFiles); err != nil {
+					logger.Error("failed to sync from rclone", "error", err)
+				}
 			}
 		}()
 	}
@@ -141,6 +477,69 @@ var (
 	doImap = func(ctx context.Context, cfg *config.ImapConfig, target string, valid []string, overwrite bool) error {
 		return imap.NewImapSyncer(cfg).RunContext(ctx, target, valid, overwrite)
 	}
+	doWebdav = func(ctx context.Context, cfg *config.WebdavConfig, target string, valid []string, overwrite bool) error {
+		return webdav.NewWebdavSyncer(cfg).RunContext(ctx, target, valid, overwrite)
+	}
+	doSftp = func(ctx context.Context, cfg *config.SftpConfig, target string, valid []string, overwrite bool) error {
+		return sftp.NewSftpSyncer(cfg).RunContext(ctx, target, valid, overwrite)
+	}
+	doFtp = func(ctx context.Context, cfg *config.FtpConfig, target string, valid []string, overwrite bool) error {
+		return ftp.NewFtpSyncer(cfg).RunContext(ctx, target, valid, overwrite)
+	}
+	doOpds = func(ctx context.Context, cfg *config.OpdsConfig, target string, valid []string, overwrite bool) error {
+		return opds.NewOpdsSyncer(cfg).RunContext(ctx, target, valid, overwrite)
+	}
+	doS3 = func(ctx context.Context, cfg *config.S3Config, target string, valid []string, overwrite bool) error {
+		return s3.NewS3Syncer(cfg).RunContext(ctx, target, valid, overwrite)
+	}
+	doCalibre = func(ctx context.Context, cfg *config.CalibreConfig, target string, valid []string, overwrite bool) error {
+		return calibre.NewCalibreSyncer(cfg).RunContext(ctx, target, valid, overwrite)
+	}
+	doLocal = func(ctx context.Context, cfg *config.LocalConfig, target string, valid []string, overwrite bool) error {
+		return local.NewLocalSyncer(cfg).RunContext(ctx, target, valid, overwrite)
+	}
+	doHttp = func(ctx context.Context, cfg *config.HttpConfig, target string, valid []string, overwrite bool) error {
+		return httpindex.NewHttpIndexSyncer(cfg).RunContext(ctx, target, valid, overwrite)
+	}
+	doFeed = func(ctx context.Context, cfg *config.FeedConfig, target string, valid []string, overwrite bool) error {
+		return feed.NewFeedSyncer(cfg).RunContext(ctx, target, valid, overwrite)
+	}
+	doPop3 = func(ctx context.Context, cfg *config.Pop3Config, target string, valid []string, overwrite bool) error {
+		return pop3.NewPop3Syncer(cfg).RunContext(ctx, target, valid, overwrite)
+	}
+	doJmap = func(ctx context.Context, cfg *config.JmapConfig, target string, valid []string, overwrite bool) error {
+		return jmap.NewJmapSyncer(cfg).RunContext(ctx, target, valid, overwrite)
+	}
+	doMaildir = func(ctx context.Context, cfg *config.MaildirConfig, target string, valid []string, overwrite bool) error {
+		return maildir.NewMaildirSyncer(cfg).RunContext(ctx, target, valid, overwrite)
+	}
+	doMbox = func(ctx context.Context, cfg *config.MboxConfig, target string, valid []string, overwrite bool) error {
+		return mbox.NewMboxSyncer(cfg).RunContext(ctx, target, valid, overwrite)
+	}
+	doCalibreLibrary = func(ctx context.Context, cfg *config.CalibreLibraryConfig, target string, valid []string, overwrite bool) error {
+		return calibrelib.NewCalibreLibrarySyncer(cfg).RunContext(ctx, target, valid, overwrite)
+	}
+	doLibraryServer = func(ctx context.Context, cfg *config.LibraryServerConfig, target string, valid []string, overwrite bool) error {
+		return libraryserver.NewLibraryServerSyncer(cfg).RunContext(ctx, target, valid, overwrite)
+	}
+	doBookManager = func(ctx context.Context, cfg *config.BookManagerConfig, target string, valid []string, overwrite bool) error {
+		return bookmanager.NewBookManagerSyncer(cfg).RunContext(ctx, target, valid, overwrite)
+	}
+	doCalibreDevice = func(ctx context.Context, cfg *config.CalibreDeviceConfig, target string, valid []string, overwrite bool) error {
+		return calibredevice.NewCalibreDeviceSyncer(cfg).RunContext(ctx, target, valid, overwrite)
+	}
+	doExec = func(ctx context.Context, cfg *config.ExecConfig, target string, valid []string, overwrite bool) error {
+		return plugin.NewPluginSyncer(cfg).RunContext(ctx, target, valid, overwrite)
+	}
+	doWallabag = func(ctx context.Context, cfg *config.WallabagConfig, target string, valid []string, overwrite bool) error {
+		return wallabag.NewWallabagSyncer(cfg).RunContext(ctx, target, valid, overwrite)
+	}
+	doPublicDomain = func(ctx context.Context, cfg *config.PublicDomainConfig, target string, valid []string, overwrite bool) error {
+		return publicdomain.NewPublicDomainSyncer(cfg).RunContext(ctx, target, valid, overwrite)
+	}
+	doRclone = func(ctx context.Context, cfg *config.RcloneConfig, target string, valid []string, overwrite bool) error {
+		return rclone.NewRcloneSyncer(cfg).RunContext(ctx, target, valid, overwrite)
+	}
 	isKoboDevice      = kobo.IsKoboDevice
 	updateKoboLibrary = kobo.UpdateLibrary
 )
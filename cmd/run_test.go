@@ -106,6 +106,27 @@ func TestRunCommand_Sources_DispatchAndErrors(t *testing.T) {
 			{Type: "nfs", Config: &config.NfsNetworkShareConfig{}},
 			{Type: "smb", Config: &config.SmbNetworkShareConfig{}},
 			{Type: "imap", Config: &config.ImapConfig{}},
+			{Type: "webdav", Config: &config.WebdavConfig{}},
+			{Type: "sftp", Config: &config.SftpConfig{}},
+			{Type: "ftp", Config: &config.FtpConfig{}},
+			{Type: "opds", Config: &config.OpdsConfig{}},
+			{Type: "s3", Config: &config.S3Config{}},
+			{Type: "calibre", Config: &config.CalibreConfig{}},
+			{Type: "local", Config: &config.LocalConfig{}},
+			{Type: "http", Config: &config.HttpConfig{}},
+			{Type: "feed", Config: &config.FeedConfig{}},
+			{Type: "pop3", Config: &config.Pop3Config{}},
+			{Type: "jmap", Config: &config.JmapConfig{}},
+			{Type: "maildir", Config: &config.MaildirConfig{}},
+			{Type: "mbox", Config: &config.MboxConfig{}},
+			{Type: "calibre_library", Config: &config.CalibreLibraryConfig{}},
+			{Type: "library_server", Config: &config.LibraryServerConfig{}},
+			{Type: "book_manager", Config: &config.BookManagerConfig{}},
+			{Type: "calibre_device", Config: &config.CalibreDeviceConfig{}},
+			{Type: "exec", Config: &config.ExecConfig{}},
+			{Type: "wallabag", Config: &config.WallabagConfig{}},
+			{Type: "public_domain", Config: &config.PublicDomainConfig{}},
+			{Type: "rclone", Config: &config.RcloneConfig{}},
 		},
 	}
 
@@ -133,6 +154,135 @@ func TestRunCommand_Sources_DispatchAndErrors(t *testing.T) {
 	}
 	doImap = func(_ context.Context, _ *config.ImapConfig, _ string, _ []string, _ bool) error { return nil }
 
+	// Count dispatches to the remaining source types.
+	var dispatched atomic.Int32
+	oldWebdav := doWebdav
+	t.Cleanup(func() { doWebdav = oldWebdav })
+	doWebdav = func(_ context.Context, _ *config.WebdavConfig, _ string, _ []string, _ bool) error {
+		dispatched.Add(1)
+		return nil
+	}
+	oldSftp := doSftp
+	t.Cleanup(func() { doSftp = oldSftp })
+	doSftp = func(_ context.Context, _ *config.SftpConfig, _ string, _ []string, _ bool) error {
+		dispatched.Add(1)
+		return nil
+	}
+	oldFtp := doFtp
+	t.Cleanup(func() { doFtp = oldFtp })
+	doFtp = func(_ context.Context, _ *config.FtpConfig, _ string, _ []string, _ bool) error {
+		dispatched.Add(1)
+		return nil
+	}
+	oldOpds := doOpds
+	t.Cleanup(func() { doOpds = oldOpds })
+	doOpds = func(_ context.Context, _ *config.OpdsConfig, _ string, _ []string, _ bool) error {
+		dispatched.Add(1)
+		return nil
+	}
+	oldS3 := doS3
+	t.Cleanup(func() { doS3 = oldS3 })
+	doS3 = func(_ context.Context, _ *config.S3Config, _ string, _ []string, _ bool) error {
+		dispatched.Add(1)
+		return nil
+	}
+	oldCalibre := doCalibre
+	t.Cleanup(func() { doCalibre = oldCalibre })
+	doCalibre = func(_ context.Context, _ *config.CalibreConfig, _ string, _ []string, _ bool) error {
+		dispatched.Add(1)
+		return nil
+	}
+	oldLocal := doLocal
+	t.Cleanup(func() { doLocal = oldLocal })
+	doLocal = func(_ context.Context, _ *config.LocalConfig, _ string, _ []string, _ bool) error {
+		dispatched.Add(1)
+		return nil
+	}
+	oldHttp := doHttp
+	t.Cleanup(func() { doHttp = oldHttp })
+	doHttp = func(_ context.Context, _ *config.HttpConfig, _ string, _ []string, _ bool) error {
+		dispatched.Add(1)
+		return nil
+	}
+	oldFeed := doFeed
+	t.Cleanup(func() { doFeed = oldFeed })
+	doFeed = func(_ context.Context, _ *config.FeedConfig, _ string, _ []string, _ bool) error {
+		dispatched.Add(1)
+		return nil
+	}
+	oldPop3 := doPop3
+	t.Cleanup(func() { doPop3 = oldPop3 })
+	doPop3 = func(_ context.Context, _ *config.Pop3Config, _ string, _ []string, _ bool) error {
+		dispatched.Add(1)
+		return nil
+	}
+	oldJmap := doJmap
+	t.Cleanup(func() { doJmap = oldJmap })
+	doJmap = func(_ context.Context, _ *config.JmapConfig, _ string, _ []string, _ bool) error {
+		dispatched.Add(1)
+		return nil
+	}
+	oldMaildir := doMaildir
+	t.Cleanup(func() { doMaildir = oldMaildir })
+	doMaildir = func(_ context.Context, _ *config.MaildirConfig, _ string, _ []string, _ bool) error {
+		dispatched.Add(1)
+		return nil
+	}
+	oldMbox := doMbox
+	t.Cleanup(func() { doMbox = oldMbox })
+	doMbox = func(_ context.Context, _ *config.MboxConfig, _ string, _ []string, _ bool) error {
+		dispatched.Add(1)
+		return nil
+	}
+	oldCalibreLibrary := doCalibreLibrary
+	t.Cleanup(func() { doCalibreLibrary = oldCalibreLibrary })
+	doCalibreLibrary = func(_ context.Context, _ *config.CalibreLibraryConfig, _ string, _ []string, _ bool) error {
+		dispatched.Add(1)
+		return nil
+	}
+	oldLibraryServer := doLibraryServer
+	t.Cleanup(func() { doLibraryServer = oldLibraryServer })
+	doLibraryServer = func(_ context.Context, _ *config.LibraryServerConfig, _ string, _ []string, _ bool) error {
+		dispatched.Add(1)
+		return nil
+	}
+	oldBookManager := doBookManager
+	t.Cleanup(func() { doBookManager = oldBookManager })
+	doBookManager = func(_ context.Context, _ *config.BookManagerConfig, _ string, _ []string, _ bool) error {
+		dispatched.Add(1)
+		return nil
+	}
+	oldCalibreDevice := doCalibreDevice
+	t.Cleanup(func() { doCalibreDevice = oldCalibreDevice })
+	doCalibreDevice = func(_ context.Context, _ *config.CalibreDeviceConfig, _ string, _ []string, _ bool) error {
+		dispatched.Add(1)
+		return nil
+	}
+	oldExec := doExec
+	t.Cleanup(func() { doExec = oldExec })
+	doExec = func(_ context.Context, _ *config.ExecConfig, _ string, _ []string, _ bool) error {
+		dispatched.Add(1)
+		return nil
+	}
+	oldWallabag := doWallabag
+	t.Cleanup(func() { doWallabag = oldWallabag })
+	doWallabag = func(_ context.Context, _ *config.WallabagConfig, _ string, _ []string, _ bool) error {
+		dispatched.Add(1)
+		return nil
+	}
+	oldPublicDomain := doPublicDomain
+	t.Cleanup(func() { doPublicDomain = oldPublicDomain })
+	doPublicDomain = func(_ context.Context, _ *config.PublicDomainConfig, _ string, _ []string, _ bool) error {
+		dispatched.Add(1)
+		return nil
+	}
+	oldRclone := doRclone
+	t.Cleanup(func() { doRclone = oldRclone })
+	doRclone = func(_ context.Context, _ *config.RcloneConfig, _ string, _ []string, _ bool) error {
+		dispatched.Add(1)
+		return nil
+	}
+
 	// Trigger Kobo update path but stub it to a fast no-op.
 	oldIsKobo, oldUpdate := isKoboDevice, updateKoboLibrary
 	t.Cleanup(func() { isKoboDevice, updateKoboLibrary = oldIsKobo, oldUpdate })
@@ -147,6 +297,9 @@ func TestRunCommand_Sources_DispatchAndErrors(t *testing.T) {
 	if !updated {
 		t.Fatalf("expected Kobo update to be called")
 	}
+	if got, want := int(dispatched.Load()), len(cfg.Sources)-3; got != want {
+		t.Fatalf("expected %d additional sources dispatched, got %d", want, got)
+	}
 
 	// Ensure the seams were exercised twice for countFiles (start/end)
 	if calls != 2 {
@@ -400,6 +553,27 @@ func TestRunCommand_InvalidConfigTypes(t *testing.T) {
 			{Type: "nfs", Config: &config.ImapConfig{}},
 			{Type: "smb", Config: &config.NfsNetworkShareConfig{}},
 			{Type: "imap", Config: &config.SmbNetworkShareConfig{}},
+			{Type: "webdav", Config: &config.NfsNetworkShareConfig{}},
+			{Type: "sftp", Config: &config.NfsNetworkShareConfig{}},
+			{Type: "ftp", Config: &config.NfsNetworkShareConfig{}},
+			{Type: "opds", Config: &config.NfsNetworkShareConfig{}},
+			{Type: "s3", Config: &config.NfsNetworkShareConfig{}},
+			{Type: "calibre", Config: &config.NfsNetworkShareConfig{}},
+			{Type: "local", Config: &config.NfsNetworkShareConfig{}},
+			{Type: "http", Config: &config.NfsNetworkShareConfig{}},
+			{Type: "feed", Config: &config.NfsNetworkShareConfig{}},
+			{Type: "pop3", Config: &config.NfsNetworkShareConfig{}},
+			{Type: "jmap", Config: &config.NfsNetworkShareConfig{}},
+			{Type: "maildir", Config: &config.NfsNetworkShareConfig{}},
+			{Type: "mbox", Config: &config.NfsNetworkShareConfig{}},
+			{Type: "calibre_library", Config: &config.NfsNetworkShareConfig{}},
+			{Type: "library_server", Config: &config.NfsNetworkShareConfig{}},
+			{Type: "book_manager", Config: &config.NfsNetworkShareConfig{}},
+			{Type: "calibre_device", Config: &config.NfsNetworkShareConfig{}},
+			{Type: "exec", Config: &config.NfsNetworkShareConfig{}},
+			{Type: "wallabag", Config: &config.NfsNetworkShareConfig{}},
+			{Type: "public_domain", Config: &config.NfsNetworkShareConfig{}},
+			{Type: "rclone", Config: &config.NfsNetworkShareConfig{}},
 		},
 	}
 
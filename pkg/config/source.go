@@ -7,7 +7,7 @@ import (
 )
 
 type Source struct {
-	Type   string       `yaml:"type" validate:"oneof=smb nfs imap"`
+	Type   string       `yaml:"type" validate:"oneof=smb nfs imap webdav sftp ftp opds s3 calibre calibre_library library_server book_manager calibre_device exec wallabag public_domain rclone local http feed pop3 jmap maildir mbox"`
 	Config SourceConfig `yaml:"config" validate:"required"`
 }
 
@@ -32,6 +32,48 @@ func (src *Source) UnmarshalYAML(input []byte) error {
 		configPtr = &SmbNetworkShareConfig{}
 	case "imap":
 		configPtr = &ImapConfig{}
+	case "webdav":
+		configPtr = &WebdavConfig{}
+	case "sftp":
+		configPtr = &SftpConfig{}
+	case "ftp":
+		configPtr = &FtpConfig{}
+	case "opds":
+		configPtr = &OpdsConfig{}
+	case "s3":
+		configPtr = &S3Config{}
+	case "calibre":
+		configPtr = &CalibreConfig{}
+	case "calibre_library":
+		configPtr = &CalibreLibraryConfig{}
+	case "library_server":
+		configPtr = &LibraryServerConfig{}
+	case "book_manager":
+		configPtr = &BookManagerConfig{}
+	case "calibre_device":
+		configPtr = &CalibreDeviceConfig{}
+	case "exec":
+		configPtr = &ExecConfig{}
+	case "wallabag":
+		configPtr = &WallabagConfig{}
+	case "public_domain":
+		configPtr = &PublicDomainConfig{}
+	case "rclone":
+		configPtr = &RcloneConfig{}
+	case "local":
+		configPtr = &LocalConfig{}
+	case "http":
+		configPtr = &HttpConfig{}
+	case "feed":
+		configPtr = &FeedConfig{}
+	case "pop3":
+		configPtr = &Pop3Config{}
+	case "jmap":
+		configPtr = &JmapConfig{}
+	case "maildir":
+		configPtr = &MaildirConfig{}
+	case "mbox":
+		configPtr = &MboxConfig{}
 	default:
 		return fmt.Errorf("unsupported source type: %s", typeVal)
 	}
@@ -50,6 +50,79 @@ func TestSourceUnmarshal_Imap(t *testing.T) {
 	}
 }
 
+// TestSourceUnmarshal_ImapSecurity ensures IMAP security options are parsed.
+func TestSourceUnmarshal_ImapSecurity(t *testing.T) {
+	y := []byte("type: imap\nconfig:\n  host: h\n  security: starttls\n  ca_file: /etc/ca.pem\n  server_name: mail.internal\n  insecure_skip_verify: true\n  mailbox: INBOX\n  filter_field: subject\n  filter_value: x\n")
+	var s Source
+	if err := s.UnmarshalYAML(y); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	c := s.Config.(*ImapConfig)
+	if c.Security != "starttls" || c.CAFile != "/etc/ca.pem" || c.ServerName != "mail.internal" || !c.InsecureSkipVerify {
+		t.Fatalf("unexpected config: %+v", c)
+	}
+}
+
+// TestSourceUnmarshal_ImapOAuth2 ensures IMAP OAuth2 options are parsed.
+func TestSourceUnmarshal_ImapOAuth2(t *testing.T) {
+	y := []byte("type: imap\nconfig:\n  host: h\n  username: u\n  auth_method: xoauth2\n  oauth2_token_url: https://t.example/token\n  oauth2_client_id: c\n  oauth2_client_secret: s\n  oauth2_refresh_token: r\n  oauth2_scopes: [a, b]\n  oauth2_token_cache: /tmp/t.json\n  mailbox: INBOX\n  filter_field: subject\n  filter_value: x\n")
+	var s Source
+	if err := s.UnmarshalYAML(y); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	c := s.Config.(*ImapConfig)
+	if c.AuthMethod != "xoauth2" || c.OAuth2TokenURL != "https://t.example/token" || c.OAuth2ClientID != "c" ||
+		c.OAuth2ClientSecret == nil || c.OAuth2RefreshToken == nil || string(*c.OAuth2RefreshToken) != "r" ||
+		len(c.OAuth2Scopes) != 2 || c.OAuth2TokenCache != "/tmp/t.json" {
+		t.Fatalf("unexpected config: %+v", c)
+	}
+}
+
+// TestSourceUnmarshal_ImapSearch ensures nested IMAP search criteria are parsed.
+func TestSourceUnmarshal_ImapSearch(t *testing.T) {
+	y := []byte("type: imap\nconfig:\n  host: h\n  mailbox: INBOX\n  search:\n    from: [a@example.com]\n    since: 30d\n    larger_than: 1024\n    header: {X-Mailer: calibre}\n    or:\n      - to: [b@example.com]\n      - cc: [b@example.com]\n    not:\n      - header: {List-Id: \"\"}\n")
+	var s Source
+	if err := s.UnmarshalYAML(y); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	c := s.Config.(*ImapConfig)
+	if c.Search == nil || c.Search.From[0] != "a@example.com" || c.Search.Since != "30d" || c.Search.LargerThan != 1024 || c.Search.Header["X-Mailer"] != "calibre" {
+		t.Fatalf("unexpected search: %+v", c.Search)
+	}
+	if len(c.Search.Or) != 2 || c.Search.Or[1].Cc[0] != "b@example.com" {
+		t.Fatalf("unexpected or: %+v", c.Search.Or)
+	}
+	if v, ok := c.Search.Not[0].Header["List-Id"]; len(c.Search.Not) != 1 || !ok || v != "" {
+		t.Fatalf("unexpected not: %+v", c.Search.Not)
+	}
+}
+
+// TestSourceUnmarshal_ImapSenders ensures IMAP sender checks are parsed.
+func TestSourceUnmarshal_ImapSenders(t *testing.T) {
+	y := []byte("type: imap\nconfig:\n  host: h\n  mailbox: INBOX\n  allowed_senders: [a@example.com, example.org]\n  require_authentication: [dkim, dmarc]\n  trusted_authserv_id: mx.example.com\n  quarantine_mailbox: Quarantine\n")
+	var s Source
+	if err := s.UnmarshalYAML(y); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	c := s.Config.(*ImapConfig)
+	if len(c.AllowedSenders) != 2 || len(c.RequireAuthentication) != 2 || c.TrustedAuthservID != "mx.example.com" || c.QuarantineMailbox != "Quarantine" {
+		t.Fatalf("unexpected config: %+v", c)
+	}
+}
+
+// TestSourceUnmarshal_ImapAfterDownload ensures IMAP after download options are parsed.
+func TestSourceUnmarshal_ImapAfterDownload(t *testing.T) {
+	y := []byte("type: imap\nconfig:\n  host: h\n  mailbox: INBOX\n  after_download: move\n  after_download_mailbox: Delivered\n  after_download_keyword: $Done\n")
+	var s Source
+	if err := s.UnmarshalYAML(y); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	c := s.Config.(*ImapConfig)
+	if c.AfterDownload != "move" || c.AfterDownloadMailbox != "Delivered" || c.AfterDownloadKeyword != "$Done" {
+		t.Fatalf("unexpected config: %+v", c)
+	}
+}
+
 // TestSourceUnmarshal_MissingType checks for failure when the type is omitted.
 func TestSourceUnmarshal_MissingType(t *testing.T) {
 	y := []byte("config: {}\n")
@@ -58,3 +131,259 @@ func TestSourceUnmarshal_MissingType(t *testing.T) {
 		t.Fatalf("expected error for missing type")
 	}
 }
+
+// TestSourceUnmarshal_Webdav ensures WebDAV source config selects the correct type.
+func TestSourceUnmarshal_Webdav(t *testing.T) {
+	y := []byte("type: webdav\nconfig:\n  url: https://cloud.example/remote.php/dav/files/alice\n  folder: Books\n")
+	var s Source
+	if err := s.UnmarshalYAML(y); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if _, ok := s.Config.(*WebdavConfig); !ok {
+		t.Fatalf("wrong type: %T", s.Config)
+	}
+}
+
+// TestSourceUnmarshal_Sftp ensures SFTP source config selects the correct type.
+func TestSourceUnmarshal_Sftp(t *testing.T) {
+	y := []byte("type: sftp\nconfig:\n  host: h\n  username: u\n  host_key_fingerprint: SHA256:abc\n  folder: /books\n")
+	var s Source
+	if err := s.UnmarshalYAML(y); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if _, ok := s.Config.(*SftpConfig); !ok {
+		t.Fatalf("wrong type: %T", s.Config)
+	}
+}
+
+// TestSourceUnmarshal_Ftp ensures FTP source config selects the correct type.
+func TestSourceUnmarshal_Ftp(t *testing.T) {
+	y := []byte("type: ftp\nconfig:\n  host: h\n  security: explicit\n  folder: /books\n")
+	var s Source
+	if err := s.UnmarshalYAML(y); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if _, ok := s.Config.(*FtpConfig); !ok {
+		t.Fatalf("wrong type: %T", s.Config)
+	}
+}
+
+// TestSourceUnmarshal_Opds ensures OPDS source config selects the correct type.
+func TestSourceUnmarshal_Opds(t *testing.T) {
+	y := []byte("type: opds\nconfig:\n  url: https://books.example/opds\n  only_new: true\n")
+	var s Source
+	if err := s.UnmarshalYAML(y); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if c, ok := s.Config.(*OpdsConfig); !ok || !c.OnlyNew {
+		t.Fatalf("wrong type: %T", s.Config)
+	}
+}
+
+// TestSourceUnmarshal_S3 ensures S3 source config selects the correct type.
+func TestSourceUnmarshal_S3(t *testing.T) {
+	y := []byte("type: s3\nconfig:\n  endpoint: http://minio:9000\n  bucket: books\n  use_path_style: true\n")
+	var s Source
+	if err := s.UnmarshalYAML(y); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if c, ok := s.Config.(*S3Config); !ok || !c.UsePathStyle {
+		t.Fatalf("wrong type: %T", s.Config)
+	}
+}
+
+// TestSourceUnmarshal_Calibre ensures Calibre source config selects the correct type.
+func TestSourceUnmarshal_Calibre(t *testing.T) {
+	y := []byte("type: calibre\nconfig:\n  url: http://calibre:8080\n  query: 'tags:\"kobo\"'\n  formats: [kepub, epub]\n")
+	var s Source
+	if err := s.UnmarshalYAML(y); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if c, ok := s.Config.(*CalibreConfig); !ok || c.Query != `tags:"kobo"` || len(c.Formats) != 2 {
+		t.Fatalf("wrong config: %#v", s.Config)
+	}
+}
+
+// TestSourceUnmarshal_Local ensures local source config selects the correct type.
+func TestSourceUnmarshal_Local(t *testing.T) {
+	y := []byte("type: local\nconfig:\n  folder: /mnt/usb/books\n  remove_files_after_download: true\n")
+	var s Source
+	if err := s.UnmarshalYAML(y); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if c, ok := s.Config.(*LocalConfig); !ok || !c.RemoveFilesAfterDownload {
+		t.Fatalf("wrong type: %T", s.Config)
+	}
+}
+
+// TestSourceUnmarshal_Http ensures HTTP index source config selects the correct type.
+func TestSourceUnmarshal_Http(t *testing.T) {
+	y := []byte("type: http\nconfig:\n  url: https://intranet.local/lists/\n  recursive: true\n")
+	var s Source
+	if err := s.UnmarshalYAML(y); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if c, ok := s.Config.(*HttpConfig); !ok || !c.Recursive {
+		t.Fatalf("wrong type: %T", s.Config)
+	}
+}
+
+// TestSourceUnmarshal_Feed ensures feed source config selects the correct type.
+func TestSourceUnmarshal_Feed(t *testing.T) {
+	y := []byte("type: feed\nconfig:\n  url: https://example.org/feed.xml\n  include_links: true\n")
+	var s Source
+	if err := s.UnmarshalYAML(y); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if c, ok := s.Config.(*FeedConfig); !ok || !c.IncludeLinks {
+		t.Fatalf("wrong type: %T", s.Config)
+	}
+}
+
+// TestSourceUnmarshal_Pop3 ensures pop3 source config selects the correct type.
+func TestSourceUnmarshal_Pop3(t *testing.T) {
+	y := []byte("type: pop3\nconfig:\n  host: pop.example\n  security: explicit\n  auth_method: apop\n")
+	var s Source
+	if err := s.UnmarshalYAML(y); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if c, ok := s.Config.(*Pop3Config); !ok || c.Security != "explicit" || c.AuthMethod != "apop" {
+		t.Fatalf("wrong type: %T", s.Config)
+	}
+}
+
+// TestSourceUnmarshal_Jmap ensures jmap source config selects the correct type.
+func TestSourceUnmarshal_Jmap(t *testing.T) {
+	y := []byte("type: jmap\nconfig:\n  session_url: https://api.fastmail.com/jmap/session\n  after_download: move\n  move_to_mailbox: Processed\n")
+	var s Source
+	if err := s.UnmarshalYAML(y); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if c, ok := s.Config.(*JmapConfig); !ok || c.AfterDownload != "move" || c.MoveToMailbox != "Processed" {
+		t.Fatalf("wrong type: %T", s.Config)
+	}
+}
+
+// TestSourceUnmarshal_Maildir ensures maildir source config selects the correct type.
+func TestSourceUnmarshal_Maildir(t *testing.T) {
+	y := []byte("type: maildir\nconfig:\n  folder: /var/mail/books\n  after_download: seen\n")
+	var s Source
+	if err := s.UnmarshalYAML(y); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if c, ok := s.Config.(*MaildirConfig); !ok || c.Folder != "/var/mail/books" || c.AfterDownload != "seen" {
+		t.Fatalf("wrong type: %T", s.Config)
+	}
+}
+
+// TestSourceUnmarshal_Mbox ensures mbox source config selects the correct type.
+func TestSourceUnmarshal_Mbox(t *testing.T) {
+	y := []byte("type: mbox\nconfig:\n  file: /var/mail/books.mbox\n  remove_emails_after_download: true\n")
+	var s Source
+	if err := s.UnmarshalYAML(y); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if c, ok := s.Config.(*MboxConfig); !ok || c.File != "/var/mail/books.mbox" || !c.RemoveEmailsAfterDownload {
+		t.Fatalf("wrong type: %T", s.Config)
+	}
+}
+
+// TestSourceUnmarshal_CalibreLibrary ensures calibre_library source config selects the correct type, including a nested share.
+func TestSourceUnmarshal_CalibreLibrary(t *testing.T) {
+	y := []byte("type: calibre_library\nconfig:\n  tags: [Kobo]\n  custom_columns:\n    '#read': 'false'\n  share:\n    type: smb\n    config:\n      host: nas\n      share: books\n      folder: Calibre Library\n")
+	var s Source
+	if err := s.UnmarshalYAML(y); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	c, ok := s.Config.(*CalibreLibraryConfig)
+	if !ok || c.CustomColumns["#read"] != "false" || len(c.Tags) != 1 || c.Share == nil {
+		t.Fatalf("wrong type: %T", s.Config)
+	}
+	if share, ok := c.Share.Config.(*SmbNetworkShareConfig); !ok || share.Folder != "Calibre Library" {
+		t.Fatalf("wrong share type: %T", c.Share.Config)
+	}
+}
+
+// TestSourceUnmarshal_LibraryServer ensures library_server source config selects the correct type.
+func TestSourceUnmarshal_LibraryServer(t *testing.T) {
+	y := []byte("type: library_server\nconfig:\n  server: kavita\n  url: http://kavita.local:5000\n  reading_lists: [Summer]\n  want_to_read: true\n")
+	var s Source
+	if err := s.UnmarshalYAML(y); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if c, ok := s.Config.(*LibraryServerConfig); !ok || c.Server != "kavita" || !c.WantToRead || len(c.ReadingLists) != 1 {
+		t.Fatalf("wrong type: %T", s.Config)
+	}
+}
+
+// TestSourceUnmarshal_BookManager ensures book_manager source config selects the correct type, including a nested share.
+func TestSourceUnmarshal_BookManager(t *testing.T) {
+	y := []byte("type: book_manager\nconfig:\n  server: readarr\n  url: http://readarr.local:8787\n  api_key: secret\n  remote_path: /books\n  max_age_days: 7\n  share:\n    type: nfs\n    config:\n      host: nas\n      folder: /export/books\n")
+	var s Source
+	if err := s.UnmarshalYAML(y); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	c, ok := s.Config.(*BookManagerConfig)
+	if !ok || c.Server != "readarr" || c.RemotePath != "/books" || c.MaxAgeDays != 7 || c.APIKey == nil {
+		t.Fatalf("wrong type: %T", s.Config)
+	}
+	if _, ok := c.Share.Config.(*NfsNetworkShareConfig); !ok {
+		t.Fatalf("wrong share type: %T", c.Share.Config)
+	}
+}
+
+// TestSourceUnmarshal_CalibreDevice ensures calibre_device source config selects the correct type.
+func TestSourceUnmarshal_CalibreDevice(t *testing.T) {
+	y := []byte("type: calibre_device\nconfig:\n  port: 9191\n  password: secret\n  device_name: Kobo Libra\n  idle_seconds: 30\n")
+	var s Source
+	if err := s.UnmarshalYAML(y); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if c, ok := s.Config.(*CalibreDeviceConfig); !ok || c.Host != "" || c.Port != 9191 || c.DeviceName != "Kobo Libra" || c.IdleSeconds != 30 || c.Password == nil {
+		t.Fatalf("wrong type: %T", s.Config)
+	}
+}
+
+func TestSourceUnmarshal_Exec(t *testing.T) {
+	y := []byte("type: exec\nconfig:\n  command: /bin/plugin\n  args: [--verbose]\n  env:\n    TOKEN: secret\n  options:\n    folder: /Books\n    depth: 2\n")
+	var s Source
+	if err := s.UnmarshalYAML(y); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if c, ok := s.Config.(*ExecConfig); !ok || c.Command != "/bin/plugin" || len(c.Args) != 1 || c.Env["TOKEN"] != "secret" || c.Options["folder"] != "/Books" {
+		t.Fatalf("wrong type: %T", s.Config)
+	}
+}
+
+func TestSourceUnmarshal_Wallabag(t *testing.T) {
+	y := []byte("type: wallabag\nconfig:\n  url: https://wallabag.example.com\n  client_id: 1_abc\n  client_secret: secret\n  username: reader\n  tags: [kobo]\n  archive_after_download: true\n")
+	var s Source
+	if err := s.UnmarshalYAML(y); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if c, ok := s.Config.(*WallabagConfig); !ok || c.ClientID != "1_abc" || c.ClientSecret == nil || c.Username != "reader" || len(c.Tags) != 1 || !c.ArchiveAfterDownload {
+		t.Fatalf("wrong type: %T", s.Config)
+	}
+}
+
+func TestSourceUnmarshal_PublicDomain(t *testing.T) {
+	y := []byte("type: public_domain\nconfig:\n  books:\n    - 1342\n    - https://standardebooks.org/ebooks/mary-shelley/frankenstein\n  book_list_file: /books/list.txt\n  prefer_kepub: true\n")
+	var s Source
+	if err := s.UnmarshalYAML(y); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if c, ok := s.Config.(*PublicDomainConfig); !ok || len(c.Books) != 2 || c.Books[0] != "1342" || c.BookListFile != "/books/list.txt" || !c.PreferKepub {
+		t.Fatalf("wrong type: %T", s.Config)
+	}
+}
+
+func TestSourceUnmarshal_Rclone(t *testing.T) {
+	y := []byte("type: rclone\nconfig:\n  url: http://nas.local:5572\n  username: rc\n  password: secret\n  remote: \"gdrive:\"\n  folder: Books\n")
+	var s Source
+	if err := s.UnmarshalYAML(y); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if c, ok := s.Config.(*RcloneConfig); !ok || c.Remote != "gdrive:" || c.Folder != "Books" || c.Username != "rc" || c.Password == nil {
+		t.Fatalf("wrong type: %T", s.Config)
+	}
+}
@@ -31,10 +31,320 @@ type ImapConfig struct {
 	Port                      int               `yaml:"port"`
 	Username                  string            `yaml:"username"`
 	Password                  *sensitive.String `yaml:"password"`
+	Security                  string            `yaml:"security" validate:"omitempty,oneof=tls starttls none"`
+	CAFile                    string            `yaml:"ca_file"`
+	ServerName                string            `yaml:"server_name"`
+	InsecureSkipVerify        bool              `yaml:"insecure_skip_verify"`
+	AuthMethod                string            `yaml:"auth_method" validate:"omitempty,oneof=login xoauth2 oauthbearer"`
+	OAuth2TokenURL            string            `yaml:"oauth2_token_url" validate:"required_if=AuthMethod xoauth2,required_if=AuthMethod oauthbearer"`
+	OAuth2ClientID            string            `yaml:"oauth2_client_id" validate:"required_if=AuthMethod xoauth2,required_if=AuthMethod oauthbearer"`
+	OAuth2ClientSecret        *sensitive.String `yaml:"oauth2_client_secret"`
+	OAuth2RefreshToken        *sensitive.String `yaml:"oauth2_refresh_token" validate:"required_if=AuthMethod xoauth2,required_if=AuthMethod oauthbearer"`
+	OAuth2Scopes              []string          `yaml:"oauth2_scopes"`
+	OAuth2TokenCache          string            `yaml:"oauth2_token_cache"`
 	Mailbox                   string            `yaml:"mailbox" validate:"required"`
+	FilterField               string            `yaml:"filter_field" validate:"required_without=Search,omitempty,oneof=to subject"`
+	FilterValue               string            `yaml:"filter_value" validate:"required_with=FilterField"`
+	Search                    *ImapSearch       `yaml:"search"`
+	AllowedSenders            []string          `yaml:"allowed_senders"`
+	RequireAuthentication     []string          `yaml:"require_authentication" validate:"dive,oneof=dkim spf dmarc"`
+	TrustedAuthservID         string            `yaml:"trusted_authserv_id"`
+	QuarantineMailbox         string            `yaml:"quarantine_mailbox"`
+	ProcessReadEmails         bool              `yaml:"process_read_emails"`
+	RemoveEmailsAfterDownload bool              `yaml:"remove_emails_after_download"`
+	AfterDownload             string            `yaml:"after_download" validate:"omitempty,oneof=none seen flag move delete"`
+	AfterDownloadMailbox      string            `yaml:"after_download_mailbox" validate:"required_if=AfterDownload move"`
+	AfterDownloadKeyword      string            `yaml:"after_download_keyword"`
+	TimeoutSeconds            int               `yaml:"timeout_seconds"`
+}
+
+// ImapSearch selects the IMAP messages to process. All criteria that are set
+// must match; text criteria are case-insensitive substring matches performed
+// by the server. And, Or and Not compose nested criteria: all of And, at least
+// one of Or and none of Not must match. Dates are YYYY-MM-DD or a number of
+// days before today such as "30d".
+type ImapSearch struct {
+	From        []string          `yaml:"from"`
+	To          []string          `yaml:"to"`
+	Cc          []string          `yaml:"cc"`
+	Subject     []string          `yaml:"subject"`
+	Header      map[string]string `yaml:"header"`
+	Body        []string          `yaml:"body"`
+	Text        []string          `yaml:"text"`
+	Since       string            `yaml:"since"`
+	Before      string            `yaml:"before"`
+	SentSince   string            `yaml:"sent_since"`
+	SentBefore  string            `yaml:"sent_before"`
+	LargerThan  int64             `yaml:"larger_than"`
+	SmallerThan int64             `yaml:"smaller_than"`
+	Keywords    []string          `yaml:"keywords"`
+	And         []ImapSearch      `yaml:"and"`
+	Or          []ImapSearch      `yaml:"or"`
+	Not         []ImapSearch      `yaml:"not"`
+}
+
+type WebdavConfig struct {
+	URL                      string            `yaml:"url" validate:"required,url"`
+	Username                 string            `yaml:"username"`
+	Password                 *sensitive.String `yaml:"password"`
+	BearerToken              *sensitive.String `yaml:"bearer_token"`
+	Folder                   string            `yaml:"folder" validate:"required"`
+	KeepFolderStructure      bool              `yaml:"keep_folderstructure"`
+	RemoveFilesAfterDownload bool              `yaml:"remove_files_after_download"`
+	TimeoutSeconds           int               `yaml:"timeout_seconds"`
+}
+
+type SftpConfig struct {
+	Host                     string            `yaml:"host" validate:"required"`
+	Port                     int               `yaml:"port"`
+	Username                 string            `yaml:"username" validate:"required"`
+	Password                 *sensitive.String `yaml:"password"`
+	PrivateKeyFile           string            `yaml:"private_key_file"`
+	PrivateKeyPassphrase     *sensitive.String `yaml:"private_key_passphrase"`
+	KnownHostsFile           string            `yaml:"known_hosts_file"`
+	HostKeyFingerprint       string            `yaml:"host_key_fingerprint"`
+	Folder                   string            `yaml:"folder" validate:"required"`
+	KeepFolderStructure      bool              `yaml:"keep_folderstructure"`
+	RemoveFilesAfterDownload bool              `yaml:"remove_files_after_download"`
+	TimeoutSeconds           int               `yaml:"timeout_seconds"`
+}
+
+type FtpConfig struct {
+	Host                     string            `yaml:"host" validate:"required"`
+	Port                     int               `yaml:"port"`
+	Username                 string            `yaml:"username"`
+	Password                 *sensitive.String `yaml:"password"`
+	Security                 string            `yaml:"security" validate:"omitempty,oneof=none explicit implicit"`
+	InsecureSkipVerify       bool              `yaml:"insecure_skip_verify"`
+	DisableEPSV              bool              `yaml:"disable_epsv"`
+	Folder                   string            `yaml:"folder" validate:"required"`
+	KeepFolderStructure      bool              `yaml:"keep_folderstructure"`
+	RemoveFilesAfterDownload bool              `yaml:"remove_files_after_download"`
+	TimeoutSeconds           int               `yaml:"timeout_seconds"`
+}
+
+type OpdsConfig struct {
+	URL            string            `yaml:"url" validate:"required,url"`
+	Username       string            `yaml:"username"`
+	Password       *sensitive.String `yaml:"password"`
+	MaxDepth       int               `yaml:"max_depth"`
+	OnlyNew        bool              `yaml:"only_new"`
+	StateFile      string            `yaml:"state_file"`
+	TimeoutSeconds int               `yaml:"timeout_seconds"`
+}
+
+type S3Config struct {
+	Endpoint                 string            `yaml:"endpoint" validate:"omitempty,url"`
+	Region                   string            `yaml:"region"`
+	Bucket                   string            `yaml:"bucket" validate:"required"`
+	Prefix                   string            `yaml:"prefix"`
+	AccessKeyID              string            `yaml:"access_key_id"`
+	SecretAccessKey          *sensitive.String `yaml:"secret_access_key"`
+	UsePathStyle             bool              `yaml:"use_path_style"`
+	KeepFolderStructure      bool              `yaml:"keep_folderstructure"`
+	RemoveFilesAfterDownload bool              `yaml:"remove_files_after_download"`
+	TimeoutSeconds           int               `yaml:"timeout_seconds"`
+}
+
+type CalibreConfig struct {
+	URL              string            `yaml:"url" validate:"required,url"`
+	Username         string            `yaml:"username"`
+	Password         *sensitive.String `yaml:"password"`
+	LibraryID        string            `yaml:"library_id"`
+	Query            string            `yaml:"query"`
+	Formats          []string          `yaml:"formats"`
+	FilenameTemplate string            `yaml:"filename_template"`
+	TimeoutSeconds   int               `yaml:"timeout_seconds"`
+}
+
+// CalibreLibraryConfig reads a Calibre library folder directly. The library
+// is either a local folder or the folder of a share source (smb, nfs, sftp,
+// ftp or webdav).
+type CalibreLibraryConfig struct {
+	Folder              string            `yaml:"folder" validate:"required_without=Share"`
+	Share               *Source           `yaml:"share"`
+	Tags                []string          `yaml:"tags"`
+	ExcludeTags         []string          `yaml:"exclude_tags"`
+	Series              string            `yaml:"series"`
+	Languages           []string          `yaml:"languages"`
+	CustomColumns       map[string]string `yaml:"custom_columns"`
+	Formats             []string          `yaml:"formats"`
+	FilenameTemplate    string            `yaml:"filename_template"`
+	KeepFolderStructure bool              `yaml:"keep_folderstructure"`
+	TimeoutSeconds      int               `yaml:"timeout_seconds"`
+}
+
+type LibraryServerConfig struct {
+	Server              string            `yaml:"server" validate:"required,oneof=kavita komga"`
+	URL                 string            `yaml:"url" validate:"required,url"`
+	APIKey              *sensitive.String `yaml:"api_key"`
+	Username            string            `yaml:"username"`
+	Password            *sensitive.String `yaml:"password"`
+	Libraries           []string          `yaml:"libraries"`
+	Collections         []string          `yaml:"collections"`
+	ReadingLists        []string          `yaml:"reading_lists"`
+	WantToRead          bool              `yaml:"want_to_read"`
+	KeepFolderStructure bool              `yaml:"keep_folderstructure"`
+	StateFile           string            `yaml:"state_file"`
+	TimeoutSeconds      int               `yaml:"timeout_seconds"`
+}
+
+// BookManagerConfig pulls books recently imported by Readarr or LazyLibrarian.
+// Book files are read from the server's library, either through a local
+// folder or the folder of a share source; remote_path is the library folder
+// as seen by the server.
+type BookManagerConfig struct {
+	Server              string            `yaml:"server" validate:"required,oneof=readarr lazylibrarian"`
+	URL                 string            `yaml:"url" validate:"required,url"`
+	APIKey              *sensitive.String `yaml:"api_key" validate:"required"`
+	RemotePath          string            `yaml:"remote_path" validate:"required"`
+	Folder              string            `yaml:"folder" validate:"required_without=Share"`
+	Share               *Source           `yaml:"share"`
+	MaxAgeDays          int               `yaml:"max_age_days"`
+	KeepFolderStructure bool              `yaml:"keep_folderstructure"`
+	StateFile           string            `yaml:"state_file"`
+	TimeoutSeconds      int               `yaml:"timeout_seconds"`
+}
+
+// CalibreDeviceConfig makes BookShift act as a Calibre wireless device.
+// Without a host, Calibre is discovered with a UDP broadcast on the local
+// network.
+type CalibreDeviceConfig struct {
+	Host                string            `yaml:"host"`
+	Port                int               `yaml:"port"`
+	Password            *sensitive.String `yaml:"password"`
+	DeviceName          string            `yaml:"device_name"`
+	KeepFolderStructure bool              `yaml:"keep_folderstructure"`
+	IdleSeconds         int               `yaml:"idle_seconds"`
+	TimeoutSeconds      int               `yaml:"timeout_seconds"`
+}
+
+// WallabagConfig exports saved articles from Wallabag as EPUB. Without
+// username, the client credentials grant is used; most Wallabag servers need
+// the password grant with the user's credentials.
+type WallabagConfig struct {
+	URL                  string            `yaml:"url" validate:"required,url"`
+	ClientID             string            `yaml:"client_id" validate:"required"`
+	ClientSecret         *sensitive.String `yaml:"client_secret" validate:"required"`
+	Username             string            `yaml:"username"`
+	Password             *sensitive.String `yaml:"password"`
+	Tags                 []string          `yaml:"tags"`
+	Starred              bool              `yaml:"starred"`
+	IncludeArchived      bool              `yaml:"include_archived"`
+	ArchiveAfterDownload bool              `yaml:"archive_after_download"`
+	StateFile            string            `yaml:"state_file"`
+	TimeoutSeconds       int               `yaml:"timeout_seconds"`
+}
+
+// PublicDomainConfig downloads books from Project Gutenberg and Standard
+// Ebooks. Books are given as Gutenberg ids or Standard Ebooks URLs, in the
+// config, in a list file with one book per line, or both.
+type PublicDomainConfig struct {
+	Books             []string `yaml:"books" validate:"required_without=BookListFile"`
+	BookListFile      string   `yaml:"book_list_file" validate:"required_without=Books"`
+	GutenbergURL      string   `yaml:"gutenberg_url" validate:"omitempty,url"`
+	StandardEbooksURL string   `yaml:"standard_ebooks_url" validate:"omitempty,url"`
+	PreferKepub       bool     `yaml:"prefer_kepub"`
+	StateFile         string   `yaml:"state_file"`
+	TimeoutSeconds    int      `yaml:"timeout_seconds"`
+}
+
+// RcloneConfig reads files through a running "rclone rcd". Remote is an
+// rclone remote as configured on that server (e.g. "gdrive:"), Folder a path
+// inside it. Files are downloaded from the rc serve endpoint (--rc-serve).
+type RcloneConfig struct {
+	URL                      string            `yaml:"url" validate:"required,url"`
+	Username                 string            `yaml:"username"`
+	Password                 *sensitive.String `yaml:"password"`
+	Remote                   string            `yaml:"remote" validate:"required"`
+	Folder                   string            `yaml:"folder"`
+	KeepFolderStructure      bool              `yaml:"keep_folderstructure"`
+	RemoveFilesAfterDownload bool              `yaml:"remove_files_after_download"`
+	TimeoutSeconds           int               `yaml:"timeout_seconds"`
+}
+
+// ExecConfig runs an external program that lists and streams the files of a
+// source BookShift does not support itself. The protocol is described in
+// docs/exec-plugins.md; options are passed to the program as-is.
+type ExecConfig struct {
+	Command                  string            `yaml:"command" validate:"required"`
+	Args                     []string          `yaml:"args"`
+	Env                      map[string]string `yaml:"env"`
+	Options                  map[string]any    `yaml:"options"`
+	KeepFolderStructure      bool              `yaml:"keep_folderstructure"`
+	RemoveFilesAfterDownload bool              `yaml:"remove_files_after_download"`
+	TimeoutSeconds           int               `yaml:"timeout_seconds"`
+}
+
+type LocalConfig struct {
+	Folder                   string `yaml:"folder" validate:"required"`
+	KeepFolderStructure      bool   `yaml:"keep_folderstructure"`
+	RemoveFilesAfterDownload bool   `yaml:"remove_files_after_download"`
+	TimeoutSeconds           int    `yaml:"timeout_seconds"`
+}
+
+type HttpConfig struct {
+	URL                 string            `yaml:"url" validate:"required,url"`
+	Username            string            `yaml:"username"`
+	Password            *sensitive.String `yaml:"password"`
+	LinkPattern         string            `yaml:"link_pattern"`
+	Recursive           bool              `yaml:"recursive"`
+	MaxDepth            int               `yaml:"max_depth"`
+	KeepFolderStructure bool              `yaml:"keep_folderstructure"`
+	StateFile           string            `yaml:"state_file"`
+	TimeoutSeconds      int               `yaml:"timeout_seconds"`
+}
+
+type FeedConfig struct {
+	URL            string            `yaml:"url" validate:"required,url"`
+	Username       string            `yaml:"username"`
+	Password       *sensitive.String `yaml:"password"`
+	IncludeLinks   bool              `yaml:"include_links"`
+	StateFile      string            `yaml:"state_file"`
+	TimeoutSeconds int               `yaml:"timeout_seconds"`
+}
+
+type Pop3Config struct {
+	Host                      string            `yaml:"host" validate:"required"`
+	Port                      int               `yaml:"port"`
+	Username                  string            `yaml:"username" validate:"required"`
+	Password                  *sensitive.String `yaml:"password"`
+	Security                  string            `yaml:"security" validate:"omitempty,oneof=none explicit implicit"`
+	AuthMethod                string            `yaml:"auth_method" validate:"omitempty,oneof=user apop"`
+	InsecureSkipVerify        bool              `yaml:"insecure_skip_verify"`
 	FilterField               string            `yaml:"filter_field" validate:"required,oneof=to subject"`
 	FilterValue               string            `yaml:"filter_value" validate:"required"`
-	ProcessReadEmails         bool              `yaml:"process_read_emails"`
 	RemoveEmailsAfterDownload bool              `yaml:"remove_emails_after_download"`
 	TimeoutSeconds            int               `yaml:"timeout_seconds"`
 }
+
+type JmapConfig struct {
+	SessionURL        string            `yaml:"session_url" validate:"required,url"`
+	BearerToken       *sensitive.String `yaml:"bearer_token" validate:"required"`
+	AccountID         string            `yaml:"account_id"`
+	Mailbox           string            `yaml:"mailbox"`
+	FilterField       string            `yaml:"filter_field" validate:"required,oneof=to subject"`
+	FilterValue       string            `yaml:"filter_value" validate:"required"`
+	ProcessReadEmails bool              `yaml:"process_read_emails"`
+	AfterDownload     string            `yaml:"after_download" validate:"omitempty,oneof=none seen move delete"`
+	MoveToMailbox     string            `yaml:"move_to_mailbox" validate:"required_if=AfterDownload move"`
+	TimeoutSeconds    int               `yaml:"timeout_seconds"`
+}
+
+type MaildirConfig struct {
+	Folder            string `yaml:"folder" validate:"required"`
+	FilterField       string `yaml:"filter_field" validate:"omitempty,oneof=to subject"`
+	FilterValue       string `yaml:"filter_value" validate:"required_with=FilterField"`
+	ProcessReadEmails bool   `yaml:"process_read_emails"`
+	AfterDownload     string `yaml:"after_download" validate:"omitempty,oneof=none seen delete"`
+	TimeoutSeconds    int    `yaml:"timeout_seconds"`
+}
+
+type MboxConfig struct {
+	File                      string `yaml:"file" validate:"required"`
+	FilterField               string `yaml:"filter_field" validate:"omitempty,oneof=to subject"`
+	FilterValue               string `yaml:"filter_value" validate:"required_with=FilterField"`
+	ProcessReadEmails         bool   `yaml:"process_read_emails"`
+	RemoveEmailsAfterDownload bool   `yaml:"remove_emails_after_download"`
+	TimeoutSeconds            int    `yaml:"timeout_seconds"`
+}
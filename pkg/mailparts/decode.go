@@ -0,0 +1,45 @@
+// Package mailparts holds the attachment handling shared by the mail based
+// sources: transfer decoding, MIME part walking and writing attachments to disk.
+package mailparts
+
+import (
+	"encoding/base64"
+	"io"
+	"log/slog"
+	"mime/quotedprintable"
+	"path"
+	"slices"
+	"strings"
+)
+
+// DecodeTransfer wraps r so that it yields the decoded content of a part sent
+// with the given Content-Transfer-Encoding.
+func DecodeTransfer(encoding string, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, r)
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	case "7bit", "8bit", "binary", "":
+		// pass through as-is
+		return r
+	default:
+		// unknown: pass-through but warn
+		slog.Warn("Unknown transfer-encoding, writing raw bytes", "encoding", encoding)
+		return r
+	}
+}
+
+// WantedAttachment reports whether an attachment with the given file name
+// should be downloaded, comparing extensions case-insensitively. An empty
+// validExtensions list accepts every file.
+func WantedAttachment(filename string, validExtensions []string) bool {
+	if filename == "" {
+		return false
+	}
+	if len(validExtensions) == 0 {
+		return true
+	}
+	ext := path.Ext(filename)
+	return slices.ContainsFunc(validExtensions, func(e string) bool { return strings.EqualFold(e, ext) })
+}
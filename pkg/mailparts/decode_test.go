@@ -0,0 +1,51 @@
+package mailparts
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+// TestDecodeTransfer covers the supported transfer-encodings and the pass-through fallback.
+func TestDecodeTransfer(t *testing.T) {
+	cases := []struct {
+		encoding string
+		in       string
+		want     string
+	}{
+		{"base64", "REFU\r\nQQ==", "DATA"},
+		{"BASE64", "REFUQQ==", "DATA"},
+		{"quoted-printable", "caf=C3=A9=\r\n!", "café!"},
+		{"7bit", "plain", "plain"},
+		{"", "plain", "plain"},
+		{"x-unknown", "raw", "raw"},
+	}
+	for _, c := range cases {
+		b, err := io.ReadAll(DecodeTransfer(c.encoding, strings.NewReader(c.in)))
+		if err != nil {
+			t.Fatalf("%s: %v", c.encoding, err)
+		}
+		if string(b) != c.want {
+			t.Fatalf("%s: want %q, got %q", c.encoding, c.want, string(b))
+		}
+	}
+}
+
+// TestWantedAttachment checks the extension filter.
+func TestWantedAttachment(t *testing.T) {
+	if !WantedAttachment("a.epub", []string{".epub"}) {
+		t.Fatalf("expected .epub to be wanted")
+	}
+	if !WantedAttachment("A.EPUB", []string{".epub"}) {
+		t.Fatalf("expected extensions to match case-insensitively")
+	}
+	if WantedAttachment("a.pdf", []string{".epub"}) {
+		t.Fatalf("expected .pdf to be filtered")
+	}
+	if !WantedAttachment("a.pdf", nil) {
+		t.Fatalf("expected every file without a filter")
+	}
+	if WantedAttachment("", nil) {
+		t.Fatalf("expected parts without a file name to be ignored")
+	}
+}
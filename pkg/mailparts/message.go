@@ -0,0 +1,152 @@
+package mailparts
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+// maxPartDepth bounds the nesting of multipart bodies that is followed.
+const maxPartDepth = 10
+
+// wordDecoder decodes RFC 2047 encoded header values in any charset known to x/net.
+var wordDecoder = &mime.WordDecoder{CharsetReader: charset.NewReaderLabel}
+
+// Attachment is a single decoded attachment found in a message.
+type Attachment struct {
+	Part     []int
+	Filename string
+	Encoding string
+	Content  []byte
+}
+
+// headerGetter is implemented by both mail.Header and textproto.MIMEHeader.
+type headerGetter interface {
+	Get(key string) string
+}
+
+// ExtractAttachments parses a raw RFC 5322 message and returns its header
+// together with all attachments whose file name matches validExtensions.
+func ExtractAttachments(raw io.Reader, validExtensions []string) (mail.Header, []Attachment, error) {
+	msg, err := mail.ReadMessage(raw)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse message: %w", err)
+	}
+
+	var attachments []Attachment
+	if err := walkPart(msg.Header, msg.Body, nil, validExtensions, &attachments); err != nil {
+		return nil, nil, err
+	}
+	return msg.Header, attachments, nil
+}
+
+// walkPart descends into multipart bodies and collects attachment leaves.
+func walkPart(header headerGetter, body io.Reader, part []int, validExtensions []string, attachments *[]Attachment) error {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		if len(part) >= maxPartDepth {
+			return nil
+		}
+		boundary := params["boundary"]
+		if boundary == "" {
+			return fmt.Errorf("multipart body without boundary")
+		}
+		mr := multipart.NewReader(body, boundary)
+		for i := 1; ; i++ {
+			// NextRawPart leaves the transfer-encoding to DecodeTransfer
+			p, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read message part: %w", err)
+			}
+			if err := walkPart(p.Header, p, append(part[:len(part):len(part)], i), validExtensions, attachments); err != nil {
+				return err
+			}
+		}
+	}
+
+	filename := PartFilename(header)
+	if !WantedAttachment(filename, validExtensions) {
+		return nil
+	}
+
+	encoding := strings.ToLower(strings.TrimSpace(header.Get("Content-Transfer-Encoding")))
+	content, err := io.ReadAll(DecodeTransfer(encoding, body))
+	if err != nil {
+		return fmt.Errorf("failed to decode attachment %s: %w", filename, err)
+	}
+	if part == nil {
+		part = []int{1}
+	}
+	*attachments = append(*attachments, Attachment{
+		Part:     part,
+		Filename: filename,
+		Encoding: encoding,
+		Content:  content,
+	})
+	return nil
+}
+
+// PartFilename returns the decoded file name of a part, taken from the
+// Content-Disposition filename or, failing that, the Content-Type name.
+func PartFilename(header headerGetter) string {
+	var filename string
+	if _, params, err := mime.ParseMediaType(header.Get("Content-Disposition")); err == nil {
+		filename = params["filename"]
+	}
+	if filename == "" {
+		if _, params, err := mime.ParseMediaType(header.Get("Content-Type")); err == nil {
+			filename = params["name"]
+		}
+	}
+	return DecodeHeader(filename)
+}
+
+// DecodeHeader decodes RFC 2047 encoded words, returning the input unchanged when decoding fails.
+func DecodeHeader(value string) string {
+	decoded, err := wordDecoder.DecodeHeader(value)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
+
+// HeaderMatches reports whether the To or Subject header (selected by field)
+// contains value, compared case-insensitively like an IMAP HEADER search.
+func HeaderMatches(header mail.Header, field string, value string) bool {
+	var haystack string
+	switch field {
+	case "to":
+		haystack = DecodeHeader(header.Get("To"))
+	case "subject":
+		haystack = DecodeHeader(header.Get("Subject"))
+	default:
+		return true
+	}
+	return strings.Contains(strings.ToLower(haystack), strings.ToLower(value))
+}
+
+// Sender returns a printable form of the From header for logging.
+func Sender(header mail.Header) string {
+	addrs, err := header.AddressList("From")
+	if err != nil || len(addrs) == 0 {
+		return DecodeHeader(header.Get("From"))
+	}
+	return fmt.Sprintf("%s (%s)", addrs[0].Name, addrs[0].Address)
+}
+
+// Subject returns the decoded Subject header.
+func Subject(header mail.Header) string {
+	return DecodeHeader(header.Get("Subject"))
+}
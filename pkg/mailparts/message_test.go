@@ -0,0 +1,100 @@
+package mailparts
+
+import (
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+// TestExtractAttachments returns decoded attachments matching the extension filter.
+func TestExtractAttachments(t *testing.T) {
+	raw := buildMessage("Books", "kobo@example.com", map[string]string{"book.epub": "EPUB"})
+
+	header, atts, err := ExtractAttachments(strings.NewReader(raw), []string{".epub"})
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	if header.Get("Subject") != "Books" {
+		t.Fatalf("unexpected header: %v", header)
+	}
+	if len(atts) != 1 || atts[0].Filename != "book.epub" || string(atts[0].Content) != "EPUB" {
+		t.Fatalf("unexpected attachments: %+v", atts)
+	}
+	if len(atts[0].Part) != 1 || atts[0].Part[0] != 2 || atts[0].Encoding != "base64" {
+		t.Fatalf("unexpected part details: %+v", atts[0])
+	}
+
+	_, atts, err = ExtractAttachments(strings.NewReader(raw), []string{".pdf"})
+	if err != nil || len(atts) != 0 {
+		t.Fatalf("expected no attachments, got %+v (%v)", atts, err)
+	}
+}
+
+// TestExtractAttachments_NestedAndEncodedNames walks nested multiparts and decodes RFC 2047 names.
+func TestExtractAttachments_NestedAndEncodedNames(t *testing.T) {
+	raw := "Subject: x\r\n" +
+		"Content-Type: multipart/mixed; boundary=a\r\n\r\n" +
+		"--a\r\n" +
+		"Content-Type: multipart/alternative; boundary=b\r\n\r\n" +
+		"--b\r\nContent-Type: text/plain\r\n\r\nhi\r\n" +
+		"--b\r\n" +
+		"Content-Type: application/epub+zip; name=\"=?UTF-8?B?w6lsw6luYS5lcHVi?=\"\r\n" +
+		"Content-Transfer-Encoding: quoted-printable\r\n\r\n" +
+		"A=3DB\r\n" +
+		"--b--\r\n" +
+		"--a--\r\n"
+
+	_, atts, err := ExtractAttachments(strings.NewReader(raw), nil)
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	if len(atts) != 1 {
+		t.Fatalf("want 1 attachment, got %+v", atts)
+	}
+	if atts[0].Filename != "éléna.epub" || string(atts[0].Content) != "A=B" {
+		t.Fatalf("unexpected attachment: %+v %q", atts[0], atts[0].Content)
+	}
+	if len(atts[0].Part) != 2 || atts[0].Part[0] != 1 || atts[0].Part[1] != 2 {
+		t.Fatalf("unexpected part path: %v", atts[0].Part)
+	}
+}
+
+// TestExtractAttachments_SinglePartAndErrors covers non-multipart messages and malformed input.
+func TestExtractAttachments_SinglePartAndErrors(t *testing.T) {
+	raw := "Content-Type: application/pdf\r\n" +
+		"Content-Disposition: attachment; filename=doc.pdf\r\n\r\n" +
+		"PDF"
+	_, atts, err := ExtractAttachments(strings.NewReader(raw), nil)
+	if err != nil || len(atts) != 1 || atts[0].Part[0] != 1 || string(atts[0].Content) != "PDF" {
+		t.Fatalf("unexpected result: %+v (%v)", atts, err)
+	}
+
+	if _, _, err := ExtractAttachments(strings.NewReader("not a message"), nil); err == nil {
+		t.Fatalf("expected parse error")
+	}
+	noBoundary := "Content-Type: multipart/mixed\r\n\r\nbody"
+	if _, _, err := ExtractAttachments(strings.NewReader(noBoundary), nil); err == nil {
+		t.Fatalf("expected missing boundary error")
+	}
+}
+
+// TestHeaderMatches compares To and Subject case-insensitively.
+func TestHeaderMatches(t *testing.T) {
+	h := mail.Header{
+		"To":      {"Kobo <Kobo@Example.com>"},
+		"Subject": {"=?UTF-8?Q?New_b=C3=B6oks?="},
+		"From":    {"Alice <alice@example.com>"},
+	}
+	if !HeaderMatches(h, "to", "kobo@example.com") {
+		t.Fatalf("expected to match")
+	}
+	if !HeaderMatches(h, "subject", "BÖOKS") {
+		t.Fatalf("expected decoded subject to match")
+	}
+	if HeaderMatches(h, "subject", "other") {
+		t.Fatalf("unexpected match")
+	}
+	if Sender(h) != "Alice (alice@example.com)" || Subject(h) != "New böoks" {
+		t.Fatalf("unexpected sender/subject: %q %q", Sender(h), Subject(h))
+	}
+}
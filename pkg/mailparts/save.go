@@ -0,0 +1,58 @@
+package mailparts
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// SaveAttachment writes src to dstFolder under a sanitised version of filename.
+// Existing files are skipped unless overwriteExistingFile is set. The returned
+// path is empty when nothing was written (skipped or dry-run).
+func SaveAttachment(dstFolder string, filename string, size int64, src io.Reader, overwriteExistingFile bool) (string, error) {
+	safeFileName := util.SafeFileName(filename)
+	dstPath := filepath.Join(dstFolder, safeFileName)
+
+	// Check if the file already exists
+	_, err := os.Stat(dstPath)
+	if !os.IsNotExist(err) {
+		if !overwriteExistingFile {
+			slog.Warn("File already exists, skipping download", "file", dstPath)
+			return "", nil
+		}
+
+		slog.Info("Overwriting existing file", "file", dstPath)
+	}
+
+	// Download the file
+	if util.DryRun {
+		slog.Info("[dry-run] Would download email attachment", "filename", safeFileName, "destination", dstPath)
+		return "", nil
+	}
+	tmpFile, err := os.CreateTemp(dstFolder, "bookshift-")
+	if err != nil {
+		return "", err
+	}
+	defer tmpFile.Close()
+
+	slog.Debug("Downloading to temporary file", "file", tmpFile.Name())
+	writer := util.NewFileWriter(tmpFile, size, true)
+	if _, err := io.Copy(writer, src); err != nil {
+		os.Remove(tmpFile.Name())
+		return "", err
+	}
+
+	if err := tmpFile.Sync(); err != nil { // ensure data flushed before rename
+		os.Remove(tmpFile.Name())
+		return "", err
+	}
+	if err := os.Rename(tmpFile.Name(), dstPath); err != nil {
+		os.Remove(tmpFile.Name())
+		return "", err
+	}
+
+	return dstPath, nil
+}
@@ -0,0 +1,70 @@
+package mailparts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// TestSaveAttachment writes, skips and overwrites files.
+func TestSaveAttachment(t *testing.T) {
+	dir := t.TempDir()
+
+	dst, err := SaveAttachment(dir, "My Book.epub", 3, strings.NewReader("NEW"), false)
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if dst != filepath.Join(dir, "my-book.epub") {
+		t.Fatalf("unexpected path %q", dst)
+	}
+
+	if dst, err := SaveAttachment(dir, "My Book.epub", 3, strings.NewReader("ALT"), false); err != nil || dst != "" {
+		t.Fatalf("expected skip, got %q (%v)", dst, err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "my-book.epub")); string(b) != "NEW" {
+		t.Fatalf("existing file changed: %q", b)
+	}
+
+	if _, err := SaveAttachment(dir, "My Book.epub", 3, strings.NewReader("ALT"), true); err != nil {
+		t.Fatalf("overwrite: %v", err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "my-book.epub")); string(b) != "ALT" {
+		t.Fatalf("expected overwritten file, got %q", b)
+	}
+}
+
+// TestSaveAttachment_DryRunAndErrors ensures dry-run writes nothing and failures are surfaced.
+func TestSaveAttachment_DryRunAndErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	old := util.DryRun
+	util.DryRun = true
+	dst, err := SaveAttachment(dir, "a.epub", 1, strings.NewReader("A"), false)
+	util.DryRun = old
+	if err != nil || dst != "" {
+		t.Fatalf("dry-run: %q %v", dst, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a.epub")); !os.IsNotExist(err) {
+		t.Fatalf("dry-run wrote a file")
+	}
+
+	if _, err := SaveAttachment(filepath.Join(dir, "missing"), "a.epub", 1, strings.NewReader("A"), false); err == nil {
+		t.Fatalf("expected create temp error")
+	}
+
+	if err := os.Mkdir(filepath.Join(dir, "b.epub"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if _, err := SaveAttachment(dir, "b.epub", 1, strings.NewReader("B"), true); err == nil {
+		t.Fatalf("expected rename error")
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), "bookshift-") {
+			t.Fatalf("temporary file left behind: %s", e.Name())
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package mailparts
+
+import (
+	"encoding/base64"
+	"strings"
+)
+
+// buildMessage assembles a multipart/mixed message with a text body and one
+// base64 encoded attachment per file name.
+func buildMessage(subject string, to string, files map[string]string) string {
+	var b strings.Builder
+	b.WriteString("From: Alice <alice@example.com>\r\n")
+	b.WriteString("To: " + to + "\r\n")
+	b.WriteString("Subject: " + subject + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: multipart/mixed; boundary=\"outer\"\r\n\r\n")
+	b.WriteString("--outer\r\nContent-Type: text/plain\r\n\r\nHello\r\n")
+	for name, content := range files {
+		b.WriteString("--outer\r\n")
+		b.WriteString("Content-Type: application/octet-stream\r\n")
+		b.WriteString("Content-Disposition: attachment; filename=\"" + name + "\"\r\n")
+		b.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+		b.WriteString(base64.StdEncoding.EncodeToString([]byte(content)) + "\r\n")
+	}
+	b.WriteString("--outer--\r\n")
+	return b.String()
+}
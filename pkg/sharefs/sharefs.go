@@ -0,0 +1,264 @@
+// Package sharefs reads files from a folder that is either local (including
+// mounted shares) or the folder of a share source (smb, nfs, sftp, ftp or
+// webdav), for sources that learn file paths from somewhere else.
+package sharefs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/bjw-s-labs/bookshift/pkg/syncer/ftp"
+	"github.com/bjw-s-labs/bookshift/pkg/syncer/nfs"
+	"github.com/bjw-s-labs/bookshift/pkg/syncer/sftp"
+	"github.com/bjw-s-labs/bookshift/pkg/syncer/smb"
+	"github.com/bjw-s-labs/bookshift/pkg/syncer/webdav"
+	"github.com/go-playground/sensitive"
+)
+
+// FolderAPI reads files from a folder. Paths are relative to the folder and
+// use forward slashes.
+type FolderAPI interface {
+	Connect(timeout time.Duration) error
+	Disconnect() error
+	ReadFile(path string, w io.Writer) (int64, error)
+	Location() string
+}
+
+// NewFolder returns a client for the local folder, or for the folder of the
+// share when one is given.
+func NewFolder(folder string, share *config.Source) (FolderAPI, error) {
+	if share == nil {
+		return &LocalFolder{Folder: folder}, nil
+	}
+
+	// Ports default the same way as for the share sources
+	switch shareCfg := share.Config.(type) {
+	case *config.SmbNetworkShareConfig:
+		port := shareCfg.Port
+		if !(port > 0) {
+			port = 445
+		}
+		password := shareCfg.Password
+		if password == nil {
+			password = new(sensitive.String)
+		}
+		conn := &smb.SmbConnection{
+			Host:     shareCfg.Host,
+			Port:     port,
+			Username: shareCfg.Username,
+			Password: password,
+			Domain:   shareCfg.Domain,
+		}
+		return &RemoteFolder{
+			client:   &smbReader{conn: conn, share: shareCfg.Share},
+			folder:   shareCfg.Folder,
+			location: fmt.Sprintf("smb://%s/%s", shareCfg.Host, shareCfg.Share),
+		}, nil
+	case *config.NfsNetworkShareConfig:
+		port := shareCfg.Port
+		if !(port > 0) {
+			port = 2049
+		}
+		return &RemoteFolder{
+			client:   &nfsReader{client: nfs.NewNfsClient(shareCfg.Host, port)},
+			folder:   shareCfg.Folder,
+			location: "nfs://" + shareCfg.Host,
+		}, nil
+	case *config.SftpConfig:
+		port := shareCfg.Port
+		if !(port > 0) {
+			port = 22
+		}
+		return &RemoteFolder{
+			client: &sftp.SftpClient{
+				Host:                 shareCfg.Host,
+				Port:                 port,
+				Username:             shareCfg.Username,
+				Password:             shareCfg.Password,
+				PrivateKeyFile:       shareCfg.PrivateKeyFile,
+				PrivateKeyPassphrase: shareCfg.PrivateKeyPassphrase,
+				KnownHostsFile:       shareCfg.KnownHostsFile,
+				HostKeyFingerprint:   shareCfg.HostKeyFingerprint,
+			},
+			folder:   shareCfg.Folder,
+			location: "sftp://" + shareCfg.Host,
+		}, nil
+	case *config.FtpConfig:
+		port := shareCfg.Port
+		if !(port > 0) {
+			port = 21
+			if shareCfg.Security == "implicit" {
+				port = 990
+			}
+		}
+		return &RemoteFolder{
+			client: &ftp.FtpClient{
+				Host:               shareCfg.Host,
+				Port:               port,
+				Username:           shareCfg.Username,
+				Password:           shareCfg.Password,
+				Security:           shareCfg.Security,
+				InsecureSkipVerify: shareCfg.InsecureSkipVerify,
+				DisableEPSV:        shareCfg.DisableEPSV,
+			},
+			folder:   shareCfg.Folder,
+			location: "ftp://" + shareCfg.Host,
+		}, nil
+	case *config.WebdavConfig:
+		client, err := webdav.NewWebdavClient(shareCfg.URL, shareCfg.Username, shareCfg.Password, shareCfg.BearerToken)
+		if err != nil {
+			return nil, err
+		}
+		return &RemoteFolder{
+			client:   &webdavReader{client: client},
+			folder:   shareCfg.Folder,
+			location: shareCfg.URL,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported share type: %s", share.Type)
+	}
+}
+
+// LocalFolder is a folder on the local filesystem (including mounted shares).
+type LocalFolder struct {
+	Folder string
+}
+
+func (l *LocalFolder) Connect(timeout time.Duration) error {
+	info, err := os.Stat(l.Folder)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", l.Folder)
+	}
+	return nil
+}
+
+func (l *LocalFolder) Disconnect() error {
+	return nil
+}
+
+func (l *LocalFolder) ReadFile(filePath string, w io.Writer) (int64, error) {
+	f, err := os.Open(filepath.Join(l.Folder, filepath.FromSlash(filePath)))
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return io.Copy(w, f)
+}
+
+func (l *LocalFolder) Location() string {
+	return l.Folder
+}
+
+// fileReader is the subset of the share clients used to read files.
+type fileReader interface {
+	Connect(timeout time.Duration) error
+	Disconnect() error
+	ReadFile(path string, w io.Writer) (int64, error)
+}
+
+// RemoteFolder is a folder of a share.
+type RemoteFolder struct {
+	client   fileReader
+	folder   string
+	location string
+}
+
+func (r *RemoteFolder) Connect(timeout time.Duration) error {
+	slog.Debug("Connecting to share", "location", r.location)
+	return r.client.Connect(timeout)
+}
+
+func (r *RemoteFolder) Disconnect() error {
+	return r.client.Disconnect()
+}
+
+func (r *RemoteFolder) ReadFile(filePath string, w io.Writer) (int64, error) {
+	return r.client.ReadFile(path.Join(r.folder, filePath), w)
+}
+
+func (r *RemoteFolder) Location() string {
+	return r.location
+}
+
+// smbConn is the subset of smb.SmbConnection used to read files from a share.
+type smbConn interface {
+	Connect() error
+	Disconnect() error
+	TreeConnect(share string) error
+	TreeDisconnect(share string) error
+	RetrieveFile(share string, filepath string, offset uint64, callback func([]byte) (int, error)) error
+}
+
+// smbReader adapts an SMB connection and share to fileReader.
+type smbReader struct {
+	conn  smbConn
+	share string
+}
+
+func (s *smbReader) Connect(timeout time.Duration) error {
+	if err := s.conn.Connect(); err != nil {
+		return err
+	}
+	if err := s.conn.TreeConnect(s.share); err != nil {
+		_ = s.conn.Disconnect()
+		return fmt.Errorf("could not connect to SMB share %s: %w", s.share, err)
+	}
+	return nil
+}
+
+func (s *smbReader) Disconnect() error {
+	_ = s.conn.TreeDisconnect(s.share)
+	return s.conn.Disconnect()
+}
+
+func (s *smbReader) ReadFile(filePath string, w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	err := s.conn.RetrieveFile(s.share, filePath, 0, cw.Write)
+	return cw.n, err
+}
+
+// nfsReader adapts the NFS client to fileReader.
+type nfsReader struct {
+	client nfs.NfsAPI
+}
+
+func (n *nfsReader) Connect(timeout time.Duration) error { return n.client.Connect(timeout) }
+func (n *nfsReader) Disconnect() error                   { return n.client.Disconnect() }
+func (n *nfsReader) ReadFile(filePath string, w io.Writer) (int64, error) {
+	written, err := n.client.ReadFileAll(filePath, w)
+	return int64(written), err
+}
+
+// webdavReader adapts the WebDAV client to fileReader.
+type webdavReader struct {
+	client webdav.WebdavAPI
+}
+
+func (d *webdavReader) Connect(timeout time.Duration) error {
+	return d.client.Connect(context.Background(), timeout)
+}
+func (d *webdavReader) Disconnect() error { return d.client.Disconnect() }
+func (d *webdavReader) ReadFile(filePath string, w io.Writer) (int64, error) {
+	return d.client.ReadFile(filePath, w)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
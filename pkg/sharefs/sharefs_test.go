@@ -0,0 +1,185 @@
+package sharefs
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/bjw-s-labs/bookshift/pkg/syncer/ftp"
+	"github.com/bjw-s-labs/bookshift/pkg/syncer/sftp"
+)
+
+// TestNewFolder builds the client matching the share type and applies the share defaults.
+func TestNewFolder(t *testing.T) {
+	c, err := NewFolder("/books", nil)
+	if l, ok := c.(*LocalFolder); err != nil || !ok || l.Location() != "/books" {
+		t.Fatalf("expected local folder, got %T %v", c, err)
+	}
+
+	cases := map[string]*config.Source{
+		"smb://nas/books":       {Type: "smb", Config: &config.SmbNetworkShareConfig{Host: "nas", Share: "books", Folder: "Calibre"}},
+		"nfs://nas":             {Type: "nfs", Config: &config.NfsNetworkShareConfig{Host: "nas", Folder: "/export/calibre"}},
+		"sftp://nas":            {Type: "sftp", Config: &config.SftpConfig{Host: "nas", Username: "u", Folder: "calibre"}},
+		"ftp://nas":             {Type: "ftp", Config: &config.FtpConfig{Host: "nas", Security: "implicit", Folder: "calibre"}},
+		"https://nas/dav/files": {Type: "webdav", Config: &config.WebdavConfig{URL: "https://nas/dav/files", Folder: "calibre"}},
+	}
+	for location, share := range cases {
+		c, err := NewFolder("", share)
+		if err != nil {
+			t.Fatalf("%s: %v", share.Type, err)
+		}
+		r, ok := c.(*RemoteFolder)
+		if !ok || r.Location() != location || r.folder == "" {
+			t.Fatalf("%s: unexpected client %T %+v", share.Type, c, c)
+		}
+		switch client := r.client.(type) {
+		case *sftp.SftpClient:
+			if client.Port != 22 {
+				t.Fatalf("sftp: unexpected port %d", client.Port)
+			}
+		case *ftp.FtpClient:
+			if client.Port != 990 {
+				t.Fatalf("ftp: unexpected port %d", client.Port)
+			}
+		case *smbReader:
+			if client.share != "books" {
+				t.Fatalf("smb: unexpected share %q", client.share)
+			}
+		}
+	}
+
+	if _, err := NewFolder("", &config.Source{Type: "s3", Config: &config.S3Config{}}); err == nil {
+		t.Fatalf("expected unsupported share error")
+	}
+	if _, err := NewFolder("", &config.Source{Type: "webdav", Config: &config.WebdavConfig{URL: "://bad"}}); err == nil {
+		t.Fatalf("expected invalid WebDAV URL error")
+	}
+}
+
+// TestLocalFolder checks the folder on connect and reads files by relative path.
+func TestLocalFolder(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "Jane Austen", "Emma (2)"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "Jane Austen", "Emma (2)", "Emma - Jane Austen.epub"), []byte("EMMA"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	l := &LocalFolder{Folder: root}
+	if err := l.Connect(time.Second); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	var buf bytes.Buffer
+	if n, err := l.ReadFile("Jane Austen/Emma (2)/Emma - Jane Austen.epub", &buf); err != nil || n != 4 || buf.String() != "EMMA" {
+		t.Fatalf("unexpected read: %d %q %v", n, buf.String(), err)
+	}
+	if _, err := l.ReadFile("missing", io.Discard); err == nil {
+		t.Fatalf("expected missing file error")
+	}
+	if err := (&LocalFolder{Folder: root + "/missing"}).Connect(time.Second); err == nil {
+		t.Fatalf("expected missing folder error")
+	}
+	if err := (&LocalFolder{Folder: filepath.Join(root, "Jane Austen", "Emma (2)", "Emma - Jane Austen.epub")}).Connect(time.Second); err == nil {
+		t.Fatalf("expected not a directory error")
+	}
+}
+
+// fakeReader records the paths read through a RemoteFolder.
+type fakeReader struct {
+	connected bool
+	paths     []string
+}
+
+func (f *fakeReader) Connect(timeout time.Duration) error { f.connected = true; return nil }
+func (f *fakeReader) Disconnect() error                   { f.connected = false; return nil }
+func (f *fakeReader) ReadFile(path string, w io.Writer) (int64, error) {
+	f.paths = append(f.paths, path)
+	n, err := io.WriteString(w, "data")
+	return int64(n), err
+}
+
+// TestRemoteFolder resolves paths below the share folder.
+func TestRemoteFolder(t *testing.T) {
+	fr := &fakeReader{}
+	r := &RemoteFolder{client: fr, folder: "/export/Calibre Library", location: "nfs://nas"}
+	if err := r.Connect(time.Second); err != nil || !fr.connected {
+		t.Fatalf("connect: %v", err)
+	}
+	if _, err := r.ReadFile("metadata.db", io.Discard); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if fr.paths[0] != "/export/Calibre Library/metadata.db" {
+		t.Fatalf("unexpected path: %v", fr.paths)
+	}
+	if err := r.Disconnect(); err != nil || fr.connected {
+		t.Fatalf("disconnect: %v", err)
+	}
+}
+
+// fakeSmbConn is an in-memory smbConn serving a single share.
+type fakeSmbConn struct {
+	files   map[string]string
+	treeErr error
+	calls   []string
+}
+
+func (f *fakeSmbConn) Connect() error { f.calls = append(f.calls, "connect"); return nil }
+func (f *fakeSmbConn) Disconnect() error {
+	f.calls = append(f.calls, "disconnect")
+	return nil
+}
+func (f *fakeSmbConn) TreeConnect(share string) error {
+	f.calls = append(f.calls, "tree:"+share)
+	return f.treeErr
+}
+func (f *fakeSmbConn) TreeDisconnect(share string) error {
+	f.calls = append(f.calls, "untree:"+share)
+	return nil
+}
+func (f *fakeSmbConn) RetrieveFile(share string, filepath string, offset uint64, callback func([]byte) (int, error)) error {
+	content, ok := f.files[filepath]
+	if !ok {
+		return errors.New("file not found")
+	}
+	// Deliver the content in two chunks like a real transfer would
+	half := len(content) / 2
+	if _, err := callback([]byte(content[:half])); err != nil {
+		return err
+	}
+	_, err := callback([]byte(content[half:]))
+	return err
+}
+
+// TestSmbReader connects to the share and counts the bytes written.
+func TestSmbReader(t *testing.T) {
+	conn := &fakeSmbConn{files: map[string]string{"Calibre/metadata.db": "SQLITE"}}
+	r := &RemoteFolder{client: &smbReader{conn: conn, share: "books"}, folder: "Calibre"}
+	if err := r.Connect(time.Second); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	var buf bytes.Buffer
+	if n, err := r.ReadFile("metadata.db", &buf); err != nil || n != 6 || buf.String() != "SQLITE" {
+		t.Fatalf("unexpected read: %d %q %v", n, buf.String(), err)
+	}
+	if _, err := r.ReadFile("missing", &buf); err == nil {
+		t.Fatalf("expected missing file error")
+	}
+	_ = r.Disconnect()
+	if got := strings.Join(conn.calls, ","); got != "connect,tree:books,untree:books,disconnect" {
+		t.Fatalf("unexpected calls: %s", got)
+	}
+
+	conn = &fakeSmbConn{treeErr: errors.New("bad share")}
+	if err := (&smbReader{conn: conn, share: "nope"}).Connect(time.Second); err == nil || !strings.Contains(err.Error(), "nope") {
+		t.Fatalf("expected share error, got %v", err)
+	}
+	if conn.calls[len(conn.calls)-1] != "disconnect" {
+		t.Fatalf("connection not closed after share error: %v", conn.calls)
+	}
+}
@@ -0,0 +1,110 @@
+package bookmanager
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/bjw-s-labs/bookshift/pkg/sharefs"
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+type BookManagerBook struct {
+	book      ImportedBook
+	file      BookFile
+	filePath  string
+	subFolder string
+	fileName  string
+
+	library sharefs.FolderAPI
+}
+
+// NewBookManagerBook copies the file at filePath, relative to the library
+// folder, under its own name. With keepFolderStructure the book keeps the
+// folder it has in the library.
+func NewBookManagerBook(book ImportedBook, file BookFile, filePath string, keepFolderStructure bool, library sharefs.FolderAPI) *BookManagerBook {
+	b := &BookManagerBook{
+		book:     book,
+		file:     file,
+		filePath: filePath,
+		fileName: util.SafeFileName(path.Base(filePath)),
+		library:  library,
+	}
+	if dir := path.Dir(filePath); keepFolderStructure && dir != "." {
+		b.subFolder = filepath.FromSlash(dir)
+	}
+	return b
+}
+
+// FileName returns the local file name of the book.
+func (b *BookManagerBook) FileName() string {
+	return b.fileName
+}
+
+func (b *BookManagerBook) Download(dstFolder string, overwriteExistingFile bool) error {
+	if b.subFolder != "" {
+		// The folder comes from the server, never write outside the target folder
+		if !filepath.IsLocal(b.subFolder) {
+			return fmt.Errorf("invalid book folder on book manager: %s", b.file.Path)
+		}
+		dstFolder = filepath.Join(dstFolder, b.subFolder)
+	}
+	dstPath := filepath.Join(dstFolder, b.fileName)
+
+	// Create folder structure if required
+	if _, err := os.Stat(dstFolder); os.IsNotExist(err) {
+		if util.DryRun {
+			slog.Info("[dry-run] Would create local folder", "folder", dstFolder)
+			return nil
+		}
+		slog.Info("Creating local folder", "folder", dstFolder)
+		if err := os.MkdirAll(dstFolder, 0755); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("Copying book from book manager library", "library", b.library.Location(), "id", b.book.ID, "author", b.book.Author, "title", b.book.Title, "destination", dstPath)
+
+	// Check if the file already exists
+	_, err := os.Stat(dstPath)
+	if !os.IsNotExist(err) {
+		if !overwriteExistingFile {
+			slog.Warn("File already exists, skipping download", "file", dstPath)
+			return nil
+		}
+
+		slog.Info("Overwriting existing file", "file", dstPath)
+	}
+
+	// Copy the file
+	if util.DryRun {
+		slog.Info("[dry-run] Would copy book", "id", b.book.ID, "file", b.filePath, "destination", dstPath)
+		return nil
+	}
+	tmpFile, err := os.CreateTemp(dstFolder, "bookshift-")
+	if err != nil {
+		return err
+	}
+	defer tmpFile.Close()
+
+	slog.Debug("Downloading to temporary file", "file", tmpFile.Name())
+	writer := util.NewFileWriter(tmpFile, b.file.Size, true)
+	if _, err := b.library.ReadFile(b.filePath, writer); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+
+	if err := tmpFile.Sync(); err != nil { // ensure data flushed before rename
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	if err := os.Rename(tmpFile.Name(), dstPath); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+
+	slog.Info("Successfully downloaded file", "filename", b.fileName)
+	return nil
+}
@@ -0,0 +1,90 @@
+package bookmanager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bjw-s-labs/bookshift/pkg/sharefs"
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// TestBookManagerBook_Download copies the file under its own name and keeps library folders on request.
+func TestBookManagerBook_Download(t *testing.T) {
+	lib := &sharefs.LocalFolder{Folder: newTestLibrary(t, map[string]string{"Frank Herbert/Dune (1965)/Dune.epub": "DUNE"})}
+	ib := ImportedBook{ID: "1", Author: "Frank Herbert", Title: "Dune"}
+	file := BookFile{Path: "/books/Frank Herbert/Dune (1965)/Dune.epub", Size: 4}
+	dst := t.TempDir()
+
+	b := NewBookManagerBook(ib, file, "Frank Herbert/Dune (1965)/Dune.epub", false, lib)
+	if b.FileName() != util.SafeFileName("Dune.epub") {
+		t.Fatalf("unexpected file name %q", b.FileName())
+	}
+	if err := b.Download(dst, false); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dst, b.FileName())); err != nil || string(data) != "DUNE" {
+		t.Fatalf("unexpected file: %q %v", data, err)
+	}
+
+	b = NewBookManagerBook(ib, file, "Frank Herbert/Dune (1965)/Dune.epub", true, lib)
+	if err := b.Download(dst, false); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	keptPath := filepath.Join(dst, "Frank Herbert", "Dune (1965)", b.FileName())
+	if _, err := os.Stat(keptPath); err != nil {
+		t.Fatalf("expected library folder: %v", err)
+	}
+
+	// Existing files are skipped unless overwriting
+	if err := os.WriteFile(keptPath, []byte("OLD"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Download(dst, false); err != nil {
+		t.Fatalf("skip: %v", err)
+	}
+	if data, _ := os.ReadFile(keptPath); string(data) != "OLD" {
+		t.Fatalf("existing file overwritten: %q", data)
+	}
+	if err := b.Download(dst, true); err != nil {
+		t.Fatalf("overwrite: %v", err)
+	}
+	if data, _ := os.ReadFile(keptPath); string(data) != "DUNE" {
+		t.Fatalf("existing file not overwritten: %q", data)
+	}
+
+	// Failed copies leave no temp files behind
+	if err := NewBookManagerBook(ib, file, "missing.epub", false, lib).Download(dst, false); err == nil {
+		t.Fatalf("expected copy error")
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dst, "bookshift-*")); len(matches) != 0 {
+		t.Fatalf("temp files left behind: %v", matches)
+	}
+}
+
+// TestBookManagerBook_InvalidFolder refuses to write outside the target folder.
+func TestBookManagerBook_InvalidFolder(t *testing.T) {
+	b := NewBookManagerBook(ImportedBook{ID: "1"}, BookFile{Path: "/x"}, "../../etc/passwd", true, &sharefs.LocalFolder{Folder: t.TempDir()})
+	if err := b.Download(t.TempDir(), false); err == nil {
+		t.Fatalf("expected invalid folder error")
+	}
+}
+
+// TestBookManagerBook_DryRun copies nothing.
+func TestBookManagerBook_DryRun(t *testing.T) {
+	old := util.DryRun
+	t.Cleanup(func() { util.DryRun = old })
+	util.DryRun = true
+
+	lib := &sharefs.LocalFolder{Folder: newTestLibrary(t, map[string]string{"Author/Dune.epub": "DUNE"})}
+	dst := t.TempDir()
+	for _, keep := range []bool{false, true} {
+		b := NewBookManagerBook(ImportedBook{ID: "1"}, BookFile{Path: "/books/Author/Dune.epub"}, "Author/Dune.epub", keep, lib)
+		if err := b.Download(dst, false); err != nil {
+			t.Fatalf("dry-run download: %v", err)
+		}
+	}
+	if entries, _ := os.ReadDir(dst); len(entries) != 0 {
+		t.Fatalf("dry-run wrote files: %v", entries)
+	}
+}
@@ -0,0 +1,154 @@
+package bookmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// BookManagerAPI is the minimal contract used by the syncer, implemented for
+// Readarr and LazyLibrarian. It enables injecting a fake in tests.
+type BookManagerAPI interface {
+	Connect(ctx context.Context, timeout time.Duration) error
+	Disconnect() error
+	ImportedBooks(since time.Time) ([]ImportedBook, error)
+	Host() string
+}
+
+// ImportedBook is a book the server imported into its library, with the
+// paths of its files as seen by the server.
+type ImportedBook struct {
+	ID     string
+	Author string
+	Title  string
+	Files  []BookFile
+}
+
+// BookFile is a single file of an imported book. Size is 0 when the server
+// does not report it.
+type BookFile struct {
+	Path string
+	Size int64
+}
+
+// Package-level errors
+var (
+	ErrBookManagerDisconnected = fmt.Errorf("not connected to the book manager")
+)
+
+// NewBookManagerClient returns the client for the configured server type.
+func NewBookManagerClient(cfg *config.BookManagerConfig) (BookManagerAPI, error) {
+	api, err := newAPIClient(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	var apiKey string
+	if cfg.APIKey != nil {
+		apiKey = string(*cfg.APIKey)
+	}
+	switch cfg.Server {
+	case "readarr":
+		api.header.Set("X-Api-Key", apiKey)
+		return &ReadarrClient{api: api}, nil
+	case "lazylibrarian":
+		return &LazyLibrarianClient{api: api, apiKey: apiKey}, nil
+	default:
+		return nil, fmt.Errorf("unsupported book manager: %s", cfg.Server)
+	}
+}
+
+// apiClient sends requests relative to the server's base URL.
+type apiClient struct {
+	baseURL *url.URL
+	header  http.Header
+
+	// ctx bounds every request of the session
+	ctx    context.Context
+	client *http.Client
+}
+
+func newAPIClient(baseURL string) (*apiClient, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid book manager url %s: %w", baseURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid book manager url %s: scheme must be http or https", baseURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	return &apiClient{baseURL: u, header: http.Header{}}, nil
+}
+
+func (a *apiClient) connect(ctx context.Context, timeout time.Duration) {
+	a.ctx = ctx
+	a.client = util.NewHTTPClient(timeout)
+}
+
+func (a *apiClient) disconnect() {
+	if a.client != nil {
+		a.client.CloseIdleConnections()
+		a.client = nil
+	}
+}
+
+// getJSON requests a path relative to the base URL and decodes the JSON
+// response into v.
+func (a *apiClient) getJSON(p string, query url.Values, v any) error {
+	if a.client == nil {
+		return ErrBookManagerDisconnected
+	}
+
+	u := *a.baseURL
+	u.Path = path.Join(a.baseURL.Path, p)
+	if query != nil {
+		u.RawQuery = query.Encode()
+	}
+	req, err := http.NewRequestWithContext(a.ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	for k, v := range a.header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected response: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (a *apiClient) host() string {
+	return a.baseURL.Host
+}
+
+// RelativePath maps a file path on the server to a path relative to
+// remotePath, the server's library folder. Windows paths are accepted. It
+// reports false for files outside remotePath.
+func RelativePath(serverPath string, remotePath string) (string, bool) {
+	clean := func(p string) string {
+		return path.Clean("/" + strings.ReplaceAll(p, `\`, "/"))
+	}
+	file, root := clean(serverPath), clean(remotePath)
+	if root == "/" {
+		return strings.TrimPrefix(file, "/"), file != "/"
+	}
+	rel, ok := strings.CutPrefix(file, root+"/")
+	return rel, ok && rel != ""
+}
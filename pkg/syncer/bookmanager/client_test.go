@@ -0,0 +1,53 @@
+package bookmanager
+
+import (
+	"testing"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+// TestNewBookManagerClient builds the client matching the server type and rejects invalid URLs.
+func TestNewBookManagerClient(t *testing.T) {
+	if c, err := NewBookManagerClient(&config.BookManagerConfig{Server: "readarr", URL: "http://readarr.local:8787/"}); err != nil || c.Host() != "readarr.local:8787" {
+		t.Fatalf("unexpected client: %v %v", c, err)
+	} else if _, ok := c.(*ReadarrClient); !ok {
+		t.Fatalf("expected Readarr client, got %T", c)
+	}
+	if c, err := NewBookManagerClient(&config.BookManagerConfig{Server: "lazylibrarian", URL: "https://ll.local"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if _, ok := c.(*LazyLibrarianClient); !ok {
+		t.Fatalf("expected LazyLibrarian client, got %T", c)
+	}
+
+	for _, u := range []string{"ftp://readarr.local", "://bad"} {
+		if _, err := NewBookManagerClient(&config.BookManagerConfig{Server: "readarr", URL: u}); err == nil {
+			t.Fatalf("expected invalid url error for %q", u)
+		}
+	}
+	if _, err := NewBookManagerClient(&config.BookManagerConfig{Server: "calibre", URL: "http://x"}); err == nil {
+		t.Fatalf("expected unsupported server error")
+	}
+}
+
+// TestRelativePath maps server paths below the remote path, including Windows paths.
+func TestRelativePath(t *testing.T) {
+	cases := []struct {
+		serverPath, remotePath, want string
+		ok                           bool
+	}{
+		{"/books/Frank Herbert/Dune/Dune.epub", "/books", "Frank Herbert/Dune/Dune.epub", true},
+		{"/books/Dune.epub", "/books/", "Dune.epub", true},
+		{`E:\eBooks\Jane Austen\Emma.epub`, `E:\eBooks`, "Jane Austen/Emma.epub", true},
+		{"/books/Dune.epub", "/", "books/Dune.epub", true},
+		{"/booksellers/Dune.epub", "/books", "", false},
+		{"/books/../etc/passwd", "/books", "", false},
+		{"/books", "/books", "", false},
+		{"/downloads/Dune.epub", "/books", "", false},
+	}
+	for _, c := range cases {
+		got, ok := RelativePath(c.serverPath, c.remotePath)
+		if ok != c.ok || (ok && got != c.want) {
+			t.Fatalf("RelativePath(%q, %q) = %q, %v", c.serverPath, c.remotePath, got, ok)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package bookmanager
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+)
+
+// LazyLibrarianClient talks to the LazyLibrarian API (/api?cmd=...),
+// authenticating with the apikey query parameter.
+type LazyLibrarianClient struct {
+	api    *apiClient
+	apiKey string
+}
+
+// lazyLibrarianDateLayout is the layout of history dates, in server time.
+const lazyLibrarianDateLayout = "2006-01-02 15:04:05"
+
+// Connect prepares the HTTP client and verifies the API key. The timeout bounds
+// connecting and waiting for responses, not the transfer of books.
+func (c *LazyLibrarianClient) Connect(ctx context.Context, timeout time.Duration) error {
+	slog.Debug("Initiating LazyLibrarian connection", "host", c.Host())
+
+	c.api.connect(ctx, timeout)
+	var version map[string]any
+	if err := c.call("getVersion", &version); err != nil {
+		c.api.disconnect()
+		return fmt.Errorf("could not connect to LazyLibrarian: %w", err)
+	}
+	return nil
+}
+
+func (c *LazyLibrarianClient) Disconnect() error {
+	slog.Debug("Disconnecting LazyLibrarian connection", "host", c.Host())
+	c.api.disconnect()
+	return nil
+}
+
+// ImportedBooks returns the books processed since the given time, most
+// recent first. LazyLibrarian keeps a single file per book and does not
+// report its size.
+func (c *LazyLibrarianClient) ImportedBooks(since time.Time) ([]ImportedBook, error) {
+	var history []struct {
+		BookID  string `json:"BookID"`
+		NZBdate string `json:"NZBdate"`
+		Status  string `json:"Status"`
+	}
+	if err := c.call("getHistory", &history); err != nil {
+		return nil, fmt.Errorf("failed to list history: %w", err)
+	}
+
+	var ids []string
+	seen := map[string]bool{}
+	for _, h := range history {
+		if h.Status != "Processed" || h.BookID == "" || seen[h.BookID] {
+			continue
+		}
+		date, err := time.ParseInLocation(lazyLibrarianDateLayout, h.NZBdate, time.Local)
+		if err != nil {
+			slog.Debug("Skipping LazyLibrarian history entry with invalid date", "id", h.BookID, "date", h.NZBdate)
+			continue
+		}
+		if date.Before(since) {
+			continue
+		}
+		seen[h.BookID] = true
+		ids = append(ids, h.BookID)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	// The book list holds the file of each book
+	var all []struct {
+		BookID     string `json:"BookID"`
+		BookName   string `json:"BookName"`
+		AuthorName string `json:"AuthorName"`
+		BookFile   string `json:"BookFile"`
+	}
+	if err := c.call("getAllBooks", &all); err != nil {
+		return nil, fmt.Errorf("failed to list books: %w", err)
+	}
+	byID := map[string]ImportedBook{}
+	for _, b := range all {
+		book := ImportedBook{ID: b.BookID, Author: b.AuthorName, Title: b.BookName}
+		if b.BookFile != "" {
+			book.Files = []BookFile{{Path: b.BookFile}}
+		}
+		byID[b.BookID] = book
+	}
+
+	books := make([]ImportedBook, 0, len(ids))
+	for _, id := range ids {
+		book, ok := byID[id]
+		if !ok {
+			slog.Debug("Skipping LazyLibrarian book that is no longer in the library", "id", id)
+			continue
+		}
+		books = append(books, book)
+	}
+	return books, nil
+}
+
+func (c *LazyLibrarianClient) Host() string {
+	return c.api.host()
+}
+
+// call runs an API command and decodes its JSON result into v.
+func (c *LazyLibrarianClient) call(cmd string, v any) error {
+	return c.api.getJSON("/api", url.Values{"apikey": {c.apiKey}, "cmd": {cmd}}, v)
+}
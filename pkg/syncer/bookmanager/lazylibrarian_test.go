@@ -0,0 +1,68 @@
+package bookmanager
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/go-playground/sensitive"
+)
+
+func newTestLazyLibrarianClient(t *testing.T, url string, apiKey string) BookManagerAPI {
+	t.Helper()
+	key := sensitive.String(apiKey)
+	c, err := NewBookManagerClient(&config.BookManagerConfig{Server: "lazylibrarian", URL: url, APIKey: &key})
+	if err != nil {
+		t.Fatalf("client: %v", err)
+	}
+	return c
+}
+
+// TestLazyLibrarianClient_ImportedBooks returns recently processed books with their file.
+func TestLazyLibrarianClient_ImportedBooks(t *testing.T) {
+	srv := newLazyLibrarianServer(t)
+	c := newTestLazyLibrarianClient(t, srv.URL, testAPIKey)
+	if err := c.Connect(context.Background(), time.Second); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer c.Disconnect()
+
+	books, err := c.ImportedBooks(time.Now().Add(-48 * time.Hour))
+	if err != nil {
+		t.Fatalf("imported books: %v", err)
+	}
+	if len(books) != 2 || books[0].ID != "10" || books[1].ID != "11" {
+		t.Fatalf("unexpected books: %+v", books)
+	}
+	if books[0].Title != "Emma" || books[0].Author != "Jane Austen" || len(books[0].Files) != 1 || books[0].Files[0].Path != "/library/Jane Austen/Emma/Emma - Jane Austen.epub" {
+		t.Fatalf("unexpected book: %+v", books[0])
+	}
+
+	// Nothing processed recently skips the book list
+	srv.requests = nil
+	if books, err := c.ImportedBooks(time.Now()); err != nil || books != nil {
+		t.Fatalf("expected no books, got %+v %v", books, err)
+	}
+	for _, r := range srv.requests {
+		if strings.Contains(r, "cmd=getAllBooks") {
+			t.Fatalf("unexpected book list: %v", srv.requests)
+		}
+	}
+}
+
+// TestLazyLibrarianClient_Errors covers an invalid API key and requests while disconnected.
+func TestLazyLibrarianClient_Errors(t *testing.T) {
+	srv := newLazyLibrarianServer(t)
+	c := newTestLazyLibrarianClient(t, srv.URL, "wrong")
+	if err := c.Connect(context.Background(), time.Second); err == nil || !strings.Contains(err.Error(), "could not connect to LazyLibrarian") {
+		t.Fatalf("expected invalid key error, got %v", err)
+	}
+	if _, err := c.ImportedBooks(time.Time{}); err == nil || !strings.Contains(err.Error(), ErrBookManagerDisconnected.Error()) {
+		t.Fatalf("expected disconnected error, got %v", err)
+	}
+	if c.Host() == "" {
+		t.Fatalf("expected host")
+	}
+}
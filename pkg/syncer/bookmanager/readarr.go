@@ -0,0 +1,126 @@
+package bookmanager
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ReadarrClient talks to the Readarr REST API (/api/v1), authenticating with
+// the X-Api-Key header.
+type ReadarrClient struct {
+	api *apiClient
+}
+
+// readarrPageSize is the number of history records requested per call.
+var readarrPageSize = 50
+
+// readarrHistoryPage mirrors the subset of a Readarr history page that is used.
+type readarrHistoryPage struct {
+	TotalRecords int `json:"totalRecords"`
+	Records      []struct {
+		BookID    int       `json:"bookId"`
+		Date      time.Time `json:"date"`
+		EventType string    `json:"eventType"`
+		Book      struct {
+			Title string `json:"title"`
+		} `json:"book"`
+		Author struct {
+			AuthorName string `json:"authorName"`
+		} `json:"author"`
+	} `json:"records"`
+}
+
+// Connect prepares the HTTP client and verifies the API key. The timeout bounds
+// connecting and waiting for responses, not the transfer of books.
+func (c *ReadarrClient) Connect(ctx context.Context, timeout time.Duration) error {
+	slog.Debug("Initiating Readarr connection", "host", c.Host())
+
+	c.api.connect(ctx, timeout)
+	var status struct {
+		Version string `json:"version"`
+	}
+	if err := c.api.getJSON("/api/v1/system/status", nil, &status); err != nil {
+		c.api.disconnect()
+		return fmt.Errorf("could not connect to Readarr: %w", err)
+	}
+	slog.Debug("Connected to Readarr", "host", c.Host(), "version", status.Version)
+	return nil
+}
+
+func (c *ReadarrClient) Disconnect() error {
+	slog.Debug("Disconnecting Readarr connection", "host", c.Host())
+	c.api.disconnect()
+	return nil
+}
+
+// ImportedBooks returns the books with a file imported since the given time,
+// most recent first, together with their current files.
+func (c *ReadarrClient) ImportedBooks(since time.Time) ([]ImportedBook, error) {
+	var books []ImportedBook
+	index := map[int]int{}
+
+	// History is sorted by date, so paging stops at the first older record
+	done := false
+	for page := 1; !done; page++ {
+		var history readarrHistoryPage
+		query := url.Values{
+			"page":          {strconv.Itoa(page)},
+			"pageSize":      {strconv.Itoa(readarrPageSize)},
+			"sortKey":       {"date"},
+			"sortDirection": {"descending"},
+			"includeBook":   {"true"},
+			"includeAuthor": {"true"},
+		}
+		if err := c.api.getJSON("/api/v1/history", query, &history); err != nil {
+			return nil, fmt.Errorf("failed to list history: %w", err)
+		}
+		for _, r := range history.Records {
+			if r.Date.Before(since) {
+				done = true
+				break
+			}
+			if r.EventType != "bookFileImported" {
+				continue
+			}
+			if _, ok := index[r.BookID]; ok {
+				continue
+			}
+			index[r.BookID] = len(books)
+			books = append(books, ImportedBook{ID: strconv.Itoa(r.BookID), Author: r.Author.AuthorName, Title: r.Book.Title})
+		}
+		if len(history.Records) < readarrPageSize || page*readarrPageSize >= history.TotalRecords {
+			done = true
+		}
+	}
+	if len(books) == 0 {
+		return nil, nil
+	}
+
+	// Look up the current files of all books at once
+	query := url.Values{}
+	for _, b := range books {
+		query.Add("bookId", b.ID)
+	}
+	var files []struct {
+		BookID int    `json:"bookId"`
+		Path   string `json:"path"`
+		Size   int64  `json:"size"`
+	}
+	if err := c.api.getJSON("/api/v1/bookfile", query, &files); err != nil {
+		return nil, fmt.Errorf("failed to list book files: %w", err)
+	}
+	for _, f := range files {
+		if i, ok := index[f.BookID]; ok {
+			books[i].Files = append(books[i].Files, BookFile{Path: f.Path, Size: f.Size})
+		}
+	}
+	return books, nil
+}
+
+func (c *ReadarrClient) Host() string {
+	return c.api.host()
+}
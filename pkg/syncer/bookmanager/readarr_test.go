@@ -0,0 +1,89 @@
+package bookmanager
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/go-playground/sensitive"
+)
+
+func newTestReadarrClient(t *testing.T, url string, apiKey string) BookManagerAPI {
+	t.Helper()
+	key := sensitive.String(apiKey)
+	c, err := NewBookManagerClient(&config.BookManagerConfig{Server: "readarr", URL: url, APIKey: &key})
+	if err != nil {
+		t.Fatalf("client: %v", err)
+	}
+	return c
+}
+
+// TestReadarrClient_ImportedBooks pages through the history until it reaches older records.
+func TestReadarrClient_ImportedBooks(t *testing.T) {
+	old := readarrPageSize
+	t.Cleanup(func() { readarrPageSize = old })
+	readarrPageSize = 2
+
+	srv := newReadarrServer(t)
+	c := newTestReadarrClient(t, srv.URL, testAPIKey)
+	if err := c.Connect(context.Background(), time.Second); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer c.Disconnect()
+
+	books, err := c.ImportedBooks(time.Now().Add(-48 * time.Hour))
+	if err != nil {
+		t.Fatalf("imported books: %v", err)
+	}
+	if len(books) != 2 || books[0].ID != "1" || books[1].ID != "2" {
+		t.Fatalf("unexpected books: %+v", books)
+	}
+	if books[0].Title != "Dune" || books[0].Author != "Frank Herbert" || len(books[0].Files) != 2 || books[0].Files[1].Size != 4 {
+		t.Fatalf("unexpected book: %+v", books[0])
+	}
+	if books[1].Files[0].Path != "/downloads/Dune Messiah.epub" {
+		t.Fatalf("unexpected files: %+v", books[1].Files)
+	}
+
+	// The history stops at the third page, which holds an older record
+	var pages, bookFiles []string
+	for _, r := range srv.requests {
+		if strings.HasPrefix(r, "/api/v1/history?") {
+			pages = append(pages, r)
+		}
+		if strings.HasPrefix(r, "/api/v1/bookfile?") {
+			bookFiles = append(bookFiles, r)
+		}
+	}
+	if len(pages) != 3 || len(bookFiles) != 1 || !strings.Contains(bookFiles[0], "bookId=1&bookId=2") {
+		t.Fatalf("unexpected requests: %v", srv.requests)
+	}
+
+	// Nothing imported recently skips the file lookup
+	srv.requests = nil
+	if books, err := c.ImportedBooks(time.Now()); err != nil || books != nil {
+		t.Fatalf("expected no books, got %+v %v", books, err)
+	}
+	for _, r := range srv.requests {
+		if strings.HasPrefix(r, "/api/v1/bookfile") {
+			t.Fatalf("unexpected book file lookup: %v", srv.requests)
+		}
+	}
+}
+
+// TestReadarrClient_Errors covers an invalid API key and requests while disconnected.
+func TestReadarrClient_Errors(t *testing.T) {
+	srv := newReadarrServer(t)
+	c := newTestReadarrClient(t, srv.URL, "wrong")
+	if err := c.Connect(context.Background(), time.Second); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("expected unauthorized error, got %v", err)
+	}
+	if _, err := c.ImportedBooks(time.Time{}); err == nil || !strings.Contains(err.Error(), ErrBookManagerDisconnected.Error()) {
+		t.Fatalf("expected disconnected error, got %v", err)
+	}
+	if c.Host() == "" {
+		t.Fatalf("expected host")
+	}
+}
@@ -0,0 +1,150 @@
+package bookmanager
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// defaultMaxAgeDays limits how far back the import history is read.
+const defaultMaxAgeDays = 30
+
+// bookManagerState is persisted between runs to remember delivered books.
+type bookManagerState struct {
+	Delivered []string `json:"delivered"`
+}
+
+type BookManagerSyncer struct {
+	config *config.BookManagerConfig
+}
+
+func NewBookManagerSyncer(managerConfig *config.BookManagerConfig) *BookManagerSyncer {
+	return &BookManagerSyncer{
+		config: managerConfig,
+	}
+}
+
+func (s *BookManagerSyncer) Run(targetFolder string, validExtensions []string, overwriteExistingFiles bool) error {
+	return s.RunContext(context.Background(), targetFolder, validExtensions, overwriteExistingFiles)
+}
+
+func (s *BookManagerSyncer) RunContext(ctx context.Context, targetFolder string, validExtensions []string, overwriteExistingFiles bool) (err error) {
+	if s.config.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(s.config.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	// Load the books delivered during previous runs
+	statePath := s.config.StateFile
+	if statePath == "" {
+		statePath = util.DefaultStatePath(targetFolder, s.config.Server, s.config.URL)
+	}
+	var state bookManagerState
+	if err := util.LoadState(statePath, &state); err != nil {
+		return fmt.Errorf("could not load book manager state from %s: %w", statePath, err)
+	}
+	delivered := map[string]bool{}
+	for _, id := range state.Delivered {
+		delivered[id] = true
+	}
+
+	// Connect to the book manager
+	managerClient, err := newBookManagerClient(s.config)
+	if err != nil {
+		return err
+	}
+	if err := bookManagerConnect(ctx, managerClient, 60*time.Second); err != nil {
+		return fmt.Errorf("could not connect to book manager %s: %w", s.config.URL, err)
+	}
+	defer managerClient.Disconnect()
+
+	maxAgeDays := s.config.MaxAgeDays
+	if !(maxAgeDays > 0) {
+		maxAgeDays = defaultMaxAgeDays
+	}
+	books, err := bookManagerBooks(managerClient, time.Now().AddDate(0, 0, -maxAgeDays))
+	if err != nil {
+		return fmt.Errorf("could not list imported books on book manager %s: %w", s.config.URL, err)
+	}
+	slog.Info("Found recently imported books", "host", managerClient.Host(), "count", len(books))
+
+	var pending []ImportedBook
+	for _, b := range books {
+		if delivered[b.ID] {
+			slog.Debug("Skipping previously delivered book", "id", b.ID, "title", b.Title)
+			continue
+		}
+		pending = append(pending, b)
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	// Connect to the library holding the book files
+	library, err := newLibraryFolder(s.config)
+	if err != nil {
+		return err
+	}
+	if err := libraryConnect(library, 60*time.Second); err != nil {
+		return fmt.Errorf("could not connect to book manager library %s: %w", library.Location(), err)
+	}
+	defer library.Disconnect()
+
+	// The state is saved even when a later book fails
+	defer func() {
+		if saveErr := util.SaveState(statePath, &state); saveErr != nil && err == nil {
+			err = fmt.Errorf("could not save book manager state to %s: %w", statePath, saveErr)
+		}
+	}()
+
+	for _, b := range pending {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		// A book is only delivered once all of its matching files are copied
+		copied, complete := 0, true
+		for _, f := range b.Files {
+			extension := path.Ext(strings.ReplaceAll(f.Path, `\`, "/"))
+			if len(validExtensions) > 0 && !slices.ContainsFunc(validExtensions, func(e string) bool { return strings.EqualFold(e, extension) }) {
+				slog.Debug("Skipping book file with invalid extension", "id", b.ID, "file", f.Path)
+				continue
+			}
+			filePath, ok := RelativePath(f.Path, s.config.RemotePath)
+			if !ok {
+				slog.Warn("Skipping book file outside of the remote path", "id", b.ID, "file", f.Path, "remote_path", s.config.RemotePath)
+				complete = false
+				continue
+			}
+
+			book := NewBookManagerBook(b, f, filePath, s.config.KeepFolderStructure, library)
+			if err := bookManagerDownload(book, targetFolder, overwriteExistingFiles); err != nil {
+				return err
+			}
+			copied++
+		}
+		if copied == 0 || !complete {
+			slog.Debug("Book not delivered", "id", b.ID, "title", b.Title, "copied", copied)
+			continue
+		}
+
+		state.Delivered = append(state.Delivered, b.ID)
+	}
+
+	return nil
+}
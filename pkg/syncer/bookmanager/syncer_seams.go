@@ -0,0 +1,32 @@
+// syncer_seams.go: Test seams for the book manager syncer.
+//
+// This file defines small function variables that production code uses by default,
+// but tests can override to substitute fakes. Keeping these seams in a separate
+// file keeps the main implementation clean while preserving simple overrides in tests.
+package bookmanager
+
+import (
+	"context"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/bjw-s-labs/bookshift/pkg/sharefs"
+)
+
+// test hooks (seams) for dependency injection in tests
+var (
+	newBookManagerClient = func(cfg *config.BookManagerConfig) (BookManagerAPI, error) {
+		return NewBookManagerClient(cfg)
+	}
+	bookManagerConnect = func(ctx context.Context, c BookManagerAPI, timeout time.Duration) error {
+		return c.Connect(ctx, timeout)
+	}
+	bookManagerBooks = func(c BookManagerAPI, since time.Time) ([]ImportedBook, error) { return c.ImportedBooks(since) }
+	newLibraryFolder = func(cfg *config.BookManagerConfig) (sharefs.FolderAPI, error) {
+		return sharefs.NewFolder(cfg.Folder, cfg.Share)
+	}
+	libraryConnect      = func(c sharefs.FolderAPI, timeout time.Duration) error { return c.Connect(timeout) }
+	bookManagerDownload = func(b *BookManagerBook, dst string, overwrite bool) error {
+		return b.Download(dst, overwrite)
+	}
+)
@@ -0,0 +1,182 @@
+package bookmanager
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/bjw-s-labs/bookshift/pkg/sharefs"
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+	"github.com/go-playground/sensitive"
+)
+
+func withFakeManager(t *testing.T, f *fakeManager) {
+	t.Helper()
+	orig := newBookManagerClient
+	t.Cleanup(func() { newBookManagerClient = orig })
+	newBookManagerClient = func(cfg *config.BookManagerConfig) (BookManagerAPI, error) { return f, nil }
+}
+
+// TestBookManagerSyncer_Run_EndToEnd copies books imported by a Readarr stand-in and skips books delivered before.
+func TestBookManagerSyncer_Run_EndToEnd(t *testing.T) {
+	srv := newReadarrServer(t)
+	key := sensitive.String(testAPIKey)
+	library := newTestLibrary(t, map[string]string{
+		"Frank Herbert/Dune (1965)/Dune - Frank Herbert.epub": "DUNE",
+		"Frank Herbert/Dune (1965)/Dune - Frank Herbert.pdf":  "PDF!",
+	})
+	cfg := &config.BookManagerConfig{Server: "readarr", URL: srv.URL, APIKey: &key, RemotePath: "/books", Folder: library, MaxAgeDays: 2}
+
+	dst := t.TempDir()
+	epub := filepath.Join(dst, util.SafeFileName("Dune - Frank Herbert.epub"))
+	if err := NewBookManagerSyncer(cfg).Run(dst, []string{".EPUB"}, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if data, err := os.ReadFile(epub); err != nil || string(data) != "DUNE" {
+		t.Fatalf("unexpected file: %q %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, util.SafeFileName("Dune - Frank Herbert.pdf"))); !os.IsNotExist(err) {
+		t.Fatalf("file with invalid extension was copied")
+	}
+
+	// Only the book with all files in the library is delivered
+	var state bookManagerState
+	if err := util.LoadState(util.DefaultStatePath(dst, "readarr", srv.URL), &state); err != nil || strings.Join(state.Delivered, ",") != "1" {
+		t.Fatalf("unexpected state: %+v %v", state, err)
+	}
+
+	// The state remembers the delivery, even after the file is removed
+	_ = os.Remove(epub)
+	if err := NewBookManagerSyncer(cfg).Run(dst, []string{".epub"}, false); err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	if _, err := os.Stat(epub); !os.IsNotExist(err) {
+		t.Fatalf("previously delivered book copied again")
+	}
+}
+
+// TestBookManagerSyncer_Run_LazyLibrarian maps the Windows paths of a LazyLibrarian stand-in and keeps library folders.
+func TestBookManagerSyncer_Run_LazyLibrarian(t *testing.T) {
+	srv := newLazyLibrarianServer(t)
+	key := sensitive.String(testAPIKey)
+	library := newTestLibrary(t, map[string]string{"Jane Austen/Persuasion/Persuasion.epub": "PERS"})
+	cfg := &config.BookManagerConfig{Server: "lazylibrarian", URL: srv.URL, APIKey: &key, RemotePath: `E:\eBooks`, Folder: library, MaxAgeDays: 2, KeepFolderStructure: true, StateFile: filepath.Join(t.TempDir(), "state.json")}
+
+	dst := t.TempDir()
+	if err := NewBookManagerSyncer(cfg).Run(dst, nil, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dst, "Jane Austen", "Persuasion", util.SafeFileName("Persuasion.epub"))); err != nil || string(data) != "PERS" {
+		t.Fatalf("unexpected file: %q %v", data, err)
+	}
+	var state bookManagerState
+	if err := util.LoadState(cfg.StateFile, &state); err != nil || strings.Join(state.Delivered, ",") != "11" {
+		t.Fatalf("unexpected state: %+v %v", state, err)
+	}
+}
+
+// TestBookManagerSyncer_Run_MaxAge defaults the history window to 30 days and skips the library when nothing is new.
+func TestBookManagerSyncer_Run_MaxAge(t *testing.T) {
+	f := &fakeManager{}
+	withFakeManager(t, f)
+	orig := newLibraryFolder
+	t.Cleanup(func() { newLibraryFolder = orig })
+	newLibraryFolder = func(cfg *config.BookManagerConfig) (sharefs.FolderAPI, error) {
+		t.Fatalf("library opened without books to deliver")
+		return nil, nil
+	}
+
+	cfg := &config.BookManagerConfig{Server: "readarr", URL: "http://readarr.local", RemotePath: "/books", Folder: "/missing"}
+	if err := NewBookManagerSyncer(cfg).Run(t.TempDir(), nil, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if age := time.Since(f.since); age < 29*24*time.Hour || age > 31*24*time.Hour {
+		t.Fatalf("unexpected history window: %v", age)
+	}
+}
+
+// TestBookManagerSyncer_Run_StateAfterFailure keeps books delivered before a failure.
+func TestBookManagerSyncer_Run_StateAfterFailure(t *testing.T) {
+	f := &fakeManager{books: []ImportedBook{
+		{ID: "1", Files: []BookFile{{Path: "/books/a.epub"}}},
+		{ID: "2", Files: []BookFile{{Path: "/books/b.epub"}}},
+	}}
+	withFakeManager(t, f)
+	library := newTestLibrary(t, map[string]string{"a.epub": "A"})
+	cfg := &config.BookManagerConfig{Server: "readarr", URL: "http://readarr.local", RemotePath: "/books", Folder: library, StateFile: filepath.Join(t.TempDir(), "state.json")}
+
+	if err := NewBookManagerSyncer(cfg).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected copy error")
+	}
+	var state bookManagerState
+	if err := util.LoadState(cfg.StateFile, &state); err != nil || strings.Join(state.Delivered, ",") != "1" {
+		t.Fatalf("unexpected state: %+v %v", state, err)
+	}
+}
+
+// TestBookManagerSyncer_Run_Errors covers state, connection, listing and library errors.
+func TestBookManagerSyncer_Run_Errors(t *testing.T) {
+	f := &fakeManager{connectErr: errors.New("refused")}
+	withFakeManager(t, f)
+	cfg := &config.BookManagerConfig{Server: "readarr", URL: "http://readarr.local", RemotePath: "/books", Folder: filepath.Join(t.TempDir(), "missing")}
+
+	if err := NewBookManagerSyncer(cfg).Run(t.TempDir(), nil, false); err == nil || !strings.Contains(err.Error(), "could not connect to book manager") {
+		t.Fatalf("expected connect error, got %v", err)
+	}
+	f.connectErr, f.booksErr = nil, errors.New("boom")
+	if err := NewBookManagerSyncer(cfg).Run(t.TempDir(), nil, false); err == nil || !strings.Contains(err.Error(), "could not list imported books") {
+		t.Fatalf("expected listing error, got %v", err)
+	}
+	f.booksErr, f.books = nil, []ImportedBook{{ID: "1", Files: []BookFile{{Path: "/books/a.epub"}}}}
+	if err := NewBookManagerSyncer(cfg).Run(t.TempDir(), nil, false); err == nil || !strings.Contains(err.Error(), "could not connect to book manager library") {
+		t.Fatalf("expected library error, got %v", err)
+	}
+	cfg.Share = &config.Source{Type: "s3", Config: &config.S3Config{}}
+	if err := NewBookManagerSyncer(cfg).Run(t.TempDir(), nil, false); err == nil || !strings.Contains(err.Error(), "unsupported share type") {
+		t.Fatalf("expected share error, got %v", err)
+	}
+
+	dst := t.TempDir()
+	cfg.StateFile = filepath.Join(dst, "state.json")
+	if err := os.WriteFile(cfg.StateFile, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewBookManagerSyncer(cfg).Run(dst, nil, false); err == nil || !strings.Contains(err.Error(), "could not load") {
+		t.Fatalf("expected state error, got %v", err)
+	}
+}
+
+// TestBookManagerSyncer_Run_Cancelled stops before and between books.
+func TestBookManagerSyncer_Run_Cancelled(t *testing.T) {
+	f := &fakeManager{books: []ImportedBook{
+		{ID: "1", Files: []BookFile{{Path: "/books/a.epub"}}},
+		{ID: "2", Files: []BookFile{{Path: "/books/b.epub"}}},
+	}}
+	withFakeManager(t, f)
+	cfg := &config.BookManagerConfig{Server: "readarr", URL: "http://readarr.local", RemotePath: "/books", Folder: t.TempDir()}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := NewBookManagerSyncer(cfg).RunContext(ctx, t.TempDir(), nil, false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	orig := bookManagerDownload
+	t.Cleanup(func() { bookManagerDownload = orig })
+	downloads := 0
+	bookManagerDownload = func(b *BookManagerBook, dst string, overwrite bool) error {
+		downloads++
+		cancel()
+		return nil
+	}
+	if err := NewBookManagerSyncer(cfg).RunContext(ctx, t.TempDir(), nil, false); !errors.Is(err, context.Canceled) || downloads != 1 {
+		t.Fatalf("expected cancellation after one book, got %v after %d", err, downloads)
+	}
+}
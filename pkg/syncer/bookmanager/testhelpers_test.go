@@ -0,0 +1,190 @@
+package bookmanager
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testAPIKey = "api-key"
+
+// recordingServer wraps an httptest server and records every request it receives.
+type recordingServer struct {
+	URL string
+
+	mu       sync.Mutex
+	requests []string
+}
+
+func (s *recordingServer) record(r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r.URL.Path+"?"+r.URL.RawQuery)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// newReadarrServer is an httptest Readarr stand-in. Its history holds two
+// imports of book 1, an import of book 2, a grab of book 3 and an import of
+// book 4 that is older than two days. History pages hold two records.
+func newReadarrServer(t *testing.T) *recordingServer {
+	t.Helper()
+	s := &recordingServer{}
+	now := time.Now().UTC()
+
+	record := func(bookID int, age time.Duration, eventType string, title string) map[string]any {
+		return map[string]any{
+			"bookId": bookID, "date": now.Add(-age).Format(time.RFC3339), "eventType": eventType,
+			"book": map[string]any{"title": title}, "author": map[string]any{"authorName": "Frank Herbert"},
+		}
+	}
+	history := []map[string]any{
+		record(1, time.Hour, "bookFileImported", "Dune"),
+		record(3, 2*time.Hour, "grabbed", "Children of Dune"),
+		record(2, 3*time.Hour, "bookFileImported", "Dune Messiah"),
+		record(1, 4*time.Hour, "bookFileImported", "Dune"),
+		record(4, 72*time.Hour, "bookFileImported", "God Emperor of Dune"),
+	}
+	files := []map[string]any{
+		{"bookId": 1, "path": "/books/Frank Herbert/Dune (1965)/Dune - Frank Herbert.epub", "size": 4},
+		{"bookId": 1, "path": "/books/Frank Herbert/Dune (1965)/Dune - Frank Herbert.pdf", "size": 4},
+		{"bookId": 2, "path": "/downloads/Dune Messiah.epub", "size": 4},
+		{"bookId": 4, "path": "/books/Frank Herbert/God Emperor of Dune (1981)/God Emperor of Dune.epub", "size": 4},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		s.record(r)
+		if r.Header.Get("X-Api-Key") != testAPIKey {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/api/v1/system/status":
+			writeJSON(w, map[string]any{"version": "0.4.18"})
+		case "/api/v1/history":
+			q := r.URL.Query()
+			if q.Get("sortKey") != "date" || q.Get("sortDirection") != "descending" {
+				http.Error(w, "unsorted history", http.StatusBadRequest)
+				return
+			}
+			page, _ := strconv.Atoi(q.Get("page"))
+			size, _ := strconv.Atoi(q.Get("pageSize"))
+			start := min((page-1)*size, len(history))
+			end := min(start+size, len(history))
+			writeJSON(w, map[string]any{"page": page, "pageSize": size, "totalRecords": len(history), "records": history[start:end]})
+		case "/api/v1/bookfile":
+			ids := map[string]bool{}
+			for _, id := range r.URL.Query()["bookId"] {
+				ids[id] = true
+			}
+			var selected []map[string]any
+			for _, f := range files {
+				if ids[strconv.Itoa(f["bookId"].(int))] {
+					selected = append(selected, f)
+				}
+			}
+			writeJSON(w, selected)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	s.URL = srv.URL
+	return s
+}
+
+// newLazyLibrarianServer is an httptest LazyLibrarian stand-in. Its history
+// holds processed books 10 and 11 (on a Windows library), a snatched book 12
+// and a processed book 13 that is older than two days.
+func newLazyLibrarianServer(t *testing.T) *recordingServer {
+	t.Helper()
+	s := &recordingServer{}
+	date := func(age time.Duration) string { return time.Now().Add(-age).Format(lazyLibrarianDateLayout) }
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+		s.record(r)
+		q := r.URL.Query()
+		if q.Get("apikey") != testAPIKey {
+			writeJSON(w, "Invalid apikey")
+			return
+		}
+
+		switch q.Get("cmd") {
+		case "getVersion":
+			writeJSON(w, map[string]any{"current_version": "1.7.2"})
+		case "getHistory":
+			writeJSON(w, []map[string]any{
+				{"BookID": "10", "NZBdate": date(time.Hour), "Status": "Processed"},
+				{"BookID": "12", "NZBdate": date(time.Hour), "Status": "Snatched"},
+				{"BookID": "11", "NZBdate": date(2 * time.Hour), "Status": "Processed"},
+				{"BookID": "10", "NZBdate": date(3 * time.Hour), "Status": "Processed"},
+				{"BookID": "14", "NZBdate": "yesterday", "Status": "Processed"},
+				{"BookID": "13", "NZBdate": date(72 * time.Hour), "Status": "Processed"},
+				{"BookID": "15", "NZBdate": date(time.Hour), "Status": "Processed"},
+			})
+		case "getAllBooks":
+			writeJSON(w, []map[string]any{
+				{"BookID": "10", "BookName": "Emma", "AuthorName": "Jane Austen", "BookFile": "/library/Jane Austen/Emma/Emma - Jane Austen.epub"},
+				{"BookID": "11", "BookName": "Persuasion", "AuthorName": "Jane Austen", "BookFile": `E:\eBooks\Jane Austen\Persuasion\Persuasion.epub`},
+				{"BookID": "12", "BookName": "Sanditon", "AuthorName": "Jane Austen", "BookFile": ""},
+				{"BookID": "13", "BookName": "Lady Susan", "AuthorName": "Jane Austen", "BookFile": "/library/Jane Austen/Lady Susan/Lady Susan.epub"},
+			})
+		default:
+			writeJSON(w, "Unknown command")
+		}
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	s.URL = srv.URL
+	return s
+}
+
+// fakeManager is an in-memory BookManagerAPI used by syncer tests.
+type fakeManager struct {
+	books      []ImportedBook
+	connectErr error
+	booksErr   error
+
+	since time.Time
+}
+
+func (f *fakeManager) Connect(ctx context.Context, timeout time.Duration) error { return f.connectErr }
+func (f *fakeManager) Disconnect() error                                        { return nil }
+func (f *fakeManager) ImportedBooks(since time.Time) ([]ImportedBook, error) {
+	f.since = since
+	return f.books, f.booksErr
+}
+func (f *fakeManager) Host() string { return "manager.example" }
+
+// newTestLibrary writes files, keyed by slash-separated path, into a
+// temporary library folder.
+func newTestLibrary(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
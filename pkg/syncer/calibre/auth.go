@@ -0,0 +1,154 @@
+package calibre
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// authTransport answers HTTP Basic and Digest challenges. The Calibre content
+// server uses Digest authentication over plain HTTP and Basic over HTTPS by
+// default, so the scheme is picked from the server's challenge.
+type authTransport struct {
+	username string
+	password string
+	base     http.RoundTripper
+
+	mu        sync.Mutex
+	challenge map[string]string // last Digest challenge, nil for Basic
+	basic     bool
+	nc        int
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Requests never carry a body, so they can safely be replayed after a challenge
+	resp, err := t.base.RoundTrip(t.authorize(req))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	header := resp.Header.Get("WWW-Authenticate")
+	scheme, params, _ := strings.Cut(header, " ")
+	t.mu.Lock()
+	switch strings.ToLower(scheme) {
+	case "digest":
+		t.challenge = parseChallenge(params)
+		t.basic = false
+		t.nc = 0
+	case "basic":
+		t.challenge = nil
+		t.basic = true
+	default:
+		t.mu.Unlock()
+		return resp, nil
+	}
+	t.mu.Unlock()
+
+	resp.Body.Close()
+	return t.base.RoundTrip(t.authorize(req))
+}
+
+// authorize returns a copy of req carrying credentials for the last seen challenge.
+func (t *authTransport) authorize(req *http.Request) *http.Request {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.basic && t.challenge == nil {
+		return req
+	}
+	r := req.Clone(req.Context())
+	if t.basic {
+		r.SetBasicAuth(t.username, t.password)
+		return r
+	}
+
+	t.nc++
+	r.Header.Set("Authorization", digestAuthorization(t.challenge, t.username, t.password, r.Method, r.URL.RequestURI(), t.nc, newCnonce()))
+	return r
+}
+
+// digestAuthorization computes an RFC 2617 Digest Authorization header value using MD5.
+func digestAuthorization(ch map[string]string, username, password, method, uri string, nc int, cnonce string) string {
+	ha1 := md5Hex(username + ":" + ch["realm"] + ":" + password)
+	ha2 := md5Hex(method + ":" + uri)
+
+	qop := ""
+	for _, q := range strings.Split(ch["qop"], ",") {
+		if strings.TrimSpace(q) == "auth" {
+			qop = "auth"
+		}
+	}
+
+	ncValue := fmt.Sprintf("%08x", nc)
+	var response string
+	if qop != "" {
+		response = md5Hex(strings.Join([]string{ha1, ch["nonce"], ncValue, cnonce, qop, ha2}, ":"))
+	} else {
+		response = md5Hex(ha1 + ":" + ch["nonce"] + ":" + ha2)
+	}
+
+	parts := []string{
+		fmt.Sprintf(`username="%s"`, username),
+		fmt.Sprintf(`realm="%s"`, ch["realm"]),
+		fmt.Sprintf(`nonce="%s"`, ch["nonce"]),
+		fmt.Sprintf(`uri="%s"`, uri),
+		fmt.Sprintf(`response="%s"`, response),
+		"algorithm=MD5",
+	}
+	if qop != "" {
+		parts = append(parts, "qop="+qop, "nc="+ncValue, fmt.Sprintf(`cnonce="%s"`, cnonce))
+	}
+	if opaque, ok := ch["opaque"]; ok {
+		parts = append(parts, fmt.Sprintf(`opaque="%s"`, opaque))
+	}
+	return "Digest " + strings.Join(parts, ", ")
+}
+
+// parseChallenge splits the parameters of a WWW-Authenticate header into a map.
+func parseChallenge(s string) map[string]string {
+	params := map[string]string{}
+	for s != "" {
+		s = strings.TrimLeft(s, " ,")
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, s = rest[1:], ""
+			} else {
+				value, s = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			value, s, _ = strings.Cut(rest, ",")
+		}
+		params[key] = strings.TrimSpace(value)
+	}
+	return params
+}
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func newCnonce() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// CloseIdleConnections forwards to the wrapped transport so http.Client.CloseIdleConnections keeps working.
+func (t *authTransport) CloseIdleConnections() {
+	if ci, ok := t.base.(interface{ CloseIdleConnections() }); ok {
+		ci.CloseIdleConnections()
+	}
+}
@@ -0,0 +1,27 @@
+package calibre
+
+import (
+	"testing"
+)
+
+// TestDigestAuthorization_RFC2617 checks the response against the example in RFC 2617 section 3.5.
+func TestDigestAuthorization_RFC2617(t *testing.T) {
+	ch := parseChallenge(`realm="testrealm@host.com", qop="auth,auth-int", nonce="dcd98b7102dd2f0e8b11d0f600bfb0c093", opaque="5ccc069c403ebaf9f0171e9517f40e41"`)
+	header := digestAuthorization(ch, "Mufasa", "Circle Of Life", "GET", "/dir/index.html", 1, "0a4f113b")
+
+	got := parseChallenge(header[len("Digest "):])
+	if got["response"] != "6629fae49393a05397450978507c4ef1" {
+		t.Fatalf("unexpected response: %s", header)
+	}
+	if got["nc"] != "00000001" || got["qop"] != "auth" || got["opaque"] != "5ccc069c403ebaf9f0171e9517f40e41" {
+		t.Fatalf("unexpected header: %s", header)
+	}
+}
+
+// TestParseChallenge covers quoted values containing commas and unquoted values.
+func TestParseChallenge(t *testing.T) {
+	got := parseChallenge(`realm="a, b", nonce=xyz, qop="auth"`)
+	if got["realm"] != "a, b" || got["nonce"] != "xyz" || got["qop"] != "auth" {
+		t.Fatalf("unexpected params: %v", got)
+	}
+}
@@ -0,0 +1,153 @@
+package calibre
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// defaultFilenameTemplate is used when no filename_template is configured.
+const defaultFilenameTemplate = "{title} - {author}"
+
+type CalibreBook struct {
+	info     CalibreBookInfo
+	format   string
+	fileName string
+
+	calibreClient CalibreAPI
+}
+
+// NewCalibreBook picks the first format from preferredFormats that the book is
+// available in. Formats must also match validExtensions when those are set.
+// It returns nil when no format matches.
+func NewCalibreBook(info CalibreBookInfo, preferredFormats []string, validExtensions []string, filenameTemplate string, conn CalibreAPI) *CalibreBook {
+	available := map[string]bool{}
+	for _, f := range info.Formats {
+		available[strings.ToUpper(f)] = true
+	}
+
+	lowerExts := make([]string, 0, len(validExtensions))
+	for _, e := range validExtensions {
+		lowerExts = append(lowerExts, strings.ToLower(e))
+	}
+
+	for _, f := range preferredFormats {
+		format := strings.ToUpper(strings.TrimPrefix(f, "."))
+		if !available[format] {
+			continue
+		}
+		extension := "." + strings.ToLower(format)
+		if len(lowerExts) > 0 && !slices.Contains(lowerExts, extension) {
+			continue
+		}
+		return &CalibreBook{
+			info:          info,
+			format:        format,
+			fileName:      util.SafeFileName(RenderFilename(filenameTemplate, info) + extension),
+			calibreClient: conn,
+		}
+	}
+	return nil
+}
+
+// RenderFilename expands the {title}, {author}, {authors}, {series}, {series_index}
+// and {id} placeholders of template with the book's metadata.
+func RenderFilename(template string, info CalibreBookInfo) string {
+	if template == "" {
+		template = defaultFilenameTemplate
+	}
+
+	var author string
+	if len(info.Authors) > 0 {
+		author = info.Authors[0]
+	}
+	var seriesIndex string
+	if info.Series != "" {
+		seriesIndex = strconv.FormatFloat(info.SeriesIndex, 'f', -1, 64)
+	}
+
+	name := strings.NewReplacer(
+		"{title}", info.Title,
+		"{author}", author,
+		"{authors}", strings.Join(info.Authors, " & "),
+		"{series}", info.Series,
+		"{series_index}", seriesIndex,
+		"{id}", strconv.Itoa(info.ID),
+	).Replace(template)
+
+	// Drop separators left dangling by empty placeholders
+	name = strings.Trim(strings.TrimSpace(name), "-_ ")
+	if name == "" {
+		name = strconv.Itoa(info.ID)
+	}
+	return strings.ReplaceAll(name, "/", " ")
+}
+
+// FileName returns the local file name of the book.
+func (b *CalibreBook) FileName() string {
+	return b.fileName
+}
+
+func (b *CalibreBook) Download(dstFolder string, overwriteExistingFile bool) error {
+	dstPath := filepath.Join(dstFolder, b.fileName)
+
+	// Create folder structure if required
+	if _, err := os.Stat(dstFolder); os.IsNotExist(err) {
+		if util.DryRun {
+			slog.Info("[dry-run] Would create local folder", "folder", dstFolder)
+			return nil
+		}
+		slog.Info("Creating local folder", "folder", dstFolder)
+		if err := os.MkdirAll(dstFolder, 0755); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("Downloading book from Calibre server", "host", b.calibreClient.Host(), "id", b.info.ID, "title", b.info.Title, "format", b.format, "destination", dstPath)
+
+	// Check if the file already exists
+	_, err := os.Stat(dstPath)
+	if !os.IsNotExist(err) {
+		if !overwriteExistingFile {
+			slog.Warn("File already exists, skipping download", "file", dstPath)
+			return nil
+		}
+
+		slog.Info("Overwriting existing file", "file", dstPath)
+	}
+
+	// Download the file
+	if util.DryRun {
+		slog.Info("[dry-run] Would download book", "id", b.info.ID, "format", b.format, "destination", dstPath)
+		return nil
+	}
+	tmpFile, err := os.CreateTemp(dstFolder, "bookshift-")
+	if err != nil {
+		return err
+	}
+	defer tmpFile.Close()
+
+	slog.Debug("Downloading to temporary file", "file", tmpFile.Name())
+	writer := util.NewFileWriter(tmpFile, b.info.FormatSizes[b.format], true)
+	if _, err := b.calibreClient.ReadFile(b.format, b.info.ID, writer); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+
+	if err := tmpFile.Sync(); err != nil { // ensure data flushed before rename
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	if err := os.Rename(tmpFile.Name(), dstPath); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+
+	slog.Info("Successfully downloaded file", "filename", b.fileName)
+	return nil
+}
@@ -0,0 +1,99 @@
+package calibre
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// TestNewCalibreBook_FormatPreference verifies preference order and the valid extension filter.
+func TestNewCalibreBook_FormatPreference(t *testing.T) {
+	info := CalibreBookInfo{ID: 2, Title: "Dune", Authors: []string{"Frank Herbert"}, Formats: []string{"EPUB", "KEPUB", "PDF"}}
+
+	if b := NewCalibreBook(info, []string{"kepub", "epub"}, nil, "", nil); b == nil || b.format != "KEPUB" {
+		t.Fatalf("expected KEPUB, got %+v", b)
+	}
+	if b := NewCalibreBook(info, []string{".mobi", ".EPUB"}, nil, "", nil); b == nil || b.format != "EPUB" {
+		t.Fatalf("expected EPUB, got %+v", b)
+	}
+	if b := NewCalibreBook(info, []string{"kepub", "pdf"}, []string{".pdf"}, "", nil); b == nil || b.format != "PDF" {
+		t.Fatalf("expected PDF due to valid extensions, got %+v", b)
+	}
+	if b := NewCalibreBook(info, []string{"mobi"}, nil, "", nil); b != nil {
+		t.Fatalf("expected nil, got %+v", b)
+	}
+}
+
+// TestRenderFilename covers placeholders and empty values.
+func TestRenderFilename(t *testing.T) {
+	info := CalibreBookInfo{ID: 7, Title: "Dune Messiah", Authors: []string{"Frank Herbert", "Co Author"}, Series: "Dune", SeriesIndex: 2}
+	tests := map[string]string{
+		"":                                  "Dune Messiah - Frank Herbert",
+		"{series} {series_index} - {title}": "Dune 2 - Dune Messiah",
+		"{authors}/{title} ({id})":          "Frank Herbert & Co Author Dune Messiah (7)",
+		"{title} - {series}":                "Dune Messiah - Dune",
+	}
+	for tmpl, want := range tests {
+		if got := RenderFilename(tmpl, info); got != want {
+			t.Fatalf("RenderFilename(%q)=%q, want %q", tmpl, got, want)
+		}
+	}
+	if got := RenderFilename("{title} - {series}", CalibreBookInfo{ID: 3, Title: "Solo"}); got != "Solo" {
+		t.Fatalf("dangling separator not trimmed: %q", got)
+	}
+	if got := RenderFilename("{series}", CalibreBookInfo{ID: 3}); got != "3" {
+		t.Fatalf("empty name must fall back to the id: %q", got)
+	}
+}
+
+// TestCalibreBook_Download writes the book using its metadata based name.
+func TestCalibreBook_Download(t *testing.T) {
+	fake := &recCalibre{files: map[string]string{"EPUB/1": "DATA"}}
+	info := CalibreBookInfo{ID: 1, Title: "The Hobbit", Authors: []string{"Tolkien"}, Formats: []string{"EPUB"}}
+	b := NewCalibreBook(info, []string{"epub"}, nil, "", fake)
+
+	dst := filepath.Join(t.TempDir(), "books")
+	if err := b.Download(dst, false); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if got, err := os.ReadFile(filepath.Join(dst, "the-hobbit-tolkien.epub")); err != nil || string(got) != "DATA" {
+		t.Fatalf("read: %v %q", err, string(got))
+	}
+
+	fake.files["EPUB/1"] = "NEW"
+	if err := b.Download(dst, false); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dst, "the-hobbit-tolkien.epub")); string(got) != "DATA" {
+		t.Fatalf("existing file must be kept")
+	}
+}
+
+// TestCalibreBook_Download_ErrorAndDryRun ensures failures leave nothing behind and dry-run writes nothing.
+func TestCalibreBook_Download_ErrorAndDryRun(t *testing.T) {
+	fake := &recCalibre{readErr: errors.New("boom")}
+	info := CalibreBookInfo{ID: 1, Title: "X", Formats: []string{"EPUB"}}
+	b := NewCalibreBook(info, []string{"epub"}, nil, "", fake)
+
+	dst := t.TempDir()
+	if err := b.Download(dst, true); err == nil {
+		t.Fatalf("expected error")
+	}
+	if entries, _ := os.ReadDir(dst); len(entries) != 0 {
+		t.Fatalf("expected no leftovers")
+	}
+
+	old := util.DryRun
+	t.Cleanup(func() { util.DryRun = old })
+	util.DryRun = true
+	fake.readErr = nil
+	if err := b.Download(dst, true); err != nil {
+		t.Fatalf("dry-run: %v", err)
+	}
+	if entries, _ := os.ReadDir(dst); len(entries) != 0 {
+		t.Fatalf("dry-run must not write files")
+	}
+}
@@ -0,0 +1,271 @@
+package calibre
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+	"github.com/go-playground/sensitive"
+)
+
+// CalibreAPI is the minimal contract used by the syncer and book logic.
+// It enables injecting a fake in tests.
+type CalibreAPI interface {
+	Connect(ctx context.Context, timeout time.Duration) error
+	Disconnect() error
+	Search(query string) ([]int, error)
+	Books(ids []int) ([]CalibreBookInfo, error)
+	ReadFile(format string, id int, w io.Writer) (int64, error)
+	Host() string
+}
+
+// CalibreBookInfo is the subset of the book metadata returned by /ajax/books that is used.
+type CalibreBookInfo struct {
+	ID          int
+	Title       string
+	Authors     []string
+	Series      string
+	SeriesIndex float64
+	Formats     []string
+	FormatSizes map[string]int64
+}
+
+type CalibreClient struct {
+	baseURL   *url.URL
+	username  string
+	password  *sensitive.String
+	libraryID string
+
+	// ctx bounds every request of the session
+	ctx    context.Context
+	client *http.Client
+}
+
+// Package-level errors
+var (
+	ErrCalibreDisconnected = fmt.Errorf("not connected to the Calibre content server")
+)
+
+// searchPageSize is the number of book ids requested per /ajax/search call.
+var searchPageSize = 500
+
+// searchResult mirrors the subset of the /ajax/search response that is used.
+type searchResult struct {
+	TotalNum int   `json:"total_num"`
+	BookIDs  []int `json:"book_ids"`
+}
+
+// bookMetadata mirrors the subset of a single /ajax/books entry that is used.
+type bookMetadata struct {
+	Title          string   `json:"title"`
+	Authors        []string `json:"authors"`
+	Series         string   `json:"series"`
+	SeriesIndex    float64  `json:"series_index"`
+	Formats        []string `json:"formats"`
+	FormatMetadata map[string]struct {
+		Size int64 `json:"size"`
+	} `json:"format_metadata"`
+}
+
+func NewCalibreClient(baseURL string, username string, password *sensitive.String, libraryID string) (*CalibreClient, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Calibre url %s: %w", baseURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid Calibre url %s: scheme must be http or https", baseURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	return &CalibreClient{
+		baseURL:   u,
+		username:  username,
+		password:  password,
+		libraryID: libraryID,
+	}, nil
+}
+
+// Connect prepares the HTTP client and verifies the server is reachable with the configured credentials.
+// The timeout bounds connecting and waiting for responses, not the transfer of books.
+func (c *CalibreClient) Connect(ctx context.Context, timeout time.Duration) error {
+	slog.Debug("Initiating Calibre connection", "host", c.Host())
+
+	c.ctx = ctx
+	var transport http.RoundTripper = util.NewHTTPTransport(timeout)
+	if c.username != "" {
+		var password string
+		if c.password != nil {
+			password = string(*c.password)
+		}
+		transport = &authTransport{username: c.username, password: password, base: transport}
+	}
+	c.client = &http.Client{Transport: transport}
+
+	resp, err := c.get("/ajax/library-info", nil)
+	if err != nil {
+		c.client = nil
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		c.client = nil
+		return fmt.Errorf("unexpected response from Calibre server: %s", resp.Status)
+	}
+	return nil
+}
+
+// Disconnect releases idle connections held by the HTTP client.
+func (c *CalibreClient) Disconnect() error {
+	slog.Debug("Disconnecting Calibre connection", "host", c.Host())
+
+	if c.client != nil {
+		c.client.CloseIdleConnections()
+		c.client = nil
+	}
+	return nil
+}
+
+// Search returns the ids of all books matching a Calibre search expression.
+func (c *CalibreClient) Search(query string) ([]int, error) {
+	if c.client == nil {
+		return nil, ErrCalibreDisconnected
+	}
+
+	var ids []int
+	for offset := 0; ; {
+		params := url.Values{
+			"query":  {query},
+			"num":    {strconv.Itoa(searchPageSize)},
+			"offset": {strconv.Itoa(offset)},
+		}
+		var result searchResult
+		if err := c.getJSON(c.libraryPath("/ajax/search"), params, &result); err != nil {
+			return nil, fmt.Errorf("failed to search for %q: %w", query, err)
+		}
+
+		ids = append(ids, result.BookIDs...)
+		offset += len(result.BookIDs)
+		if len(result.BookIDs) == 0 || offset >= result.TotalNum {
+			break
+		}
+	}
+	return ids, nil
+}
+
+// Books returns the metadata of the given books, sorted by id. Unknown ids are skipped.
+func (c *CalibreClient) Books(ids []int) ([]CalibreBookInfo, error) {
+	if c.client == nil {
+		return nil, ErrCalibreDisconnected
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	idList := make([]string, 0, len(ids))
+	for _, id := range ids {
+		idList = append(idList, strconv.Itoa(id))
+	}
+
+	var result map[string]*bookMetadata
+	if err := c.getJSON(c.libraryPath("/ajax/books"), url.Values{"ids": {strings.Join(idList, ",")}}, &result); err != nil {
+		return nil, fmt.Errorf("failed to fetch book metadata: %w", err)
+	}
+
+	books := make([]CalibreBookInfo, 0, len(result))
+	for key, m := range result {
+		id, err := strconv.Atoi(key)
+		if err != nil || m == nil {
+			continue
+		}
+		info := CalibreBookInfo{
+			ID:          id,
+			Title:       m.Title,
+			Authors:     m.Authors,
+			Series:      m.Series,
+			SeriesIndex: m.SeriesIndex,
+			Formats:     m.Formats,
+			FormatSizes: map[string]int64{},
+		}
+		for f, fm := range m.FormatMetadata {
+			info.FormatSizes[strings.ToUpper(f)] = fm.Size
+		}
+		books = append(books, info)
+	}
+	sort.Slice(books, func(i, j int) bool { return books[i].ID < books[j].ID })
+	return books, nil
+}
+
+// ReadFile streams one format of a book to the provided writer.
+func (c *CalibreClient) ReadFile(format string, id int, w io.Writer) (int64, error) {
+	if c.client == nil {
+		return 0, ErrCalibreDisconnected
+	}
+
+	p := path.Join("/get", strings.ToUpper(format), strconv.Itoa(id))
+	if c.libraryID != "" {
+		p = path.Join(p, c.libraryID)
+	}
+	resp, err := c.get(p, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed to download %s of book %d: %s", format, id, resp.Status)
+	}
+	return io.Copy(w, resp.Body)
+}
+
+func (c *CalibreClient) Host() string {
+	return c.baseURL.Host
+}
+
+// libraryPath appends the configured library id to an endpoint that accepts one.
+func (c *CalibreClient) libraryPath(endpoint string) string {
+	if c.libraryID == "" {
+		return endpoint
+	}
+	return path.Join(endpoint, c.libraryID)
+}
+
+// get sends a GET request for a path relative to the base URL.
+func (c *CalibreClient) get(p string, query url.Values) (*http.Response, error) {
+	u := *c.baseURL
+	u.Path = path.Join(c.baseURL.Path, p)
+	if query != nil {
+		u.RawQuery = query.Encode()
+	}
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.client.Do(req)
+}
+
+// getJSON sends a GET request and decodes a JSON response into v.
+func (c *CalibreClient) getJSON(p string, query url.Values, v any) error {
+	resp, err := c.get(p, query)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected response: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,143 @@
+package calibre
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-playground/sensitive"
+)
+
+func testBooks() map[int]fakeBook {
+	return map[int]fakeBook{
+		1: {Title: "The Hobbit", Authors: []string{"J.R.R. Tolkien"}, Tags: []string{"kobo"}, Files: map[string]string{"EPUB": "hobbit-epub", "PDF": "hobbit-pdf"}},
+		2: {Title: "Dune", Authors: []string{"Frank Herbert"}, Series: "Dune", Index: 1, Tags: []string{"kobo", "read"}, Files: map[string]string{"KEPUB": "dune-kepub", "EPUB": "dune-epub"}},
+		3: {Title: "Scan", Authors: []string{"Unknown"}, Tags: []string{"kobo"}, Files: map[string]string{"PDF": "scan"}},
+		4: {Title: "Other", Authors: []string{"Someone"}, Files: map[string]string{"EPUB": "other"}},
+	}
+}
+
+// TestNewCalibreClient_InvalidURL ensures unsupported schemes are rejected.
+func TestNewCalibreClient_InvalidURL(t *testing.T) {
+	if _, err := NewCalibreClient("ftp://calibre", "", nil, ""); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+// TestCalibreClient_NotConnected ensures operations fail before Connect.
+func TestCalibreClient_NotConnected(t *testing.T) {
+	c, _ := NewCalibreClient("http://h", "", nil, "")
+	if _, err := c.Search(""); err != ErrCalibreDisconnected {
+		t.Fatalf("expected ErrCalibreDisconnected, got %v", err)
+	}
+	if _, err := c.Books([]int{1}); err != ErrCalibreDisconnected {
+		t.Fatalf("expected ErrCalibreDisconnected, got %v", err)
+	}
+	if _, err := c.ReadFile("EPUB", 1, &bytes.Buffer{}); err != ErrCalibreDisconnected {
+		t.Fatalf("expected ErrCalibreDisconnected, got %v", err)
+	}
+}
+
+// TestCalibreClient_SearchBooksRead exercises paging, metadata and downloads with Digest authentication.
+func TestCalibreClient_SearchBooksRead(t *testing.T) {
+	old := searchPageSize
+	t.Cleanup(func() { searchPageSize = old })
+	searchPageSize = 2
+
+	fake, url := newFakeCalibre(t, testBooks())
+	fake.user, fake.pass = "alice", "pw"
+	pw := sensitive.String("pw")
+	c, _ := NewCalibreClient(url, "alice", &pw, "Books")
+	if err := c.Connect(context.Background(), 5*time.Second); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Disconnect() })
+
+	ids, err := c.Search("tags:kobo")
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(ids) != 3 || fake.searches != 2 {
+		t.Fatalf("unexpected search result %v after %d pages", ids, fake.searches)
+	}
+
+	books, err := c.Books(append(ids, 99))
+	if err != nil {
+		t.Fatalf("books: %v", err)
+	}
+	if len(books) != 3 || books[1].Title != "Dune" || books[1].Series != "Dune" || books[1].FormatSizes["KEPUB"] != 10 {
+		t.Fatalf("unexpected metadata: %+v", books)
+	}
+
+	var buf bytes.Buffer
+	if _, err := c.ReadFile("kepub", 2, &buf); err != nil || buf.String() != "dune-kepub" {
+		t.Fatalf("read: %v %q", err, buf.String())
+	}
+	if _, err := c.ReadFile("MOBI", 2, &buf); err == nil {
+		t.Fatalf("expected error for missing format")
+	}
+}
+
+// TestCalibreClient_BasicAuth ensures Basic challenges are answered too.
+func TestCalibreClient_BasicAuth(t *testing.T) {
+	fake, url := newFakeCalibre(t, testBooks())
+	fake.user, fake.pass, fake.basic = "alice", "pw", true
+	pw := sensitive.String("pw")
+	c, _ := NewCalibreClient(url, "alice", &pw, "")
+	if err := c.Connect(context.Background(), 5*time.Second); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if ids, err := c.Search(""); err != nil || len(ids) != 4 {
+		t.Fatalf("search: %v %v", err, ids)
+	}
+}
+
+// TestCalibreClient_WrongPassword ensures authentication failures surface on Connect.
+func TestCalibreClient_WrongPassword(t *testing.T) {
+	fake, url := newFakeCalibre(t, testBooks())
+	fake.user, fake.pass = "alice", "pw"
+	bad := sensitive.String("nope")
+	c, _ := NewCalibreClient(url, "alice", &bad, "")
+	if err := c.Connect(context.Background(), 5*time.Second); err == nil {
+		t.Fatalf("expected auth error")
+	}
+	anon, _ := NewCalibreClient(url, "", nil, "")
+	if err := anon.Connect(context.Background(), 5*time.Second); err == nil {
+		t.Fatalf("expected auth error without credentials")
+	}
+}
+
+// TestCalibreClient_SlowDownload verifies books may take longer than the
+// connect timeout to download and are stopped by the session context.
+func TestCalibreClient_SlowDownload(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ajax/library-info" {
+			return
+		}
+		for range 3 {
+			_, _ = w.Write([]byte("chunk"))
+			w.(http.Flusher).Flush()
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c, _ := NewCalibreClient(srv.URL, "", nil, "")
+	if err := c.Connect(ctx, 50*time.Millisecond); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := c.ReadFile("EPUB", 1, &buf); err != nil || buf.String() != "chunkchunkchunk" {
+		t.Fatalf("slow download: %q %v", buf.String(), err)
+	}
+
+	cancel()
+	if _, err := c.ReadFile("EPUB", 1, &buf); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+}
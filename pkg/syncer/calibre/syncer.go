@@ -0,0 +1,94 @@
+package calibre
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+// metadataBatchSize is the number of books requested per /ajax/books call.
+const metadataBatchSize = 100
+
+type CalibreSyncer struct {
+	config *config.CalibreConfig
+}
+
+func NewCalibreSyncer(serverConfig *config.CalibreConfig) *CalibreSyncer {
+	return &CalibreSyncer{
+		config: serverConfig,
+	}
+}
+
+func (s *CalibreSyncer) Run(targetFolder string, validExtensions []string, overwriteExistingFiles bool) error {
+	return s.RunContext(context.Background(), targetFolder, validExtensions, overwriteExistingFiles)
+}
+
+func (s *CalibreSyncer) RunContext(ctx context.Context, targetFolder string, validExtensions []string, overwriteExistingFiles bool) error {
+	if s.config.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(s.config.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	// Fall back to the global extension list as format preference
+	formats := s.config.Formats
+	if len(formats) == 0 {
+		formats = validExtensions
+	}
+	if len(formats) == 0 {
+		return fmt.Errorf("no formats configured for Calibre server %s", s.config.URL)
+	}
+
+	// Connect to the Calibre content server
+	calibreClient, err := newCalibreClient(s.config)
+	if err != nil {
+		return err
+	}
+	if err := calibreConnect(ctx, calibreClient, 60*time.Second); err != nil {
+		return fmt.Errorf("could not connect to Calibre server %s: %w", s.config.URL, err)
+	}
+	defer calibreClient.Disconnect()
+
+	// Select the books
+	ids, err := calibreSearch(calibreClient, s.config.Query)
+	if err != nil {
+		return fmt.Errorf("could not search Calibre server %s: %w", s.config.URL, err)
+	}
+	slog.Info("Found matching books on Calibre server", "host", calibreClient.Host(), "query", s.config.Query, "count", len(ids))
+
+	// Download the books, fetching metadata in batches
+	for start := 0; start < len(ids); start += metadataBatchSize {
+		end := min(start+metadataBatchSize, len(ids))
+		books, err := calibreBooks(calibreClient, ids[start:end])
+		if err != nil {
+			return fmt.Errorf("could not fetch metadata from Calibre server %s: %w", s.config.URL, err)
+		}
+
+		for _, info := range books {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+			}
+
+			book := NewCalibreBook(info, formats, validExtensions, s.config.FilenameTemplate, calibreClient)
+			if book == nil {
+				slog.Debug("No preferred format available, skipping book", "id", info.ID, "title", info.Title, "formats", info.Formats)
+				continue
+			}
+			if err := calibreDownload(book, targetFolder, overwriteExistingFiles); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
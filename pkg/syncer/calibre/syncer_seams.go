@@ -0,0 +1,26 @@
+// syncer_seams.go: Test seams for the Calibre syncer.
+//
+// This file defines small function variables that production code uses by default,
+// but tests can override to substitute fakes. Keeping these seams in a separate
+// file keeps the main implementation clean while preserving simple overrides in tests.
+package calibre
+
+import (
+	"context"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+// test hooks (seams) for dependency injection in tests
+var (
+	newCalibreClient = func(cfg *config.CalibreConfig) (CalibreAPI, error) {
+		return NewCalibreClient(cfg.URL, cfg.Username, cfg.Password, cfg.LibraryID)
+	}
+	calibreConnect  = func(ctx context.Context, c CalibreAPI, timeout time.Duration) error { return c.Connect(ctx, timeout) }
+	calibreSearch   = func(c CalibreAPI, query string) ([]int, error) { return c.Search(query) }
+	calibreBooks    = func(c CalibreAPI, ids []int) ([]CalibreBookInfo, error) { return c.Books(ids) }
+	calibreDownload = func(b *CalibreBook, dst string, overwrite bool) error {
+		return b.Download(dst, overwrite)
+	}
+)
@@ -0,0 +1,92 @@
+package calibre
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+// TestCalibreSyncer_Run_EndToEnd selects books by tag and downloads the preferred formats.
+func TestCalibreSyncer_Run_EndToEnd(t *testing.T) {
+	_, url := newFakeCalibre(t, testBooks())
+	cfg := &config.CalibreConfig{URL: url, Query: "tags:kobo", FilenameTemplate: "{author} - {title}"}
+	dst := t.TempDir()
+	if err := NewCalibreSyncer(cfg).Run(dst, []string{".kepub", ".epub"}, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	entries, _ := os.ReadDir(dst)
+	if len(entries) != 2 {
+		t.Fatalf("want 2 books, got %d", len(entries))
+	}
+	for name, want := range map[string]string{
+		"j.r.r.-tolkien-the-hobbit.epub": "hobbit-epub",
+		"frank-herbert-dune.kepub":       "dune-kepub",
+	} {
+		if got, err := os.ReadFile(filepath.Join(dst, name)); err != nil || string(got) != want {
+			t.Fatalf("%s: %v %q", name, err, string(got))
+		}
+	}
+}
+
+// TestCalibreSyncer_Run_FormatsOverride ensures configured formats take precedence over valid extensions order.
+func TestCalibreSyncer_Run_FormatsOverride(t *testing.T) {
+	_, url := newFakeCalibre(t, testBooks())
+	cfg := &config.CalibreConfig{URL: url, Query: "tags:read", Formats: []string{"epub"}}
+	dst := t.TempDir()
+	if err := NewCalibreSyncer(cfg).Run(dst, []string{".kepub", ".epub"}, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "dune-frank-herbert.epub")); err != nil {
+		t.Fatalf("expected epub: %v", err)
+	}
+}
+
+// TestCalibreSyncer_Run_Errors ensures configuration, connect, search and download errors abort the run.
+func TestCalibreSyncer_Run_Errors(t *testing.T) {
+	if err := NewCalibreSyncer(&config.CalibreConfig{URL: "http://h"}).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected error without formats")
+	}
+	if err := NewCalibreSyncer(&config.CalibreConfig{URL: "ftp://h"}).Run(t.TempDir(), []string{".epub"}, false); err == nil {
+		t.Fatalf("expected client error")
+	}
+
+	origConnect := calibreConnect
+	t.Cleanup(func() { calibreConnect = origConnect })
+	calibreConnect = func(ctx context.Context, c CalibreAPI, timeout time.Duration) error { return errors.New("x") }
+	if err := NewCalibreSyncer(&config.CalibreConfig{URL: "http://h"}).Run(t.TempDir(), []string{".epub"}, false); err == nil {
+		t.Fatalf("expected connect error")
+	}
+	calibreConnect = origConnect
+
+	origClient, origSearch, origDownload := newCalibreClient, calibreSearch, calibreDownload
+	t.Cleanup(func() { newCalibreClient, calibreSearch, calibreDownload = origClient, origSearch, origDownload })
+	fake := &recCalibre{ids: []int{1}, books: []CalibreBookInfo{{ID: 1, Title: "A", Formats: []string{"EPUB"}}}}
+	newCalibreClient = func(cfg *config.CalibreConfig) (CalibreAPI, error) { return fake, nil }
+
+	calibreSearch = func(c CalibreAPI, query string) ([]int, error) { return nil, errors.New("search") }
+	if err := NewCalibreSyncer(&config.CalibreConfig{URL: "http://h"}).Run(t.TempDir(), []string{".epub"}, false); err == nil {
+		t.Fatalf("expected search error")
+	}
+	calibreSearch = origSearch
+
+	calibreDownload = func(b *CalibreBook, dst string, overwrite bool) error { return errors.New("z") }
+	if err := NewCalibreSyncer(&config.CalibreConfig{URL: "http://h"}).Run(t.TempDir(), []string{".epub"}, false); err == nil {
+		t.Fatalf("expected download error")
+	}
+}
+
+// TestCalibreSyncer_RunContext_Cancel verifies that a canceled context stops the run.
+func TestCalibreSyncer_RunContext_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cfg := &config.CalibreConfig{URL: "http://h"}
+	if err := NewCalibreSyncer(cfg).RunContext(ctx, t.TempDir(), []string{".epub"}, false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled, got %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+package calibre
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeBook is a book served by the fake content server.
+type fakeBook struct {
+	Title   string
+	Authors []string
+	Series  string
+	Index   float64
+	Tags    []string
+	Files   map[string]string // format -> content
+}
+
+// fakeCalibreServer is a minimal Calibre content server. Queries are limited
+// to "tags:<tag>" or empty; authentication uses Digest when a user is set.
+type fakeCalibreServer struct {
+	books    map[int]fakeBook
+	user     string
+	pass     string
+	basic    bool
+	searches int
+}
+
+func newFakeCalibre(t *testing.T, books map[int]fakeBook) (*fakeCalibreServer, string) {
+	t.Helper()
+	f := &fakeCalibreServer{books: books}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv.URL
+}
+
+const testRealm = "calibre"
+const testNonce = "abc123"
+
+func (f *fakeCalibreServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if f.user != "" && !f.authorized(r) {
+		if f.basic {
+			w.Header().Set("WWW-Authenticate", `Basic realm="calibre"`)
+		} else {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Digest realm="%s", nonce="%s", qop="auth", algorithm="MD5"`, testRealm, testNonce))
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.URL.Path == "/ajax/library-info":
+		writeJSON(w, map[string]any{"default_library": "Books", "library_map": map[string]string{"Books": "Books"}})
+	case parts[0] == "ajax" && len(parts) >= 2 && parts[1] == "search":
+		f.search(w, r)
+	case parts[0] == "ajax" && len(parts) >= 2 && parts[1] == "books":
+		f.metadata(w, r)
+	case parts[0] == "get" && len(parts) >= 3:
+		id, _ := strconv.Atoi(parts[2])
+		content, ok := f.books[id].Files[parts[1]]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = io.WriteString(w, content)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeCalibreServer) search(w http.ResponseWriter, r *http.Request) {
+	f.searches++
+	q := r.URL.Query()
+	tag := strings.TrimPrefix(q.Get("query"), "tags:")
+
+	var ids []int
+	for id := 1; id <= 1000; id++ {
+		b, ok := f.books[id]
+		if !ok {
+			continue
+		}
+		if tag == "" || strings.Contains(strings.Join(b.Tags, ","), tag) {
+			ids = append(ids, id)
+		}
+	}
+	offset, _ := strconv.Atoi(q.Get("offset"))
+	num, _ := strconv.Atoi(q.Get("num"))
+	end := min(offset+num, len(ids))
+	offset = min(offset, end)
+	writeJSON(w, map[string]any{"total_num": len(ids), "offset": offset, "num": end - offset, "book_ids": ids[offset:end]})
+}
+
+func (f *fakeCalibreServer) metadata(w http.ResponseWriter, r *http.Request) {
+	out := map[string]any{}
+	for _, s := range strings.Split(r.URL.Query().Get("ids"), ",") {
+		id, _ := strconv.Atoi(s)
+		b, ok := f.books[id]
+		if !ok {
+			out[s] = nil
+			continue
+		}
+		var formats []string
+		sizes := map[string]any{}
+		for format, content := range b.Files {
+			formats = append(formats, format)
+			sizes[strings.ToLower(format)] = map[string]any{"size": len(content)}
+		}
+		out[s] = map[string]any{
+			"title": b.Title, "authors": b.Authors, "series": b.Series, "series_index": b.Index,
+			"formats": formats, "format_metadata": sizes, "tags": b.Tags,
+		}
+	}
+	writeJSON(w, out)
+}
+
+// authorized validates Basic or Digest credentials against the configured user.
+func (f *fakeCalibreServer) authorized(r *http.Request) bool {
+	if f.basic {
+		u, p, ok := r.BasicAuth()
+		return ok && u == f.user && p == f.pass
+	}
+	scheme, params, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	if scheme != "Digest" {
+		return false
+	}
+	got := parseChallenge(params)
+	nc, _ := strconv.ParseInt(got["nc"], 16, 64)
+	want := parseChallenge(strings.TrimPrefix(digestAuthorization(
+		map[string]string{"realm": testRealm, "nonce": testNonce, "qop": "auth"},
+		f.user, f.pass, r.Method, r.URL.RequestURI(), int(nc), got["cnonce"]), "Digest "))
+	return got["uri"] == r.URL.RequestURI() && got["response"] == want["response"]
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// recCalibre implements CalibreAPI in memory.
+type recCalibre struct {
+	ids     []int
+	books   []CalibreBookInfo
+	files   map[string]string // "FORMAT/id" -> content
+	readErr error
+}
+
+func (f *recCalibre) Connect(ctx context.Context, timeout time.Duration) error { return nil }
+func (f *recCalibre) Disconnect() error                                        { return nil }
+func (f *recCalibre) Host() string                                             { return "fake" }
+func (f *recCalibre) Search(query string) ([]int, error)                       { return f.ids, nil }
+func (f *recCalibre) Books(ids []int) ([]CalibreBookInfo, error) {
+	return f.books, nil
+}
+func (f *recCalibre) ReadFile(format string, id int, w io.Writer) (int64, error) {
+	if f.readErr != nil {
+		return 0, f.readErr
+	}
+	s, ok := f.files[format+"/"+strconv.Itoa(id)]
+	if !ok {
+		return 0, errors.New("not found")
+	}
+	n, err := w.Write([]byte(s))
+	return int64(n), err
+}
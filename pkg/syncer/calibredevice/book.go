@@ -0,0 +1,116 @@
+package calibredevice
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+type CalibreDeviceBook struct {
+	lpath     string
+	length    int64
+	subFolder string
+	fileName  string
+}
+
+// NewCalibreDeviceBook names the local file after the file name Calibre
+// picked for the device. With keepFolderStructure the book keeps the folders
+// of its lpath (by default "Author/Title - Author.ext").
+func NewCalibreDeviceBook(lpath string, length int64, keepFolderStructure bool) *CalibreDeviceBook {
+	b := &CalibreDeviceBook{
+		lpath:    lpath,
+		length:   length,
+		fileName: util.SafeFileName(path.Base(lpath)),
+	}
+	if dir := path.Dir(lpath); keepFolderStructure && dir != "." {
+		b.subFolder = filepath.FromSlash(dir)
+	}
+	return b
+}
+
+// FileName returns the local file name of the book.
+func (b *CalibreDeviceBook) FileName() string {
+	return b.fileName
+}
+
+// LocalPath returns the path of the book relative to the target folder.
+func (b *CalibreDeviceBook) LocalPath() (string, error) {
+	if b.subFolder != "" && !filepath.IsLocal(b.subFolder) {
+		// The folder comes from Calibre, never write outside the target folder
+		return "", fmt.Errorf("invalid book folder from Calibre: %s", b.lpath)
+	}
+	return filepath.Join(b.subFolder, b.fileName), nil
+}
+
+// Receive writes the book data read from r. Existing files are kept unless
+// overwriteExistingFile is set; the data is then left unread.
+func (b *CalibreDeviceBook) Receive(dstFolder string, overwriteExistingFile bool, r io.Reader) error {
+	localPath, err := b.LocalPath()
+	if err != nil {
+		return err
+	}
+	dstPath := filepath.Join(dstFolder, localPath)
+	dstFolder = filepath.Dir(dstPath)
+
+	// Create folder structure if required
+	if _, err := os.Stat(dstFolder); os.IsNotExist(err) {
+		if util.DryRun {
+			slog.Info("[dry-run] Would create local folder", "folder", dstFolder)
+			return nil
+		}
+		slog.Info("Creating local folder", "folder", dstFolder)
+		if err := os.MkdirAll(dstFolder, 0755); err != nil {
+			return err
+		}
+	}
+
+	// Check if the file already exists
+	_, err = os.Stat(dstPath)
+	if !os.IsNotExist(err) {
+		if !overwriteExistingFile {
+			slog.Warn("File already exists, skipping download", "file", dstPath)
+			return nil
+		}
+
+		slog.Info("Overwriting existing file", "file", dstPath)
+	}
+
+	// Write the file
+	if util.DryRun {
+		slog.Info("[dry-run] Would receive book", "file", b.lpath, "destination", dstPath)
+		return nil
+	}
+	tmpFile, err := os.CreateTemp(dstFolder, "bookshift-")
+	if err != nil {
+		return err
+	}
+	defer tmpFile.Close()
+
+	slog.Debug("Downloading to temporary file", "file", tmpFile.Name())
+	writer := util.NewFileWriter(tmpFile, b.length, true)
+	n, err := io.Copy(writer, r)
+	if err == nil && n != b.length {
+		err = fmt.Errorf("received %d of %d bytes of %s", n, b.length, b.lpath)
+	}
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+
+	if err := tmpFile.Sync(); err != nil { // ensure data flushed before rename
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	if err := os.Rename(tmpFile.Name(), dstPath); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+
+	slog.Info("Successfully downloaded file", "filename", b.fileName)
+	return nil
+}
@@ -0,0 +1,85 @@
+package calibredevice
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// TestCalibreDeviceBook_Receive writes the book flat or in its lpath folders.
+func TestCalibreDeviceBook_Receive(t *testing.T) {
+	dst := t.TempDir()
+
+	b := NewCalibreDeviceBook("Frank Herbert/Dune - Frank Herbert.epub", 4, false)
+	if err := b.Receive(dst, false, strings.NewReader("DUNE")); err != nil {
+		t.Fatalf("receive: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dst, b.FileName())); err != nil || string(data) != "DUNE" {
+		t.Fatalf("unexpected file: %q %v", data, err)
+	}
+
+	b = NewCalibreDeviceBook("Frank Herbert/Dune - Frank Herbert.epub", 4, true)
+	if err := b.Receive(dst, false, strings.NewReader("DUNE")); err != nil {
+		t.Fatalf("receive: %v", err)
+	}
+	keptPath := filepath.Join(dst, "Frank Herbert", b.FileName())
+	if _, err := os.Stat(keptPath); err != nil {
+		t.Fatalf("expected lpath folder: %v", err)
+	}
+
+	// Existing files are skipped unless overwriting
+	if err := b.Receive(dst, false, strings.NewReader("NEW!")); err != nil {
+		t.Fatalf("skip: %v", err)
+	}
+	if data, _ := os.ReadFile(keptPath); string(data) != "DUNE" {
+		t.Fatalf("existing file overwritten: %q", data)
+	}
+	if err := b.Receive(dst, true, strings.NewReader("NEW!")); err != nil {
+		t.Fatalf("overwrite: %v", err)
+	}
+	if data, _ := os.ReadFile(keptPath); string(data) != "NEW!" {
+		t.Fatalf("existing file not overwritten: %q", data)
+	}
+
+	// Incomplete books leave no files behind
+	if err := NewCalibreDeviceBook("short.epub", 10, false).Receive(dst, false, strings.NewReader("SHORT")); err == nil {
+		t.Fatalf("expected incomplete book error")
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dst, "*short*")); len(matches) != 0 {
+		t.Fatalf("incomplete book written: %v", matches)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dst, "bookshift-*")); len(matches) != 0 {
+		t.Fatalf("temp files left behind: %v", matches)
+	}
+}
+
+// TestCalibreDeviceBook_InvalidFolder refuses to write outside the target folder.
+func TestCalibreDeviceBook_InvalidFolder(t *testing.T) {
+	b := NewCalibreDeviceBook("../../outside.epub", 1, true)
+	if err := b.Receive(t.TempDir(), false, strings.NewReader("X")); err == nil {
+		t.Fatalf("expected invalid folder error")
+	}
+	if p, err := NewCalibreDeviceBook("../../outside.epub", 1, false).LocalPath(); err != nil || p != util.SafeFileName("outside.epub") {
+		t.Fatalf("unexpected flat path: %q %v", p, err)
+	}
+}
+
+// TestCalibreDeviceBook_DryRun writes nothing.
+func TestCalibreDeviceBook_DryRun(t *testing.T) {
+	old := util.DryRun
+	t.Cleanup(func() { util.DryRun = old })
+	util.DryRun = true
+
+	dst := t.TempDir()
+	for _, keep := range []bool{false, true} {
+		if err := NewCalibreDeviceBook("Author/Dune.epub", 4, keep).Receive(dst, false, strings.NewReader("DUNE")); err != nil {
+			t.Fatalf("dry-run receive: %v", err)
+		}
+	}
+	if entries, _ := os.ReadDir(dst); len(entries) != 0 {
+		t.Fatalf("dry-run wrote files: %v", entries)
+	}
+}
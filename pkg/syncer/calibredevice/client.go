@@ -0,0 +1,334 @@
+package calibredevice
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/sensitive"
+)
+
+// CalibreDeviceAPI is the minimal contract used by the syncer. It enables
+// injecting a fake in tests.
+type CalibreDeviceAPI interface {
+	Connect(timeout time.Duration) error
+	Disconnect() error
+	Serve(ctx context.Context, receiver BookReceiver) (int, error)
+	Host() string
+}
+
+// BookReceiver stores the books Calibre sends. Books are identified by their
+// lpath, the path Calibre gives the book on the device.
+type BookReceiver interface {
+	Extensions() []string
+	Space() (free uint64, total uint64, err error)
+	ReceiveBook(lpath string, length int64, r io.Reader) error
+	DeleteBook(lpath string) error
+}
+
+// Package-level errors
+var (
+	ErrCalibreDeviceDisconnected = fmt.Errorf("not connected to Calibre")
+)
+
+// DeviceClient connects to Calibre as a wireless device and answers its
+// requests until Calibre ejects the device, closes the connection or sends
+// no book for the idle time.
+type DeviceClient struct {
+	host       string
+	port       int
+	password   *sensitive.String
+	deviceName string
+	idle       time.Duration
+
+	conn   *streamConn
+	reader *bufio.Reader
+}
+
+func NewDeviceClient(host string, port int, password *sensitive.String, deviceName string, idle time.Duration) *DeviceClient {
+	return &DeviceClient{
+		host:       host,
+		port:       port,
+		password:   password,
+		deviceName: deviceName,
+		idle:       idle,
+	}
+}
+
+func (c *DeviceClient) Connect(timeout time.Duration) error {
+	address := net.JoinHostPort(c.host, strconv.Itoa(c.port))
+	slog.Debug("Initiating Calibre wireless device connection", "address", address)
+
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return err
+	}
+	c.conn = &streamConn{Conn: conn}
+	c.reader = bufio.NewReader(c.conn)
+	return nil
+}
+
+func (c *DeviceClient) Disconnect() error {
+	if c.conn == nil {
+		return nil
+	}
+	slog.Debug("Disconnecting Calibre wireless device connection", "host", c.host)
+	err := c.conn.Close()
+	c.conn, c.reader = nil, nil
+	return err
+}
+
+func (c *DeviceClient) Host() string {
+	return c.host
+}
+
+// Serve answers Calibre's requests and passes the books it sends to the
+// receiver. It returns the number of books received.
+func (c *DeviceClient) Serve(ctx context.Context, receiver BookReceiver) (int, error) {
+	if c.conn == nil {
+		return 0, ErrCalibreDeviceDisconnected
+	}
+	c.conn.ctx = ctx
+	stop := context.AfterFunc(ctx, func() { _ = c.conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	received := 0
+	lastBook := time.Now()
+	for {
+		if err := ctx.Err(); err != nil {
+			return received, err
+		}
+		_ = c.conn.SetReadDeadline(lastBook.Add(c.idle))
+		op, payload, err := readMessage(c.reader)
+		if err != nil {
+			switch {
+			case ctx.Err() != nil:
+				return received, ctx.Err()
+			case errors.Is(err, os.ErrDeadlineExceeded):
+				slog.Info("No more books from Calibre, disconnecting", "idle", c.idle)
+				return received, nil
+			case errors.Is(err, io.EOF):
+				slog.Info("Calibre closed the connection")
+				return received, nil
+			}
+			return received, fmt.Errorf("failed to read from Calibre: %w", err)
+		}
+
+		switch op {
+		case opGetInitializationInfo:
+			err = c.sendInitializationInfo(payload, receiver)
+		case opGetDeviceInformation:
+			err = c.send(opOK, map[string]any{
+				"device_info":    map[string]string{"device_store_uuid": deviceStoreUUID(c.deviceName), "device_name": c.deviceName},
+				"version":        "1",
+				"device_version": "BookShift",
+			})
+		case opSetCalibreDeviceInfo, opSetCalibreDeviceName, opSetLibraryInfo:
+			err = c.send(opOK, map[string]any{})
+		case opTotalSpace, opFreeSpace:
+			err = c.sendSpace(op, receiver)
+		case opGetBookCount:
+			// Books on the device are not reported, Calibre treats it as empty
+			err = c.send(opOK, map[string]any{"count": 0, "willStream": true, "willScan": true})
+		case opSendBooklists, opSendBookMetadata, opBookDone:
+			// Informational, Calibre does not wait for a reply
+		case opNoop:
+			var noop struct {
+				Ejecting bool `json:"ejecting"`
+			}
+			_ = json.Unmarshal(payload, &noop)
+			if err := c.send(opOK, map[string]any{}); err != nil {
+				return received, err
+			}
+			if noop.Ejecting {
+				slog.Info("Calibre ejected the device")
+				return received, nil
+			}
+		case opSendBook:
+			err = c.receiveBook(payload, receiver)
+			if err == nil {
+				received++
+				lastBook = time.Now()
+			}
+		case opDeleteBook:
+			err = c.deleteBooks(payload, receiver)
+		case opGetBookFileSegment, opGetBookMetadata:
+			err = c.send(opError, map[string]string{"message": "not supported by BookShift"})
+		case opDisplayMessage:
+			var message struct {
+				Kind    int    `json:"messageKind"`
+				Message string `json:"message"`
+			}
+			_ = json.Unmarshal(payload, &message)
+			if message.Kind == 1 {
+				return received, fmt.Errorf("calibre rejected the password: %s", message.Message)
+			}
+			slog.Info("Message from Calibre", "message", message.Message)
+		case opCalibreBusy:
+			return received, fmt.Errorf("calibre is busy with another device")
+		default:
+			slog.Debug("Ignoring unknown Calibre request", "opcode", op)
+		}
+		if err != nil {
+			return received, err
+		}
+	}
+}
+
+// sendInitializationInfo answers the first request of a session, telling
+// Calibre what the device supports and proving knowledge of the password.
+func (c *DeviceClient) sendInitializationInfo(payload json.RawMessage, receiver BookReceiver) error {
+	var info struct {
+		Challenge      string `json:"passwordChallenge"`
+		LibraryName    string `json:"currentLibraryName"`
+		CalibreVersion []int  `json:"calibre_version"`
+	}
+	if err := json.Unmarshal(payload, &info); err != nil {
+		return fmt.Errorf("invalid initialization request: %w", err)
+	}
+	slog.Info("Connected to Calibre", "host", c.host, "library", info.LibraryName, "version", info.CalibreVersion)
+
+	var passwordHash string
+	if info.Challenge != "" {
+		var password string
+		if c.password != nil {
+			password = string(*c.password)
+		}
+		sum := sha1.Sum([]byte(password + info.Challenge))
+		passwordHash = hex.EncodeToString(sum[:])
+	}
+
+	return c.send(opOK, map[string]any{
+		"appName":                 "BookShift",
+		"deviceKind":              "BookShift",
+		"deviceName":              c.deviceName,
+		"versionOK":               true,
+		"ccVersionNumber":         1,
+		"acceptedExtensions":      receiver.Extensions(),
+		"cacheUsesLpaths":         true,
+		"canAcceptLibraryInfo":    true,
+		"canDeleteMultipleBooks":  true,
+		"canReceiveBookBinary":    true,
+		"canSendOkToSendbook":     true,
+		"canStreamBooks":          true,
+		"canStreamMetadata":       true,
+		"canUseCachedMetadata":    false,
+		"coverHeight":             240,
+		"maxBookContentPacketLen": 4096,
+		"passwordHash":            passwordHash,
+		"useUuidFileNames":        false,
+	})
+}
+
+func (c *DeviceClient) sendSpace(op int, receiver BookReceiver) error {
+	free, total, err := receiver.Space()
+	if err != nil {
+		return fmt.Errorf("could not determine free space: %w", err)
+	}
+	if op == opTotalSpace {
+		return c.send(opOK, map[string]uint64{"total_space_on_device": total})
+	}
+	return c.send(opOK, map[string]uint64{"free_space_on_device": free})
+}
+
+// receiveBook streams a book to the receiver. Whatever the receiver does not
+// read is discarded, so the connection stays in sync.
+func (c *DeviceClient) receiveBook(payload json.RawMessage, receiver BookReceiver) error {
+	var book struct {
+		LPath         string `json:"lpath"`
+		Length        int64  `json:"length"`
+		ThisBook      int    `json:"thisBook"`
+		TotalBooks    int    `json:"totalBooks"`
+		WantsOK       bool   `json:"wantsSendOkToSendbook"`
+		StreamsBinary bool   `json:"willStreamBinary"`
+	}
+	if err := json.Unmarshal(payload, &book); err != nil {
+		return fmt.Errorf("invalid book request: %w", err)
+	}
+	if !book.StreamsBinary {
+		return fmt.Errorf("calibre does not stream books as binary, update Calibre")
+	}
+	if book.WantsOK {
+		if err := c.send(opOK, map[string]string{"lpath": book.LPath}); err != nil {
+			return err
+		}
+	}
+	slog.Info("Receiving book from Calibre", "file", book.LPath, "book", book.ThisBook+1, "total", book.TotalBooks)
+
+	c.conn.timeout = c.idle
+	defer func() { c.conn.timeout = 0 }()
+
+	r := io.LimitReader(c.reader, book.Length)
+	err := receiver.ReceiveBook(book.LPath, book.Length, r)
+	if _, discardErr := io.Copy(io.Discard, r); discardErr != nil && err == nil {
+		err = discardErr
+	}
+	return err
+}
+
+func (c *DeviceClient) deleteBooks(payload json.RawMessage, receiver BookReceiver) error {
+	var request struct {
+		LPaths []string `json:"lpaths"`
+	}
+	if err := json.Unmarshal(payload, &request); err != nil {
+		return fmt.Errorf("invalid delete request: %w", err)
+	}
+	if err := c.send(opOK, map[string]any{}); err != nil {
+		return err
+	}
+	for _, lpath := range request.LPaths {
+		if err := receiver.DeleteBook(lpath); err != nil {
+			slog.Warn("Could not delete book", "file", lpath, "error", err)
+		}
+		if err := c.send(opOK, map[string]string{"uuid": ""}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *DeviceClient) send(op int, payload any) error {
+	_ = c.conn.SetWriteDeadline(time.Now().Add(c.idle))
+	if err := writeMessage(c.conn, op, payload); err != nil {
+		return fmt.Errorf("failed to write to Calibre: %w", err)
+	}
+	return nil
+}
+
+// deviceStoreUUID derives a stable device id from the device name, so
+// Calibre recognizes the device across sessions.
+func deviceStoreUUID(deviceName string) string {
+	sum := sha1.Sum([]byte(deviceName))
+	h := hex.EncodeToString(sum[:16])
+	return strings.Join([]string{h[0:8], h[8:12], h[12:16], h[16:20], h[20:32]}, "-")
+}
+
+// streamConn refreshes the read deadline before every read while a book is
+// streamed, so large books on slow networks are not cut off, and stops
+// reading once the context of the session is done.
+type streamConn struct {
+	net.Conn
+	ctx     context.Context
+	timeout time.Duration
+}
+
+func (s *streamConn) Read(p []byte) (int, error) {
+	if s.ctx != nil && s.ctx.Err() != nil {
+		return 0, s.ctx.Err()
+	}
+	if s.timeout > 0 {
+		_ = s.Conn.SetReadDeadline(time.Now().Add(s.timeout))
+	}
+	return s.Conn.Read(p)
+}
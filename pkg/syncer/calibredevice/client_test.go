@@ -0,0 +1,254 @@
+package calibredevice
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/sensitive"
+)
+
+func connectTestClient(t *testing.T, port int, password string, idle time.Duration) *DeviceClient {
+	t.Helper()
+	pw := sensitive.String(password)
+	c := NewDeviceClient("127.0.0.1", port, &pw, "Kobo Libra", idle)
+	if err := c.Connect(time.Second); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { c.Disconnect() })
+	return c
+}
+
+// TestDeviceClient_Serve runs a full session: handshake, books, deletes and eject.
+func TestDeviceClient_Serve(t *testing.T) {
+	port, done := newFakeCalibre(t, func(c *calibreConn) error {
+		var init struct {
+			PasswordHash       string   `json:"passwordHash"`
+			AcceptedExtensions []string `json:"acceptedExtensions"`
+			VersionOK          bool     `json:"versionOK"`
+			CanReceiveBinary   bool     `json:"canReceiveBookBinary"`
+		}
+		if err := c.call(opGetInitializationInfo, map[string]any{"passwordChallenge": "abc", "currentLibraryName": "Books", "calibre_version": []int{7, 4, 0}}, &init); err != nil {
+			return err
+		}
+		sum := sha1.Sum([]byte("secret" + "abc"))
+		if init.PasswordHash != hex.EncodeToString(sum[:]) || !init.VersionOK || !init.CanReceiveBinary || strings.Join(init.AcceptedExtensions, ",") != "epub" {
+			return errors.New("unexpected initialization info")
+		}
+
+		var info struct {
+			DeviceInfo map[string]string `json:"device_info"`
+		}
+		if err := c.call(opGetDeviceInformation, map[string]any{}, &info); err != nil {
+			return err
+		}
+		if info.DeviceInfo["device_name"] != "Kobo Libra" || info.DeviceInfo["device_store_uuid"] != deviceStoreUUID("Kobo Libra") {
+			return errors.New("unexpected device information")
+		}
+		if err := c.call(opSetCalibreDeviceInfo, map[string]any{"device_name": "Kobo Libra"}, nil); err != nil {
+			return err
+		}
+		var space map[string]uint64
+		if err := c.call(opFreeSpace, map[string]any{}, &space); err != nil || space["free_space_on_device"] != 1<<20 {
+			return errors.New("unexpected free space")
+		}
+		if err := c.call(opTotalSpace, map[string]any{}, &space); err != nil || space["total_space_on_device"] != 1<<30 {
+			return errors.New("unexpected total space")
+		}
+		var count struct {
+			Count int `json:"count"`
+		}
+		if err := c.call(opGetBookCount, map[string]any{"canStream": true}, &count); err != nil || count.Count != 0 {
+			return errors.New("unexpected book count")
+		}
+		if err := c.send(opSendBooklists, map[string]any{"count": 0}); err != nil {
+			return err
+		}
+		if err := c.call(opSetLibraryInfo, map[string]any{}, nil); err != nil {
+			return err
+		}
+
+		if err := c.sendBook("Frank Herbert/Dune - Frank Herbert.epub", "DUNE"); err != nil {
+			return err
+		}
+		if err := c.send(opSendBookMetadata, map[string]any{"index": 0}); err != nil {
+			return err
+		}
+		if err := c.send(99, map[string]any{}); err != nil {
+			return err
+		}
+		if err := c.call(opGetBookFileSegment, map[string]any{}, nil); err == nil || !strings.Contains(err.Error(), "expected opcode 0, got 20") {
+			return errors.New("expected an error for book file segments")
+		}
+		if err := c.call(opNoop, map[string]any{}, nil); err != nil {
+			return err
+		}
+		if err := c.call(opDeleteBook, map[string]any{"lpaths": []string{"a.epub", "b.epub"}}, nil); err != nil {
+			return err
+		}
+		for range 2 {
+			if err := c.expect(opOK, nil); err != nil {
+				return err
+			}
+		}
+		return c.call(opNoop, map[string]any{"ejecting": true}, nil)
+	})
+
+	c := connectTestClient(t, port, "secret", 5*time.Second)
+	receiver := &memoryReceiver{}
+	received, err := c.Serve(context.Background(), receiver)
+	if err != nil || received != 1 {
+		t.Fatalf("unexpected serve result: %d %v", received, err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("calibre: %v", err)
+	}
+	if receiver.books["Frank Herbert/Dune - Frank Herbert.epub"] != "DUNE" || strings.Join(receiver.deleted, ",") != "a.epub,b.epub" {
+		t.Fatalf("unexpected receiver state: %+v", receiver)
+	}
+}
+
+// TestDeviceClient_Serve_DiscardsUnreadData keeps the connection in sync when a book is skipped.
+func TestDeviceClient_Serve_DiscardsUnreadData(t *testing.T) {
+	port, done := newFakeCalibre(t, func(c *calibreConn) error {
+		if err := c.sendBook("skipped.epub", strings.Repeat("x", 10000)); err != nil {
+			return err
+		}
+		if err := c.sendBook("kept.epub", "KEPT"); err != nil {
+			return err
+		}
+		return c.call(opNoop, map[string]any{"ejecting": true}, nil)
+	})
+
+	c := connectTestClient(t, port, "", 5*time.Second)
+	receiver := &skippingReceiver{memoryReceiver: &memoryReceiver{}, skip: "skipped.epub"}
+	if received, err := c.Serve(context.Background(), receiver); err != nil || received != 2 {
+		t.Fatalf("unexpected serve result: %d %v", received, err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("calibre: %v", err)
+	}
+	if len(receiver.books) != 1 || receiver.books["kept.epub"] != "KEPT" {
+		t.Fatalf("unexpected books: %v", receiver.books)
+	}
+}
+
+// skippingReceiver leaves the data of one book unread.
+type skippingReceiver struct {
+	*memoryReceiver
+	skip string
+}
+
+func (s *skippingReceiver) ReceiveBook(lpath string, length int64, r io.Reader) error {
+	if lpath == s.skip {
+		return nil
+	}
+	return s.memoryReceiver.ReceiveBook(lpath, length, r)
+}
+
+// TestDeviceClient_Serve_EndOfSession ends the session when Calibre closes the connection or stays idle.
+func TestDeviceClient_Serve_EndOfSession(t *testing.T) {
+	port, done := newFakeCalibre(t, func(c *calibreConn) error { return nil })
+	c := connectTestClient(t, port, "", 5*time.Second)
+	if received, err := c.Serve(context.Background(), &memoryReceiver{}); err != nil || received != 0 {
+		t.Fatalf("expected clean close, got %d %v", received, err)
+	}
+	<-done
+
+	// NOOP polls do not keep an idle session open
+	port, done = newFakeCalibre(t, func(c *calibreConn) error {
+		for {
+			if err := c.call(opNoop, map[string]any{}, nil); err != nil {
+				return nil
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	})
+	c = connectTestClient(t, port, "", 200*time.Millisecond)
+	start := time.Now()
+	if _, err := c.Serve(context.Background(), &memoryReceiver{}); err != nil {
+		t.Fatalf("expected idle end, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("idle session lasted %v", elapsed)
+	}
+	c.Disconnect()
+	<-done
+}
+
+// TestDeviceClient_Serve_Errors covers refusals by Calibre, receiver failures and cancellation.
+func TestDeviceClient_Serve_Errors(t *testing.T) {
+	scripts := map[string]func(c *calibreConn) error{
+		"rejected the password": func(c *calibreConn) error {
+			return c.send(opDisplayMessage, map[string]any{"messageKind": 1, "message": "Invalid password"})
+		},
+		"busy": func(c *calibreConn) error {
+			return c.send(opCalibreBusy, map[string]any{"otherDevice": "Kindle"})
+		},
+		"stream books as binary": func(c *calibreConn) error {
+			return c.send(opSendBook, map[string]any{"lpath": "a.epub", "length": 1})
+		},
+		"disk full": func(c *calibreConn) error {
+			return c.sendBook("a.epub", "A")
+		},
+		"invalid initialization": func(c *calibreConn) error {
+			return c.send(opGetInitializationInfo, []int{1})
+		},
+		"failed to read": func(c *calibreConn) error {
+			_, err := io.WriteString(c.conn, "x[")
+			return err
+		},
+	}
+	for want, script := range scripts {
+		port, done := newFakeCalibre(t, func(c *calibreConn) error {
+			if err := c.send(opDisplayMessage, map[string]any{"messageKind": 0, "message": "hello"}); err != nil {
+				return err
+			}
+			if err := script(c); err != nil {
+				return err
+			}
+			_, _ = io.Copy(io.Discard, c.reader)
+			return nil
+		})
+		c := connectTestClient(t, port, "", 5*time.Second)
+		if _, err := c.Serve(context.Background(), &memoryReceiver{err: errors.New("disk full")}); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q error, got %v", want, err)
+		}
+		c.Disconnect()
+		<-done
+	}
+
+	// Cancellation interrupts a waiting session
+	port, done := newFakeCalibre(t, func(c *calibreConn) error {
+		_, _ = io.Copy(io.Discard, c.reader)
+		return nil
+	})
+	c := connectTestClient(t, port, "", 5*time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if _, err := c.Serve(ctx, &memoryReceiver{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+	c.Disconnect()
+	<-done
+
+	if _, err := NewDeviceClient("127.0.0.1", 1, nil, "x", time.Second).Serve(context.Background(), &memoryReceiver{}); !errors.Is(err, ErrCalibreDeviceDisconnected) {
+		t.Fatalf("expected disconnected error, got %v", err)
+	}
+	if err := NewDeviceClient("127.0.0.1", 1, nil, "x", time.Second).Connect(time.Second); err == nil {
+		t.Fatalf("expected connection error")
+	}
+}
+
+// TestDeviceStoreUUID is stable per device name and formatted as a UUID.
+func TestDeviceStoreUUID(t *testing.T) {
+	a, b := deviceStoreUUID("Kobo"), deviceStoreUUID("Kindle")
+	if a == b || a != deviceStoreUUID("Kobo") || len(a) != 36 || strings.Count(a, "-") != 4 {
+		t.Fatalf("unexpected uuids %q %q", a, b)
+	}
+}
@@ -0,0 +1,68 @@
+package calibredevice
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Calibre listens for device broadcasts on the first free one of these ports.
+var broadcastPorts = []int{54982, 48123, 39001, 44044, 59678}
+
+// broadcastAddress is where discovery broadcasts are sent.
+var broadcastAddress = "255.255.255.255"
+
+// DiscoverCalibre broadcasts a hello on the local network and returns the
+// address and wireless device port of the first Calibre instance to reply.
+func DiscoverCalibre(timeout time.Duration) (string, int, error) {
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return "", 0, err
+	}
+	defer conn.Close()
+
+	slog.Debug("Broadcasting for Calibre", "address", broadcastAddress, "ports", broadcastPorts)
+	for _, port := range broadcastPorts {
+		addr := &net.UDPAddr{IP: net.ParseIP(broadcastAddress), Port: port}
+		if _, err := conn.WriteToUDP([]byte("hello"), addr); err != nil {
+			slog.Debug("Could not send discovery broadcast", "port", port, "error", err)
+		}
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return "", 0, err
+	}
+	buf := make([]byte, 1024)
+	for {
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			return "", 0, fmt.Errorf("no reply from Calibre: %w", err)
+		}
+		port, err := parseDiscoveryReply(string(buf[:n]))
+		if err != nil {
+			slog.Debug("Ignoring discovery reply", "from", from, "error", err)
+			continue
+		}
+		slog.Debug("Discovered Calibre", "host", from.IP.String(), "port", port)
+		return from.IP.String(), port, nil
+	}
+}
+
+// parseDiscoveryReply returns the wireless device port from a reply like
+// "calibre wireless device client (on desktop);9080,9090", where the first
+// port is the content server and the second the wireless device port.
+func parseDiscoveryReply(reply string) (int, error) {
+	i := strings.LastIndex(reply, ";")
+	if i < 0 {
+		return 0, fmt.Errorf("invalid reply %q", reply)
+	}
+	ports := strings.Split(strings.TrimSpace(reply[i+1:]), ",")
+	port, err := strconv.Atoi(ports[len(ports)-1])
+	if err != nil || port <= 0 || port > 65535 {
+		return 0, fmt.Errorf("invalid port in reply %q", reply)
+	}
+	return port, nil
+}
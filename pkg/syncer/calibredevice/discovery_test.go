@@ -0,0 +1,56 @@
+package calibredevice
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// TestDiscoverCalibre finds Calibre through the broadcast reply and ignores unrelated replies.
+func TestDiscoverCalibre(t *testing.T) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 16)
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil || string(buf[:n]) != "hello" {
+			return
+		}
+		_, _ = conn.WriteToUDP([]byte("something else"), from)
+		_, _ = conn.WriteToUDP([]byte("calibre wireless device client (on desktop);9080,9191"), from)
+	}()
+
+	oldAddress, oldPorts := broadcastAddress, broadcastPorts
+	t.Cleanup(func() { broadcastAddress, broadcastPorts = oldAddress, oldPorts })
+	broadcastAddress = "127.0.0.1"
+	broadcastPorts = []int{conn.LocalAddr().(*net.UDPAddr).Port}
+
+	host, port, err := DiscoverCalibre(5 * time.Second)
+	if err != nil || host != "127.0.0.1" || port != 9191 {
+		t.Fatalf("unexpected discovery: %s %d %v", host, port, err)
+	}
+
+	// Without a reply discovery gives up after the timeout
+	broadcastPorts = []int{1}
+	if _, _, err := DiscoverCalibre(50 * time.Millisecond); err == nil {
+		t.Fatalf("expected discovery timeout")
+	}
+}
+
+// TestParseDiscoveryReply takes the last port of the reply.
+func TestParseDiscoveryReply(t *testing.T) {
+	if port, err := parseDiscoveryReply("calibre wireless device client (on my;pc);8080,9090\n"); err != nil || port != 9090 {
+		t.Fatalf("unexpected port: %d %v", port, err)
+	}
+	if port, err := parseDiscoveryReply("calibre (on pc);9090"); err != nil || port != 9090 {
+		t.Fatalf("unexpected port: %d %v", port, err)
+	}
+	for _, reply := range []string{"calibre", "calibre;", "calibre;8080,abc", "calibre;0", "calibre;70000"} {
+		if _, err := parseDiscoveryReply(reply); err == nil {
+			t.Fatalf("expected error for %q", reply)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package calibredevice
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Opcodes of the Calibre smart device protocol
+const (
+	opOK                    = 0
+	opSetCalibreDeviceInfo  = 1
+	opSetCalibreDeviceName  = 2
+	opGetDeviceInformation  = 3
+	opTotalSpace            = 4
+	opFreeSpace             = 5
+	opGetBookCount          = 6
+	opSendBooklists         = 7
+	opSendBook              = 8
+	opGetInitializationInfo = 9
+	opBookDone              = 11
+	opNoop                  = 12
+	opDeleteBook            = 13
+	opGetBookFileSegment    = 14
+	opGetBookMetadata       = 15
+	opSendBookMetadata      = 16
+	opDisplayMessage        = 17
+	opCalibreBusy           = 18
+	opSetLibraryInfo        = 19
+	opError                 = 20
+)
+
+// maxMessageLength bounds a single JSON message; book data is streamed
+// separately and is not subject to it.
+const maxMessageLength = 16 << 20
+
+// maxLengthDigits bounds the decimal length prefix, so a peer that never
+// sends '[' cannot make us buffer without limit.
+const maxLengthDigits = 10
+
+// writeMessage sends a message framed as the decimal length of the JSON
+// followed by the JSON array [opcode, payload].
+func writeMessage(w io.Writer, op int, payload any) error {
+	data, err := json.Marshal([]any{op, payload})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, strconv.Itoa(len(data))+string(data))
+	return err
+}
+
+// readMessage reads a single framed message and returns its opcode and payload.
+func readMessage(r *bufio.Reader) (int, json.RawMessage, error) {
+	var prefix []byte
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		if c == '[' {
+			break
+		}
+		if c < '0' || c > '9' || len(prefix) == maxLengthDigits {
+			return 0, nil, fmt.Errorf("invalid message length %q", append(prefix, c))
+		}
+		prefix = append(prefix, c)
+	}
+	length, err := strconv.Atoi(string(prefix))
+	if err != nil || length < 2 || length > maxMessageLength {
+		return 0, nil, fmt.Errorf("invalid message length %q", prefix)
+	}
+
+	data := make([]byte, length)
+	data[0] = '['
+	if _, err := io.ReadFull(r, data[1:]); err != nil {
+		return 0, nil, err
+	}
+	var message []json.RawMessage
+	if err := json.Unmarshal(data, &message); err != nil || len(message) != 2 {
+		return 0, nil, fmt.Errorf("invalid message: %.100s", data)
+	}
+	var op int
+	if err := json.Unmarshal(message[0], &op); err != nil {
+		return 0, nil, fmt.Errorf("invalid opcode: %s", message[0])
+	}
+	return op, message[1], nil
+}
@@ -0,0 +1,70 @@
+package calibredevice
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestMessageRoundTrip frames messages with the byte length of their JSON.
+func TestMessageRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeMessage(&buf, opNoop, map[string]any{"ejecting": true}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := writeMessage(&buf, opOK, map[string]string{"name": "Émile"}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), `22[12,{"ejecting":true}]`) {
+		t.Fatalf("unexpected framing: %q", buf.String())
+	}
+
+	r := bufio.NewReader(&buf)
+	op, payload, err := readMessage(r)
+	if err != nil || op != opNoop || string(payload) != `{"ejecting":true}` {
+		t.Fatalf("unexpected message: %d %s %v", op, payload, err)
+	}
+	op, payload, err = readMessage(r)
+	if err != nil || op != opOK || string(payload) != `{"name":"Émile"}` {
+		t.Fatalf("unexpected message: %d %s %v", op, payload, err)
+	}
+}
+
+// TestReadMessage_Invalid rejects malformed frames, including length prefixes
+// that are not digits or never end.
+func TestReadMessage_Invalid(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"x[0,{}]",
+		"1[0,{}]",
+		"99999999999[0,{}]",
+		"-7[0,{}]",
+		" 7[0,{}]",
+		"[0,{}]",
+		strings.Repeat("1", 64),
+		"7[0,{}]",
+		"9[0,{},1]",
+		`8["a",{}]`,
+	} {
+		if _, _, err := readMessage(bufio.NewReader(strings.NewReader(input))); err == nil {
+			t.Fatalf("expected error for %q", input)
+		}
+	}
+
+	// An endless prefix is rejected after maxLengthDigits bytes.
+	r := bufio.NewReader(endless{})
+	if _, _, err := readMessage(r); err == nil || !strings.Contains(err.Error(), "invalid message length") {
+		t.Fatalf("unexpected err: %v", err)
+	}
+}
+
+// endless is a reader that never ends and never sends '['.
+type endless struct{}
+
+func (endless) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = '1'
+	}
+	return len(p), nil
+}
@@ -0,0 +1,206 @@
+package calibredevice
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+const (
+	defaultPort       = 9090
+	defaultDeviceName = "BookShift"
+	defaultIdle       = 60 * time.Second
+)
+
+// defaultExtensions are offered to Calibre when no valid_extensions are set.
+var defaultExtensions = []string{"epub", "kepub", "pdf", "mobi", "azw3", "cbz", "cbr", "txt"}
+
+// calibreDeviceState records the books this source received, so that only
+// those are deleted when Calibre asks for it.
+type calibreDeviceState struct {
+	Books []string `json:"books"`
+}
+
+type CalibreDeviceSyncer struct {
+	config *config.CalibreDeviceConfig
+}
+
+func NewCalibreDeviceSyncer(deviceConfig *config.CalibreDeviceConfig) *CalibreDeviceSyncer {
+	return &CalibreDeviceSyncer{
+		config: deviceConfig,
+	}
+}
+
+func (s *CalibreDeviceSyncer) Run(targetFolder string, validExtensions []string, overwriteExistingFiles bool) error {
+	return s.RunContext(context.Background(), targetFolder, validExtensions, overwriteExistingFiles)
+}
+
+func (s *CalibreDeviceSyncer) RunContext(ctx context.Context, targetFolder string, validExtensions []string, overwriteExistingFiles bool) error {
+	if s.config.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(s.config.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	host, port := s.config.Host, s.config.Port
+	if host == "" {
+		var err error
+		if host, port, err = calibreDiscover(10 * time.Second); err != nil {
+			return fmt.Errorf("could not discover Calibre: %w", err)
+		}
+	}
+	if !(port > 0) {
+		port = defaultPort
+	}
+
+	deviceName := s.config.DeviceName
+	if deviceName == "" {
+		deviceName = defaultDeviceName
+	}
+	idle := time.Duration(s.config.IdleSeconds) * time.Second
+	if !(idle > 0) {
+		idle = defaultIdle
+	}
+
+	statePath := util.DefaultStatePath(targetFolder, "calibre_device", deviceName)
+	var state calibreDeviceState
+	if err := util.LoadState(statePath, &state); err != nil {
+		return fmt.Errorf("could not load Calibre device state from %s: %w", statePath, err)
+	}
+
+	deviceClient := newCalibreDeviceClient(host, port, s.config.Password, deviceName, idle)
+	if err := calibreDeviceConnect(deviceClient, 60*time.Second); err != nil {
+		return fmt.Errorf("could not connect to Calibre at %s: %w", host, err)
+	}
+	defer deviceClient.Disconnect()
+
+	receiver := &deviceReceiver{
+		targetFolder:        targetFolder,
+		validExtensions:     validExtensions,
+		overwrite:           overwriteExistingFiles,
+		keepFolderStructure: s.config.KeepFolderStructure,
+		books:               map[string]bool{},
+	}
+	for _, lpath := range state.Books {
+		receiver.books[lpath] = true
+	}
+	received, err := calibreDeviceServe(ctx, deviceClient, receiver)
+	slog.Info("Received books from Calibre", "host", deviceClient.Host(), "count", received)
+
+	// Save the received books even when the session failed halfway
+	state.Books = receiver.receivedBooks()
+	if saveErr := util.SaveState(statePath, &state); saveErr != nil && err == nil {
+		return fmt.Errorf("could not save Calibre device state to %s: %w", statePath, saveErr)
+	}
+	if err != nil {
+		return fmt.Errorf("calibre wireless device session with %s failed: %w", host, err)
+	}
+	return nil
+}
+
+// deviceReceiver stores the books Calibre sends in the target folder.
+type deviceReceiver struct {
+	targetFolder        string
+	validExtensions     []string
+	overwrite           bool
+	keepFolderStructure bool
+	// books holds the lpaths this source wrote to the target folder.
+	books map[string]bool
+}
+
+// receivedBooks returns the lpaths this source wrote, sorted.
+func (d *deviceReceiver) receivedBooks() []string {
+	books := make([]string, 0, len(d.books))
+	for lpath := range d.books {
+		books = append(books, lpath)
+	}
+	sort.Strings(books)
+	return books
+}
+
+// validExtension reports whether lpath has one of the offered formats.
+func (d *deviceReceiver) validExtension(lpath string) bool {
+	extension := strings.TrimPrefix(path.Ext(lpath), ".")
+	return slices.ContainsFunc(d.Extensions(), func(e string) bool { return strings.EqualFold(e, extension) })
+}
+
+// Extensions returns the formats Calibre may send, without leading dots.
+func (d *deviceReceiver) Extensions() []string {
+	if len(d.validExtensions) == 0 {
+		return defaultExtensions
+	}
+	extensions := make([]string, 0, len(d.validExtensions))
+	for _, e := range d.validExtensions {
+		extensions = append(extensions, strings.ToLower(strings.TrimPrefix(e, ".")))
+	}
+	return extensions
+}
+
+func (d *deviceReceiver) Space() (uint64, uint64, error) {
+	return util.FolderSpace(d.targetFolder)
+}
+
+func (d *deviceReceiver) ReceiveBook(lpath string, length int64, r io.Reader) error {
+	if !d.validExtension(lpath) {
+		slog.Warn("Skipping book with invalid extension", "file", lpath)
+		return nil
+	}
+	book := NewCalibreDeviceBook(lpath, length, d.keepFolderStructure)
+	localPath, err := book.LocalPath()
+	if err != nil {
+		return err
+	}
+	// A file that is skipped because it exists was not written by this source
+	_, statErr := os.Stat(filepath.Join(d.targetFolder, localPath))
+	if err := calibreDeviceReceive(book, d.targetFolder, d.overwrite, r); err != nil {
+		return err
+	}
+	if os.IsNotExist(statErr) || d.overwrite {
+		d.books[lpath] = true
+	}
+	return nil
+}
+
+// DeleteBook removes a book this source received earlier. Other files in the
+// target folder are left alone.
+func (d *deviceReceiver) DeleteBook(lpath string) error {
+	if !d.books[lpath] || !d.validExtension(lpath) {
+		slog.Warn("Ignoring delete of book not received from Calibre", "file", lpath)
+		return nil
+	}
+	localPath, err := NewCalibreDeviceBook(lpath, 0, d.keepFolderStructure).LocalPath()
+	if err != nil {
+		return err
+	}
+	dstPath := filepath.Join(d.targetFolder, localPath)
+	if _, err := os.Stat(dstPath); os.IsNotExist(err) {
+		delete(d.books, lpath)
+		return nil
+	}
+	if util.DryRun {
+		slog.Info("[dry-run] Would delete book", "file", dstPath)
+		return nil
+	}
+	slog.Info("Deleting book on request of Calibre", "file", dstPath)
+	if err := os.Remove(dstPath); err != nil {
+		return err
+	}
+	delete(d.books, lpath)
+	return nil
+}
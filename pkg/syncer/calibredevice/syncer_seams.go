@@ -0,0 +1,29 @@
+// syncer_seams.go: Test seams for the Calibre wireless device syncer.
+//
+// This file defines small function variables that production code uses by default,
+// but tests can override to substitute fakes. Keeping these seams in a separate
+// file keeps the main implementation clean while preserving simple overrides in tests.
+package calibredevice
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/go-playground/sensitive"
+)
+
+// test hooks (seams) for dependency injection in tests
+var (
+	calibreDiscover        = func(timeout time.Duration) (string, int, error) { return DiscoverCalibre(timeout) }
+	newCalibreDeviceClient = func(host string, port int, password *sensitive.String, deviceName string, idle time.Duration) CalibreDeviceAPI {
+		return NewDeviceClient(host, port, password, deviceName, idle)
+	}
+	calibreDeviceConnect = func(c CalibreDeviceAPI, timeout time.Duration) error { return c.Connect(timeout) }
+	calibreDeviceServe   = func(ctx context.Context, c CalibreDeviceAPI, r BookReceiver) (int, error) {
+		return c.Serve(ctx, r)
+	}
+	calibreDeviceReceive = func(b *CalibreDeviceBook, dst string, overwrite bool, r io.Reader) error {
+		return b.Receive(dst, overwrite, r)
+	}
+)
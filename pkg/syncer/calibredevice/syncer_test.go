@@ -0,0 +1,258 @@
+package calibredevice
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+	"github.com/go-playground/sensitive"
+)
+
+// TestCalibreDeviceSyncer_Run_EndToEnd receives books from a fake Calibre found by discovery
+// and deletes only books it received before.
+func TestCalibreDeviceSyncer_Run_EndToEnd(t *testing.T) {
+	dst := t.TempDir()
+	for _, name := range []string{"Old.epub", "Foreign.epub"} {
+		if err := os.WriteFile(filepath.Join(dst, util.SafeFileName(name)), []byte("OLD"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	statePath := util.DefaultStatePath(dst, "calibre_device", defaultDeviceName)
+	if err := util.SaveState(statePath, calibreDeviceState{Books: []string{"Someone/Old.epub"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	port, done := newFakeCalibre(t, func(c *calibreConn) error {
+		var init struct {
+			AcceptedExtensions []string `json:"acceptedExtensions"`
+		}
+		if err := c.call(opGetInitializationInfo, map[string]any{}, &init); err != nil {
+			return err
+		}
+		if strings.Join(init.AcceptedExtensions, ",") != "epub,kepub" {
+			return errors.New("unexpected extensions")
+		}
+		var space map[string]uint64
+		if err := c.call(opFreeSpace, map[string]any{}, &space); err != nil || space["free_space_on_device"] == 0 {
+			return errors.New("unexpected free space")
+		}
+		if err := c.sendBook("Frank Herbert/Dune - Frank Herbert.epub", "DUNE"); err != nil {
+			return err
+		}
+		if err := c.sendBook("Frank Herbert/Dune - Frank Herbert.pdf", "PDF!"); err != nil {
+			return err
+		}
+		if err := c.call(opDeleteBook, map[string]any{"lpaths": []string{"Someone/Old.epub", "Someone/Missing.epub", "Other/Foreign.epub"}}, nil); err != nil {
+			return err
+		}
+		for range 3 {
+			if err := c.expect(opOK, nil); err != nil {
+				return err
+			}
+		}
+		return c.call(opNoop, map[string]any{"ejecting": true}, nil)
+	})
+
+	oldDiscover := calibreDiscover
+	t.Cleanup(func() { calibreDiscover = oldDiscover })
+	calibreDiscover = func(timeout time.Duration) (string, int, error) { return "127.0.0.1", port, nil }
+
+	password := sensitive.String("secret")
+	cfg := &config.CalibreDeviceConfig{Password: &password}
+	if err := NewCalibreDeviceSyncer(cfg).Run(dst, []string{".epub", ".KEPUB"}, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("calibre: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dst, util.SafeFileName("Dune - Frank Herbert.epub"))); err != nil || string(data) != "DUNE" {
+		t.Fatalf("unexpected file: %q %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, util.SafeFileName("Dune - Frank Herbert.pdf"))); !os.IsNotExist(err) {
+		t.Fatalf("book with invalid extension was written")
+	}
+	if _, err := os.Stat(filepath.Join(dst, util.SafeFileName("Old.epub"))); !os.IsNotExist(err) {
+		t.Fatalf("book deleted by Calibre still present")
+	}
+	if _, err := os.Stat(filepath.Join(dst, util.SafeFileName("Foreign.epub"))); err != nil {
+		t.Fatalf("book not received from Calibre was deleted: %v", err)
+	}
+
+	var state calibreDeviceState
+	if err := util.LoadState(statePath, &state); err != nil {
+		t.Fatalf("load state: %v", err)
+	}
+	if strings.Join(state.Books, ",") != "Frank Herbert/Dune - Frank Herbert.epub" {
+		t.Fatalf("unexpected state: %v", state.Books)
+	}
+}
+
+// TestCalibreDeviceSyncer_Run_Defaults applies the default port, device name and idle time.
+func TestCalibreDeviceSyncer_Run_Defaults(t *testing.T) {
+	oldClient, oldServe := newCalibreDeviceClient, calibreDeviceServe
+	t.Cleanup(func() { newCalibreDeviceClient, calibreDeviceServe = oldClient, oldServe })
+	var gotPort int
+	var gotName string
+	var gotIdle time.Duration
+	newCalibreDeviceClient = func(host string, port int, password *sensitive.String, deviceName string, idle time.Duration) CalibreDeviceAPI {
+		gotPort, gotName, gotIdle = port, deviceName, idle
+		return &fakeDevice{}
+	}
+	calibreDeviceServe = func(ctx context.Context, c CalibreDeviceAPI, r BookReceiver) (int, error) {
+		if strings.Join(r.Extensions(), ",") != strings.Join(defaultExtensions, ",") {
+			t.Fatalf("unexpected extensions %v", r.Extensions())
+		}
+		return 0, nil
+	}
+
+	if err := NewCalibreDeviceSyncer(&config.CalibreDeviceConfig{Host: "calibre.local"}).Run(t.TempDir(), nil, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if gotPort != defaultPort || gotName != defaultDeviceName || gotIdle != defaultIdle {
+		t.Fatalf("unexpected defaults: %d %q %v", gotPort, gotName, gotIdle)
+	}
+
+	cfg := &config.CalibreDeviceConfig{Host: "calibre.local", Port: 9191, DeviceName: "Libra", IdleSeconds: 5}
+	if err := NewCalibreDeviceSyncer(cfg).Run(t.TempDir(), nil, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if gotPort != 9191 || gotName != "Libra" || gotIdle != 5*time.Second {
+		t.Fatalf("unexpected settings: %d %q %v", gotPort, gotName, gotIdle)
+	}
+}
+
+// fakeDevice is an in-memory CalibreDeviceAPI used by syncer tests.
+type fakeDevice struct {
+	connectErr error
+}
+
+func (f *fakeDevice) Connect(timeout time.Duration) error { return f.connectErr }
+func (f *fakeDevice) Disconnect() error                   { return nil }
+func (f *fakeDevice) Serve(ctx context.Context, r BookReceiver) (int, error) {
+	return 0, errors.New("session failed")
+}
+func (f *fakeDevice) Host() string { return "calibre.local" }
+
+// TestCalibreDeviceSyncer_Run_Errors covers discovery, connection and session errors.
+func TestCalibreDeviceSyncer_Run_Errors(t *testing.T) {
+	oldDiscover, oldClient := calibreDiscover, newCalibreDeviceClient
+	t.Cleanup(func() { calibreDiscover, newCalibreDeviceClient = oldDiscover, oldClient })
+	calibreDiscover = func(timeout time.Duration) (string, int, error) { return "", 0, errors.New("no reply") }
+	if err := NewCalibreDeviceSyncer(&config.CalibreDeviceConfig{}).Run(t.TempDir(), nil, false); err == nil || !strings.Contains(err.Error(), "could not discover") {
+		t.Fatalf("expected discovery error, got %v", err)
+	}
+
+	device := &fakeDevice{connectErr: errors.New("refused")}
+	newCalibreDeviceClient = func(string, int, *sensitive.String, string, time.Duration) CalibreDeviceAPI { return device }
+	cfg := &config.CalibreDeviceConfig{Host: "calibre.local"}
+	if err := NewCalibreDeviceSyncer(cfg).Run(t.TempDir(), nil, false); err == nil || !strings.Contains(err.Error(), "could not connect") {
+		t.Fatalf("expected connect error, got %v", err)
+	}
+	device.connectErr = nil
+	if err := NewCalibreDeviceSyncer(cfg).Run(t.TempDir(), nil, false); err == nil || !strings.Contains(err.Error(), "session failed") {
+		t.Fatalf("expected session error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := NewCalibreDeviceSyncer(cfg).RunContext(ctx, t.TempDir(), nil, false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+}
+
+// TestDeviceReceiver_DeleteBook removes only books this source received, inside the
+// target folder, and honours dry-run.
+func TestDeviceReceiver_DeleteBook(t *testing.T) {
+	dst := t.TempDir()
+	kept := filepath.Join(dst, "Author", util.SafeFileName("Book.epub"))
+	stateFile := util.DefaultStatePath(dst, "calibre_device", "Other")
+	for _, file := range []string{kept, stateFile} {
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte("X"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stateLpath, _ := filepath.Rel(dst, stateFile)
+	r := &deviceReceiver{targetFolder: dst, keepFolderStructure: true, books: map[string]bool{
+		"Author/Book.epub":           true,
+		"../../etc/passwd.epub":      true,
+		filepath.ToSlash(stateLpath): true,
+	}}
+
+	if err := r.DeleteBook("../../etc/passwd.epub"); err == nil {
+		t.Fatalf("expected invalid folder error")
+	}
+	if err := r.DeleteBook(filepath.ToSlash(stateLpath)); err != nil {
+		t.Fatalf("delete state file: %v", err)
+	}
+	if _, err := os.Stat(stateFile); err != nil {
+		t.Fatalf("file with invalid extension was deleted: %v", err)
+	}
+
+	old := util.DryRun
+	util.DryRun = true
+	if err := r.DeleteBook("Author/Book.epub"); err != nil {
+		t.Fatalf("dry-run delete: %v", err)
+	}
+	util.DryRun = old
+	if _, err := os.Stat(kept); err != nil {
+		t.Fatalf("dry-run deleted the book: %v", err)
+	}
+
+	delete(r.books, "Author/Book.epub")
+	if err := r.DeleteBook("Author/Book.epub"); err != nil {
+		t.Fatalf("delete unknown book: %v", err)
+	}
+	if _, err := os.Stat(kept); err != nil {
+		t.Fatalf("book not received from Calibre was deleted: %v", err)
+	}
+
+	r.books["Author/Book.epub"] = true
+	if err := r.DeleteBook("Author/Book.epub"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := os.Stat(kept); !os.IsNotExist(err) {
+		t.Fatalf("book not deleted")
+	}
+	if r.books["Author/Book.epub"] {
+		t.Fatalf("deleted book still recorded")
+	}
+}
+
+// TestDeviceReceiver_ReceiveBook skips formats outside valid_extensions without reading them
+// and records only books it wrote.
+func TestDeviceReceiver_ReceiveBook(t *testing.T) {
+	orig := calibreDeviceReceive
+	t.Cleanup(func() { calibreDeviceReceive = orig })
+	var got []string
+	calibreDeviceReceive = func(b *CalibreDeviceBook, dst string, overwrite bool, r io.Reader) error {
+		got = append(got, b.FileName())
+		return nil
+	}
+
+	dst := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dst, util.SafeFileName("e.epub")), []byte("OLD"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r := &deviceReceiver{targetFolder: dst, validExtensions: []string{".EPUB"}, books: map[string]bool{}}
+	for _, lpath := range []string{"a.epub", "b.pdf", "c.Epub", "d", "e.epub"} {
+		if err := r.ReceiveBook(lpath, 1, strings.NewReader("X")); err != nil {
+			t.Fatalf("receive %s: %v", lpath, err)
+		}
+	}
+	if strings.Join(got, ",") != util.SafeFileName("a.epub")+","+util.SafeFileName("c.Epub")+","+util.SafeFileName("e.epub") {
+		t.Fatalf("unexpected books: %v", got)
+	}
+	if strings.Join(r.receivedBooks(), ",") != "a.epub,c.Epub" {
+		t.Fatalf("unexpected recorded books: %v", r.receivedBooks())
+	}
+}
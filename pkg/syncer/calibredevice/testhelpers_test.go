@@ -0,0 +1,109 @@
+package calibredevice
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// calibreConn is the Calibre side of a wireless device connection.
+type calibreConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func (c *calibreConn) send(op int, payload any) error {
+	return writeMessage(c.conn, op, payload)
+}
+
+// call sends a request and decodes the device's reply, which must be OK.
+func (c *calibreConn) call(op int, payload any, reply any) error {
+	if err := c.send(op, payload); err != nil {
+		return err
+	}
+	return c.expect(opOK, reply)
+}
+
+func (c *calibreConn) expect(want int, reply any) error {
+	op, data, err := readMessage(c.reader)
+	if err != nil {
+		return err
+	}
+	if op != want {
+		return fmt.Errorf("expected opcode %d, got %d: %s", want, op, data)
+	}
+	if reply != nil {
+		return json.Unmarshal(data, reply)
+	}
+	return nil
+}
+
+func (c *calibreConn) sendBook(lpath string, data string) error {
+	var ok struct {
+		LPath string `json:"lpath"`
+	}
+	if err := c.call(opSendBook, map[string]any{"lpath": lpath, "length": len(data), "thisBook": 0, "totalBooks": 1, "wantsSendOkToSendbook": true, "willStreamBinary": true}, &ok); err != nil {
+		return err
+	}
+	if ok.LPath != lpath {
+		return fmt.Errorf("unexpected lpath %q", ok.LPath)
+	}
+	_, err := io.WriteString(c.conn, data)
+	return err
+}
+
+// newFakeCalibre listens like Calibre's wireless device driver and runs the
+// script against the first device that connects. The script's error is
+// returned on the channel once it finishes.
+func newFakeCalibre(t *testing.T, script func(c *calibreConn) error) (int, <-chan error) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	done := make(chan error, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			done <- err
+			return
+		}
+		defer conn.Close()
+		_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+		done <- script(&calibreConn{conn: conn, reader: bufio.NewReader(conn)})
+	}()
+	return ln.Addr().(*net.TCPAddr).Port, done
+}
+
+// memoryReceiver is an in-memory BookReceiver used by client tests.
+type memoryReceiver struct {
+	books   map[string]string
+	deleted []string
+	err     error
+}
+
+func (m *memoryReceiver) Extensions() []string { return []string{"epub"} }
+func (m *memoryReceiver) Space() (uint64, uint64, error) {
+	return 1 << 20, 1 << 30, nil
+}
+func (m *memoryReceiver) ReceiveBook(lpath string, length int64, r io.Reader) error {
+	if m.err != nil {
+		return m.err
+	}
+	data, err := io.ReadAll(r)
+	if m.books == nil {
+		m.books = map[string]string{}
+	}
+	m.books[lpath] = string(data)
+	return err
+}
+func (m *memoryReceiver) DeleteBook(lpath string) error {
+	m.deleted = append(m.deleted, lpath)
+	return nil
+}
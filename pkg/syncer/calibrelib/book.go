@@ -0,0 +1,134 @@
+package calibrelib
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bjw-s-labs/bookshift/pkg/sharefs"
+	"github.com/bjw-s-labs/bookshift/pkg/syncer/calibre"
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+type CalibreLibraryBook struct {
+	info       *LibraryBook
+	format     string
+	remotePath string
+	subFolder  string
+	fileName   string
+
+	library sharefs.FolderAPI
+}
+
+// NewCalibreLibraryBook picks the first format from preferredFormats that the
+// book is available in. Formats must also match validExtensions when those
+// are set. With keepFolderStructure the book keeps its library folder and
+// file name, otherwise it is named after filenameTemplate. It returns nil when
+// no format matches.
+func NewCalibreLibraryBook(info *LibraryBook, preferredFormats []string, validExtensions []string, filenameTemplate string, keepFolderStructure bool, library sharefs.FolderAPI) *CalibreLibraryBook {
+	lowerExts := make([]string, 0, len(validExtensions))
+	for _, e := range validExtensions {
+		lowerExts = append(lowerExts, strings.ToLower(e))
+	}
+
+	for _, f := range preferredFormats {
+		format := strings.ToUpper(strings.TrimPrefix(f, "."))
+		name, ok := info.FileNames[format]
+		if !ok {
+			continue
+		}
+		extension := "." + strings.ToLower(format)
+		if len(lowerExts) > 0 && !slices.Contains(lowerExts, extension) {
+			continue
+		}
+
+		book := &CalibreLibraryBook{
+			info:       info,
+			format:     format,
+			remotePath: path.Join(info.Path, name+extension),
+			fileName:   util.SafeFileName(calibre.RenderFilename(filenameTemplate, info.CalibreBookInfo) + extension),
+			library:    library,
+		}
+		if keepFolderStructure {
+			book.subFolder = filepath.FromSlash(info.Path)
+			book.fileName = util.SafeFileName(name + extension)
+		}
+		return book
+	}
+	return nil
+}
+
+// FileName returns the local file name of the book.
+func (b *CalibreLibraryBook) FileName() string {
+	return b.fileName
+}
+
+func (b *CalibreLibraryBook) Download(dstFolder string, overwriteExistingFile bool) error {
+	if b.subFolder != "" {
+		// The folder comes from metadata.db, never write outside the target folder
+		if !filepath.IsLocal(b.subFolder) {
+			return fmt.Errorf("invalid book folder in Calibre library: %s", b.info.Path)
+		}
+		dstFolder = filepath.Join(dstFolder, b.subFolder)
+	}
+	dstPath := filepath.Join(dstFolder, b.fileName)
+
+	// Create folder structure if required
+	if _, err := os.Stat(dstFolder); os.IsNotExist(err) {
+		if util.DryRun {
+			slog.Info("[dry-run] Would create local folder", "folder", dstFolder)
+			return nil
+		}
+		slog.Info("Creating local folder", "folder", dstFolder)
+		if err := os.MkdirAll(dstFolder, 0755); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("Copying book from Calibre library", "library", b.library.Location(), "id", b.info.ID, "title", b.info.Title, "format", b.format, "destination", dstPath)
+
+	// Check if the file already exists
+	_, err := os.Stat(dstPath)
+	if !os.IsNotExist(err) {
+		if !overwriteExistingFile {
+			slog.Warn("File already exists, skipping download", "file", dstPath)
+			return nil
+		}
+
+		slog.Info("Overwriting existing file", "file", dstPath)
+	}
+
+	// Copy the file
+	if util.DryRun {
+		slog.Info("[dry-run] Would copy book", "id", b.info.ID, "file", b.remotePath, "destination", dstPath)
+		return nil
+	}
+	tmpFile, err := os.CreateTemp(dstFolder, "bookshift-")
+	if err != nil {
+		return err
+	}
+	defer tmpFile.Close()
+
+	slog.Debug("Downloading to temporary file", "file", tmpFile.Name())
+	writer := util.NewFileWriter(tmpFile, b.info.FormatSizes[b.format], true)
+	if _, err := b.library.ReadFile(b.remotePath, writer); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+
+	if err := tmpFile.Sync(); err != nil { // ensure data flushed before rename
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	if err := os.Rename(tmpFile.Name(), dstPath); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+
+	slog.Info("Successfully downloaded file", "filename", b.fileName)
+	return nil
+}
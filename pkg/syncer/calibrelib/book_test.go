@@ -0,0 +1,117 @@
+package calibrelib
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bjw-s-labs/bookshift/pkg/sharefs"
+	"github.com/bjw-s-labs/bookshift/pkg/syncer/calibre"
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+func testBook() *LibraryBook {
+	return &LibraryBook{
+		CalibreBookInfo: calibre.CalibreBookInfo{
+			ID: 1, Title: "Dune", Authors: []string{"Frank Herbert"},
+			Formats: []string{"EPUB", "PDF"}, FormatSizes: map[string]int64{"EPUB": 9, "PDF": 8},
+		},
+		Path:      "Frank Herbert/Dune (1)",
+		FileNames: map[string]string{"EPUB": "Dune - Frank Herbert", "PDF": "Dune - Frank Herbert"},
+	}
+}
+
+// TestNewCalibreLibraryBook_FormatSelection picks the first preferred format allowed by validExtensions.
+func TestNewCalibreLibraryBook_FormatSelection(t *testing.T) {
+	lib := &sharefs.LocalFolder{Folder: t.TempDir()}
+	if b := NewCalibreLibraryBook(testBook(), []string{"azw3", ".pdf", "epub"}, nil, "", false, lib); b == nil || b.format != "PDF" || b.remotePath != "Frank Herbert/Dune (1)/Dune - Frank Herbert.pdf" {
+		t.Fatalf("unexpected selection: %+v", b)
+	}
+	if b := NewCalibreLibraryBook(testBook(), []string{"pdf", "epub"}, []string{".EPUB"}, "", false, lib); b == nil || b.format != "EPUB" {
+		t.Fatalf("expected EPUB, got %+v", b)
+	}
+	if b := NewCalibreLibraryBook(testBook(), []string{"azw3"}, nil, "", false, lib); b != nil {
+		t.Fatalf("expected no selection, got %+v", b)
+	}
+
+	b := NewCalibreLibraryBook(testBook(), []string{"epub"}, nil, "{author} - {title}", false, lib)
+	if b.FileName() != util.SafeFileName("Frank Herbert - Dune.epub") || b.subFolder != "" {
+		t.Fatalf("unexpected name: %q %q", b.FileName(), b.subFolder)
+	}
+	b = NewCalibreLibraryBook(testBook(), []string{"epub"}, nil, "", true, lib)
+	if b.FileName() != util.SafeFileName("Dune - Frank Herbert.epub") || b.subFolder != filepath.FromSlash("Frank Herbert/Dune (1)") {
+		t.Fatalf("unexpected structure: %q %q", b.FileName(), b.subFolder)
+	}
+}
+
+// TestCalibreLibraryBook_Download copies the file, keeps the folder structure and skips existing files.
+func TestCalibreLibraryBook_Download(t *testing.T) {
+	lib := &sharefs.LocalFolder{Folder: newTestLibrary(t)}
+	dst := t.TempDir()
+
+	b := NewCalibreLibraryBook(testBook(), []string{"epub"}, nil, "", true, lib)
+	if err := b.Download(dst, false); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	dstPath := filepath.Join(dst, "Frank Herbert", "Dune (1)", b.FileName())
+	if data, err := os.ReadFile(dstPath); err != nil || string(data) != "DUNE-EPUB" {
+		t.Fatalf("unexpected file: %q %v", data, err)
+	}
+
+	// Existing files are kept unless overwriting
+	if err := os.WriteFile(dstPath, []byte("OLD"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Download(dst, false); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if data, _ := os.ReadFile(dstPath); string(data) != "OLD" {
+		t.Fatalf("existing file overwritten")
+	}
+	if err := b.Download(dst, true); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if data, _ := os.ReadFile(dstPath); string(data) != "DUNE-EPUB" {
+		t.Fatalf("existing file not overwritten")
+	}
+
+	// Missing source files fail without leaving temp files behind
+	missing := testBook()
+	missing.FileNames["EPUB"] = "gone"
+	b = NewCalibreLibraryBook(missing, []string{"epub"}, nil, "", false, lib)
+	if err := b.Download(dst, false); err == nil {
+		t.Fatalf("expected error for missing file")
+	}
+	if entries, _ := filepath.Glob(filepath.Join(dst, "bookshift-*")); len(entries) != 0 {
+		t.Fatalf("temp files left behind: %v", entries)
+	}
+}
+
+// TestCalibreLibraryBook_DownloadRejectsEscapingPaths never writes outside the target folder.
+func TestCalibreLibraryBook_DownloadRejectsEscapingPaths(t *testing.T) {
+	info := testBook()
+	info.Path = "../outside"
+	b := NewCalibreLibraryBook(info, []string{"epub"}, nil, "", true, &sharefs.LocalFolder{Folder: t.TempDir()})
+	if err := b.Download(t.TempDir(), false); err == nil {
+		t.Fatalf("expected invalid folder error")
+	}
+}
+
+// TestCalibreLibraryBook_DryRun copies nothing.
+func TestCalibreLibraryBook_DryRun(t *testing.T) {
+	old := util.DryRun
+	t.Cleanup(func() { util.DryRun = old })
+	util.DryRun = true
+
+	lib := &sharefs.LocalFolder{Folder: newTestLibrary(t)}
+	dst := t.TempDir()
+	if err := NewCalibreLibraryBook(testBook(), []string{"epub"}, nil, "", false, lib).Download(dst, false); err != nil {
+		t.Fatalf("dry-run: %v", err)
+	}
+	if err := NewCalibreLibraryBook(testBook(), []string{"epub"}, nil, "", true, lib).Download(dst, false); err != nil {
+		t.Fatalf("dry-run: %v", err)
+	}
+	if entries, _ := os.ReadDir(dst); len(entries) != 0 {
+		t.Fatalf("files written in dry-run: %v", entries)
+	}
+}
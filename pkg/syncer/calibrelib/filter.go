@@ -0,0 +1,74 @@
+package calibrelib
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// BookFilter selects books from the library. All of its conditions must
+// match; empty conditions match every book. Comparisons are case-insensitive.
+type BookFilter struct {
+	Tags          []string          // the book must have all of these tags
+	ExcludeTags   []string          // the book must have none of these tags
+	Series        string            // the book must be part of this series
+	Languages     []string          // the book must be in one of these languages
+	CustomColumns map[string]string // lookup name -> value, "" matches books without a value
+}
+
+// Columns returns the lookup names of the custom columns used by the filter.
+func (f BookFilter) Columns() []string {
+	return slices.Sorted(maps.Keys(f.CustomColumns))
+}
+
+// Matches reports whether the book satisfies the filter.
+func (f BookFilter) Matches(book *LibraryBook) bool {
+	for _, tag := range f.Tags {
+		if !containsFold(book.Tags, tag) {
+			return false
+		}
+	}
+	for _, tag := range f.ExcludeTags {
+		if containsFold(book.Tags, tag) {
+			return false
+		}
+	}
+	if f.Series != "" && !strings.EqualFold(book.Series, f.Series) {
+		return false
+	}
+	if len(f.Languages) > 0 && !slices.ContainsFunc(f.Languages, func(lang string) bool { return containsFold(book.Languages, lang) }) {
+		return false
+	}
+	for lookup, want := range f.CustomColumns {
+		if !customMatches(book.Custom[strings.TrimPrefix(lookup, "#")], want) {
+			return false
+		}
+	}
+	return true
+}
+
+// customMatches compares a custom column value with the wanted value. For
+// bool columns "false" also matches books without a value, so a "Read"
+// column selects every book that has not been marked as read.
+func customMatches(value CustomValue, want string) bool {
+	if want == "" {
+		return len(value.Values) == 0
+	}
+	if value.Datatype == "bool" {
+		want = strings.ToLower(want)
+		switch want {
+		case "yes":
+			want = "true"
+		case "no":
+			want = "false"
+		}
+		if want == "false" && len(value.Values) == 0 {
+			return true
+		}
+	}
+	return containsFold(value.Values, want)
+}
+
+func containsFold(values []string, want string) bool {
+	return slices.ContainsFunc(values, func(v string) bool { return strings.EqualFold(v, want) })
+}
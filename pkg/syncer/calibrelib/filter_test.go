@@ -0,0 +1,64 @@
+package calibrelib
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bjw-s-labs/bookshift/pkg/syncer/calibre"
+)
+
+// TestBookFilter_Matches covers tags, series, languages and custom columns.
+func TestBookFilter_Matches(t *testing.T) {
+	book := &LibraryBook{
+		CalibreBookInfo: calibre.CalibreBookInfo{ID: 1, Title: "Dune", Series: "Dune"},
+		Tags:            []string{"Fiction", "SciFi"},
+		Languages:       []string{"eng"},
+		Custom: map[string]CustomValue{
+			"read":  {Datatype: "bool"},
+			"shelf": {Datatype: "text", Values: []string{"Kobo", "Favorites"}},
+			"notes": {Datatype: "comments"},
+		},
+	}
+
+	cases := []struct {
+		name   string
+		filter BookFilter
+		want   bool
+	}{
+		{"empty", BookFilter{}, true},
+		{"all tags", BookFilter{Tags: []string{"fiction", "SCIFI"}}, true},
+		{"missing tag", BookFilter{Tags: []string{"Fiction", "Classic"}}, false},
+		{"excluded tag", BookFilter{ExcludeTags: []string{"scifi"}}, false},
+		{"series", BookFilter{Series: "dune"}, true},
+		{"other series", BookFilter{Series: "Foundation"}, false},
+		{"language", BookFilter{Languages: []string{"deu", "ENG"}}, true},
+		{"other language", BookFilter{Languages: []string{"deu"}}, false},
+		{"unset bool is false", BookFilter{CustomColumns: map[string]string{"#read": "false"}}, true},
+		{"unset bool is not true", BookFilter{CustomColumns: map[string]string{"read": "yes"}}, false},
+		{"multi value", BookFilter{CustomColumns: map[string]string{"shelf": "kobo"}}, true},
+		{"multi value miss", BookFilter{CustomColumns: map[string]string{"shelf": "Later"}}, false},
+		{"empty value", BookFilter{CustomColumns: map[string]string{"notes": ""}}, true},
+		{"empty value miss", BookFilter{CustomColumns: map[string]string{"shelf": ""}}, false},
+	}
+	for _, tc := range cases {
+		if got := tc.filter.Matches(book); got != tc.want {
+			t.Errorf("%s: Matches()=%v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	book.Custom["read"] = CustomValue{Datatype: "bool", Values: []string{"true"}}
+	if (BookFilter{CustomColumns: map[string]string{"read": "false"}}).Matches(book) {
+		t.Fatalf("read book matched read=false")
+	}
+	if !(BookFilter{CustomColumns: map[string]string{"read": "Yes"}}).Matches(book) {
+		t.Fatalf("read book did not match read=yes")
+	}
+}
+
+// TestBookFilter_Columns lists the custom columns in a stable order.
+func TestBookFilter_Columns(t *testing.T) {
+	f := BookFilter{CustomColumns: map[string]string{"shelf": "Kobo", "#read": "false"}}
+	if got := f.Columns(); !reflect.DeepEqual(got, []string{"#read", "shelf"}) {
+		t.Fatalf("unexpected columns: %v", got)
+	}
+}
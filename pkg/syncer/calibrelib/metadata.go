@@ -0,0 +1,233 @@
+package calibrelib
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/bjw-s-labs/bookshift/pkg/syncer/calibre"
+	_ "modernc.org/sqlite"
+)
+
+// metadataFile is the Calibre database in the root of every library.
+const metadataFile = "metadata.db"
+
+// LibraryBook is a book read from metadata.db.
+type LibraryBook struct {
+	calibre.CalibreBookInfo
+
+	// Path is the folder of the book relative to the library, e.g. "Author/Title (123)"
+	Path      string
+	Tags      []string
+	Languages []string
+	// FileNames maps each format (EPUB, PDF, ...) to its file name without extension
+	FileNames map[string]string
+	// Custom holds the values of the requested custom columns by lookup name
+	Custom map[string]CustomValue
+}
+
+// CustomValue is the value of a custom column for one book. Bool columns hold
+// "true" or "false"; multi-value columns (tag-like) can hold several values.
+type CustomValue struct {
+	Datatype string
+	Values   []string
+}
+
+// customColumn is a row of the custom_columns table.
+type customColumn struct {
+	id         int
+	label      string
+	datatype   string
+	normalized bool
+}
+
+type MetadataDB struct {
+	db *sql.DB
+}
+
+// OpenMetadataDB opens a copy of metadata.db read-only.
+func OpenMetadataDB(dbPath string) (*MetadataDB, error) {
+	db, err := sql.Open("sqlite", "file:"+dbPath+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &MetadataDB{db: db}, nil
+}
+
+func (m *MetadataDB) Close() error {
+	return m.db.Close()
+}
+
+// Books returns all books in the library with their formats, tags, languages
+// and the values of the given custom columns (lookup names, with or without #).
+func (m *MetadataDB) Books(customColumns []string) ([]*LibraryBook, error) {
+	var books []*LibraryBook
+	byID := map[int]*LibraryBook{}
+
+	rows, err := m.db.Query(`SELECT b.id, b.title, b.path, COALESCE(b.series_index, 1), COALESCE(s.name, '')
+		FROM books b
+		LEFT JOIN books_series_link bs ON bs.book = b.id
+		LEFT JOIN series s ON s.id = bs.series
+		ORDER BY b.id`)
+	if err != nil {
+		return nil, fmt.Errorf("could not read books: %w", err)
+	}
+	for rows.Next() {
+		book := &LibraryBook{
+			FileNames: map[string]string{},
+			Custom:    map[string]CustomValue{},
+		}
+		book.FormatSizes = map[string]int64{}
+		if err := rows.Scan(&book.ID, &book.Title, &book.Path, &book.SeriesIndex, &book.Series); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		books = append(books, book)
+		byID[book.ID] = book
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Authors, in the order they were added to the book
+	if err := m.eachValue(`SELECT ba.book, a.name FROM books_authors_link ba JOIN authors a ON a.id = ba.author ORDER BY ba.id`, func(id int, value string) {
+		if book := byID[id]; book != nil {
+			book.Authors = append(book.Authors, value)
+		}
+	}); err != nil {
+		return nil, fmt.Errorf("could not read authors: %w", err)
+	}
+
+	if err := m.eachValue(`SELECT bt.book, t.name FROM books_tags_link bt JOIN tags t ON t.id = bt.tag`, func(id int, value string) {
+		if book := byID[id]; book != nil {
+			book.Tags = append(book.Tags, value)
+		}
+	}); err != nil {
+		return nil, fmt.Errorf("could not read tags: %w", err)
+	}
+
+	if err := m.eachValue(`SELECT bl.book, l.lang_code FROM books_languages_link bl JOIN languages l ON l.id = bl.lang_code ORDER BY bl.item_order`, func(id int, value string) {
+		if book := byID[id]; book != nil {
+			book.Languages = append(book.Languages, value)
+		}
+	}); err != nil {
+		return nil, fmt.Errorf("could not read languages: %w", err)
+	}
+
+	if err := m.readFormats(byID); err != nil {
+		return nil, fmt.Errorf("could not read formats: %w", err)
+	}
+
+	for _, lookup := range customColumns {
+		if err := m.readCustomColumn(strings.TrimPrefix(lookup, "#"), byID); err != nil {
+			return nil, err
+		}
+	}
+
+	return books, nil
+}
+
+// readFormats fills the formats, file names and sizes from the data table.
+func (m *MetadataDB) readFormats(byID map[int]*LibraryBook) error {
+	rows, err := m.db.Query(`SELECT book, format, name, COALESCE(uncompressed_size, 0) FROM data`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			id           int
+			format, name string
+			size         int64
+		)
+		if err := rows.Scan(&id, &format, &name, &size); err != nil {
+			return err
+		}
+		book := byID[id]
+		if book == nil {
+			continue
+		}
+		format = strings.ToUpper(format)
+		book.Formats = append(book.Formats, format)
+		book.FormatSizes[format] = size
+		book.FileNames[format] = name
+	}
+	return rows.Err()
+}
+
+// readCustomColumn reads the values of one custom column for all books.
+// Normalized columns (text, series, enumeration, ...) store their values in a
+// separate table linked to the books; the others store one value per book.
+func (m *MetadataDB) readCustomColumn(label string, byID map[int]*LibraryBook) error {
+	var col customColumn
+	err := m.db.QueryRow(`SELECT id, label, datatype, normalized FROM custom_columns WHERE label = ? AND mark_for_delete = 0`, label).
+		Scan(&col.id, &col.label, &col.datatype, &col.normalized)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("custom column #%s not found in Calibre library", label)
+	}
+	if err != nil {
+		return fmt.Errorf("could not read custom column #%s: %w", label, err)
+	}
+
+	for _, book := range byID {
+		book.Custom[col.label] = CustomValue{Datatype: col.datatype}
+	}
+
+	query := fmt.Sprintf(`SELECT book, value FROM custom_column_%d`, col.id)
+	if col.normalized {
+		query = fmt.Sprintf(`SELECT l.book, v.value FROM books_custom_column_%d_link l JOIN custom_column_%d v ON v.id = l.value`, col.id, col.id)
+	}
+	if err := m.eachValue(query, func(id int, value string) {
+		book := byID[id]
+		if book == nil {
+			return
+		}
+		if col.datatype == "bool" {
+			value = boolValue(value)
+		}
+		cv := book.Custom[col.label]
+		cv.Values = append(cv.Values, value)
+		book.Custom[col.label] = cv
+	}); err != nil {
+		return fmt.Errorf("could not read custom column #%s: %w", label, err)
+	}
+	return nil
+}
+
+// eachValue runs a query returning (book id, value) rows and calls fn for
+// every row with a non-NULL value.
+func (m *MetadataDB) eachValue(query string, fn func(id int, value string)) error {
+	rows, err := m.db.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			id    int
+			value sql.NullString
+		)
+		if err := rows.Scan(&id, &value); err != nil {
+			return err
+		}
+		if value.Valid {
+			fn(id, value.String)
+		}
+	}
+	return rows.Err()
+}
+
+// boolValue normalizes the 0/1 stored for bool custom columns.
+func boolValue(value string) string {
+	switch strings.ToLower(value) {
+	case "1", "true":
+		return "true"
+	default:
+		return "false"
+	}
+}
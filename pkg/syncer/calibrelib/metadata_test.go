@@ -0,0 +1,75 @@
+package calibrelib
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestMetadataDB_Books reads books with their metadata and custom columns.
+func TestMetadataDB_Books(t *testing.T) {
+	root := newTestLibrary(t)
+	db, err := OpenMetadataDB(filepath.Join(root, metadataFile))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	books, err := db.Books([]string{"#read", "shelf"})
+	if err != nil {
+		t.Fatalf("books: %v", err)
+	}
+	if len(books) != 3 {
+		t.Fatalf("want 3 books, got %d", len(books))
+	}
+
+	dune := books[0]
+	if dune.Title != "Dune" || dune.Path != "Frank Herbert/Dune (1)" || dune.Series != "Dune" || dune.SeriesIndex != 1 {
+		t.Fatalf("unexpected book: %+v", dune)
+	}
+	if !reflect.DeepEqual(dune.Authors, []string{"Frank Herbert"}) || !reflect.DeepEqual(dune.Tags, []string{"Fiction", "SciFi"}) || !reflect.DeepEqual(dune.Languages, []string{"eng"}) {
+		t.Fatalf("unexpected metadata: %+v", dune)
+	}
+	if dune.FileNames["EPUB"] != "Dune - Frank Herbert" || dune.FormatSizes["PDF"] != 8 || len(dune.Formats) != 2 {
+		t.Fatalf("unexpected formats: %+v", dune)
+	}
+	if got := dune.Custom["read"]; got.Datatype != "bool" || !reflect.DeepEqual(got.Values, []string{"true"}) {
+		t.Fatalf("unexpected read column: %+v", got)
+	}
+	if got := dune.Custom["shelf"]; !reflect.DeepEqual(got.Values, []string{"Favorites", "Kobo"}) {
+		t.Fatalf("unexpected shelf column: %+v", got)
+	}
+	if got := books[1].Custom["read"]; len(got.Values) != 0 || got.Datatype != "bool" {
+		t.Fatalf("expected empty read column: %+v", got)
+	}
+	if got := books[2].Custom["read"].Values; !reflect.DeepEqual(got, []string{"false"}) {
+		t.Fatalf("unexpected read column: %v", got)
+	}
+}
+
+// TestMetadataDB_Errors reports unknown columns and unreadable databases.
+func TestMetadataDB_Errors(t *testing.T) {
+	root := newTestLibrary(t)
+	db, err := OpenMetadataDB(filepath.Join(root, metadataFile))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Books([]string{"missing"}); err == nil || !strings.Contains(err.Error(), "#missing not found") {
+		t.Fatalf("expected missing column error, got %v", err)
+	}
+
+	if _, err := OpenMetadataDB(filepath.Join(root, "nope.db")); err == nil {
+		t.Fatalf("expected open error")
+	}
+
+	notADB := filepath.Join(root, filepath.FromSlash("Jane Austen/Emma (2)/Emma - Jane Austen.epub"))
+	if db2, err := OpenMetadataDB(notADB); err == nil {
+		defer db2.Close()
+		_, err = db2.Books(nil)
+		if err == nil {
+			t.Fatalf("expected error reading a non-database file")
+		}
+	}
+}
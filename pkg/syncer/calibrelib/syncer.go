@@ -0,0 +1,118 @@
+package calibrelib
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+type CalibreLibrarySyncer struct {
+	config *config.CalibreLibraryConfig
+}
+
+func NewCalibreLibrarySyncer(libraryConfig *config.CalibreLibraryConfig) *CalibreLibrarySyncer {
+	return &CalibreLibrarySyncer{
+		config: libraryConfig,
+	}
+}
+
+func (s *CalibreLibrarySyncer) Run(targetFolder string, validExtensions []string, overwriteExistingFiles bool) error {
+	return s.RunContext(context.Background(), targetFolder, validExtensions, overwriteExistingFiles)
+}
+
+func (s *CalibreLibrarySyncer) RunContext(ctx context.Context, targetFolder string, validExtensions []string, overwriteExistingFiles bool) error {
+	if s.config.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(s.config.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	// Fall back to the global extension list as format preference
+	formats := s.config.Formats
+	if len(formats) == 0 {
+		formats = validExtensions
+	}
+	if len(formats) == 0 {
+		return fmt.Errorf("no formats configured for Calibre library")
+	}
+
+	// Connect to the library
+	library, err := newLibraryClient(s.config)
+	if err != nil {
+		return err
+	}
+	if err := libraryConnect(library, 60*time.Second); err != nil {
+		return fmt.Errorf("could not connect to Calibre library %s: %w", library.Location(), err)
+	}
+	defer library.Disconnect()
+
+	// Work on a local copy of metadata.db, so Calibre can keep writing to
+	// the library and SQLite never has to lock a file on a share
+	dbFile, err := os.CreateTemp("", "bookshift-metadata-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(dbFile.Name())
+	_, err = library.ReadFile(metadataFile, dbFile)
+	if cerr := dbFile.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("could not read %s from Calibre library %s: %w", metadataFile, library.Location(), err)
+	}
+
+	db, err := libraryOpenDB(dbFile.Name())
+	if err != nil {
+		return fmt.Errorf("could not open %s from Calibre library %s: %w", metadataFile, library.Location(), err)
+	}
+	defer db.Close()
+
+	// Select the books
+	filter := BookFilter{
+		Tags:          s.config.Tags,
+		ExcludeTags:   s.config.ExcludeTags,
+		Series:        s.config.Series,
+		Languages:     s.config.Languages,
+		CustomColumns: s.config.CustomColumns,
+	}
+	books, err := libraryBooks(db, filter.Columns())
+	if err != nil {
+		return fmt.Errorf("could not read books from Calibre library %s: %w", library.Location(), err)
+	}
+	var selected []*LibraryBook
+	for _, book := range books {
+		if filter.Matches(book) {
+			selected = append(selected, book)
+		}
+	}
+	slog.Info("Found matching books in Calibre library", "library", library.Location(), "count", len(selected), "total", len(books))
+
+	// Download the books
+	for _, info := range selected {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		book := NewCalibreLibraryBook(info, formats, validExtensions, s.config.FilenameTemplate, s.config.KeepFolderStructure, library)
+		if book == nil {
+			slog.Debug("No preferred format available, skipping book", "id", info.ID, "title", info.Title, "formats", info.Formats)
+			continue
+		}
+		if err := libraryDownload(book, targetFolder, overwriteExistingFiles); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
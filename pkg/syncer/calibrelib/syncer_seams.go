@@ -0,0 +1,26 @@
+// syncer_seams.go: Test seams for the Calibre library syncer.
+//
+// This file defines small function variables that production code uses by default,
+// but tests can override to substitute fakes. Keeping these seams in a separate
+// file keeps the main implementation clean while preserving simple overrides in tests.
+package calibrelib
+
+import (
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/bjw-s-labs/bookshift/pkg/sharefs"
+)
+
+// test hooks (seams) for dependency injection in tests
+var (
+	newLibraryClient = func(cfg *config.CalibreLibraryConfig) (sharefs.FolderAPI, error) {
+		return sharefs.NewFolder(cfg.Folder, cfg.Share)
+	}
+	libraryConnect  = func(c sharefs.FolderAPI, timeout time.Duration) error { return c.Connect(timeout) }
+	libraryOpenDB   = func(dbPath string) (*MetadataDB, error) { return OpenMetadataDB(dbPath) }
+	libraryBooks    = func(db *MetadataDB, columns []string) ([]*LibraryBook, error) { return db.Books(columns) }
+	libraryDownload = func(b *CalibreLibraryBook, dst string, overwrite bool) error {
+		return b.Download(dst, overwrite)
+	}
+)
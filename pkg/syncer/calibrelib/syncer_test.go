@@ -0,0 +1,103 @@
+package calibrelib
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+// TestCalibreLibrarySyncer_Run_EndToEnd selects unread fiction from a local library.
+func TestCalibreLibrarySyncer_Run_EndToEnd(t *testing.T) {
+	cfg := &config.CalibreLibraryConfig{
+		Folder:        newTestLibrary(t),
+		Tags:          []string{"fiction"},
+		CustomColumns: map[string]string{"#read": "false"},
+	}
+	dst := t.TempDir()
+	if err := NewCalibreLibrarySyncer(cfg).Run(dst, []string{".epub", ".azw3"}, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	entries, _ := os.ReadDir(dst)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if strings.Join(names, ",") != "der-process-franz-kafka.azw3,emma-jane-austen.epub" {
+		t.Fatalf("unexpected files: %v", names)
+	}
+}
+
+// TestCalibreLibrarySyncer_Run_KeepFolderStructure mirrors the library layout.
+func TestCalibreLibrarySyncer_Run_KeepFolderStructure(t *testing.T) {
+	cfg := &config.CalibreLibraryConfig{
+		Folder:              newTestLibrary(t),
+		Series:              "Dune",
+		Formats:             []string{"pdf"},
+		KeepFolderStructure: true,
+	}
+	dst := t.TempDir()
+	if err := NewCalibreLibrarySyncer(cfg).Run(dst, []string{".epub", ".pdf"}, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	matches, _ := filepath.Glob(filepath.Join(dst, "Frank Herbert", "Dune (1)", "*.pdf"))
+	if len(matches) != 1 {
+		t.Fatalf("expected the PDF in the library layout, got %v", matches)
+	}
+}
+
+// TestCalibreLibrarySyncer_Run_Errors covers configuration, library and download errors.
+func TestCalibreLibrarySyncer_Run_Errors(t *testing.T) {
+	root := newTestLibrary(t)
+
+	if err := NewCalibreLibrarySyncer(&config.CalibreLibraryConfig{Folder: root}).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected no formats error")
+	}
+	if err := NewCalibreLibrarySyncer(&config.CalibreLibraryConfig{Folder: root + "/missing"}).Run(t.TempDir(), []string{".epub"}, false); err == nil || !strings.Contains(err.Error(), "could not connect") {
+		t.Fatalf("expected connect error, got %v", err)
+	}
+	if err := NewCalibreLibrarySyncer(&config.CalibreLibraryConfig{Folder: t.TempDir()}).Run(t.TempDir(), []string{".epub"}, false); err == nil || !strings.Contains(err.Error(), metadataFile) {
+		t.Fatalf("expected missing metadata.db error, got %v", err)
+	}
+	if err := NewCalibreLibrarySyncer(&config.CalibreLibraryConfig{Folder: root, CustomColumns: map[string]string{"rating": "5"}}).Run(t.TempDir(), []string{".epub"}, false); err == nil || !strings.Contains(err.Error(), "#rating") {
+		t.Fatalf("expected unknown column error, got %v", err)
+	}
+	if err := NewCalibreLibrarySyncer(&config.CalibreLibraryConfig{Share: &config.Source{Type: "s3", Config: &config.S3Config{}}}).Run(t.TempDir(), []string{".epub"}, false); err == nil {
+		t.Fatalf("expected unsupported share error")
+	}
+
+	orig := libraryDownload
+	t.Cleanup(func() { libraryDownload = orig })
+	libraryDownload = func(b *CalibreLibraryBook, dst string, overwrite bool) error { return errors.New("disk full") }
+	if err := NewCalibreLibrarySyncer(&config.CalibreLibraryConfig{Folder: root}).Run(t.TempDir(), []string{".epub"}, false); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Fatalf("expected download error, got %v", err)
+	}
+}
+
+// TestCalibreLibrarySyncer_Run_Cancelled stops before and between books.
+func TestCalibreLibrarySyncer_Run_Cancelled(t *testing.T) {
+	cfg := &config.CalibreLibraryConfig{Folder: newTestLibrary(t)}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := NewCalibreLibrarySyncer(cfg).RunContext(ctx, t.TempDir(), []string{".epub"}, false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	orig := libraryDownload
+	t.Cleanup(func() { libraryDownload = orig })
+	downloads := 0
+	libraryDownload = func(b *CalibreLibraryBook, dst string, overwrite bool) error {
+		downloads++
+		cancel()
+		return nil
+	}
+	if err := NewCalibreLibrarySyncer(cfg).RunContext(ctx, t.TempDir(), []string{".epub"}, false); !errors.Is(err, context.Canceled) || downloads != 1 {
+		t.Fatalf("expected cancellation after one book, got %v after %d", err, downloads)
+	}
+}
@@ -0,0 +1,74 @@
+package calibrelib
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// libraryFiles are the book files of the test library, relative to its root.
+var libraryFiles = map[string]string{
+	"Frank Herbert/Dune (1)/Dune - Frank Herbert.epub": "DUNE-EPUB",
+	"Frank Herbert/Dune (1)/Dune - Frank Herbert.pdf":  "DUNE-PDF",
+	"Jane Austen/Emma (2)/Emma - Jane Austen.epub":     "EMMA",
+	"Franz Kafka/Der Process (3)/Der Process.azw3":     "KAFKA",
+}
+
+// newTestLibrary writes a Calibre library with a minimal metadata.db holding
+// three books, a bool "read" column and a normalized, multi-value "shelf" column.
+func newTestLibrary(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range libraryFiles {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	db, err := sql.Open("sqlite", filepath.Join(root, metadataFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, stmt := range []string{
+		`CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT, path TEXT, series_index REAL DEFAULT 1.0)`,
+		`CREATE TABLE authors (id INTEGER PRIMARY KEY, name TEXT)`,
+		`CREATE TABLE books_authors_link (id INTEGER PRIMARY KEY, book INTEGER, author INTEGER)`,
+		`CREATE TABLE tags (id INTEGER PRIMARY KEY, name TEXT)`,
+		`CREATE TABLE books_tags_link (id INTEGER PRIMARY KEY, book INTEGER, tag INTEGER)`,
+		`CREATE TABLE series (id INTEGER PRIMARY KEY, name TEXT)`,
+		`CREATE TABLE books_series_link (id INTEGER PRIMARY KEY, book INTEGER, series INTEGER)`,
+		`CREATE TABLE languages (id INTEGER PRIMARY KEY, lang_code TEXT)`,
+		`CREATE TABLE books_languages_link (id INTEGER PRIMARY KEY, book INTEGER, lang_code INTEGER, item_order INTEGER)`,
+		`CREATE TABLE data (id INTEGER PRIMARY KEY, book INTEGER, format TEXT, uncompressed_size INTEGER, name TEXT)`,
+		`CREATE TABLE custom_columns (id INTEGER PRIMARY KEY, label TEXT, name TEXT, datatype TEXT, mark_for_delete BOOL DEFAULT 0, normalized BOOL)`,
+		`CREATE TABLE custom_column_1 (id INTEGER PRIMARY KEY, book INTEGER, value BOOL)`,
+		`CREATE TABLE custom_column_2 (id INTEGER PRIMARY KEY, value TEXT)`,
+		`CREATE TABLE books_custom_column_2_link (id INTEGER PRIMARY KEY, book INTEGER, value INTEGER)`,
+
+		`INSERT INTO books VALUES (1, 'Dune', 'Frank Herbert/Dune (1)', 1), (2, 'Emma', 'Jane Austen/Emma (2)', 1), (3, 'Der Process', 'Franz Kafka/Der Process (3)', 1)`,
+		`INSERT INTO authors VALUES (1, 'Frank Herbert'), (2, 'Jane Austen'), (3, 'Franz Kafka')`,
+		`INSERT INTO books_authors_link (book, author) VALUES (1, 1), (2, 2), (3, 3)`,
+		`INSERT INTO tags VALUES (1, 'Fiction'), (2, 'SciFi'), (3, 'Classic')`,
+		`INSERT INTO books_tags_link (book, tag) VALUES (1, 1), (1, 2), (2, 1), (2, 3), (3, 1)`,
+		`INSERT INTO series VALUES (1, 'Dune')`,
+		`INSERT INTO books_series_link (book, series) VALUES (1, 1)`,
+		`INSERT INTO languages VALUES (1, 'eng'), (2, 'deu')`,
+		`INSERT INTO books_languages_link (book, lang_code, item_order) VALUES (1, 1, 0), (2, 1, 0), (3, 2, 0)`,
+		`INSERT INTO data (book, format, uncompressed_size, name) VALUES (1, 'EPUB', 9, 'Dune - Frank Herbert'), (1, 'PDF', 8, 'Dune - Frank Herbert'), (2, 'EPUB', 4, 'Emma - Jane Austen'), (3, 'AZW3', 5, 'Der Process')`,
+		`INSERT INTO custom_columns (id, label, name, datatype, normalized) VALUES (1, 'read', 'Read', 'bool', 0), (2, 'shelf', 'Shelf', 'text', 1)`,
+		`INSERT INTO custom_column_1 (book, value) VALUES (1, 1), (3, 0)`,
+		`INSERT INTO custom_column_2 VALUES (1, 'Favorites'), (2, 'Kobo')`,
+		`INSERT INTO books_custom_column_2_link (book, value) VALUES (1, 1), (1, 2), (2, 2)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	return root
+}
@@ -0,0 +1,130 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+	"github.com/go-playground/sensitive"
+)
+
+// Package-level errors
+var (
+	ErrFeedDisconnected = fmt.Errorf("not connected to the feed server")
+)
+
+// FeedAPI is the minimal contract used by the syncer and download logic.
+// It enables injecting a fake in tests.
+type FeedAPI interface {
+	Connect(ctx context.Context, timeout time.Duration) error
+	Disconnect() error
+	FetchFeed(feedURL string) ([]FeedItem, error)
+	ReadFile(fileURL string, w io.Writer) (int64, error)
+	Host() string
+}
+
+type FeedClient struct {
+	baseURL  *url.URL
+	username string
+	password *sensitive.String
+
+	// ctx bounds every request of the session
+	ctx    context.Context
+	client *http.Client
+}
+
+func NewFeedClient(feedURL string, username string, password *sensitive.String) (*FeedClient, error) {
+	u, err := url.Parse(feedURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid feed url %s: %w", feedURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid feed url %s: scheme must be http or https", feedURL)
+	}
+
+	return &FeedClient{
+		baseURL:  u,
+		username: username,
+		password: password,
+	}, nil
+}
+
+// Connect prepares the HTTP client used for all requests. The timeout bounds
+// connecting and waiting for responses, not the transfer of enclosures.
+func (c *FeedClient) Connect(ctx context.Context, timeout time.Duration) error {
+	slog.Debug("Initiating feed connection", "host", c.Host())
+	c.ctx = ctx
+	c.client = util.NewHTTPClient(timeout)
+	return nil
+}
+
+// Disconnect releases idle connections held by the HTTP client.
+func (c *FeedClient) Disconnect() error {
+	slog.Debug("Disconnecting feed connection", "host", c.Host())
+	if c.client != nil {
+		c.client.CloseIdleConnections()
+		c.client = nil
+	}
+	return nil
+}
+
+// FetchFeed retrieves and parses an RSS or Atom feed.
+func (c *FeedClient) FetchFeed(feedURL string) ([]FeedItem, error) {
+	resp, err := c.get(feedURL, "application/rss+xml, application/atom+xml, application/xml;q=0.9, */*;q=0.8")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch feed %s: %s", feedURL, resp.Status)
+	}
+
+	// Resolve relative links against the final URL after redirects
+	return parseFeed(resp.Body, resp.Request.URL)
+}
+
+// ReadFile streams a remote file to the provided writer.
+func (c *FeedClient) ReadFile(fileURL string, w io.Writer) (int64, error) {
+	resp, err := c.get(fileURL, "*/*")
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed to download %s: %s", fileURL, resp.Status)
+	}
+	return io.Copy(w, resp.Body)
+}
+
+func (c *FeedClient) Host() string {
+	return c.baseURL.Host
+}
+
+// get sends a GET request. Credentials are only sent to the feed host.
+func (c *FeedClient) get(rawURL string, accept string) (*http.Response, error) {
+	if c.client == nil {
+		return nil, ErrFeedDisconnected
+	}
+
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	if c.username != "" && strings.EqualFold(req.URL.Host, c.baseURL.Host) {
+		var password string
+		if c.password != nil {
+			password = string(*c.password)
+		}
+		req.SetBasicAuth(c.username, password)
+	}
+	return c.client.Do(req)
+}
@@ -0,0 +1,78 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestNewFeedClient_InvalidURL ensures unsupported schemes are rejected.
+func TestNewFeedClient_InvalidURL(t *testing.T) {
+	if _, err := NewFeedClient("ftp://h/feed", "", nil); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+// TestFeedClient_FetchAndRead fetches the feed and a file from the test server.
+func TestFeedClient_FetchAndRead(t *testing.T) {
+	srvURL, _ := newFeedServer(t)
+	c, _ := NewFeedClient(srvURL+"/feed.xml", "", nil)
+	if _, err := c.FetchFeed(srvURL + "/feed.xml"); err != ErrFeedDisconnected {
+		t.Fatalf("expected ErrFeedDisconnected, got %v", err)
+	}
+	if err := c.Connect(context.Background(), 5*time.Second); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Disconnect() })
+
+	items, err := c.FetchFeed(srvURL + "/feed.xml")
+	if err != nil || len(items) != 3 {
+		t.Fatalf("fetch: %v %d", err, len(items))
+	}
+	if items[0].Enclosures[0].Href != srvURL+"/files/time-machine.epub" {
+		t.Fatalf("links must resolve against the feed URL: %s", items[0].Enclosures[0].Href)
+	}
+
+	var buf bytes.Buffer
+	if _, err := c.ReadFile(srvURL+"/files/x.epub", &buf); err != nil || buf.String() != "file:x.epub" {
+		t.Fatalf("read: %v %q", err, buf.String())
+	}
+	if _, err := c.FetchFeed(srvURL + "/missing.xml"); err == nil {
+		t.Fatalf("expected error for missing feed")
+	}
+	if _, err := c.ReadFile(srvURL+"/missing.epub", &buf); err == nil {
+		t.Fatalf("expected error for missing file")
+	}
+}
+
+// TestFeedClient_SlowDownload verifies enclosures may take longer than the
+// connect timeout to download and are stopped by the session context.
+func TestFeedClient_SlowDownload(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for range 3 {
+			_, _ = w.Write([]byte("chunk"))
+			w.(http.Flusher).Flush()
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c, _ := NewFeedClient(srv.URL+"/feed", "", nil)
+	if err := c.Connect(ctx, 50*time.Millisecond); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := c.ReadFile(srv.URL+"/book.epub", &buf); err != nil || buf.String() != "chunkchunkchunk" {
+		t.Fatalf("slow download: %q %v", buf.String(), err)
+	}
+
+	cancel()
+	if _, err := c.ReadFile(srv.URL+"/book.epub", &buf); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+package feed
+
+import (
+	"log/slog"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+type FeedDownload struct {
+	item     FeedItem
+	link     FeedLink
+	fileName string
+
+	feedClient FeedAPI
+}
+
+// NewFeedDownloads returns the enclosures of item, and with includeLinks its
+// links, whose MIME type or URL extension matches validExtensions.
+func NewFeedDownloads(item FeedItem, validExtensions []string, includeLinks bool, conn FeedAPI) []*FeedDownload {
+	// Build a lower-cased set of valid extensions for case-insensitive match
+	lowerExts := make([]string, 0, len(validExtensions))
+	for _, e := range validExtensions {
+		lowerExts = append(lowerExts, strings.ToLower(e))
+	}
+
+	candidates := item.Enclosures
+	if includeLinks {
+		candidates = append(slices.Clone(candidates), item.Links...)
+	}
+
+	var downloads []*FeedDownload
+	seen := map[string]bool{}
+	for _, link := range candidates {
+		if seen[link.Href] {
+			continue
+		}
+		extension := linkExtension(link)
+		if extension == "" || (len(lowerExts) > 0 && !slices.Contains(lowerExts, extension)) {
+			continue
+		}
+		seen[link.Href] = true
+		downloads = append(downloads, &FeedDownload{
+			item:       item,
+			link:       link,
+			fileName:   fileName(item, link, extension),
+			feedClient: conn,
+		})
+	}
+	return downloads
+}
+
+// linkExtension derives the file extension from the link's MIME type, falling back to the URL path.
+func linkExtension(l FeedLink) string {
+	if ext := util.ExtensionForMimeType(l.Type); ext != "" {
+		return ext
+	}
+	if u, err := url.Parse(l.Href); err == nil {
+		return strings.ToLower(path.Ext(u.Path))
+	}
+	return ""
+}
+
+// fileName uses the name from the URL when it carries the expected extension,
+// and the item title otherwise (e.g. for "/download?id=1" style links).
+func fileName(item FeedItem, link FeedLink, extension string) string {
+	if u, err := url.Parse(link.Href); err == nil {
+		base := path.Base(u.Path)
+		if strings.EqualFold(path.Ext(base), extension) {
+			return util.SafeFileName(base)
+		}
+	}
+	name := item.Title
+	if name == "" {
+		name = "feed-item"
+	}
+	return util.SafeFileName(strings.ReplaceAll(name, "/", " ") + extension)
+}
+
+func (d *FeedDownload) Download(dstFolder string, overwriteExistingFile bool) error {
+	dstPath := filepath.Join(dstFolder, d.fileName)
+
+	// Create folder structure if required
+	if _, err := os.Stat(dstFolder); os.IsNotExist(err) {
+		if util.DryRun {
+			slog.Info("[dry-run] Would create local folder", "folder", dstFolder)
+			return nil
+		}
+		slog.Info("Creating local folder", "folder", dstFolder)
+		if err := os.MkdirAll(dstFolder, 0755); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("Downloading feed item", "host", d.feedClient.Host(), "title", d.item.Title, "url", d.link.Href, "destination", dstPath)
+
+	// Check if the file already exists
+	_, err := os.Stat(dstPath)
+	if !os.IsNotExist(err) {
+		if !overwriteExistingFile {
+			slog.Warn("File already exists, skipping download", "file", dstPath)
+			return nil
+		}
+
+		slog.Info("Overwriting existing file", "file", dstPath)
+	}
+
+	// Download the file
+	if util.DryRun {
+		slog.Info("[dry-run] Would download file", "source", d.link.Href, "destination", dstPath)
+		return nil
+	}
+	tmpFile, err := os.CreateTemp(dstFolder, "bookshift-")
+	if err != nil {
+		return err
+	}
+	defer tmpFile.Close()
+
+	slog.Debug("Downloading to temporary file", "file", tmpFile.Name())
+	// Feeds often carry a wrong enclosure length, so the size is treated as unknown
+	writer := util.NewFileWriter(tmpFile, 0, true)
+	if _, err := d.feedClient.ReadFile(d.link.Href, writer); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+
+	if err := tmpFile.Sync(); err != nil { // ensure data flushed before rename
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	if err := os.Rename(tmpFile.Name(), dstPath); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+
+	slog.Info("Successfully downloaded file", "filename", d.fileName)
+	return nil
+}
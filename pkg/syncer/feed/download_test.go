@@ -0,0 +1,81 @@
+package feed
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// TestNewFeedDownloads filters by MIME type or extension and optionally includes links.
+func TestNewFeedDownloads(t *testing.T) {
+	item := FeedItem{
+		GUID:  "1",
+		Title: "Emma",
+		Enclosures: []FeedLink{
+			{Href: "https://cdn/download?id=1", Type: "application/epub+zip"},
+			{Href: "https://cdn/emma.pdf"},
+		},
+		Links: []FeedLink{{Href: "https://pub/emma.kepub"}, {Href: "https://pub/emma.html"}},
+	}
+
+	got := NewFeedDownloads(item, []string{".epub", ".kepub"}, false, nil)
+	if len(got) != 1 || got[0].fileName != "emma.epub" {
+		t.Fatalf("unexpected downloads: %+v", got)
+	}
+
+	got = NewFeedDownloads(item, []string{".EPUB", ".kepub"}, true, nil)
+	if len(got) != 2 || got[1].fileName != "emma.kepub" {
+		t.Fatalf("unexpected downloads with links: %+v", got)
+	}
+
+	got = NewFeedDownloads(item, nil, false, nil)
+	if len(got) != 2 || got[1].fileName != "emma.pdf" {
+		t.Fatalf("without a filter every typed link is used: %+v", got)
+	}
+}
+
+// TestFeedDownload_Download writes the file and skips existing files.
+func TestFeedDownload_Download(t *testing.T) {
+	fake := &fakeFeed{files: map[string]string{"https://cdn/a.epub": "DATA"}}
+	item := FeedItem{Title: "A", Enclosures: []FeedLink{{Href: "https://cdn/a.epub"}}}
+	d := NewFeedDownloads(item, nil, false, fake)[0]
+
+	dst := filepath.Join(t.TempDir(), "books")
+	if err := d.Download(dst, false); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if got, err := os.ReadFile(filepath.Join(dst, "a.epub")); err != nil || string(got) != "DATA" {
+		t.Fatalf("read: %v %q", err, string(got))
+	}
+
+	fake.files["https://cdn/a.epub"] = "NEW"
+	if err := d.Download(dst, false); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dst, "a.epub")); string(got) != "DATA" {
+		t.Fatalf("existing file must be kept")
+	}
+}
+
+// TestFeedDownload_Download_ErrorAndDryRun ensures failures leave nothing behind and dry-run writes nothing.
+func TestFeedDownload_Download_ErrorAndDryRun(t *testing.T) {
+	item := FeedItem{Title: "A", Enclosures: []FeedLink{{Href: "https://cdn/a.epub"}}}
+	d := NewFeedDownloads(item, nil, false, &fakeFeed{})[0]
+
+	dst := t.TempDir()
+	if err := d.Download(dst, false); err == nil {
+		t.Fatalf("expected error")
+	}
+	if entries, _ := os.ReadDir(dst); len(entries) != 0 {
+		t.Fatalf("expected no leftovers")
+	}
+
+	old := util.DryRun
+	t.Cleanup(func() { util.DryRun = old })
+	util.DryRun = true
+	if err := d.Download(dst, false); err != nil {
+		t.Fatalf("dry-run: %v", err)
+	}
+}
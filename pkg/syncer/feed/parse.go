@@ -0,0 +1,172 @@
+package feed
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+// FeedLink is a downloadable target of a feed item.
+type FeedLink struct {
+	Href   string
+	Type   string
+	Length int64
+}
+
+// FeedItem is the format-independent view of an RSS item or Atom entry.
+type FeedItem struct {
+	GUID       string
+	Title      string
+	Enclosures []FeedLink
+	Links      []FeedLink
+}
+
+// rssItem mirrors the subset of an RSS 0.9x/1.0/2.0 item that is used.
+type rssItem struct {
+	GUID      string `xml:"guid"`
+	Title     string `xml:"title"`
+	Link      string `xml:"link"`
+	Enclosure []struct {
+		URL    string `xml:"url,attr"`
+		Type   string `xml:"type,attr"`
+		Length string `xml:"length,attr"`
+	} `xml:"enclosure"`
+}
+
+// rssDocument covers RSS 2.0 (items below channel) and RSS 1.0/RDF (items at the root).
+type rssDocument struct {
+	Channel struct {
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Items []rssItem `xml:"item"`
+}
+
+// atomDocument mirrors the subset of an Atom feed that is used.
+type atomDocument struct {
+	Entries []struct {
+		ID    string `xml:"http://www.w3.org/2005/Atom id"`
+		Title string `xml:"http://www.w3.org/2005/Atom title"`
+		Links []struct {
+			Rel    string `xml:"rel,attr"`
+			Href   string `xml:"href,attr"`
+			Type   string `xml:"type,attr"`
+			Length string `xml:"length,attr"`
+		} `xml:"http://www.w3.org/2005/Atom link"`
+	} `xml:"http://www.w3.org/2005/Atom entry"`
+}
+
+// parseFeed decodes an RSS or Atom document, resolving relative links against base.
+func parseFeed(r io.Reader, base *url.URL) ([]FeedItem, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = charset.NewReaderLabel
+	decoder.Strict = false
+
+	// Find the root element to pick the format
+	var root xml.StartElement
+	for {
+		tok, err := decoder.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("empty feed document")
+			}
+			return nil, fmt.Errorf("failed to parse feed: %w", err)
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			root = se
+			break
+		}
+	}
+
+	switch strings.ToLower(root.Name.Local) {
+	case "rss", "rdf":
+		var doc rssDocument
+		if err := decoder.DecodeElement(&doc, &root); err != nil {
+			return nil, fmt.Errorf("failed to parse RSS feed: %w", err)
+		}
+		return rssItems(append(doc.Channel.Items, doc.Items...), base), nil
+	case "feed":
+		var doc atomDocument
+		if err := decoder.DecodeElement(&doc, &root); err != nil {
+			return nil, fmt.Errorf("failed to parse Atom feed: %w", err)
+		}
+		return atomItems(doc, base), nil
+	default:
+		return nil, fmt.Errorf("unsupported feed format: <%s>", root.Name.Local)
+	}
+}
+
+func rssItems(items []rssItem, base *url.URL) []FeedItem {
+	out := make([]FeedItem, 0, len(items))
+	for _, it := range items {
+		item := FeedItem{GUID: strings.TrimSpace(it.GUID), Title: strings.TrimSpace(it.Title)}
+		for _, e := range it.Enclosure {
+			if href := resolve(base, e.URL); href != "" {
+				item.Enclosures = append(item.Enclosures, FeedLink{Href: href, Type: e.Type, Length: parseLength(e.Length)})
+			}
+		}
+		if href := resolve(base, it.Link); href != "" {
+			item.Links = append(item.Links, FeedLink{Href: href})
+		}
+		out = append(out, withFallbackGUID(item))
+	}
+	return out
+}
+
+func atomItems(doc atomDocument, base *url.URL) []FeedItem {
+	out := make([]FeedItem, 0, len(doc.Entries))
+	for _, e := range doc.Entries {
+		item := FeedItem{GUID: strings.TrimSpace(e.ID), Title: strings.TrimSpace(e.Title)}
+		for _, l := range e.Links {
+			href := resolve(base, l.Href)
+			if href == "" {
+				continue
+			}
+			link := FeedLink{Href: href, Type: l.Type, Length: parseLength(l.Length)}
+			switch l.Rel {
+			case "enclosure":
+				item.Enclosures = append(item.Enclosures, link)
+			case "", "alternate":
+				item.Links = append(item.Links, link)
+			}
+		}
+		out = append(out, withFallbackGUID(item))
+	}
+	return out
+}
+
+// withFallbackGUID identifies items without a guid/id by their first link or title.
+func withFallbackGUID(item FeedItem) FeedItem {
+	switch {
+	case item.GUID != "":
+	case len(item.Links) > 0:
+		item.GUID = item.Links[0].Href
+	case len(item.Enclosures) > 0:
+		item.GUID = item.Enclosures[0].Href
+	default:
+		item.GUID = item.Title
+	}
+	return item
+}
+
+func resolve(base *url.URL, href string) string {
+	href = strings.TrimSpace(href)
+	if href == "" {
+		return ""
+	}
+	u, err := base.Parse(href)
+	if err != nil {
+		return ""
+	}
+	return u.String()
+}
+
+func parseLength(s string) int64 {
+	n, _ := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	return n
+}
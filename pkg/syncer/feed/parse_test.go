@@ -0,0 +1,72 @@
+package feed
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// TestParseFeed_RSS extracts enclosures and links and resolves relative URLs.
+func TestParseFeed_RSS(t *testing.T) {
+	base, _ := url.Parse("https://se.example/feeds/new.xml")
+	items, err := parseFeed(strings.NewReader(rssBody), base)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("want 3 items, got %d", len(items))
+	}
+	it := items[0]
+	if it.GUID != "urn:se:time-machine" || it.Title != "The Time Machine" {
+		t.Fatalf("unexpected item: %+v", it)
+	}
+	if len(it.Enclosures) != 2 || it.Enclosures[0].Href != "https://se.example/files/time-machine.epub" || it.Enclosures[0].Length != 4 {
+		t.Fatalf("unexpected enclosures: %+v", it.Enclosures)
+	}
+	if len(it.Links) != 1 || it.Links[0].Href != "https://se.example/ebooks/time-machine" {
+		t.Fatalf("unexpected links: %+v", it.Links)
+	}
+}
+
+// TestParseFeed_Atom maps rel=enclosure and alternate links and falls back to the link as guid.
+func TestParseFeed_Atom(t *testing.T) {
+	base, _ := url.Parse("https://publisher.example/atom")
+	items, err := parseFeed(strings.NewReader(atomBody), base)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("want 2 items, got %d", len(items))
+	}
+	if items[0].GUID != "tag:publisher,2024:1" || len(items[0].Enclosures) != 1 || len(items[0].Links) != 1 {
+		t.Fatalf("unexpected entry: %+v", items[0])
+	}
+	if items[1].GUID != "https://publisher.example/persuasion.epub" {
+		t.Fatalf("expected link fallback guid, got %q", items[1].GUID)
+	}
+}
+
+// TestParseFeed_RDFAndCharset covers RSS 1.0 documents in a legacy encoding.
+func TestParseFeed_RDFAndCharset(t *testing.T) {
+	body := "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n" +
+		`<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/">` +
+		"<item><title>Caf\xe9</title><link>http://h/cafe.epub</link></item></rdf:RDF>"
+	base, _ := url.Parse("http://h/")
+	items, err := parseFeed(strings.NewReader(body), base)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(items) != 1 || items[0].Title != "Café" || items[0].GUID != "http://h/cafe.epub" {
+		t.Fatalf("unexpected items: %+v", items)
+	}
+}
+
+// TestParseFeed_Invalid reports unsupported and broken documents.
+func TestParseFeed_Invalid(t *testing.T) {
+	base, _ := url.Parse("http://h/")
+	for _, body := range []string{"", "<html><body/></html>", "<rss><channel><item><title>x</title>"} {
+		if _, err := parseFeed(strings.NewReader(body), base); err == nil {
+			t.Fatalf("expected error for %q", body)
+		}
+	}
+}
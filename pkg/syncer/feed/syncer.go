@@ -0,0 +1,114 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// feedState is persisted between runs to remember processed items.
+type feedState struct {
+	Seen []string `json:"seen"`
+}
+
+type FeedSyncer struct {
+	config *config.FeedConfig
+}
+
+func NewFeedSyncer(feedConfig *config.FeedConfig) *FeedSyncer {
+	return &FeedSyncer{
+		config: feedConfig,
+	}
+}
+
+func (s *FeedSyncer) Run(targetFolder string, validExtensions []string, overwriteExistingFiles bool) error {
+	return s.RunContext(context.Background(), targetFolder, validExtensions, overwriteExistingFiles)
+}
+
+func (s *FeedSyncer) RunContext(ctx context.Context, targetFolder string, validExtensions []string, overwriteExistingFiles bool) (err error) {
+	if s.config.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(s.config.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	// Load the items processed during previous runs
+	statePath := s.config.StateFile
+	if statePath == "" {
+		statePath = util.DefaultStatePath(targetFolder, "feed", s.config.URL)
+	}
+	var state feedState
+	if err := util.LoadState(statePath, &state); err != nil {
+		return fmt.Errorf("could not load feed state from %s: %w", statePath, err)
+	}
+	seen := map[string]bool{}
+	for _, guid := range state.Seen {
+		seen[guid] = true
+	}
+
+	// Connect to the feed server
+	feedClient, err := newFeedClient(s.config)
+	if err != nil {
+		return err
+	}
+	if err := feedConnect(ctx, feedClient, 60*time.Second); err != nil {
+		return fmt.Errorf("could not connect to feed server %s: %w", s.config.URL, err)
+	}
+	defer feedClient.Disconnect()
+
+	items, err := feedFetch(feedClient, s.config.URL)
+	if err != nil {
+		return fmt.Errorf("could not fetch feed %s: %w", s.config.URL, err)
+	}
+
+	// Only remember items that are still in the feed, plus whatever gets processed now.
+	// The state is saved even when a later item fails.
+	state.Seen = state.Seen[:0]
+	for _, item := range items {
+		if seen[item.GUID] {
+			state.Seen = append(state.Seen, item.GUID)
+		}
+	}
+	defer func() {
+		if saveErr := util.SaveState(statePath, &state); saveErr != nil && err == nil {
+			err = fmt.Errorf("could not save feed state to %s: %w", statePath, saveErr)
+		}
+	}()
+
+	// Download all new items
+	for _, item := range items {
+		if seen[item.GUID] {
+			slog.Debug("Skipping already processed feed item", "guid", item.GUID, "title", item.Title)
+			continue
+		}
+
+		downloads := NewFeedDownloads(item, validExtensions, s.config.IncludeLinks, feedClient)
+		if len(downloads) == 0 {
+			slog.Debug("No link of the feed item matches the valid extensions", "guid", item.GUID, "title", item.Title)
+		}
+		for _, d := range downloads {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+			}
+			if err := feedDownload(d, targetFolder, overwriteExistingFiles); err != nil {
+				return err
+			}
+		}
+
+		seen[item.GUID] = true
+		state.Seen = append(state.Seen, item.GUID)
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+// syncer_seams.go: Test seams for the feed syncer.
+//
+// This file defines small function variables that production code uses by default,
+// but tests can override to substitute fakes. Keeping these seams in a separate
+// file keeps the main implementation clean while preserving simple overrides in tests.
+package feed
+
+import (
+	"context"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+// test hooks (seams) for dependency injection in tests
+var (
+	newFeedClient = func(cfg *config.FeedConfig) (FeedAPI, error) {
+		return NewFeedClient(cfg.URL, cfg.Username, cfg.Password)
+	}
+	feedConnect  = func(ctx context.Context, c FeedAPI, timeout time.Duration) error { return c.Connect(ctx, timeout) }
+	feedFetch    = func(c FeedAPI, feedURL string) ([]FeedItem, error) { return c.FetchFeed(feedURL) }
+	feedDownload = func(d *FeedDownload, dst string, overwrite bool) error {
+		return d.Download(dst, overwrite)
+	}
+)
@@ -0,0 +1,108 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// TestFeedSyncer_Run_EndToEnd downloads matching enclosures once and remembers processed items.
+func TestFeedSyncer_Run_EndToEnd(t *testing.T) {
+	srvURL, requests := newFeedServer(t)
+	dst := t.TempDir()
+	cfg := &config.FeedConfig{URL: srvURL + "/feed.xml", IncludeLinks: true}
+
+	if err := NewFeedSyncer(cfg).Run(dst, []string{".epub", ".kepub"}, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	entries, _ := os.ReadDir(dst)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if strings.Join(names, ",") != ".bookshift,flatland.kepub,time-machine.epub" {
+		t.Fatalf("unexpected files: %v", names)
+	}
+
+	var state feedState
+	if err := util.LoadState(util.DefaultStatePath(dst, "feed", cfg.URL), &state); err != nil || len(state.Seen) != 3 {
+		t.Fatalf("expected 3 processed items: %v %+v", err, state)
+	}
+
+	// Processed items are not downloaded again, even after the files are removed
+	_ = os.Remove(filepath.Join(dst, "flatland.kepub"))
+	*requests = nil
+	if err := NewFeedSyncer(cfg).Run(dst, []string{".epub", ".kepub"}, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(*requests) != 1 || (*requests)[0] != "/feed.xml" {
+		t.Fatalf("unexpected requests on second run: %v", *requests)
+	}
+}
+
+// TestFeedSyncer_Run_PrunesAndKeepsProgress verifies state pruning and that progress survives a failure.
+func TestFeedSyncer_Run_PrunesAndKeepsProgress(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	if err := util.SaveState(statePath, feedState{Seen: []string{"gone", "a"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	fake := &fakeFeed{
+		items: []FeedItem{
+			{GUID: "a", Enclosures: []FeedLink{{Href: "https://h/a.epub"}}},
+			{GUID: "b", Enclosures: []FeedLink{{Href: "https://h/b.epub"}}},
+			{GUID: "c", Enclosures: []FeedLink{{Href: "https://h/c.epub"}}},
+		},
+		files: map[string]string{"https://h/b.epub": "B"},
+	}
+	orig := newFeedClient
+	t.Cleanup(func() { newFeedClient = orig })
+	newFeedClient = func(cfg *config.FeedConfig) (FeedAPI, error) { return fake, nil }
+
+	cfg := &config.FeedConfig{URL: "https://h/feed", StateFile: statePath}
+	if err := NewFeedSyncer(cfg).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected error for c.epub")
+	}
+
+	var state feedState
+	_ = util.LoadState(statePath, &state)
+	if strings.Join(state.Seen, ",") != "a,b" {
+		t.Fatalf("unexpected state: %v", state.Seen)
+	}
+}
+
+// TestFeedSyncer_Run_Errors ensures client, connect and fetch errors abort the run.
+func TestFeedSyncer_Run_Errors(t *testing.T) {
+	if err := NewFeedSyncer(&config.FeedConfig{URL: "ftp://h"}).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected client error")
+	}
+
+	srvURL, _ := newFeedServer(t)
+	if err := NewFeedSyncer(&config.FeedConfig{URL: srvURL + "/missing.xml"}).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected fetch error")
+	}
+
+	origConnect := feedConnect
+	t.Cleanup(func() { feedConnect = origConnect })
+	feedConnect = func(ctx context.Context, c FeedAPI, timeout time.Duration) error { return errors.New("x") }
+	if err := NewFeedSyncer(&config.FeedConfig{URL: srvURL + "/feed.xml"}).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected connect error")
+	}
+}
+
+// TestFeedSyncer_RunContext_Cancel verifies that a canceled context stops the run.
+func TestFeedSyncer_RunContext_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cfg := &config.FeedConfig{URL: "http://h/feed"}
+	if err := NewFeedSyncer(cfg).RunContext(ctx, t.TempDir(), nil, false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled, got %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rssBody is an RSS 2.0 feed with enclosures and a plain link.
+const rssBody = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+  <channel>
+    <title>New releases</title>
+    <item>
+      <title>The Time Machine</title>
+      <guid>urn:se:time-machine</guid>
+      <link>/ebooks/time-machine</link>
+      <enclosure url="/files/time-machine.epub" type="application/epub+zip" length="4"/>
+      <enclosure url="/files/time-machine.azw3" type="application/x-mobi8-ebook" length="4"/>
+    </item>
+    <item>
+      <title>Flatland</title>
+      <guid>urn:se:flatland</guid>
+      <link>/files/flatland.kepub</link>
+    </item>
+    <item>
+      <title>Audio only</title>
+      <guid>urn:se:audio</guid>
+      <enclosure url="/files/audio.mp3" type="audio/mpeg"/>
+    </item>
+  </channel>
+</rss>`
+
+// atomBody is an Atom feed with an enclosure, an alternate link and an id-less entry.
+const atomBody = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Publisher</title>
+  <entry>
+    <id>tag:publisher,2024:1</id>
+    <title>Emma</title>
+    <link rel="alternate" href="https://publisher.example/emma.html" type="text/html"/>
+    <link rel="enclosure" href="https://cdn.example/download?id=1" type="application/epub+zip" length="10"/>
+  </entry>
+  <entry>
+    <title>Persuasion</title>
+    <link href="https://publisher.example/persuasion.epub"/>
+  </entry>
+</feed>`
+
+// newFeedServer serves rssBody at /feed.xml and every /files/* path as "file:<name>".
+func newFeedServer(t *testing.T) (string, *[]string) {
+	t.Helper()
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		switch {
+		case r.URL.Path == "/feed.xml":
+			w.Header().Set("Content-Type", "application/rss+xml")
+			_, _ = io.WriteString(w, rssBody)
+		case strings.HasPrefix(r.URL.Path, "/files/"):
+			_, _ = io.WriteString(w, "file:"+strings.TrimPrefix(r.URL.Path, "/files/"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL, &requests
+}
+
+// fakeFeed implements FeedAPI in memory.
+type fakeFeed struct {
+	items []FeedItem
+	files map[string]string
+}
+
+func (f *fakeFeed) Connect(ctx context.Context, timeout time.Duration) error { return nil }
+func (f *fakeFeed) Disconnect() error                                        { return nil }
+func (f *fakeFeed) Host() string                                             { return "fake" }
+func (f *fakeFeed) FetchFeed(feedURL string) ([]FeedItem, error)             { return f.items, nil }
+func (f *fakeFeed) ReadFile(u string, w io.Writer) (int64, error) {
+	s, ok := f.files[u]
+	if !ok {
+		return 0, errors.New("not found")
+	}
+	n, err := w.Write([]byte(s))
+	return int64(n), err
+}
@@ -0,0 +1,159 @@
+package ftp
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/sensitive"
+	"github.com/jlaffaye/ftp"
+)
+
+// Package-level errors
+var (
+	ErrFtpDisconnected = fmt.Errorf("not connected to the FTP server")
+)
+
+// FtpAPI is the minimal contract used by folder and file logic.
+// It enables injecting a fake in tests.
+type FtpAPI interface {
+	Connect(timeout time.Duration) error
+	Disconnect() error
+	List(path string) ([]*ftp.Entry, error)
+	ReadFile(path string, w io.Writer) (int64, error)
+	DeleteFile(path string) error
+	Hostname() string
+}
+
+// ftpLowLevel captures the minimal calls used from the underlying ftp.ServerConn.
+type ftpLowLevel interface {
+	Login(user, password string) error
+	List(path string) ([]*ftp.Entry, error)
+	Retr(path string) (io.ReadCloser, error)
+	Delete(path string) error
+	Quit() error
+}
+
+type FtpClient struct {
+	Host               string
+	Port               int
+	Username           string
+	Password           *sensitive.String
+	Security           string
+	InsecureSkipVerify bool
+	DisableEPSV        bool
+
+	client ftpLowLevel
+}
+
+// serverConnWrapper adapts *ftp.ServerConn to our minimal ftpLowLevel.
+type serverConnWrapper struct{ *ftp.ServerConn }
+
+func (c *serverConnWrapper) Retr(path string) (io.ReadCloser, error) { return c.ServerConn.Retr(path) }
+
+// dial hook for the low-level client (overridable in tests)
+var ftpDial = func(addr string, options ...ftp.DialOption) (ftpLowLevel, error) {
+	c, err := ftp.Dial(addr, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &serverConnWrapper{ServerConn: c}, nil
+}
+
+// Connect dials the FTP server using the configured security mode and logs in.
+// Data connections always use passive mode (EPSV, falling back to PASV).
+func (c *FtpClient) Connect(timeout time.Duration) error {
+	slog.Debug("Initiating FTP connection", "host", c.Host, "security", c.Security)
+
+	options := []ftp.DialOption{
+		ftp.DialWithTimeout(timeout),
+		ftp.DialWithDisabledEPSV(c.DisableEPSV),
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         c.Host,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+	switch c.Security {
+	case "", "none":
+	case "explicit":
+		options = append(options, ftp.DialWithExplicitTLS(tlsConfig))
+	case "implicit":
+		options = append(options, ftp.DialWithTLS(tlsConfig))
+	default:
+		return fmt.Errorf("unsupported FTP security mode: %s", c.Security)
+	}
+
+	client, err := ftpDial(net.JoinHostPort(c.Host, strconv.Itoa(c.Port)), options...)
+	if err != nil {
+		return err
+	}
+
+	username, password := c.Username, ""
+	if c.Password != nil {
+		password = string(*c.Password)
+	}
+	if username == "" {
+		username, password = "anonymous", "anonymous"
+	}
+	if err := client.Login(username, password); err != nil {
+		_ = client.Quit()
+		return fmt.Errorf("failed to log in (%w)", err)
+	}
+
+	c.client = client
+	return nil
+}
+
+// Disconnect ends the FTP session.
+func (c *FtpClient) Disconnect() error {
+	slog.Debug("Disconnecting FTP connection", "host", c.Host)
+
+	if c.client == nil {
+		return nil
+	}
+	err := c.client.Quit()
+	c.client = nil
+	return err
+}
+
+// List returns the entries of a remote directory, using MLSD when the server supports it and LIST otherwise.
+func (c *FtpClient) List(path string) ([]*ftp.Entry, error) {
+	if c.client == nil {
+		return nil, ErrFtpDisconnected
+	}
+	return c.client.List(path)
+}
+
+// ReadFile streams a remote file to the provided writer.
+func (c *FtpClient) ReadFile(path string, w io.Writer) (int64, error) {
+	if c.client == nil {
+		return 0, ErrFtpDisconnected
+	}
+	r, err := c.client.Retr(path)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(w, r)
+	// Closing the data connection reads the transfer status from the control connection
+	if cerr := r.Close(); err == nil {
+		err = cerr
+	}
+	return n, err
+}
+
+// DeleteFile removes a remote file.
+func (c *FtpClient) DeleteFile(path string) error {
+	if c.client == nil {
+		return ErrFtpDisconnected
+	}
+	return c.client.Delete(path)
+}
+
+func (c *FtpClient) Hostname() string {
+	return c.Host
+}
@@ -0,0 +1,133 @@
+package ftp
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-playground/sensitive"
+	"github.com/jlaffaye/ftp"
+)
+
+// TestFtpClient_NotConnectedErrors ensures client methods error when not connected.
+func TestFtpClient_NotConnectedErrors(t *testing.T) {
+	c := &FtpClient{Host: "h"}
+	if _, err := c.List("/"); err == nil {
+		t.Fatalf("expected error")
+	}
+	if _, err := c.ReadFile("/x", nil); err == nil {
+		t.Fatalf("expected error")
+	}
+	if err := c.DeleteFile("/x"); err == nil {
+		t.Fatalf("expected error")
+	}
+	if err := c.Disconnect(); err != nil {
+		t.Fatalf("disconnect without connection: %v", err)
+	}
+	if c.Hostname() != "h" {
+		t.Fatalf("hostname mismatch")
+	}
+}
+
+// TestFtpClient_Connect_SecurityModes verifies TLS options are added per security mode.
+func TestFtpClient_Connect_SecurityModes(t *testing.T) {
+	orig := ftpDial
+	t.Cleanup(func() { ftpDial = orig })
+
+	tests := []struct {
+		security string
+		options  int
+		wantErr  bool
+	}{
+		{"", 2, false},
+		{"none", 2, false},
+		{"explicit", 3, false},
+		{"implicit", 3, false},
+		{"bogus", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.security, func(t *testing.T) {
+			got := -1
+			ftpDial = func(addr string, options ...ftp.DialOption) (ftpLowLevel, error) {
+				got = len(options)
+				return &fakeFtpLow{}, nil
+			}
+			c := &FtpClient{Host: "h", Port: 21, Security: tt.security}
+			err := c.Connect(time.Second)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("connect: %v", err)
+			}
+			if got != tt.options {
+				t.Fatalf("want %d dial options, got %d", tt.options, got)
+			}
+		})
+	}
+}
+
+// TestFtpClient_Connect_Login verifies credentials, anonymous fallback and login errors.
+func TestFtpClient_Connect_Login(t *testing.T) {
+	orig := ftpDial
+	t.Cleanup(func() { ftpDial = orig })
+	low := &fakeFtpLow{}
+	ftpDial = func(addr string, options ...ftp.DialOption) (ftpLowLevel, error) { return low, nil }
+
+	pw := sensitive.String("pw")
+	c := &FtpClient{Host: "h", Port: 21, Username: "u", Password: &pw}
+	if err := c.Connect(time.Second); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if low.user != "u" || low.pass != "pw" {
+		t.Fatalf("unexpected credentials %q/%q", low.user, low.pass)
+	}
+	if err := c.Disconnect(); err != nil || !low.quit {
+		t.Fatalf("expected quit on disconnect: %v", err)
+	}
+
+	anon := &FtpClient{Host: "h", Port: 21}
+	if err := anon.Connect(time.Second); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if low.user != "anonymous" {
+		t.Fatalf("expected anonymous login, got %q", low.user)
+	}
+
+	low.loginErr = errors.New("530")
+	low.quit = false
+	if err := c.Connect(time.Second); err == nil || !low.quit {
+		t.Fatalf("expected login error and quit, got %v", err)
+	}
+}
+
+// TestFtpClient_Connect_DialError ensures dialing errors are returned.
+func TestFtpClient_Connect_DialError(t *testing.T) {
+	orig := ftpDial
+	t.Cleanup(func() { ftpDial = orig })
+	ftpDial = func(addr string, options ...ftp.DialOption) (ftpLowLevel, error) { return nil, errors.New("dial") }
+	c := &FtpClient{Host: "h", Port: 21}
+	if err := c.Connect(time.Second); err == nil {
+		t.Fatalf("expected dial error")
+	}
+}
+
+// TestFtpClient_ReadAndDelete verifies pass-through of transfers and deletions.
+func TestFtpClient_ReadAndDelete(t *testing.T) {
+	low := &fakeFtpLow{reads: map[string]string{"/a.epub": "abc"}}
+	c := &FtpClient{Host: "h", client: low}
+	var buf bytes.Buffer
+	if n, err := c.ReadFile("/a.epub", &buf); err != nil || n != 3 || buf.String() != "abc" {
+		t.Fatalf("read n=%d err=%v", n, err)
+	}
+	if _, err := c.ReadFile("/missing", &buf); err == nil {
+		t.Fatalf("expected error")
+	}
+	if err := c.DeleteFile("/a.epub"); err != nil || len(low.deleted) != 1 {
+		t.Fatalf("delete: %v", err)
+	}
+}
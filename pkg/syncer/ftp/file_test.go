@@ -0,0 +1,81 @@
+package ftp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// TestFtpFile_Download_KeepFolderStructureAndDelete verifies subfolders are kept and the source removed.
+func TestFtpFile_Download_KeepFolderStructureAndDelete(t *testing.T) {
+	low := &fakeFtpLow{reads: map[string]string{"/r/a/b/x.epub": "DATA"}}
+	ff := NewFtpFile("/r", "a/b", fileEntry("x.epub", 4), NewFtpFolder("/r", &fakeFtp{low}))
+
+	dst := t.TempDir()
+	if err := ff.Download(dst, "", true, true, true); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if b, err := os.ReadFile(filepath.Join(dst, "a", "b", "x.epub")); err != nil || string(b) != "DATA" {
+		t.Fatalf("read: %v %q", err, string(b))
+	}
+	if len(low.deleted) != 1 || low.deleted[0] != "/r/a/b/x.epub" {
+		t.Fatalf("expected source deleted, got %v", low.deleted)
+	}
+}
+
+// TestFtpFile_Download_SkipWhenExists verifies existing files are kept when overwrite is false.
+func TestFtpFile_Download_SkipWhenExists(t *testing.T) {
+	low := &fakeFtpLow{reads: map[string]string{"/r/y.epub": "NEW"}}
+	ff := NewFtpFile("/r", "", fileEntry("y.epub", 3), NewFtpFolder("/r", &fakeFtp{low}))
+
+	dst := t.TempDir()
+	p := filepath.Join(dst, "y.epub")
+	if err := os.WriteFile(p, []byte("OLD"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ff.Download(dst, "", false, false, true); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if b, _ := os.ReadFile(p); string(b) != "OLD" {
+		t.Fatalf("file was overwritten: %q", string(b))
+	}
+	if len(low.deleted) != 0 {
+		t.Fatalf("skipped file must not delete source")
+	}
+}
+
+// TestFtpFile_Download_ReadError ensures transfer errors are returned and no temp file is left.
+func TestFtpFile_Download_ReadError(t *testing.T) {
+	ff := NewFtpFile("/r", "", fileEntry("z.epub", 1), NewFtpFolder("/r", &fakeFtp{&fakeFtpLow{}}))
+
+	dst := t.TempDir()
+	if err := ff.Download(dst, "", true, false, false); err == nil {
+		t.Fatalf("expected read error")
+	}
+	if entries, _ := os.ReadDir(dst); len(entries) != 0 {
+		t.Fatalf("expected no leftovers, got %d entries", len(entries))
+	}
+}
+
+// TestFtpFile_Download_DryRun ensures no files are written or deleted in dry-run mode.
+func TestFtpFile_Download_DryRun(t *testing.T) {
+	old := util.DryRun
+	t.Cleanup(func() { util.DryRun = old })
+	util.DryRun = true
+
+	low := &fakeFtpLow{reads: map[string]string{"/r/a.epub": "A"}}
+	ff := NewFtpFile("/r", "", fileEntry("a.epub", 1), NewFtpFolder("/r", &fakeFtp{low}))
+
+	dst := t.TempDir()
+	if err := ff.Download(dst, "", true, false, true); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "a.epub")); !os.IsNotExist(err) {
+		t.Fatalf("dry-run must not write files")
+	}
+	if len(low.deleted) != 0 {
+		t.Fatalf("dry-run must not delete")
+	}
+}
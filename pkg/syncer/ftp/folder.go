@@ -0,0 +1,79 @@
+package ftp
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/jlaffaye/ftp"
+)
+
+type FtpFolder struct {
+	Folder string
+
+	ftpClient FtpAPI
+}
+
+func NewFtpFolder(folder string, conn FtpAPI) *FtpFolder {
+	return &FtpFolder{
+		Folder:    folder,
+		ftpClient: conn,
+	}
+}
+
+func (s *FtpFolder) FetchFiles(folder string, validExtensions []string, recurse bool) ([]FtpFile, error) {
+	allFiles, err := s.fetchAllFiles(folder, "", validExtensions, recurse)
+	if err != nil {
+		return nil, err
+	}
+
+	return allFiles, nil
+}
+
+func (s *FtpFolder) fetchAllFiles(rootFolder string, folder string, validExtensions []string, recurse bool) ([]FtpFile, error) {
+	var allFiles []FtpFile
+
+	if folder == "" {
+		folder = rootFolder
+	}
+
+	files, err := s.ftpClient.List(folder)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build a lower-cased set of valid extensions for case-insensitive match
+	lowerExts := make([]string, 0, len(validExtensions))
+	for _, e := range validExtensions {
+		lowerExts = append(lowerExts, strings.ToLower(e))
+	}
+
+	for _, file := range files {
+		// MLSD listings may include the current and parent directory
+		if file.Name == "." || file.Name == ".." {
+			continue
+		}
+
+		fullPath := path.Join(folder, file.Name)
+		if recurse && file.Type == ftp.EntryTypeFolder {
+			tmpFiles, err := s.fetchAllFiles(rootFolder, fullPath, validExtensions, recurse)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list files in directory %s: %w", fullPath, err)
+			}
+			allFiles = append(allFiles, tmpFiles...)
+		} else if file.Type == ftp.EntryTypeFile {
+			extension := strings.ToLower(path.Ext(fullPath))
+			if len(lowerExts) > 0 && !slices.Contains(lowerExts, extension) {
+				continue
+			}
+
+			parentFolder := path.Dir(fullPath)
+			_, subFolder, _ := strings.Cut(parentFolder, rootFolder)
+
+			allFiles = append(allFiles, *NewFtpFile(rootFolder, subFolder, file, s))
+		}
+	}
+
+	return allFiles, nil
+}
@@ -0,0 +1,41 @@
+package ftp
+
+import (
+	"testing"
+
+	"github.com/jlaffaye/ftp"
+)
+
+// TestFtpFolderFetchFiles_Recurse verifies recursion, extension filtering and MLSD dot entries.
+func TestFtpFolderFetchFiles_Recurse(t *testing.T) {
+	low := &fakeFtpLow{entries: map[string][]*ftp.Entry{
+		"/root": {
+			{Name: ".", Type: ftp.EntryTypeFolder},
+			{Name: "..", Type: ftp.EntryTypeFolder},
+			fileEntry("a.EPUB", 1),
+			fileEntry("skip.txt", 1),
+			dirEntry("sub"),
+			{Name: "link.epub", Type: ftp.EntryTypeLink},
+		},
+		"/root/sub": {fileEntry("b.kepub", 2)},
+	}}
+
+	files, err := NewFtpFolder("/root", &fakeFtp{low}).FetchFiles("/root", []string{".epub", ".kepub"}, true)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("want 2, got %d", len(files))
+	}
+	if files[1].subFolder != "/sub" || files[1].remotePath != "/root/sub/b.kepub" {
+		t.Fatalf("unexpected nested file: %+v", files[1])
+	}
+}
+
+// TestFtpFolderFetchFiles_SubfolderError ensures listing errors in subfolders abort the walk.
+func TestFtpFolderFetchFiles_SubfolderError(t *testing.T) {
+	low := &fakeFtpLow{entries: map[string][]*ftp.Entry{"/root": {dirEntry("locked")}}}
+	if _, err := NewFtpFolder("/root", &fakeFtp{low}).FetchFiles("/root", nil, true); err == nil {
+		t.Fatalf("expected error")
+	}
+}
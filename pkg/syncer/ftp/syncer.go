@@ -0,0 +1,80 @@
+package ftp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+type FtpSyncer struct {
+	config *config.FtpConfig
+}
+
+func NewFtpSyncer(serverConfig *config.FtpConfig) *FtpSyncer {
+	// Set default port, implicit FTPS listens on its own port
+	if !(serverConfig.Port > 0) {
+		serverConfig.Port = 21
+		if serverConfig.Security == "implicit" {
+			serverConfig.Port = 990
+		}
+	}
+
+	return &FtpSyncer{
+		config: serverConfig,
+	}
+}
+
+func (s *FtpSyncer) Run(targetFolder string, validExtensions []string, overwriteExistingFiles bool) error {
+	return s.RunContext(context.Background(), targetFolder, validExtensions, overwriteExistingFiles)
+}
+
+func (s *FtpSyncer) RunContext(ctx context.Context, targetFolder string, validExtensions []string, overwriteExistingFiles bool) error {
+	if s.config.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(s.config.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	// Connect to the FTP server
+	ftpClient := newFtpClient(s.config)
+	if err := ftpConnect(ftpClient, 10*time.Second); err != nil {
+		return fmt.Errorf("could not connect to FTP server %s: %w", s.config.Host, err)
+	}
+	defer ftpClient.Disconnect()
+
+	// Instantiate an FTP Folder (via hook)
+	ftpFolder := ftpNewFolder(s.config.Folder, ftpClient)
+
+	// Fetch all files in the folder
+	allFiles, err := ftpFetchFiles(ftpFolder, s.config.Folder, validExtensions, true)
+	if err != nil {
+		return fmt.Errorf("could not fetch files from folder %s on FTP server %s: %w", s.config.Folder, s.config.Host, err)
+	}
+
+	// Download all files
+	for i := range allFiles {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		if err := ftpDownload(&allFiles[i],
+			targetFolder,
+			"",
+			overwriteExistingFiles,
+			s.config.KeepFolderStructure,
+			s.config.RemoveFilesAfterDownload,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+// syncer_seams.go: Test seams for the FTP syncer.
+//
+// This file defines small function variables that production code uses by default,
+// but tests can override to substitute fakes. Keeping these seams in a separate
+// file keeps the main implementation clean while preserving simple overrides in tests.
+package ftp
+
+import (
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+// test hooks (seams) for dependency injection in tests
+var (
+	newFtpClient = func(cfg *config.FtpConfig) FtpAPI {
+		return &FtpClient{
+			Host:               cfg.Host,
+			Port:               cfg.Port,
+			Username:           cfg.Username,
+			Password:           cfg.Password,
+			Security:           cfg.Security,
+			InsecureSkipVerify: cfg.InsecureSkipVerify,
+			DisableEPSV:        cfg.DisableEPSV,
+		}
+	}
+	ftpConnect    = func(c FtpAPI, timeout time.Duration) error { return c.Connect(timeout) }
+	ftpNewFolder  = func(folder string, conn FtpAPI) *FtpFolder { return NewFtpFolder(folder, conn) }
+	ftpFetchFiles = func(f *FtpFolder, folder string, valid []string, recurse bool) ([]FtpFile, error) {
+		return f.FetchFiles(folder, valid, recurse)
+	}
+	ftpDownload = func(ff *FtpFile, dst, name string, overwrite, keep, del bool) error {
+		return ff.Download(dst, name, overwrite, keep, del)
+	}
+)
@@ -0,0 +1,91 @@
+package ftp
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/jlaffaye/ftp"
+)
+
+// TestNewFtpSyncer_DefaultPort ensures the default port follows the security mode.
+func TestNewFtpSyncer_DefaultPort(t *testing.T) {
+	cfg := &config.FtpConfig{}
+	NewFtpSyncer(cfg)
+	if cfg.Port != 21 {
+		t.Fatalf("want 21, got %d", cfg.Port)
+	}
+	cfg = &config.FtpConfig{Security: "implicit"}
+	NewFtpSyncer(cfg)
+	if cfg.Port != 990 {
+		t.Fatalf("want 990, got %d", cfg.Port)
+	}
+}
+
+// TestFtpSyncer_Run_HappyPath downloads matching files through the dial seam.
+func TestFtpSyncer_Run_HappyPath(t *testing.T) {
+	orig := ftpDial
+	t.Cleanup(func() { ftpDial = orig })
+	low := &fakeFtpLow{
+		entries: map[string][]*ftp.Entry{
+			"/books":     {fileEntry("a.epub", 1), fileEntry("c.txt", 1), dirEntry("sub")},
+			"/books/sub": {fileEntry("b.kepub", 2)},
+		},
+		reads: map[string]string{"/books/a.epub": "A", "/books/sub/b.kepub": "BB"},
+	}
+	ftpDial = func(addr string, options ...ftp.DialOption) (ftpLowLevel, error) { return low, nil }
+
+	cfg := &config.FtpConfig{Host: "h", Folder: "/books", KeepFolderStructure: true, RemoveFilesAfterDownload: true}
+	dst := t.TempDir()
+	if err := NewFtpSyncer(cfg).Run(dst, []string{".epub", ".kepub"}, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for _, p := range []string{"a.epub", filepath.Join("sub", "b.kepub")} {
+		if _, err := os.Stat(filepath.Join(dst, p)); err != nil {
+			t.Fatalf("expected %s downloaded: %v", p, err)
+		}
+	}
+	if len(low.deleted) != 2 || !low.quit {
+		t.Fatalf("expected 2 deletions and quit, got %v quit=%v", low.deleted, low.quit)
+	}
+}
+
+// TestFtpSyncer_Run_Errors ensures connect, list and download errors abort the run.
+func TestFtpSyncer_Run_Errors(t *testing.T) {
+	origConn, origNew, origDL := ftpConnect, newFtpClient, ftpDownload
+	t.Cleanup(func() { ftpConnect, newFtpClient, ftpDownload = origConn, origNew, origDL })
+
+	ftpConnect = func(c FtpAPI, timeout time.Duration) error { return errors.New("x") }
+	cfg := &config.FtpConfig{Host: "h", Folder: "/r"}
+	if err := NewFtpSyncer(cfg).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected connect error")
+	}
+
+	ftpConnect = origConn
+	low := &fakeFtpLow{entries: map[string][]*ftp.Entry{"/r": {fileEntry("a.epub", 1)}}}
+	newFtpClient = func(cfg *config.FtpConfig) FtpAPI { return &fakeFtp{low} }
+	cfg.Folder = "/missing"
+	if err := NewFtpSyncer(cfg).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected list error")
+	}
+
+	ftpDownload = func(ff *FtpFile, dst, name string, overwrite, keep, del bool) error { return errors.New("z") }
+	cfg.Folder = "/r"
+	if err := NewFtpSyncer(cfg).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected download error")
+	}
+}
+
+// TestFtpSyncer_RunContext_Cancel verifies that a canceled context stops the run.
+func TestFtpSyncer_RunContext_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cfg := &config.FtpConfig{Host: "h", Folder: "/"}
+	if err := NewFtpSyncer(cfg).RunContext(ctx, t.TempDir(), nil, false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled, got %v", err)
+	}
+}
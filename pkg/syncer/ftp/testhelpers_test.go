@@ -0,0 +1,61 @@
+package ftp
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jlaffaye/ftp"
+)
+
+// fakeFtpLow implements ftpLowLevel and records calls.
+type fakeFtpLow struct {
+	user, pass string
+	loginErr   error
+	entries    map[string][]*ftp.Entry
+	reads      map[string]string
+	deleted    []string
+	quit       bool
+}
+
+func (f *fakeFtpLow) Login(u, p string) error { f.user, f.pass = u, p; return f.loginErr }
+func (f *fakeFtpLow) List(p string) ([]*ftp.Entry, error) {
+	if l, ok := f.entries[p]; ok {
+		return l, nil
+	}
+	return nil, errors.New("550 not found")
+}
+func (f *fakeFtpLow) Retr(p string) (io.ReadCloser, error) {
+	s, ok := f.reads[p]
+	if !ok {
+		return nil, errors.New("550 not found")
+	}
+	return io.NopCloser(strings.NewReader(s)), nil
+}
+func (f *fakeFtpLow) Delete(p string) error { f.deleted = append(f.deleted, p); return nil }
+func (f *fakeFtpLow) Quit() error           { f.quit = true; return nil }
+
+// fakeFtp implements FtpAPI on top of a fakeFtpLow.
+type fakeFtp struct{ low *fakeFtpLow }
+
+func (f *fakeFtp) Connect(timeout time.Duration) error { return nil }
+func (f *fakeFtp) Disconnect() error                   { return nil }
+func (f *fakeFtp) Hostname() string                    { return "fake" }
+func (f *fakeFtp) List(p string) ([]*ftp.Entry, error) { return f.low.List(p) }
+func (f *fakeFtp) DeleteFile(p string) error           { return f.low.Delete(p) }
+func (f *fakeFtp) ReadFile(p string, w io.Writer) (int64, error) {
+	r, err := f.low.Retr(p)
+	if err != nil {
+		return 0, err
+	}
+	return io.Copy(w, r)
+}
+
+func fileEntry(name string, size uint64) *ftp.Entry {
+	return &ftp.Entry{Name: name, Type: ftp.EntryTypeFile, Size: size}
+}
+
+func dirEntry(name string) *ftp.Entry {
+	return &ftp.Entry{Name: name, Type: ftp.EntryTypeFolder}
+}
@@ -0,0 +1,165 @@
+package httpindex
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+	"github.com/go-playground/sensitive"
+)
+
+// Package-level errors
+var (
+	ErrHttpDisconnected = fmt.Errorf("not connected to the web server")
+)
+
+// maxPageSize bounds how much of an index page is read.
+const maxPageSize = 10 << 20
+
+// HttpIndexAPI is the minimal contract used by index and file logic.
+// It enables injecting a fake in tests.
+type HttpIndexAPI interface {
+	Connect(ctx context.Context, timeout time.Duration) error
+	Disconnect() error
+	FetchPage(pageURL string) (*url.URL, []byte, error)
+	ReadFile(fileURL string, validators Validators, w io.Writer) (Validators, bool, error)
+	Host() string
+}
+
+// Validators hold the cache validators of a previously downloaded file.
+type Validators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+type HttpIndexClient struct {
+	baseURL  *url.URL
+	username string
+	password *sensitive.String
+
+	// ctx bounds every request of the session
+	ctx    context.Context
+	client *http.Client
+}
+
+func NewHttpIndexClient(pageURL string, username string, password *sensitive.String) (*HttpIndexClient, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url %s: %w", pageURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid url %s: scheme must be http or https", pageURL)
+	}
+
+	return &HttpIndexClient{
+		baseURL:  u,
+		username: username,
+		password: password,
+	}, nil
+}
+
+// Connect prepares the HTTP client used for all requests. The timeout bounds
+// connecting and waiting for responses, not the transfer of files.
+func (c *HttpIndexClient) Connect(ctx context.Context, timeout time.Duration) error {
+	slog.Debug("Initiating HTTP connection", "host", c.Host())
+	c.ctx = ctx
+	c.client = util.NewHTTPClient(timeout)
+	return nil
+}
+
+// Disconnect releases idle connections held by the HTTP client.
+func (c *HttpIndexClient) Disconnect() error {
+	slog.Debug("Disconnecting HTTP connection", "host", c.Host())
+	if c.client != nil {
+		c.client.CloseIdleConnections()
+		c.client = nil
+	}
+	return nil
+}
+
+// FetchPage returns the body of an HTML page and its final URL after redirects.
+func (c *HttpIndexClient) FetchPage(pageURL string) (*url.URL, []byte, error) {
+	resp, err := c.get(pageURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("failed to fetch page %s: %s", pageURL, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPageSize))
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp.Request.URL, body, nil
+}
+
+// ReadFile streams a remote file to the provided writer using a conditional
+// request. It returns the new validators and false when the server reports the
+// file as not modified, in which case nothing is written.
+func (c *HttpIndexClient) ReadFile(fileURL string, validators Validators, w io.Writer) (Validators, bool, error) {
+	headers := map[string]string{}
+	if validators.ETag != "" {
+		headers["If-None-Match"] = validators.ETag
+	}
+	if validators.LastModified != "" {
+		headers["If-Modified-Since"] = validators.LastModified
+	}
+
+	resp, err := c.get(fileURL, headers)
+	if err != nil {
+		return validators, false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return validators, false, nil
+	case http.StatusOK:
+	default:
+		return validators, false, fmt.Errorf("failed to download %s: %s", fileURL, resp.Status)
+	}
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return validators, false, err
+	}
+	return Validators{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}, true, nil
+}
+
+func (c *HttpIndexClient) Host() string {
+	return c.baseURL.Host
+}
+
+// get sends a GET request. Credentials are only sent to the host of the start page.
+func (c *HttpIndexClient) get(rawURL string, headers map[string]string) (*http.Response, error) {
+	if c.client == nil {
+		return nil, ErrHttpDisconnected
+	}
+
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if c.username != "" && strings.EqualFold(req.URL.Host, c.baseURL.Host) {
+		var password string
+		if c.password != nil {
+			password = string(*c.password)
+		}
+		req.SetBasicAuth(c.username, password)
+	}
+	return c.client.Do(req)
+}
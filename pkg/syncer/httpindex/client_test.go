@@ -0,0 +1,117 @@
+package httpindex
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-playground/sensitive"
+)
+
+// TestNewHttpIndexClient_InvalidURL ensures unsupported schemes are rejected.
+func TestNewHttpIndexClient_InvalidURL(t *testing.T) {
+	if _, err := NewHttpIndexClient("ftp://h/", "", nil); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+// TestHttpIndexClient_NotConnected ensures requests fail before Connect.
+func TestHttpIndexClient_NotConnected(t *testing.T) {
+	c, _ := NewHttpIndexClient("http://h/", "", nil)
+	if _, _, err := c.FetchPage("http://h/"); err != ErrHttpDisconnected {
+		t.Fatalf("expected ErrHttpDisconnected, got %v", err)
+	}
+}
+
+// TestHttpIndexClient_ConditionalRequests verifies ETag and Last-Modified validators are sent back.
+func TestHttpIndexClient_ConditionalRequests(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, ok := r.BasicAuth(); !ok || u != "alice" || p != "pw" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/etag.epub":
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			_, _ = w.Write([]byte("E"))
+		case "/lm.epub":
+			if r.Header.Get("If-Modified-Since") == "Mon, 01 Jan 2024 00:00:00 GMT" {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("Last-Modified", "Mon, 01 Jan 2024 00:00:00 GMT")
+			_, _ = w.Write([]byte("L"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	pw := sensitive.String("pw")
+	c, _ := NewHttpIndexClient(srv.URL+"/", "alice", &pw)
+	if err := c.Connect(context.Background(), 5*time.Second); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Disconnect() })
+
+	var buf bytes.Buffer
+	v, modified, err := c.ReadFile(srv.URL+"/etag.epub", Validators{}, &buf)
+	if err != nil || !modified || v.ETag != `"v1"` || buf.String() != "E" {
+		t.Fatalf("first etag read: %v %v %+v %q", err, modified, v, buf.String())
+	}
+	buf.Reset()
+	if _, modified, err := c.ReadFile(srv.URL+"/etag.epub", v, &buf); err != nil || modified || buf.Len() != 0 {
+		t.Fatalf("second etag read: %v %v %q", err, modified, buf.String())
+	}
+
+	v, modified, _ = c.ReadFile(srv.URL+"/lm.epub", Validators{}, &buf)
+	if !modified || v.LastModified == "" {
+		t.Fatalf("first last-modified read: %v %+v", modified, v)
+	}
+	if _, modified, _ := c.ReadFile(srv.URL+"/lm.epub", v, &buf); modified {
+		t.Fatalf("expected not modified")
+	}
+
+	if _, _, err := c.ReadFile(srv.URL+"/missing.epub", Validators{}, &buf); err == nil {
+		t.Fatalf("expected error for missing file")
+	}
+	if _, _, err := c.FetchPage(srv.URL + "/missing/"); err == nil {
+		t.Fatalf("expected error for missing page")
+	}
+}
+
+// TestHttpIndexClient_SlowDownload verifies files may take longer than the
+// connect timeout to download and are stopped by the session context.
+func TestHttpIndexClient_SlowDownload(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for range 3 {
+			_, _ = w.Write([]byte("chunk"))
+			w.(http.Flusher).Flush()
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c, _ := NewHttpIndexClient(srv.URL, "", nil)
+	if err := c.Connect(ctx, 50*time.Millisecond); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	var buf bytes.Buffer
+	if _, _, err := c.ReadFile(srv.URL+"/book.epub", Validators{}, &buf); err != nil || buf.String() != "chunkchunkchunk" {
+		t.Fatalf("slow download: %q %v", buf.String(), err)
+	}
+
+	cancel()
+	if _, _, err := c.ReadFile(srv.URL+"/book.epub", Validators{}, &buf); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package httpindex
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+type HttpIndexFile struct {
+	URL  string
+	Name string
+
+	subFolder string
+	httpIndex *HttpIndex
+}
+
+func NewHttpIndexFile(fileURL string, name string, subFolder string, httpIndex *HttpIndex) *HttpIndexFile {
+	return &HttpIndexFile{
+		URL:       fileURL,
+		Name:      name,
+		subFolder: subFolder,
+		httpIndex: httpIndex,
+	}
+}
+
+// Download fetches the file unless the server reports it unchanged since the
+// download recorded in state. state is updated with the new cache validators.
+func (f *HttpIndexFile) Download(dstFolder string, overwriteExistingFile bool, keepFolderStructure bool, state httpState) error {
+	// Create folder structure if required
+	if keepFolderStructure {
+		dstFolder = filepath.Join(dstFolder, filepath.FromSlash(f.subFolder))
+	}
+
+	safeFileName := util.SafeFileName(f.Name)
+	dstPath := filepath.Join(dstFolder, safeFileName)
+
+	// Create folder structure if required
+	if _, err := os.Stat(dstFolder); os.IsNotExist(err) {
+		if util.DryRun {
+			slog.Info("[dry-run] Would create local folder", "folder", dstFolder)
+			return nil
+		}
+		slog.Info("Creating local folder", "folder", dstFolder)
+		if err := os.MkdirAll(dstFolder, 0755); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("Downloading file from web server", "host", f.httpIndex.httpClient.Host(), "url", f.URL, "destination", dstPath)
+
+	// Check if the file already exists; the saved validators only apply to it,
+	// so a file that was removed locally is fetched unconditionally
+	validators := state[f.URL]
+	_, err := os.Stat(dstPath)
+	if os.IsNotExist(err) {
+		validators = Validators{}
+	} else {
+		if !overwriteExistingFile {
+			slog.Warn("File already exists, skipping download", "file", dstPath)
+			return nil
+		}
+
+		slog.Info("Overwriting existing file", "file", dstPath)
+	}
+
+	// Download the file
+	if util.DryRun {
+		slog.Info("[dry-run] Would download file", "source", f.URL, "destination", dstPath)
+		return nil
+	}
+	tmpFile, err := os.CreateTemp(dstFolder, "bookshift-")
+	if err != nil {
+		return err
+	}
+	defer tmpFile.Close()
+
+	slog.Debug("Downloading to temporary file", "file", tmpFile.Name())
+	writer := util.NewFileWriter(tmpFile, 0, true)
+	validators, modified, err := f.httpIndex.httpClient.ReadFile(f.URL, validators, writer)
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	if !modified {
+		os.Remove(tmpFile.Name())
+		slog.Info("File not modified since last download, skipping", "url", f.URL)
+		return nil
+	}
+
+	if err := tmpFile.Sync(); err != nil { // ensure data flushed before rename
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	if err := os.Rename(tmpFile.Name(), dstPath); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	state[f.URL] = validators
+
+	slog.Info("Successfully downloaded file", "filename", safeFileName)
+	return nil
+}
@@ -0,0 +1,75 @@
+package httpindex
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// TestHttpIndexFile_Download records validators and skips unchanged files.
+func TestHttpIndexFile_Download(t *testing.T) {
+	fake := &fakeHttp{files: map[string]string{"http://h/sub/My Book.epub": "DATA"}}
+	x := NewHttpIndex("http://h/", nil, 0, fake)
+	f := NewHttpIndexFile("http://h/sub/My Book.epub", "My Book.epub", "sub", x)
+	state := httpState{}
+
+	dst := t.TempDir()
+	if err := f.Download(dst, false, true, state); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	p := filepath.Join(dst, "sub", "my-book.epub")
+	if got, err := os.ReadFile(p); err != nil || string(got) != "DATA" {
+		t.Fatalf("read: %v %q", err, string(got))
+	}
+	if state[f.URL].ETag != "v1" {
+		t.Fatalf("validators not recorded: %+v", state)
+	}
+
+	// Not modified: the existing file is left alone even when overwriting
+	if err := os.WriteFile(p, []byte("LOCAL"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Download(dst, true, true, state); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if got, _ := os.ReadFile(p); string(got) != "LOCAL" {
+		t.Fatalf("unchanged file must not be re-fetched")
+	}
+	entries, _ := os.ReadDir(filepath.Join(dst, "sub"))
+	if len(entries) != 1 {
+		t.Fatalf("expected no temporary leftovers, got %d entries", len(entries))
+	}
+
+	// Removed locally: the file is fetched again without the saved validators
+	if err := os.Remove(p); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Download(dst, false, true, state); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if got, err := os.ReadFile(p); err != nil || string(got) != "DATA" {
+		t.Fatalf("removed file not downloaded again: %v %q", err, string(got))
+	}
+}
+
+// TestHttpIndexFile_Download_ErrorAndDryRun ensures failures leave nothing behind and dry-run writes nothing.
+func TestHttpIndexFile_Download_ErrorAndDryRun(t *testing.T) {
+	x := NewHttpIndex("http://h/", nil, 0, &fakeHttp{})
+	f := NewHttpIndexFile("http://h/missing.epub", "missing.epub", "", x)
+	dst := t.TempDir()
+	if err := f.Download(dst, false, false, httpState{}); err == nil {
+		t.Fatalf("expected error")
+	}
+	if entries, _ := os.ReadDir(dst); len(entries) != 0 {
+		t.Fatalf("expected no leftovers")
+	}
+
+	old := util.DryRun
+	t.Cleanup(func() { util.DryRun = old })
+	util.DryRun = true
+	if err := f.Download(dst, false, false, httpState{}); err != nil {
+		t.Fatalf("dry-run: %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package httpindex
+
+import (
+	"bytes"
+	"log/slog"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// maxPagesPerCrawl bounds the number of index pages fetched in a single run.
+const maxPagesPerCrawl = 1000
+
+type HttpIndex struct {
+	StartURL string
+	MaxDepth int
+
+	linkPattern *regexp.Regexp
+	httpClient  HttpIndexAPI
+}
+
+func NewHttpIndex(startURL string, linkPattern *regexp.Regexp, maxDepth int, conn HttpIndexAPI) *HttpIndex {
+	return &HttpIndex{
+		StartURL:    startURL,
+		MaxDepth:    maxDepth,
+		linkPattern: linkPattern,
+		httpClient:  conn,
+	}
+}
+
+// FetchFiles collects the file links of the start page. Links to sub-index
+// pages (ending in a slash) below the start page are followed up to MaxDepth
+// levels deep. Files match the link pattern when one is set, validExtensions otherwise.
+func (x *HttpIndex) FetchFiles(validExtensions []string) ([]HttpIndexFile, error) {
+	type page struct {
+		url   string
+		depth int
+	}
+
+	// Build a lower-cased set of valid extensions for case-insensitive match
+	lowerExts := make([]string, 0, len(validExtensions))
+	for _, e := range validExtensions {
+		lowerExts = append(lowerExts, strings.ToLower(e))
+	}
+
+	var root *url.URL
+	var allFiles []HttpIndexFile
+	seenPages := map[string]bool{}
+	seenFiles := map[string]bool{}
+	queue := []page{{url: x.StartURL}}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if seenPages[current.url] {
+			continue
+		}
+		if len(seenPages) >= maxPagesPerCrawl {
+			slog.Warn("Reached index page limit, stopping crawl", "limit", maxPagesPerCrawl)
+			break
+		}
+		seenPages[current.url] = true
+
+		slog.Debug("Fetching index page", "url", current.url, "depth", current.depth)
+		pageURL, body, err := x.httpClient.FetchPage(current.url)
+		if err != nil {
+			// Only the start page is essential, broken sub-pages are skipped
+			if root == nil {
+				return nil, err
+			}
+			slog.Warn("Failed to fetch index page", "url", current.url, "error", err)
+			continue
+		}
+		if root == nil {
+			root = pageURL
+		}
+
+		for _, link := range extractLinks(pageURL, body) {
+			if !isBelow(root, link) {
+				continue
+			}
+
+			if strings.HasSuffix(link.Path, "/") {
+				// Sub-index pages; sort links such as "?C=N;O=D" point at the same page
+				link.RawQuery = ""
+				if current.depth < x.MaxDepth {
+					queue = append(queue, page{url: link.String(), depth: current.depth + 1})
+				}
+				continue
+			}
+
+			if seenFiles[link.String()] || !x.matches(link, lowerExts) {
+				continue
+			}
+			seenFiles[link.String()] = true
+
+			subFolder := strings.TrimPrefix(path.Dir(link.Path)+"/", rootFolder(root))
+			allFiles = append(allFiles, *NewHttpIndexFile(link.String(), path.Base(link.Path), strings.Trim(subFolder, "/"), x))
+		}
+	}
+
+	return allFiles, nil
+}
+
+// matches reports whether a file link should be downloaded.
+func (x *HttpIndex) matches(link *url.URL, lowerExts []string) bool {
+	if x.linkPattern != nil {
+		return x.linkPattern.MatchString(link.String())
+	}
+	extension := strings.ToLower(path.Ext(link.Path))
+	return len(lowerExts) == 0 || slices.Contains(lowerExts, extension)
+}
+
+// extractLinks returns the resolved targets of all anchors on an HTML page.
+func extractLinks(base *url.URL, body []byte) []*url.URL {
+	var links []*url.URL
+	tokenizer := html.NewTokenizer(bytes.NewReader(body))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return links
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			if string(name) != "a" || !hasAttr {
+				continue
+			}
+			for {
+				key, val, more := tokenizer.TagAttr()
+				if string(key) == "href" {
+					if u, err := base.Parse(strings.TrimSpace(string(val))); err == nil {
+						u.Fragment = ""
+						links = append(links, u)
+					}
+				}
+				if !more {
+					break
+				}
+			}
+		}
+	}
+}
+
+// isBelow reports whether link points below the folder of the start page on the same host.
+func isBelow(root *url.URL, link *url.URL) bool {
+	if link.Scheme != root.Scheme || !strings.EqualFold(link.Host, root.Host) {
+		return false
+	}
+	return strings.HasPrefix(link.Path, rootFolder(root)) && link.Path != rootFolder(root)
+}
+
+// rootFolder returns the folder part of the start page path, including the trailing slash.
+func rootFolder(root *url.URL) string {
+	if root.Path == "" || strings.HasSuffix(root.Path, "/") {
+		return "/" + strings.TrimPrefix(root.Path, "/")
+	}
+	return strings.TrimSuffix(path.Dir(root.Path), "/") + "/"
+}
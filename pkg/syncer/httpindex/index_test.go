@@ -0,0 +1,97 @@
+package httpindex
+
+import (
+	"net/url"
+	"regexp"
+	"testing"
+)
+
+// TestExtractLinks resolves relative links and drops fragments.
+func TestExtractLinks(t *testing.T) {
+	base, _ := url.Parse("https://books.example/lists/")
+	body := []byte(`<html><body>
+<a href="../">Parent</a>
+<a href="a.epub#top">A</a>
+<A HREF=" sub/ ">Sub</A>
+<a name="anchor">no href</a>
+<a href="https://other.example/x.epub">Other</a>
+</body></html>`)
+
+	var got []string
+	for _, l := range extractLinks(base, body) {
+		got = append(got, l.String())
+	}
+	want := []string{"https://books.example/", "https://books.example/lists/a.epub", "https://books.example/lists/sub/", "https://other.example/x.epub"}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected links: %v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("link %d: got %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+// TestIsBelow keeps the crawl on the same host and below the start folder.
+func TestIsBelow(t *testing.T) {
+	root, _ := url.Parse("https://books.example/lists/index.html")
+	tests := map[string]bool{
+		"https://books.example/lists/a.epub": true,
+		"https://books.example/lists/sub/":   true,
+		"https://books.example/lists/":       false,
+		"https://books.example/other/a.epub": false,
+		"http://books.example/lists/a.epub":  false,
+		"https://evil.example/lists/a.epub":  false,
+		"https://BOOKS.example/lists/b.epub": true,
+	}
+	for raw, want := range tests {
+		u, _ := url.Parse(raw)
+		if got := isBelow(root, u); got != want {
+			t.Fatalf("isBelow(%s)=%v, want %v", raw, got, want)
+		}
+	}
+	if got := rootFolder(&url.URL{}); got != "/" {
+		t.Fatalf("unexpected root folder for empty path: %s", got)
+	}
+}
+
+// TestHttpIndex_FetchFiles_DepthAndFilters covers recursion limits, sort links and both filters.
+func TestHttpIndex_FetchFiles_DepthAndFilters(t *testing.T) {
+	fake := &fakeHttp{pages: map[string]string{
+		"http://h/books/":          `<a href="../">up</a><a href="?C=N;O=D">sort</a><a href="a.epub">a</a><a href="b.pdf">b</a><a href="sub/">sub</a><a href="a.epub">dup</a>`,
+		"http://h/books/sub/":      `<a href="c.EPUB">c</a><a href="deep/">deep</a><a href="/books/">home</a>`,
+		"http://h/books/sub/deep/": `<a href="d.epub">d</a>`,
+	}}
+
+	files, err := NewHttpIndex("http://h/books/", nil, 0, fake).FetchFiles([]string{".epub"})
+	if err != nil || len(files) != 1 || files[0].Name != "a.epub" {
+		t.Fatalf("depth 0: %v %+v", err, files)
+	}
+
+	files, _ = NewHttpIndex("http://h/books/", nil, 1, fake).FetchFiles([]string{".epub"})
+	if len(files) != 2 || files[1].Name != "c.EPUB" || files[1].subFolder != "sub" {
+		t.Fatalf("depth 1: %+v", files)
+	}
+
+	files, _ = NewHttpIndex("http://h/books/", nil, 5, fake).FetchFiles([]string{".epub"})
+	if len(files) != 3 || files[2].subFolder != "sub/deep" {
+		t.Fatalf("depth 5: %+v", files)
+	}
+
+	files, _ = NewHttpIndex("http://h/books/", regexp.MustCompile(`\.pdf$`), 0, fake).FetchFiles([]string{".epub"})
+	if len(files) != 1 || files[0].Name != "b.pdf" {
+		t.Fatalf("link pattern must replace the extension filter: %+v", files)
+	}
+}
+
+// TestHttpIndex_FetchFiles_Errors ensures only a failing start page aborts the crawl.
+func TestHttpIndex_FetchFiles_Errors(t *testing.T) {
+	fake := &fakeHttp{pages: map[string]string{"http://h/": `<a href="broken/">x</a><a href="a.epub">a</a>`}}
+	if _, err := NewHttpIndex("http://h/missing/", nil, 1, fake).FetchFiles(nil); err == nil {
+		t.Fatalf("expected error")
+	}
+	files, err := NewHttpIndex("http://h/", nil, 1, fake).FetchFiles(nil)
+	if err != nil || len(files) != 1 {
+		t.Fatalf("broken sub-page must be skipped: %v %+v", err, files)
+	}
+}
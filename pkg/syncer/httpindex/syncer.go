@@ -0,0 +1,116 @@
+package httpindex
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// httpState maps file URLs to the cache validators of their last download.
+type httpState map[string]Validators
+
+type HttpIndexSyncer struct {
+	config *config.HttpConfig
+}
+
+func NewHttpIndexSyncer(serverConfig *config.HttpConfig) *HttpIndexSyncer {
+	// Set default recursion depth
+	if serverConfig.Recursive && !(serverConfig.MaxDepth > 0) {
+		serverConfig.MaxDepth = 5
+	}
+
+	return &HttpIndexSyncer{
+		config: serverConfig,
+	}
+}
+
+func (s *HttpIndexSyncer) Run(targetFolder string, validExtensions []string, overwriteExistingFiles bool) error {
+	return s.RunContext(context.Background(), targetFolder, validExtensions, overwriteExistingFiles)
+}
+
+func (s *HttpIndexSyncer) RunContext(ctx context.Context, targetFolder string, validExtensions []string, overwriteExistingFiles bool) (err error) {
+	if s.config.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(s.config.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	var linkPattern *regexp.Regexp
+	if s.config.LinkPattern != "" {
+		if linkPattern, err = regexp.Compile(s.config.LinkPattern); err != nil {
+			return fmt.Errorf("invalid link_pattern %q: %w", s.config.LinkPattern, err)
+		}
+	}
+	maxDepth := 0
+	if s.config.Recursive {
+		maxDepth = s.config.MaxDepth
+	}
+
+	// Load the cache validators of previous downloads
+	statePath := s.config.StateFile
+	if statePath == "" {
+		statePath = util.DefaultStatePath(targetFolder, "http", s.config.URL)
+	}
+	state := httpState{}
+	if err := util.LoadState(statePath, &state); err != nil {
+		return fmt.Errorf("could not load HTTP state from %s: %w", statePath, err)
+	}
+
+	// Connect to the web server
+	httpClient, err := newHttpIndexClient(s.config)
+	if err != nil {
+		return err
+	}
+	if err := httpConnect(ctx, httpClient, 60*time.Second); err != nil {
+		return fmt.Errorf("could not connect to web server %s: %w", s.config.URL, err)
+	}
+	defer httpClient.Disconnect()
+
+	// Walk the index pages
+	index := httpNewIndex(s.config.URL, linkPattern, maxDepth, httpClient)
+	allFiles, err := httpFetchFiles(index, validExtensions)
+	if err != nil {
+		return fmt.Errorf("could not fetch index %s: %w", s.config.URL, err)
+	}
+
+	// Forget files that are no longer listed
+	listed := map[string]bool{}
+	for _, f := range allFiles {
+		listed[f.URL] = true
+	}
+	for fileURL := range state {
+		if !listed[fileURL] {
+			delete(state, fileURL)
+		}
+	}
+
+	// Persist validators of whatever was downloaded, even when a later file fails
+	defer func() {
+		if saveErr := util.SaveState(statePath, state); saveErr != nil && err == nil {
+			err = fmt.Errorf("could not save HTTP state to %s: %w", statePath, saveErr)
+		}
+	}()
+
+	// Download all files
+	for i := range allFiles {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		if err := httpDownload(&allFiles[i], targetFolder, overwriteExistingFiles, s.config.KeepFolderStructure, state); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
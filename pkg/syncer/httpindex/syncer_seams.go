@@ -0,0 +1,29 @@
+// syncer_seams.go: Test seams for the HTTP index syncer.
+//
+// This file defines small function variables that production code uses by default,
+// but tests can override to substitute fakes. Keeping these seams in a separate
+// file keeps the main implementation clean while preserving simple overrides in tests.
+package httpindex
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+// test hooks (seams) for dependency injection in tests
+var (
+	newHttpIndexClient = func(cfg *config.HttpConfig) (HttpIndexAPI, error) {
+		return NewHttpIndexClient(cfg.URL, cfg.Username, cfg.Password)
+	}
+	httpConnect  = func(ctx context.Context, c HttpIndexAPI, timeout time.Duration) error { return c.Connect(ctx, timeout) }
+	httpNewIndex = func(startURL string, pattern *regexp.Regexp, maxDepth int, conn HttpIndexAPI) *HttpIndex {
+		return NewHttpIndex(startURL, pattern, maxDepth, conn)
+	}
+	httpFetchFiles = func(x *HttpIndex, valid []string) ([]HttpIndexFile, error) { return x.FetchFiles(valid) }
+	httpDownload   = func(f *HttpIndexFile, dst string, overwrite, keep bool, state httpState) error {
+		return f.Download(dst, overwrite, keep, state)
+	}
+)
@@ -0,0 +1,127 @@
+package httpindex
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// TestNewHttpIndexSyncer_DefaultDepth ensures recursion gets a default depth.
+func TestNewHttpIndexSyncer_DefaultDepth(t *testing.T) {
+	cfg := &config.HttpConfig{Recursive: true}
+	NewHttpIndexSyncer(cfg)
+	if cfg.MaxDepth != 5 {
+		t.Fatalf("want 5, got %d", cfg.MaxDepth)
+	}
+}
+
+// TestHttpIndexSyncer_Run_EndToEnd crawls a directory listing and re-runs with conditional requests.
+func TestHttpIndexSyncer_Run_EndToEnd(t *testing.T) {
+	remote := t.TempDir()
+	writeRemote(t, remote, "lists/a.epub", "A")
+	writeRemote(t, remote, "lists/sub/b.kepub", "BB")
+	writeRemote(t, remote, "lists/c.txt", "C")
+	writeRemote(t, remote, "private/d.epub", "D")
+	srvURL, requests := newIndexServer(t, remote)
+
+	dst := t.TempDir()
+	cfg := &config.HttpConfig{URL: srvURL + "/lists/", Recursive: true, KeepFolderStructure: true}
+	if err := NewHttpIndexSyncer(cfg).Run(dst, []string{".epub", ".kepub"}, true); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for _, p := range []string{"a.epub", filepath.Join("sub", "b.kepub")} {
+		if _, err := os.Stat(filepath.Join(dst, p)); err != nil {
+			t.Fatalf("expected %s downloaded: %v", p, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dst, "d.epub")); !os.IsNotExist(err) {
+		t.Fatalf("files outside the start folder must not be downloaded")
+	}
+
+	var state httpState
+	if err := util.LoadState(util.DefaultStatePath(dst, "http", cfg.URL), &state); err != nil || len(state) != 2 {
+		t.Fatalf("expected state for 2 files: %v %+v", err, state)
+	}
+
+	// The second run sends conditional requests and the server answers 304
+	*requests = nil
+	if err := NewHttpIndexSyncer(cfg).Run(dst, []string{".epub", ".kepub"}, true); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	conditional := 0
+	for _, r := range *requests {
+		if !strings.HasSuffix(r.URL.Path, "/") {
+			if r.Header.Get("If-Modified-Since") == "" {
+				t.Fatalf("expected conditional request for %s", r.URL.Path)
+			}
+			conditional++
+		}
+	}
+	if conditional != 2 {
+		t.Fatalf("expected 2 conditional requests, got %d", conditional)
+	}
+}
+
+// TestHttpIndexSyncer_Run_PrunesState drops validators of files that disappeared.
+func TestHttpIndexSyncer_Run_PrunesState(t *testing.T) {
+	remote := t.TempDir()
+	writeRemote(t, remote, "a.epub", "A")
+	srvURL, _ := newIndexServer(t, remote)
+
+	dst := t.TempDir()
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	if err := util.SaveState(statePath, httpState{srvURL + "/gone.epub": {ETag: "x"}}); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.HttpConfig{URL: srvURL + "/", StateFile: statePath}
+	if err := NewHttpIndexSyncer(cfg).Run(dst, nil, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	var state httpState
+	_ = util.LoadState(statePath, &state)
+	if _, ok := state[srvURL+"/gone.epub"]; ok || len(state) != 1 {
+		t.Fatalf("unexpected state: %+v", state)
+	}
+}
+
+// TestHttpIndexSyncer_Run_Errors ensures configuration, fetch and download errors abort the run.
+func TestHttpIndexSyncer_Run_Errors(t *testing.T) {
+	if err := NewHttpIndexSyncer(&config.HttpConfig{URL: "http://h/", LinkPattern: "("}).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected pattern error")
+	}
+	if err := NewHttpIndexSyncer(&config.HttpConfig{URL: "ftp://h/"}).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected client error")
+	}
+
+	remote := t.TempDir()
+	writeRemote(t, remote, "a.epub", "A")
+	srvURL, _ := newIndexServer(t, remote)
+	if err := NewHttpIndexSyncer(&config.HttpConfig{URL: srvURL + "/missing/"}).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected fetch error")
+	}
+
+	orig := httpDownload
+	t.Cleanup(func() { httpDownload = orig })
+	httpDownload = func(f *HttpIndexFile, dst string, overwrite, keep bool, state httpState) error {
+		return errors.New("z")
+	}
+	if err := NewHttpIndexSyncer(&config.HttpConfig{URL: srvURL + "/"}).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected download error")
+	}
+}
+
+// TestHttpIndexSyncer_RunContext_Cancel verifies that a canceled context stops the run.
+func TestHttpIndexSyncer_RunContext_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cfg := &config.HttpConfig{URL: "http://h/"}
+	if err := NewHttpIndexSyncer(cfg).RunContext(ctx, t.TempDir(), nil, false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled, got %v", err)
+	}
+}
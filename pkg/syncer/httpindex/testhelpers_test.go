@@ -0,0 +1,73 @@
+package httpindex
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newIndexServer serves dir with the standard library's directory listings,
+// which look like a minimal nginx/Apache autoindex, and records requests.
+func newIndexServer(t *testing.T, dir string) (string, *[]*http.Request) {
+	t.Helper()
+	var requests []*http.Request
+	fs := http.FileServer(http.Dir(dir))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		fs.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL, &requests
+}
+
+// writeRemote creates a file below the served directory with a fixed modification time.
+func writeRemote(t *testing.T, dir, name, content string) {
+	t.Helper()
+	p := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	mtime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(p, mtime, mtime); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+}
+
+// fakeHttp serves pages and files from memory.
+type fakeHttp struct {
+	pages map[string]string
+	files map[string]string
+}
+
+func (f *fakeHttp) Connect(ctx context.Context, timeout time.Duration) error { return nil }
+func (f *fakeHttp) Disconnect() error                                        { return nil }
+func (f *fakeHttp) Host() string                                             { return "fake" }
+func (f *fakeHttp) FetchPage(pageURL string) (*url.URL, []byte, error) {
+	body, ok := f.pages[pageURL]
+	if !ok {
+		return nil, nil, errors.New("not found")
+	}
+	u, _ := url.Parse(pageURL)
+	return u, []byte(body), nil
+}
+func (f *fakeHttp) ReadFile(fileURL string, v Validators, w io.Writer) (Validators, bool, error) {
+	body, ok := f.files[fileURL]
+	if !ok {
+		return v, false, errors.New("not found")
+	}
+	if v.ETag == "v1" {
+		return v, false, nil
+	}
+	_, err := io.WriteString(w, body)
+	return Validators{ETag: "v1"}, true, err
+}
@@ -2,17 +2,13 @@ package imap
 
 import (
 	"bytes"
-	"encoding/base64"
 	"fmt"
-	"io"
 	"log/slog"
-	"mime/quotedprintable"
 	"os"
-	"path"
 	"path/filepath"
-	"slices"
 	"strings"
 
+	"github.com/bjw-s-labs/bookshift/pkg/mailparts"
 	"github.com/bjw-s-labs/bookshift/pkg/util"
 	"github.com/emersion/go-imap/v2"
 	"github.com/emersion/go-imap/v2/imapclient"
@@ -26,13 +22,18 @@ type messageAttachmentPart struct {
 	encoding       string
 }
 
-// DownloadAttachments downloads all valid attachments to dstFolder and optionally deletes the message.
-func (im *ImapMessage) DownloadAttachments(dstFolder string, validExtensions []string, overwriteExistingFile bool, removeMessageAfterDownload bool) error {
+// DownloadAttachments downloads all valid attachments to dstFolder and then applies the after download action.
+func (im *ImapMessage) DownloadAttachments(dstFolder string, validExtensions []string, overwriteExistingFile bool, after AfterDownload) error {
 	// Fetch basic message information from the server
-	message, err := im.imapClient.fetchByUID(im.uid, &imap.FetchOptions{
+	fetchOptions := &imap.FetchOptions{
 		Envelope:      true,
 		BodyStructure: &imap.FetchItemBodyStructure{Extended: true},
-	})
+	}
+	policy := im.imapClient.Policy
+	if policy.enabled() && len(policy.RequireAuthentication) > 0 {
+		fetchOptions.BodySection = []*imap.FetchItemBodySection{authResultsSection}
+	}
+	message, err := im.imapClient.fetchByUID(im.uid, fetchOptions)
 	if err != nil {
 		return err
 	}
@@ -43,6 +44,12 @@ func (im *ImapMessage) DownloadAttachments(dstFolder string, validExtensions []s
 	}
 	messageSubject := message.Envelope.Subject
 
+	// Only download attachments from trusted senders
+	if reason := policy.check(message); reason != "" {
+		slog.Warn("Rejecting email", "host", im.imapClient.Host, "uid", im.uid, "sender", messageSender, "subject", messageSubject, "reason", reason)
+		return im.quarantine(policy.QuarantineMailbox)
+	}
+
 	// Find message attachment parts
 	msgAttachmentParts, err := im.determineAttachmentParts(message, validExtensions)
 	if err != nil {
@@ -63,86 +70,45 @@ func (im *ImapMessage) DownloadAttachments(dstFolder string, validExtensions []s
 
 	// Download the attachments
 	for _, msgAttachmentPart := range msgAttachmentParts {
-		safeFileName := util.SafeFileName(msgAttachmentPart.filename)
-		dstPath := filepath.Join(dstFolder, safeFileName)
-
 		slog.Info("Downloading email attachment", "host", im.imapClient.Host, "sender", messageSender, "subject", messageSubject, "filename", msgAttachmentPart.filename)
 
+		// Peek so that only the after_download action changes the flags
 		message, err := im.imapClient.fetchByUID(im.uid, &imap.FetchOptions{
-			BodySection: []*imap.FetchItemBodySection{{Part: msgAttachmentPart.part}},
+			BodySection: []*imap.FetchItemBodySection{{Part: msgAttachmentPart.part, Peek: true}},
 		})
 		if err != nil {
 			return err
 		}
 
 		for _, section := range message.BodySection {
-			// Check if the file already exists
-			_, err := os.Stat(dstPath)
-			if !os.IsNotExist(err) {
-				if !overwriteExistingFile {
-					slog.Warn("File already exists, skipping download", "file", dstPath)
-					continue // skip this attachment only
-				}
-
-				slog.Info("Overwriting existing file", "file", dstPath)
-			}
-
-			// Download the file
-			if util.DryRun {
-				slog.Info("[dry-run] Would download email attachment", "uid", im.uid, "filename", safeFileName, "destination", dstPath)
-				continue
-			}
-			tmpFile, err := os.CreateTemp(dstFolder, "bookshift-")
-			if err != nil {
-				return err
-			}
-			defer tmpFile.Close()
-
-			slog.Debug("Downloading to temporary file", "file", tmpFile.Name())
-			writer := util.NewFileWriter(tmpFile, int64(msgAttachmentPart.attachmentSize), true)
 			// Decode according to transfer-encoding
-			var src io.Reader
-			switch strings.ToLower(msgAttachmentPart.encoding) {
-			case "base64":
-				src = base64.NewDecoder(base64.StdEncoding, bytes.NewReader(section.Bytes))
-			case "quoted-printable":
-				src = quotedprintable.NewReader(bytes.NewReader(section.Bytes))
-			case "7bit", "8bit", "binary", "":
-				// pass through as-is
-				src = bytes.NewReader(section.Bytes)
-			default:
-				// unknown: pass-through but warn
-				slog.Warn("Unknown transfer-encoding, writing raw bytes", "encoding", msgAttachmentPart.encoding)
-				src = bytes.NewReader(section.Bytes)
-			}
-
-			if _, err := io.Copy(writer, src); err != nil {
-				return err
-			}
-
-			if err := tmpFile.Sync(); err != nil { // ensure data flushed before rename
-				os.Remove(tmpFile.Name())
+			src := mailparts.DecodeTransfer(msgAttachmentPart.encoding, bytes.NewReader(section.Bytes))
+			dstPath, err := mailparts.SaveAttachment(dstFolder, msgAttachmentPart.filename, int64(msgAttachmentPart.attachmentSize), src, overwriteExistingFile)
+			if err != nil {
 				return err
 			}
-			if err := os.Rename(tmpFile.Name(), dstPath); err != nil {
-				os.Remove(tmpFile.Name())
-				return err
+			if dstPath != "" {
+				slog.Info("Successfully downloaded attachment", "uid", im.uid, "filename", filepath.Base(dstPath), "path", dstPath)
 			}
-
-			slog.Info("Successfully downloaded attachment", "uid", im.uid, "filename", safeFileName, "path", dstPath)
 		}
 	}
 
-	if removeMessageAfterDownload {
-		if util.DryRun {
-			slog.Info("[dry-run] Would delete message from server", "uid", im.uid)
-		} else {
-			if err := im.DeleteFromServer(); err != nil {
-				return err
-			}
-		}
-	}
+	return im.applyAfterDownload(after)
+}
 
+// quarantine moves a rejected message to the quarantine mailbox, if any.
+func (im *ImapMessage) quarantine(mailbox string) error {
+	if mailbox == "" {
+		return nil
+	}
+	if util.DryRun {
+		slog.Info("[dry-run] Would move message to quarantine mailbox", "uid", im.uid, "mailbox", mailbox)
+		return nil
+	}
+	if err := im.MoveToMailbox(mailbox); err != nil {
+		return fmt.Errorf("could not move message to quarantine mailbox %s: %w", mailbox, err)
+	}
+	slog.Info("Moved message to quarantine mailbox", "uid", im.uid, "mailbox", mailbox)
 	return nil
 }
 
@@ -160,10 +126,9 @@ func (im *ImapMessage) determineAttachmentParts(msg *imapclient.FetchMessageBuff
 			if partObj.Disposition() != nil && partObj.Disposition().Params["filename"] != "" {
 				attachmentPart := part
 				attachmentFilename := partObj.Disposition().Params["filename"]
-				attachmentFileExtension := path.Ext(attachmentFilename)
 
 				// Only continue if the file extension is wanted
-				if len(validExtensions) > 0 && !slices.Contains(validExtensions, attachmentFileExtension) {
+				if !mailparts.WantedAttachment(attachmentFilename, validExtensions) {
 					return false
 				}
 
@@ -51,7 +51,7 @@ func TestDownloadAttachments_DownloadsAndDeletes(t *testing.T) {
 	msg := NewImapMessage(uid, ic)
 
 	dir := t.TempDir()
-	if err := msg.DownloadAttachments(dir, []string{".epub"}, false, true); err != nil {
+	if err := msg.DownloadAttachments(dir, []string{".epub"}, false, AfterDownload{Action: "delete"}); err != nil {
 		t.Fatalf("DownloadAttachments error: %v", err)
 	}
 
@@ -67,8 +67,40 @@ func TestDownloadAttachments_DownloadsAndDeletes(t *testing.T) {
 	if len(backend.stored) != 1 || backend.stored[0] != uid {
 		t.Fatalf("expected StoreAddFlags for uid=%v, got %v", uid, backend.stored)
 	}
-	if backend.expunges != 1 {
-		t.Fatalf("expected 1 expunge, got %d", backend.expunges)
+	if len(backend.expunged) != 1 || backend.expunged[0] != uid {
+		t.Fatalf("expected UID EXPUNGE of uid=%v, got %v", uid, backend.expunged)
+	}
+}
+
+// TestDownloadAttachments_PeeksSections ensures attachments are fetched with
+// BODY.PEEK so after_download none leaves the message unread.
+func TestDownloadAttachments_PeeksSections(t *testing.T) {
+	uid := imap.UID(8)
+	encoded := base64.StdEncoding.EncodeToString([]byte("DATA"))
+	backend := &recordingBackend{
+		meta:   map[imap.UID]*imapclient.FetchMessageBuffer{uid: buildMeta("S", "Bob", "bob", "example.com", "book.epub", 4)},
+		bodies: map[imap.UID]*imapclient.FetchMessageBuffer{uid: buildBody(encoded)},
+	}
+	msg := NewImapMessage(uid, &ImapClient{Backend: backend})
+
+	if err := msg.DownloadAttachments(t.TempDir(), []string{".epub"}, false, AfterDownload{Action: "none"}); err != nil {
+		t.Fatalf("DownloadAttachments error: %v", err)
+	}
+	if len(backend.sections) == 0 {
+		t.Fatalf("expected attachment sections to be fetched")
+	}
+	for _, section := range backend.sections {
+		if !section.Peek {
+			t.Fatalf("section %v fetched without peek", section.Part)
+		}
+	}
+	for _, flag := range backend.flags {
+		if flag == imap.FlagSeen {
+			t.Fatalf("after_download none must not set \\Seen")
+		}
+	}
+	if len(backend.stored) != 0 {
+		t.Fatalf("after_download none must not store flags, got %v", backend.stored)
 	}
 }
 
@@ -89,7 +121,7 @@ func TestDownloadAttachments_SkipExisting_NoOverwrite(t *testing.T) {
 		t.Fatalf("precreate: %v", err)
 	}
 
-	if err := msg.DownloadAttachments(dir, []string{".epub"}, false, false); err != nil {
+	if err := msg.DownloadAttachments(dir, []string{".epub"}, false, AfterDownload{}); err != nil {
 		t.Fatalf("DownloadAttachments error: %v", err)
 	}
 	b, _ := os.ReadFile(dst)
@@ -115,7 +147,7 @@ func TestDownloadAttachments_OverwriteExisting(t *testing.T) {
 		t.Fatalf("precreate: %v", err)
 	}
 
-	if err := msg.DownloadAttachments(dir, []string{".epub"}, true, false); err != nil {
+	if err := msg.DownloadAttachments(dir, []string{".epub"}, true, AfterDownload{}); err != nil {
 		t.Fatalf("DownloadAttachments error: %v", err)
 	}
 	b, _ := os.ReadFile(dst)
@@ -137,7 +169,7 @@ func TestDownloadAttachments_RenameError(t *testing.T) {
 	if err := os.Mkdir(filepath.Join(dir, "x.epub"), 0o755); err != nil {
 		t.Fatalf("mkdir: %v", err)
 	}
-	if err := msg.DownloadAttachments(dir, []string{".epub"}, true, false); err == nil {
+	if err := msg.DownloadAttachments(dir, []string{".epub"}, true, AfterDownload{}); err == nil {
 		t.Fatalf("expected rename error")
 	}
 }
@@ -145,7 +177,7 @@ func TestDownloadAttachments_RenameError(t *testing.T) {
 // TestCollectMessages_BackendError ensures backend search errors are returned.
 func TestCollectMessages_BackendError(t *testing.T) {
 	ic := &ImapClient{Backend: &fakeBackend{searchErr: os.ErrNotExist}}
-	if _, err := ic.CollectMessages(true, "to", "someone@example.com"); err == nil {
+	if _, err := ic.CollectMessages(true, "to", "someone@example.com", nil); err == nil {
 		t.Fatalf("expected search error")
 	}
 }
@@ -157,7 +189,7 @@ func TestDownloadAttachments_FetchBodyError(t *testing.T) {
 	be := &errBodyBackend{meta: meta}
 	ic := &ImapClient{Backend: be}
 	msg := NewImapMessage(uid, ic)
-	if err := msg.DownloadAttachments(t.TempDir(), []string{".epub"}, false, false); err == nil {
+	if err := msg.DownloadAttachments(t.TempDir(), []string{".epub"}, false, AfterDownload{}); err == nil {
 		t.Fatalf("expected fetch body error")
 	}
 }
@@ -175,7 +207,7 @@ func TestDownloadAttachments_CreateTempError(t *testing.T) {
 	if err := os.WriteFile(file, []byte("x"), 0o644); err != nil {
 		t.Fatalf("prep file: %v", err)
 	}
-	if err := msg.DownloadAttachments(file, []string{".epub"}, true, false); err == nil {
+	if err := msg.DownloadAttachments(file, []string{".epub"}, true, AfterDownload{}); err == nil {
 		t.Fatalf("expected create temp error")
 	}
 }
@@ -189,7 +221,7 @@ func TestDownloadAttachments_CreatesFolder(t *testing.T) {
 	ic := &ImapClient{Backend: be}
 	msg := NewImapMessage(uid, ic)
 	dst := filepath.Join(t.TempDir(), "nested", "dir")
-	if err := msg.DownloadAttachments(dst, []string{".epub"}, false, false); err != nil {
+	if err := msg.DownloadAttachments(dst, []string{".epub"}, false, AfterDownload{}); err != nil {
 		t.Fatalf("download: %v", err)
 	}
 	if _, err := os.Stat(filepath.Join(dst, "c.epub")); err != nil {
@@ -1,10 +1,13 @@
 package imap
 
 import (
+	"crypto/tls"
+	"errors"
 	"fmt"
 
 	"github.com/emersion/go-imap/v2"
 	"github.com/emersion/go-imap/v2/imapclient"
+	"github.com/emersion/go-sasl"
 )
 
 // ImapOps abstracts the subset of IMAP operations used by this package; implemented by realImapOps and test fakes.
@@ -12,12 +15,18 @@ type ImapOps interface {
 	UIDSearch(criteria *imap.SearchCriteria) ([]imap.UID, error)
 	FetchOneByUID(uid imap.UID, options *imap.FetchOptions) (*imapclient.FetchMessageBuffer, error)
 	StoreAddFlags(uid imap.UID, flags []imap.Flag) error
-	Expunge() error
+	UIDExpunge(uid imap.UID) error
+	Move(uid imap.UID, mailbox string) error
 }
 
+// errUIDExpungeUnsupported is returned when the server lacks UIDPLUS, so a
+// message can only be expunged together with every other deleted message.
+var errUIDExpungeUnsupported = errors.New("IMAP server does not support UID EXPUNGE")
+
 // imapConn is a minimal low-level interface used by Connect/Disconnect.
 type imapConn interface {
 	Login(username, password string) waitErr
+	Authenticate(saslClient sasl.Client) error
 	Select(mailbox string, options *imap.SelectOptions) waitSelect
 	Logout() waitErr
 	Close() error
@@ -33,6 +42,9 @@ type waitSelect interface {
 type clientWrapper struct{ *imapclient.Client }
 
 func (c *clientWrapper) Login(u, p string) waitErr { return c.Client.Login(u, p) }
+func (c *clientWrapper) Authenticate(s sasl.Client) error {
+	return c.Client.Authenticate(s)
+}
 func (c *clientWrapper) Select(m string, opt *imap.SelectOptions) waitSelect {
 	return c.Client.Select(m, opt)
 }
@@ -40,8 +52,22 @@ func (c *clientWrapper) Logout() waitErr { return c.Client.Logout() }
 func (c *clientWrapper) Close() error    { return c.Client.Close() }
 
 // imapDial is a dial seam for tests; returns a low-level wrapper and the real client for backend wiring.
-var imapDial = func(addr string) (imapConn, *imapclient.Client, error) {
-	c, err := imapclient.DialTLS(addr, nil)
+// security selects implicit TLS ("tls"), STARTTLS ("starttls") or a plaintext connection ("none").
+var imapDial = func(addr string, security string, tlsConfig *tls.Config) (imapConn, *imapclient.Client, error) {
+	options := &imapclient.Options{TLSConfig: tlsConfig}
+
+	var c *imapclient.Client
+	var err error
+	switch security {
+	case "", "tls":
+		c, err = imapclient.DialTLS(addr, options)
+	case "starttls":
+		c, err = imapclient.DialStartTLS(addr, options)
+	case "none":
+		c, err = imapclient.DialInsecure(addr, options)
+	default:
+		return nil, nil, fmt.Errorf("unsupported IMAP security mode: %s", security)
+	}
 	if err != nil {
 		return nil, nil, err
 	}
@@ -75,7 +101,28 @@ func (r *realImapOps) StoreAddFlags(uid imap.UID, flags []imap.Flag) error {
 	return err
 }
 
-func (r *realImapOps) Expunge() error {
-	_, err := r.c.Expunge().Collect()
+// UIDExpunge expunges a single message, leaving messages other clients
+// marked as deleted alone.
+func (r *realImapOps) UIDExpunge(uid imap.UID) error {
+	if !r.c.Caps().Has(imap.CapUIDPlus) {
+		return errUIDExpungeUnsupported
+	}
+	_, err := r.c.UIDExpunge(imap.UIDSetNum(uid)).Collect()
 	return err
 }
+
+// Move moves a message with MOVE, or with COPY, STORE \Deleted and
+// UID EXPUNGE when the server does not support MOVE.
+func (r *realImapOps) Move(uid imap.UID, mailbox string) error {
+	if r.c.Caps().Has(imap.CapMove) {
+		_, err := r.c.Move(imap.UIDSetNum(uid), mailbox).Wait()
+		return err
+	}
+	if _, err := r.c.Copy(imap.UIDSetNum(uid), mailbox).Wait(); err != nil {
+		return err
+	}
+	if err := r.StoreAddFlags(uid, []imap.Flag{imap.FlagDeleted}); err != nil {
+		return err
+	}
+	return r.UIDExpunge(uid)
+}
@@ -2,6 +2,7 @@ package imap
 
 import (
 	"errors"
+	"strings"
 	"testing"
 
 	"github.com/emersion/go-imap/v2"
@@ -10,7 +11,7 @@ import (
 // TestImapCollectMessages_Backend verifies collection using the Backend interface path.
 func TestImapCollectMessages_Backend(t *testing.T) {
 	ic := &ImapClient{Backend: &fakeBackend{uids: []imap.UID{1, 2, 3}}}
-	msgs, err := ic.CollectMessages(true, "to", "someone@example.com")
+	msgs, err := ic.CollectMessages(true, "to", "someone@example.com", nil)
 	if err != nil {
 		t.Fatalf("Collect: %v", err)
 	}
@@ -40,3 +41,57 @@ func TestImapDeleteFromServer_BackendErrors(t *testing.T) {
 		t.Fatalf("expected expunge error")
 	}
 }
+
+// TestImapDial_SecurityModes dials a local server with implicit TLS, STARTTLS
+// and without encryption, trusting the server certificate through a CA file.
+func TestImapDial_SecurityModes(t *testing.T) {
+	cert, caFile := newTestCert(t)
+	ic := &ImapClient{Host: "127.0.0.1", ServerName: "imap.test", CAFile: caFile}
+	tlsConfig, err := ic.tlsConfig()
+	if err != nil {
+		t.Fatalf("tlsConfig: %v", err)
+	}
+
+	for _, security := range []string{"tls", "starttls", "none"} {
+		t.Run(security, func(t *testing.T) {
+			addr := serveGreeting(t, security, cert)
+			conn, real, err := imapDial(addr, security, tlsConfig)
+			if err != nil {
+				t.Fatalf("dial: %v", err)
+			}
+			if conn == nil || real == nil {
+				t.Fatalf("expected clients")
+			}
+			_ = conn.Close()
+		})
+	}
+}
+
+// TestImapDial_UntrustedCertificate ensures certificates are verified unless
+// insecure_skip_verify is set.
+func TestImapDial_UntrustedCertificate(t *testing.T) {
+	cert, _ := newTestCert(t)
+	for _, skip := range []bool{false, true} {
+		ic := &ImapClient{Host: "imap.test", InsecureSkipVerify: skip}
+		tlsConfig, err := ic.tlsConfig()
+		if err != nil {
+			t.Fatalf("tlsConfig: %v", err)
+		}
+		addr := serveGreeting(t, "tls", cert)
+		conn, _, err := imapDial(addr, "tls", tlsConfig)
+		if skip != (err == nil) {
+			t.Fatalf("insecure_skip_verify=%v: unexpected err %v", skip, err)
+		}
+		if conn != nil {
+			_ = conn.Close()
+		}
+	}
+}
+
+// TestImapDial_UnsupportedSecurity ensures unknown modes are rejected before dialing.
+func TestImapDial_UnsupportedSecurity(t *testing.T) {
+	_, _, err := imapDial("127.0.0.1:1", "ssl", nil)
+	if err == nil || !strings.Contains(err.Error(), "unsupported IMAP security mode") {
+		t.Fatalf("unexpected err: %v", err)
+	}
+}
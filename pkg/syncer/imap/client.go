@@ -1,8 +1,13 @@
 package imap
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"log/slog"
+	"os"
 
+	"github.com/bjw-s-labs/bookshift/pkg/config"
 	"github.com/emersion/go-imap/v2"
 	"github.com/emersion/go-imap/v2/imapclient"
 	"github.com/go-playground/sensitive"
@@ -14,21 +19,42 @@ type ImapClient struct {
 	Username string
 	Password *sensitive.String
 
+	// Security is one of tls, starttls or none; empty means tls.
+	Security           string
+	CAFile             string
+	ServerName         string
+	InsecureSkipVerify bool
+
+	// AuthMethod is one of login, xoauth2 or oauthbearer; empty means login.
+	// The OAuth2 methods take their access token from OAuth2.
+	AuthMethod string
+	OAuth2     *OAuth2Source
+
+	// Policy, when set, is checked before attachments of a message are downloaded.
+	Policy *SenderPolicy
+
 	Client  imapConn
 	Backend ImapOps
 }
 
-// Connect establishes a TLS IMAP connection, logs in, selects the mailbox, and wires the backend.
+// Connect establishes an IMAP connection, logs in, selects the mailbox, and wires the backend.
 func (ic *ImapClient) Connect(mailbox string) error {
 	connStr := fmt.Sprintf("%s:%v", ic.Host, ic.Port)
+	slog.Debug("Initiating IMAP connection", "host", ic.Host, "security", ic.Security)
+
+	tlsConfig, err := ic.tlsConfig()
+	if err != nil {
+		return err
+	}
 
 	// Connect to the IMAP server (via seam)
-	client, real, err := imapDial(connStr)
+	client, real, err := imapDial(connStr, ic.Security, tlsConfig)
 	if err != nil {
 		return err
 	}
 
-	if err := client.Login(ic.Username, string(*ic.Password)).Wait(); err != nil {
+	if err := ic.authenticate(client); err != nil {
+		_ = client.Close()
 		return err
 	}
 
@@ -43,6 +69,70 @@ func (ic *ImapClient) Connect(mailbox string) error {
 	return nil
 }
 
+// authenticate logs in with the password or, for the OAuth2 auth methods,
+// with an access token. A token the server rejects is refreshed once, as a
+// cached token may have been revoked before it expired.
+func (ic *ImapClient) authenticate(client imapConn) error {
+	switch ic.AuthMethod {
+	case "", "login":
+		password := ""
+		if ic.Password != nil {
+			password = string(*ic.Password)
+		}
+		return client.Login(ic.Username, password).Wait()
+	case "xoauth2", "oauthbearer":
+		if ic.OAuth2 == nil {
+			return fmt.Errorf("no OAuth2 token source configured for %s", ic.AuthMethod)
+		}
+		token, err := ic.OAuth2.AccessToken(false)
+		if err != nil {
+			return err
+		}
+		err = client.Authenticate(newOAuth2SaslClient(ic.AuthMethod, ic.Username, token, ic.Host, ic.Port))
+		if err == nil {
+			return nil
+		}
+		slog.Debug("IMAP server rejected access token, refreshing", "host", ic.Host, "error", err)
+		token, err = ic.OAuth2.AccessToken(true)
+		if err != nil {
+			return err
+		}
+		return client.Authenticate(newOAuth2SaslClient(ic.AuthMethod, ic.Username, token, ic.Host, ic.Port))
+	default:
+		return fmt.Errorf("unsupported IMAP auth method: %s", ic.AuthMethod)
+	}
+}
+
+// tlsConfig builds the TLS configuration used for implicit TLS and STARTTLS.
+// The server name defaults to the host; a CA file is trusted in addition to
+// the system roots.
+func (ic *ImapClient) tlsConfig() (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName:         ic.Host,
+		InsecureSkipVerify: ic.InsecureSkipVerify,
+	}
+	if ic.ServerName != "" {
+		cfg.ServerName = ic.ServerName
+	}
+	if ic.CAFile == "" {
+		return cfg, nil
+	}
+
+	pem, err := os.ReadFile(ic.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not read CA file: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in CA file %s", ic.CAFile)
+	}
+	cfg.RootCAs = pool
+	return cfg, nil
+}
+
 // Disconnect logs out and closes the IMAP connection if present.
 func (ic *ImapClient) Disconnect() error {
 	// If the client is nil, there's nothing to disconnect from.
@@ -62,7 +152,9 @@ func (ic *ImapClient) Disconnect() error {
 	return nil
 }
 
-func (ic *ImapClient) CollectMessages(ignoreReadMessages bool, filterHeader string, filterValue string) ([]*ImapMessage, error) {
+// CollectMessages searches the selected mailbox for messages matching the
+// to/subject filter and the optional search criteria.
+func (ic *ImapClient) CollectMessages(ignoreReadMessages bool, filterHeader string, filterValue string, search *config.ImapSearch) ([]*ImapMessage, error) {
 	// If the backend is nil return an error indicating that the client is not connected.
 	if ic.Backend == nil {
 		return nil, fmt.Errorf("failed to collect messages, IMAP client not connected")
@@ -87,6 +179,14 @@ func (ic *ImapClient) CollectMessages(ignoreReadMessages bool, filterHeader stri
 		criteria.Header = append(criteria.Header, imap.SearchCriteriaHeaderField{Key: "SUBJECT", Value: filterValue})
 	}
 
+	if search != nil {
+		searchCriteria, err := buildSearchCriteria(search)
+		if err != nil {
+			return nil, err
+		}
+		criteria.And(searchCriteria)
+	}
+
 	uids, err := ic.Backend.UIDSearch(criteria)
 	if err != nil {
 		return nil, err
@@ -1,8 +1,11 @@
 package imap
 
 import (
+	"crypto/tls"
 	"errors"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	imapv2 "github.com/emersion/go-imap/v2"
@@ -13,7 +16,7 @@ import (
 // TestImapCollectMessages_NotConnected ensures CollectMessages returns an error when not connected.
 func TestImapCollectMessages_NotConnected(t *testing.T) {
 	ic := &ImapClient{}
-	if _, err := ic.CollectMessages(true, "to", "x"); err == nil {
+	if _, err := ic.CollectMessages(true, "to", "x", nil); err == nil {
 		t.Fatalf("expected not connected error")
 	}
 }
@@ -21,7 +24,7 @@ func TestImapCollectMessages_NotConnected(t *testing.T) {
 // TestImapCollectMessages_Subject_NoUnread collects messages without unread filter using subject.
 func TestImapCollectMessages_Subject_NoUnread(t *testing.T) {
 	ic := &ImapClient{Backend: &fakeBackend{uids: []imapv2.UID{10, 20}}}
-	msgs, err := ic.CollectMessages(false, "subject", "Weekly")
+	msgs, err := ic.CollectMessages(false, "subject", "Weekly", nil)
 	if err != nil {
 		t.Fatalf("Collect: %v", err)
 	}
@@ -77,7 +80,7 @@ func TestImapDeleteFromServer_NotConnected(t *testing.T) {
 func TestImapClient_Connect_And_Disconnect(t *testing.T) {
 	orig := imapDial
 	t.Cleanup(func() { imapDial = orig })
-	imapDial = func(addr string) (imapConn, *imapclient.Client, error) {
+	imapDial = func(addr string, security string, tlsConfig *tls.Config) (imapConn, *imapclient.Client, error) {
 		return &fakeConn{}, nil, nil
 	}
 	pw := sensitive.String("pw")
@@ -98,7 +101,7 @@ func TestImapClient_Connect_And_Disconnect(t *testing.T) {
 func TestImapClient_Connect_LoginError(t *testing.T) {
 	orig := imapDial
 	t.Cleanup(func() { imapDial = orig })
-	imapDial = func(addr string) (imapConn, *imapclient.Client, error) {
+	imapDial = func(addr string, security string, tlsConfig *tls.Config) (imapConn, *imapclient.Client, error) {
 		return &fakeConn{loginErr: errors.New("x")}, nil, nil
 	}
 	pw := sensitive.String("pw")
@@ -112,7 +115,7 @@ func TestImapClient_Connect_LoginError(t *testing.T) {
 func TestImapClient_Connect_SelectError(t *testing.T) {
 	orig := imapDial
 	t.Cleanup(func() { imapDial = orig })
-	imapDial = func(addr string) (imapConn, *imapclient.Client, error) {
+	imapDial = func(addr string, security string, tlsConfig *tls.Config) (imapConn, *imapclient.Client, error) {
 		return &fakeConn{selErr: errors.New("x")}, nil, nil
 	}
 	pw := sensitive.String("pw")
@@ -126,7 +129,9 @@ func TestImapClient_Connect_SelectError(t *testing.T) {
 func TestImapClient_Connect_DialError(t *testing.T) {
 	orig := imapDial
 	t.Cleanup(func() { imapDial = orig })
-	imapDial = func(addr string) (imapConn, *imapclient.Client, error) { return nil, nil, errors.New("dial") }
+	imapDial = func(addr string, security string, tlsConfig *tls.Config) (imapConn, *imapclient.Client, error) {
+		return nil, nil, errors.New("dial")
+	}
 	pw := sensitive.String("pw")
 	ic := &ImapClient{Password: &pw}
 	if err := ic.Connect("INBOX"); err == nil {
@@ -138,7 +143,7 @@ func TestImapClient_Connect_DialError(t *testing.T) {
 func TestImapClient_Connect_BackendSet(t *testing.T) {
 	orig := imapDial
 	t.Cleanup(func() { imapDial = orig })
-	imapDial = func(addr string) (imapConn, *imapclient.Client, error) {
+	imapDial = func(addr string, security string, tlsConfig *tls.Config) (imapConn, *imapclient.Client, error) {
 		return &fakeConn{}, &imapclient.Client{}, nil
 	}
 	pw := sensitive.String("pw")
@@ -184,7 +189,71 @@ func TestDownloadAttachments_InvalidBase64(t *testing.T) {
 	}
 	ic := &ImapClient{Backend: backend}
 	msg := NewImapMessage(uid, ic)
-	if err := msg.DownloadAttachments(t.TempDir(), []string{".epub"}, false, false); err == nil {
+	if err := msg.DownloadAttachments(t.TempDir(), []string{".epub"}, false, AfterDownload{}); err == nil {
 		t.Fatalf("expected decode error")
 	}
 }
+
+// TestImapClient_Connect_PassesSecurity ensures the security mode and TLS
+// settings reach the dial seam.
+func TestImapClient_Connect_PassesSecurity(t *testing.T) {
+	orig := imapDial
+	t.Cleanup(func() { imapDial = orig })
+	var gotAddr, gotSecurity string
+	var gotConfig *tls.Config
+	imapDial = func(addr string, security string, tlsConfig *tls.Config) (imapConn, *imapclient.Client, error) {
+		gotAddr, gotSecurity, gotConfig = addr, security, tlsConfig
+		return &fakeConn{}, nil, nil
+	}
+	pw := sensitive.String("pw")
+	ic := &ImapClient{Host: "mail.example.com", Port: 143, Password: &pw, Security: "starttls", InsecureSkipVerify: true}
+	if err := ic.Connect("INBOX"); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if gotAddr != "mail.example.com:143" || gotSecurity != "starttls" {
+		t.Fatalf("unexpected dial %q %q", gotAddr, gotSecurity)
+	}
+	if gotConfig.ServerName != "mail.example.com" || !gotConfig.InsecureSkipVerify || gotConfig.RootCAs != nil {
+		t.Fatalf("unexpected tls config %+v", gotConfig)
+	}
+}
+
+// TestImapClient_TLSConfig_ServerNameAndCA ensures server_name overrides the
+// host and a CA file is added to the trusted roots.
+func TestImapClient_TLSConfig_ServerNameAndCA(t *testing.T) {
+	_, caFile := newTestCert(t)
+	ic := &ImapClient{Host: "10.0.0.5", ServerName: "imap.test", CAFile: caFile}
+	cfg, err := ic.tlsConfig()
+	if err != nil {
+		t.Fatalf("tlsConfig: %v", err)
+	}
+	if cfg.ServerName != "imap.test" || cfg.RootCAs == nil {
+		t.Fatalf("unexpected tls config %+v", cfg)
+	}
+}
+
+// TestImapClient_TLSConfig_CAFileErrors ensures unreadable or empty CA files
+// abort the connection before dialing.
+func TestImapClient_TLSConfig_CAFileErrors(t *testing.T) {
+	orig := imapDial
+	t.Cleanup(func() { imapDial = orig })
+	imapDial = func(addr string, security string, tlsConfig *tls.Config) (imapConn, *imapclient.Client, error) {
+		t.Fatalf("dial should not be called")
+		return nil, nil, nil
+	}
+
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	if err := os.WriteFile(empty, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	pw := sensitive.String("pw")
+	for file, want := range map[string]string{
+		filepath.Join(t.TempDir(), "missing.pem"): "could not read CA file",
+		empty: "no certificates found",
+	} {
+		ic := &ImapClient{Host: "h", Password: &pw, CAFile: file}
+		if err := ic.Connect("INBOX"); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("%s: unexpected err %v", file, err)
+		}
+	}
+}
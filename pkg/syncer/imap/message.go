@@ -1,8 +1,11 @@
 package imap
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
 
+	"github.com/bjw-s-labs/bookshift/pkg/util"
 	"github.com/emersion/go-imap/v2"
 )
 
@@ -20,7 +23,17 @@ func NewImapMessage(uid imap.UID, ic *ImapClient) *ImapMessage {
 	}
 }
 
-// DeleteFromServer marks the message as \Deleted and expunges it from the mailbox.
+// AfterDownload is what happens to a message once its attachments were
+// downloaded: nothing ("none"), marking it as read ("seen"), adding Keyword
+// ("flag"), moving it to Mailbox ("move") or deleting it ("delete").
+type AfterDownload struct {
+	Action  string
+	Mailbox string
+	Keyword string
+}
+
+// DeleteFromServer marks the message as \Deleted and expunges only this
+// message. Without UID EXPUNGE support the message stays marked as deleted.
 func (im *ImapMessage) DeleteFromServer() error {
 	if im.imapClient.Backend == nil {
 		return fmt.Errorf("failed to delete message, IMAP client not connected")
@@ -28,8 +41,55 @@ func (im *ImapMessage) DeleteFromServer() error {
 	if err := im.imapClient.Backend.StoreAddFlags(imap.UID(im.uid), []imap.Flag{imap.FlagDeleted}); err != nil {
 		return err
 	}
-	if err := im.imapClient.Backend.Expunge(); err != nil {
-		return err
+	return im.expunged(im.imapClient.Backend.UIDExpunge(im.uid))
+}
+
+// MoveToMailbox moves the message to another mailbox.
+func (im *ImapMessage) MoveToMailbox(mailbox string) error {
+	if im.imapClient.Backend == nil {
+		return fmt.Errorf("failed to move message, IMAP client not connected")
+	}
+	return im.expunged(im.imapClient.Backend.Move(im.uid, mailbox))
+}
+
+// AddFlags adds flags or keywords to the message.
+func (im *ImapMessage) AddFlags(flags ...imap.Flag) error {
+	if im.imapClient.Backend == nil {
+		return fmt.Errorf("failed to flag message, IMAP client not connected")
+	}
+	return im.imapClient.Backend.StoreAddFlags(im.uid, flags)
+}
+
+// expunged turns a missing UID EXPUNGE into a warning: expunging the whole
+// mailbox instead would also remove messages other clients marked as deleted.
+func (im *ImapMessage) expunged(err error) error {
+	if errors.Is(err, errUIDExpungeUnsupported) {
+		slog.Warn("IMAP server does not support UID EXPUNGE, leaving message marked as deleted", "host", im.imapClient.Host, "uid", im.uid)
+		return nil
+	}
+	return err
+}
+
+// applyAfterDownload performs the configured action on a processed message.
+func (im *ImapMessage) applyAfterDownload(after AfterDownload) error {
+	if after.Action == "" || after.Action == "none" {
+		return nil
+	}
+	if util.DryRun {
+		slog.Info("[dry-run] Would process message on server", "uid", im.uid, "action", after.Action, "mailbox", after.Mailbox, "keyword", after.Keyword)
+		return nil
+	}
+
+	switch after.Action {
+	case "seen":
+		return im.AddFlags(imap.FlagSeen)
+	case "flag":
+		return im.AddFlags(imap.Flag(after.Keyword))
+	case "move":
+		return im.MoveToMailbox(after.Mailbox)
+	case "delete":
+		return im.DeleteFromServer()
+	default:
+		return fmt.Errorf("unsupported after_download action: %s", after.Action)
 	}
-	return nil
 }
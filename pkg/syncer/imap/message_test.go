@@ -2,8 +2,10 @@ package imap
 
 import (
 	// "errors"
+	"reflect"
 	"testing"
 
+	"github.com/bjw-s-labs/bookshift/pkg/util"
 	"github.com/emersion/go-imap/v2"
 )
 
@@ -52,3 +54,71 @@ func TestNewImapMessage_UID(t *testing.T) {
 		t.Fatalf("want uid %v, got %v", uid, msg.uid)
 	}
 }
+
+// TestApplyAfterDownload_Actions ensures each action sends the expected
+// commands and only expunges the processed message.
+func TestApplyAfterDownload_Actions(t *testing.T) {
+	cases := []struct {
+		after    AfterDownload
+		flags    []imap.Flag
+		moved    string
+		expunged bool
+	}{
+		{after: AfterDownload{}},
+		{after: AfterDownload{Action: "none"}},
+		{after: AfterDownload{Action: "seen"}, flags: []imap.Flag{imap.FlagSeen}},
+		{after: AfterDownload{Action: "flag", Keyword: "$BookShiftDone"}, flags: []imap.Flag{"$BookShiftDone"}},
+		{after: AfterDownload{Action: "move", Mailbox: "Delivered"}, moved: "Delivered"},
+		{after: AfterDownload{Action: "delete"}, flags: []imap.Flag{imap.FlagDeleted}, expunged: true},
+	}
+	for _, tc := range cases {
+		backend := &recordingBackend{}
+		m := NewImapMessage(5, &ImapClient{Backend: backend})
+		if err := m.applyAfterDownload(tc.after); err != nil {
+			t.Fatalf("%+v: %v", tc.after, err)
+		}
+		if !reflect.DeepEqual(backend.flags, tc.flags) {
+			t.Fatalf("%+v: flags %v, want %v", tc.after, backend.flags, tc.flags)
+		}
+		if backend.moved[5] != tc.moved {
+			t.Fatalf("%+v: moved %v, want %q", tc.after, backend.moved, tc.moved)
+		}
+		if tc.expunged != reflect.DeepEqual(backend.expunged, []imap.UID{5}) {
+			t.Fatalf("%+v: expunged %v", tc.after, backend.expunged)
+		}
+	}
+
+	if err := NewImapMessage(5, &ImapClient{Backend: &recordingBackend{}}).applyAfterDownload(AfterDownload{Action: "archive"}); err == nil {
+		t.Fatalf("expected unsupported action error")
+	}
+	if err := NewImapMessage(5, &ImapClient{}).applyAfterDownload(AfterDownload{Action: "seen"}); err == nil {
+		t.Fatalf("expected not connected error")
+	}
+}
+
+// TestApplyAfterDownload_DryRun ensures dry-run leaves the message alone.
+func TestApplyAfterDownload_DryRun(t *testing.T) {
+	util.DryRun = true
+	t.Cleanup(func() { util.DryRun = false })
+	backend := &recordingBackend{}
+	if err := NewImapMessage(5, &ImapClient{Backend: backend}).applyAfterDownload(AfterDownload{Action: "delete"}); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if len(backend.stored) != 0 || len(backend.expunged) != 0 {
+		t.Fatalf("dry-run must not change the message")
+	}
+}
+
+// TestImapMessage_UIDExpungeUnsupported ensures a missing UID EXPUNGE leaves
+// the message marked as deleted instead of failing or expunging the mailbox.
+func TestImapMessage_UIDExpungeUnsupported(t *testing.T) {
+	fb := &fakeBackend{expungeErr: errUIDExpungeUnsupported}
+	m := NewImapMessage(5, &ImapClient{Backend: fb})
+	if err := m.DeleteFromServer(); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	fb.moveErr = errUIDExpungeUnsupported
+	if err := m.MoveToMailbox("Delivered"); err != nil {
+		t.Fatalf("move: %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+package imap
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+	"github.com/emersion/go-sasl"
+	"github.com/go-playground/sensitive"
+)
+
+// oauth2Timeout bounds a single request to the token endpoint.
+var oauth2Timeout = 30 * time.Second
+
+// OAuth2Source obtains access tokens for SASL XOAUTH2 and OAUTHBEARER by
+// redeeming a refresh token at a token endpoint. Tokens are cached in
+// CacheFile between runs and only refreshed when they are about to expire.
+type OAuth2Source struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret *sensitive.String
+	RefreshToken *sensitive.String
+	Scopes       []string
+	CacheFile    string
+
+	mu     sync.Mutex
+	cached *oauth2Token
+}
+
+// oauth2Token is the token cache file content. RefreshToken is only set when
+// the token endpoint rotated the configured refresh token.
+type oauth2Token struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Expiry       time.Time `json:"expiry"`
+}
+
+// valid reports whether the access token can still be used for at least a minute.
+func (t *oauth2Token) valid() bool {
+	return t != nil && t.AccessToken != "" && time.Now().Add(time.Minute).Before(t.Expiry)
+}
+
+// AccessToken returns a cached access token or requests a new one. With
+// forceRefresh a new token is requested even when the cached one has not
+// expired, e.g. after the server rejected it.
+func (s *OAuth2Source) AccessToken(forceRefresh bool) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cached == nil && s.CacheFile != "" {
+		var cached oauth2Token
+		if err := util.LoadState(s.CacheFile, &cached); err != nil {
+			slog.Warn("Ignoring unreadable OAuth2 token cache", "file", s.CacheFile, "error", err)
+		} else {
+			s.cached = &cached
+		}
+	}
+	if !forceRefresh && s.cached.valid() {
+		return s.cached.AccessToken, nil
+	}
+
+	refreshToken := ""
+	if s.RefreshToken != nil {
+		refreshToken = string(*s.RefreshToken)
+	}
+	used := s.rotatedRefreshToken(refreshToken)
+	token, err := s.refresh(used)
+	if err != nil && used != refreshToken {
+		// The rotated refresh token may have been revoked; fall back to the configured one.
+		slog.Debug("Rotated OAuth2 refresh token rejected, retrying with the configured one", "error", err)
+		used = refreshToken
+		token, err = s.refresh(used)
+	}
+	if err != nil {
+		return "", fmt.Errorf("could not refresh OAuth2 access token: %w", err)
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = used
+	}
+	if token.RefreshToken == refreshToken {
+		token.RefreshToken = ""
+	}
+
+	s.cached = token
+	if s.CacheFile != "" {
+		if err := util.SaveState(s.CacheFile, token); err != nil {
+			slog.Warn("Could not write OAuth2 token cache", "file", s.CacheFile, "error", err)
+		}
+	}
+	return token.AccessToken, nil
+}
+
+// rotatedRefreshToken returns the refresh token returned by an earlier
+// refresh, or the configured one when it was never rotated.
+func (s *OAuth2Source) rotatedRefreshToken(configured string) string {
+	if s.cached != nil && s.cached.RefreshToken != "" {
+		return s.cached.RefreshToken
+	}
+	return configured
+}
+
+// refresh redeems a refresh token at the token endpoint.
+func (s *OAuth2Source) refresh(refreshToken string) (*oauth2Token, error) {
+	if s.TokenURL == "" || refreshToken == "" {
+		return nil, fmt.Errorf("oauth2_token_url and oauth2_refresh_token are required")
+	}
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+		"client_id":     {s.ClientID},
+	}
+	if s.ClientSecret != nil {
+		form.Set("client_secret", string(*s.ClientSecret))
+	}
+	if len(s.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.Scopes, " "))
+	}
+
+	slog.Debug("Requesting OAuth2 access token", "url", s.TokenURL)
+	client := &http.Client{Timeout: oauth2Timeout}
+	resp, err := client.PostForm(s.TokenURL, form)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		AccessToken      string `json:"access_token"`
+		RefreshToken     string `json:"refresh_token"`
+		ExpiresIn        int    `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected response: %s: %.200s", resp.Status, strings.TrimSpace(string(data)))
+		}
+		return nil, fmt.Errorf("invalid token response: %w", err)
+	}
+	if body.Error != "" {
+		return nil, fmt.Errorf("token endpoint returned %s: %s", body.Error, body.ErrorDescription)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+	if body.AccessToken == "" {
+		return nil, fmt.Errorf("no access token in response")
+	}
+
+	token := &oauth2Token{AccessToken: body.AccessToken, RefreshToken: body.RefreshToken}
+	if body.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(body.ExpiresIn) * time.Second)
+	} else {
+		// Without an expiry the token is used for this session only.
+		token.Expiry = time.Now().Add(time.Minute)
+	}
+	return token, nil
+}
+
+// newOAuth2SaslClient returns the SASL client for the xoauth2 or oauthbearer
+// auth method.
+func newOAuth2SaslClient(method string, username string, token string, host string, port int) sasl.Client {
+	if method == "oauthbearer" {
+		return sasl.NewOAuthBearerClient(&sasl.OAuthBearerOptions{
+			Username: username,
+			Token:    token,
+			Host:     host,
+			Port:     port,
+		})
+	}
+	return &xoauth2Client{username: username, token: token}
+}
+
+// xoauth2Client implements the SASL XOAUTH2 mechanism used by Gmail and
+// Microsoft 365, which go-sasl does not provide.
+type xoauth2Client struct {
+	username string
+	token    string
+}
+
+func (c *xoauth2Client) Start() (string, []byte, error) {
+	return "XOAUTH2", []byte("user=" + c.username + "\x01auth=Bearer " + c.token + "\x01\x01"), nil
+}
+
+// Next answers the JSON error challenge a server sends when it rejects the
+// token with an empty response, after which the server fails the command.
+func (c *xoauth2Client) Next(challenge []byte) ([]byte, error) {
+	slog.Debug("XOAUTH2 authentication rejected", "challenge", string(challenge))
+	return []byte{}, nil
+}
@@ -0,0 +1,225 @@
+package imap
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+	"github.com/emersion/go-imap/v2/imapclient"
+	"github.com/go-playground/sensitive"
+)
+
+// fakeTokenEndpoint is a local stand-in for an OAuth2 token endpoint. It
+// hands out access-1, access-2, ... and records the refresh tokens it got.
+type fakeTokenEndpoint struct {
+	srv           *httptest.Server
+	refreshTokens []string
+	form          map[string]string
+	rotate        bool
+	reject        map[string]bool
+}
+
+func newFakeTokenEndpoint(t *testing.T) *fakeTokenEndpoint {
+	t.Helper()
+	f := &fakeTokenEndpoint{reject: map[string]bool{}}
+	f.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parse form: %v", err)
+		}
+		f.form = map[string]string{}
+		for k := range r.PostForm {
+			f.form[k] = r.PostForm.Get(k)
+		}
+		rt := r.PostForm.Get("refresh_token")
+		f.refreshTokens = append(f.refreshTokens, rt)
+		w.Header().Set("Content-Type", "application/json")
+		if f.reject[rt] {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant", "error_description": "token revoked"})
+			return
+		}
+		resp := map[string]any{"access_token": "access-" + string(rune('0'+len(f.refreshTokens))), "expires_in": 3600}
+		if f.rotate {
+			resp["refresh_token"] = "rotated-" + string(rune('0'+len(f.refreshTokens)))
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func newTestOAuth2Source(f *fakeTokenEndpoint, cacheFile string) *OAuth2Source {
+	secret := sensitive.String("secret")
+	refresh := sensitive.String("refresh")
+	return &OAuth2Source{
+		TokenURL:     f.srv.URL,
+		ClientID:     "client",
+		ClientSecret: &secret,
+		RefreshToken: &refresh,
+		Scopes:       []string{"https://mail.google.com/"},
+		CacheFile:    cacheFile,
+	}
+}
+
+// TestOAuth2Source_RefreshAndCache ensures a refreshed token is written to the
+// cache file and reused by the next run without contacting the endpoint.
+func TestOAuth2Source_RefreshAndCache(t *testing.T) {
+	f := newFakeTokenEndpoint(t)
+	cache := filepath.Join(t.TempDir(), "token.json")
+
+	token, err := newTestOAuth2Source(f, cache).AccessToken(false)
+	if err != nil {
+		t.Fatalf("AccessToken: %v", err)
+	}
+	if token != "access-1" {
+		t.Fatalf("unexpected token %q", token)
+	}
+	want := map[string]string{"grant_type": "refresh_token", "refresh_token": "refresh", "client_id": "client", "client_secret": "secret", "scope": "https://mail.google.com/"}
+	for k, v := range want {
+		if f.form[k] != v {
+			t.Fatalf("form %s = %q, want %q", k, f.form[k], v)
+		}
+	}
+
+	var cached oauth2Token
+	if err := util.LoadState(cache, &cached); err != nil {
+		t.Fatalf("load cache: %v", err)
+	}
+	if cached.AccessToken != "access-1" || cached.RefreshToken != "" || time.Until(cached.Expiry) < 50*time.Minute {
+		t.Fatalf("unexpected cache %+v", cached)
+	}
+
+	token, err = newTestOAuth2Source(f, cache).AccessToken(false)
+	if err != nil || token != "access-1" {
+		t.Fatalf("cached token: %q %v", token, err)
+	}
+	if len(f.refreshTokens) != 1 {
+		t.Fatalf("expected a single token request, got %d", len(f.refreshTokens))
+	}
+}
+
+// TestOAuth2Source_ExpiredAndForcedRefresh ensures expired cached tokens and
+// forced refreshes request a new token.
+func TestOAuth2Source_ExpiredAndForcedRefresh(t *testing.T) {
+	f := newFakeTokenEndpoint(t)
+	cache := filepath.Join(t.TempDir(), "token.json")
+	if err := util.SaveState(cache, oauth2Token{AccessToken: "old", Expiry: time.Now().Add(30 * time.Second)}); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTestOAuth2Source(f, cache)
+	if token, err := s.AccessToken(false); err != nil || token != "access-1" {
+		t.Fatalf("expired token not refreshed: %q %v", token, err)
+	}
+	if token, err := s.AccessToken(false); err != nil || token != "access-1" {
+		t.Fatalf("valid token not reused: %q %v", token, err)
+	}
+	if token, err := s.AccessToken(true); err != nil || token != "access-2" {
+		t.Fatalf("forced refresh: %q %v", token, err)
+	}
+}
+
+// TestOAuth2Source_RotatedRefreshToken ensures a rotated refresh token is
+// cached and used, and that the configured one is tried when it is rejected.
+func TestOAuth2Source_RotatedRefreshToken(t *testing.T) {
+	f := newFakeTokenEndpoint(t)
+	f.rotate = true
+	cache := filepath.Join(t.TempDir(), "token.json")
+
+	if _, err := newTestOAuth2Source(f, cache).AccessToken(true); err != nil {
+		t.Fatalf("AccessToken: %v", err)
+	}
+	if _, err := newTestOAuth2Source(f, cache).AccessToken(true); err != nil {
+		t.Fatalf("AccessToken: %v", err)
+	}
+	if got := strings.Join(f.refreshTokens, ","); got != "refresh,rotated-1" {
+		t.Fatalf("unexpected refresh tokens %s", got)
+	}
+
+	f.reject["rotated-2"] = true
+	if token, err := newTestOAuth2Source(f, cache).AccessToken(true); err != nil || token != "access-4" {
+		t.Fatalf("fallback: %q %v", token, err)
+	}
+	if got := strings.Join(f.refreshTokens, ","); got != "refresh,rotated-1,rotated-2,refresh" {
+		t.Fatalf("unexpected refresh tokens %s", got)
+	}
+}
+
+// TestOAuth2Source_EndpointError ensures OAuth2 errors are reported.
+func TestOAuth2Source_EndpointError(t *testing.T) {
+	f := newFakeTokenEndpoint(t)
+	f.reject["refresh"] = true
+	_, err := newTestOAuth2Source(f, "").AccessToken(false)
+	if err == nil || !strings.Contains(err.Error(), "invalid_grant: token revoked") {
+		t.Fatalf("unexpected err: %v", err)
+	}
+}
+
+// TestImapClient_Connect_OAuth2 ensures XOAUTH2 and OAUTHBEARER send the
+// access token, and that a rejected token is refreshed once.
+func TestImapClient_Connect_OAuth2(t *testing.T) {
+	f := newFakeTokenEndpoint(t)
+	conn := &fakeConn{authErrs: []error{errors.New("rejected")}}
+	orig := imapDial
+	t.Cleanup(func() { imapDial = orig })
+	imapDial = func(addr string, security string, tlsConfig *tls.Config) (imapConn, *imapclient.Client, error) {
+		return conn, nil, nil
+	}
+
+	ic := &ImapClient{Host: "imap.example", Port: 993, Username: "reader@example.com", AuthMethod: "xoauth2", OAuth2: newTestOAuth2Source(f, "")}
+	if err := ic.Connect("INBOX"); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	want := []string{
+		"XOAUTH2 user=reader@example.com\x01auth=Bearer access-1\x01\x01",
+		"XOAUTH2 user=reader@example.com\x01auth=Bearer access-2\x01\x01",
+	}
+	if strings.Join(conn.auths, "|") != strings.Join(want, "|") {
+		t.Fatalf("unexpected auths %q", conn.auths)
+	}
+
+	conn = &fakeConn{}
+	ic = &ImapClient{Host: "imap.example", Port: 993, Username: "reader@example.com", AuthMethod: "oauthbearer", OAuth2: newTestOAuth2Source(f, "")}
+	if err := ic.Connect("INBOX"); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if len(conn.auths) != 1 || !strings.HasPrefix(conn.auths[0], "OAUTHBEARER n,a=reader@example.com,") || !strings.Contains(conn.auths[0], "auth=Bearer access-3") {
+		t.Fatalf("unexpected auths %q", conn.auths)
+	}
+}
+
+// TestImapClient_Connect_OAuth2Rejected ensures a token rejected twice fails
+// the connection and closes it.
+func TestImapClient_Connect_OAuth2Rejected(t *testing.T) {
+	f := newFakeTokenEndpoint(t)
+	conn := &fakeConn{authErrs: []error{errors.New("rejected"), errors.New("rejected")}}
+	orig := imapDial
+	t.Cleanup(func() { imapDial = orig })
+	imapDial = func(addr string, security string, tlsConfig *tls.Config) (imapConn, *imapclient.Client, error) {
+		return conn, nil, nil
+	}
+
+	ic := &ImapClient{Host: "h", Username: "u", AuthMethod: "oauthbearer", OAuth2: newTestOAuth2Source(f, "")}
+	if err := ic.Connect("INBOX"); err == nil {
+		t.Fatalf("expected authentication error")
+	}
+	if !conn.closed || len(conn.auths) != 2 {
+		t.Fatalf("closed=%v auths=%d", conn.closed, len(conn.auths))
+	}
+}
+
+// TestOAuth2Source_MissingSettings ensures a missing token URL or refresh token
+// is reported instead of sending a request.
+func TestOAuth2Source_MissingSettings(t *testing.T) {
+	_, err := (&OAuth2Source{ClientID: "c"}).AccessToken(false)
+	if err == nil || !strings.Contains(err.Error(), "oauth2_token_url and oauth2_refresh_token are required") {
+		t.Fatalf("unexpected err: %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+package imap
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/emersion/go-imap/v2"
+)
+
+// searchNow is the reference time for relative dates (overridable in tests).
+var searchNow = time.Now
+
+// buildSearchCriteria maps the configured search onto IMAP SEARCH criteria.
+func buildSearchCriteria(search *config.ImapSearch) (*imap.SearchCriteria, error) {
+	criteria := &imap.SearchCriteria{}
+
+	headers := []struct {
+		key    string
+		values []string
+	}{
+		{"FROM", search.From},
+		{"TO", search.To},
+		{"CC", search.Cc},
+		{"SUBJECT", search.Subject},
+	}
+	for _, h := range headers {
+		for _, v := range h.values {
+			criteria.Header = append(criteria.Header, imap.SearchCriteriaHeaderField{Key: h.key, Value: v})
+		}
+	}
+	// Sort arbitrary headers so the search sent to the server is stable.
+	names := make([]string, 0, len(search.Header))
+	for name := range search.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		criteria.Header = append(criteria.Header, imap.SearchCriteriaHeaderField{Key: name, Value: search.Header[name]})
+	}
+	criteria.Body = append(criteria.Body, search.Body...)
+	criteria.Text = append(criteria.Text, search.Text...)
+
+	dates := []struct {
+		name  string
+		value string
+		field *time.Time
+	}{
+		{"since", search.Since, &criteria.Since},
+		{"before", search.Before, &criteria.Before},
+		{"sent_since", search.SentSince, &criteria.SentSince},
+		{"sent_before", search.SentBefore, &criteria.SentBefore},
+	}
+	for _, d := range dates {
+		if d.value == "" {
+			continue
+		}
+		t, err := parseSearchDate(d.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid search %s: %w", d.name, err)
+		}
+		*d.field = t
+	}
+
+	if search.LargerThan < 0 || search.SmallerThan < 0 {
+		return nil, fmt.Errorf("invalid search size: sizes must not be negative")
+	}
+	criteria.Larger = search.LargerThan
+	criteria.Smaller = search.SmallerThan
+
+	for _, k := range search.Keywords {
+		criteria.Flag = append(criteria.Flag, imap.Flag(k))
+	}
+
+	for i := range search.And {
+		nested, err := buildSearchCriteria(&search.And[i])
+		if err != nil {
+			return nil, err
+		}
+		criteria.And(nested)
+	}
+
+	for i := range search.Not {
+		nested, err := buildSearchCriteria(&search.Not[i])
+		if err != nil {
+			return nil, err
+		}
+		criteria.Not = append(criteria.Not, *nested)
+	}
+
+	// IMAP OR takes two keys; longer lists are nested: a OR (b OR c).
+	var alternatives []imap.SearchCriteria
+	for i := range search.Or {
+		nested, err := buildSearchCriteria(&search.Or[i])
+		if err != nil {
+			return nil, err
+		}
+		alternatives = append(alternatives, *nested)
+	}
+	if len(alternatives) > 0 {
+		either := alternatives[len(alternatives)-1]
+		for i := len(alternatives) - 2; i >= 0; i-- {
+			either = imap.SearchCriteria{Or: [][2]imap.SearchCriteria{{alternatives[i], either}}}
+		}
+		criteria.And(&either)
+	}
+
+	return criteria, nil
+}
+
+// parseSearchDate parses a YYYY-MM-DD date or a number of days before today
+// such as "30d".
+func parseSearchDate(value string) (time.Time, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return time.Time{}, fmt.Errorf("%q is not a number of days", value)
+		}
+		return searchNow().AddDate(0, 0, -n), nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not a YYYY-MM-DD date", value)
+	}
+	return t, nil
+}
@@ -0,0 +1,151 @@
+package imap
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	imapv2 "github.com/emersion/go-imap/v2"
+)
+
+// TestBuildSearchCriteria_Fields ensures every search field maps onto the
+// matching IMAP search key.
+func TestBuildSearchCriteria_Fields(t *testing.T) {
+	orig := searchNow
+	t.Cleanup(func() { searchNow = orig })
+	searchNow = func() time.Time { return time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC) }
+
+	got, err := buildSearchCriteria(&config.ImapSearch{
+		From:        []string{"friend@example.com"},
+		To:          []string{"books+kindle@example.com"},
+		Cc:          []string{"archive@example.com"},
+		Subject:     []string{"[BOOK]"},
+		Header:      map[string]string{"X-Mailer": "calibre", "Delivered-To": "me"},
+		Body:        []string{"attached"},
+		Text:        []string{"epub"},
+		Since:       "2024-01-01",
+		Before:      "7d",
+		SentSince:   "2023-12-01",
+		SentBefore:  "0d",
+		LargerThan:  1024,
+		SmallerThan: 50 << 20,
+		Keywords:    []string{"$Books"},
+	})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+
+	want := &imapv2.SearchCriteria{
+		Header: []imapv2.SearchCriteriaHeaderField{
+			{Key: "FROM", Value: "friend@example.com"},
+			{Key: "TO", Value: "books+kindle@example.com"},
+			{Key: "CC", Value: "archive@example.com"},
+			{Key: "SUBJECT", Value: "[BOOK]"},
+			{Key: "Delivered-To", Value: "me"},
+			{Key: "X-Mailer", Value: "calibre"},
+		},
+		Body:       []string{"attached"},
+		Text:       []string{"epub"},
+		Since:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Before:     time.Date(2024, 3, 24, 12, 0, 0, 0, time.UTC),
+		SentSince:  time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC),
+		SentBefore: time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC),
+		Larger:     1024,
+		Smaller:    50 << 20,
+		Flag:       []imapv2.Flag{"$Books"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v\nwant %+v", got, want)
+	}
+}
+
+// TestBuildSearchCriteria_Composition ensures and, or and not nest as IMAP
+// AND, OR and NOT keys.
+func TestBuildSearchCriteria_Composition(t *testing.T) {
+	got, err := buildSearchCriteria(&config.ImapSearch{
+		Subject: []string{"book"},
+		And:     []config.ImapSearch{{From: []string{"a"}}},
+		Or:      []config.ImapSearch{{To: []string{"x"}}, {To: []string{"y"}}, {Cc: []string{"z"}}},
+		Not:     []config.ImapSearch{{Header: map[string]string{"List-Id": ""}}, {From: []string{"noreply"}}},
+	})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+
+	header := func(k, v string) imapv2.SearchCriteria {
+		return imapv2.SearchCriteria{Header: []imapv2.SearchCriteriaHeaderField{{Key: k, Value: v}}}
+	}
+	want := &imapv2.SearchCriteria{
+		Header: []imapv2.SearchCriteriaHeaderField{{Key: "SUBJECT", Value: "book"}, {Key: "FROM", Value: "a"}},
+		Not:    []imapv2.SearchCriteria{header("List-Id", ""), header("FROM", "noreply")},
+		Or: [][2]imapv2.SearchCriteria{{
+			header("TO", "x"),
+			{Or: [][2]imapv2.SearchCriteria{{header("TO", "y"), header("CC", "z")}}},
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v\nwant %+v", got, want)
+	}
+
+	// A single alternative is simply required.
+	got, err = buildSearchCriteria(&config.ImapSearch{Or: []config.ImapSearch{{To: []string{"x"}}}})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if !reflect.DeepEqual(got, &imapv2.SearchCriteria{Header: header("TO", "x").Header}) {
+		t.Fatalf("unexpected single or: %+v", got)
+	}
+}
+
+// TestBuildSearchCriteria_Errors ensures invalid dates and sizes are reported,
+// including in nested criteria.
+func TestBuildSearchCriteria_Errors(t *testing.T) {
+	cases := map[string]*config.ImapSearch{
+		"invalid search since":  {Since: "yesterday"},
+		"invalid search before": {Before: "-3d"},
+		"invalid search size":   {LargerThan: -1},
+		"sent_before":           {Not: []config.ImapSearch{{SentBefore: "2024-13-01"}}},
+		"sent_since":            {Or: []config.ImapSearch{{SentSince: "xd"}}},
+		"since":                 {And: []config.ImapSearch{{Since: "01/02/2024"}}},
+	}
+	for want, search := range cases {
+		if _, err := buildSearchCriteria(search); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("%s: unexpected err %v", want, err)
+		}
+	}
+}
+
+// TestImapCollectMessages_Search ensures the search criteria are combined
+// with the unread and to/subject filters.
+func TestImapCollectMessages_Search(t *testing.T) {
+	fb := &fakeBackend{uids: []imapv2.UID{4}}
+	ic := &ImapClient{Backend: fb}
+	msgs, err := ic.CollectMessages(true, "to", "books@example.com", &config.ImapSearch{
+		From: []string{"friend@example.com"},
+		Not:  []config.ImapSearch{{Header: map[string]string{"List-Id": ""}}},
+	})
+	if err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+	if len(msgs) != 1 {
+		t.Fatalf("want 1 message, got %d", len(msgs))
+	}
+
+	want := &imapv2.SearchCriteria{
+		NotFlag: []imapv2.Flag{"\\Seen", "\\Deleted"},
+		Header: []imapv2.SearchCriteriaHeaderField{
+			{Key: "TO", Value: "books@example.com"},
+			{Key: "FROM", Value: "friend@example.com"},
+		},
+		Not: []imapv2.SearchCriteria{{Header: []imapv2.SearchCriteriaHeaderField{{Key: "List-Id", Value: ""}}}},
+	}
+	if !reflect.DeepEqual(fb.criteria, want) {
+		t.Fatalf("got %+v\nwant %+v", fb.criteria, want)
+	}
+
+	if _, err := ic.CollectMessages(true, "", "", &config.ImapSearch{Since: "soon"}); err == nil {
+		t.Fatalf("expected invalid search error")
+	}
+}
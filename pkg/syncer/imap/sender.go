@@ -0,0 +1,255 @@
+package imap
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/textproto"
+	"strings"
+
+	"github.com/emersion/go-imap/v2"
+	"github.com/emersion/go-imap/v2/imapclient"
+)
+
+// SenderPolicy decides whether the attachments of a message may be
+// downloaded. Messages that fail the policy are skipped and, when
+// QuarantineMailbox is set, moved there.
+type SenderPolicy struct {
+	// AllowedSenders holds addresses (reader@example.com) and domains
+	// (example.com or @example.com) the From address must match.
+	AllowedSenders []string
+	// RequireAuthentication lists the methods (dkim, spf, dmarc) that must
+	// pass in the Authentication-Results header, aligned with the From domain.
+	RequireAuthentication []string
+	// TrustedAuthservID selects the Authentication-Results headers added by
+	// the own mail server. When empty only the topmost header is used.
+	TrustedAuthservID string
+	QuarantineMailbox string
+}
+
+// authResultsSection fetches the Authentication-Results headers without
+// marking the message as read.
+var authResultsSection = &imap.FetchItemBodySection{
+	Specifier:    imap.PartSpecifierHeader,
+	HeaderFields: []string{"Authentication-Results"},
+	Peek:         true,
+}
+
+// validateAuthMethods reports unknown authentication methods.
+func validateAuthMethods(methods []string) error {
+	for _, m := range methods {
+		switch strings.ToLower(m) {
+		case "dkim", "spf", "dmarc":
+		default:
+			return fmt.Errorf("unsupported authentication method: %s", m)
+		}
+	}
+	return nil
+}
+
+// enabled reports whether messages need to be checked at all.
+func (p *SenderPolicy) enabled() bool {
+	return p != nil && (len(p.AllowedSenders) > 0 || len(p.RequireAuthentication) > 0)
+}
+
+// check returns why a message is rejected, or an empty string when its
+// attachments may be downloaded.
+func (p *SenderPolicy) check(msg *imapclient.FetchMessageBuffer) string {
+	if !p.enabled() {
+		return ""
+	}
+	if msg.Envelope == nil || len(msg.Envelope.From) != 1 {
+		return "message does not have exactly one From address"
+	}
+	from := msg.Envelope.From[0]
+	address := strings.ToLower(from.Mailbox + "@" + from.Host)
+	domain := strings.ToLower(from.Host)
+
+	if len(p.AllowedSenders) > 0 && !p.allowed(address, domain) {
+		return "sender " + address + " is not allowed"
+	}
+
+	if len(p.RequireAuthentication) > 0 {
+		results := p.authResults(msg.FindBodySection(authResultsSection))
+		for _, method := range p.RequireAuthentication {
+			method = strings.ToLower(method)
+			if !authPassed(results, method, domain) {
+				return "no aligned " + method + "=pass in Authentication-Results"
+			}
+		}
+	}
+	return ""
+}
+
+// allowed matches the sender against the allowlist.
+func (p *SenderPolicy) allowed(address string, domain string) bool {
+	for _, entry := range p.AllowedSenders {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if strings.Contains(entry, "@") && !strings.HasPrefix(entry, "@") {
+			if entry == address {
+				return true
+			}
+		} else if strings.TrimPrefix(entry, "@") == domain {
+			return true
+		}
+	}
+	return false
+}
+
+// authResults returns the method results of the trusted Authentication-Results
+// headers in a raw header section.
+func (p *SenderPolicy) authResults(header []byte) []authResult {
+	if len(header) == 0 {
+		return nil
+	}
+	r := textproto.NewReader(bufio.NewReader(bytes.NewReader(header)))
+	fields, err := r.ReadMIMEHeader()
+	if err != nil && len(fields) == 0 {
+		return nil
+	}
+
+	var results []authResult
+	for _, value := range fields.Values("Authentication-Results") {
+		authservID, methodResults := parseAuthResults(value)
+		if p.TrustedAuthservID == "" {
+			// Headers are prepended on delivery, so the topmost one was added
+			// by the receiving server; the others may come from the sender.
+			return methodResults
+		}
+		if strings.EqualFold(authservID, p.TrustedAuthservID) {
+			results = append(results, methodResults...)
+		}
+	}
+	return results
+}
+
+// authResult is a single method result of an Authentication-Results header
+// (RFC 8601), such as dkim=pass header.d=example.com.
+type authResult struct {
+	method string
+	result string
+	props  map[string]string
+}
+
+// parseAuthResults parses an Authentication-Results header value into its
+// authserv-id and method results. Comments are ignored.
+func parseAuthResults(value string) (string, []authResult) {
+	statements := splitOutsideQuotes(stripComments(value), ';')
+	if len(statements) == 0 {
+		return "", nil
+	}
+	// The authserv-id may be followed by a version number.
+	authservID := ""
+	if fields := strings.Fields(statements[0]); len(fields) > 0 {
+		authservID = fields[0]
+	}
+
+	var results []authResult
+	for _, statement := range statements[1:] {
+		fields := strings.Fields(statement)
+		if len(fields) == 0 {
+			continue
+		}
+		method, result, ok := strings.Cut(fields[0], "=")
+		if !ok {
+			continue
+		}
+		method, _, _ = strings.Cut(method, "/")
+		res := authResult{method: strings.ToLower(method), result: strings.ToLower(result), props: map[string]string{}}
+		for _, f := range fields[1:] {
+			if k, v, ok := strings.Cut(f, "="); ok {
+				res.props[strings.ToLower(k)] = strings.Trim(v, `"`)
+			}
+		}
+		results = append(results, res)
+	}
+	return authservID, results
+}
+
+// authPassed reports whether method passed for an identity aligned with the
+// From domain.
+func authPassed(results []authResult, method string, fromDomain string) bool {
+	for _, r := range results {
+		if r.method != method || r.result != "pass" {
+			continue
+		}
+		var identity string
+		switch method {
+		case "dkim":
+			identity = r.props["header.d"]
+			if identity == "" {
+				identity = r.props["header.i"]
+			}
+		case "spf":
+			identity = r.props["smtp.mailfrom"]
+		case "dmarc":
+			identity = r.props["header.from"]
+			if identity == "" {
+				// DMARC evaluates the From domain itself.
+				return true
+			}
+		}
+		if aligned(identity, fromDomain) {
+			return true
+		}
+	}
+	return false
+}
+
+// aligned reports whether an identity (a domain or address) is the From
+// domain or one of its parent domains (relaxed alignment).
+func aligned(identity string, fromDomain string) bool {
+	if i := strings.LastIndex(identity, "@"); i >= 0 {
+		identity = identity[i+1:]
+	}
+	identity = strings.ToLower(strings.TrimSuffix(identity, "."))
+	return identity != "" && (identity == fromDomain || strings.HasSuffix(fromDomain, "."+identity))
+}
+
+// stripComments removes parenthesized comments outside quoted strings.
+func stripComments(s string) string {
+	var b strings.Builder
+	depth, quoted := 0, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && (quoted || depth > 0):
+			if depth == 0 {
+				b.WriteByte(c)
+				b.WriteByte(s[i+1])
+			}
+			i++
+			continue
+		case c == '"' && depth == 0:
+			quoted = !quoted
+		case c == '(' && !quoted:
+			depth++
+			continue
+		case c == ')' && !quoted && depth > 0:
+			depth--
+			continue
+		}
+		if depth == 0 {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// splitOutsideQuotes splits s on sep, ignoring separators in quoted strings.
+func splitOutsideQuotes(s string, sep byte) []string {
+	var parts []string
+	quoted, start := false, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case sep:
+			if !quoted {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
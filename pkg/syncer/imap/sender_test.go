@@ -0,0 +1,235 @@
+package imap
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+	imapv2 "github.com/emersion/go-imap/v2"
+	"github.com/emersion/go-imap/v2/imapclient"
+)
+
+// withAuthResults adds raw Authentication-Results headers to a message, as
+// returned for authResultsSection.
+func withAuthResults(msg *imapclient.FetchMessageBuffer, headers ...string) *imapclient.FetchMessageBuffer {
+	var raw string
+	for _, h := range headers {
+		raw += "Authentication-Results: " + h + "\r\n"
+	}
+	msg.BodySection = []imapclient.FetchBodySectionBuffer{{Section: authResultsSection, Bytes: []byte(raw + "\r\n")}}
+	return msg
+}
+
+// TestParseAuthResults ensures authserv-id, methods, results and properties
+// are parsed while comments and versions are ignored.
+func TestParseAuthResults(t *testing.T) {
+	id, results := parseAuthResults(`mx.example.net 1; dkim=pass (2048-bit key; secure) header.d=example.com header.s="sel;1";` +
+		` spf=softfail (domain of (nested) x) smtp.mailfrom=bounce@lists.example.org; DMARC/1=Pass header.from=example.com`)
+	if id != "mx.example.net" {
+		t.Fatalf("authserv-id %q", id)
+	}
+	if len(results) != 3 {
+		t.Fatalf("want 3 results, got %+v", results)
+	}
+	if r := results[0]; r.method != "dkim" || r.result != "pass" || r.props["header.d"] != "example.com" || r.props["header.s"] != "sel;1" {
+		t.Fatalf("unexpected dkim %+v", r)
+	}
+	if r := results[1]; r.method != "spf" || r.result != "softfail" || r.props["smtp.mailfrom"] != "bounce@lists.example.org" {
+		t.Fatalf("unexpected spf %+v", r)
+	}
+	if r := results[2]; r.method != "dmarc" || r.result != "pass" || r.props["header.from"] != "example.com" {
+		t.Fatalf("unexpected dmarc %+v", r)
+	}
+
+	if id, results := parseAuthResults("mx.example.net; none"); id != "mx.example.net" || len(results) != 0 {
+		t.Fatalf("unexpected none: %q %+v", id, results)
+	}
+}
+
+// TestSenderPolicy_AllowedSenders ensures addresses and domains are matched
+// case-insensitively and other senders are rejected.
+func TestSenderPolicy_AllowedSenders(t *testing.T) {
+	p := &SenderPolicy{AllowedSenders: []string{"Friend@Example.com", "@books.example", "family.example"}}
+	cases := map[string]bool{
+		"friend@example.com":      true,
+		"other@example.com":       false,
+		"shop@books.example":      true,
+		"mum@family.example":      true,
+		"x@sub.family.example":    false,
+		"friend@example.com.evil": false,
+	}
+	for sender, want := range cases {
+		mailbox, host, _ := strings.Cut(sender, "@")
+		reason := p.check(buildMeta("s", "", mailbox, host, "a.epub", 1))
+		if (reason == "") != want {
+			t.Fatalf("%s: allowed=%v, reason %q", sender, want, reason)
+		}
+	}
+
+	msg := buildMeta("s", "", "friend", "example.com", "a.epub", 1)
+	msg.Envelope.From = append(msg.Envelope.From, imapv2.Address{Mailbox: "evil", Host: "example.net"})
+	if reason := p.check(msg); !strings.Contains(reason, "exactly one From") {
+		t.Fatalf("unexpected reason for two From addresses: %q", reason)
+	}
+
+	if reason := (*SenderPolicy)(nil).check(msg); reason != "" {
+		t.Fatalf("nil policy must accept, got %q", reason)
+	}
+}
+
+// TestSenderPolicy_Authentication ensures the required methods must pass for
+// an identity aligned with the From domain, in a trusted header.
+func TestSenderPolicy_Authentication(t *testing.T) {
+	meta := func(headers ...string) *imapclient.FetchMessageBuffer {
+		return withAuthResults(buildMeta("s", "", "friend", "mail.example.com", "a.epub", 1), headers...)
+	}
+	cases := []struct {
+		name    string
+		policy  SenderPolicy
+		msg     *imapclient.FetchMessageBuffer
+		allowed bool
+	}{
+		{"dkim aligned parent domain", SenderPolicy{RequireAuthentication: []string{"dkim"}},
+			meta("mx.local; dkim=pass header.d=example.com"), true},
+		{"dkim other domain", SenderPolicy{RequireAuthentication: []string{"dkim"}},
+			meta("mx.local; dkim=pass header.d=lists.example.org"), false},
+		{"dkim header.i", SenderPolicy{RequireAuthentication: []string{"DKIM"}},
+			meta("mx.local; dkim=pass header.i=@mail.example.com"), true},
+		{"dkim fail", SenderPolicy{RequireAuthentication: []string{"dkim"}},
+			meta("mx.local; dkim=fail header.d=example.com"), false},
+		{"spf aligned", SenderPolicy{RequireAuthentication: []string{"spf"}},
+			meta("mx.local; spf=pass smtp.mailfrom=bounce@mail.example.com"), true},
+		{"spf and dmarc", SenderPolicy{RequireAuthentication: []string{"spf", "dmarc"}},
+			meta("mx.local; spf=pass smtp.mailfrom=example.com; dmarc=fail header.from=mail.example.com"), false},
+		{"dmarc", SenderPolicy{RequireAuthentication: []string{"dmarc"}},
+			meta("mx.local; dmarc=pass header.from=mail.example.com"), true},
+		{"no header", SenderPolicy{RequireAuthentication: []string{"dmarc"}},
+			buildMeta("s", "", "friend", "mail.example.com", "a.epub", 1), false},
+		{"only topmost header is trusted", SenderPolicy{RequireAuthentication: []string{"dkim"}},
+			meta("mx.local; dkim=none", "mx.local; dkim=pass header.d=example.com"), false},
+		{"trusted authserv-id", SenderPolicy{RequireAuthentication: []string{"dkim"}, TrustedAuthservID: "MX.local"},
+			meta("spoofed.example; dkim=none", "mx.local; dkim=pass header.d=example.com"), true},
+		{"untrusted authserv-id", SenderPolicy{RequireAuthentication: []string{"dkim"}, TrustedAuthservID: "mx.local"},
+			meta("spoofed.example; dkim=pass header.d=example.com"), false},
+		{"allowlist and dkim", SenderPolicy{AllowedSenders: []string{"friend@mail.example.com"}, RequireAuthentication: []string{"dkim"}},
+			meta("mx.local; dkim=pass header.d=mail.example.com"), true},
+	}
+	for _, tc := range cases {
+		reason := tc.policy.check(tc.msg)
+		if (reason == "") != tc.allowed {
+			t.Fatalf("%s: allowed=%v, reason %q", tc.name, tc.allowed, reason)
+		}
+	}
+}
+
+// TestDownloadAttachments_RejectedSender ensures rejected messages are not
+// downloaded and are moved to the quarantine mailbox when configured.
+func TestDownloadAttachments_RejectedSender(t *testing.T) {
+	uid := imapv2.UID(3)
+	encoded := base64.StdEncoding.EncodeToString([]byte("DATA"))
+	newBackend := func() *recordingBackend {
+		return &recordingBackend{
+			meta:   map[imapv2.UID]*imapclient.FetchMessageBuffer{uid: buildMeta("S", "Eve", "eve", "evil.example", "book.epub", 4)},
+			bodies: map[imapv2.UID]*imapclient.FetchMessageBuffer{uid: buildBody(encoded)},
+		}
+	}
+
+	for _, quarantine := range []string{"", "Quarantine"} {
+		backend := newBackend()
+		ic := &ImapClient{Backend: backend, Policy: &SenderPolicy{AllowedSenders: []string{"example.com"}, QuarantineMailbox: quarantine}}
+		dir := t.TempDir()
+		if err := NewImapMessage(uid, ic).DownloadAttachments(dir, []string{".epub"}, false, AfterDownload{Action: "delete"}); err != nil {
+			t.Fatalf("DownloadAttachments: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "book.epub")); !os.IsNotExist(err) {
+			t.Fatalf("rejected attachment was written")
+		}
+		if len(backend.stored) != 0 {
+			t.Fatalf("rejected message must not be deleted")
+		}
+		if backend.moved[uid] != quarantine {
+			t.Fatalf("want move to %q, got %v", quarantine, backend.moved)
+		}
+	}
+
+	// Dry-run only logs the move
+	util.DryRun = true
+	t.Cleanup(func() { util.DryRun = false })
+	backend := newBackend()
+	ic := &ImapClient{Backend: backend, Policy: &SenderPolicy{AllowedSenders: []string{"example.com"}, QuarantineMailbox: "Quarantine"}}
+	if err := NewImapMessage(uid, ic).DownloadAttachments(t.TempDir(), []string{".epub"}, false, AfterDownload{}); err != nil {
+		t.Fatalf("DownloadAttachments: %v", err)
+	}
+	if len(backend.moved) != 0 {
+		t.Fatalf("dry-run must not move messages")
+	}
+}
+
+// TestDownloadAttachments_AuthenticatedSender ensures messages passing the
+// policy are downloaded as before.
+func TestDownloadAttachments_AuthenticatedSender(t *testing.T) {
+	uid := imapv2.UID(4)
+	meta := withAuthResults(buildMeta("S", "Bob", "bob", "example.com", "book.epub", 4), "mx.local; dmarc=pass header.from=example.com")
+	backend := &recordingBackend{
+		meta:   map[imapv2.UID]*imapclient.FetchMessageBuffer{uid: meta},
+		bodies: map[imapv2.UID]*imapclient.FetchMessageBuffer{uid: buildBody(base64.StdEncoding.EncodeToString([]byte("DATA")))},
+	}
+	ic := &ImapClient{Backend: backend, Policy: &SenderPolicy{AllowedSenders: []string{"bob@example.com"}, RequireAuthentication: []string{"dmarc"}, QuarantineMailbox: "Quarantine"}}
+	dir := t.TempDir()
+	if err := NewImapMessage(uid, ic).DownloadAttachments(dir, []string{".epub"}, false, AfterDownload{}); err != nil {
+		t.Fatalf("DownloadAttachments: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "book.epub")); err != nil {
+		t.Fatalf("expected attachment: %v", err)
+	}
+	if len(backend.moved) != 0 {
+		t.Fatalf("accepted message must not be moved")
+	}
+}
+
+// TestImapMessage_MoveToMailbox ensures moves require a connection and
+// report backend errors.
+func TestImapMessage_MoveToMailbox(t *testing.T) {
+	if err := NewImapMessage(1, &ImapClient{}).MoveToMailbox("Junk"); err == nil {
+		t.Fatalf("expected not connected error")
+	}
+	fb := &fakeBackend{moveErr: errors.New("no such mailbox")}
+	ic := &ImapClient{Backend: fb, Policy: &SenderPolicy{AllowedSenders: []string{"example.org"}, QuarantineMailbox: "Junk"}}
+	fb.fetch = map[imapv2.UID]*imapclient.FetchMessageBuffer{1: buildMeta("s", "", "a", "example.com", "a.epub", 1)}
+	err := NewImapMessage(1, ic).DownloadAttachments(t.TempDir(), []string{".epub"}, false, AfterDownload{})
+	if err == nil || !strings.Contains(err.Error(), "quarantine mailbox Junk") {
+		t.Fatalf("unexpected err: %v", err)
+	}
+}
+
+// TestImapSyncer_Run_InvalidAuthMethod ensures unknown authentication methods
+// are rejected before connecting.
+func TestImapSyncer_Run_InvalidAuthMethod(t *testing.T) {
+	origNew := newImapClient
+	t.Cleanup(func() { newImapClient = origNew })
+	newImapClient = func(cfg *config.ImapConfig, tokenCache string) imapSyncClient {
+		t.Fatalf("must not connect")
+		return nil
+	}
+	cfg := &config.ImapConfig{Host: "h", Mailbox: "INBOX", RequireAuthentication: []string{"dkim", "arc"}}
+	err := NewImapSyncer(cfg).Run(t.TempDir(), []string{".epub"}, false)
+	if err == nil || !strings.Contains(err.Error(), "unsupported authentication method: arc") {
+		t.Fatalf("unexpected err: %v", err)
+	}
+}
+
+// TestNewImapClient_SenderPolicy ensures the policy is only set when configured.
+func TestNewImapClient_SenderPolicy(t *testing.T) {
+	c := newImapClient(&config.ImapConfig{AllowedSenders: []string{"a@b"}, TrustedAuthservID: "mx", QuarantineMailbox: "Q"}, "").(*ImapClient)
+	if c.Policy == nil || c.Policy.AllowedSenders[0] != "a@b" || c.Policy.TrustedAuthservID != "mx" || c.Policy.QuarantineMailbox != "Q" {
+		t.Fatalf("unexpected policy %+v", c.Policy)
+	}
+	if c := newImapClient(&config.ImapConfig{QuarantineMailbox: "Q"}, "").(*ImapClient); c.Policy != nil {
+		t.Fatalf("expected no policy without allowlist or authentication")
+	}
+}
@@ -3,9 +3,11 @@ package imap
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/bjw-s-labs/bookshift/pkg/util"
 )
 
 type ImapSyncer struct {
@@ -13,9 +15,31 @@ type ImapSyncer struct {
 }
 
 func NewImapSyncer(shareConfig *config.ImapConfig) *ImapSyncer {
+	// Default to implicit TLS
+	if shareConfig.Security == "" {
+		shareConfig.Security = "tls"
+	}
+
 	// Set default port if nothing is specified
 	if !(shareConfig.Port > 0) {
-		shareConfig.Port = 143
+		if shareConfig.Security == "tls" {
+			shareConfig.Port = 993
+		} else {
+			shareConfig.Port = 143
+		}
+	}
+
+	// remove_emails_after_download predates after_download; without it
+	// processed messages are marked as read, so they are not fetched again
+	if shareConfig.AfterDownload == "" {
+		if shareConfig.RemoveEmailsAfterDownload {
+			shareConfig.AfterDownload = "delete"
+		} else {
+			shareConfig.AfterDownload = "seen"
+		}
+	}
+	if shareConfig.AfterDownloadKeyword == "" {
+		shareConfig.AfterDownloadKeyword = "$BookShiftDone"
 	}
 
 	return &ImapSyncer{
@@ -39,18 +63,42 @@ func (s *ImapSyncer) RunContext(ctx context.Context, targetFolder string, validE
 	default:
 	}
 
+	if err := validateAuthMethods(s.config.RequireAuthentication); err != nil {
+		return err
+	}
+	after := AfterDownload{Action: s.config.AfterDownload, Mailbox: s.config.AfterDownloadMailbox, Keyword: s.config.AfterDownloadKeyword}
+	if err := validateAfterDownload(after); err != nil {
+		return err
+	}
+
+	// OAuth2 access tokens are cached next to the other source state
+	tokenCache := s.config.OAuth2TokenCache
+	if tokenCache == "" {
+		tokenCache = util.DefaultStatePath(targetFolder, "imap-oauth2", s.config.Host+"|"+s.config.Username+"|"+s.config.OAuth2ClientID)
+	}
+
 	// Connect to the IMAP server
-	imapConnection := newImapClient(s.config)
+	imapConnection := newImapClient(s.config, tokenCache)
 	if err := imapConnect(imapConnection, s.config.Mailbox); err != nil {
 		return fmt.Errorf("could not connect to IMAP server %s: %w", s.config.Host, err)
 	}
 	defer imapDisconnect(imapConnection)
 
+	// Skip messages flagged as processed by an earlier run
+	search := s.config.Search
+	if after.Action == "flag" {
+		search = &config.ImapSearch{Not: []config.ImapSearch{{Keywords: []string{after.Keyword}}}}
+		if s.config.Search != nil {
+			search.And = []config.ImapSearch{*s.config.Search}
+		}
+	}
+
 	// Collect messages from the IMAP server
 	allMessages, err := imapCollect(imapConnection,
 		!s.config.ProcessReadEmails,
 		s.config.FilterField,
 		s.config.FilterValue,
+		search,
 	)
 	if err != nil {
 		return err
@@ -67,7 +115,7 @@ func (s *ImapSyncer) RunContext(ctx context.Context, targetFolder string, validE
 			targetFolder,
 			validExtensions,
 			overwriteExistingFiles,
-			s.config.RemoveEmailsAfterDownload,
+			after,
 		); err != nil {
 			return err
 		}
@@ -75,3 +123,21 @@ func (s *ImapSyncer) RunContext(ctx context.Context, targetFolder string, validE
 
 	return nil
 }
+
+// validateAfterDownload checks the after download action before connecting.
+func validateAfterDownload(after AfterDownload) error {
+	switch after.Action {
+	case "", "none", "seen", "delete":
+	case "move":
+		if after.Mailbox == "" {
+			return fmt.Errorf("after_download: move requires after_download_mailbox")
+		}
+	case "flag":
+		if after.Keyword == "" || strings.ContainsAny(after.Keyword, " (){%*\"]\\") {
+			return fmt.Errorf("invalid after_download_keyword: %q", after.Keyword)
+		}
+	default:
+		return fmt.Errorf("unsupported after_download action: %s", after.Action)
+	}
+	return nil
+}
@@ -13,19 +13,48 @@ import "github.com/bjw-s-labs/bookshift/pkg/config"
 type imapSyncClient interface {
 	Connect(mailbox string) error
 	Disconnect() error
-	CollectMessages(unreadOnly bool, filterField, filterValue string) ([]*ImapMessage, error)
+	CollectMessages(unreadOnly bool, filterField, filterValue string, search *config.ImapSearch) ([]*ImapMessage, error)
 }
 
 var (
-	newImapClient = func(cfg *config.ImapConfig) imapSyncClient {
-		return &ImapClient{Host: cfg.Host, Port: cfg.Port, Username: cfg.Username, Password: cfg.Password}
+	newImapClient = func(cfg *config.ImapConfig, tokenCache string) imapSyncClient {
+		c := &ImapClient{
+			Host:               cfg.Host,
+			Port:               cfg.Port,
+			Username:           cfg.Username,
+			Password:           cfg.Password,
+			Security:           cfg.Security,
+			CAFile:             cfg.CAFile,
+			ServerName:         cfg.ServerName,
+			InsecureSkipVerify: cfg.InsecureSkipVerify,
+			AuthMethod:         cfg.AuthMethod,
+		}
+		if len(cfg.AllowedSenders) > 0 || len(cfg.RequireAuthentication) > 0 {
+			c.Policy = &SenderPolicy{
+				AllowedSenders:        cfg.AllowedSenders,
+				RequireAuthentication: cfg.RequireAuthentication,
+				TrustedAuthservID:     cfg.TrustedAuthservID,
+				QuarantineMailbox:     cfg.QuarantineMailbox,
+			}
+		}
+		if cfg.AuthMethod == "xoauth2" || cfg.AuthMethod == "oauthbearer" {
+			c.OAuth2 = &OAuth2Source{
+				TokenURL:     cfg.OAuth2TokenURL,
+				ClientID:     cfg.OAuth2ClientID,
+				ClientSecret: cfg.OAuth2ClientSecret,
+				RefreshToken: cfg.OAuth2RefreshToken,
+				Scopes:       cfg.OAuth2Scopes,
+				CacheFile:    tokenCache,
+			}
+		}
+		return c
 	}
 	imapConnect    = func(c imapSyncClient, mailbox string) error { return c.Connect(mailbox) }
 	imapDisconnect = func(c imapSyncClient) { _ = c.Disconnect() }
-	imapCollect    = func(c imapSyncClient, unreadOnly bool, field, value string) ([]*ImapMessage, error) {
-		return c.CollectMessages(unreadOnly, field, value)
+	imapCollect    = func(c imapSyncClient, unreadOnly bool, field, value string, search *config.ImapSearch) ([]*ImapMessage, error) {
+		return c.CollectMessages(unreadOnly, field, value, search)
 	}
-	imapDownload = func(m *ImapMessage, dst string, valid []string, overwrite bool, remove bool) error {
-		return m.DownloadAttachments(dst, valid, overwrite, remove)
+	imapDownload = func(m *ImapMessage, dst string, valid []string, overwrite bool, after AfterDownload) error {
+		return m.DownloadAttachments(dst, valid, overwrite, after)
 	}
 )
@@ -2,13 +2,20 @@ package imap
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+	imapv2 "github.com/emersion/go-imap/v2"
+	"github.com/emersion/go-imap/v2/imapclient"
+	"github.com/go-playground/sensitive"
 )
 
 type fakeSyncClient struct {
@@ -19,19 +26,33 @@ type fakeSyncClient struct {
 
 func (f *fakeSyncClient) Connect(mailbox string) error { return f.connectErr }
 func (f *fakeSyncClient) Disconnect() error            { return nil }
-func (f *fakeSyncClient) CollectMessages(unreadOnly bool, filterField, filterValue string) ([]*ImapMessage, error) {
+func (f *fakeSyncClient) CollectMessages(unreadOnly bool, filterField, filterValue string, search *config.ImapSearch) ([]*ImapMessage, error) {
 	if f.collectErr != nil {
 		return nil, f.collectErr
 	}
 	return f.msgs, nil
 }
 
-// TestNewImapSyncer_DefaultPort ensures default IMAP port is set when unspecified.
+// TestNewImapSyncer_DefaultPort ensures the default IMAP port matches the security mode.
 func TestNewImapSyncer_DefaultPort(t *testing.T) {
 	cfg := &config.ImapConfig{}
 	NewImapSyncer(cfg)
-	if cfg.Port != 143 {
-		t.Fatalf("want 143, got %d", cfg.Port)
+	if cfg.Security != "tls" || cfg.Port != 993 {
+		t.Fatalf("want tls on 993, got %s on %d", cfg.Security, cfg.Port)
+	}
+
+	for _, security := range []string{"starttls", "none"} {
+		cfg := &config.ImapConfig{Security: security}
+		NewImapSyncer(cfg)
+		if cfg.Port != 143 {
+			t.Fatalf("%s: want 143, got %d", security, cfg.Port)
+		}
+	}
+
+	cfg = &config.ImapConfig{Port: 1143}
+	NewImapSyncer(cfg)
+	if cfg.Port != 1143 {
+		t.Fatalf("want configured port 1143, got %d", cfg.Port)
 	}
 }
 
@@ -41,7 +62,9 @@ func TestImapSyncer_Run_ConnectError(t *testing.T) {
 	s := NewImapSyncer(cfg)
 	origNew, origConn := newImapClient, imapConnect
 	t.Cleanup(func() { newImapClient, imapConnect = origNew, origConn })
-	newImapClient = func(cfg *config.ImapConfig) imapSyncClient { return &fakeSyncClient{connectErr: errors.New("x")} }
+	newImapClient = func(cfg *config.ImapConfig, tokenCache string) imapSyncClient {
+		return &fakeSyncClient{connectErr: errors.New("x")}
+	}
 	imapConnect = func(c imapSyncClient, mailbox string) error { return c.Connect(mailbox) }
 	if err := s.Run(t.TempDir(), []string{".epub"}, true); err == nil {
 		t.Fatalf("expected connect error")
@@ -54,7 +77,9 @@ func TestImapSyncer_Run_CollectError(t *testing.T) {
 	s := NewImapSyncer(cfg)
 	origNew := newImapClient
 	t.Cleanup(func() { newImapClient = origNew })
-	newImapClient = func(cfg *config.ImapConfig) imapSyncClient { return &fakeSyncClient{collectErr: errors.New("y")} }
+	newImapClient = func(cfg *config.ImapConfig, tokenCache string) imapSyncClient {
+		return &fakeSyncClient{collectErr: errors.New("y")}
+	}
 	if err := s.Run(t.TempDir(), []string{".epub"}, true); err == nil {
 		t.Fatalf("expected collect error")
 	}
@@ -67,8 +92,10 @@ func TestImapSyncer_Run_DownloadError(t *testing.T) {
 	origNew, origDL := newImapClient, imapDownload
 	t.Cleanup(func() { newImapClient, imapDownload = origNew, origDL })
 	m := &ImapMessage{}
-	newImapClient = func(cfg *config.ImapConfig) imapSyncClient { return &fakeSyncClient{msgs: []*ImapMessage{m}} }
-	imapDownload = func(m *ImapMessage, dst string, valid []string, overwrite bool, remove bool) error {
+	newImapClient = func(cfg *config.ImapConfig, tokenCache string) imapSyncClient {
+		return &fakeSyncClient{msgs: []*ImapMessage{m}}
+	}
+	imapDownload = func(m *ImapMessage, dst string, valid []string, overwrite bool, after AfterDownload) error {
 		return errors.New("z")
 	}
 	if err := s.Run(t.TempDir(), []string{".epub"}, true); err == nil {
@@ -92,15 +119,15 @@ func TestImapSyncer_Run_HappyPath(t *testing.T) {
 	msg := &ImapMessage{}
 
 	// Provide a fake client returning our msg
-	newImapClient = func(cfg *config.ImapConfig) imapSyncClient {
+	newImapClient = func(cfg *config.ImapConfig, tokenCache string) imapSyncClient {
 		return &fakeSyncClient{msgs: []*ImapMessage{msg}}
 	}
 	imapConnect = func(c imapSyncClient, mailbox string) error { return nil }
 	imapDisconnect = func(c imapSyncClient) {}
-	imapCollect = func(c imapSyncClient, unreadOnly bool, field, value string) ([]*ImapMessage, error) {
+	imapCollect = func(c imapSyncClient, unreadOnly bool, field, value string, search *config.ImapSearch) ([]*ImapMessage, error) {
 		return []*ImapMessage{msg}, nil
 	}
-	imapDownload = func(m *ImapMessage, dst string, valid []string, overwrite bool, remove bool) error {
+	imapDownload = func(m *ImapMessage, dst string, valid []string, overwrite bool, after AfterDownload) error {
 		// Simulate writing a file
 		return os.WriteFile(filepath.Join(dst, "file.epub"), []byte("X"), 0o644)
 	}
@@ -122,12 +149,14 @@ func TestImapSyncer_RunContext_Cancel(t *testing.T) {
 	msgs := []*ImapMessage{{}, {}, {}, {}, {}}
 	origNew, origCollect := newImapClient, imapCollect
 	t.Cleanup(func() { newImapClient, imapCollect = origNew, origCollect })
-	newImapClient = func(cfg *config.ImapConfig) imapSyncClient { return &fakeSyncClient{msgs: msgs} }
-	imapCollect = func(c imapSyncClient, unreadOnly bool, field, value string) ([]*ImapMessage, error) { return msgs, nil }
+	newImapClient = func(cfg *config.ImapConfig, tokenCache string) imapSyncClient { return &fakeSyncClient{msgs: msgs} }
+	imapCollect = func(c imapSyncClient, unreadOnly bool, field, value string, search *config.ImapSearch) ([]*ImapMessage, error) {
+		return msgs, nil
+	}
 	// Download sleeps a bit to allow cancel to fire between items
 	origDL := imapDownload
 	t.Cleanup(func() { imapDownload = origDL })
-	imapDownload = func(m *ImapMessage, dst string, valid []string, overwrite bool, remove bool) error {
+	imapDownload = func(m *ImapMessage, dst string, valid []string, overwrite bool, after AfterDownload) error {
 		time.Sleep(10 * time.Millisecond)
 		return nil
 	}
@@ -136,3 +165,156 @@ func TestImapSyncer_RunContext_Cancel(t *testing.T) {
 	go func() { time.Sleep(15 * time.Millisecond); cancel() }()
 	_ = s.RunContext(ctx, t.TempDir(), []string{".epub"}, false)
 }
+
+// TestImapSyncer_Run_TokenCachePath ensures the OAuth2 token cache defaults to
+// the state folder below the target folder and can be overridden.
+func TestImapSyncer_Run_TokenCachePath(t *testing.T) {
+	origNew := newImapClient
+	t.Cleanup(func() { newImapClient = origNew })
+	var got string
+	newImapClient = func(cfg *config.ImapConfig, tokenCache string) imapSyncClient {
+		got = tokenCache
+		return &fakeSyncClient{connectErr: errors.New("x")}
+	}
+
+	dst := t.TempDir()
+	cfg := &config.ImapConfig{Host: "h", Username: "u", Mailbox: "INBOX", AuthMethod: "xoauth2", OAuth2ClientID: "c"}
+	_ = NewImapSyncer(cfg).Run(dst, []string{".epub"}, true)
+	if want := util.DefaultStatePath(dst, "imap-oauth2", "h|u|c"); got != want {
+		t.Fatalf("want %s, got %s", want, got)
+	}
+
+	cfg.OAuth2TokenCache = filepath.Join(dst, "token.json")
+	_ = NewImapSyncer(cfg).Run(dst, []string{".epub"}, true)
+	if got != cfg.OAuth2TokenCache {
+		t.Fatalf("want %s, got %s", cfg.OAuth2TokenCache, got)
+	}
+}
+
+// TestNewImapClient_OAuth2 ensures the OAuth2 settings reach the client.
+func TestNewImapClient_OAuth2(t *testing.T) {
+	rt := sensitive.String("r")
+	cfg := &config.ImapConfig{Host: "h", AuthMethod: "oauthbearer", OAuth2TokenURL: "http://t", OAuth2ClientID: "c", OAuth2RefreshToken: &rt, OAuth2Scopes: []string{"s"}}
+	c := newImapClient(cfg, "/tmp/cache.json").(*ImapClient)
+	if c.AuthMethod != "oauthbearer" || c.OAuth2 == nil || c.OAuth2.TokenURL != "http://t" || c.OAuth2.CacheFile != "/tmp/cache.json" || c.OAuth2.RefreshToken != &rt {
+		t.Fatalf("unexpected client %+v", c)
+	}
+
+	c = newImapClient(&config.ImapConfig{Host: "h"}, "/tmp/cache.json").(*ImapClient)
+	if c.OAuth2 != nil {
+		t.Fatalf("expected no OAuth2 source for login")
+	}
+}
+
+// TestNewImapSyncer_AfterDownloadDefaults ensures remove_emails_after_download
+// maps onto after_download and the keyword has a default.
+func TestNewImapSyncer_AfterDownloadDefaults(t *testing.T) {
+	cfg := &config.ImapConfig{}
+	NewImapSyncer(cfg)
+	if cfg.AfterDownload != "seen" || cfg.AfterDownloadKeyword != "$BookShiftDone" {
+		t.Fatalf("unexpected defaults %q %q", cfg.AfterDownload, cfg.AfterDownloadKeyword)
+	}
+
+	cfg = &config.ImapConfig{RemoveEmailsAfterDownload: true}
+	NewImapSyncer(cfg)
+	if cfg.AfterDownload != "delete" {
+		t.Fatalf("want delete, got %q", cfg.AfterDownload)
+	}
+
+	cfg = &config.ImapConfig{RemoveEmailsAfterDownload: true, AfterDownload: "move", AfterDownloadKeyword: "$Done"}
+	NewImapSyncer(cfg)
+	if cfg.AfterDownload != "move" || cfg.AfterDownloadKeyword != "$Done" {
+		t.Fatalf("configured values overwritten: %q %q", cfg.AfterDownload, cfg.AfterDownloadKeyword)
+	}
+}
+
+// TestImapSyncer_Run_DefaultMarksSeen ensures the default configuration marks
+// processed messages as read, so they are not fetched again on the next run.
+func TestImapSyncer_Run_DefaultMarksSeen(t *testing.T) {
+	uid := imapv2.UID(5)
+	backend := &recordingBackend{
+		meta:   map[imapv2.UID]*imapclient.FetchMessageBuffer{uid: buildMeta("S", "Bob", "bob", "example.com", "book.epub", 4)},
+		bodies: map[imapv2.UID]*imapclient.FetchMessageBuffer{uid: buildBody(base64.StdEncoding.EncodeToString([]byte("DATA")))},
+	}
+	origNew, origConn, origDisc := newImapClient, imapConnect, imapDisconnect
+	t.Cleanup(func() { newImapClient, imapConnect, imapDisconnect = origNew, origConn, origDisc })
+	newImapClient = func(cfg *config.ImapConfig, tokenCache string) imapSyncClient { return &ImapClient{Backend: backend} }
+	imapConnect = func(c imapSyncClient, mailbox string) error { return nil }
+	imapDisconnect = func(c imapSyncClient) {}
+
+	dir := t.TempDir()
+	if err := NewImapSyncer(&config.ImapConfig{Host: "h", Mailbox: "INBOX"}).Run(dir, []string{".epub"}, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "book.epub")); err != nil {
+		t.Fatalf("expected file: %v", err)
+	}
+	if len(backend.stored) != 1 || backend.stored[0] != uid || len(backend.flags) != 1 || backend.flags[0] != imapv2.FlagSeen {
+		t.Fatalf("expected \\Seen on uid=%v, got %v %v", uid, backend.stored, backend.flags)
+	}
+	if len(backend.expunged) != 0 || len(backend.moved) != 0 {
+		t.Fatalf("default must not delete or move messages")
+	}
+}
+
+// TestImapSyncer_Run_AfterDownload ensures the action reaches the download and
+// that flagged messages are excluded from the search.
+func TestImapSyncer_Run_AfterDownload(t *testing.T) {
+	origNew, origCollect, origDL := newImapClient, imapCollect, imapDownload
+	t.Cleanup(func() { newImapClient, imapCollect, imapDownload = origNew, origCollect, origDL })
+	newImapClient = func(cfg *config.ImapConfig, tokenCache string) imapSyncClient { return &fakeSyncClient{} }
+	var gotSearch *config.ImapSearch
+	imapCollect = func(c imapSyncClient, unreadOnly bool, field, value string, search *config.ImapSearch) ([]*ImapMessage, error) {
+		gotSearch = search
+		return []*ImapMessage{{}}, nil
+	}
+	var gotAfter AfterDownload
+	imapDownload = func(m *ImapMessage, dst string, valid []string, overwrite bool, after AfterDownload) error {
+		gotAfter = after
+		return nil
+	}
+
+	userSearch := &config.ImapSearch{From: []string{"a@example.com"}}
+	cfg := &config.ImapConfig{Host: "h", Mailbox: "INBOX", AfterDownload: "flag", Search: userSearch}
+	if err := NewImapSyncer(cfg).Run(t.TempDir(), []string{".epub"}, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if gotAfter != (AfterDownload{Action: "flag", Keyword: "$BookShiftDone"}) {
+		t.Fatalf("unexpected after download %+v", gotAfter)
+	}
+	want := &config.ImapSearch{Not: []config.ImapSearch{{Keywords: []string{"$BookShiftDone"}}}, And: []config.ImapSearch{*userSearch}}
+	if !reflect.DeepEqual(gotSearch, want) {
+		t.Fatalf("unexpected search %+v", gotSearch)
+	}
+
+	cfg = &config.ImapConfig{Host: "h", Mailbox: "INBOX", AfterDownload: "move", AfterDownloadMailbox: "Delivered", Search: userSearch}
+	if err := NewImapSyncer(cfg).Run(t.TempDir(), []string{".epub"}, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if gotAfter.Action != "move" || gotAfter.Mailbox != "Delivered" || gotSearch != userSearch {
+		t.Fatalf("unexpected move run %+v %+v", gotAfter, gotSearch)
+	}
+}
+
+// TestImapSyncer_Run_InvalidAfterDownload ensures invalid settings are
+// rejected before connecting.
+func TestImapSyncer_Run_InvalidAfterDownload(t *testing.T) {
+	origNew := newImapClient
+	t.Cleanup(func() { newImapClient = origNew })
+	newImapClient = func(cfg *config.ImapConfig, tokenCache string) imapSyncClient {
+		t.Fatalf("must not connect")
+		return nil
+	}
+	cases := map[string]*config.ImapConfig{
+		"requires after_download_mailbox":   {AfterDownload: "move"},
+		"invalid after_download_keyword":    {AfterDownload: "flag", AfterDownloadKeyword: `\Flagged`},
+		"unsupported after_download action": {AfterDownload: "archive"},
+	}
+	for want, cfg := range cases {
+		cfg.Host, cfg.Mailbox = "h", "INBOX"
+		err := NewImapSyncer(cfg).Run(t.TempDir(), []string{".epub"}, false)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("%s: unexpected err %v", want, err)
+		}
+	}
+}
@@ -1,10 +1,25 @@
 package imap
 
 import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
 	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
 
 	imapv2 "github.com/emersion/go-imap/v2"
 	"github.com/emersion/go-imap/v2/imapclient"
+	"github.com/emersion/go-sasl"
 )
 
 // ---- Minimal wait wrappers used by fake connections ----
@@ -24,9 +39,22 @@ type fakeConn struct {
 	selErr    error
 	logoutErr error
 	closed    bool
+
+	// authErrs are returned by successive Authenticate calls; auths records
+	// the mechanism and initial response of each call.
+	authErrs []error
+	auths    []string
 }
 
 func (f *fakeConn) Login(u, p string) waitErr { return fakeWaitErr{err: f.loginErr} }
+func (f *fakeConn) Authenticate(c sasl.Client) error {
+	mech, ir, _ := c.Start()
+	f.auths = append(f.auths, mech+" "+string(ir))
+	if len(f.authErrs) >= len(f.auths) {
+		return f.authErrs[len(f.auths)-1]
+	}
+	return nil
+}
 func (f *fakeConn) Select(m string, o *imapv2.SelectOptions) waitSelect {
 	return fakeWaitSelect{err: f.selErr}
 }
@@ -38,6 +66,7 @@ func (f *fakeConn) Close() error    { f.closed = true; return nil }
 type fakeConn2 struct{ logoutErr, closeErr error }
 
 func (f *fakeConn2) Login(u, p string) waitErr                           { return fakeWaitErr{} }
+func (f *fakeConn2) Authenticate(c sasl.Client) error                    { return nil }
 func (f *fakeConn2) Select(m string, o *imapv2.SelectOptions) waitSelect { return fakeWaitSelect{} }
 func (f *fakeConn2) Logout() waitErr                                     { return fakeWaitErr{err: f.logoutErr} }
 func (f *fakeConn2) Close() error                                        { return f.closeErr }
@@ -51,9 +80,16 @@ type fakeBackend struct {
 	fetchErr   error
 	storeErr   error
 	expungeErr error
+
+	moveErr error
+
+	// criteria records the last search, moved the target mailbox of each move.
+	criteria *imapv2.SearchCriteria
+	moved    []string
 }
 
 func (f *fakeBackend) UIDSearch(criteria *imapv2.SearchCriteria) ([]imapv2.UID, error) {
+	f.criteria = criteria
 	return f.uids, f.searchErr
 }
 func (f *fakeBackend) FetchOneByUID(uid imapv2.UID, options *imapv2.FetchOptions) (*imapclient.FetchMessageBuffer, error) {
@@ -63,7 +99,11 @@ func (f *fakeBackend) FetchOneByUID(uid imapv2.UID, options *imapv2.FetchOptions
 	return f.fetch[uid], nil
 }
 func (f *fakeBackend) StoreAddFlags(uid imapv2.UID, flags []imapv2.Flag) error { return f.storeErr }
-func (f *fakeBackend) Expunge() error                                          { return f.expungeErr }
+func (f *fakeBackend) UIDExpunge(uid imapv2.UID) error                         { return f.expungeErr }
+func (f *fakeBackend) Move(uid imapv2.UID, mailbox string) error {
+	f.moved = append(f.moved, mailbox)
+	return f.moveErr
+}
 
 // recordingBackend simulates the ImapOps backend and records actions.
 
@@ -71,8 +111,11 @@ type recordingBackend struct {
 	meta   map[imapv2.UID]*imapclient.FetchMessageBuffer
 	bodies map[imapv2.UID]*imapclient.FetchMessageBuffer
 
+	sections []*imapv2.FetchItemBodySection
 	stored   []imapv2.UID
-	expunges int
+	flags    []imapv2.Flag
+	expunged []imapv2.UID
+	moved    map[imapv2.UID]string
 }
 
 func (r *recordingBackend) UIDSearch(criteria *imapv2.SearchCriteria) ([]imapv2.UID, error) {
@@ -89,6 +132,7 @@ func (r *recordingBackend) FetchOneByUID(uid imapv2.UID, options *imapv2.FetchOp
 		return r.meta[uid], nil
 	}
 	if options != nil && len(options.BodySection) > 0 {
+		r.sections = append(r.sections, options.BodySection...)
 		return r.bodies[uid], nil
 	}
 	return r.meta[uid], nil
@@ -96,9 +140,20 @@ func (r *recordingBackend) FetchOneByUID(uid imapv2.UID, options *imapv2.FetchOp
 
 func (r *recordingBackend) StoreAddFlags(uid imapv2.UID, flags []imapv2.Flag) error {
 	r.stored = append(r.stored, uid)
+	r.flags = append(r.flags, flags...)
+	return nil
+}
+func (r *recordingBackend) UIDExpunge(uid imapv2.UID) error {
+	r.expunged = append(r.expunged, uid)
+	return nil
+}
+func (r *recordingBackend) Move(uid imapv2.UID, mailbox string) error {
+	if r.moved == nil {
+		r.moved = map[imapv2.UID]string{}
+	}
+	r.moved[uid] = mailbox
 	return nil
 }
-func (r *recordingBackend) Expunge() error { r.expunges++; return nil }
 
 // backend that errors on BodySection fetch
 
@@ -116,7 +171,8 @@ func (e *errBodyBackend) FetchOneByUID(uid imapv2.UID, options *imapv2.FetchOpti
 	return nil, os.ErrPermission
 }
 func (e *errBodyBackend) StoreAddFlags(uid imapv2.UID, flags []imapv2.Flag) error { return nil }
-func (e *errBodyBackend) Expunge() error                                          { return nil }
+func (e *errBodyBackend) UIDExpunge(uid imapv2.UID) error                         { return nil }
+func (e *errBodyBackend) Move(uid imapv2.UID, mailbox string) error               { return nil }
 
 // Builders used across tests
 
@@ -141,3 +197,81 @@ func buildBody(contentBase64 string) *imapclient.FetchMessageBuffer {
 		BodySection: []imapclient.FetchBodySectionBuffer{{Section: &imapv2.FetchItemBodySection{Part: []int{1}}, Bytes: []byte(contentBase64)}},
 	}
 }
+
+// ---- TLS helpers used by dial tests ----
+
+// newTestCert creates a self-signed certificate for imap.test and writes its
+// PEM encoding to a CA file.
+func newTestCert(t *testing.T) (tls.Certificate, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "imap.test"},
+		DNSNames:              []string{"imap.test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("cert: %v", err)
+	}
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("write ca: %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, caFile
+}
+
+// serveGreeting accepts a single connection and sends an IMAP greeting,
+// upgrading to TLS first for "tls" and after a STARTTLS command for
+// "starttls". It returns the listener address.
+func serveGreeting(t *testing.T, security string, cert tls.Certificate) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if security == "tls" {
+			conn = tls.Server(conn, tlsConfig)
+		}
+		if _, err := conn.Write([]byte("* OK [CAPABILITY IMAP4rev1 STARTTLS] ready\r\n")); err != nil {
+			return
+		}
+		r := bufio.NewReader(conn)
+		if security == "starttls" {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			tag, _, _ := strings.Cut(line, " ")
+			if _, err := conn.Write([]byte(tag + " OK begin TLS\r\n")); err != nil {
+				return
+			}
+			tlsConn := tls.Server(conn, tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			r = bufio.NewReader(tlsConn)
+		}
+		// Keep the connection open until the client closes it.
+		_, _ = r.ReadString(0)
+	}()
+	return ln.Addr().String()
+}
@@ -0,0 +1,436 @@
+package jmap
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+	"github.com/go-playground/sensitive"
+)
+
+// Capabilities used by this package.
+const (
+	capabilityCore = "urn:ietf:params:jmap:core"
+	capabilityMail = "urn:ietf:params:jmap:mail"
+)
+
+// queryPageSize is the number of email ids requested per Email/query call.
+var queryPageSize = 100
+
+// Package-level errors
+var (
+	ErrJmapDisconnected = fmt.Errorf("not connected to the JMAP server")
+)
+
+// JmapAPI is the minimal contract used by message and syncer logic.
+// It enables injecting a fake in tests.
+type JmapAPI interface {
+	Connect(ctx context.Context, timeout time.Duration) error
+	Disconnect() error
+	FindMailbox(name string) (string, error)
+	QueryEmails(filter JmapFilter) ([]JmapEmail, error)
+	OpenBlob(blobID string, name string, mimeType string) (io.ReadCloser, error)
+	UpdateEmail(id string, patch map[string]any) error
+	DestroyEmail(id string) error
+	Host() string
+}
+
+// JmapFilter selects the emails to process.
+type JmapFilter struct {
+	MailboxID   string
+	UnreadOnly  bool
+	FilterField string
+	FilterValue string
+}
+
+type JmapClient struct {
+	sessionURL *url.URL
+	token      *sensitive.String
+	accountID  string
+
+	// ctx bounds every request of the session
+	ctx         context.Context
+	client      *http.Client
+	apiURL      string
+	downloadURL string
+}
+
+// jmapSession is the subset of the JMAP session resource that is used.
+type jmapSession struct {
+	Capabilities    map[string]json.RawMessage `json:"capabilities"`
+	Accounts        map[string]json.RawMessage `json:"accounts"`
+	PrimaryAccounts map[string]string          `json:"primaryAccounts"`
+	APIURL          string                     `json:"apiUrl"`
+	DownloadURL     string                     `json:"downloadUrl"`
+}
+
+// jmapInvocation is a method call or response: [name, arguments, call id].
+type jmapInvocation struct {
+	Name      string
+	Arguments json.RawMessage
+	CallID    string
+}
+
+func (i jmapInvocation) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]any{i.Name, i.Arguments, i.CallID})
+}
+
+func (i *jmapInvocation) UnmarshalJSON(b []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if len(raw) != 3 {
+		return fmt.Errorf("invalid JMAP invocation: %s", b)
+	}
+	if err := json.Unmarshal(raw[0], &i.Name); err != nil {
+		return err
+	}
+	i.Arguments = raw[1]
+	return json.Unmarshal(raw[2], &i.CallID)
+}
+
+func NewJmapClient(sessionURL string, token *sensitive.String, accountID string) (*JmapClient, error) {
+	u, err := url.Parse(sessionURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JMAP session url %s: %w", sessionURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid JMAP session url %s: scheme must be http or https", sessionURL)
+	}
+
+	return &JmapClient{
+		sessionURL: u,
+		token:      token,
+		accountID:  accountID,
+	}, nil
+}
+
+// Connect fetches the session resource and selects the mail account. The timeout
+// bounds connecting and waiting for responses, not the transfer of blobs.
+func (c *JmapClient) Connect(ctx context.Context, timeout time.Duration) error {
+	slog.Debug("Initiating JMAP connection", "host", c.Host())
+	c.ctx = ctx
+	client := util.NewHTTPClient(timeout)
+
+	req, err := c.newRequest(http.MethodGet, c.sessionURL.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch JMAP session: %s", resp.Status)
+	}
+
+	var session jmapSession
+	if err := json.NewDecoder(resp.Body).Decode(&session); err != nil {
+		return fmt.Errorf("failed to parse JMAP session: %w", err)
+	}
+	if _, ok := session.Capabilities[capabilityMail]; !ok {
+		return fmt.Errorf("server does not support JMAP mail")
+	}
+	if session.APIURL == "" || session.DownloadURL == "" {
+		return fmt.Errorf("JMAP session is missing apiUrl or downloadUrl")
+	}
+
+	accountID := c.accountID
+	if accountID == "" {
+		accountID = session.PrimaryAccounts[capabilityMail]
+	}
+	if _, ok := session.Accounts[accountID]; !ok {
+		return fmt.Errorf("JMAP account %q not found", accountID)
+	}
+
+	// Relative URLs are resolved against the session resource
+	c.apiURL = resolveURL(resp.Request.URL, session.APIURL)
+	c.downloadURL = resolveURL(resp.Request.URL, session.DownloadURL)
+	c.accountID = accountID
+	c.client = client
+	return nil
+}
+
+// Disconnect releases idle connections held by the HTTP client.
+func (c *JmapClient) Disconnect() error {
+	slog.Debug("Disconnecting JMAP connection", "host", c.Host())
+	if c.client != nil {
+		c.client.CloseIdleConnections()
+		c.client = nil
+	}
+	return nil
+}
+
+// FindMailbox returns the id of the mailbox with the given name, or of the
+// inbox when name is empty. Names are compared case-insensitively.
+func (c *JmapClient) FindMailbox(name string) (string, error) {
+	var result struct {
+		List []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+			Role string `json:"role"`
+		} `json:"list"`
+	}
+	if err := c.call("Mailbox/get", map[string]any{
+		"accountId":  c.accountID,
+		"ids":        nil,
+		"properties": []string{"id", "name", "role"},
+	}, &result); err != nil {
+		return "", err
+	}
+
+	for _, m := range result.List {
+		if (name == "" && m.Role == "inbox") || (name != "" && strings.EqualFold(m.Name, name)) {
+			return m.ID, nil
+		}
+	}
+	if name == "" {
+		name = "inbox"
+	}
+	return "", fmt.Errorf("mailbox %q not found", name)
+}
+
+// QueryEmails runs Email/query with the filter and fetches the matching emails
+// with Email/get in the same request, page by page.
+func (c *JmapClient) QueryEmails(filter JmapFilter) ([]JmapEmail, error) {
+	condition := map[string]any{}
+	if filter.MailboxID != "" {
+		condition["inMailbox"] = filter.MailboxID
+	}
+	if filter.UnreadOnly {
+		condition["notKeyword"] = "$seen"
+	}
+	switch filter.FilterField {
+	case "to":
+		condition["to"] = filter.FilterValue
+	case "subject":
+		condition["subject"] = filter.FilterValue
+	}
+
+	var emails []JmapEmail
+	for position := 0; ; {
+		var query struct {
+			IDs   []string `json:"ids"`
+			Total *int     `json:"total"`
+		}
+		var get struct {
+			List []JmapEmail `json:"list"`
+		}
+		err := c.batch([]jmapInvocation{
+			invocation("Email/query", "0", map[string]any{
+				"accountId":      c.accountID,
+				"filter":         condition,
+				"sort":           []map[string]any{{"property": "receivedAt", "isAscending": true}},
+				"position":       position,
+				"limit":          queryPageSize,
+				"calculateTotal": true,
+			}),
+			invocation("Email/get", "1", map[string]any{
+				"accountId":      c.accountID,
+				"#ids":           map[string]string{"resultOf": "0", "name": "Email/query", "path": "/ids"},
+				"properties":     []string{"id", "subject", "from", "mailboxIds", "keywords", "attachments"},
+				"bodyProperties": []string{"partId", "blobId", "size", "name", "type", "disposition"},
+			}),
+		}, &query, &get)
+		if err != nil {
+			return nil, err
+		}
+
+		emails = append(emails, get.List...)
+		position += len(query.IDs)
+		if len(query.IDs) == 0 || (query.Total != nil && position >= *query.Total) {
+			return emails, nil
+		}
+	}
+}
+
+// OpenBlob starts the download of a blob via the session's download URL template.
+func (c *JmapClient) OpenBlob(blobID string, name string, mimeType string) (io.ReadCloser, error) {
+	if c.client == nil {
+		return nil, ErrJmapDisconnected
+	}
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	downloadURL := strings.NewReplacer(
+		"{accountId}", url.PathEscape(c.accountID),
+		"{blobId}", url.PathEscape(blobID),
+		"{name}", url.PathEscape(name),
+		"{type}", url.QueryEscape(mimeType),
+	).Replace(c.downloadURL)
+
+	req, err := c.newRequest(http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to download blob %s: %s", blobID, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// UpdateEmail applies a patch (e.g. keywords or mailboxIds) with Email/set.
+func (c *JmapClient) UpdateEmail(id string, patch map[string]any) error {
+	var result struct {
+		NotUpdated map[string]jmapSetError `json:"notUpdated"`
+	}
+	if err := c.call("Email/set", map[string]any{
+		"accountId": c.accountID,
+		"update":    map[string]any{id: patch},
+	}, &result); err != nil {
+		return err
+	}
+	if e, ok := result.NotUpdated[id]; ok {
+		return fmt.Errorf("failed to update email %s: %s", id, e)
+	}
+	return nil
+}
+
+// DestroyEmail permanently deletes an email with Email/set.
+func (c *JmapClient) DestroyEmail(id string) error {
+	var result struct {
+		NotDestroyed map[string]jmapSetError `json:"notDestroyed"`
+	}
+	if err := c.call("Email/set", map[string]any{
+		"accountId": c.accountID,
+		"destroy":   []string{id},
+	}, &result); err != nil {
+		return err
+	}
+	if e, ok := result.NotDestroyed[id]; ok {
+		return fmt.Errorf("failed to destroy email %s: %s", id, e)
+	}
+	return nil
+}
+
+func (c *JmapClient) Host() string {
+	return c.sessionURL.Host
+}
+
+// jmapSetError is a SetError returned for a record that could not be changed.
+type jmapSetError struct {
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+func (e jmapSetError) String() string {
+	if e.Description != "" {
+		return e.Type + ": " + e.Description
+	}
+	return e.Type
+}
+
+// call sends a single method call and decodes its response arguments into result.
+func (c *JmapClient) call(method string, args map[string]any, result any) error {
+	return c.batch([]jmapInvocation{invocation(method, "0", args)}, result)
+}
+
+// batch sends several method calls in one request and decodes the response
+// arguments of each call, in order, into results.
+func (c *JmapClient) batch(calls []jmapInvocation, results ...any) error {
+	if c.client == nil {
+		return ErrJmapDisconnected
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"using":       []string{capabilityCore, capabilityMail},
+		"methodCalls": calls,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := c.newRequest(http.MethodPost, c.apiURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("JMAP request failed: %s", resp.Status)
+	}
+
+	var response struct {
+		MethodResponses []jmapInvocation `json:"methodResponses"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("failed to parse JMAP response: %w", err)
+	}
+
+	for i, call := range calls {
+		if i >= len(results) {
+			break
+		}
+		var answer *jmapInvocation
+		for j := range response.MethodResponses {
+			if response.MethodResponses[j].CallID == call.CallID {
+				answer = &response.MethodResponses[j]
+				break
+			}
+		}
+		if answer == nil {
+			return fmt.Errorf("no JMAP response for %s", call.Name)
+		}
+		if answer.Name == "error" {
+			var e jmapSetError
+			_ = json.Unmarshal(answer.Arguments, &e)
+			return fmt.Errorf("JMAP %s failed: %s", call.Name, e)
+		}
+		if err := json.Unmarshal(answer.Arguments, results[i]); err != nil {
+			return fmt.Errorf("failed to parse JMAP %s response: %w", call.Name, err)
+		}
+	}
+	return nil
+}
+
+// newRequest builds a request carrying the bearer token.
+func (c *JmapClient) newRequest(method string, rawURL string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(c.ctx, method, rawURL, body)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != nil {
+		req.Header.Set("Authorization", "Bearer "+string(*c.token))
+	}
+	return req, nil
+}
+
+func invocation(method string, callID string, args map[string]any) jmapInvocation {
+	raw, _ := json.Marshal(args)
+	return jmapInvocation{Name: method, Arguments: raw, CallID: callID}
+}
+
+// resolveURL resolves a possibly relative session URL. URL templates are kept
+// verbatim, since parsing them would escape the {placeholders}.
+func resolveURL(base *url.URL, ref string) string {
+	if strings.Contains(ref, "://") {
+		return ref
+	}
+	if strings.HasPrefix(ref, "/") {
+		return base.Scheme + "://" + base.Host + ref
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return base.ResolveReference(u).String()
+}
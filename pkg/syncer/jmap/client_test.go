@@ -0,0 +1,208 @@
+package jmap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/sensitive"
+)
+
+func connectTestClient(t *testing.T, srv *jmapServer) *JmapClient {
+	t.Helper()
+	token := sensitive.String(testToken)
+	c, err := NewJmapClient(srv.URL+"/jmap/session", &token, "")
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	if err := c.Connect(context.Background(), 5*time.Second); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Disconnect() })
+	return c
+}
+
+// TestNewJmapClient_InvalidURL rejects unsupported schemes.
+func TestNewJmapClient_InvalidURL(t *testing.T) {
+	if _, err := NewJmapClient("imap://mail.example", nil, ""); err == nil {
+		t.Fatalf("expected scheme error")
+	}
+	if _, err := NewJmapClient("://bad", nil, ""); err == nil {
+		t.Fatalf("expected parse error")
+	}
+}
+
+// TestJmapClient_Connect resolves the session and rejects bad tokens or accounts.
+func TestJmapClient_Connect(t *testing.T) {
+	srv := newJmapServer(t)
+	c := connectTestClient(t, srv)
+	if c.accountID != "A1" || c.apiURL != srv.URL+"/jmap/api/" || !strings.Contains(c.downloadURL, "{blobId}") {
+		t.Fatalf("unexpected session: %+v", c)
+	}
+
+	wrong := sensitive.String("nope")
+	bad, _ := NewJmapClient(srv.URL+"/jmap/session", &wrong, "")
+	if err := bad.Connect(context.Background(), time.Second); err == nil {
+		t.Fatalf("expected unauthorized error")
+	}
+
+	token := sensitive.String(testToken)
+	other, _ := NewJmapClient(srv.URL+"/jmap/session", &token, "A9")
+	if err := other.Connect(context.Background(), time.Second); err == nil || !strings.Contains(err.Error(), "A9") {
+		t.Fatalf("expected unknown account error, got %v", err)
+	}
+}
+
+// TestJmapClient_FindMailbox finds the inbox by role and others by name.
+func TestJmapClient_FindMailbox(t *testing.T) {
+	c := connectTestClient(t, newJmapServer(t))
+	if id, err := c.FindMailbox(""); err != nil || id != "mb-inbox" {
+		t.Fatalf("inbox: %q %v", id, err)
+	}
+	if id, err := c.FindMailbox("processed"); err != nil || id != "mb-done" {
+		t.Fatalf("by name: %q %v", id, err)
+	}
+	if _, err := c.FindMailbox("Archive"); err == nil {
+		t.Fatalf("expected missing mailbox error")
+	}
+}
+
+// TestJmapClient_QueryEmails pages through Email/query and applies the filter.
+func TestJmapClient_QueryEmails(t *testing.T) {
+	orig := queryPageSize
+	t.Cleanup(func() { queryPageSize = orig })
+	queryPageSize = 1
+
+	srv := newJmapServer(t)
+	c := connectTestClient(t, srv)
+
+	emails, err := c.QueryEmails(JmapFilter{MailboxID: "mb-inbox", FilterField: "to", FilterValue: "kobo@example.com"})
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if len(emails) != 2 || emails[0].ID != "e1" || emails[1].ID != "e2" || len(srv.queries) != 2 {
+		t.Fatalf("unexpected emails %+v after %d queries", emails, len(srv.queries))
+	}
+	if emails[0].Attachments[0].BlobID != "b1" || emails[0].From[0].Email != "alice@example.com" {
+		t.Fatalf("unexpected email details: %+v", emails[0])
+	}
+
+	emails, err = c.QueryEmails(JmapFilter{UnreadOnly: true, FilterField: "subject", FilterValue: "books"})
+	if err != nil || len(emails) != 1 || emails[0].ID != "e1" {
+		t.Fatalf("unread subject query: %+v %v", emails, err)
+	}
+	last := srv.queries[len(srv.queries)-1]["filter"].(map[string]any)
+	if last["notKeyword"] != "$seen" || last["subject"] != "books" {
+		t.Fatalf("unexpected filter: %v", last)
+	}
+}
+
+// TestJmapClient_BlobsAndSet downloads blobs and updates or destroys emails.
+func TestJmapClient_BlobsAndSet(t *testing.T) {
+	srv := newJmapServer(t)
+	c := connectTestClient(t, srv)
+
+	rc, err := c.OpenBlob("b1", "Dune 1.epub", "application/epub+zip")
+	if err != nil {
+		t.Fatalf("open blob: %v", err)
+	}
+	b, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(b) != "DUNE" {
+		t.Fatalf("unexpected blob %q", b)
+	}
+	if got := srv.downloads[0]; got != "/jmap/download/A1/b1/Dune 1.epub?type=application%2Fepub%2Bzip" {
+		t.Fatalf("unexpected download url %q", got)
+	}
+	if _, err := c.OpenBlob("nope", "x", ""); err == nil {
+		t.Fatalf("expected missing blob error")
+	}
+
+	if err := c.UpdateEmail("e1", map[string]any{"keywords/$seen": true}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if srv.updates["e1"]["keywords/$seen"] != true {
+		t.Fatalf("unexpected updates %v", srv.updates)
+	}
+	if err := c.UpdateEmail("missing", map[string]any{}); err == nil || !strings.Contains(err.Error(), "notFound") {
+		t.Fatalf("expected notUpdated error, got %v", err)
+	}
+
+	if err := c.DestroyEmail("e3"); err != nil || len(srv.destroyed) != 1 {
+		t.Fatalf("destroy: %v %v", err, srv.destroyed)
+	}
+	if err := c.DestroyEmail("missing"); err == nil {
+		t.Fatalf("expected notDestroyed error")
+	}
+}
+
+// TestJmapClient_Errors covers method errors and calls before Connect.
+func TestJmapClient_Errors(t *testing.T) {
+	c := connectTestClient(t, newJmapServer(t))
+	c.accountID = "A9"
+	if _, err := c.FindMailbox(""); err == nil || !strings.Contains(err.Error(), "accountNotFound") {
+		t.Fatalf("expected method error, got %v", err)
+	}
+
+	token := sensitive.String(testToken)
+	d, _ := NewJmapClient("https://jmap.example/session", &token, "")
+	if _, err := d.QueryEmails(JmapFilter{}); !errors.Is(err, ErrJmapDisconnected) {
+		t.Fatalf("query: %v", err)
+	}
+	if _, err := d.OpenBlob("b", "n", ""); !errors.Is(err, ErrJmapDisconnected) {
+		t.Fatalf("open: %v", err)
+	}
+	if err := d.Disconnect(); err != nil {
+		t.Fatalf("disconnect: %v", err)
+	}
+}
+
+// TestJmapClient_SlowDownload verifies blobs may take longer than the connect
+// timeout to download and are stopped by the session context.
+func TestJmapClient_SlowDownload(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/session" {
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"capabilities":    map[string]any{capabilityMail: map[string]any{}},
+				"accounts":        map[string]any{"A1": map[string]any{}},
+				"primaryAccounts": map[string]string{capabilityMail: "A1"},
+				"apiUrl":          "/api/",
+				"downloadUrl":     srv.URL + "/download/{blobId}",
+			})
+			return
+		}
+		for range 3 {
+			_, _ = w.Write([]byte("chunk"))
+			w.(http.Flusher).Flush()
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c, _ := NewJmapClient(srv.URL+"/session", nil, "")
+	if err := c.Connect(ctx, 50*time.Millisecond); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	blob, err := c.OpenBlob("b1", "book.epub", "")
+	if err != nil {
+		t.Fatalf("open blob: %v", err)
+	}
+	data, err := io.ReadAll(blob)
+	blob.Close()
+	if err != nil || string(data) != "chunkchunkchunk" {
+		t.Fatalf("slow download: %q %v", data, err)
+	}
+
+	cancel()
+	if _, err := c.OpenBlob("b1", "book.epub", ""); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+}
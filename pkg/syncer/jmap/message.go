@@ -0,0 +1,152 @@
+package jmap
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/bjw-s-labs/bookshift/pkg/mailparts"
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// keywordSeen is the keyword JMAP uses for read messages.
+const keywordSeen = "$seen"
+
+// JmapEmail is the subset of the Email object that is fetched.
+type JmapEmail struct {
+	ID          string          `json:"id"`
+	Subject     string          `json:"subject"`
+	From        []JmapAddress   `json:"from"`
+	MailboxIDs  map[string]bool `json:"mailboxIds"`
+	Keywords    map[string]bool `json:"keywords"`
+	Attachments []JmapBodyPart  `json:"attachments"`
+}
+
+type JmapAddress struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// JmapBodyPart is an attachment of an email, downloadable by its blob id.
+type JmapBodyPart struct {
+	PartID      string `json:"partId"`
+	BlobID      string `json:"blobId"`
+	Size        int64  `json:"size"`
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Disposition string `json:"disposition"`
+}
+
+// JmapAction is what happens to an email once its attachments are downloaded.
+type JmapAction struct {
+	Kind          string // none, seen, move or delete
+	MoveMailboxID string
+}
+
+type JmapMessage struct {
+	email JmapEmail
+
+	jmapClient JmapAPI
+}
+
+func NewJmapMessage(email JmapEmail, conn JmapAPI) *JmapMessage {
+	return &JmapMessage{
+		email:      email,
+		jmapClient: conn,
+	}
+}
+
+// DownloadAttachments downloads all valid attachments to dstFolder and then applies the action.
+func (jm *JmapMessage) DownloadAttachments(dstFolder string, validExtensions []string, overwriteExistingFile bool, action JmapAction) error {
+	var messageSender string
+	for _, addr := range jm.email.From {
+		messageSender = fmt.Sprintf("%s (%s)", addr.Name, addr.Email)
+	}
+
+	// Create target folder if required
+	if _, err := os.Stat(dstFolder); os.IsNotExist(err) {
+		if util.DryRun {
+			slog.Info("[dry-run] Would create local folder", "folder", dstFolder)
+			return nil
+		}
+		slog.Info("Creating local folder", "folder", dstFolder)
+		if err := os.MkdirAll(dstFolder, 0755); err != nil {
+			return err
+		}
+	}
+
+	// Download the attachments
+	for _, part := range jm.email.Attachments {
+		if !mailparts.WantedAttachment(part.Name, validExtensions) {
+			continue
+		}
+
+		slog.Info("Downloading email attachment", "host", jm.jmapClient.Host(), "sender", messageSender, "subject", jm.email.Subject, "filename", part.Name)
+
+		blob := &blobReader{open: func() (io.ReadCloser, error) {
+			return jm.jmapClient.OpenBlob(part.BlobID, part.Name, part.Type)
+		}}
+		dstPath, err := mailparts.SaveAttachment(dstFolder, part.Name, part.Size, blob, overwriteExistingFile)
+		blob.Close()
+		if err != nil {
+			return err
+		}
+		if dstPath != "" {
+			slog.Info("Successfully downloaded attachment", "id", jm.email.ID, "filename", filepath.Base(dstPath), "path", dstPath)
+		}
+	}
+
+	return jm.applyAction(action)
+}
+
+// applyAction marks, moves or destroys the email.
+func (jm *JmapMessage) applyAction(action JmapAction) error {
+	if action.Kind == "" || action.Kind == "none" {
+		return nil
+	}
+	if util.DryRun {
+		slog.Info("[dry-run] Would apply action to message", "id", jm.email.ID, "action", action.Kind)
+		return nil
+	}
+
+	switch action.Kind {
+	case "seen":
+		return jm.jmapClient.UpdateEmail(jm.email.ID, map[string]any{"keywords/" + keywordSeen: true})
+	case "move":
+		return jm.jmapClient.UpdateEmail(jm.email.ID, map[string]any{
+			"mailboxIds":              map[string]bool{action.MoveMailboxID: true},
+			"keywords/" + keywordSeen: true,
+		})
+	case "delete":
+		return jm.jmapClient.DestroyEmail(jm.email.ID)
+	default:
+		return fmt.Errorf("unsupported JMAP after_download action: %s", action.Kind)
+	}
+}
+
+// blobReader opens the blob download on first read, so attachments that are
+// skipped (existing file, dry-run) are never fetched.
+type blobReader struct {
+	open func() (io.ReadCloser, error)
+	rc   io.ReadCloser
+}
+
+func (b *blobReader) Read(p []byte) (int, error) {
+	if b.rc == nil {
+		rc, err := b.open()
+		if err != nil {
+			return 0, err
+		}
+		b.rc = rc
+	}
+	return b.rc.Read(p)
+}
+
+func (b *blobReader) Close() error {
+	if b.rc == nil {
+		return nil
+	}
+	return b.rc.Close()
+}
@@ -0,0 +1,98 @@
+package jmap
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+func testEmail() JmapEmail {
+	return JmapEmail{
+		ID:      "e1",
+		Subject: "Books",
+		Attachments: []JmapBodyPart{
+			{BlobID: "b1", Name: "Dune.epub", Size: 4},
+			{BlobID: "b2", Name: "cover.jpg", Size: 3},
+		},
+	}
+}
+
+// TestDownloadAttachments_FiltersAndActions writes matching attachments and applies each action.
+func TestDownloadAttachments_FiltersAndActions(t *testing.T) {
+	for _, kind := range []string{"none", "seen", "move", "delete"} {
+		fake := &fakeJmap{blobs: map[string]string{"b1": "DUNE", "b2": "JPG"}}
+		dir := filepath.Join(t.TempDir(), "nested")
+		err := NewJmapMessage(testEmail(), fake).DownloadAttachments(dir, []string{".epub"}, false, JmapAction{Kind: kind, MoveMailboxID: "mb-done"})
+		if err != nil {
+			t.Fatalf("%s: %v", kind, err)
+		}
+		if b, err := os.ReadFile(filepath.Join(dir, "dune.epub")); err != nil || string(b) != "DUNE" {
+			t.Fatalf("%s: unexpected file %q %v", kind, b, err)
+		}
+		if len(fake.opened) != 1 {
+			t.Fatalf("%s: unexpected blob downloads %v", kind, fake.opened)
+		}
+
+		switch kind {
+		case "none":
+			if len(fake.updated) != 0 || len(fake.destroyed) != 0 {
+				t.Fatalf("none changed the email")
+			}
+		case "seen":
+			if fake.updated["e1"]["keywords/$seen"] != true {
+				t.Fatalf("seen: %v", fake.updated)
+			}
+		case "move":
+			if mb := fake.updated["e1"]["mailboxIds"].(map[string]bool); !mb["mb-done"] || len(mb) != 1 {
+				t.Fatalf("move: %v", fake.updated)
+			}
+		case "delete":
+			if len(fake.destroyed) != 1 || fake.destroyed[0] != "e1" {
+				t.Fatalf("delete: %v", fake.destroyed)
+			}
+		}
+	}
+}
+
+// TestDownloadAttachments_SkipsExistingWithoutFetching ensures skipped attachments are never downloaded.
+func TestDownloadAttachments_SkipsExistingWithoutFetching(t *testing.T) {
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "dune.epub"), []byte("OLD"), 0o644)
+
+	fake := &fakeJmap{blobs: map[string]string{"b1": "DUNE"}}
+	if err := NewJmapMessage(testEmail(), fake).DownloadAttachments(dir, []string{".epub"}, false, JmapAction{}); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if len(fake.opened) != 0 {
+		t.Fatalf("expected no blob download, got %v", fake.opened)
+	}
+
+	old := util.DryRun
+	t.Cleanup(func() { util.DryRun = old })
+	util.DryRun = true
+	if err := NewJmapMessage(testEmail(), fake).DownloadAttachments(dir, nil, true, JmapAction{Kind: "delete"}); err != nil {
+		t.Fatalf("dry-run: %v", err)
+	}
+	if len(fake.opened) != 0 || len(fake.destroyed) != 0 {
+		t.Fatalf("dry-run had side effects: %v %v", fake.opened, fake.destroyed)
+	}
+}
+
+// TestDownloadAttachments_Errors surfaces blob and action failures.
+func TestDownloadAttachments_Errors(t *testing.T) {
+	fake := &fakeJmap{blobs: map[string]string{}}
+	if err := NewJmapMessage(testEmail(), fake).DownloadAttachments(t.TempDir(), []string{".epub"}, false, JmapAction{}); err == nil {
+		t.Fatalf("expected blob error")
+	}
+
+	fake = &fakeJmap{blobs: map[string]string{"b1": "DUNE"}, updateErr: errors.New("boom")}
+	if err := NewJmapMessage(testEmail(), fake).DownloadAttachments(t.TempDir(), []string{".epub"}, false, JmapAction{Kind: "seen"}); err == nil {
+		t.Fatalf("expected update error")
+	}
+	if err := NewJmapMessage(testEmail(), fake).DownloadAttachments(t.TempDir(), []string{".pdf"}, false, JmapAction{Kind: "archive"}); err == nil {
+		t.Fatalf("expected unsupported action error")
+	}
+}
@@ -0,0 +1,93 @@
+package jmap
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+type JmapSyncer struct {
+	config *config.JmapConfig
+}
+
+func NewJmapSyncer(mailConfig *config.JmapConfig) *JmapSyncer {
+	// Mark processed messages as read unless told otherwise
+	if mailConfig.AfterDownload == "" {
+		mailConfig.AfterDownload = "seen"
+	}
+
+	return &JmapSyncer{
+		config: mailConfig,
+	}
+}
+
+func (s *JmapSyncer) Run(targetFolder string, validExtensions []string, overwriteExistingFiles bool) error {
+	return s.RunContext(context.Background(), targetFolder, validExtensions, overwriteExistingFiles)
+}
+
+func (s *JmapSyncer) RunContext(ctx context.Context, targetFolder string, validExtensions []string, overwriteExistingFiles bool) error {
+	if s.config.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(s.config.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	// Connect to the JMAP server
+	jmapClient, err := newJmapClient(s.config)
+	if err != nil {
+		return err
+	}
+	if err := jmapConnect(ctx, jmapClient, 60*time.Second); err != nil {
+		return fmt.Errorf("could not connect to JMAP server %s: %w", s.config.SessionURL, err)
+	}
+	defer jmapClient.Disconnect()
+
+	// Resolve the mailboxes by name
+	mailboxID, err := jmapClient.FindMailbox(s.config.Mailbox)
+	if err != nil {
+		return fmt.Errorf("could not find JMAP mailbox: %w", err)
+	}
+	action := JmapAction{Kind: s.config.AfterDownload}
+	if action.Kind == "move" {
+		if action.MoveMailboxID, err = jmapClient.FindMailbox(s.config.MoveToMailbox); err != nil {
+			return fmt.Errorf("could not find JMAP mailbox: %w", err)
+		}
+	}
+
+	// Collect messages from the JMAP server
+	emails, err := jmapCollect(jmapClient, JmapFilter{
+		MailboxID:   mailboxID,
+		UnreadOnly:  !s.config.ProcessReadEmails,
+		FilterField: s.config.FilterField,
+		FilterValue: s.config.FilterValue,
+	})
+	if err != nil {
+		return fmt.Errorf("could not query JMAP emails: %w", err)
+	}
+
+	// Download attachments for each message
+	for _, email := range emails {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		if err := jmapDownload(NewJmapMessage(email, jmapClient),
+			targetFolder,
+			validExtensions,
+			overwriteExistingFiles,
+			action,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+// syncer_seams.go: Test seams for the JMAP syncer.
+//
+// This file defines small function variables that production code uses by default,
+// but tests can override to substitute fakes. Keeping these seams in a separate
+// file keeps the main implementation clean while preserving simple overrides in tests.
+package jmap
+
+import (
+	"context"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+// test hooks (seams) for dependency injection in tests
+var (
+	newJmapClient = func(cfg *config.JmapConfig) (JmapAPI, error) {
+		return NewJmapClient(cfg.SessionURL, cfg.BearerToken, cfg.AccountID)
+	}
+	jmapConnect  = func(ctx context.Context, c JmapAPI, timeout time.Duration) error { return c.Connect(ctx, timeout) }
+	jmapCollect  = func(c JmapAPI, filter JmapFilter) ([]JmapEmail, error) { return c.QueryEmails(filter) }
+	jmapDownload = func(m *JmapMessage, dst string, valid []string, overwrite bool, action JmapAction) error {
+		return m.DownloadAttachments(dst, valid, overwrite, action)
+	}
+)
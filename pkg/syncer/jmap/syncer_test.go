@@ -0,0 +1,109 @@
+package jmap
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/go-playground/sensitive"
+)
+
+// TestNewJmapSyncer_Defaults marks processed messages as read by default.
+func TestNewJmapSyncer_Defaults(t *testing.T) {
+	if s := NewJmapSyncer(&config.JmapConfig{}); s.config.AfterDownload != "seen" {
+		t.Fatalf("unexpected default %q", s.config.AfterDownload)
+	}
+}
+
+// TestJmapSyncer_Run_EndToEnd downloads matching attachments from the stand-in and moves the emails.
+func TestJmapSyncer_Run_EndToEnd(t *testing.T) {
+	srv := newJmapServer(t)
+	token := sensitive.String(testToken)
+	cfg := &config.JmapConfig{
+		SessionURL:    srv.URL + "/jmap/session",
+		BearerToken:   &token,
+		FilterField:   "to",
+		FilterValue:   "kobo@example.com",
+		AfterDownload: "move",
+		MoveToMailbox: "Processed",
+	}
+
+	dst := t.TempDir()
+	if err := NewJmapSyncer(cfg).Run(dst, []string{".epub"}, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	entries, _ := os.ReadDir(dst)
+	if len(entries) != 1 || entries[0].Name() != "dune.epub" {
+		t.Fatalf("unexpected files: %v", entries)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dst, "dune.epub")); string(b) != "DUNE" {
+		t.Fatalf("unexpected content %q", b)
+	}
+	mb, _ := srv.updates["e1"]["mailboxIds"].(map[string]any)
+	if len(srv.updates) != 1 || mb["mb-done"] != true {
+		t.Fatalf("unexpected updates: %v", srv.updates)
+	}
+}
+
+// TestJmapSyncer_Run_Seams covers client, connect, mailbox and query errors and cancellation.
+func TestJmapSyncer_Run_Seams(t *testing.T) {
+	origNew := newJmapClient
+	t.Cleanup(func() { newJmapClient = origNew })
+
+	fake := &fakeJmap{
+		mailboxes: map[string]string{"": "mb-inbox"},
+		emails:    []JmapEmail{testEmail()},
+		blobs:     map[string]string{"b1": "DUNE", "b2": "JPG"},
+	}
+	newJmapClient = func(cfg *config.JmapConfig) (JmapAPI, error) { return fake, nil }
+	cfg := &config.JmapConfig{FilterField: "subject", FilterValue: "books", ProcessReadEmails: true}
+
+	if err := NewJmapSyncer(cfg).Run(t.TempDir(), nil, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if fake.filter.MailboxID != "mb-inbox" || fake.filter.UnreadOnly || fake.updated["e1"] == nil {
+		t.Fatalf("unexpected filter or action: %+v %v", fake.filter, fake.updated)
+	}
+
+	cfg.AfterDownload, cfg.MoveToMailbox = "move", "Archive"
+	if err := NewJmapSyncer(cfg).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected missing move mailbox error")
+	}
+	cfg.AfterDownload = "seen"
+
+	fake.queryErr = errors.New("boom")
+	if err := NewJmapSyncer(cfg).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected query error")
+	}
+	fake.queryErr = nil
+
+	fake.connectErr = errors.New("refused")
+	if err := NewJmapSyncer(cfg).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected connect error")
+	}
+	fake.connectErr = nil
+
+	newJmapClient = func(cfg *config.JmapConfig) (JmapAPI, error) { return nil, errors.New("bad url") }
+	if err := NewJmapSyncer(cfg).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected client error")
+	}
+	newJmapClient = func(cfg *config.JmapConfig) (JmapAPI, error) { return fake, nil }
+
+	origConnect := jmapConnect
+	t.Cleanup(func() { jmapConnect = origConnect })
+	jmapConnect = func(ctx context.Context, c JmapAPI, timeout time.Duration) error {
+		if timeout != 60*time.Second {
+			t.Fatalf("unexpected timeout %v", timeout)
+		}
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := NewJmapSyncer(cfg).RunContext(ctx, t.TempDir(), nil, false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+}
@@ -0,0 +1,281 @@
+package jmap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testToken = "secret-token"
+
+// stubEmail is an email stored by the JMAP stand-in.
+type stubEmail struct {
+	JmapEmail
+	To    string
+	blobs map[string]string
+}
+
+// jmapServer is a minimal JMAP stand-in covering the session resource,
+// Mailbox/get, Email/query, Email/get, Email/set and blob downloads.
+type jmapServer struct {
+	URL string
+
+	mu        sync.Mutex
+	emails    []*stubEmail
+	mailboxes map[string]string // id -> name
+	queries   []map[string]any
+	updates   map[string]map[string]any
+	destroyed []string
+	downloads []string
+}
+
+func newJmapServer(t *testing.T) *jmapServer {
+	t.Helper()
+	s := &jmapServer{
+		mailboxes: map[string]string{"mb-inbox": "Inbox", "mb-done": "Processed"},
+		updates:   map[string]map[string]any{},
+	}
+	s.emails = []*stubEmail{
+		{
+			JmapEmail: JmapEmail{
+				ID: "e1", Subject: "Weekly books",
+				From:        []JmapAddress{{Name: "Alice", Email: "alice@example.com"}},
+				MailboxIDs:  map[string]bool{"mb-inbox": true},
+				Attachments: []JmapBodyPart{{BlobID: "b1", Name: "Dune.epub", Type: "application/epub+zip", Size: 4}, {BlobID: "b2", Name: "cover.jpg", Type: "image/jpeg", Size: 3}},
+			},
+			To:    "kobo@example.com",
+			blobs: map[string]string{"b1": "DUNE", "b2": "JPG"},
+		},
+		{
+			JmapEmail: JmapEmail{
+				ID: "e2", Subject: "More books",
+				MailboxIDs:  map[string]bool{"mb-inbox": true},
+				Keywords:    map[string]bool{"$seen": true},
+				Attachments: []JmapBodyPart{{BlobID: "b3", Name: "emma.epub", Size: 4}},
+			},
+			To:    "kobo@example.com",
+			blobs: map[string]string{"b3": "EMMA"},
+		},
+		{
+			JmapEmail: JmapEmail{
+				ID: "e3", Subject: "Newsletter",
+				MailboxIDs:  map[string]bool{"mb-inbox": true},
+				Attachments: []JmapBodyPart{{BlobID: "b4", Name: "news.epub", Size: 4}},
+			},
+			To:    "someone@example.com",
+			blobs: map[string]string{"b4": "NEWS"},
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/jmap/session", s.auth(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"capabilities":    map[string]any{capabilityCore: map[string]any{}, capabilityMail: map[string]any{}},
+			"accounts":        map[string]any{"A1": map[string]any{"name": "reader"}},
+			"primaryAccounts": map[string]string{capabilityMail: "A1"},
+			"apiUrl":          "/jmap/api/",
+			"downloadUrl":     s.URL + "/jmap/download/{accountId}/{blobId}/{name}?type={type}",
+		})
+	}))
+	mux.HandleFunc("/jmap/api/", s.auth(s.handleAPI))
+	mux.HandleFunc("/jmap/download/", s.auth(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/jmap/download/"), "/")
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.downloads = append(s.downloads, r.URL.Path+"?"+r.URL.RawQuery)
+		for _, e := range s.emails {
+			if content, ok := e.blobs[parts[1]]; ok && parts[0] == "A1" {
+				_, _ = io.WriteString(w, content)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	}))
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	s.URL = srv.URL
+	return s
+}
+
+func (s *jmapServer) auth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *jmapServer) handleAPI(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Using       []string          `json:"using"`
+		MethodCalls []json.RawMessage `json:"methodCalls"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !slices.Contains(req.Using, capabilityMail) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var lastIDs []string
+	var responses []any
+	for _, raw := range req.MethodCalls {
+		var call []json.RawMessage
+		_ = json.Unmarshal(raw, &call)
+		var name, callID string
+		var args map[string]any
+		_ = json.Unmarshal(call[0], &name)
+		_ = json.Unmarshal(call[1], &args)
+		_ = json.Unmarshal(call[2], &callID)
+
+		if args["accountId"] != "A1" {
+			responses = append(responses, []any{"error", map[string]any{"type": "accountNotFound"}, callID})
+			continue
+		}
+
+		switch name {
+		case "Mailbox/get":
+			var list []map[string]string
+			for id, n := range s.mailboxes {
+				m := map[string]string{"id": id, "name": n}
+				if n == "Inbox" {
+					m["role"] = "inbox"
+				}
+				list = append(list, m)
+			}
+			responses = append(responses, []any{name, map[string]any{"list": list}, callID})
+		case "Email/query":
+			s.queries = append(s.queries, args)
+			filter, _ := args["filter"].(map[string]any)
+			var ids []string
+			for _, e := range s.emails {
+				if mb, ok := filter["inMailbox"].(string); ok && !e.MailboxIDs[mb] {
+					continue
+				}
+				if kw, ok := filter["notKeyword"].(string); ok && e.Keywords[kw] {
+					continue
+				}
+				if v, ok := filter["to"].(string); ok && !strings.Contains(e.To, v) {
+					continue
+				}
+				if v, ok := filter["subject"].(string); ok && !strings.Contains(strings.ToLower(e.Subject), strings.ToLower(v)) {
+					continue
+				}
+				ids = append(ids, e.ID)
+			}
+			total := len(ids)
+			position := int(args["position"].(float64))
+			limit := int(args["limit"].(float64))
+			ids = ids[min(position, len(ids)):min(position+limit, len(ids))]
+			lastIDs = ids
+			responses = append(responses, []any{name, map[string]any{"ids": ids, "total": total, "position": position}, callID})
+		case "Email/get":
+			if _, ok := args["#ids"]; !ok {
+				responses = append(responses, []any{"error", map[string]any{"type": "invalidArguments"}, callID})
+				continue
+			}
+			var list []JmapEmail
+			for _, id := range lastIDs {
+				for _, e := range s.emails {
+					if e.ID == id {
+						list = append(list, e.JmapEmail)
+					}
+				}
+			}
+			responses = append(responses, []any{name, map[string]any{"list": list}, callID})
+		case "Email/set":
+			result := map[string]any{}
+			if update, ok := args["update"].(map[string]any); ok {
+				notUpdated := map[string]any{}
+				for id, patch := range update {
+					if id == "missing" {
+						notUpdated[id] = map[string]string{"type": "notFound"}
+						continue
+					}
+					s.updates[id] = patch.(map[string]any)
+				}
+				result["notUpdated"] = notUpdated
+			}
+			if destroy, ok := args["destroy"].([]any); ok {
+				notDestroyed := map[string]any{}
+				for _, id := range destroy {
+					if id == "missing" {
+						notDestroyed["missing"] = map[string]string{"type": "notFound"}
+						continue
+					}
+					s.destroyed = append(s.destroyed, id.(string))
+				}
+				result["notDestroyed"] = notDestroyed
+			}
+			responses = append(responses, []any{name, result, callID})
+		default:
+			responses = append(responses, []any{"error", map[string]any{"type": "unknownMethod"}, callID})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"methodResponses": responses, "sessionState": "1"})
+}
+
+// fakeJmap is an in-memory JmapAPI used by message and syncer tests.
+type fakeJmap struct {
+	emails     []JmapEmail
+	blobs      map[string]string
+	mailboxes  map[string]string // name -> id
+	connectErr error
+	queryErr   error
+	updateErr  error
+
+	opened    []string
+	updated   map[string]map[string]any
+	destroyed []string
+	filter    JmapFilter
+}
+
+func (f *fakeJmap) Connect(ctx context.Context, timeout time.Duration) error { return f.connectErr }
+func (f *fakeJmap) Disconnect() error                                        { return nil }
+func (f *fakeJmap) FindMailbox(name string) (string, error) {
+	if id, ok := f.mailboxes[name]; ok {
+		return id, nil
+	}
+	return "", fmt.Errorf("mailbox %q not found", name)
+}
+func (f *fakeJmap) QueryEmails(filter JmapFilter) ([]JmapEmail, error) {
+	f.filter = filter
+	return f.emails, f.queryErr
+}
+func (f *fakeJmap) OpenBlob(blobID string, name string, mimeType string) (io.ReadCloser, error) {
+	f.opened = append(f.opened, blobID)
+	content, ok := f.blobs[blobID]
+	if !ok {
+		return nil, fmt.Errorf("blob %s not found", blobID)
+	}
+	return io.NopCloser(strings.NewReader(content)), nil
+}
+func (f *fakeJmap) UpdateEmail(id string, patch map[string]any) error {
+	if f.updateErr != nil {
+		return f.updateErr
+	}
+	if f.updated == nil {
+		f.updated = map[string]map[string]any{}
+	}
+	f.updated[id] = patch
+	return nil
+}
+func (f *fakeJmap) DestroyEmail(id string) error {
+	f.destroyed = append(f.destroyed, id)
+	return nil
+}
+func (f *fakeJmap) Host() string { return "jmap.example" }
@@ -0,0 +1,98 @@
+package libraryserver
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+type LibraryServerBook struct {
+	book      ServerBook
+	subFolder string
+	fileName  string
+
+	serverClient LibraryServerAPI
+}
+
+// NewLibraryServerBook names the local file after the file on the server.
+// With keepFolderStructure the book is placed in a folder named after its series.
+func NewLibraryServerBook(book ServerBook, keepFolderStructure bool, conn LibraryServerAPI) *LibraryServerBook {
+	b := &LibraryServerBook{
+		book:         book,
+		fileName:     util.SafeFileName(book.FileName),
+		serverClient: conn,
+	}
+	if keepFolderStructure && book.Series != "" {
+		b.subFolder = util.SafeFileName(book.Series)
+	}
+	return b
+}
+
+// FileName returns the local file name of the book.
+func (b *LibraryServerBook) FileName() string {
+	return b.fileName
+}
+
+func (b *LibraryServerBook) Download(dstFolder string, overwriteExistingFile bool) error {
+	if b.subFolder != "" {
+		dstFolder = filepath.Join(dstFolder, b.subFolder)
+	}
+	dstPath := filepath.Join(dstFolder, b.fileName)
+
+	// Create folder structure if required
+	if _, err := os.Stat(dstFolder); os.IsNotExist(err) {
+		if util.DryRun {
+			slog.Info("[dry-run] Would create local folder", "folder", dstFolder)
+			return nil
+		}
+		slog.Info("Creating local folder", "folder", dstFolder)
+		if err := os.MkdirAll(dstFolder, 0755); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("Downloading book from library server", "host", b.serverClient.Host(), "id", b.book.ID, "series", b.book.Series, "title", b.book.Title, "destination", dstPath)
+
+	// Check if the file already exists
+	_, err := os.Stat(dstPath)
+	if !os.IsNotExist(err) {
+		if !overwriteExistingFile {
+			slog.Warn("File already exists, skipping download", "file", dstPath)
+			return nil
+		}
+
+		slog.Info("Overwriting existing file", "file", dstPath)
+	}
+
+	// Download the file
+	if util.DryRun {
+		slog.Info("[dry-run] Would download book", "id", b.book.ID, "destination", dstPath)
+		return nil
+	}
+	tmpFile, err := os.CreateTemp(dstFolder, "bookshift-")
+	if err != nil {
+		return err
+	}
+	defer tmpFile.Close()
+
+	slog.Debug("Downloading to temporary file", "file", tmpFile.Name())
+	writer := util.NewFileWriter(tmpFile, b.book.Size, true)
+	if _, err := b.serverClient.ReadFile(b.book, writer); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+
+	if err := tmpFile.Sync(); err != nil { // ensure data flushed before rename
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	if err := os.Rename(tmpFile.Name(), dstPath); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+
+	slog.Info("Successfully downloaded file", "filename", b.fileName)
+	return nil
+}
@@ -0,0 +1,68 @@
+package libraryserver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// TestLibraryServerBook_Download names the file after the server file and keeps series folders on request.
+func TestLibraryServerBook_Download(t *testing.T) {
+	srv := &fakeServer{content: map[string]string{"1": "SAGA"}}
+	sb := ServerBook{ID: "1", Series: "Saga", Title: "Saga 01", FileName: "Saga 01.cbz", Size: 4}
+	dst := t.TempDir()
+
+	b := NewLibraryServerBook(sb, false, srv)
+	if err := b.Download(dst, false); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dst, b.FileName())); err != nil || string(data) != "SAGA" {
+		t.Fatalf("unexpected file: %q %v", data, err)
+	}
+
+	b = NewLibraryServerBook(sb, true, srv)
+	if err := b.Download(dst, false); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, util.SafeFileName("Saga"), b.FileName())); err != nil {
+		t.Fatalf("expected series folder: %v", err)
+	}
+
+	// Existing files are skipped unless overwriting
+	srv.read = nil
+	if err := b.Download(dst, false); err != nil || len(srv.read) != 0 {
+		t.Fatalf("expected skip, got %v %v", err, srv.read)
+	}
+	if err := b.Download(dst, true); err != nil || len(srv.read) != 1 {
+		t.Fatalf("expected overwrite, got %v %v", err, srv.read)
+	}
+
+	// Failed downloads leave no temp files behind
+	if err := NewLibraryServerBook(ServerBook{ID: "2", FileName: "x.cbz"}, false, srv).Download(dst, false); err == nil {
+		t.Fatalf("expected download error")
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dst, "bookshift-*")); len(matches) != 0 {
+		t.Fatalf("temp files left behind: %v", matches)
+	}
+}
+
+// TestLibraryServerBook_DryRun downloads nothing.
+func TestLibraryServerBook_DryRun(t *testing.T) {
+	old := util.DryRun
+	t.Cleanup(func() { util.DryRun = old })
+	util.DryRun = true
+
+	srv := &fakeServer{content: map[string]string{"1": "SAGA"}}
+	dst := t.TempDir()
+	sb := ServerBook{ID: "1", Series: "Saga", FileName: "Saga 01.cbz"}
+	for _, keep := range []bool{false, true} {
+		if err := NewLibraryServerBook(sb, keep, srv).Download(dst, false); err != nil {
+			t.Fatalf("dry-run: %v", err)
+		}
+	}
+	if entries, _ := os.ReadDir(dst); len(entries) != 0 || len(srv.read) != 0 {
+		t.Fatalf("dry-run wrote files or downloaded: %v %v", entries, srv.read)
+	}
+}
@@ -0,0 +1,213 @@
+package libraryserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// LibraryServerAPI is the minimal contract used by the syncer and book logic,
+// implemented for Kavita and Komga. It enables injecting a fake in tests.
+type LibraryServerAPI interface {
+	Connect(ctx context.Context, timeout time.Duration) error
+	Disconnect() error
+	Books(selection Selection) ([]ServerBook, error)
+	ReadFile(book ServerBook, w io.Writer) (int64, error)
+	Host() string
+}
+
+// Selection names the libraries, collections and reading lists to pull books
+// from. A book is selected when it is part of any of them.
+type Selection struct {
+	Libraries    []string
+	Collections  []string
+	ReadingLists []string
+	WantToRead   bool
+}
+
+// ServerBook is a single downloadable file on the server. For Kavita this is
+// a chapter, for Komga a book.
+type ServerBook struct {
+	ID       string
+	Series   string
+	Title    string
+	FileName string
+	Size     int64
+}
+
+// Package-level errors
+var (
+	ErrLibraryServerDisconnected = fmt.Errorf("not connected to the library server")
+)
+
+// NewLibraryServerClient returns the client for the configured server type.
+func NewLibraryServerClient(cfg *config.LibraryServerConfig) (LibraryServerAPI, error) {
+	api, err := newAPIClient(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	switch cfg.Server {
+	case "kavita":
+		return &KavitaClient{api: api, apiKey: cfg.APIKey, username: cfg.Username, password: cfg.Password}, nil
+	case "komga":
+		return &KomgaClient{api: api, apiKey: cfg.APIKey, username: cfg.Username, password: cfg.Password}, nil
+	default:
+		return nil, fmt.Errorf("unsupported library server: %s", cfg.Server)
+	}
+}
+
+// apiClient sends requests relative to the server's base URL.
+type apiClient struct {
+	baseURL *url.URL
+	header  http.Header
+
+	// ctx bounds every request of the session
+	ctx    context.Context
+	client *http.Client
+}
+
+func newAPIClient(baseURL string) (*apiClient, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid library server url %s: %w", baseURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid library server url %s: scheme must be http or https", baseURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	return &apiClient{baseURL: u, header: http.Header{}}, nil
+}
+
+func (a *apiClient) connect(ctx context.Context, timeout time.Duration) {
+	a.ctx = ctx
+	a.client = util.NewHTTPClient(timeout)
+}
+
+func (a *apiClient) disconnect() {
+	if a.client != nil {
+		a.client.CloseIdleConnections()
+		a.client = nil
+	}
+}
+
+// do sends a request for a path relative to the base URL, with an optional JSON body.
+func (a *apiClient) do(method string, p string, query url.Values, body any) (*http.Response, error) {
+	if a.client == nil {
+		return nil, ErrLibraryServerDisconnected
+	}
+
+	u := *a.baseURL
+	u.Path = path.Join(a.baseURL.Path, p)
+	if query != nil {
+		u.RawQuery = query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(a.ctx, method, u.String(), reader)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range a.header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return a.client.Do(req)
+}
+
+// doJSON sends a request and decodes a JSON response into v.
+func (a *apiClient) doJSON(method string, p string, query url.Values, body any, v any) error {
+	resp, err := a.do(method, p, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected response: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if v == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// download streams a file response to w.
+func (a *apiClient) download(p string, query url.Values, w io.Writer) (int64, error) {
+	resp, err := a.do(http.MethodGet, p, query, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed to download %s: %s", p, resp.Status)
+	}
+	return io.Copy(w, resp.Body)
+}
+
+func (a *apiClient) host() string {
+	return a.baseURL.Host
+}
+
+// resolveNames maps the configured names to server ids, case-insensitively.
+// Unknown names are an error, so typos do not silently select nothing.
+func resolveNames[T any](kind string, names []string, available map[string]T) ([]T, error) {
+	var ids []T
+	for _, name := range names {
+		var (
+			id    T
+			found bool
+		)
+		for n, i := range available {
+			if strings.EqualFold(n, name) {
+				id, found = i, true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%s %q not found on library server", kind, name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// appendBook adds a book unless it was already selected through another list.
+func appendBook(books []ServerBook, seen map[string]bool, book ServerBook) []ServerBook {
+	if seen[book.ID] {
+		return books
+	}
+	seen[book.ID] = true
+	return append(books, book)
+}
+
+// baseName returns the file name of a path on the server, which may use
+// Windows separators.
+func baseName(p string) string {
+	if i := strings.LastIndexAny(p, `/\`); i >= 0 {
+		return p[i+1:]
+	}
+	return p
+}
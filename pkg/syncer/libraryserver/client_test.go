@@ -0,0 +1,109 @@
+package libraryserver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+// TestNewLibraryServerClient selects the client for the server type and validates the URL.
+func TestNewLibraryServerClient(t *testing.T) {
+	if c, err := NewLibraryServerClient(&config.LibraryServerConfig{Server: "kavita", URL: "http://kavita.local:5000/"}); err != nil || c.Host() != "kavita.local:5000" {
+		t.Fatalf("unexpected kavita client: %T %v", c, err)
+	} else if _, ok := c.(*KavitaClient); !ok {
+		t.Fatalf("expected KavitaClient, got %T", c)
+	}
+	if c, err := NewLibraryServerClient(&config.LibraryServerConfig{Server: "komga", URL: "https://komga.local"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if _, ok := c.(*KomgaClient); !ok {
+		t.Fatalf("expected KomgaClient, got %T", c)
+	}
+	for _, cfg := range []*config.LibraryServerConfig{
+		{Server: "plex", URL: "http://plex.local"},
+		{Server: "komga", URL: "ftp://komga.local"},
+		{Server: "komga", URL: "://bad"},
+	} {
+		if _, err := NewLibraryServerClient(cfg); err == nil {
+			t.Fatalf("expected error for %+v", cfg)
+		}
+	}
+}
+
+// TestAPIClient_Disconnected refuses requests before Connect.
+func TestAPIClient_Disconnected(t *testing.T) {
+	api, _ := newAPIClient("http://library.local")
+	if err := api.doJSON("GET", "/x", nil, nil, nil); !errors.Is(err, ErrLibraryServerDisconnected) {
+		t.Fatalf("expected disconnected error, got %v", err)
+	}
+	if _, err := api.download("/x", nil, io.Discard); !errors.Is(err, ErrLibraryServerDisconnected) {
+		t.Fatalf("expected disconnected error, got %v", err)
+	}
+	api.connect(context.Background(), time.Second)
+	api.disconnect()
+	api.disconnect()
+}
+
+// TestResolveNames matches names case-insensitively and rejects unknown names.
+func TestResolveNames(t *testing.T) {
+	available := map[string]int{"Comics": 1, "Manga": 2}
+	ids, err := resolveNames("library", []string{"manga", "COMICS"}, available)
+	if err != nil || !reflect.DeepEqual(ids, []int{2, 1}) {
+		t.Fatalf("unexpected ids: %v %v", ids, err)
+	}
+	if _, err := resolveNames("library", []string{"Books"}, available); err == nil || !strings.Contains(err.Error(), `library "Books" not found`) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
+
+// TestBaseName handles both path separators.
+func TestBaseName(t *testing.T) {
+	for in, want := range map[string]string{
+		"/comics/Saga/Saga 01.cbz": "Saga 01.cbz",
+		`D:\manga\Berserk v01.cbr`: "Berserk v01.cbr",
+		"plain.epub":               "plain.epub",
+	} {
+		if got := baseName(in); got != want {
+			t.Errorf("baseName(%q)=%q, want %q", in, got, want)
+		}
+	}
+}
+
+// TestKomgaClient_SlowDownload verifies book files may take longer than the
+// connect timeout to download and are stopped by the session context.
+func TestKomgaClient_SlowDownload(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/users/me" {
+			return
+		}
+		for range 3 {
+			_, _ = w.Write([]byte("chunk"))
+			w.(http.Flusher).Flush()
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c, _ := NewLibraryServerClient(&config.LibraryServerConfig{Server: "komga", URL: srv.URL})
+	if err := c.Connect(ctx, 50*time.Millisecond); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := c.ReadFile(ServerBook{ID: "B1"}, &buf); err != nil || buf.String() != "chunkchunkchunk" {
+		t.Fatalf("slow download: %q %v", buf.String(), err)
+	}
+
+	cancel()
+	if _, err := c.ReadFile(ServerBook{ID: "B1"}, &buf); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+}
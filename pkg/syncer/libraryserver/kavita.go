@@ -0,0 +1,282 @@
+package libraryserver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/sensitive"
+)
+
+// KavitaClient talks to the Kavita REST API. It logs in with an API key
+// (through the plugin endpoint) or with a username and password, and uses the
+// returned JWT for all further requests.
+type KavitaClient struct {
+	api      *apiClient
+	apiKey   *sensitive.String
+	username string
+	password *sensitive.String
+}
+
+// Kavita series filter (FilterV2Dto) values that are used.
+const (
+	kavitaFieldCollectionTags = 7
+	kavitaFieldLibraries      = 19
+	kavitaFieldWantToRead     = 26
+	kavitaComparisonEqual     = 0
+	kavitaCombinationOr       = 0
+)
+
+// kavitaPageSize is the number of entries requested per paginated call.
+var kavitaPageSize = 100
+
+type kavitaStatement struct {
+	Comparison int    `json:"comparison"`
+	Field      int    `json:"field"`
+	Value      string `json:"value"`
+}
+
+type kavitaFilter struct {
+	Statements  []kavitaStatement `json:"statements"`
+	Combination int               `json:"combination"`
+	LimitTo     int               `json:"limitTo"`
+}
+
+type kavitaSeries struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// kavitaChapter mirrors the subset of a Kavita chapter that is used.
+type kavitaChapter struct {
+	ID    int    `json:"id"`
+	Title string `json:"titleName"`
+	Files []struct {
+		FilePath string `json:"filePath"`
+		Bytes    int64  `json:"bytes"`
+	} `json:"files"`
+}
+
+type kavitaVolume struct {
+	ID       int             `json:"id"`
+	Chapters []kavitaChapter `json:"chapters"`
+}
+
+// Connect prepares the HTTP client and logs in. The timeout bounds connecting
+// and waiting for responses, not the transfer of books.
+func (c *KavitaClient) Connect(ctx context.Context, timeout time.Duration) error {
+	slog.Debug("Initiating Kavita connection", "host", c.Host())
+
+	c.api.connect(ctx, timeout)
+	var user struct {
+		Token string `json:"token"`
+	}
+	var err error
+	if c.apiKey != nil {
+		err = c.api.doJSON(http.MethodPost, "/api/Plugin/authenticate", url.Values{"apiKey": {string(*c.apiKey)}, "pluginName": {"BookShift"}}, nil, &user)
+	} else {
+		var password string
+		if c.password != nil {
+			password = string(*c.password)
+		}
+		err = c.api.doJSON(http.MethodPost, "/api/Account/login", nil, map[string]string{"username": c.username, "password": password}, &user)
+	}
+	if err == nil && user.Token == "" {
+		err = fmt.Errorf("no token returned")
+	}
+	if err != nil {
+		c.api.disconnect()
+		return fmt.Errorf("could not log in to Kavita: %w", err)
+	}
+
+	c.api.header.Set("Authorization", "Bearer "+user.Token)
+	return nil
+}
+
+func (c *KavitaClient) Disconnect() error {
+	slog.Debug("Disconnecting Kavita connection", "host", c.Host())
+	c.api.disconnect()
+	return nil
+}
+
+// Books returns the chapters of all series in the selected libraries,
+// collections and want-to-read list, followed by the chapters of the
+// selected reading lists.
+func (c *KavitaClient) Books(selection Selection) ([]ServerBook, error) {
+	var statements []kavitaStatement
+
+	if len(selection.Libraries) > 0 {
+		var libraries []struct {
+			ID   int    `json:"id"`
+			Name string `json:"name"`
+		}
+		if err := c.api.doJSON(http.MethodGet, "/api/Library/libraries", nil, nil, &libraries); err != nil {
+			return nil, fmt.Errorf("failed to list libraries: %w", err)
+		}
+		available := map[string]int{}
+		for _, l := range libraries {
+			available[l.Name] = l.ID
+		}
+		ids, err := resolveNames("library", selection.Libraries, available)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			statements = append(statements, kavitaStatement{Comparison: kavitaComparisonEqual, Field: kavitaFieldLibraries, Value: strconv.Itoa(id)})
+		}
+	}
+
+	if len(selection.Collections) > 0 {
+		var collections []struct {
+			ID    int    `json:"id"`
+			Title string `json:"title"`
+		}
+		if err := c.api.doJSON(http.MethodGet, "/api/Collection", nil, nil, &collections); err != nil {
+			return nil, fmt.Errorf("failed to list collections: %w", err)
+		}
+		available := map[string]int{}
+		for _, col := range collections {
+			available[col.Title] = col.ID
+		}
+		ids, err := resolveNames("collection", selection.Collections, available)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			statements = append(statements, kavitaStatement{Comparison: kavitaComparisonEqual, Field: kavitaFieldCollectionTags, Value: strconv.Itoa(id)})
+		}
+	}
+
+	if selection.WantToRead {
+		statements = append(statements, kavitaStatement{Comparison: kavitaComparisonEqual, Field: kavitaFieldWantToRead, Value: "true"})
+	}
+
+	var books []ServerBook
+	seen := map[string]bool{}
+
+	if len(statements) > 0 {
+		series, err := c.filterSeries(kavitaFilter{Statements: statements, Combination: kavitaCombinationOr})
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range series {
+			var volumes []kavitaVolume
+			if err := c.api.doJSON(http.MethodGet, "/api/Series/volumes", url.Values{"seriesId": {strconv.Itoa(s.ID)}}, nil, &volumes); err != nil {
+				return nil, fmt.Errorf("failed to list volumes of series %s: %w", s.Name, err)
+			}
+			for _, v := range volumes {
+				for _, ch := range v.Chapters {
+					if book, ok := ch.serverBook(s.Name); ok {
+						books = appendBook(books, seen, book)
+					}
+				}
+			}
+		}
+	}
+
+	if len(selection.ReadingLists) > 0 {
+		lists, err := c.readingLists()
+		if err != nil {
+			return nil, err
+		}
+		ids, err := resolveNames("reading list", selection.ReadingLists, lists)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			var items []struct {
+				ChapterID  int    `json:"chapterId"`
+				SeriesName string `json:"seriesName"`
+			}
+			if err := c.api.doJSON(http.MethodGet, "/api/ReadingList/items", url.Values{"readingListId": {strconv.Itoa(id)}}, nil, &items); err != nil {
+				return nil, fmt.Errorf("failed to list items of reading list %d: %w", id, err)
+			}
+			for _, item := range items {
+				if seen[strconv.Itoa(item.ChapterID)] {
+					continue
+				}
+				var ch kavitaChapter
+				if err := c.api.doJSON(http.MethodGet, "/api/Chapter", url.Values{"chapterId": {strconv.Itoa(item.ChapterID)}}, nil, &ch); err != nil {
+					return nil, fmt.Errorf("failed to fetch chapter %d: %w", item.ChapterID, err)
+				}
+				if book, ok := ch.serverBook(item.SeriesName); ok {
+					books = appendBook(books, seen, book)
+				}
+			}
+		}
+	}
+
+	return books, nil
+}
+
+// filterSeries pages through /api/Series/all-v2 with the given filter.
+func (c *KavitaClient) filterSeries(filter kavitaFilter) ([]kavitaSeries, error) {
+	var series []kavitaSeries
+	for page := 1; ; page++ {
+		var result []kavitaSeries
+		query := url.Values{"PageNumber": {strconv.Itoa(page)}, "PageSize": {strconv.Itoa(kavitaPageSize)}}
+		if err := c.api.doJSON(http.MethodPost, "/api/Series/all-v2", query, filter, &result); err != nil {
+			return nil, fmt.Errorf("failed to list series: %w", err)
+		}
+		series = append(series, result...)
+		if len(result) < kavitaPageSize {
+			return series, nil
+		}
+	}
+}
+
+// readingLists returns the ids of all reading lists visible to the user, by title.
+func (c *KavitaClient) readingLists() (map[string]int, error) {
+	lists := map[string]int{}
+	for page := 1; ; page++ {
+		var result []struct {
+			ID    int    `json:"id"`
+			Title string `json:"title"`
+		}
+		query := url.Values{"includePromoted": {"true"}, "PageNumber": {strconv.Itoa(page)}, "PageSize": {strconv.Itoa(kavitaPageSize)}}
+		if err := c.api.doJSON(http.MethodPost, "/api/ReadingList/lists", query, nil, &result); err != nil {
+			return nil, fmt.Errorf("failed to list reading lists: %w", err)
+		}
+		for _, rl := range result {
+			lists[rl.Title] = rl.ID
+		}
+		if len(result) < kavitaPageSize {
+			return lists, nil
+		}
+	}
+}
+
+// ReadFile streams the file of a chapter.
+func (c *KavitaClient) ReadFile(book ServerBook, w io.Writer) (int64, error) {
+	return c.api.download("/api/Download/chapter", url.Values{"chapterId": {book.ID}}, w)
+}
+
+func (c *KavitaClient) Host() string {
+	return c.api.host()
+}
+
+// serverBook converts a chapter backed by a single file. Chapters made of
+// several files (loose images) cannot be downloaded as one book.
+func (ch kavitaChapter) serverBook(series string) (ServerBook, bool) {
+	if len(ch.Files) != 1 {
+		slog.Debug("Skipping Kavita chapter without a single file", "series", series, "chapter", ch.ID, "files", len(ch.Files))
+		return ServerBook{}, false
+	}
+	fileName := baseName(ch.Files[0].FilePath)
+	title := ch.Title
+	if title == "" {
+		title = fileName
+	}
+	return ServerBook{
+		ID:       strconv.Itoa(ch.ID),
+		Series:   series,
+		Title:    title,
+		FileName: fileName,
+		Size:     ch.Files[0].Bytes,
+	}, true
+}
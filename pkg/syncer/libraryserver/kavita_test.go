@@ -0,0 +1,124 @@
+package libraryserver
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/go-playground/sensitive"
+)
+
+func newTestKavita(t *testing.T, url string) *KavitaClient {
+	t.Helper()
+	key := sensitive.String(testAPIKey)
+	c, err := NewLibraryServerClient(&config.LibraryServerConfig{Server: "kavita", URL: url, APIKey: &key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Connect(context.Background(), 5*time.Second); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Disconnect() })
+	return c.(*KavitaClient)
+}
+
+// TestKavitaClient_Connect authenticates with an API key or a password.
+func TestKavitaClient_Connect(t *testing.T) {
+	srv := newKavitaServer(t)
+	newTestKavita(t, srv.URL)
+	if !strings.Contains(srv.requests[0], "pluginName=BookShift") {
+		t.Fatalf("unexpected login request: %v", srv.requests)
+	}
+
+	password := sensitive.String("secret")
+	c := &KavitaClient{username: "reader", password: &password}
+	c.api, _ = newAPIClient(srv.URL)
+	if err := c.Connect(context.Background(), time.Second); err != nil {
+		t.Fatalf("password login: %v", err)
+	}
+	if body := srv.bodies["POST /api/Account/login"]; !strings.Contains(body, `"username":"reader"`) {
+		t.Fatalf("unexpected login body: %s", body)
+	}
+
+	bad := sensitive.String("wrong")
+	c = &KavitaClient{apiKey: &bad}
+	c.api, _ = newAPIClient(srv.URL)
+	if err := c.Connect(context.Background(), time.Second); err == nil || !strings.Contains(err.Error(), "could not log in to Kavita") {
+		t.Fatalf("expected login error, got %v", err)
+	}
+	if _, err := c.Books(Selection{Libraries: []string{"Comics"}}); err == nil {
+		t.Fatalf("expected error after failed login")
+	}
+}
+
+// TestKavitaClient_Books combines libraries, collections, want-to-read and reading lists.
+func TestKavitaClient_Books(t *testing.T) {
+	srv := newKavitaServer(t)
+	c := newTestKavita(t, srv.URL)
+
+	books, err := c.Books(Selection{Libraries: []string{"comics"}, Collections: []string{"Favourites"}, WantToRead: true, ReadingLists: []string{"summer reading"}})
+	if err != nil {
+		t.Fatalf("books: %v", err)
+	}
+	var got []string
+	for _, b := range books {
+		got = append(got, b.ID+":"+b.Series+":"+b.FileName)
+	}
+	// Saga is selected twice (library and collection) and chapter 2 again via the reading list;
+	// chapter 4 consists of loose images and is skipped
+	want := "1:Saga:Saga 01.cbz,2:Saga:Saga 02.cbz,3:Berserk:Berserk v01.cbr"
+	if strings.Join(got, ",") != want {
+		t.Fatalf("unexpected books:\n got %s\nwant %s", strings.Join(got, ","), want)
+	}
+
+	body := srv.bodies["POST /api/Series/all-v2"]
+	for _, part := range []string{`"field":19,"value":"1"`, `"field":7,"value":"5"`, `"field":26,"value":"true"`, `"combination":0`} {
+		if !strings.Contains(body, part) {
+			t.Fatalf("filter %s misses %s", body, part)
+		}
+	}
+
+	if _, err := c.Books(Selection{Libraries: []string{"Books"}}); err == nil {
+		t.Fatalf("expected unknown library error")
+	}
+	if _, err := c.Books(Selection{Collections: []string{"Nope"}}); err == nil {
+		t.Fatalf("expected unknown collection error")
+	}
+	if _, err := c.Books(Selection{ReadingLists: []string{"Nope"}}); err == nil {
+		t.Fatalf("expected unknown reading list error")
+	}
+}
+
+// TestKavitaClient_Paging requests further pages while they are full.
+func TestKavitaClient_Paging(t *testing.T) {
+	old := kavitaPageSize
+	t.Cleanup(func() { kavitaPageSize = old })
+	kavitaPageSize = 1
+
+	srv := newKavitaServer(t)
+	c := newTestKavita(t, srv.URL)
+	if _, err := c.Books(Selection{Libraries: []string{"Comics"}}); err != nil {
+		t.Fatalf("books: %v", err)
+	}
+	pages := 0
+	for _, r := range srv.requests {
+		if strings.HasPrefix(r, "POST /api/Series/all-v2") {
+			pages++
+		}
+	}
+	if pages != 2 {
+		t.Fatalf("expected 2 page requests, got %d: %v", pages, srv.requests)
+	}
+}
+
+// TestKavitaClient_ReadFile downloads a chapter.
+func TestKavitaClient_ReadFile(t *testing.T) {
+	c := newTestKavita(t, newKavitaServer(t).URL)
+	var buf bytes.Buffer
+	if n, err := c.ReadFile(ServerBook{ID: "3"}, &buf); err != nil || n != 5 || buf.String() != "CH3!!" {
+		t.Fatalf("unexpected download: %d %q %v", n, buf.String(), err)
+	}
+}
@@ -0,0 +1,187 @@
+package libraryserver
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/go-playground/sensitive"
+)
+
+// KomgaClient talks to the Komga REST API (/api/v1). It authenticates with
+// an API key (X-API-Key) or with basic authentication.
+type KomgaClient struct {
+	api      *apiClient
+	apiKey   *sensitive.String
+	username string
+	password *sensitive.String
+}
+
+// komgaBook mirrors the subset of a Komga book that is used.
+type komgaBook struct {
+	ID          string `json:"id"`
+	SeriesTitle string `json:"seriesTitle"`
+	Name        string `json:"name"`
+	URL         string `json:"url"`
+	SizeBytes   int64  `json:"sizeBytes"`
+	Metadata    struct {
+		Title string `json:"title"`
+	} `json:"metadata"`
+}
+
+// komgaPage is a page of a Komga list response; lists are requested unpaged.
+type komgaPage[T any] struct {
+	Content []T `json:"content"`
+}
+
+// Connect prepares the HTTP client and verifies the credentials. The timeout
+// bounds connecting and waiting for responses, not the transfer of books.
+func (c *KomgaClient) Connect(ctx context.Context, timeout time.Duration) error {
+	slog.Debug("Initiating Komga connection", "host", c.Host())
+
+	c.api.connect(ctx, timeout)
+	switch {
+	case c.apiKey != nil:
+		c.api.header.Set("X-API-Key", string(*c.apiKey))
+	case c.username != "":
+		var password string
+		if c.password != nil {
+			password = string(*c.password)
+		}
+		c.api.header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(c.username+":"+password)))
+	}
+
+	if err := c.api.doJSON(http.MethodGet, "/api/v1/users/me", nil, nil, nil); err != nil {
+		c.api.disconnect()
+		return fmt.Errorf("could not log in to Komga: %w", err)
+	}
+	return nil
+}
+
+func (c *KomgaClient) Disconnect() error {
+	slog.Debug("Disconnecting Komga connection", "host", c.Host())
+	c.api.disconnect()
+	return nil
+}
+
+// Books returns the books in the selected libraries, collections and read lists.
+func (c *KomgaClient) Books(selection Selection) ([]ServerBook, error) {
+	if selection.WantToRead {
+		return nil, fmt.Errorf("want_to_read is only supported by Kavita")
+	}
+
+	var books []ServerBook
+	seen := map[string]bool{}
+	add := func(list []komgaBook) {
+		for _, b := range list {
+			books = appendBook(books, seen, b.serverBook())
+		}
+	}
+
+	if len(selection.Libraries) > 0 {
+		var libraries []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		}
+		if err := c.api.doJSON(http.MethodGet, "/api/v1/libraries", nil, nil, &libraries); err != nil {
+			return nil, fmt.Errorf("failed to list libraries: %w", err)
+		}
+		available := map[string]string{}
+		for _, l := range libraries {
+			available[l.Name] = l.ID
+		}
+		ids, err := resolveNames("library", selection.Libraries, available)
+		if err != nil {
+			return nil, err
+		}
+		var page komgaPage[komgaBook]
+		if err := c.api.doJSON(http.MethodGet, "/api/v1/books", url.Values{"library_id": ids, "unpaged": {"true"}}, nil, &page); err != nil {
+			return nil, fmt.Errorf("failed to list books: %w", err)
+		}
+		add(page.Content)
+	}
+
+	if len(selection.Collections) > 0 {
+		var collections komgaPage[struct {
+			ID        string   `json:"id"`
+			Name      string   `json:"name"`
+			SeriesIDs []string `json:"seriesIds"`
+		}]
+		if err := c.api.doJSON(http.MethodGet, "/api/v1/collections", url.Values{"unpaged": {"true"}}, nil, &collections); err != nil {
+			return nil, fmt.Errorf("failed to list collections: %w", err)
+		}
+		available := map[string][]string{}
+		for _, col := range collections.Content {
+			available[col.Name] = col.SeriesIDs
+		}
+		selected, err := resolveNames("collection", selection.Collections, available)
+		if err != nil {
+			return nil, err
+		}
+		for _, seriesIDs := range selected {
+			for _, seriesID := range seriesIDs {
+				var page komgaPage[komgaBook]
+				if err := c.api.doJSON(http.MethodGet, path.Join("/api/v1/series", seriesID, "books"), url.Values{"unpaged": {"true"}}, nil, &page); err != nil {
+					return nil, fmt.Errorf("failed to list books of series %s: %w", seriesID, err)
+				}
+				add(page.Content)
+			}
+		}
+	}
+
+	if len(selection.ReadingLists) > 0 {
+		var readLists komgaPage[struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		}]
+		if err := c.api.doJSON(http.MethodGet, "/api/v1/readlists", url.Values{"unpaged": {"true"}}, nil, &readLists); err != nil {
+			return nil, fmt.Errorf("failed to list read lists: %w", err)
+		}
+		available := map[string]string{}
+		for _, rl := range readLists.Content {
+			available[rl.Name] = rl.ID
+		}
+		ids, err := resolveNames("read list", selection.ReadingLists, available)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			var page komgaPage[komgaBook]
+			if err := c.api.doJSON(http.MethodGet, path.Join("/api/v1/readlists", id, "books"), url.Values{"unpaged": {"true"}}, nil, &page); err != nil {
+				return nil, fmt.Errorf("failed to list books of read list %s: %w", id, err)
+			}
+			add(page.Content)
+		}
+	}
+
+	return books, nil
+}
+
+// ReadFile streams the original file of a book.
+func (c *KomgaClient) ReadFile(book ServerBook, w io.Writer) (int64, error) {
+	return c.api.download(path.Join("/api/v1/books", book.ID, "file"), nil, w)
+}
+
+func (c *KomgaClient) Host() string {
+	return c.api.host()
+}
+
+func (b komgaBook) serverBook() ServerBook {
+	title := b.Metadata.Title
+	if title == "" {
+		title = b.Name
+	}
+	return ServerBook{
+		ID:       b.ID,
+		Series:   b.SeriesTitle,
+		Title:    title,
+		FileName: baseName(b.URL),
+		Size:     b.SizeBytes,
+	}
+}
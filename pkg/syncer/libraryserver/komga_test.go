@@ -0,0 +1,86 @@
+package libraryserver
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/go-playground/sensitive"
+)
+
+func newTestKomga(t *testing.T, url string) *KomgaClient {
+	t.Helper()
+	key := sensitive.String(testAPIKey)
+	c, err := NewLibraryServerClient(&config.LibraryServerConfig{Server: "komga", URL: url, APIKey: &key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Connect(context.Background(), 5*time.Second); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Disconnect() })
+	return c.(*KomgaClient)
+}
+
+// TestKomgaClient_Connect authenticates with an API key or basic auth.
+func TestKomgaClient_Connect(t *testing.T) {
+	srv := newKomgaServer(t)
+	newTestKomga(t, srv.URL)
+
+	password := sensitive.String("secret")
+	c := &KomgaClient{username: "reader", password: &password}
+	c.api, _ = newAPIClient(srv.URL)
+	if err := c.Connect(context.Background(), time.Second); err != nil {
+		t.Fatalf("basic auth: %v", err)
+	}
+
+	wrong := sensitive.String("wrong")
+	c = &KomgaClient{username: "reader", password: &wrong}
+	c.api, _ = newAPIClient(srv.URL)
+	if err := c.Connect(context.Background(), time.Second); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("expected unauthorized error, got %v", err)
+	}
+}
+
+// TestKomgaClient_Books combines libraries, collections and read lists without duplicates.
+func TestKomgaClient_Books(t *testing.T) {
+	srv := newKomgaServer(t)
+	c := newTestKomga(t, srv.URL)
+
+	books, err := c.Books(Selection{Libraries: []string{"comics"}, Collections: []string{"Favourites"}, ReadingLists: []string{"Summer Reading"}})
+	if err != nil {
+		t.Fatalf("books: %v", err)
+	}
+	var got []string
+	for _, b := range books {
+		got = append(got, b.ID+":"+b.Series+":"+b.Title+":"+b.FileName)
+	}
+	want := "B1:Saga:Saga 01:Saga 01.cbz,B2:Saga:Saga 02:Saga 02.cbz,B3:Monstress:Monstress 01:Monstress 01.cbz,B4:Paper Girls::pg.pdf"
+	if strings.Join(got, ",") != want {
+		t.Fatalf("unexpected books:\n got %s\nwant %s", strings.Join(got, ","), want)
+	}
+
+	if _, err := c.Books(Selection{WantToRead: true}); err == nil || !strings.Contains(err.Error(), "only supported by Kavita") {
+		t.Fatalf("expected want_to_read error, got %v", err)
+	}
+	for _, sel := range []Selection{{Libraries: []string{"Nope"}}, {Collections: []string{"Nope"}}, {ReadingLists: []string{"Nope"}}} {
+		if _, err := c.Books(sel); err == nil {
+			t.Fatalf("expected not found error for %+v", sel)
+		}
+	}
+}
+
+// TestKomgaClient_ReadFile downloads the original book file.
+func TestKomgaClient_ReadFile(t *testing.T) {
+	c := newTestKomga(t, newKomgaServer(t).URL)
+	var buf bytes.Buffer
+	if _, err := c.ReadFile(ServerBook{ID: "B2"}, &buf); err != nil || buf.String() != "B2!!" {
+		t.Fatalf("unexpected download: %q %v", buf.String(), err)
+	}
+	if _, err := c.ReadFile(ServerBook{ID: "X9"}, &buf); err == nil {
+		t.Fatalf("expected download error")
+	}
+}
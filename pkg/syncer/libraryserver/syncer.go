@@ -0,0 +1,122 @@
+package libraryserver
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// libraryServerState is persisted between runs to remember downloaded books.
+type libraryServerState struct {
+	Fetched []string `json:"fetched"`
+}
+
+type LibraryServerSyncer struct {
+	config *config.LibraryServerConfig
+}
+
+func NewLibraryServerSyncer(serverConfig *config.LibraryServerConfig) *LibraryServerSyncer {
+	return &LibraryServerSyncer{
+		config: serverConfig,
+	}
+}
+
+func (s *LibraryServerSyncer) Run(targetFolder string, validExtensions []string, overwriteExistingFiles bool) error {
+	return s.RunContext(context.Background(), targetFolder, validExtensions, overwriteExistingFiles)
+}
+
+func (s *LibraryServerSyncer) RunContext(ctx context.Context, targetFolder string, validExtensions []string, overwriteExistingFiles bool) (err error) {
+	if s.config.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(s.config.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	selection := Selection{
+		Libraries:    s.config.Libraries,
+		Collections:  s.config.Collections,
+		ReadingLists: s.config.ReadingLists,
+		WantToRead:   s.config.WantToRead,
+	}
+	if len(selection.Libraries) == 0 && len(selection.Collections) == 0 && len(selection.ReadingLists) == 0 && !selection.WantToRead {
+		return fmt.Errorf("no libraries, collections, reading lists or want_to_read configured for library server %s", s.config.URL)
+	}
+
+	// Load the books downloaded during previous runs
+	statePath := s.config.StateFile
+	if statePath == "" {
+		statePath = util.DefaultStatePath(targetFolder, s.config.Server, s.config.URL)
+	}
+	var state libraryServerState
+	if err := util.LoadState(statePath, &state); err != nil {
+		return fmt.Errorf("could not load library server state from %s: %w", statePath, err)
+	}
+	fetched := map[string]bool{}
+	for _, id := range state.Fetched {
+		fetched[id] = true
+	}
+
+	// Connect to the library server
+	serverClient, err := newLibraryServerClient(s.config)
+	if err != nil {
+		return err
+	}
+	if err := libraryServerConnect(ctx, serverClient, 60*time.Second); err != nil {
+		return fmt.Errorf("could not connect to library server %s: %w", s.config.URL, err)
+	}
+	defer serverClient.Disconnect()
+
+	books, err := libraryServerBooks(serverClient, selection)
+	if err != nil {
+		return fmt.Errorf("could not list books on library server %s: %w", s.config.URL, err)
+	}
+	slog.Info("Found books on library server", "host", serverClient.Host(), "count", len(books))
+
+	// The state is saved even when a later book fails
+	defer func() {
+		if saveErr := util.SaveState(statePath, &state); saveErr != nil && err == nil {
+			err = fmt.Errorf("could not save library server state to %s: %w", statePath, saveErr)
+		}
+	}()
+
+	// Download all books that were not fetched before
+	for _, sb := range books {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		if fetched[sb.ID] {
+			slog.Debug("Skipping previously downloaded book", "id", sb.ID, "title", sb.Title)
+			continue
+		}
+		extension := filepath.Ext(sb.FileName)
+		if len(validExtensions) > 0 && !slices.ContainsFunc(validExtensions, func(e string) bool { return strings.EqualFold(e, extension) }) {
+			slog.Debug("Skipping book with invalid extension", "id", sb.ID, "file", sb.FileName)
+			continue
+		}
+
+		book := NewLibraryServerBook(sb, s.config.KeepFolderStructure, serverClient)
+		if err := libraryServerDownload(book, targetFolder, overwriteExistingFiles); err != nil {
+			return err
+		}
+
+		fetched[sb.ID] = true
+		state.Fetched = append(state.Fetched, sb.ID)
+	}
+
+	return nil
+}
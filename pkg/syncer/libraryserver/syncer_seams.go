@@ -0,0 +1,27 @@
+// syncer_seams.go: Test seams for the library server syncer.
+//
+// This file defines small function variables that production code uses by default,
+// but tests can override to substitute fakes. Keeping these seams in a separate
+// file keeps the main implementation clean while preserving simple overrides in tests.
+package libraryserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+// test hooks (seams) for dependency injection in tests
+var (
+	newLibraryServerClient = func(cfg *config.LibraryServerConfig) (LibraryServerAPI, error) {
+		return NewLibraryServerClient(cfg)
+	}
+	libraryServerConnect = func(ctx context.Context, c LibraryServerAPI, timeout time.Duration) error {
+		return c.Connect(ctx, timeout)
+	}
+	libraryServerBooks    = func(c LibraryServerAPI, sel Selection) ([]ServerBook, error) { return c.Books(sel) }
+	libraryServerDownload = func(b *LibraryServerBook, dst string, overwrite bool) error {
+		return b.Download(dst, overwrite)
+	}
+)
@@ -0,0 +1,132 @@
+package libraryserver
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+	"github.com/go-playground/sensitive"
+)
+
+func withFakeServer(t *testing.T, f *fakeServer) {
+	t.Helper()
+	orig := newLibraryServerClient
+	t.Cleanup(func() { newLibraryServerClient = orig })
+	newLibraryServerClient = func(cfg *config.LibraryServerConfig) (LibraryServerAPI, error) { return f, nil }
+}
+
+// TestLibraryServerSyncer_Run_EndToEnd downloads from a Komga stand-in and skips books fetched before.
+func TestLibraryServerSyncer_Run_EndToEnd(t *testing.T) {
+	srv := newKomgaServer(t)
+	key := sensitive.String(testAPIKey)
+	cfg := &config.LibraryServerConfig{Server: "komga", URL: srv.URL, APIKey: &key, ReadingLists: []string{"Summer reading"}}
+
+	dst := t.TempDir()
+	if err := NewLibraryServerSyncer(cfg).Run(dst, []string{".cbz"}, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dst, util.SafeFileName("Saga 02.cbz"))); err != nil || string(data) != "B2!!" {
+		t.Fatalf("unexpected file: %q %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "pg.pdf")); !os.IsNotExist(err) {
+		t.Fatalf("book with invalid extension was downloaded")
+	}
+
+	// The state remembers the download, even after the file is removed
+	_ = os.Remove(filepath.Join(dst, util.SafeFileName("Saga 02.cbz")))
+	if err := NewLibraryServerSyncer(cfg).Run(dst, []string{".cbz"}, false); err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, util.SafeFileName("Saga 02.cbz"))); !os.IsNotExist(err) {
+		t.Fatalf("previously fetched book downloaded again")
+	}
+
+	// Adding an extension picks up the skipped book
+	if err := NewLibraryServerSyncer(cfg).Run(dst, []string{".cbz", ".PDF"}, false); err != nil {
+		t.Fatalf("third run: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "pg.pdf")); err != nil {
+		t.Fatalf("expected pdf after adding the extension: %v", err)
+	}
+}
+
+// TestLibraryServerSyncer_Run_StateAfterFailure keeps books downloaded before a failure.
+func TestLibraryServerSyncer_Run_StateAfterFailure(t *testing.T) {
+	f := &fakeServer{
+		books:   []ServerBook{{ID: "1", FileName: "a.cbz"}, {ID: "2", FileName: "b.cbz"}},
+		content: map[string]string{"1": "A"},
+	}
+	withFakeServer(t, f)
+	cfg := &config.LibraryServerConfig{Server: "kavita", URL: "http://kavita.local", WantToRead: true, StateFile: filepath.Join(t.TempDir(), "state.json")}
+
+	if err := NewLibraryServerSyncer(cfg).Run(t.TempDir(), nil, false); err == nil || !strings.Contains(err.Error(), "book 2 not found") {
+		t.Fatalf("expected download error, got %v", err)
+	}
+	if !f.selection.WantToRead {
+		t.Fatalf("selection not passed on: %+v", f.selection)
+	}
+	var state libraryServerState
+	if err := util.LoadState(cfg.StateFile, &state); err != nil || strings.Join(state.Fetched, ",") != "1" {
+		t.Fatalf("unexpected state: %+v %v", state, err)
+	}
+}
+
+// TestLibraryServerSyncer_Run_Errors covers configuration, connection and listing errors.
+func TestLibraryServerSyncer_Run_Errors(t *testing.T) {
+	cfg := &config.LibraryServerConfig{Server: "komga", URL: "http://komga.local"}
+	if err := NewLibraryServerSyncer(cfg).Run(t.TempDir(), nil, false); err == nil || !strings.Contains(err.Error(), "no libraries") {
+		t.Fatalf("expected selection error, got %v", err)
+	}
+
+	cfg.Libraries = []string{"Comics"}
+	f := &fakeServer{connectErr: errors.New("refused")}
+	withFakeServer(t, f)
+	if err := NewLibraryServerSyncer(cfg).Run(t.TempDir(), nil, false); err == nil || !strings.Contains(err.Error(), "could not connect") {
+		t.Fatalf("expected connect error, got %v", err)
+	}
+	f.connectErr, f.booksErr = nil, errors.New("boom")
+	if err := NewLibraryServerSyncer(cfg).Run(t.TempDir(), nil, false); err == nil || !strings.Contains(err.Error(), "could not list books") {
+		t.Fatalf("expected listing error, got %v", err)
+	}
+
+	dst := t.TempDir()
+	cfg.StateFile = filepath.Join(dst, "state.json")
+	if err := os.WriteFile(cfg.StateFile, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewLibraryServerSyncer(cfg).Run(dst, nil, false); err == nil || !strings.Contains(err.Error(), "could not load") {
+		t.Fatalf("expected state error, got %v", err)
+	}
+}
+
+// TestLibraryServerSyncer_Run_Cancelled stops before and between books.
+func TestLibraryServerSyncer_Run_Cancelled(t *testing.T) {
+	f := &fakeServer{books: []ServerBook{{ID: "1", FileName: "a.cbz"}, {ID: "2", FileName: "b.cbz"}}}
+	withFakeServer(t, f)
+	cfg := &config.LibraryServerConfig{Server: "kavita", URL: "http://kavita.local", Libraries: []string{"Comics"}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := NewLibraryServerSyncer(cfg).RunContext(ctx, t.TempDir(), nil, false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	orig := libraryServerDownload
+	t.Cleanup(func() { libraryServerDownload = orig })
+	downloads := 0
+	libraryServerDownload = func(b *LibraryServerBook, dst string, overwrite bool) error {
+		downloads++
+		cancel()
+		return nil
+	}
+	if err := NewLibraryServerSyncer(cfg).RunContext(ctx, t.TempDir(), nil, false); !errors.Is(err, context.Canceled) || downloads != 1 {
+		t.Fatalf("expected cancellation after one book, got %v after %d", err, downloads)
+	}
+}
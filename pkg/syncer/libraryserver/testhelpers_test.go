@@ -0,0 +1,213 @@
+package libraryserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testAPIKey = "api-key"
+	testToken  = "jwt-token"
+)
+
+// recordingServer replays canned API responses keyed by "METHOD /path" and
+// records every request it receives.
+type recordingServer struct {
+	URL string
+
+	mu       sync.Mutex
+	requests []string
+	bodies   map[string]string
+}
+
+func (s *recordingServer) record(r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery)
+	if r.Body != nil {
+		body, _ := io.ReadAll(r.Body)
+		if len(body) > 0 {
+			s.bodies[r.Method+" "+r.URL.Path] = string(body)
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// newKavitaServer is an httptest Kavita stand-in with two libraries, a
+// collection, a reading list and a want-to-read list.
+func newKavitaServer(t *testing.T) *recordingServer {
+	t.Helper()
+	s := &recordingServer{bodies: map[string]string{}}
+
+	chapter := func(id int, file string) map[string]any {
+		return map[string]any{"id": id, "titleName": "", "files": []map[string]any{{"filePath": file, "bytes": 4}}}
+	}
+	volumes := map[string]any{
+		"10": []map[string]any{{"id": 100, "chapters": []map[string]any{chapter(1, "/comics/Saga/Saga 01.cbz"), chapter(2, "/comics/Saga/Saga 02.cbz")}}},
+		"20": []map[string]any{{"id": 200, "chapters": []map[string]any{
+			chapter(3, `D:\manga\Berserk\Berserk v01.cbr`),
+			{"id": 4, "files": []map[string]any{{"filePath": "/manga/p1.jpg"}, {"filePath": "/manga/p2.jpg"}}},
+		}}},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		s.record(r)
+		if r.URL.Path == "/api/Plugin/authenticate" {
+			if r.Method != http.MethodPost || r.URL.Query().Get("apiKey") != testAPIKey {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			writeJSON(w, map[string]string{"username": "reader", "token": testToken})
+			return
+		}
+		if r.URL.Path == "/api/Account/login" {
+			writeJSON(w, map[string]string{"token": testToken})
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer "+testToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/Library/libraries":
+			writeJSON(w, []map[string]any{{"id": 1, "name": "Comics"}, {"id": 2, "name": "Manga"}})
+		case "GET /api/Collection":
+			writeJSON(w, []map[string]any{{"id": 5, "title": "Favourites"}})
+		case "POST /api/Series/all-v2":
+			var filter kavitaFilter
+			_ = json.NewDecoder(strings.NewReader(s.bodies["POST /api/Series/all-v2"])).Decode(&filter)
+			var series []map[string]any
+			for _, st := range filter.Statements {
+				switch {
+				case st.Field == kavitaFieldLibraries && st.Value == "1", st.Field == kavitaFieldCollectionTags && st.Value == "5":
+					series = append(series, map[string]any{"id": 10, "name": "Saga"})
+				case st.Field == kavitaFieldLibraries && st.Value == "2", st.Field == kavitaFieldWantToRead:
+					series = append(series, map[string]any{"id": 20, "name": "Berserk"})
+				}
+			}
+			if r.URL.Query().Get("PageNumber") != "1" {
+				series = nil
+			}
+			writeJSON(w, series)
+		case "GET /api/Series/volumes":
+			writeJSON(w, volumes[r.URL.Query().Get("seriesId")])
+		case "POST /api/ReadingList/lists":
+			writeJSON(w, []map[string]any{{"id": 7, "title": "Summer reading"}})
+		case "GET /api/ReadingList/items":
+			writeJSON(w, []map[string]any{{"chapterId": 2, "seriesName": "Saga"}, {"chapterId": 3, "seriesName": "Berserk"}})
+		case "GET /api/Chapter":
+			switch r.URL.Query().Get("chapterId") {
+			case "2":
+				writeJSON(w, chapter(2, "/comics/Saga/Saga 02.cbz"))
+			case "3":
+				writeJSON(w, chapter(3, `D:\manga\Berserk\Berserk v01.cbr`))
+			default:
+				http.NotFound(w, r)
+			}
+		case "GET /api/Download/chapter":
+			_, _ = io.WriteString(w, "CH"+r.URL.Query().Get("chapterId")+"!!")
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	s.URL = srv.URL
+	return s
+}
+
+// newKomgaServer is an httptest Komga stand-in with a library, a collection and a read list.
+func newKomgaServer(t *testing.T) *recordingServer {
+	t.Helper()
+	s := &recordingServer{bodies: map[string]string{}}
+
+	book := func(id, series, name, url string) map[string]any {
+		return map[string]any{"id": id, "seriesTitle": series, "name": name, "url": url, "sizeBytes": 4, "metadata": map[string]any{"title": name}}
+	}
+	page := func(content ...map[string]any) map[string]any { return map[string]any{"content": content} }
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		s.record(r)
+		user, pass, basic := r.BasicAuth()
+		if r.Header.Get("X-API-Key") != testAPIKey && !(basic && user == "reader" && pass == "secret") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/api/v1/users/me":
+			writeJSON(w, map[string]any{"email": "reader@example.com"})
+		case "/api/v1/libraries":
+			writeJSON(w, []map[string]any{{"id": "L1", "name": "Comics"}})
+		case "/api/v1/books":
+			if r.URL.Query().Get("library_id") != "L1" || r.URL.Query().Get("unpaged") != "true" {
+				writeJSON(w, page())
+				return
+			}
+			writeJSON(w, page(book("B1", "Saga", "Saga 01", "/data/comics/Saga/Saga 01.cbz"), book("B2", "Saga", "Saga 02", "/data/comics/Saga/Saga 02.cbz")))
+		case "/api/v1/collections":
+			writeJSON(w, page(map[string]any{"id": "C1", "name": "Favourites", "seriesIds": []string{"S2"}}))
+		case "/api/v1/series/S2/books":
+			writeJSON(w, page(book("B3", "Monstress", "Monstress 01", "/data/comics/Monstress/Monstress 01.cbz")))
+		case "/api/v1/readlists":
+			writeJSON(w, page(map[string]any{"id": "R1", "name": "Summer reading"}))
+		case "/api/v1/readlists/R1/books":
+			writeJSON(w, page(book("B2", "Saga", "Saga 02", "/data/comics/Saga/Saga 02.cbz"), book("B4", "Paper Girls", "", "/data/comics/Paper Girls/pg.pdf")))
+		default:
+			if id, ok := strings.CutPrefix(r.URL.Path, "/api/v1/books/"); ok && strings.HasPrefix(id, "B") && strings.HasSuffix(id, "/file") {
+				_, _ = io.WriteString(w, strings.TrimSuffix(id, "/file")+"!!")
+				return
+			}
+			http.NotFound(w, r)
+		}
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	s.URL = srv.URL
+	return s
+}
+
+// fakeServer is an in-memory LibraryServerAPI used by book and syncer tests.
+type fakeServer struct {
+	books      []ServerBook
+	content    map[string]string
+	connectErr error
+	booksErr   error
+
+	selection Selection
+	read      []string
+}
+
+func (f *fakeServer) Connect(ctx context.Context, timeout time.Duration) error { return f.connectErr }
+func (f *fakeServer) Disconnect() error                                        { return nil }
+func (f *fakeServer) Books(selection Selection) ([]ServerBook, error) {
+	f.selection = selection
+	return f.books, f.booksErr
+}
+func (f *fakeServer) ReadFile(book ServerBook, w io.Writer) (int64, error) {
+	f.read = append(f.read, book.ID)
+	content, ok := f.content[book.ID]
+	if !ok {
+		return 0, fmt.Errorf("book %s not found", book.ID)
+	}
+	n, err := io.WriteString(w, content)
+	return int64(n), err
+}
+func (f *fakeServer) Host() string { return "library.example" }
@@ -0,0 +1,150 @@
+package local
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+type LocalFile struct {
+	localFolder *LocalFolder
+	localFile   fs.FileInfo
+
+	rootFolder string
+	subFolder  string
+	sourcePath string
+}
+
+func NewLocalFile(rootFolder string, subFolder string, file fs.FileInfo, localFolder *LocalFolder) *LocalFile {
+	return &LocalFile{
+		localFolder: localFolder,
+		localFile:   file,
+
+		rootFolder: rootFolder,
+		subFolder:  subFolder,
+		sourcePath: filepath.Join(rootFolder, subFolder, file.Name()),
+	}
+}
+
+func (f *LocalFile) Download(dstFolder string, dstFileName string, overwriteExistingFile bool, keepFolderStructure bool, deleteSourceFile bool) error {
+	// Create folder structure if required
+	if keepFolderStructure {
+		dstFolder = filepath.Join(dstFolder, f.subFolder)
+	}
+
+	// If no destination filename is provided, use the source file name by default
+	if dstFileName == "" {
+		dstFileName = f.localFile.Name()
+	}
+	safeFileName := util.SafeFileName(dstFileName)
+	dstPath := filepath.Join(dstFolder, safeFileName)
+
+	// Create folder structure if required
+	if _, err := os.Stat(dstFolder); os.IsNotExist(err) {
+		if util.DryRun {
+			slog.Info("[dry-run] Would create local folder", "folder", dstFolder)
+			return nil
+		}
+		slog.Info("Creating local folder", "folder", dstFolder)
+		if err := os.MkdirAll(dstFolder, 0755); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("Copying local file", "file", f.sourcePath, "destination", dstPath)
+
+	// Check if the file already exists
+	_, err := os.Stat(dstPath)
+	if !os.IsNotExist(err) {
+		if !overwriteExistingFile {
+			slog.Warn("File already exists, skipping copy", "file", dstPath)
+			return nil
+		}
+
+		slog.Info("Overwriting existing file", "file", dstPath)
+	}
+
+	if util.DryRun {
+		if deleteSourceFile {
+			slog.Info("[dry-run] Would move file", "source", f.sourcePath, "destination", dstPath)
+		} else {
+			slog.Info("[dry-run] Would copy file", "source", f.sourcePath, "destination", dstPath)
+		}
+		return nil
+	}
+
+	// A file that is removed afterwards can simply be moved when both paths
+	// are on the same filesystem
+	if deleteSourceFile {
+		err := localRename(f.sourcePath, dstPath)
+		if err == nil {
+			slog.Info("Successfully moved file", "filename", safeFileName)
+			return nil
+		}
+		if !errors.Is(err, syscall.EXDEV) {
+			return err
+		}
+		slog.Debug("Source and target are on different filesystems, copying instead", "file", f.sourcePath)
+	}
+
+	if err := f.copyTo(dstFolder, dstPath); err != nil {
+		return err
+	}
+
+	// Delete the source file if requested
+	if deleteSourceFile {
+		if err := f.Delete(); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("Successfully copied file", "filename", safeFileName)
+	return nil
+}
+
+// copyTo copies the source file to dstPath through a synced temporary file.
+func (f *LocalFile) copyTo(dstFolder string, dstPath string) error {
+	src, err := os.Open(f.sourcePath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmpFile, err := os.CreateTemp(dstFolder, "bookshift-")
+	if err != nil {
+		return err
+	}
+	defer tmpFile.Close()
+
+	slog.Debug("Copying to temporary file", "file", tmpFile.Name())
+	writer := util.NewFileWriter(tmpFile, f.localFile.Size(), true)
+	if _, err := io.Copy(writer, src); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+
+	if err := tmpFile.Sync(); err != nil { // ensure data flushed before rename
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	if err := os.Rename(tmpFile.Name(), dstPath); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	return nil
+}
+
+func (f *LocalFile) Delete() error {
+	if err := os.Remove(f.sourcePath); err != nil {
+		return fmt.Errorf("failed to delete the file %s: (%w)", f.sourcePath, err)
+	}
+	slog.Info("Deleted source file", "file", f.sourcePath)
+	return nil
+}
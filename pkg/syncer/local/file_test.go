@@ -0,0 +1,124 @@
+package local
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+func fetchOne(t *testing.T, src string) *LocalFile {
+	t.Helper()
+	files, err := NewLocalFolder(src, "").FetchFiles(src, nil, true)
+	if err != nil || len(files) != 1 {
+		t.Fatalf("fetch: %v %d", err, len(files))
+	}
+	return &files[0]
+}
+
+// TestLocalFile_Download_Copy keeps the source and honours keep_folderstructure.
+func TestLocalFile_Download_Copy(t *testing.T) {
+	src := t.TempDir()
+	p := writeFile(t, src, "sub/My Book.epub", "DATA")
+	dst := t.TempDir()
+
+	if err := fetchOne(t, src).Download(dst, "", false, true, false); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if got := readFile(t, filepath.Join(dst, "sub", "my-book.epub")); got != "DATA" {
+		t.Fatalf("unexpected content %q", got)
+	}
+	if _, err := os.Stat(p); err != nil {
+		t.Fatalf("source must be kept: %v", err)
+	}
+}
+
+// TestLocalFile_Download_Move renames the file when the source is removed.
+func TestLocalFile_Download_Move(t *testing.T) {
+	src := t.TempDir()
+	p := writeFile(t, src, "a.epub", "DATA")
+	dst := t.TempDir()
+
+	renamed := false
+	orig := localRename
+	t.Cleanup(func() { localRename = orig })
+	localRename = func(from, to string) error { renamed = true; return os.Rename(from, to) }
+
+	if err := fetchOne(t, src).Download(dst, "", false, false, true); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if !renamed {
+		t.Fatalf("expected rename")
+	}
+	if got := readFile(t, filepath.Join(dst, "a.epub")); got != "DATA" {
+		t.Fatalf("unexpected content %q", got)
+	}
+	if _, err := os.Stat(p); !os.IsNotExist(err) {
+		t.Fatalf("source must be removed")
+	}
+}
+
+// TestLocalFile_Download_CrossDevice falls back to copy and delete on EXDEV.
+func TestLocalFile_Download_CrossDevice(t *testing.T) {
+	src := t.TempDir()
+	p := writeFile(t, src, "a.epub", "DATA")
+	dst := t.TempDir()
+
+	orig := localRename
+	t.Cleanup(func() { localRename = orig })
+	localRename = func(from, to string) error {
+		return &os.LinkError{Op: "rename", Old: from, New: to, Err: syscall.EXDEV}
+	}
+
+	if err := fetchOne(t, src).Download(dst, "", false, false, true); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if got := readFile(t, filepath.Join(dst, "a.epub")); got != "DATA" {
+		t.Fatalf("unexpected content %q", got)
+	}
+	if _, err := os.Stat(p); !os.IsNotExist(err) {
+		t.Fatalf("source must be removed after copy")
+	}
+
+	// Other rename errors are reported
+	writeFile(t, src, "b.epub", "B")
+	localRename = func(from, to string) error { return errors.New("boom") }
+	if err := fetchOne(t, src).Download(t.TempDir(), "", false, false, true); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+// TestLocalFile_Download_ExistingAndDryRun covers skip-existing and dry-run.
+func TestLocalFile_Download_ExistingAndDryRun(t *testing.T) {
+	src := t.TempDir()
+	p := writeFile(t, src, "a.epub", "NEW")
+	dst := t.TempDir()
+	writeFile(t, dst, "a.epub", "OLD")
+
+	f := fetchOne(t, src)
+	if err := f.Download(dst, "", false, false, true); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if got := readFile(t, filepath.Join(dst, "a.epub")); got != "OLD" {
+		t.Fatalf("existing file must be kept")
+	}
+	if _, err := os.Stat(p); err != nil {
+		t.Fatalf("skipped source must be kept: %v", err)
+	}
+
+	old := util.DryRun
+	t.Cleanup(func() { util.DryRun = old })
+	util.DryRun = true
+	if err := f.Download(dst, "", true, false, true); err != nil {
+		t.Fatalf("dry-run: %v", err)
+	}
+	if got := readFile(t, filepath.Join(dst, "a.epub")); got != "OLD" {
+		t.Fatalf("dry-run must not write")
+	}
+	if _, err := os.Stat(p); err != nil {
+		t.Fatalf("dry-run must not delete: %v", err)
+	}
+}
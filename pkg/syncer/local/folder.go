@@ -0,0 +1,97 @@
+package local
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+type LocalFolder struct {
+	Folder string
+
+	// excludeFolder is never descended into, so a target folder nested
+	// inside the source folder is not picked up again.
+	excludeFolder string
+}
+
+func NewLocalFolder(folder string, excludeFolder string) *LocalFolder {
+	return &LocalFolder{
+		Folder:        folder,
+		excludeFolder: excludeFolder,
+	}
+}
+
+func (s *LocalFolder) FetchFiles(folder string, validExtensions []string, recurse bool) ([]LocalFile, error) {
+	allFiles, err := s.fetchAllFiles(folder, "", validExtensions, recurse)
+	if err != nil {
+		return nil, err
+	}
+
+	return allFiles, nil
+}
+
+func (s *LocalFolder) fetchAllFiles(rootFolder string, folder string, validExtensions []string, recurse bool) ([]LocalFile, error) {
+	var allFiles []LocalFile
+
+	if folder == "" {
+		folder = rootFolder
+	}
+
+	files, err := os.ReadDir(folder)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build a lower-cased set of valid extensions for case-insensitive match
+	lowerExts := make([]string, 0, len(validExtensions))
+	for _, e := range validExtensions {
+		lowerExts = append(lowerExts, strings.ToLower(e))
+	}
+
+	for _, file := range files {
+		fullPath := filepath.Join(folder, file.Name())
+		if recurse && file.IsDir() {
+			if s.isExcluded(fullPath) {
+				slog.Debug("Skipping target folder inside source folder", "folder", fullPath)
+				continue
+			}
+			tmpFiles, err := s.fetchAllFiles(rootFolder, fullPath, validExtensions, recurse)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list files in directory %s: %w", fullPath, err)
+			}
+			allFiles = append(allFiles, tmpFiles...)
+		} else if file.Type().IsRegular() {
+			extension := strings.ToLower(filepath.Ext(fullPath))
+			if len(lowerExts) > 0 && !slices.Contains(lowerExts, extension) {
+				continue
+			}
+
+			info, err := file.Info()
+			if err != nil {
+				return nil, err
+			}
+
+			subFolder, err := filepath.Rel(rootFolder, folder)
+			if err != nil || subFolder == "." {
+				subFolder = ""
+			}
+
+			allFiles = append(allFiles, *NewLocalFile(rootFolder, subFolder, info, s))
+		}
+	}
+
+	return allFiles, nil
+}
+
+// isExcluded reports whether folder is the excluded folder.
+func (s *LocalFolder) isExcluded(folder string) bool {
+	if s.excludeFolder == "" {
+		return false
+	}
+	a, errA := filepath.Abs(folder)
+	b, errB := filepath.Abs(s.excludeFolder)
+	return errA == nil && errB == nil && a == b
+}
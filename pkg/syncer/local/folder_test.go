@@ -0,0 +1,61 @@
+package local
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// TestLocalFolder_FetchFiles filters by extension, recurses and records sub folders.
+func TestLocalFolder_FetchFiles(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, src, "a.EPUB", "A")
+	writeFile(t, src, "sub/deep/b.kepub", "B")
+	writeFile(t, src, "c.txt", "C")
+	if err := os.Symlink(filepath.Join(src, "a.EPUB"), filepath.Join(src, "link.epub")); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := NewLocalFolder(src, "").FetchFiles(src, []string{".epub", ".kepub"}, true)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	var got []string
+	for _, f := range files {
+		got = append(got, filepath.Join(f.subFolder, f.localFile.Name()))
+	}
+	sort.Strings(got)
+	want := []string{"a.EPUB", filepath.Join("sub", "deep", "b.kepub")}
+	if len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("unexpected files: %v", got)
+	}
+
+	flat, _ := NewLocalFolder(src, "").FetchFiles(src, nil, false)
+	if len(flat) != 2 {
+		t.Fatalf("non-recursive fetch must only list top-level files, got %d", len(flat))
+	}
+}
+
+// TestLocalFolder_FetchFiles_ExcludesTarget ensures a nested target folder is skipped.
+func TestLocalFolder_FetchFiles_ExcludesTarget(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, src, "a.epub", "A")
+	writeFile(t, src, "library/a.epub", "A")
+
+	files, err := NewLocalFolder(src, filepath.Join(src, "library")).FetchFiles(src, nil, true)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if len(files) != 1 || files[0].subFolder != "" {
+		t.Fatalf("target folder must be skipped: %+v", files)
+	}
+}
+
+// TestLocalFolder_FetchFiles_Missing ensures errors propagate.
+func TestLocalFolder_FetchFiles_Missing(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+	if _, err := NewLocalFolder(missing, "").FetchFiles(missing, nil, true); err == nil {
+		t.Fatalf("expected error")
+	}
+}
@@ -0,0 +1,73 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+type LocalSyncer struct {
+	config *config.LocalConfig
+}
+
+func NewLocalSyncer(folderConfig *config.LocalConfig) *LocalSyncer {
+	return &LocalSyncer{
+		config: folderConfig,
+	}
+}
+
+func (s *LocalSyncer) Run(targetFolder string, validExtensions []string, overwriteExistingFiles bool) error {
+	return s.RunContext(context.Background(), targetFolder, validExtensions, overwriteExistingFiles)
+}
+
+func (s *LocalSyncer) RunContext(ctx context.Context, targetFolder string, validExtensions []string, overwriteExistingFiles bool) error {
+	if s.config.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(s.config.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	// Make sure the folder is available (e.g. the USB stick is mounted)
+	if info, err := os.Stat(s.config.Folder); err != nil {
+		return fmt.Errorf("could not access folder %s: %w", s.config.Folder, err)
+	} else if !info.IsDir() {
+		return fmt.Errorf("could not access folder %s: not a directory", s.config.Folder)
+	}
+
+	// Instantiate a local Folder (via hook)
+	localFolder := localNewFolder(s.config.Folder, targetFolder)
+
+	// Fetch all files in the folder
+	allFiles, err := localFetchFiles(localFolder, s.config.Folder, validExtensions, true)
+	if err != nil {
+		return fmt.Errorf("could not fetch files from folder %s: %w", s.config.Folder, err)
+	}
+
+	// Copy all files
+	for i := range allFiles {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		if err := localDownload(&allFiles[i],
+			targetFolder,
+			"",
+			overwriteExistingFiles,
+			s.config.KeepFolderStructure,
+			s.config.RemoveFilesAfterDownload,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
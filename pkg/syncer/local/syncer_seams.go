@@ -0,0 +1,21 @@
+// syncer_seams.go: Test seams for the local folder syncer.
+//
+// This file defines small function variables that production code uses by default,
+// but tests can override to substitute fakes. Keeping these seams in a separate
+// file keeps the main implementation clean while preserving simple overrides in tests.
+package local
+
+import "os"
+
+// test hooks (seams) for dependency injection in tests
+var (
+	localNewFolder  = func(folder, exclude string) *LocalFolder { return NewLocalFolder(folder, exclude) }
+	localFetchFiles = func(f *LocalFolder, folder string, valid []string, recurse bool) ([]LocalFile, error) {
+		return f.FetchFiles(folder, valid, recurse)
+	}
+	localDownload = func(lf *LocalFile, dst, name string, overwrite, keep, del bool) error {
+		return lf.Download(dst, name, overwrite, keep, del)
+	}
+	// localRename moves a file; overridden in tests to simulate cross-device moves.
+	localRename = os.Rename
+)
@@ -0,0 +1,81 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+// TestLocalSyncer_Run_EndToEnd copies matching files into the target folder.
+func TestLocalSyncer_Run_EndToEnd(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, src, "a.epub", "A")
+	writeFile(t, src, "sub/b.kepub", "B")
+	writeFile(t, src, "c.txt", "C")
+
+	dst := t.TempDir()
+	cfg := &config.LocalConfig{Folder: src, KeepFolderStructure: true, RemoveFilesAfterDownload: true}
+	if err := NewLocalSyncer(cfg).Run(dst, []string{".epub", ".kepub"}, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if got := readFile(t, filepath.Join(dst, "sub", "b.kepub")); got != "B" {
+		t.Fatalf("unexpected content %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(src, "a.epub")); !os.IsNotExist(err) {
+		t.Fatalf("expected a.epub moved")
+	}
+	if _, err := os.Stat(filepath.Join(src, "c.txt")); err != nil {
+		t.Fatalf("unmatched file must remain: %v", err)
+	}
+}
+
+// TestLocalSyncer_Run_NestedTarget ensures a target folder inside the source is not re-ingested.
+func TestLocalSyncer_Run_NestedTarget(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, src, "a.epub", "A")
+	dst := filepath.Join(src, "library")
+	writeFile(t, dst, "old.epub", "OLD")
+
+	cfg := &config.LocalConfig{Folder: src, KeepFolderStructure: true}
+	if err := NewLocalSyncer(cfg).Run(dst, nil, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "library")); !os.IsNotExist(err) {
+		t.Fatalf("target folder must not be copied into itself")
+	}
+}
+
+// TestLocalSyncer_Run_Errors ensures missing folders and copy errors abort the run.
+func TestLocalSyncer_Run_Errors(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "usb")
+	if err := NewLocalSyncer(&config.LocalConfig{Folder: missing}).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected error for missing folder")
+	}
+	notDir := writeFile(t, t.TempDir(), "file", "x")
+	if err := NewLocalSyncer(&config.LocalConfig{Folder: notDir}).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected error for a file")
+	}
+
+	src := t.TempDir()
+	writeFile(t, src, "a.epub", "A")
+	orig := localDownload
+	t.Cleanup(func() { localDownload = orig })
+	localDownload = func(lf *LocalFile, dst, name string, overwrite, keep, del bool) error { return errors.New("z") }
+	if err := NewLocalSyncer(&config.LocalConfig{Folder: src}).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected download error")
+	}
+}
+
+// TestLocalSyncer_RunContext_Cancel verifies that a canceled context stops the run.
+func TestLocalSyncer_RunContext_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cfg := &config.LocalConfig{Folder: t.TempDir()}
+	if err := NewLocalSyncer(cfg).RunContext(ctx, t.TempDir(), nil, false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled, got %v", err)
+	}
+}
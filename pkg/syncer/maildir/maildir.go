@@ -0,0 +1,91 @@
+package maildir
+
+import (
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bjw-s-labs/bookshift/pkg/mailparts"
+)
+
+// infoSeparator separates the unique name of a message from its flags ("unique:2,FS").
+const infoSeparator = ":2,"
+
+type Maildir struct {
+	Folder string
+}
+
+func NewMaildir(folder string) *Maildir {
+	return &Maildir{
+		Folder: folder,
+	}
+}
+
+// Validate checks that the folder looks like a Maildir (has cur/ and new/).
+func (m *Maildir) Validate() error {
+	for _, sub := range []string{"cur", "new"} {
+		info, err := os.Stat(filepath.Join(m.Folder, sub))
+		if err != nil {
+			return fmt.Errorf("not a Maildir: %w", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("not a Maildir: %s is not a directory", sub)
+		}
+	}
+	return nil
+}
+
+// CollectMessages returns the messages in new/ and cur/ whose To or Subject
+// header (selected by filterField) contains filterValue. With unreadOnly,
+// messages carrying the Seen flag are skipped.
+func (m *Maildir) CollectMessages(unreadOnly bool, filterField string, filterValue string) ([]*MaildirMessage, error) {
+	var filteredMessages []*MaildirMessage
+	for _, sub := range []string{"new", "cur"} {
+		entries, err := os.ReadDir(filepath.Join(m.Folder, sub))
+		if err != nil {
+			return nil, err
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+		for _, entry := range entries {
+			// Only regular files are messages; dot files are left alone
+			if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			msg := NewMaildirMessage(m.Folder, sub, entry.Name())
+			if unreadOnly && msg.HasFlag('S') {
+				continue
+			}
+
+			header, err := msg.readHeader()
+			if err != nil {
+				slog.Warn("Skipping unreadable message", "file", msg.Path(), "error", err)
+				continue
+			}
+			if !mailparts.HeaderMatches(header, filterField, filterValue) {
+				continue
+			}
+			filteredMessages = append(filteredMessages, msg)
+		}
+	}
+	return filteredMessages, nil
+}
+
+// readHeader parses only the header of the message file.
+func (mm *MaildirMessage) readHeader() (mail.Header, error) {
+	f, err := os.Open(mm.Path())
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	msg, err := mail.ReadMessage(f)
+	if err != nil {
+		return nil, err
+	}
+	return msg.Header, nil
+}
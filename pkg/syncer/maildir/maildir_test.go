@@ -0,0 +1,54 @@
+package maildir
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestMaildir_Validate requires cur/ and new/ folders.
+func TestMaildir_Validate(t *testing.T) {
+	if err := NewMaildir(newTestMaildir(t, nil)).Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if err := NewMaildir(t.TempDir()).Validate(); err == nil {
+		t.Fatalf("expected error for a plain folder")
+	}
+	root := t.TempDir()
+	_ = os.Mkdir(filepath.Join(root, "cur"), 0o755)
+	_ = os.WriteFile(filepath.Join(root, "new"), nil, 0o644)
+	if err := NewMaildir(root).Validate(); err == nil {
+		t.Fatalf("expected error when new is a file")
+	}
+}
+
+// TestMaildir_CollectMessages filters by header and Seen flag.
+func TestMaildir_CollectMessages(t *testing.T) {
+	root := newTestMaildir(t, map[string]string{
+		"new/1.host":     buildMessage("kobo@example.com", "Books", "a.epub", "A"),
+		"cur/2.host:2,S": buildMessage("kobo@example.com", "Books", "b.epub", "B"),
+		"cur/3.host:2,F": buildMessage("kobo@example.com", "Books", "c.epub", "C"),
+		"new/4.host":     buildMessage("other@example.com", "News", "d.epub", "D"),
+		"new/.hidden":    buildMessage("kobo@example.com", "Books", "e.epub", "E"),
+		"tmp/5.host":     buildMessage("kobo@example.com", "Books", "f.epub", "F"),
+		"cur/6.host:2,":  "not a message",
+	})
+	m := NewMaildir(root)
+
+	msgs, err := m.CollectMessages(true, "to", "KOBO@example.com")
+	if err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+	if len(msgs) != 2 || msgs[0].name != "1.host" || msgs[1].name != "3.host:2,F" {
+		t.Fatalf("unexpected unread messages: %+v", msgs)
+	}
+
+	msgs, err = m.CollectMessages(false, "", "")
+	if err != nil || len(msgs) != 4 {
+		t.Fatalf("unexpected messages without filter: %+v %v", msgs, err)
+	}
+
+	if _, err := NewMaildir(t.TempDir()).CollectMessages(true, "", ""); err == nil {
+		t.Fatalf("expected error for missing folders")
+	}
+}
@@ -0,0 +1,124 @@
+package maildir
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bjw-s-labs/bookshift/pkg/mailparts"
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// MaildirMessage is a single message file in the new/ or cur/ folder of a Maildir.
+type MaildirMessage struct {
+	root   string
+	sub    string
+	name   string
+	unique string
+	flags  string
+}
+
+func NewMaildirMessage(root string, sub string, name string) *MaildirMessage {
+	unique, flags, _ := strings.Cut(name, infoSeparator)
+	return &MaildirMessage{
+		root:   root,
+		sub:    sub,
+		name:   name,
+		unique: unique,
+		flags:  flags,
+	}
+}
+
+// Path returns the current location of the message file.
+func (mm *MaildirMessage) Path() string {
+	return filepath.Join(mm.root, mm.sub, mm.name)
+}
+
+// HasFlag reports whether the message carries the given Maildir flag.
+func (mm *MaildirMessage) HasFlag(flag rune) bool {
+	return strings.ContainsRune(mm.flags, flag)
+}
+
+// DownloadAttachments writes all valid attachments to dstFolder and then
+// marks the message as seen or deletes it, depending on afterDownload.
+func (mm *MaildirMessage) DownloadAttachments(dstFolder string, validExtensions []string, overwriteExistingFile bool, afterDownload string) error {
+	f, err := os.Open(mm.Path())
+	if err != nil {
+		return err
+	}
+	header, attachments, err := mailparts.ExtractAttachments(f, validExtensions)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("could not read message %s: %w", mm.Path(), err)
+	}
+	messageSender := mailparts.Sender(header)
+	messageSubject := mailparts.Subject(header)
+
+	// Create target folder if required
+	if _, err := os.Stat(dstFolder); os.IsNotExist(err) {
+		if util.DryRun {
+			slog.Info("[dry-run] Would create local folder", "folder", dstFolder)
+			return nil
+		}
+		slog.Info("Creating local folder", "folder", dstFolder)
+		if err := os.MkdirAll(dstFolder, 0755); err != nil {
+			return err
+		}
+	}
+
+	// Save the attachments
+	for _, attachment := range attachments {
+		slog.Info("Extracting email attachment", "maildir", mm.root, "sender", messageSender, "subject", messageSubject, "filename", attachment.Filename)
+
+		dstPath, err := mailparts.SaveAttachment(dstFolder, attachment.Filename, int64(len(attachment.Content)), bytes.NewReader(attachment.Content), overwriteExistingFile)
+		if err != nil {
+			return err
+		}
+		if dstPath != "" {
+			slog.Info("Successfully extracted attachment", "message", mm.unique, "filename", filepath.Base(dstPath), "path", dstPath)
+		}
+	}
+
+	switch afterDownload {
+	case "", "none":
+		return nil
+	case "seen":
+		if util.DryRun {
+			slog.Info("[dry-run] Would mark message as seen", "file", mm.Path())
+			return nil
+		}
+		return mm.MarkSeen()
+	case "delete":
+		if util.DryRun {
+			slog.Info("[dry-run] Would delete message", "file", mm.Path())
+			return nil
+		}
+		return os.Remove(mm.Path())
+	default:
+		return fmt.Errorf("unsupported maildir after_download action: %s", afterDownload)
+	}
+}
+
+// MarkSeen moves the message to cur/ and adds the Seen flag.
+func (mm *MaildirMessage) MarkSeen() error {
+	if mm.sub == "cur" && mm.HasFlag('S') {
+		return nil
+	}
+
+	flags := []rune(mm.flags)
+	if !mm.HasFlag('S') {
+		flags = append(flags, 'S')
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i] < flags[j] })
+
+	seen := NewMaildirMessage(mm.root, "cur", mm.unique+infoSeparator+string(flags))
+	if err := os.Rename(mm.Path(), seen.Path()); err != nil {
+		return err
+	}
+	*mm = *seen
+	return nil
+}
@@ -0,0 +1,94 @@
+package maildir
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// TestMaildirMessage_DownloadAndMarkSeen extracts attachments and moves the message to cur/.
+func TestMaildirMessage_DownloadAndMarkSeen(t *testing.T) {
+	root := newTestMaildir(t, map[string]string{"new/1.host": buildMessage("kobo@example.com", "Books", "Dune.epub", "DUNE")})
+	msg := NewMaildirMessage(root, "new", "1.host")
+
+	dst := filepath.Join(t.TempDir(), "books")
+	if err := msg.DownloadAttachments(dst, []string{".epub"}, false, "seen"); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if b, err := os.ReadFile(filepath.Join(dst, "dune.epub")); err != nil || string(b) != "DUNE" {
+		t.Fatalf("unexpected file: %q %v", b, err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "cur", "1.host:2,S")); err != nil {
+		t.Fatalf("expected message in cur/: %v", err)
+	}
+	if msg.Path() != filepath.Join(root, "cur", "1.host:2,S") {
+		t.Fatalf("unexpected path %s", msg.Path())
+	}
+
+	// Existing flags are kept in order and seen messages are left alone
+	other := NewMaildirMessage(root, "cur", "1.host:2,S")
+	if err := other.MarkSeen(); err != nil {
+		t.Fatalf("mark seen: %v", err)
+	}
+	root2 := newTestMaildir(t, map[string]string{"cur/2.host:2,RF": "Subject: x\r\n\r\nbody"})
+	flagged := NewMaildirMessage(root2, "cur", "2.host:2,RF")
+	if err := flagged.MarkSeen(); err != nil || flagged.name != "2.host:2,FRS" {
+		t.Fatalf("unexpected flags %q (%v)", flagged.name, err)
+	}
+}
+
+// TestMaildirMessage_DeleteAndNone covers the delete and none actions.
+func TestMaildirMessage_DeleteAndNone(t *testing.T) {
+	root := newTestMaildir(t, map[string]string{
+		"new/1.host": buildMessage("kobo@example.com", "Books", "a.epub", "A"),
+		"new/2.host": buildMessage("kobo@example.com", "Books", "b.epub", "B"),
+	})
+	dst := t.TempDir()
+
+	if err := NewMaildirMessage(root, "new", "1.host").DownloadAttachments(dst, nil, false, "delete"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "new", "1.host")); !os.IsNotExist(err) {
+		t.Fatalf("expected message to be deleted")
+	}
+
+	if err := NewMaildirMessage(root, "new", "2.host").DownloadAttachments(dst, []string{".pdf"}, false, "none"); err != nil {
+		t.Fatalf("none: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "new", "2.host")); err != nil {
+		t.Fatalf("expected message to stay: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "b.epub")); !os.IsNotExist(err) {
+		t.Fatalf("filtered attachment was written")
+	}
+
+	if err := NewMaildirMessage(root, "new", "2.host").DownloadAttachments(dst, nil, false, "archive"); err == nil {
+		t.Fatalf("expected unsupported action error")
+	}
+	if err := NewMaildirMessage(root, "new", "missing").DownloadAttachments(dst, nil, false, "none"); err == nil {
+		t.Fatalf("expected missing file error")
+	}
+}
+
+// TestMaildirMessage_DryRun leaves the Maildir and target folder untouched.
+func TestMaildirMessage_DryRun(t *testing.T) {
+	old := util.DryRun
+	t.Cleanup(func() { util.DryRun = old })
+	util.DryRun = true
+
+	root := newTestMaildir(t, map[string]string{"new/1.host": buildMessage("kobo@example.com", "Books", "a.epub", "A")})
+	dst := t.TempDir()
+	for _, action := range []string{"seen", "delete"} {
+		if err := NewMaildirMessage(root, "new", "1.host").DownloadAttachments(dst, nil, false, action); err != nil {
+			t.Fatalf("%s: %v", action, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "new", "1.host")); err != nil {
+		t.Fatalf("message changed in dry-run: %v", err)
+	}
+	if entries, _ := os.ReadDir(dst); len(entries) != 0 {
+		t.Fatalf("files written in dry-run: %v", entries)
+	}
+}
@@ -0,0 +1,75 @@
+package maildir
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+type MaildirSyncer struct {
+	config *config.MaildirConfig
+}
+
+func NewMaildirSyncer(maildirConfig *config.MaildirConfig) *MaildirSyncer {
+	if maildirConfig.AfterDownload == "" {
+		maildirConfig.AfterDownload = "seen"
+	}
+
+	return &MaildirSyncer{
+		config: maildirConfig,
+	}
+}
+
+func (s *MaildirSyncer) Run(targetFolder string, validExtensions []string, overwriteExistingFiles bool) error {
+	return s.RunContext(context.Background(), targetFolder, validExtensions, overwriteExistingFiles)
+}
+
+func (s *MaildirSyncer) RunContext(ctx context.Context, targetFolder string, validExtensions []string, overwriteExistingFiles bool) error {
+	if s.config.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(s.config.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	// Make sure the folder is a Maildir
+	maildir := maildirNew(s.config.Folder)
+	if err := maildir.Validate(); err != nil {
+		return fmt.Errorf("could not access Maildir %s: %w", s.config.Folder, err)
+	}
+
+	// Collect matching messages
+	allMessages, err := maildirCollect(maildir,
+		!s.config.ProcessReadEmails,
+		s.config.FilterField,
+		s.config.FilterValue,
+	)
+	if err != nil {
+		return fmt.Errorf("could not read Maildir %s: %w", s.config.Folder, err)
+	}
+
+	// Extract attachments for each message
+	for _, m := range allMessages {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		if err := maildirDownload(m,
+			targetFolder,
+			validExtensions,
+			overwriteExistingFiles,
+			s.config.AfterDownload,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
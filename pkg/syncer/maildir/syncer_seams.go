@@ -0,0 +1,17 @@
+// syncer_seams.go: Test seams for the Maildir syncer.
+//
+// This file defines small function variables that production code uses by default,
+// but tests can override to substitute fakes. Keeping these seams in a separate
+// file keeps the main implementation clean while preserving simple overrides in tests.
+package maildir
+
+// test hooks (seams) for dependency injection in tests
+var (
+	maildirNew     = func(folder string) *Maildir { return NewMaildir(folder) }
+	maildirCollect = func(m *Maildir, unreadOnly bool, field, value string) ([]*MaildirMessage, error) {
+		return m.CollectMessages(unreadOnly, field, value)
+	}
+	maildirDownload = func(mm *MaildirMessage, dst string, valid []string, overwrite bool, afterDownload string) error {
+		return mm.DownloadAttachments(dst, valid, overwrite, afterDownload)
+	}
+)
@@ -0,0 +1,74 @@
+package maildir
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+// TestMaildirSyncer_Run_EndToEnd extracts unread matching messages and marks
+// them seen, the default action.
+func TestMaildirSyncer_Run_EndToEnd(t *testing.T) {
+	root := newTestMaildir(t, map[string]string{
+		"new/1.host":     buildMessage("kobo@example.com", "Books", "a.epub", "A"),
+		"cur/2.host:2,S": buildMessage("kobo@example.com", "Books", "b.epub", "B"),
+		"new/3.host":     buildMessage("other@example.com", "Books", "c.epub", "C"),
+	})
+	cfg := &config.MaildirConfig{Folder: root, FilterField: "to", FilterValue: "kobo@example.com"}
+
+	dst := t.TempDir()
+	if err := NewMaildirSyncer(cfg).Run(dst, []string{".epub"}, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	entries, _ := os.ReadDir(dst)
+	if len(entries) != 1 || entries[0].Name() != "a.epub" {
+		t.Fatalf("unexpected files: %v", entries)
+	}
+	if _, err := os.Stat(filepath.Join(root, "cur", "1.host:2,S")); err != nil {
+		t.Fatalf("expected message to be marked seen: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "new", "3.host")); err != nil {
+		t.Fatalf("unmatched message was touched: %v", err)
+	}
+}
+
+// TestMaildirSyncer_Run_Errors covers a missing Maildir, seam errors and cancellation.
+func TestMaildirSyncer_Run_Errors(t *testing.T) {
+	if s := NewMaildirSyncer(&config.MaildirConfig{}); s.config.AfterDownload != "seen" {
+		t.Fatalf("unexpected default action %q", s.config.AfterDownload)
+	}
+	if err := NewMaildirSyncer(&config.MaildirConfig{Folder: t.TempDir()}).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected Maildir error")
+	}
+
+	root := newTestMaildir(t, map[string]string{"new/1.host": buildMessage("kobo@example.com", "Books", "a.epub", "A")})
+	cfg := &config.MaildirConfig{Folder: root}
+
+	origCollect, origDownload := maildirCollect, maildirDownload
+	t.Cleanup(func() { maildirCollect, maildirDownload = origCollect, origDownload })
+
+	maildirCollect = func(m *Maildir, unreadOnly bool, field, value string) ([]*MaildirMessage, error) {
+		return nil, errors.New("boom")
+	}
+	if err := NewMaildirSyncer(cfg).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected collect error")
+	}
+	maildirCollect = origCollect
+
+	maildirDownload = func(mm *MaildirMessage, dst string, valid []string, overwrite bool, afterDownload string) error {
+		return errors.New("boom")
+	}
+	if err := NewMaildirSyncer(cfg).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected download error")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := NewMaildirSyncer(cfg).RunContext(ctx, t.TempDir(), nil, false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+}
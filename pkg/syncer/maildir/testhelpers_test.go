@@ -0,0 +1,42 @@
+package maildir
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// buildMessage assembles a message with a text body and a base64 encoded attachment.
+func buildMessage(to, subject, filename, content string) string {
+	return "From: Alice <alice@example.com>\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/mixed; boundary=\"b\"\r\n" +
+		"\r\n" +
+		"--b\r\nContent-Type: text/plain\r\n\r\nHello\r\n" +
+		"--b\r\n" +
+		"Content-Type: application/epub+zip\r\n" +
+		"Content-Disposition: attachment; filename=\"" + filename + "\"\r\n" +
+		"Content-Transfer-Encoding: base64\r\n\r\n" +
+		base64.StdEncoding.EncodeToString([]byte(content)) + "\r\n" +
+		"--b--\r\n"
+}
+
+// newTestMaildir creates a Maildir and writes messages, keyed by their path relative to the root.
+func newTestMaildir(t *testing.T, messages map[string]string) string {
+	t.Helper()
+	root := filepath.Join(t.TempDir(), "Maildir")
+	for _, sub := range []string{"cur", "new", "tmp"} {
+		if err := os.MkdirAll(filepath.Join(root, sub), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, content := range messages {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
@@ -0,0 +1,169 @@
+package mbox
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// fromLine starts every message in an mbox file, at the start of the file or
+// after an empty line.
+var fromLine = []byte("From ")
+
+type MboxFile struct {
+	Path string
+}
+
+func NewMboxFile(path string) *MboxFile {
+	return &MboxFile{
+		Path: path,
+	}
+}
+
+// Scan reads the mbox file one message at a time and calls fn for each of
+// them. Lines escaped as ">From " (mboxo and mboxrd) are unescaped, and
+// unescaped "From " lines that do not follow an empty line are kept as body.
+func (f *MboxFile) Scan(fn func(m *MboxMessage) error) error {
+	file, err := os.Open(f.Path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+	var (
+		current *MboxMessage
+		offset  int64
+		index   int
+		blank   bool
+	)
+	finish := func() error {
+		if current == nil {
+			return nil
+		}
+		// The empty line before the next "From " line belongs to the separator
+		if blank {
+			current.Raw = trimTrailingNewline(current.Raw)
+		}
+		current.Length = offset - current.Offset
+		if msg, err := mail.ReadMessage(bytes.NewReader(current.Raw)); err == nil {
+			current.Header = msg.Header
+		}
+		return fn(current)
+	}
+
+	for {
+		line, readErr := r.ReadBytes('\n')
+		if len(line) > 0 {
+			if bytes.HasPrefix(line, fromLine) && (current == nil || blank) {
+				if err := finish(); err != nil {
+					return err
+				}
+				index++
+				current = &MboxMessage{Index: index, Offset: offset}
+			} else if current == nil {
+				return fmt.Errorf("not an mbox file: %s", f.Path)
+			} else {
+				current.Raw = append(current.Raw, unescapeFrom(line)...)
+			}
+			blank = len(bytes.TrimRight(line, "\r\n")) == 0
+			offset += int64(len(line))
+		}
+		if readErr == io.EOF {
+			return finish()
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
+
+// Lock takes a dot-lock (<file>.lock) so that the mail delivery agent does
+// not append to the mbox file while it is being rewritten.
+func (f *MboxFile) Lock() (func(), error) {
+	lockPath := f.Path + ".lock"
+	lock, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return nil, fmt.Errorf("mbox file is locked by %s", lockPath)
+		}
+		return nil, err
+	}
+	lock.Close()
+	return func() { os.Remove(lockPath) }, nil
+}
+
+// Remove rewrites the mbox file without the given messages. Anything
+// appended after the last scanned message is kept.
+func (f *MboxFile) Remove(messages []*MboxMessage) error {
+	removed := append([]*MboxMessage(nil), messages...)
+	sort.Slice(removed, func(i, j int) bool { return removed[i].Offset < removed[j].Offset })
+
+	src, err := os.Open(f.Path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(f.Path), ".bookshift-mbox-")
+	if err != nil {
+		return err
+	}
+	defer tmpFile.Close()
+
+	var pos int64
+	for _, m := range removed {
+		if _, err := io.CopyN(tmpFile, src, m.Offset-pos); err != nil {
+			os.Remove(tmpFile.Name())
+			return err
+		}
+		if _, err := src.Seek(m.Offset+m.Length, io.SeekStart); err != nil {
+			os.Remove(tmpFile.Name())
+			return err
+		}
+		pos = m.Offset + m.Length
+	}
+	if _, err := io.Copy(tmpFile, src); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+
+	if err := tmpFile.Chmod(info.Mode().Perm()); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	if err := tmpFile.Sync(); err != nil { // ensure data flushed before rename
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	if err := os.Rename(tmpFile.Name(), f.Path); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	return nil
+}
+
+// unescapeFrom strips one ">" from lines matching ^>+From .
+func unescapeFrom(line []byte) []byte {
+	trimmed := bytes.TrimLeft(line, ">")
+	if len(trimmed) < len(line) && bytes.HasPrefix(trimmed, fromLine) {
+		return line[1:]
+	}
+	return line
+}
+
+func trimTrailingNewline(b []byte) []byte {
+	if bytes.HasSuffix(b, []byte("\r\n")) {
+		return b[:len(b)-2]
+	}
+	return bytes.TrimSuffix(b, []byte("\n"))
+}
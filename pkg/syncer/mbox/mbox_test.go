@@ -0,0 +1,116 @@
+package mbox
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestMboxFile_Scan splits messages, unescapes From lines and records their location.
+func TestMboxFile_Scan(t *testing.T) {
+	first := buildMessage("kobo@example.com", "One", "", "a.epub", "A")
+	second := buildMessage("kobo@example.com", "Two", "RO", "b.epub", "B")
+	path := writeMbox(t, first, second)
+
+	var msgs []*MboxMessage
+	if err := NewMboxFile(path).Scan(func(m *MboxMessage) error {
+		msgs = append(msgs, m)
+		return nil
+	}); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if len(msgs) != 2 {
+		t.Fatalf("want 2 messages, got %d", len(msgs))
+	}
+	if string(msgs[0].Raw) != strings.Replace(first, ">From the desk", "From the desk", 1) {
+		t.Fatalf("unexpected raw message:\n%s", msgs[0].Raw)
+	}
+	if msgs[1].Header.Get("Subject") != "Two" || !msgs[1].IsRead() || msgs[0].IsRead() {
+		t.Fatalf("unexpected headers: %v %v", msgs[0].Header, msgs[1].Header)
+	}
+
+	data, _ := os.ReadFile(path)
+	if msgs[1].Offset != msgs[0].Length || msgs[1].Offset+msgs[1].Length != int64(len(data)) {
+		t.Fatalf("unexpected offsets: %+v %+v", msgs[0], msgs[1])
+	}
+	if !strings.HasPrefix(string(data[msgs[1].Offset:]), "From ") {
+		t.Fatalf("offset does not point at a From line")
+	}
+}
+
+// TestMboxFile_ScanUnescapedFrom keeps an unescaped "From " body line that does
+// not follow an empty line in its message.
+func TestMboxFile_ScanUnescapedFrom(t *testing.T) {
+	first := "Subject: One\n\nForwarded message\nFrom Bob, with love\n"
+	path := writeMbox(t, first, "Subject: Two\n\nbody\n")
+
+	var msgs []*MboxMessage
+	if err := NewMboxFile(path).Scan(func(m *MboxMessage) error {
+		msgs = append(msgs, m)
+		return nil
+	}); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if len(msgs) != 2 {
+		t.Fatalf("want 2 messages, got %d", len(msgs))
+	}
+	if string(msgs[0].Raw) != first || msgs[1].Header.Get("Subject") != "Two" {
+		t.Fatalf("unexpected messages:\n%s\n%s", msgs[0].Raw, msgs[1].Raw)
+	}
+}
+
+// TestMboxFile_ScanErrors rejects files that are not mbox and stops on callback errors.
+func TestMboxFile_ScanErrors(t *testing.T) {
+	path := writeMbox(t, "Subject: x\n\nbody\n")
+	if err := os.WriteFile(path, []byte("Subject: x\n\nbody\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewMboxFile(path).Scan(func(*MboxMessage) error { return nil }); err == nil {
+		t.Fatalf("expected not an mbox error")
+	}
+	if err := NewMboxFile(path + ".missing").Scan(func(*MboxMessage) error { return nil }); err == nil {
+		t.Fatalf("expected missing file error")
+	}
+
+	path = writeMbox(t, "Subject: x\n\nbody\n", "Subject: y\n\nbody\n")
+	calls := 0
+	err := NewMboxFile(path).Scan(func(*MboxMessage) error {
+		calls++
+		return os.ErrPermission
+	})
+	if err != os.ErrPermission || calls != 1 {
+		t.Fatalf("expected scan to stop at first error, got %v after %d calls", err, calls)
+	}
+}
+
+// TestMboxFile_RemoveAndLock rewrites the file without the removed messages.
+func TestMboxFile_RemoveAndLock(t *testing.T) {
+	path := writeMbox(t, "Subject: one\n\n1\n", "Subject: two\n\n2\n", "Subject: three\n\n3\n")
+	f := NewMboxFile(path)
+
+	unlock, err := f.Lock()
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	if _, err := f.Lock(); err == nil {
+		t.Fatalf("expected second lock to fail")
+	}
+
+	var msgs []*MboxMessage
+	_ = f.Scan(func(m *MboxMessage) error { msgs = append(msgs, m); return nil })
+	if err := f.Remove([]*MboxMessage{msgs[2], msgs[0]}); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	unlock()
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Fatalf("lock file left behind")
+	}
+
+	data, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(data), "From ") || strings.Count(string(data), "From ") != 1 || !strings.Contains(string(data), "Subject: two") {
+		t.Fatalf("unexpected mbox content:\n%s", data)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+		t.Fatalf("file mode not preserved: %v", info.Mode())
+	}
+}
@@ -0,0 +1,65 @@
+package mbox
+
+import (
+	"bytes"
+	"log/slog"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bjw-s-labs/bookshift/pkg/mailparts"
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// MboxMessage is a single message of an mbox file. Offset and Length locate
+// the message, including its "From " line, within the file.
+type MboxMessage struct {
+	Index  int
+	Offset int64
+	Length int64
+	Raw    []byte
+	Header mail.Header
+}
+
+// IsRead reports whether the mail client marked the message as read in its Status header.
+func (m *MboxMessage) IsRead() bool {
+	return m.Header != nil && strings.Contains(m.Header.Get("Status"), "R")
+}
+
+// DownloadAttachments writes all valid attachments to dstFolder.
+func (m *MboxMessage) DownloadAttachments(dstFolder string, validExtensions []string, overwriteExistingFile bool) error {
+	header, attachments, err := mailparts.ExtractAttachments(bytes.NewReader(m.Raw), validExtensions)
+	if err != nil {
+		return err
+	}
+	messageSender := mailparts.Sender(header)
+	messageSubject := mailparts.Subject(header)
+
+	// Create target folder if required
+	if _, err := os.Stat(dstFolder); os.IsNotExist(err) {
+		if util.DryRun {
+			slog.Info("[dry-run] Would create local folder", "folder", dstFolder)
+			return nil
+		}
+		slog.Info("Creating local folder", "folder", dstFolder)
+		if err := os.MkdirAll(dstFolder, 0755); err != nil {
+			return err
+		}
+	}
+
+	// Save the attachments
+	for _, attachment := range attachments {
+		slog.Info("Extracting email attachment", "message", m.Index, "sender", messageSender, "subject", messageSubject, "filename", attachment.Filename)
+
+		dstPath, err := mailparts.SaveAttachment(dstFolder, attachment.Filename, int64(len(attachment.Content)), bytes.NewReader(attachment.Content), overwriteExistingFile)
+		if err != nil {
+			return err
+		}
+		if dstPath != "" {
+			slog.Info("Successfully extracted attachment", "message", m.Index, "filename", filepath.Base(dstPath), "path", dstPath)
+		}
+	}
+
+	return nil
+}
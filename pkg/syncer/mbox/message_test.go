@@ -0,0 +1,50 @@
+package mbox
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// TestMboxMessage_DownloadAttachments extracts matching attachments.
+func TestMboxMessage_DownloadAttachments(t *testing.T) {
+	m := &MboxMessage{Index: 1, Raw: []byte(buildMessage("kobo@example.com", "Books", "", "Dune.epub", "DUNE"))}
+
+	dst := filepath.Join(t.TempDir(), "books")
+	if err := m.DownloadAttachments(dst, []string{".epub"}, false); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if b, err := os.ReadFile(filepath.Join(dst, "dune.epub")); err != nil || string(b) != "DUNE" {
+		t.Fatalf("unexpected file: %q %v", b, err)
+	}
+
+	other := t.TempDir()
+	if err := m.DownloadAttachments(other, []string{".pdf"}, false); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if entries, _ := os.ReadDir(other); len(entries) != 0 {
+		t.Fatalf("filtered attachment was written: %v", entries)
+	}
+
+	if err := (&MboxMessage{Raw: []byte("garbage")}).DownloadAttachments(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected parse error")
+	}
+}
+
+// TestMboxMessage_DryRun writes nothing.
+func TestMboxMessage_DryRun(t *testing.T) {
+	old := util.DryRun
+	t.Cleanup(func() { util.DryRun = old })
+	util.DryRun = true
+
+	m := &MboxMessage{Index: 1, Raw: []byte(buildMessage("kobo@example.com", "Books", "", "a.epub", "A"))}
+	dst := t.TempDir()
+	if err := m.DownloadAttachments(dst, nil, false); err != nil {
+		t.Fatalf("dry-run: %v", err)
+	}
+	if entries, _ := os.ReadDir(dst); len(entries) != 0 {
+		t.Fatalf("files written in dry-run: %v", entries)
+	}
+}
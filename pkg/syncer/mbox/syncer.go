@@ -0,0 +1,98 @@
+package mbox
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/bjw-s-labs/bookshift/pkg/mailparts"
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+type MboxSyncer struct {
+	config *config.MboxConfig
+}
+
+func NewMboxSyncer(mboxConfig *config.MboxConfig) *MboxSyncer {
+	return &MboxSyncer{
+		config: mboxConfig,
+	}
+}
+
+func (s *MboxSyncer) Run(targetFolder string, validExtensions []string, overwriteExistingFiles bool) error {
+	return s.RunContext(context.Background(), targetFolder, validExtensions, overwriteExistingFiles)
+}
+
+func (s *MboxSyncer) RunContext(ctx context.Context, targetFolder string, validExtensions []string, overwriteExistingFiles bool) (err error) {
+	if s.config.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(s.config.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	mboxFile := mboxNewFile(s.config.File)
+	removeMessages := s.config.RemoveEmailsAfterDownload && !util.DryRun
+
+	// Keep the delivery agent out while messages may be removed
+	if removeMessages {
+		unlock, err := mboxFile.Lock()
+		if err != nil {
+			return fmt.Errorf("could not lock mbox file %s: %w", s.config.File, err)
+		}
+		defer unlock()
+	}
+
+	// Messages that were processed are removed even when a later one fails
+	var processed []*MboxMessage
+	defer func() {
+		if len(processed) == 0 || !s.config.RemoveEmailsAfterDownload {
+			return
+		}
+		if util.DryRun {
+			slog.Info("[dry-run] Would remove messages from mbox file", "file", s.config.File, "count", len(processed))
+			return
+		}
+		if removeErr := mboxRemove(mboxFile, processed); removeErr != nil && err == nil {
+			err = fmt.Errorf("could not remove messages from mbox file %s: %w", s.config.File, removeErr)
+		}
+	}()
+
+	scanErr := mboxScan(mboxFile, func(m *MboxMessage) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		if m.Header == nil {
+			slog.Warn("Skipping unreadable message", "file", s.config.File, "message", m.Index)
+			return nil
+		}
+		if !s.config.ProcessReadEmails && m.IsRead() {
+			return nil
+		}
+		if !mailparts.HeaderMatches(m.Header, s.config.FilterField, s.config.FilterValue) {
+			return nil
+		}
+
+		if err := mboxDownload(m, targetFolder, validExtensions, overwriteExistingFiles); err != nil {
+			return err
+		}
+		// Only the location is needed from here on
+		m.Raw = nil
+		processed = append(processed, m)
+		return nil
+	})
+	if scanErr != nil {
+		return fmt.Errorf("could not process mbox file %s: %w", s.config.File, scanErr)
+	}
+
+	return nil
+}
@@ -0,0 +1,16 @@
+// syncer_seams.go: Test seams for the mbox syncer.
+//
+// This file defines small function variables that production code uses by default,
+// but tests can override to substitute fakes. Keeping these seams in a separate
+// file keeps the main implementation clean while preserving simple overrides in tests.
+package mbox
+
+// test hooks (seams) for dependency injection in tests
+var (
+	mboxNewFile  = func(path string) *MboxFile { return NewMboxFile(path) }
+	mboxScan     = func(f *MboxFile, fn func(m *MboxMessage) error) error { return f.Scan(fn) }
+	mboxRemove   = func(f *MboxFile, messages []*MboxMessage) error { return f.Remove(messages) }
+	mboxDownload = func(m *MboxMessage, dst string, valid []string, overwrite bool) error {
+		return m.DownloadAttachments(dst, valid, overwrite)
+	}
+)
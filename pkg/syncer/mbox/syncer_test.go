@@ -0,0 +1,105 @@
+package mbox
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// TestMboxSyncer_Run_EndToEnd extracts unread matching messages and removes them from the file.
+func TestMboxSyncer_Run_EndToEnd(t *testing.T) {
+	path := writeMbox(t,
+		buildMessage("kobo@example.com", "One", "", "a.epub", "A"),
+		buildMessage("kobo@example.com", "Two", "RO", "b.epub", "B"),
+		buildMessage("other@example.com", "Three", "", "c.epub", "C"),
+	)
+	cfg := &config.MboxConfig{File: path, FilterField: "to", FilterValue: "kobo@example.com", RemoveEmailsAfterDownload: true}
+
+	dst := t.TempDir()
+	if err := NewMboxSyncer(cfg).Run(dst, []string{".epub"}, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	entries, _ := os.ReadDir(dst)
+	if len(entries) != 1 || entries[0].Name() != "a.epub" {
+		t.Fatalf("unexpected files: %v", entries)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "Subject: One") || !strings.Contains(string(data), "Subject: Two") || !strings.Contains(string(data), "Subject: Three") {
+		t.Fatalf("unexpected mbox content:\n%s", data)
+	}
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Fatalf("lock file left behind")
+	}
+}
+
+// TestMboxSyncer_Run_KeepsProgressAndDryRun removes processed messages after a failure and changes nothing in dry-run.
+func TestMboxSyncer_Run_KeepsProgressAndDryRun(t *testing.T) {
+	path := writeMbox(t,
+		buildMessage("kobo@example.com", "One", "", "a.epub", "A"),
+		buildMessage("kobo@example.com", "Two", "", "b.epub", "B"),
+	)
+	cfg := &config.MboxConfig{File: path, ProcessReadEmails: true, RemoveEmailsAfterDownload: true}
+
+	orig := mboxDownload
+	t.Cleanup(func() { mboxDownload = orig })
+	mboxDownload = func(m *MboxMessage, dst string, valid []string, overwrite bool) error {
+		if m.Index == 2 {
+			return errors.New("disk full")
+		}
+		return orig(m, dst, valid, overwrite)
+	}
+	if err := NewMboxSyncer(cfg).Run(t.TempDir(), nil, false); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Fatalf("expected download error, got %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "Subject: One") || !strings.Contains(string(data), "Subject: Two") {
+		t.Fatalf("expected only the processed message to be removed:\n%s", data)
+	}
+	mboxDownload = orig
+
+	old := util.DryRun
+	t.Cleanup(func() { util.DryRun = old })
+	util.DryRun = true
+	if err := NewMboxSyncer(cfg).Run(t.TempDir(), nil, false); err != nil {
+		t.Fatalf("dry-run: %v", err)
+	}
+	if after, _ := os.ReadFile(path); string(after) != string(data) {
+		t.Fatalf("mbox changed in dry-run")
+	}
+}
+
+// TestMboxSyncer_Run_Errors covers locking, missing files, removal failures and cancellation.
+func TestMboxSyncer_Run_Errors(t *testing.T) {
+	path := writeMbox(t, buildMessage("kobo@example.com", "One", "", "a.epub", "A"))
+	cfg := &config.MboxConfig{File: path, RemoveEmailsAfterDownload: true}
+
+	if err := os.WriteFile(path+".lock", nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewMboxSyncer(cfg).Run(t.TempDir(), nil, false); err == nil || !strings.Contains(err.Error(), "lock") {
+		t.Fatalf("expected lock error, got %v", err)
+	}
+	_ = os.Remove(path + ".lock")
+
+	if err := NewMboxSyncer(&config.MboxConfig{File: path + ".missing"}).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected missing file error")
+	}
+
+	orig := mboxRemove
+	t.Cleanup(func() { mboxRemove = orig })
+	mboxRemove = func(f *MboxFile, messages []*MboxMessage) error { return errors.New("read-only") }
+	if err := NewMboxSyncer(cfg).Run(t.TempDir(), nil, false); err == nil || !strings.Contains(err.Error(), "read-only") {
+		t.Fatalf("expected remove error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := NewMboxSyncer(cfg).RunContext(ctx, t.TempDir(), nil, false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package mbox
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// buildMessage assembles a message with an escaped "From " body line and a base64 encoded attachment.
+func buildMessage(to, subject, status, filename, content string) string {
+	var b strings.Builder
+	b.WriteString("From: Alice <alice@example.com>\n")
+	b.WriteString("To: " + to + "\n")
+	b.WriteString("Subject: " + subject + "\n")
+	if status != "" {
+		b.WriteString("Status: " + status + "\n")
+	}
+	b.WriteString("MIME-Version: 1.0\n")
+	b.WriteString("Content-Type: multipart/mixed; boundary=\"b\"\n\n")
+	b.WriteString("--b\nContent-Type: text/plain\n\n>From the desk of Alice\n")
+	b.WriteString("--b\n")
+	b.WriteString("Content-Disposition: attachment; filename=\"" + filename + "\"\n")
+	b.WriteString("Content-Transfer-Encoding: base64\n\n")
+	b.WriteString(base64.StdEncoding.EncodeToString([]byte(content)) + "\n")
+	b.WriteString("--b--\n")
+	return b.String()
+}
+
+// writeMbox joins the messages with "From " separator lines into an mbox file.
+func writeMbox(t *testing.T, messages ...string) string {
+	t.Helper()
+	var b strings.Builder
+	for _, m := range messages {
+		b.WriteString("From alice@example.com Sat Jan  3 01:05:34 2026\n")
+		b.WriteString(m)
+		b.WriteString("\n")
+	}
+	path := filepath.Join(t.TempDir(), "inbox.mbox")
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,135 @@
+package opds
+
+import (
+	"log/slog"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+type OpdsBook struct {
+	entry     OpdsEntry
+	link      OpdsLink
+	extension string
+
+	opdsClient OpdsAPI
+}
+
+// NewOpdsBook picks the acquisition link of entry that best matches validExtensions,
+// honouring the order of validExtensions. It returns nil when no link matches.
+func NewOpdsBook(entry OpdsEntry, validExtensions []string, conn OpdsAPI) *OpdsBook {
+	candidates := map[string]OpdsLink{}
+	var fallback []string
+	for _, l := range entry.Acquisitions {
+		ext := linkExtension(l)
+		if ext == "" {
+			continue
+		}
+		if _, ok := candidates[ext]; !ok {
+			candidates[ext] = l
+			fallback = append(fallback, ext)
+		}
+	}
+
+	wanted := fallback
+	if len(validExtensions) > 0 {
+		wanted = wanted[:0:0]
+		for _, e := range validExtensions {
+			wanted = append(wanted, strings.ToLower(e))
+		}
+	}
+
+	for _, ext := range wanted {
+		if l, ok := candidates[ext]; ok {
+			return &OpdsBook{entry: entry, link: l, extension: ext, opdsClient: conn}
+		}
+	}
+	return nil
+}
+
+// linkExtension derives the file extension from the link's MIME type, falling back to the URL path.
+func linkExtension(l OpdsLink) string {
+	if ext := util.ExtensionForMimeType(l.Type); ext != "" {
+		return ext
+	}
+	if u, err := url.Parse(l.Href); err == nil {
+		return strings.ToLower(path.Ext(u.Path))
+	}
+	return ""
+}
+
+// FileName returns the local file name derived from the entry's title and author.
+func (b *OpdsBook) FileName() string {
+	name := b.entry.Title
+	if name == "" {
+		name = b.entry.ID
+	}
+	if b.entry.Author != "" {
+		name += " - " + b.entry.Author
+	}
+	return util.SafeFileName(strings.ReplaceAll(name, "/", " ") + b.extension)
+}
+
+func (b *OpdsBook) Download(dstFolder string, overwriteExistingFile bool) error {
+	safeFileName := b.FileName()
+	dstPath := filepath.Join(dstFolder, safeFileName)
+
+	// Create folder structure if required
+	if _, err := os.Stat(dstFolder); os.IsNotExist(err) {
+		if util.DryRun {
+			slog.Info("[dry-run] Would create local folder", "folder", dstFolder)
+			return nil
+		}
+		slog.Info("Creating local folder", "folder", dstFolder)
+		if err := os.MkdirAll(dstFolder, 0755); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("Downloading book from OPDS catalog", "host", b.opdsClient.Host(), "title", b.entry.Title, "destination", dstPath)
+
+	// Check if the file already exists
+	_, err := os.Stat(dstPath)
+	if !os.IsNotExist(err) {
+		if !overwriteExistingFile {
+			slog.Warn("File already exists, skipping download", "file", dstPath)
+			return nil
+		}
+
+		slog.Info("Overwriting existing file", "file", dstPath)
+	}
+
+	// Download the file
+	if util.DryRun {
+		slog.Info("[dry-run] Would download file", "source", b.link.Href, "destination", dstPath)
+		return nil
+	}
+	tmpFile, err := os.CreateTemp(dstFolder, "bookshift-")
+	if err != nil {
+		return err
+	}
+	defer tmpFile.Close()
+
+	slog.Debug("Downloading to temporary file", "file", tmpFile.Name())
+	writer := util.NewFileWriter(tmpFile, 0, true)
+	if _, err := b.opdsClient.ReadFile(b.link.Href, writer); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+
+	if err := tmpFile.Sync(); err != nil { // ensure data flushed before rename
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	if err := os.Rename(tmpFile.Name(), dstPath); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+
+	slog.Info("Successfully downloaded file", "filename", safeFileName)
+	return nil
+}
@@ -0,0 +1,85 @@
+package opds
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// TestNewOpdsBook_PrefersValidExtensionOrder verifies the first matching valid extension wins.
+func TestNewOpdsBook_PrefersValidExtensionOrder(t *testing.T) {
+	entry := OpdsEntry{Title: "T", Acquisitions: []OpdsLink{
+		{Href: "https://h/get/1.pdf", Type: "application/pdf"},
+		{Href: "https://h/get/1", Type: "application/epub+zip"},
+		{Href: "https://h/dl/1.KEPUB", Type: "application/octet-stream"},
+	}}
+
+	if b := NewOpdsBook(entry, []string{".kepub", ".epub"}, nil); b == nil || b.extension != ".kepub" {
+		t.Fatalf("expected kepub via URL extension, got %+v", b)
+	}
+	if b := NewOpdsBook(entry, []string{".EPUB"}, nil); b == nil || b.link.Href != "https://h/get/1" {
+		t.Fatalf("expected epub, got %+v", b)
+	}
+	if b := NewOpdsBook(entry, []string{".mobi"}, nil); b != nil {
+		t.Fatalf("expected no match, got %+v", b)
+	}
+	if b := NewOpdsBook(entry, nil, nil); b == nil || b.extension != ".pdf" {
+		t.Fatalf("expected first link without filter, got %+v", b)
+	}
+}
+
+// TestOpdsBook_Download writes the book using a title based name and skips existing files.
+func TestOpdsBook_Download(t *testing.T) {
+	fake := &fakeOpds{reads: map[string]string{"/1": "DATA"}}
+	entry := OpdsEntry{Title: "The Hobbit", Author: "Tolkien", Acquisitions: []OpdsLink{{Href: "/1", Type: "application/epub+zip"}}}
+	b := NewOpdsBook(entry, []string{".epub"}, fake)
+
+	dst := filepath.Join(t.TempDir(), "new")
+	if err := b.Download(dst, false); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	p := filepath.Join(dst, "the-hobbit-tolkien.epub")
+	if got, err := os.ReadFile(p); err != nil || string(got) != "DATA" {
+		t.Fatalf("read: %v %q", err, string(got))
+	}
+
+	fake.reads["/1"] = "NEW"
+	if err := b.Download(dst, false); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if got, _ := os.ReadFile(p); string(got) != "DATA" {
+		t.Fatalf("existing file must be kept")
+	}
+	if err := b.Download(dst, true); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if got, _ := os.ReadFile(p); string(got) != "NEW" {
+		t.Fatalf("existing file must be overwritten")
+	}
+}
+
+// TestOpdsBook_Download_ErrorAndDryRun ensures failed transfers leave nothing behind and dry-run writes nothing.
+func TestOpdsBook_Download_ErrorAndDryRun(t *testing.T) {
+	entry := OpdsEntry{Title: "X", Acquisitions: []OpdsLink{{Href: "/missing", Type: "application/epub+zip"}}}
+	b := NewOpdsBook(entry, nil, &fakeOpds{})
+
+	dst := t.TempDir()
+	if err := b.Download(dst, true); err == nil {
+		t.Fatalf("expected error")
+	}
+	if entries, _ := os.ReadDir(dst); len(entries) != 0 {
+		t.Fatalf("expected no leftovers")
+	}
+
+	old := util.DryRun
+	t.Cleanup(func() { util.DryRun = old })
+	util.DryRun = true
+	if err := b.Download(dst, true); err != nil {
+		t.Fatalf("dry-run: %v", err)
+	}
+	if entries, _ := os.ReadDir(dst); len(entries) != 0 {
+		t.Fatalf("dry-run must not write files")
+	}
+}
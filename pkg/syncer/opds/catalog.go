@@ -0,0 +1,83 @@
+package opds
+
+import "log/slog"
+
+// maxPagesPerCrawl bounds the number of feed pages fetched in a single run.
+const maxPagesPerCrawl = 1000
+
+type OpdsCatalog struct {
+	StartURL string
+	MaxDepth int
+
+	opdsClient OpdsAPI
+}
+
+func NewOpdsCatalog(startURL string, maxDepth int, conn OpdsAPI) *OpdsCatalog {
+	return &OpdsCatalog{
+		StartURL:   startURL,
+		MaxDepth:   maxDepth,
+		opdsClient: conn,
+	}
+}
+
+// Crawl walks the catalog starting at StartURL. Pagination via "next" links is
+// always followed, navigation links are followed up to MaxDepth levels deep.
+// Entries are de-duplicated by their identifier.
+func (c *OpdsCatalog) Crawl() ([]OpdsEntry, error) {
+	type page struct {
+		url   string
+		depth int
+	}
+
+	var allEntries []OpdsEntry
+	seenPages := map[string]bool{}
+	seenEntries := map[string]bool{}
+	queue := []page{{url: c.StartURL}}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if seenPages[current.url] {
+			continue
+		}
+		if len(seenPages) >= maxPagesPerCrawl {
+			slog.Warn("Reached OPDS page limit, stopping crawl", "limit", maxPagesPerCrawl)
+			break
+		}
+		seenPages[current.url] = true
+
+		slog.Debug("Fetching OPDS feed", "url", current.url, "depth", current.depth)
+		feed, err := c.opdsClient.FetchFeed(current.url)
+		if err != nil {
+			// Only the start page is essential, broken sub-feeds are skipped
+			if current.url == c.StartURL {
+				return nil, err
+			}
+			slog.Warn("Failed to fetch OPDS feed", "url", current.url, "error", err)
+			continue
+		}
+
+		for _, e := range feed.Entries {
+			key := e.ID
+			if key == "" {
+				key = e.Acquisitions[0].Href
+			}
+			if seenEntries[key] {
+				continue
+			}
+			seenEntries[key] = true
+			allEntries = append(allEntries, e)
+		}
+
+		if feed.Next != "" {
+			queue = append(queue, page{url: feed.Next, depth: current.depth})
+		}
+		if current.depth < c.MaxDepth {
+			for _, nav := range feed.Navigation {
+				queue = append(queue, page{url: nav, depth: current.depth + 1})
+			}
+		}
+	}
+
+	return allEntries, nil
+}
@@ -0,0 +1,78 @@
+package opds
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// fakeOpds serves feeds from memory.
+type fakeOpds struct {
+	feeds map[string]*OpdsFeed
+	reads map[string]string
+}
+
+func (f *fakeOpds) Connect(ctx context.Context, timeout time.Duration) error { return nil }
+func (f *fakeOpds) Disconnect() error                                        { return nil }
+func (f *fakeOpds) Host() string                                             { return "fake" }
+func (f *fakeOpds) FetchFeed(u string) (*OpdsFeed, error) {
+	if feed, ok := f.feeds[u]; ok {
+		return feed, nil
+	}
+	return nil, errors.New("not found")
+}
+func (f *fakeOpds) ReadFile(u string, w io.Writer) (int64, error) {
+	s, ok := f.reads[u]
+	if !ok {
+		return 0, errors.New("not found")
+	}
+	n, err := w.Write([]byte(s))
+	return int64(n), err
+}
+
+func book(id string) OpdsEntry {
+	return OpdsEntry{ID: id, Title: id, Acquisitions: []OpdsLink{{Href: "/" + id, Type: "application/epub+zip"}}}
+}
+
+// TestOpdsCatalog_Crawl_DepthAndPaging verifies depth limits apply to navigation but not to pagination.
+func TestOpdsCatalog_Crawl_DepthAndPaging(t *testing.T) {
+	fake := &fakeOpds{feeds: map[string]*OpdsFeed{
+		"root":  {Navigation: []string{"lvl1"}, Entries: []OpdsEntry{book("a")}},
+		"lvl1":  {Navigation: []string{"lvl2", "root"}, Next: "lvl1b", Entries: []OpdsEntry{book("b")}},
+		"lvl1b": {Entries: []OpdsEntry{book("c"), book("a")}},
+		"lvl2":  {Entries: []OpdsEntry{book("d")}},
+	}}
+
+	entries, err := NewOpdsCatalog("root", 1, fake).Crawl()
+	if err != nil {
+		t.Fatalf("crawl: %v", err)
+	}
+	var ids []string
+	for _, e := range entries {
+		ids = append(ids, e.ID)
+	}
+	if len(ids) != 3 || ids[0] != "a" || ids[1] != "b" || ids[2] != "c" {
+		t.Fatalf("unexpected entries: %v", ids)
+	}
+
+	entries, _ = NewOpdsCatalog("root", 2, fake).Crawl()
+	if len(entries) != 4 {
+		t.Fatalf("want 4 entries at depth 2, got %d", len(entries))
+	}
+}
+
+// TestOpdsCatalog_Crawl_Errors ensures only a failing start page aborts the crawl.
+func TestOpdsCatalog_Crawl_Errors(t *testing.T) {
+	fake := &fakeOpds{feeds: map[string]*OpdsFeed{
+		"root": {Navigation: []string{"broken"}, Entries: []OpdsEntry{book("a")}},
+	}}
+	if _, err := NewOpdsCatalog("missing", 3, fake).Crawl(); err == nil {
+		t.Fatalf("expected error for missing start page")
+	}
+	entries, err := NewOpdsCatalog("root", 3, fake).Crawl()
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("broken sub-feed must be skipped: %v %d", err, len(entries))
+	}
+}
@@ -0,0 +1,155 @@
+package opds
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+	"github.com/go-playground/sensitive"
+)
+
+// Package-level errors
+var (
+	ErrOpdsDisconnected = fmt.Errorf("not connected to the OPDS server")
+)
+
+// OpdsAPI is the minimal contract used by catalog and book logic.
+// It enables injecting a fake in tests.
+type OpdsAPI interface {
+	Connect(ctx context.Context, timeout time.Duration) error
+	Disconnect() error
+	FetchFeed(feedURL string) (*OpdsFeed, error)
+	ReadFile(fileURL string, w io.Writer) (int64, error)
+	Host() string
+}
+
+type OpdsClient struct {
+	baseURL  *url.URL
+	username string
+	password *sensitive.String
+
+	// ctx bounds every request of the session
+	ctx    context.Context
+	client *http.Client
+}
+
+func NewOpdsClient(feedURL string, username string, password *sensitive.String) (*OpdsClient, error) {
+	u, err := url.Parse(feedURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OPDS url %s: %w", feedURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid OPDS url %s: scheme must be http or https", feedURL)
+	}
+
+	return &OpdsClient{
+		baseURL:  u,
+		username: username,
+		password: password,
+	}, nil
+}
+
+// Connect prepares the HTTP client used for all catalog requests. The timeout
+// bounds connecting and waiting for responses, not the transfer of books.
+func (c *OpdsClient) Connect(ctx context.Context, timeout time.Duration) error {
+	slog.Debug("Initiating OPDS connection", "host", c.Host())
+	c.ctx = ctx
+	c.client = util.NewHTTPClient(timeout)
+	return nil
+}
+
+// Disconnect releases idle connections held by the HTTP client.
+func (c *OpdsClient) Disconnect() error {
+	slog.Debug("Disconnecting OPDS connection", "host", c.Host())
+	if c.client != nil {
+		c.client.CloseIdleConnections()
+		c.client = nil
+	}
+	return nil
+}
+
+// FetchFeed retrieves and parses an OPDS 1.x (Atom) or OPDS 2.0 (JSON) feed.
+func (c *OpdsClient) FetchFeed(feedURL string) (*OpdsFeed, error) {
+	resp, err := c.get(feedURL, "application/atom+xml;profile=opds-catalog, application/opds+json, application/json;q=0.9, */*;q=0.8")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch feed %s: %s", feedURL, resp.Status)
+	}
+
+	// Resolve relative links against the final URL after redirects
+	pageURL := resp.Request.URL
+
+	body := bufio.NewReader(resp.Body)
+	if isJSONFeed(resp.Header.Get("Content-Type"), body) {
+		return parseJSONFeed(body, pageURL)
+	}
+	return parseAtomFeed(body, pageURL)
+}
+
+// ReadFile streams a remote file to the provided writer.
+func (c *OpdsClient) ReadFile(fileURL string, w io.Writer) (int64, error) {
+	resp, err := c.get(fileURL, "*/*")
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed to download %s: %s", fileURL, resp.Status)
+	}
+	return io.Copy(w, resp.Body)
+}
+
+func (c *OpdsClient) Host() string {
+	return c.baseURL.Host
+}
+
+// get sends an authenticated GET request. Credentials are only sent to the catalog host.
+func (c *OpdsClient) get(rawURL string, accept string) (*http.Response, error) {
+	if c.client == nil {
+		return nil, ErrOpdsDisconnected
+	}
+
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	if c.username != "" && strings.EqualFold(req.URL.Host, c.baseURL.Host) {
+		var password string
+		if c.password != nil {
+			password = string(*c.password)
+		}
+		req.SetBasicAuth(c.username, password)
+	}
+	return c.client.Do(req)
+}
+
+// isJSONFeed decides between OPDS 2.0 and Atom based on the content type,
+// falling back to sniffing the first non-whitespace byte of the body.
+func isJSONFeed(contentType string, body *bufio.Reader) bool {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		switch {
+		case strings.HasSuffix(mediaType, "json"):
+			return true
+		case strings.HasSuffix(mediaType, "xml"):
+			return false
+		}
+	}
+	peek, _ := body.Peek(512)
+	peek = bytes.TrimLeft(peek, " \t\r\n\ufeff")
+	return len(peek) > 0 && peek[0] == '{'
+}
@@ -0,0 +1,98 @@
+package opds
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-playground/sensitive"
+)
+
+// TestNewOpdsClient_InvalidURL ensures unsupported schemes are rejected.
+func TestNewOpdsClient_InvalidURL(t *testing.T) {
+	if _, err := NewOpdsClient("ftp://h/opds", "", nil); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+// TestOpdsClient_NotConnected ensures requests fail before Connect.
+func TestOpdsClient_NotConnected(t *testing.T) {
+	c, _ := NewOpdsClient("https://h/opds", "", nil)
+	if _, err := c.FetchFeed("https://h/opds"); err == nil {
+		t.Fatalf("expected error")
+	}
+	if _, err := c.ReadFile("https://h/x", nil); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+// TestOpdsClient_FetchFeed_DetectsFormat verifies Atom and JSON feeds are both parsed, with basic auth.
+func TestOpdsClient_FetchFeed_DetectsFormat(t *testing.T) {
+	srv, _ := newCatalogServer(t, "alice", "pw")
+	pw := sensitive.String("pw")
+	c, _ := NewOpdsClient(srv.URL+"/opds", "alice", &pw)
+	if err := c.Connect(context.Background(), 5*time.Second); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Disconnect() })
+
+	atom, err := c.FetchFeed(srv.URL + "/opds/new")
+	if err != nil || len(atom.Entries) != 1 {
+		t.Fatalf("atom: %v %+v", err, atom)
+	}
+	js, err := c.FetchFeed(srv.URL + "/v2")
+	if err != nil || len(js.Entries) != 1 {
+		t.Fatalf("json: %v %+v", err, js)
+	}
+	if _, err := c.FetchFeed(srv.URL + "/missing"); err == nil {
+		t.Fatalf("expected error for missing feed")
+	}
+
+	var buf bytes.Buffer
+	if _, err := c.ReadFile(srv.URL+"/get/epub/1", &buf); err != nil || buf.String() != "book:/get/epub/1" {
+		t.Fatalf("read: %v %q", err, buf.String())
+	}
+}
+
+// TestOpdsClient_WrongCredentials ensures authentication failures are reported.
+func TestOpdsClient_WrongCredentials(t *testing.T) {
+	srv, _ := newCatalogServer(t, "alice", "pw")
+	bad := sensitive.String("nope")
+	c, _ := NewOpdsClient(srv.URL+"/opds", "alice", &bad)
+	_ = c.Connect(context.Background(), 5*time.Second)
+	if _, err := c.FetchFeed(srv.URL + "/opds"); err == nil {
+		t.Fatalf("expected auth error")
+	}
+}
+
+// TestOpdsClient_SlowDownload verifies books may take longer than the connect
+// timeout to download and are stopped by the session context.
+func TestOpdsClient_SlowDownload(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for range 3 {
+			_, _ = w.Write([]byte("chunk"))
+			w.(http.Flusher).Flush()
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c, _ := NewOpdsClient(srv.URL+"/opds", "", nil)
+	if err := c.Connect(ctx, 50*time.Millisecond); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := c.ReadFile(srv.URL+"/book.epub", &buf); err != nil || buf.String() != "chunkchunkchunk" {
+		t.Fatalf("slow download: %q %v", buf.String(), err)
+	}
+
+	cancel()
+	if _, err := c.ReadFile(srv.URL+"/book.epub", &buf); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+}
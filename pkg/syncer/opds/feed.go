@@ -0,0 +1,253 @@
+package opds
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Link relations used while walking a catalog.
+const (
+	relAcquisition = "http://opds-spec.org/acquisition"
+	relNext        = "next"
+)
+
+// OpdsLink is a link from a feed or an entry.
+type OpdsLink struct {
+	Rel  string
+	Href string
+	Type string
+}
+
+// OpdsEntry is a publication listed in a feed, with its acquisition links.
+type OpdsEntry struct {
+	ID           string
+	Title        string
+	Author       string
+	Updated      time.Time
+	Acquisitions []OpdsLink
+}
+
+// OpdsFeed is the format-independent view of a single catalog page.
+type OpdsFeed struct {
+	Entries    []OpdsEntry
+	Next       string
+	Navigation []string
+}
+
+// atomFeed mirrors the subset of an OPDS 1.x Atom feed that is used.
+type atomFeed struct {
+	Links   []atomLink `xml:"http://www.w3.org/2005/Atom link"`
+	Entries []struct {
+		ID      string     `xml:"http://www.w3.org/2005/Atom id"`
+		Title   string     `xml:"http://www.w3.org/2005/Atom title"`
+		Updated string     `xml:"http://www.w3.org/2005/Atom updated"`
+		Authors []string   `xml:"http://www.w3.org/2005/Atom author>name"`
+		Links   []atomLink `xml:"http://www.w3.org/2005/Atom link"`
+	} `xml:"http://www.w3.org/2005/Atom entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+	Type string `xml:"type,attr"`
+}
+
+// jsonFeed mirrors the subset of an OPDS 2.0 feed that is used.
+type jsonFeed struct {
+	Links        []jsonLink        `json:"links"`
+	Navigation   []jsonLink        `json:"navigation"`
+	Publications []jsonPublication `json:"publications"`
+	Groups       []struct {
+		Navigation   []jsonLink        `json:"navigation"`
+		Publications []jsonPublication `json:"publications"`
+	} `json:"groups"`
+}
+
+type jsonLink struct {
+	Rel  jsonRel `json:"rel"`
+	Href string  `json:"href"`
+	Type string  `json:"type"`
+}
+
+type jsonPublication struct {
+	Metadata struct {
+		Identifier string          `json:"identifier"`
+		Title      string          `json:"title"`
+		Modified   string          `json:"modified"`
+		Updated    string          `json:"updated"`
+		Author     json.RawMessage `json:"author"`
+	} `json:"metadata"`
+	Links []jsonLink `json:"links"`
+}
+
+// jsonRel accepts both a single relation and a list of relations.
+type jsonRel []string
+
+func (r *jsonRel) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*r = jsonRel{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(b, &many); err != nil {
+		return err
+	}
+	*r = many
+	return nil
+}
+
+func parseAtomFeed(r io.Reader, pageURL *url.URL) (*OpdsFeed, error) {
+	var af atomFeed
+	if err := xml.NewDecoder(r).Decode(&af); err != nil {
+		return nil, fmt.Errorf("failed to parse OPDS feed: %w", err)
+	}
+
+	feed := &OpdsFeed{}
+	for _, l := range af.Links {
+		if hasRel(l.Rel, relNext) {
+			feed.Next = resolveHref(pageURL, l.Href)
+		}
+	}
+
+	for _, e := range af.Entries {
+		entry := OpdsEntry{
+			ID:      strings.TrimSpace(e.ID),
+			Title:   strings.TrimSpace(e.Title),
+			Updated: parseTime(e.Updated),
+		}
+		if len(e.Authors) > 0 {
+			entry.Author = strings.TrimSpace(e.Authors[0])
+		}
+		for _, l := range e.Links {
+			href := resolveHref(pageURL, l.Href)
+			switch {
+			case isAcquisition(l.Rel):
+				entry.Acquisitions = append(entry.Acquisitions, OpdsLink{Rel: l.Rel, Href: href, Type: l.Type})
+			case isCatalogType(l.Type):
+				feed.Navigation = append(feed.Navigation, href)
+			}
+		}
+		if len(entry.Acquisitions) > 0 {
+			feed.Entries = append(feed.Entries, entry)
+		}
+	}
+	return feed, nil
+}
+
+func parseJSONFeed(r io.Reader, pageURL *url.URL) (*OpdsFeed, error) {
+	var jf jsonFeed
+	if err := json.NewDecoder(r).Decode(&jf); err != nil {
+		return nil, fmt.Errorf("failed to parse OPDS feed: %w", err)
+	}
+
+	feed := &OpdsFeed{}
+	for _, l := range jf.Links {
+		if hasRel(strings.Join(l.Rel, " "), relNext) {
+			feed.Next = resolveHref(pageURL, l.Href)
+		}
+	}
+
+	navigation := jf.Navigation
+	publications := jf.Publications
+	for _, g := range jf.Groups {
+		navigation = append(navigation, g.Navigation...)
+		publications = append(publications, g.Publications...)
+	}
+	for _, l := range navigation {
+		feed.Navigation = append(feed.Navigation, resolveHref(pageURL, l.Href))
+	}
+
+	for _, p := range publications {
+		updated := p.Metadata.Modified
+		if updated == "" {
+			updated = p.Metadata.Updated
+		}
+		entry := OpdsEntry{
+			ID:      p.Metadata.Identifier,
+			Title:   strings.TrimSpace(p.Metadata.Title),
+			Author:  jsonAuthor(p.Metadata.Author),
+			Updated: parseTime(updated),
+		}
+		for _, l := range p.Links {
+			rel := strings.Join(l.Rel, " ")
+			if isAcquisition(rel) {
+				entry.Acquisitions = append(entry.Acquisitions, OpdsLink{Rel: rel, Href: resolveHref(pageURL, l.Href), Type: l.Type})
+			}
+		}
+		if len(entry.Acquisitions) > 0 {
+			feed.Entries = append(feed.Entries, entry)
+		}
+	}
+	return feed, nil
+}
+
+// jsonAuthor extracts the first author name from the string, object or array forms allowed by OPDS 2.0.
+func jsonAuthor(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var name string
+	if err := json.Unmarshal(raw, &name); err == nil {
+		return name
+	}
+	var obj struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(raw, &obj); err == nil && obj.Name != "" {
+		return obj.Name
+	}
+	var list []json.RawMessage
+	if err := json.Unmarshal(raw, &list); err == nil && len(list) > 0 {
+		return jsonAuthor(list[0])
+	}
+	return ""
+}
+
+// isAcquisition matches the acquisition relation and its sub-relations (open-access, borrow, ...).
+func isAcquisition(rel string) bool {
+	for _, r := range strings.Fields(rel) {
+		if strings.HasPrefix(r, relAcquisition) {
+			return true
+		}
+	}
+	return false
+}
+
+// isCatalogType reports whether a link points at another catalog feed.
+func isCatalogType(linkType string) bool {
+	t := strings.ToLower(linkType)
+	return strings.Contains(t, "profile=opds-catalog") || strings.HasPrefix(t, "application/opds+json")
+}
+
+func hasRel(rels string, want string) bool {
+	for _, r := range strings.Fields(rels) {
+		if r == want {
+			return true
+		}
+	}
+	return false
+}
+
+func resolveHref(base *url.URL, href string) string {
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return href
+	}
+	return base.ResolveReference(ref).String()
+}
+
+func parseTime(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{time.RFC3339Nano, time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,89 @@
+package opds
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseAtomFeed verifies acquisition links, pagination, navigation and metadata are extracted.
+func TestParseAtomFeed(t *testing.T) {
+	base, _ := url.Parse("https://books.example/opds/new")
+	feed, err := parseAtomFeed(strings.NewReader(atomNewPage1), base)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if feed.Next != "https://books.example/opds/new?page=2" {
+		t.Fatalf("unexpected next: %q", feed.Next)
+	}
+	if len(feed.Entries) != 1 {
+		t.Fatalf("want 1 entry, got %d", len(feed.Entries))
+	}
+	e := feed.Entries[0]
+	if e.Title != "The Hobbit" || e.Author != "J.R.R. Tolkien" || e.ID != "urn:book:1" {
+		t.Fatalf("unexpected entry: %+v", e)
+	}
+	if !e.Updated.Equal(time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected updated: %v", e.Updated)
+	}
+	if len(e.Acquisitions) != 2 || e.Acquisitions[1].Href != "https://books.example/get/epub/1" {
+		t.Fatalf("unexpected acquisitions: %+v", e.Acquisitions)
+	}
+
+	root, err := parseAtomFeed(strings.NewReader(atomRoot), base)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(root.Entries) != 0 || len(root.Navigation) != 1 || root.Navigation[0] != "https://books.example/opds/new" {
+		t.Fatalf("unexpected navigation feed: %+v", root)
+	}
+}
+
+// TestParseJSONFeed verifies OPDS 2.0 publications and navigation are extracted.
+func TestParseJSONFeed(t *testing.T) {
+	base, _ := url.Parse("https://books.example/v2")
+	feed, err := parseJSONFeed(strings.NewReader(jsonFeedBody), base)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(feed.Navigation) != 1 || feed.Navigation[0] != "https://books.example/v2/new" {
+		t.Fatalf("unexpected navigation: %v", feed.Navigation)
+	}
+	if len(feed.Entries) != 1 {
+		t.Fatalf("want 1 entry, got %d", len(feed.Entries))
+	}
+	e := feed.Entries[0]
+	if e.Title != "Emma" || e.Author != "Jane Austen" || e.Updated.IsZero() {
+		t.Fatalf("unexpected entry: %+v", e)
+	}
+	if e.Acquisitions[0].Href != "https://books.example/files/emma.epub" {
+		t.Fatalf("unexpected acquisition: %+v", e.Acquisitions)
+	}
+}
+
+// TestParseFeed_Invalid ensures malformed documents are reported.
+func TestParseFeed_Invalid(t *testing.T) {
+	base, _ := url.Parse("https://books.example/")
+	if _, err := parseAtomFeed(strings.NewReader("<feed"), base); err == nil {
+		t.Fatalf("expected atom error")
+	}
+	if _, err := parseJSONFeed(strings.NewReader("{"), base); err == nil {
+		t.Fatalf("expected json error")
+	}
+}
+
+// TestJSONAuthor covers the string, object and array author forms.
+func TestJSONAuthor(t *testing.T) {
+	tests := map[string]string{
+		`"Ann"`:                           "Ann",
+		`{"name": "Bob"}`:                 "Bob",
+		`[{"name": "Cy"}, {"name": "D"}]`: "Cy",
+		`42`:                              "",
+	}
+	for in, want := range tests {
+		if got := jsonAuthor([]byte(in)); got != want {
+			t.Fatalf("jsonAuthor(%s)=%q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package opds
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// opdsState is persisted between runs when only_new is enabled.
+type opdsState struct {
+	LastUpdated time.Time `json:"last_updated"`
+}
+
+type OpdsSyncer struct {
+	config *config.OpdsConfig
+}
+
+func NewOpdsSyncer(catalogConfig *config.OpdsConfig) *OpdsSyncer {
+	// Set default crawl depth
+	if !(catalogConfig.MaxDepth > 0) {
+		catalogConfig.MaxDepth = 3
+	}
+
+	return &OpdsSyncer{
+		config: catalogConfig,
+	}
+}
+
+func (s *OpdsSyncer) Run(targetFolder string, validExtensions []string, overwriteExistingFiles bool) error {
+	return s.RunContext(context.Background(), targetFolder, validExtensions, overwriteExistingFiles)
+}
+
+func (s *OpdsSyncer) RunContext(ctx context.Context, targetFolder string, validExtensions []string, overwriteExistingFiles bool) error {
+	if s.config.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(s.config.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	// Load the timestamp of the newest entry seen during previous runs
+	statePath := s.config.StateFile
+	if statePath == "" {
+		statePath = util.DefaultStatePath(targetFolder, "opds", s.config.URL)
+	}
+	var state opdsState
+	if s.config.OnlyNew {
+		if err := util.LoadState(statePath, &state); err != nil {
+			return fmt.Errorf("could not load OPDS state from %s: %w", statePath, err)
+		}
+	}
+
+	// Connect to the OPDS server
+	opdsClient, err := newOpdsClient(s.config)
+	if err != nil {
+		return err
+	}
+	if err := opdsConnect(ctx, opdsClient, 60*time.Second); err != nil {
+		return fmt.Errorf("could not connect to OPDS server %s: %w", s.config.URL, err)
+	}
+	defer opdsClient.Disconnect()
+
+	// Walk the catalog
+	catalog := opdsNewCatalog(s.config.URL, s.config.MaxDepth, opdsClient)
+	allEntries, err := opdsCrawl(catalog)
+	if err != nil {
+		return fmt.Errorf("could not read OPDS catalog %s: %w", s.config.URL, err)
+	}
+
+	// Download all new books
+	newest := state.LastUpdated
+	for _, entry := range allEntries {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		if s.config.OnlyNew && !entry.Updated.IsZero() && !entry.Updated.After(state.LastUpdated) {
+			slog.Debug("Skipping entry not updated since last run", "title", entry.Title, "updated", entry.Updated)
+			continue
+		}
+
+		book := NewOpdsBook(entry, validExtensions, opdsClient)
+		if book == nil {
+			slog.Debug("No acquisition link matches the valid extensions", "title", entry.Title)
+			continue
+		}
+		if err := opdsDownload(book, targetFolder, overwriteExistingFiles); err != nil {
+			return err
+		}
+		if entry.Updated.After(newest) {
+			newest = entry.Updated
+		}
+	}
+
+	if s.config.OnlyNew && newest.After(state.LastUpdated) {
+		state.LastUpdated = newest
+		if err := util.SaveState(statePath, &state); err != nil {
+			return fmt.Errorf("could not save OPDS state to %s: %w", statePath, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,24 @@
+// syncer_seams.go: Test seams for the OPDS syncer.
+//
+// This file defines small function variables that production code uses by default,
+// but tests can override to substitute fakes. Keeping these seams in a separate
+// file keeps the main implementation clean while preserving simple overrides in tests.
+package opds
+
+import (
+	"context"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+// test hooks (seams) for dependency injection in tests
+var (
+	newOpdsClient = func(cfg *config.OpdsConfig) (OpdsAPI, error) {
+		return NewOpdsClient(cfg.URL, cfg.Username, cfg.Password)
+	}
+	opdsConnect    = func(ctx context.Context, c OpdsAPI, timeout time.Duration) error { return c.Connect(ctx, timeout) }
+	opdsNewCatalog = func(url string, depth int, conn OpdsAPI) *OpdsCatalog { return NewOpdsCatalog(url, depth, conn) }
+	opdsCrawl      = func(c *OpdsCatalog) ([]OpdsEntry, error) { return c.Crawl() }
+	opdsDownload   = func(b *OpdsBook, dst string, overwrite bool) error { return b.Download(dst, overwrite) }
+)
@@ -0,0 +1,111 @@
+package opds
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// TestNewOpdsSyncer_DefaultDepth ensures a default crawl depth is set.
+func TestNewOpdsSyncer_DefaultDepth(t *testing.T) {
+	cfg := &config.OpdsConfig{}
+	NewOpdsSyncer(cfg)
+	if cfg.MaxDepth != 3 {
+		t.Fatalf("want 3, got %d", cfg.MaxDepth)
+	}
+}
+
+// TestOpdsSyncer_Run_EndToEnd walks navigation and pagination and downloads matching formats.
+func TestOpdsSyncer_Run_EndToEnd(t *testing.T) {
+	srv, _ := newCatalogServer(t, "", "")
+	dst := t.TempDir()
+	cfg := &config.OpdsConfig{URL: srv.URL + "/opds"}
+	if err := NewOpdsSyncer(cfg).Run(dst, []string{".epub", ".kepub"}, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for name, want := range map[string]string{
+		"the-hobbit-j.r.r.-tolkien.epub": "book:/get/epub/1",
+		"dune.kepub":                     "book:/get/kepub/2",
+	} {
+		got, err := os.ReadFile(filepath.Join(dst, name))
+		if err != nil || string(got) != want {
+			t.Fatalf("%s: %v %q", name, err, string(got))
+		}
+	}
+}
+
+// TestOpdsSyncer_Run_OnlyNew verifies entries not updated since the last run are skipped.
+func TestOpdsSyncer_Run_OnlyNew(t *testing.T) {
+	srv, requests := newCatalogServer(t, "", "")
+	dst := t.TempDir()
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	if err := util.SaveState(statePath, opdsState{LastUpdated: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)}); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.OpdsConfig{URL: srv.URL + "/opds", OnlyNew: true, StateFile: statePath}
+	if err := NewOpdsSyncer(cfg).Run(dst, []string{".epub", ".kepub"}, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "the-hobbit-j.r.r.-tolkien.epub")); !os.IsNotExist(err) {
+		t.Fatalf("old entry must be skipped")
+	}
+	if _, err := os.Stat(filepath.Join(dst, "dune.kepub")); err != nil {
+		t.Fatalf("new entry must be downloaded: %v", err)
+	}
+
+	var state opdsState
+	if err := util.LoadState(statePath, &state); err != nil {
+		t.Fatal(err)
+	}
+	if !state.LastUpdated.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("state not advanced: %v", state.LastUpdated)
+	}
+
+	// A second run downloads nothing
+	*requests = nil
+	if err := NewOpdsSyncer(cfg).Run(dst, []string{".epub", ".kepub"}, true); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for _, r := range *requests {
+		if strings.HasPrefix(r, "/get/") {
+			t.Fatalf("unexpected download on second run: %s", r)
+		}
+	}
+}
+
+// TestOpdsSyncer_Run_Errors ensures client, crawl and download errors abort the run.
+func TestOpdsSyncer_Run_Errors(t *testing.T) {
+	if err := NewOpdsSyncer(&config.OpdsConfig{URL: "ftp://x"}).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected client error")
+	}
+
+	srv, _ := newCatalogServer(t, "", "")
+	if err := NewOpdsSyncer(&config.OpdsConfig{URL: srv.URL + "/missing"}).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected crawl error")
+	}
+
+	orig := opdsDownload
+	t.Cleanup(func() { opdsDownload = orig })
+	opdsDownload = func(b *OpdsBook, dst string, overwrite bool) error { return errors.New("z") }
+	if err := NewOpdsSyncer(&config.OpdsConfig{URL: srv.URL + "/opds"}).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected download error")
+	}
+}
+
+// TestOpdsSyncer_RunContext_Cancel verifies that a canceled context stops the run.
+func TestOpdsSyncer_RunContext_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cfg := &config.OpdsConfig{URL: "http://h"}
+	if err := NewOpdsSyncer(cfg).RunContext(ctx, t.TempDir(), nil, false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled, got %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package opds
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// atomRoot is a navigation feed linking to an acquisition feed.
+const atomRoot = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <id>root</id>
+  <title>Catalog</title>
+  <entry>
+    <title>New books</title>
+    <id>new</id>
+    <link rel="subsection" href="/opds/new" type="application/atom+xml;profile=opds-catalog;kind=acquisition"/>
+  </entry>
+</feed>`
+
+// atomNewPage1 lists one book and links to a second page.
+const atomNewPage1 = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <id>new</id>
+  <link rel="next" href="/opds/new?page=2" type="application/atom+xml;profile=opds-catalog;kind=acquisition"/>
+  <entry>
+    <title>The Hobbit</title>
+    <id>urn:book:1</id>
+    <updated>2024-01-02T10:00:00Z</updated>
+    <author><name>J.R.R. Tolkien</name></author>
+    <link rel="http://opds-spec.org/image" href="/cover/1.jpg" type="image/jpeg"/>
+    <link rel="http://opds-spec.org/acquisition" href="/get/pdf/1" type="application/pdf"/>
+    <link rel="http://opds-spec.org/acquisition/open-access" href="/get/epub/1" type="application/epub+zip"/>
+  </entry>
+</feed>`
+
+// atomNewPage2 lists a second book; the first entry repeats and must be de-duplicated.
+const atomNewPage2 = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <id>new-2</id>
+  <entry>
+    <title>The Hobbit</title>
+    <id>urn:book:1</id>
+    <updated>2024-01-02T10:00:00Z</updated>
+    <link rel="http://opds-spec.org/acquisition" href="/get/epub/1" type="application/epub+zip"/>
+  </entry>
+  <entry>
+    <title>Dune</title>
+    <id>urn:book:2</id>
+    <updated>2024-03-01T00:00:00Z</updated>
+    <link rel="http://opds-spec.org/acquisition" href="/get/kepub/2" type="application/kepub+zip"/>
+  </entry>
+</feed>`
+
+// jsonFeedBody is an OPDS 2.0 feed with one publication and a navigation link.
+const jsonFeedBody = `{
+  "metadata": {"title": "Catalog"},
+  "links": [{"rel": "self", "href": "/v2", "type": "application/opds+json"}],
+  "navigation": [{"href": "/v2/new", "title": "New", "type": "application/opds+json"}],
+  "publications": [{
+    "metadata": {"identifier": "urn:book:3", "title": "Emma", "modified": "2024-05-01T00:00:00Z", "author": {"name": "Jane Austen"}},
+    "links": [
+      {"rel": ["http://opds-spec.org/acquisition"], "href": "/files/emma.epub", "type": "application/epub+zip"}
+    ]
+  }]
+}`
+
+// newCatalogServer serves the fixtures above. Book downloads return "book:<path>".
+func newCatalogServer(t *testing.T, user, pass string) (*httptest.Server, *[]string) {
+	t.Helper()
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RequestURI())
+		if user != "" {
+			if u, p, ok := r.BasicAuth(); !ok || u != user || p != pass {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		}
+		switch {
+		case r.URL.Path == "/opds":
+			w.Header().Set("Content-Type", "application/atom+xml;profile=opds-catalog;kind=navigation")
+			_, _ = w.Write([]byte(atomRoot))
+		case r.URL.Path == "/opds/new" && r.URL.Query().Get("page") == "2":
+			w.Header().Set("Content-Type", "application/atom+xml")
+			_, _ = w.Write([]byte(atomNewPage2))
+		case r.URL.Path == "/opds/new":
+			w.Header().Set("Content-Type", "application/atom+xml")
+			_, _ = w.Write([]byte(atomNewPage1))
+		case r.URL.Path == "/v2":
+			w.Header().Set("Content-Type", "application/opds+json")
+			_, _ = w.Write([]byte(jsonFeedBody))
+		case strings.HasPrefix(r.URL.Path, "/get/"), strings.HasPrefix(r.URL.Path, "/files/"):
+			_, _ = w.Write([]byte("book:" + r.URL.Path))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
@@ -0,0 +1,284 @@
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// PluginAPI is the minimal contract used by the syncer and file logic. It
+// enables injecting a fake in tests.
+type PluginAPI interface {
+	Connect(timeout time.Duration) error
+	Disconnect() error
+	ListItems() ([]PluginItem, error)
+	ReadItem(id string, w io.Writer) (int64, error)
+	RemoveItem(id string) error
+	Name() string
+}
+
+// PluginItem is a file offered by the plugin. Path uses forward slashes and
+// is relative to the root of the plugin's source.
+type PluginItem struct {
+	ID   string `json:"id"`
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// killDelay is how long a plugin gets to exit after its stdin is closed.
+var killDelay = 5 * time.Second
+
+// Package-level errors
+var (
+	ErrPluginDisconnected = fmt.Errorf("not connected to the plugin")
+)
+
+// PluginClient runs an external program and talks to it with
+// newline-delimited JSON over its stdin and stdout. Lines the program writes
+// to stderr are logged.
+type PluginClient struct {
+	Command string
+	Args    []string
+	Env     map[string]string
+	Options map[string]any
+
+	name    string
+	timeout time.Duration
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	lines   chan []byte
+	readErr error
+	nextID  int
+	exited  chan struct{}
+}
+
+func (c *PluginClient) Connect(timeout time.Duration) error {
+	slog.Debug("Starting plugin", "command", c.Command)
+
+	cmd := exec.Command(c.Command, c.Args...)
+	cmd.Env = os.Environ()
+	keys := make([]string, 0, len(c.Env))
+	for k := range c.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		cmd.Env = append(cmd.Env, k+"="+c.Env[k])
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("could not start plugin %s: %w", c.Command, err)
+	}
+
+	c.cmd, c.stdin, c.timeout = cmd, stdin, timeout
+	c.name = filepath.Base(c.Command)
+	c.lines = make(chan []byte)
+	c.exited = make(chan struct{})
+	go c.readLines(stdout)
+	go c.logStderr(stderr)
+
+	var hello helloResult
+	if err := c.call("hello", helloParams{Protocol: protocolVersion, Options: c.Options}, &hello); err != nil {
+		_ = c.Disconnect()
+		return fmt.Errorf("plugin %s did not answer hello: %w", c.Command, err)
+	}
+	if hello.Protocol != protocolVersion {
+		_ = c.Disconnect()
+		return fmt.Errorf("plugin %s speaks protocol version %d, expected %d", c.Command, hello.Protocol, protocolVersion)
+	}
+	if hello.Name != "" {
+		c.name = hello.Name
+	}
+	slog.Debug("Plugin started", "plugin", c.name)
+	return nil
+}
+
+// Disconnect asks the plugin to exit by closing its stdin, and kills it when
+// it does not exit in time.
+func (c *PluginClient) Disconnect() error {
+	if c.cmd == nil {
+		return nil
+	}
+	slog.Debug("Stopping plugin", "plugin", c.name)
+	close(c.exited)
+	_ = c.stdin.Close()
+
+	cmd := c.cmd
+	c.cmd = nil
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(killDelay):
+		_ = cmd.Process.Kill()
+		<-done
+		slog.Warn("Killed plugin that did not exit", "plugin", c.name)
+		return nil
+	}
+}
+
+func (c *PluginClient) Name() string {
+	return c.name
+}
+
+// ListItems returns all files the plugin offers.
+func (c *PluginClient) ListItems() ([]PluginItem, error) {
+	var result listResult
+	if err := c.call("list", nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Items, nil
+}
+
+// ReadItem streams the data of an item to w.
+func (c *PluginClient) ReadItem(id string, w io.Writer) (int64, error) {
+	var result readResult
+	var written int64
+	err := c.exchange("read", itemParams{ID: id}, &result, func(data []byte) error {
+		n, err := w.Write(data)
+		written += int64(n)
+		return err
+	})
+	if err != nil {
+		return written, err
+	}
+	if result.Size != written {
+		return written, fmt.Errorf("plugin sent %d bytes of %s, announced %d", written, id, result.Size)
+	}
+	return written, nil
+}
+
+// RemoveItem asks the plugin to delete an item at its source.
+func (c *PluginClient) RemoveItem(id string) error {
+	return c.call("remove", itemParams{ID: id}, nil)
+}
+
+func (c *PluginClient) call(method string, params any, result any) error {
+	return c.exchange(method, params, result, func([]byte) error {
+		return fmt.Errorf("unexpected data in answer to %s", method)
+	})
+}
+
+// exchange sends a request and waits for its answer, passing data messages
+// to onData. Messages for other requests are ignored.
+func (c *PluginClient) exchange(method string, params any, result any, onData func([]byte) error) error {
+	if c.cmd == nil {
+		return ErrPluginDisconnected
+	}
+
+	c.nextID++
+	id := c.nextID
+	line, err := json.Marshal(request{ID: id, Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	if _, err := c.stdin.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("could not write to plugin: %w", err)
+	}
+
+	for {
+		var line []byte
+		select {
+		case l, ok := <-c.lines:
+			if !ok {
+				if c.readErr != nil {
+					return fmt.Errorf("could not read from plugin: %w", c.readErr)
+				}
+				return fmt.Errorf("plugin exited")
+			}
+			line = l
+		case <-time.After(c.timeout):
+			return fmt.Errorf("plugin did not answer %s within %s", method, c.timeout)
+		}
+
+		var resp response
+		if err := json.Unmarshal(line, &resp); err != nil {
+			return fmt.Errorf("invalid message from plugin: %.100s", line)
+		}
+		if resp.ID != id {
+			slog.Debug("Ignoring plugin message for another request", "plugin", c.name, "id", resp.ID)
+			continue
+		}
+		switch {
+		case resp.Error != "":
+			return errors.New(resp.Error)
+		case resp.Result != nil:
+			if result == nil {
+				return nil
+			}
+			if err := json.Unmarshal(resp.Result, result); err != nil {
+				return fmt.Errorf("invalid result from plugin: %w", err)
+			}
+			return nil
+		case resp.Data != nil:
+			if err := onData(resp.Data); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("message from plugin without result, error or data: %.100s", line)
+		}
+	}
+}
+
+// readLines passes the lines the plugin writes to stdout to the lines channel.
+func (c *PluginClient) readLines(stdout io.Reader) {
+	defer close(c.lines)
+	r := bufio.NewReaderSize(stdout, 64*1024)
+	for {
+		var line []byte
+		for {
+			chunk, isPrefix, err := r.ReadLine()
+			if err != nil {
+				if err != io.EOF {
+					c.readErr = err
+				}
+				return
+			}
+			line = append(line, chunk...)
+			if len(line) > maxMessageLength {
+				c.readErr = fmt.Errorf("message longer than %d bytes", maxMessageLength)
+				return
+			}
+			if !isPrefix {
+				break
+			}
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		select {
+		case c.lines <- line:
+		case <-c.exited:
+			return
+		}
+	}
+}
+
+// logStderr logs every line the plugin writes to stderr.
+func (c *PluginClient) logStderr(stderr io.Reader) {
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		slog.Info("Plugin output", "plugin", c.Command, "message", scanner.Text())
+	}
+}
@@ -0,0 +1,134 @@
+package plugin
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestPluginClient_ListReadRemove runs the fake plugin through the whole protocol.
+func TestPluginClient_ListReadRemove(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.epub": "ABCDEFG", "sub/b.pdf": "B"})
+	c := newFakePluginClient(t, "ok", dir)
+	if c.Name() != "fake" {
+		t.Fatalf("expected the name from hello, got %q", c.Name())
+	}
+
+	items, err := c.ListItems()
+	if err != nil || len(items) != 2 {
+		t.Fatalf("list: %v %+v", err, items)
+	}
+	if items[0] != (PluginItem{ID: "a.epub", Path: "a.epub", Size: 7}) {
+		t.Fatalf("unexpected item: %+v", items[0])
+	}
+
+	var buf bytes.Buffer
+	n, err := c.ReadItem("a.epub", &buf)
+	if err != nil || n != 7 || buf.String() != "ABCDEFG" {
+		t.Fatalf("read: %v %d %q", err, n, buf.String())
+	}
+	if _, err := c.ReadItem("missing.epub", &buf); err == nil {
+		t.Fatalf("expected error for missing item")
+	}
+
+	if err := c.RemoveItem("sub/b.pdf"); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if err := c.RemoveItem("sub/b.pdf"); err == nil {
+		t.Fatalf("expected error removing twice")
+	}
+
+	if err := c.Disconnect(); err != nil {
+		t.Fatalf("disconnect: %v", err)
+	}
+	if _, err := c.ListItems(); !errors.Is(err, ErrPluginDisconnected) {
+		t.Fatalf("expected ErrPluginDisconnected, got %v", err)
+	}
+}
+
+// TestPluginClient_Connect_Errors covers start failures and a bad hello.
+func TestPluginClient_Connect_Errors(t *testing.T) {
+	c := &PluginClient{Command: "/nonexistent/plugin"}
+	if err := c.Connect(time.Second); err == nil {
+		t.Fatalf("expected start error")
+	}
+
+	for _, mode := range []string{"version", "crash"} {
+		cfg := newFakePluginConfig(t, mode, t.TempDir())
+		c := &PluginClient{Command: cfg.Command, Env: cfg.Env}
+		if err := c.Connect(5 * time.Second); err == nil {
+			t.Fatalf("%s: expected connect error", mode)
+		}
+	}
+
+	cfg := newFakePluginConfig(t, "ok", t.TempDir())
+	c = &PluginClient{Command: cfg.Command, Env: cfg.Env, Options: map[string]any{"fail": true}}
+	if err := c.Connect(5 * time.Second); err == nil || !strings.Contains(err.Error(), "bad options") {
+		t.Fatalf("expected the plugin's error, got %v", err)
+	}
+}
+
+// TestPluginClient_Misbehaving covers short reads, timeouts and a plugin ignoring stdin EOF.
+func TestPluginClient_Misbehaving(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.epub": "A"})
+
+	c := newFakePluginClient(t, "short", dir)
+	var buf bytes.Buffer
+	if _, err := c.ReadItem("a.epub", &buf); err == nil || !strings.Contains(err.Error(), "announced") {
+		t.Fatalf("expected size mismatch, got %v", err)
+	}
+
+	c = newFakePluginClient(t, "hang", dir)
+	c.timeout = 100 * time.Millisecond
+	if _, err := c.ListItems(); err == nil || !strings.Contains(err.Error(), "did not answer") {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+
+	old := killDelay
+	t.Cleanup(func() { killDelay = old })
+	killDelay = 100 * time.Millisecond
+	c = newFakePluginClient(t, "stubborn", dir)
+	start := time.Now()
+	if err := c.Disconnect(); err != nil {
+		t.Fatalf("disconnect: %v", err)
+	}
+	if time.Since(start) > 10*time.Second {
+		t.Fatalf("plugin was not killed")
+	}
+}
+
+// TestPluginClient_Exchange_InvalidMessages feeds unexpected lines to exchange.
+func TestPluginClient_Exchange_InvalidMessages(t *testing.T) {
+	for name, line := range map[string]string{
+		"garbage": `not json`,
+		"empty":   `{"id":1}`,
+		"data":    `{"id":1,"data":"QQ=="}`,
+		"result":  `{"id":1,"result":"x"}`,
+	} {
+		lines := make(chan []byte, 1)
+		lines <- []byte(line)
+		close(lines)
+		c := &PluginClient{cmd: &exec.Cmd{}, stdin: nopWriteCloser{}, lines: lines, timeout: time.Second}
+		var result listResult
+		if err := c.call("list", nil, &result); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+
+	lines := make(chan []byte)
+	close(lines)
+	c := &PluginClient{cmd: &exec.Cmd{}, stdin: nopWriteCloser{}, lines: lines, timeout: time.Second}
+	if err := c.call("list", nil, nil); err == nil || !strings.Contains(err.Error(), "exited") {
+		t.Fatalf("expected exit error, got %v", err)
+	}
+}
+
+type nopWriteCloser struct{}
+
+func (nopWriteCloser) Write(p []byte) (int, error) { return len(p), nil }
+func (nopWriteCloser) Close() error                { return nil }
@@ -0,0 +1,90 @@
+package plugin
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// TestPluginFile_Download_KeepStructureAndRemove writes into the sub folder and removes the item.
+func TestPluginFile_Download_KeepStructureAndRemove(t *testing.T) {
+	fake := &memoryPlugin{items: map[string]string{"sub/a.epub": "DATA"}}
+	f := NewPluginFile(PluginItem{ID: "sub/a.epub", Path: "sub/a.epub", Size: 4}, fake)
+
+	dst := t.TempDir()
+	if err := f.Download(dst, false, true, true); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if got, err := os.ReadFile(filepath.Join(dst, "sub", "a.epub")); err != nil || string(got) != "DATA" {
+		t.Fatalf("read: %v %q", err, string(got))
+	}
+	if len(fake.removed) != 1 || fake.removed[0] != "sub/a.epub" {
+		t.Fatalf("expected removal, got %v", fake.removed)
+	}
+
+	flat := t.TempDir()
+	if err := f.Download(flat, false, false, false); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(flat, "a.epub")); err != nil {
+		t.Fatalf("expected flat download: %v", err)
+	}
+}
+
+// TestPluginFile_Download_ExistingAndErrors covers skip-existing, unsafe paths and failed reads.
+func TestPluginFile_Download_ExistingAndErrors(t *testing.T) {
+	fake := &memoryPlugin{items: map[string]string{"a.epub": "NEW"}}
+	dst := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dst, "a.epub"), []byte("OLD"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f := NewPluginFile(PluginItem{ID: "a.epub", Path: "a.epub"}, fake)
+	if err := f.Download(dst, false, false, true); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dst, "a.epub")); string(got) != "OLD" {
+		t.Fatalf("existing file must be kept")
+	}
+	if len(fake.removed) != 0 {
+		t.Fatalf("skipped item must not be removed")
+	}
+
+	for _, p := range []string{"../x/b.epub", "/abs/b.epub"} {
+		unsafe := NewPluginFile(PluginItem{ID: p, Path: p}, fake)
+		if err := unsafe.Download(dst, true, true, false); err == nil {
+			t.Fatalf("%s: expected error for path outside the target folder", p)
+		}
+	}
+
+	fake.readErr = errors.New("boom")
+	if err := f.Download(dst, true, false, false); err == nil {
+		t.Fatalf("expected read error")
+	}
+	entries, _ := os.ReadDir(dst)
+	if len(entries) != 1 {
+		t.Fatalf("expected no temporary leftovers, got %d entries", len(entries))
+	}
+}
+
+// TestPluginFile_Download_DryRun ensures nothing is written or removed.
+func TestPluginFile_Download_DryRun(t *testing.T) {
+	old := util.DryRun
+	t.Cleanup(func() { util.DryRun = old })
+	util.DryRun = true
+
+	fake := &memoryPlugin{items: map[string]string{"a.epub": "A"}}
+	f := NewPluginFile(PluginItem{ID: "a.epub", Path: "sub/a.epub"}, fake)
+	dst := t.TempDir()
+	for _, keep := range []bool{false, true} {
+		if err := f.Download(dst, true, keep, true); err != nil {
+			t.Fatalf("dry-run: %v", err)
+		}
+	}
+	if entries, _ := os.ReadDir(dst); len(entries) != 0 || len(fake.removed) != 0 {
+		t.Fatalf("dry-run must not write or remove")
+	}
+}
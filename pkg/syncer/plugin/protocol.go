@@ -0,0 +1,49 @@
+package plugin
+
+import "encoding/json"
+
+// protocolVersion is the version of the exec plugin protocol described in
+// docs/exec-plugins.md.
+const protocolVersion = 1
+
+// maxMessageLength bounds a single line written by the plugin.
+const maxMessageLength = 16 << 20
+
+// request is a single line sent to the plugin on stdin.
+type request struct {
+	ID     int    `json:"id"`
+	Method string `json:"method"`
+	Params any    `json:"params,omitempty"`
+}
+
+// response is a single line read from the plugin's stdout. A request is
+// answered by exactly one message with a result or an error; a read request
+// is preceded by any number of messages carrying base64 encoded data.
+type response struct {
+	ID     int             `json:"id"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+	Data   []byte          `json:"data,omitempty"`
+}
+
+type helloParams struct {
+	Protocol int            `json:"protocol"`
+	Options  map[string]any `json:"options"`
+}
+
+type helloResult struct {
+	Name     string `json:"name"`
+	Protocol int    `json:"protocol"`
+}
+
+type listResult struct {
+	Items []PluginItem `json:"items"`
+}
+
+type itemParams struct {
+	ID string `json:"id"`
+}
+
+type readResult struct {
+	Size int64 `json:"size"`
+}
@@ -0,0 +1,96 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+type PluginSyncer struct {
+	config *config.ExecConfig
+}
+
+func NewPluginSyncer(execConfig *config.ExecConfig) *PluginSyncer {
+	return &PluginSyncer{
+		config: execConfig,
+	}
+}
+
+func (s *PluginSyncer) Run(targetFolder string, validExtensions []string, overwriteExistingFiles bool) error {
+	return s.RunContext(context.Background(), targetFolder, validExtensions, overwriteExistingFiles)
+}
+
+func (s *PluginSyncer) RunContext(ctx context.Context, targetFolder string, validExtensions []string, overwriteExistingFiles bool) error {
+	if s.config.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(s.config.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	// Start the plugin
+	client := newPluginClient(s.config)
+	if err := pluginConnect(client, 30*time.Second); err != nil {
+		return fmt.Errorf("could not start plugin %s: %w", s.config.Command, err)
+	}
+	defer client.Disconnect()
+
+	// Fetch all items offered by the plugin
+	items, err := pluginListItems(client)
+	if err != nil {
+		return fmt.Errorf("could not list files of plugin %s: %w", client.Name(), err)
+	}
+	allFiles := filterItems(items, validExtensions, client)
+	slog.Info("Found files", "plugin", client.Name(), "count", len(allFiles))
+
+	// Download all files
+	for _, f := range allFiles {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		if err := pluginDownload(f,
+			targetFolder,
+			overwriteExistingFiles,
+			s.config.KeepFolderStructure,
+			s.config.RemoveFilesAfterDownload,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// filterItems keeps the items with a valid extension, compared
+// case-insensitively.
+func filterItems(items []PluginItem, validExtensions []string, client PluginAPI) []*PluginFile {
+	lowerExts := make([]string, 0, len(validExtensions))
+	for _, e := range validExtensions {
+		lowerExts = append(lowerExts, strings.ToLower(e))
+	}
+
+	var files []*PluginFile
+	for _, item := range items {
+		if item.ID == "" || item.Path == "" {
+			slog.Warn("Ignoring plugin item without id or path", "plugin", client.Name(), "id", item.ID, "path", item.Path)
+			continue
+		}
+		if !slices.Contains(lowerExts, strings.ToLower(path.Ext(item.Path))) {
+			continue
+		}
+		files = append(files, NewPluginFile(item, client))
+	}
+	return files
+}
@@ -0,0 +1,24 @@
+// syncer_seams.go: Test seams for the exec plugin syncer.
+//
+// This file defines small function variables that production code uses by default,
+// but tests can override to substitute fakes. Keeping these seams in a separate
+// file keeps the main implementation clean while preserving simple overrides in tests.
+package plugin
+
+import (
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+// test hooks (seams) for dependency injection in tests
+var (
+	newPluginClient = func(cfg *config.ExecConfig) PluginAPI {
+		return &PluginClient{Command: cfg.Command, Args: cfg.Args, Env: cfg.Env, Options: cfg.Options}
+	}
+	pluginConnect   = func(c PluginAPI, timeout time.Duration) error { return c.Connect(timeout) }
+	pluginListItems = func(c PluginAPI) ([]PluginItem, error) { return c.ListItems() }
+	pluginDownload  = func(f *PluginFile, dst string, overwrite, keep, del bool) error {
+		return f.Download(dst, overwrite, keep, del)
+	}
+)
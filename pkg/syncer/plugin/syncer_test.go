@@ -0,0 +1,107 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+// TestPluginSyncer_Run_EndToEnd syncs from the fake plugin and removes the files.
+func TestPluginSyncer_Run_EndToEnd(t *testing.T) {
+	src := t.TempDir()
+	writeFiles(t, src, map[string]string{
+		"a.EPUB":     "A",
+		"sub/b.epub": "BB",
+		"c.txt":      "C",
+	})
+	cfg := newFakePluginConfig(t, "ok", src)
+	cfg.KeepFolderStructure = true
+	cfg.RemoveFilesAfterDownload = true
+
+	dst := t.TempDir()
+	if err := NewPluginSyncer(cfg).Run(dst, []string{".epub"}, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for _, p := range []string{"a.epub", filepath.Join("sub", "b.epub")} {
+		if _, err := os.Stat(filepath.Join(dst, p)); err != nil {
+			t.Fatalf("expected %s downloaded: %v", p, err)
+		}
+	}
+	for _, p := range []string{"a.EPUB", filepath.Join("sub", "b.epub")} {
+		if _, err := os.Stat(filepath.Join(src, p)); !os.IsNotExist(err) {
+			t.Fatalf("expected %s removed at the source", p)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(src, "c.txt")); err != nil {
+		t.Fatalf("c.txt must remain: %v", err)
+	}
+}
+
+// TestPluginSyncer_Run_Errors ensures connect, list and download errors abort the run.
+func TestPluginSyncer_Run_Errors(t *testing.T) {
+	if err := NewPluginSyncer(&config.ExecConfig{Command: "/nonexistent/plugin"}).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected connect error")
+	}
+
+	origNew, origList, origDownload := newPluginClient, pluginListItems, pluginDownload
+	t.Cleanup(func() { newPluginClient, pluginListItems, pluginDownload = origNew, origList, origDownload })
+	newPluginClient = func(cfg *config.ExecConfig) PluginAPI {
+		return &memoryPlugin{items: map[string]string{"a.epub": "A"}}
+	}
+
+	pluginListItems = func(c PluginAPI) ([]PluginItem, error) { return nil, errors.New("x") }
+	if err := NewPluginSyncer(&config.ExecConfig{Command: "p"}).Run(t.TempDir(), []string{".epub"}, false); err == nil {
+		t.Fatalf("expected list error")
+	}
+
+	pluginListItems = origList
+	pluginDownload = func(f *PluginFile, dst string, overwrite, keep, del bool) error { return errors.New("x") }
+	if err := NewPluginSyncer(&config.ExecConfig{Command: "p"}).Run(t.TempDir(), []string{".epub"}, false); err == nil {
+		t.Fatalf("expected download error")
+	}
+}
+
+// TestPluginSyncer_RunContext_Cancelled ensures cancellation stops the run.
+func TestPluginSyncer_RunContext_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := NewPluginSyncer(&config.ExecConfig{Command: "p"}).RunContext(ctx, t.TempDir(), nil, false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	origNew, origDownload := newPluginClient, pluginDownload
+	t.Cleanup(func() { newPluginClient, pluginDownload = origNew, origDownload })
+	newPluginClient = func(cfg *config.ExecConfig) PluginAPI {
+		return &memoryPlugin{items: map[string]string{"a.epub": "A", "b.epub": "B"}}
+	}
+	calls := 0
+	pluginDownload = func(f *PluginFile, dst string, overwrite, keep, del bool) error {
+		calls++
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	}
+	cfg := &config.ExecConfig{Command: "p", TimeoutSeconds: 0}
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := NewPluginSyncer(cfg).RunContext(ctx, t.TempDir(), []string{".epub"}, false); !errors.Is(err, context.DeadlineExceeded) || calls != 1 {
+		t.Fatalf("expected deadline after one download, got %v (%d calls)", err, calls)
+	}
+}
+
+// TestFilterItems ensures extensions match case-insensitively and incomplete items are skipped.
+func TestFilterItems(t *testing.T) {
+	files := filterItems([]PluginItem{
+		{ID: "1", Path: "a.EPUB"},
+		{ID: "2", Path: "b.txt"},
+		{ID: "", Path: "c.epub"},
+		{ID: "4", Path: ""},
+	}, []string{".Epub"}, &memoryPlugin{})
+	if len(files) != 1 || files[0].item.ID != "1" {
+		t.Fatalf("unexpected files: %+v", files)
+	}
+}
@@ -0,0 +1,195 @@
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+// TestMain lets the test binary act as a plugin: when started with
+// BOOKSHIFT_TEST_PLUGIN set it serves the files below BOOKSHIFT_TEST_PLUGIN_DIR
+// instead of running the tests.
+func TestMain(m *testing.M) {
+	if mode := os.Getenv("BOOKSHIFT_TEST_PLUGIN"); mode != "" {
+		os.Exit(runFakePlugin(mode, os.Getenv("BOOKSHIFT_TEST_PLUGIN_DIR")))
+	}
+	os.Exit(m.Run())
+}
+
+// runFakePlugin implements the plugin side of the protocol. The mode selects
+// misbehaviour: "version" answers hello with another protocol version, "crash"
+// exits on hello, "hang" never answers list, "short" announces more bytes
+// than it sends and "stubborn" keeps running after stdin is closed.
+func runFakePlugin(mode, dir string) int {
+	out := json.NewEncoder(os.Stdout)
+	reply := func(v map[string]any) { _ = out.Encode(v) }
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req struct {
+			ID     int             `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			fmt.Fprintln(os.Stderr, "invalid request:", err)
+			return 2
+		}
+		var params struct {
+			ID       string         `json:"id"`
+			Protocol int            `json:"protocol"`
+			Options  map[string]any `json:"options"`
+		}
+		_ = json.Unmarshal(req.Params, &params)
+
+		switch req.Method {
+		case "hello":
+			switch {
+			case mode == "crash":
+				return 3
+			case params.Options["fail"] == true:
+				reply(map[string]any{"id": req.ID, "error": "bad options"})
+				continue
+			}
+			protocol := params.Protocol
+			if mode == "version" {
+				protocol = 99
+			}
+			fmt.Fprintln(os.Stderr, "fake plugin ready")
+			reply(map[string]any{"id": req.ID + 100, "result": map[string]any{}})
+			reply(map[string]any{"id": req.ID, "result": map[string]any{"name": "fake", "protocol": protocol}})
+		case "list":
+			if mode == "hang" {
+				continue
+			}
+			items := []PluginItem{}
+			_ = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+				if err != nil || d.IsDir() {
+					return err
+				}
+				info, err := d.Info()
+				if err != nil {
+					return err
+				}
+				rel, _ := filepath.Rel(dir, p)
+				items = append(items, PluginItem{ID: filepath.ToSlash(rel), Path: filepath.ToSlash(rel), Size: info.Size()})
+				return nil
+			})
+			reply(map[string]any{"id": req.ID, "result": map[string]any{"items": items}})
+		case "read":
+			data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(params.ID)))
+			if err != nil {
+				reply(map[string]any{"id": req.ID, "error": err.Error()})
+				continue
+			}
+			for chunk := range slices.Chunk(data, 3) {
+				reply(map[string]any{"id": req.ID, "data": chunk})
+			}
+			size := len(data)
+			if mode == "short" {
+				size++
+			}
+			reply(map[string]any{"id": req.ID, "result": map[string]any{"size": size}})
+		case "remove":
+			if err := os.Remove(filepath.Join(dir, filepath.FromSlash(params.ID))); err != nil {
+				reply(map[string]any{"id": req.ID, "error": err.Error()})
+				continue
+			}
+			reply(map[string]any{"id": req.ID, "result": map[string]any{}})
+		default:
+			reply(map[string]any{"id": req.ID, "error": "unknown method " + req.Method})
+		}
+	}
+	if mode == "stubborn" {
+		time.Sleep(time.Minute)
+	}
+	return 0
+}
+
+// newFakePluginConfig returns a config that runs the test binary as a plugin
+// serving the files of dir.
+func newFakePluginConfig(t *testing.T, mode, dir string) *config.ExecConfig {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatalf("executable: %v", err)
+	}
+	return &config.ExecConfig{
+		Command: exe,
+		Env:     map[string]string{"BOOKSHIFT_TEST_PLUGIN": mode, "BOOKSHIFT_TEST_PLUGIN_DIR": dir},
+	}
+}
+
+// newFakePluginClient starts the test binary as a plugin and stops it when
+// the test ends.
+func newFakePluginClient(t *testing.T, mode, dir string) *PluginClient {
+	t.Helper()
+	cfg := newFakePluginConfig(t, mode, dir)
+	c := &PluginClient{Command: cfg.Command, Env: cfg.Env}
+	if err := c.Connect(5 * time.Second); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Disconnect() })
+	return c
+}
+
+// writeFiles creates the files with the given contents below dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// memoryPlugin is an in-memory PluginAPI.
+type memoryPlugin struct {
+	items   map[string]string
+	removed []string
+	readErr error
+}
+
+func (m *memoryPlugin) Connect(time.Duration) error { return nil }
+func (m *memoryPlugin) Disconnect() error           { return nil }
+func (m *memoryPlugin) Name() string                { return "memory" }
+
+func (m *memoryPlugin) ListItems() ([]PluginItem, error) {
+	var items []PluginItem
+	for id, content := range m.items {
+		items = append(items, PluginItem{ID: id, Path: id, Size: int64(len(content))})
+	}
+	return items, nil
+}
+
+func (m *memoryPlugin) ReadItem(id string, w io.Writer) (int64, error) {
+	if m.readErr != nil {
+		return 0, m.readErr
+	}
+	content, ok := m.items[id]
+	if !ok {
+		return 0, errors.New("no such item")
+	}
+	n, err := io.Copy(w, bytes.NewBufferString(content))
+	return n, err
+}
+
+func (m *memoryPlugin) RemoveItem(id string) error {
+	m.removed = append(m.removed, id)
+	return nil
+}
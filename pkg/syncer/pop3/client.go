@@ -0,0 +1,291 @@
+package pop3
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/sensitive"
+)
+
+// Package-level errors
+var (
+	ErrPop3Disconnected = fmt.Errorf("not connected to the POP3 server")
+)
+
+// apopTimestamp matches the msg-id style timestamp in the server greeting used by APOP.
+var apopTimestamp = regexp.MustCompile(`<[^<>@\s]+@[^<>\s]+>`)
+
+// Pop3API is the minimal contract used by message and syncer logic.
+// It enables injecting a fake in tests.
+type Pop3API interface {
+	Connect(timeout time.Duration) error
+	Disconnect() error
+	List() ([]Pop3MessageInfo, error)
+	Header(id int) (mail.Header, error)
+	Retrieve(id int) ([]byte, error)
+	Delete(id int) error
+	Hostname() string
+}
+
+// Pop3MessageInfo is a single scan listing returned by LIST.
+type Pop3MessageInfo struct {
+	ID   int
+	Size int64
+}
+
+type Pop3Client struct {
+	Host               string
+	Port               int
+	Username           string
+	Password           *sensitive.String
+	Security           string
+	AuthMethod         string
+	InsecureSkipVerify bool
+
+	timeout time.Duration
+	netConn net.Conn
+	conn    *textproto.Conn
+}
+
+// dial hook for the network connection (overridable in tests)
+var pop3Dial = func(addr string, timeout time.Duration) (net.Conn, error) {
+	return net.DialTimeout("tcp", addr, timeout)
+}
+
+// Connect dials the POP3 server using the configured security mode and logs in.
+func (c *Pop3Client) Connect(timeout time.Duration) error {
+	slog.Debug("Initiating POP3 connection", "host", c.Host, "security", c.Security)
+
+	tlsConfig := &tls.Config{
+		ServerName:         c.Host,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+	switch c.Security {
+	case "", "none", "explicit", "implicit":
+	default:
+		return fmt.Errorf("unsupported POP3 security mode: %s", c.Security)
+	}
+
+	netConn, err := pop3Dial(net.JoinHostPort(c.Host, strconv.Itoa(c.Port)), timeout)
+	if err != nil {
+		return err
+	}
+	c.timeout = timeout
+	if c.Security == "implicit" {
+		netConn, err = startTLS(netConn, tlsConfig, timeout)
+		if err != nil {
+			return err
+		}
+	}
+	c.setConn(netConn)
+
+	greeting, err := c.readResponse()
+	if err != nil {
+		c.close()
+		return err
+	}
+
+	if c.Security == "explicit" {
+		if _, err := c.cmd("STLS"); err != nil {
+			c.close()
+			return fmt.Errorf("server refused STLS (%w)", err)
+		}
+		tlsConn, err := startTLS(c.netConn, tlsConfig, timeout)
+		if err != nil {
+			c.close()
+			return err
+		}
+		c.setConn(tlsConn)
+	}
+
+	if err := c.login(greeting); err != nil {
+		_, _ = c.cmd("QUIT")
+		c.close()
+		return fmt.Errorf("failed to log in (%w)", err)
+	}
+	return nil
+}
+
+// login authenticates with USER/PASS or APOP, depending on AuthMethod.
+func (c *Pop3Client) login(greeting string) error {
+	var password string
+	if c.Password != nil {
+		password = string(*c.Password)
+	}
+
+	switch c.AuthMethod {
+	case "", "user":
+		if _, err := c.cmd("USER %s", c.Username); err != nil {
+			return err
+		}
+		_, err := c.cmd("PASS %s", password)
+		return err
+	case "apop":
+		timestamp := apopTimestamp.FindString(greeting)
+		if timestamp == "" {
+			return fmt.Errorf("server does not support APOP")
+		}
+		_, err := c.cmd("APOP %s %s", c.Username, apopDigest(timestamp, password))
+		return err
+	default:
+		return fmt.Errorf("unsupported POP3 auth method: %s", c.AuthMethod)
+	}
+}
+
+// Disconnect ends the POP3 session. Messages marked with DELE are only
+// removed once the server acknowledges QUIT.
+func (c *Pop3Client) Disconnect() error {
+	slog.Debug("Disconnecting POP3 connection", "host", c.Host)
+
+	if c.conn == nil {
+		return nil
+	}
+	_, err := c.cmd("QUIT")
+	c.close()
+	return err
+}
+
+// List returns the scan listing of all messages in the maildrop.
+func (c *Pop3Client) List() ([]Pop3MessageInfo, error) {
+	if _, err := c.cmd("LIST"); err != nil {
+		return nil, err
+	}
+	lines, err := c.conn.ReadDotLines()
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []Pop3MessageInfo
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("malformed LIST response: %q", line)
+		}
+		id, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("malformed LIST response: %q", line)
+		}
+		size, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed LIST response: %q", line)
+		}
+		messages = append(messages, Pop3MessageInfo{ID: id, Size: size})
+	}
+	return messages, nil
+}
+
+// Header returns the header of a message using TOP, falling back to RETR for
+// servers that do not implement the optional TOP command.
+func (c *Pop3Client) Header(id int) (mail.Header, error) {
+	var raw []byte
+	if _, err := c.cmd("TOP %d 0", id); err == nil {
+		if raw, err = c.conn.ReadDotBytes(); err != nil {
+			return nil, err
+		}
+	} else {
+		if c.conn == nil {
+			return nil, err
+		}
+		slog.Debug("TOP failed, retrieving the full message", "id", id, "error", err)
+		if raw, err = c.Retrieve(id); err != nil {
+			return nil, err
+		}
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse header of message %d: %w", id, err)
+	}
+	return msg.Header, nil
+}
+
+// Retrieve returns the full raw message.
+func (c *Pop3Client) Retrieve(id int) ([]byte, error) {
+	if _, err := c.cmd("RETR %d", id); err != nil {
+		return nil, err
+	}
+	return c.conn.ReadDotBytes()
+}
+
+// Delete marks a message for deletion at the end of the session.
+func (c *Pop3Client) Delete(id int) error {
+	_, err := c.cmd("DELE %d", id)
+	return err
+}
+
+func (c *Pop3Client) Hostname() string {
+	return c.Host
+}
+
+// cmd sends a single command and returns the text following +OK.
+func (c *Pop3Client) cmd(format string, args ...any) (string, error) {
+	if c.conn == nil {
+		return "", ErrPop3Disconnected
+	}
+	if err := c.conn.PrintfLine(format, args...); err != nil {
+		return "", err
+	}
+	return c.readResponse()
+}
+
+// readResponse reads a status line and converts -ERR replies into errors.
+func (c *Pop3Client) readResponse() (string, error) {
+	if c.timeout > 0 {
+		_ = c.netConn.SetDeadline(time.Now().Add(c.timeout))
+	}
+	line, err := c.conn.ReadLine()
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case strings.HasPrefix(line, "+OK"):
+		return strings.TrimSpace(line[3:]), nil
+	case strings.HasPrefix(line, "-ERR"):
+		return "", fmt.Errorf("POP3 server error: %s", strings.TrimSpace(line[4:]))
+	default:
+		return "", fmt.Errorf("unexpected POP3 response: %q", line)
+	}
+}
+
+func (c *Pop3Client) setConn(netConn net.Conn) {
+	c.netConn = netConn
+	c.conn = textproto.NewConn(netConn)
+}
+
+func (c *Pop3Client) close() {
+	if c.conn != nil {
+		_ = c.conn.Close()
+	}
+	c.conn = nil
+	c.netConn = nil
+}
+
+// startTLS performs the client side TLS handshake on an established connection.
+func startTLS(netConn net.Conn, tlsConfig *tls.Config, timeout time.Duration) (net.Conn, error) {
+	tlsConn := tls.Client(netConn, tlsConfig)
+	if timeout > 0 {
+		_ = tlsConn.SetDeadline(time.Now().Add(timeout))
+	}
+	if err := tlsConn.Handshake(); err != nil {
+		_ = netConn.Close()
+		return nil, fmt.Errorf("TLS handshake failed (%w)", err)
+	}
+	return tlsConn, nil
+}
+
+// apopDigest computes the APOP digest as described in RFC 1939.
+func apopDigest(timestamp string, password string) string {
+	sum := md5.Sum([]byte(timestamp + password))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,167 @@
+package pop3
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/sensitive"
+)
+
+func newTestClient(security, auth, password string) *Pop3Client {
+	pw := sensitive.String(password)
+	return &Pop3Client{
+		Host:               "pop.example",
+		Port:               995,
+		Username:           "user",
+		Password:           &pw,
+		Security:           security,
+		AuthMethod:         auth,
+		InsecureSkipVerify: true,
+	}
+}
+
+// TestApopDigest checks the digest against the example from RFC 1939.
+func TestApopDigest(t *testing.T) {
+	if got := apopDigest(testTimestamp, "tanstaaf"); got != "c4c9334bac560ecc979e58001b3e22fb" {
+		t.Fatalf("unexpected digest %s", got)
+	}
+}
+
+// TestPop3Client_SecurityAndAuthModes connects with every security mode and auth method.
+func TestPop3Client_SecurityAndAuthModes(t *testing.T) {
+	for _, tc := range []struct{ security, auth string }{
+		{"none", "user"},
+		{"", "apop"},
+		{"explicit", "user"},
+		{"implicit", "apop"},
+	} {
+		srv := newFakeServer(t, buildMessage("kobo@example.com", "Books", "a.epub", "A"))
+		srv.implicit = tc.security == "implicit"
+
+		c := newTestClient(tc.security, tc.auth, "tanstaaf")
+		if err := c.Connect(time.Second); err != nil {
+			t.Fatalf("%s/%s connect: %v", tc.security, tc.auth, err)
+		}
+		if tc.security == "explicit" && !srv.sawCommand("STLS") {
+			t.Fatalf("expected STLS to be sent")
+		}
+		if tc.security != "explicit" && srv.sawCommand("STLS") {
+			t.Fatalf("%s: unexpected STLS", tc.security)
+		}
+		infos, err := c.List()
+		if err != nil || len(infos) != 1 || infos[0].ID != 1 {
+			t.Fatalf("%s/%s list: %+v %v", tc.security, tc.auth, infos, err)
+		}
+		if err := c.Disconnect(); err != nil {
+			t.Fatalf("disconnect: %v", err)
+		}
+		if !srv.quit {
+			t.Fatalf("expected QUIT")
+		}
+	}
+}
+
+// TestPop3Client_ConnectErrors surfaces dial, TLS, STLS and login failures.
+func TestPop3Client_ConnectErrors(t *testing.T) {
+	newFakeServer(t)
+	if err := newTestClient("none", "user", "wrong").Connect(time.Second); err == nil || !strings.Contains(err.Error(), "log in") {
+		t.Fatalf("expected login error, got %v", err)
+	}
+	if err := newTestClient("none", "cram", "tanstaaf").Connect(time.Second); err == nil {
+		t.Fatalf("expected unsupported auth method")
+	}
+	if err := newTestClient("ssl", "user", "tanstaaf").Connect(time.Second); err == nil {
+		t.Fatalf("expected unsupported security mode")
+	}
+
+	srv := newFakeServer(t)
+	srv.noStls = true
+	if err := newTestClient("explicit", "user", "tanstaaf").Connect(time.Second); err == nil || !strings.Contains(err.Error(), "STLS") {
+		t.Fatalf("expected STLS error, got %v", err)
+	}
+
+	c := newTestClient("explicit", "user", "tanstaaf")
+	c.InsecureSkipVerify = false
+	newFakeServer(t)
+	if err := c.Connect(time.Second); err == nil || !strings.Contains(err.Error(), "TLS") {
+		t.Fatalf("expected certificate error, got %v", err)
+	}
+
+	orig := pop3Dial
+	t.Cleanup(func() { pop3Dial = orig })
+	pop3Dial = func(addr string, timeout time.Duration) (net.Conn, error) {
+		if addr != "pop.example:995" {
+			t.Fatalf("unexpected address %s", addr)
+		}
+		return nil, errors.New("refused")
+	}
+	if err := newTestClient("none", "user", "tanstaaf").Connect(time.Second); err == nil {
+		t.Fatalf("expected dial error")
+	}
+}
+
+// TestPop3Client_Messages covers TOP, the RETR fallback, RETR dot-unstuffing and DELE.
+func TestPop3Client_Messages(t *testing.T) {
+	srv := newFakeServer(t,
+		buildMessage("kobo@example.com", "Books", "a.epub", "A"),
+		buildMessage("other@example.com", "News", "b.epub", "B"),
+	)
+	c := newTestClient("none", "user", "tanstaaf")
+	if err := c.Connect(time.Second); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+
+	h, err := c.Header(2)
+	if err != nil || h.Get("Subject") != "News" {
+		t.Fatalf("header: %v %v", h, err)
+	}
+	srv.noTop = true
+	h, err = c.Header(1)
+	if err != nil || h.Get("To") != "kobo@example.com" {
+		t.Fatalf("header via RETR: %v %v", h, err)
+	}
+	if _, err := c.Header(9); err == nil {
+		t.Fatalf("expected error for missing message")
+	}
+
+	raw, err := c.Retrieve(1)
+	if err != nil {
+		t.Fatalf("retrieve: %v", err)
+	}
+	if !strings.Contains(string(raw), "\n.leading dot\n") {
+		t.Fatalf("expected dot-unstuffed body, got %q", raw)
+	}
+
+	if err := c.Delete(1); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if !srv.deleted[1] {
+		t.Fatalf("expected message 1 to be marked deleted")
+	}
+	if err := c.Disconnect(); err != nil {
+		t.Fatalf("disconnect: %v", err)
+	}
+}
+
+// TestPop3Client_NotConnected ensures operations fail before Connect.
+func TestPop3Client_NotConnected(t *testing.T) {
+	c := &Pop3Client{}
+	if _, err := c.List(); !errors.Is(err, ErrPop3Disconnected) {
+		t.Fatalf("list: %v", err)
+	}
+	if _, err := c.Header(1); !errors.Is(err, ErrPop3Disconnected) {
+		t.Fatalf("header: %v", err)
+	}
+	if _, err := c.Retrieve(1); !errors.Is(err, ErrPop3Disconnected) {
+		t.Fatalf("retrieve: %v", err)
+	}
+	if err := c.Delete(1); !errors.Is(err, ErrPop3Disconnected) {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := c.Disconnect(); err != nil {
+		t.Fatalf("disconnect: %v", err)
+	}
+}
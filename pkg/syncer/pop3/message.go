@@ -0,0 +1,106 @@
+package pop3
+
+import (
+	"bytes"
+	"log/slog"
+	"net/mail"
+	"os"
+	"path/filepath"
+
+	"github.com/bjw-s-labs/bookshift/pkg/mailparts"
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+type Pop3Message struct {
+	ID     int
+	Size   int64
+	Header mail.Header
+
+	pop3Client Pop3API
+}
+
+// NewPop3Message represents a single message in the maildrop, addressed by its
+// message number for the duration of the session.
+func NewPop3Message(info Pop3MessageInfo, header mail.Header, conn Pop3API) *Pop3Message {
+	return &Pop3Message{
+		ID:         info.ID,
+		Size:       info.Size,
+		Header:     header,
+		pop3Client: conn,
+	}
+}
+
+// CollectMessages lists the maildrop and returns the messages whose To or
+// Subject header (selected by filterField) contains filterValue.
+func CollectMessages(conn Pop3API, filterField string, filterValue string) ([]*Pop3Message, error) {
+	infos, err := conn.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var filteredMessages []*Pop3Message
+	for _, info := range infos {
+		header, err := conn.Header(info.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !mailparts.HeaderMatches(header, filterField, filterValue) {
+			slog.Debug("Skipping message not matching the filter", "id", info.ID, "subject", mailparts.Subject(header))
+			continue
+		}
+		filteredMessages = append(filteredMessages, NewPop3Message(info, header, conn))
+	}
+	return filteredMessages, nil
+}
+
+// DownloadAttachments downloads all valid attachments to dstFolder and optionally deletes the message.
+func (pm *Pop3Message) DownloadAttachments(dstFolder string, validExtensions []string, overwriteExistingFile bool, removeMessageAfterDownload bool) error {
+	raw, err := pm.pop3Client.Retrieve(pm.ID)
+	if err != nil {
+		return err
+	}
+
+	header, attachments, err := mailparts.ExtractAttachments(bytes.NewReader(raw), validExtensions)
+	if err != nil {
+		return err
+	}
+	messageSender := mailparts.Sender(header)
+	messageSubject := mailparts.Subject(header)
+
+	// Create target folder if required
+	if _, err := os.Stat(dstFolder); os.IsNotExist(err) {
+		if util.DryRun {
+			slog.Info("[dry-run] Would create local folder", "folder", dstFolder)
+			return nil
+		}
+		slog.Info("Creating local folder", "folder", dstFolder)
+		if err := os.MkdirAll(dstFolder, 0755); err != nil {
+			return err
+		}
+	}
+
+	// Download the attachments
+	for _, attachment := range attachments {
+		slog.Info("Downloading email attachment", "host", pm.pop3Client.Hostname(), "sender", messageSender, "subject", messageSubject, "filename", attachment.Filename)
+
+		dstPath, err := mailparts.SaveAttachment(dstFolder, attachment.Filename, int64(len(attachment.Content)), bytes.NewReader(attachment.Content), overwriteExistingFile)
+		if err != nil {
+			return err
+		}
+		if dstPath != "" {
+			slog.Info("Successfully downloaded attachment", "id", pm.ID, "filename", filepath.Base(dstPath), "path", dstPath)
+		}
+	}
+
+	if removeMessageAfterDownload {
+		if util.DryRun {
+			slog.Info("[dry-run] Would delete message from server", "id", pm.ID)
+		} else {
+			if err := pm.pop3Client.Delete(pm.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
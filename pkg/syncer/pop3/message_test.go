@@ -0,0 +1,93 @@
+package pop3
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// TestCollectMessages filters by To or Subject.
+func TestCollectMessages(t *testing.T) {
+	fake := &fakePop3{messages: []string{
+		buildMessage("Kobo <kobo@example.com>", "Books", "a.epub", "A"),
+		buildMessage("other@example.com", "Weekly books", "b.epub", "B"),
+	}}
+
+	msgs, err := CollectMessages(fake, "to", "KOBO@example.com")
+	if err != nil || len(msgs) != 1 || msgs[0].ID != 1 {
+		t.Fatalf("to filter: %+v %v", msgs, err)
+	}
+	msgs, err = CollectMessages(fake, "subject", "books")
+	if err != nil || len(msgs) != 2 {
+		t.Fatalf("subject filter: %+v %v", msgs, err)
+	}
+
+	if _, err := CollectMessages(&fakePop3{listErr: errors.New("boom")}, "to", "x"); err == nil {
+		t.Fatalf("expected list error")
+	}
+	fake.headerErr = errors.New("boom")
+	if _, err := CollectMessages(fake, "to", "x"); err == nil {
+		t.Fatalf("expected header error")
+	}
+}
+
+// TestDownloadAttachments_DownloadsAndDeletes verifies decoding, filtering and deletion.
+func TestDownloadAttachments_DownloadsAndDeletes(t *testing.T) {
+	fake := &fakePop3{messages: []string{buildMessage("kobo@example.com", "Books", "Report.epub", "DATA")}}
+	msg := NewPop3Message(Pop3MessageInfo{ID: 1}, nil, fake)
+
+	dir := filepath.Join(t.TempDir(), "nested")
+	if err := msg.DownloadAttachments(dir, []string{".epub"}, false, true); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "report.epub"))
+	if err != nil || string(b) != "DATA" {
+		t.Fatalf("unexpected file: %q %v", b, err)
+	}
+	if len(fake.deleted) != 1 || fake.deleted[0] != 1 {
+		t.Fatalf("expected message 1 to be deleted, got %v", fake.deleted)
+	}
+
+	// Filtered out attachments are not written, existing files are kept
+	other := t.TempDir()
+	if err := msg.DownloadAttachments(other, []string{".pdf"}, false, false); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if entries, _ := os.ReadDir(other); len(entries) != 0 {
+		t.Fatalf("expected no files, got %v", entries)
+	}
+	_ = os.WriteFile(filepath.Join(dir, "report.epub"), []byte("OLD"), 0o644)
+	if err := msg.DownloadAttachments(dir, []string{".epub"}, false, false); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "report.epub")); string(b) != "OLD" {
+		t.Fatalf("existing file overwritten: %q", b)
+	}
+}
+
+// TestDownloadAttachments_ErrorsAndDryRun surfaces server errors and keeps dry-run side-effect free.
+func TestDownloadAttachments_ErrorsAndDryRun(t *testing.T) {
+	raw := buildMessage("kobo@example.com", "Books", "a.epub", "A")
+
+	if err := NewPop3Message(Pop3MessageInfo{ID: 1}, nil, &fakePop3{messages: []string{raw}, retrErr: errors.New("boom")}).DownloadAttachments(t.TempDir(), nil, false, false); err == nil {
+		t.Fatalf("expected retrieve error")
+	}
+	if err := NewPop3Message(Pop3MessageInfo{ID: 1}, nil, &fakePop3{messages: []string{raw}, deleteErr: errors.New("boom")}).DownloadAttachments(t.TempDir(), nil, false, true); err == nil {
+		t.Fatalf("expected delete error")
+	}
+
+	old := util.DryRun
+	t.Cleanup(func() { util.DryRun = old })
+	util.DryRun = true
+	fake := &fakePop3{messages: []string{raw}}
+	dir := t.TempDir()
+	if err := NewPop3Message(Pop3MessageInfo{ID: 1}, nil, fake).DownloadAttachments(dir, nil, false, true); err != nil {
+		t.Fatalf("dry-run: %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 || len(fake.deleted) != 0 {
+		t.Fatalf("dry-run changed state: %v %v", entries, fake.deleted)
+	}
+}
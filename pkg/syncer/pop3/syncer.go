@@ -0,0 +1,91 @@
+package pop3
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+type Pop3Syncer struct {
+	config *config.Pop3Config
+}
+
+func NewPop3Syncer(mailConfig *config.Pop3Config) *Pop3Syncer {
+	// Default to implicit TLS
+	if mailConfig.Security == "" {
+		mailConfig.Security = "implicit"
+	}
+
+	// Set default port if nothing is specified
+	if !(mailConfig.Port > 0) {
+		if mailConfig.Security == "implicit" {
+			mailConfig.Port = 995
+		} else {
+			mailConfig.Port = 110
+		}
+	}
+
+	if mailConfig.AuthMethod == "" {
+		mailConfig.AuthMethod = "user"
+	}
+
+	return &Pop3Syncer{
+		config: mailConfig,
+	}
+}
+
+func (s *Pop3Syncer) Run(targetFolder string, validExtensions []string, overwriteExistingFiles bool) error {
+	return s.RunContext(context.Background(), targetFolder, validExtensions, overwriteExistingFiles)
+}
+
+func (s *Pop3Syncer) RunContext(ctx context.Context, targetFolder string, validExtensions []string, overwriteExistingFiles bool) (err error) {
+	if s.config.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(s.config.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	// Connect to the POP3 server
+	pop3Connection := newPop3Client(s.config)
+	if err := pop3Connect(pop3Connection, 60*time.Second); err != nil {
+		return fmt.Errorf("could not connect to POP3 server %s: %w", s.config.Host, err)
+	}
+	// Deletions are only committed when QUIT succeeds, so that error matters
+	defer func() {
+		if quitErr := pop3Disconnect(pop3Connection); quitErr != nil && err == nil && s.config.RemoveEmailsAfterDownload {
+			err = fmt.Errorf("could not close POP3 session with %s: %w", s.config.Host, quitErr)
+		}
+	}()
+
+	// Collect messages from the POP3 server
+	allMessages, err := pop3Collect(pop3Connection, s.config.FilterField, s.config.FilterValue)
+	if err != nil {
+		return err
+	}
+
+	// Download attachments for each message
+	for _, m := range allMessages {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		if err := pop3Download(m,
+			targetFolder,
+			validExtensions,
+			overwriteExistingFiles,
+			s.config.RemoveEmailsAfterDownload,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
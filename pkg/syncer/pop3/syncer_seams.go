@@ -0,0 +1,35 @@
+// syncer_seams.go: Test seams for the POP3 syncer.
+//
+// This file defines small function variables that production code uses by default,
+// but tests can override to substitute fakes. Keeping these seams in a separate
+// file keeps the main implementation clean while preserving simple overrides in tests.
+package pop3
+
+import (
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+// test hooks (seams) for dependency injection in tests
+var (
+	newPop3Client = func(cfg *config.Pop3Config) Pop3API {
+		return &Pop3Client{
+			Host:               cfg.Host,
+			Port:               cfg.Port,
+			Username:           cfg.Username,
+			Password:           cfg.Password,
+			Security:           cfg.Security,
+			AuthMethod:         cfg.AuthMethod,
+			InsecureSkipVerify: cfg.InsecureSkipVerify,
+		}
+	}
+	pop3Connect    = func(c Pop3API, timeout time.Duration) error { return c.Connect(timeout) }
+	pop3Disconnect = func(c Pop3API) error { return c.Disconnect() }
+	pop3Collect    = func(c Pop3API, field, value string) ([]*Pop3Message, error) {
+		return CollectMessages(c, field, value)
+	}
+	pop3Download = func(m *Pop3Message, dst string, valid []string, overwrite bool, remove bool) error {
+		return m.DownloadAttachments(dst, valid, overwrite, remove)
+	}
+)
@@ -0,0 +1,104 @@
+package pop3
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/go-playground/sensitive"
+)
+
+// TestNewPop3Syncer_Defaults sets security, port and auth defaults.
+func TestNewPop3Syncer_Defaults(t *testing.T) {
+	s := NewPop3Syncer(&config.Pop3Config{})
+	if s.config.Security != "implicit" || s.config.Port != 995 || s.config.AuthMethod != "user" {
+		t.Fatalf("unexpected defaults: %+v", s.config)
+	}
+	s = NewPop3Syncer(&config.Pop3Config{Security: "explicit"})
+	if s.config.Port != 110 {
+		t.Fatalf("want port 110, got %d", s.config.Port)
+	}
+}
+
+// TestPop3Syncer_Run_EndToEnd downloads from the fake server and commits deletions with QUIT.
+func TestPop3Syncer_Run_EndToEnd(t *testing.T) {
+	srv := newFakeServer(t,
+		buildMessage("kobo@example.com", "Books", "a.epub", "A"),
+		buildMessage("someone@example.com", "Other", "b.epub", "B"),
+	)
+	pw := sensitive.String("tanstaaf")
+	cfg := &config.Pop3Config{
+		Host:                      "pop.example",
+		Username:                  "user",
+		Password:                  &pw,
+		InsecureSkipVerify:        true,
+		FilterField:               "to",
+		FilterValue:               "kobo@example.com",
+		RemoveEmailsAfterDownload: true,
+	}
+	srv.implicit = true
+
+	dst := t.TempDir()
+	if err := NewPop3Syncer(cfg).Run(dst, []string{".epub"}, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if b, err := os.ReadFile(filepath.Join(dst, "a.epub")); err != nil || string(b) != "A" {
+		t.Fatalf("unexpected file: %q %v", b, err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "b.epub")); !os.IsNotExist(err) {
+		t.Fatalf("unfiltered message was downloaded")
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if !srv.deleted[1] || srv.deleted[2] || !srv.quit {
+		t.Fatalf("unexpected deletions %v (quit=%v)", srv.deleted, srv.quit)
+	}
+}
+
+// TestPop3Syncer_Run_Seams covers connect, collect and download errors, QUIT failures and cancellation.
+func TestPop3Syncer_Run_Seams(t *testing.T) {
+	origNew, origConnect := newPop3Client, pop3Connect
+	t.Cleanup(func() { newPop3Client, pop3Connect = origNew, origConnect })
+
+	fake := &fakePop3{messages: []string{buildMessage("kobo@example.com", "Books", "a.epub", "A")}}
+	newPop3Client = func(cfg *config.Pop3Config) Pop3API { return fake }
+	cfg := &config.Pop3Config{Host: "h", FilterField: "subject", FilterValue: "books"}
+
+	pop3Connect = func(c Pop3API, timeout time.Duration) error { return errors.New("refused") }
+	if err := NewPop3Syncer(cfg).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected connect error")
+	}
+	pop3Connect = origConnect
+
+	fake.listErr = errors.New("boom")
+	if err := NewPop3Syncer(cfg).Run(t.TempDir(), nil, false); err == nil || !fake.disconnected {
+		t.Fatalf("expected collect error and disconnect, got %v", err)
+	}
+	fake.listErr = nil
+
+	fake.retrErr = errors.New("boom")
+	if err := NewPop3Syncer(cfg).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected download error")
+	}
+	fake.retrErr = nil
+
+	// A failed QUIT only matters when deletions have to be committed
+	fake.quitErr = errors.New("quit failed")
+	if err := NewPop3Syncer(cfg).Run(t.TempDir(), nil, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cfg.RemoveEmailsAfterDownload = true
+	if err := NewPop3Syncer(cfg).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected QUIT error")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := NewPop3Syncer(cfg).RunContext(ctx, t.TempDir(), nil, false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+}
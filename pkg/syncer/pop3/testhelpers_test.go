@@ -0,0 +1,252 @@
+package pop3
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http/httptest"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testTimestamp = "<1896.697170952@dbc.mtview.ca.us>"
+
+// fakeServer is a minimal in-memory POP3 server reached through the pop3Dial seam.
+type fakeServer struct {
+	username string
+	password string
+	messages []string
+	implicit bool
+	noTop    bool
+	noStls   bool
+
+	tlsConfig *tls.Config
+
+	mu       sync.Mutex
+	commands []string
+	deleted  map[int]bool
+	quit     bool
+}
+
+// newFakeServer installs the server behind pop3Dial for the duration of the test.
+func newFakeServer(t *testing.T, messages ...string) *fakeServer {
+	t.Helper()
+	ts := httptest.NewTLSServer(nil)
+	t.Cleanup(ts.Close)
+
+	s := &fakeServer{
+		username:  "user",
+		password:  "tanstaaf",
+		messages:  messages,
+		tlsConfig: &tls.Config{Certificates: ts.TLS.Certificates},
+		deleted:   map[int]bool{},
+	}
+
+	orig := pop3Dial
+	t.Cleanup(func() { pop3Dial = orig })
+	pop3Dial = func(addr string, timeout time.Duration) (net.Conn, error) {
+		client, server := net.Pipe()
+		go s.serve(server)
+		return client, nil
+	}
+	return s
+}
+
+func (s *fakeServer) serve(conn net.Conn) {
+	defer conn.Close()
+	if s.implicit {
+		conn = tls.Server(conn, s.tlsConfig)
+	}
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	reply := func(format string, args ...any) {
+		fmt.Fprintf(w, format+"\r\n", args...)
+		w.Flush()
+	}
+
+	reply("+OK POP3 server ready %s", testTimestamp)
+	var user string
+	authed := false
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(strings.TrimSpace(line))
+		if len(fields) == 0 {
+			continue
+		}
+		cmd := strings.ToUpper(fields[0])
+		s.mu.Lock()
+		s.commands = append(s.commands, cmd)
+		s.mu.Unlock()
+
+		if !authed && cmd != "USER" && cmd != "PASS" && cmd != "APOP" && cmd != "STLS" && cmd != "QUIT" {
+			reply("-ERR not authenticated")
+			continue
+		}
+
+		switch cmd {
+		case "STLS":
+			if s.noStls {
+				reply("-ERR not supported")
+				continue
+			}
+			reply("+OK begin TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			r = bufio.NewReader(conn)
+			w = bufio.NewWriter(conn)
+		case "USER":
+			user = fields[1]
+			reply("+OK")
+		case "PASS":
+			if user == s.username && len(fields) > 1 && fields[1] == s.password {
+				authed = true
+				reply("+OK logged in")
+			} else {
+				reply("-ERR invalid password")
+			}
+		case "APOP":
+			if len(fields) == 3 && fields[1] == s.username && fields[2] == apopDigest(testTimestamp, s.password) {
+				authed = true
+				reply("+OK logged in")
+			} else {
+				reply("-ERR permission denied")
+			}
+		case "LIST":
+			reply("+OK %d messages", len(s.messages))
+			for i, m := range s.messages {
+				if !s.deleted[i+1] {
+					fmt.Fprintf(w, "%d %d\r\n", i+1, len(m))
+				}
+			}
+			reply(".")
+		case "TOP", "RETR":
+			if cmd == "TOP" && s.noTop {
+				reply("-ERR unknown command")
+				continue
+			}
+			var id int
+			fmt.Sscanf(fields[1], "%d", &id)
+			if id < 1 || id > len(s.messages) || s.deleted[id] {
+				reply("-ERR no such message")
+				continue
+			}
+			msg := s.messages[id-1]
+			if cmd == "TOP" {
+				msg = msg[:strings.Index(msg, "\r\n\r\n")+2]
+			}
+			reply("+OK")
+			for _, l := range strings.Split(strings.TrimSuffix(msg, "\r\n"), "\r\n") {
+				if strings.HasPrefix(l, ".") {
+					l = "." + l
+				}
+				fmt.Fprintf(w, "%s\r\n", l)
+			}
+			reply(".")
+		case "DELE":
+			var id int
+			fmt.Sscanf(fields[1], "%d", &id)
+			s.mu.Lock()
+			s.deleted[id] = true
+			s.mu.Unlock()
+			reply("+OK deleted")
+		case "QUIT":
+			s.mu.Lock()
+			s.quit = true
+			s.mu.Unlock()
+			reply("+OK bye")
+			return
+		default:
+			reply("-ERR unknown command")
+		}
+	}
+}
+
+func (s *fakeServer) sawCommand(cmd string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.commands {
+		if c == cmd {
+			return true
+		}
+	}
+	return false
+}
+
+// buildMessage assembles a message with a text body and a base64 encoded attachment.
+func buildMessage(to, subject, filename, content string) string {
+	return "From: Alice <alice@example.com>\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/mixed; boundary=\"b\"\r\n" +
+		"\r\n" +
+		"--b\r\nContent-Type: text/plain\r\n\r\n.leading dot\r\n" +
+		"--b\r\n" +
+		"Content-Type: application/epub+zip\r\n" +
+		"Content-Disposition: attachment; filename=\"" + filename + "\"\r\n" +
+		"Content-Transfer-Encoding: base64\r\n\r\n" +
+		base64.StdEncoding.EncodeToString([]byte(content)) + "\r\n" +
+		"--b--\r\n"
+}
+
+// fakePop3 is an in-memory Pop3API used by message and syncer tests.
+type fakePop3 struct {
+	messages  []string
+	listErr   error
+	headerErr error
+	retrErr   error
+	deleteErr error
+	quitErr   error
+
+	deleted      []int
+	connected    bool
+	disconnected bool
+}
+
+func (f *fakePop3) Connect(timeout time.Duration) error { f.connected = true; return nil }
+func (f *fakePop3) Disconnect() error                   { f.disconnected = true; return f.quitErr }
+func (f *fakePop3) List() ([]Pop3MessageInfo, error) {
+	if f.listErr != nil {
+		return nil, f.listErr
+	}
+	var infos []Pop3MessageInfo
+	for i, m := range f.messages {
+		infos = append(infos, Pop3MessageInfo{ID: i + 1, Size: int64(len(m))})
+	}
+	return infos, nil
+}
+func (f *fakePop3) Header(id int) (mail.Header, error) {
+	if f.headerErr != nil {
+		return nil, f.headerErr
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(f.messages[id-1]))
+	if err != nil {
+		return nil, err
+	}
+	return msg.Header, nil
+}
+func (f *fakePop3) Retrieve(id int) ([]byte, error) {
+	if f.retrErr != nil {
+		return nil, f.retrErr
+	}
+	return []byte(f.messages[id-1]), nil
+}
+func (f *fakePop3) Delete(id int) error {
+	if f.deleteErr != nil {
+		return f.deleteErr
+	}
+	f.deleted = append(f.deleted, id)
+	return nil
+}
+func (f *fakePop3) Hostname() string { return "pop.example" }
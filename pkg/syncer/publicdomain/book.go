@@ -0,0 +1,91 @@
+package publicdomain
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+type CatalogBook struct {
+	ref      BookRef
+	file     BookFile
+	fileName string
+
+	catalogClient CatalogAPI
+}
+
+// NewCatalogBook names the local file after the file in the catalog.
+func NewCatalogBook(ref BookRef, file BookFile, conn CatalogAPI) *CatalogBook {
+	return &CatalogBook{
+		ref:           ref,
+		file:          file,
+		fileName:      util.SafeFileName(file.FileName),
+		catalogClient: conn,
+	}
+}
+
+// FileName returns the local file name of the book.
+func (b *CatalogBook) FileName() string {
+	return b.fileName
+}
+
+func (b *CatalogBook) Download(dstFolder string, overwriteExistingFile bool) error {
+	dstPath := filepath.Join(dstFolder, b.fileName)
+
+	// Create folder structure if required
+	if _, err := os.Stat(dstFolder); os.IsNotExist(err) {
+		if util.DryRun {
+			slog.Info("[dry-run] Would create local folder", "folder", dstFolder)
+			return nil
+		}
+		slog.Info("Creating local folder", "folder", dstFolder)
+		if err := os.MkdirAll(dstFolder, 0755); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("Downloading book from catalog", "book", b.ref, "url", b.file.URL, "destination", dstPath)
+
+	// Check if the file already exists
+	_, err := os.Stat(dstPath)
+	if !os.IsNotExist(err) {
+		if !overwriteExistingFile {
+			slog.Warn("File already exists, skipping download", "file", dstPath)
+			return nil
+		}
+
+		slog.Info("Overwriting existing file", "file", dstPath)
+	}
+
+	// Download the file
+	if util.DryRun {
+		slog.Info("[dry-run] Would download book", "book", b.ref, "destination", dstPath)
+		return nil
+	}
+	tmpFile, err := os.CreateTemp(dstFolder, "bookshift-")
+	if err != nil {
+		return err
+	}
+	defer tmpFile.Close()
+
+	slog.Debug("Downloading to temporary file", "file", tmpFile.Name())
+	writer := util.NewFileWriter(tmpFile, b.file.Size, true)
+	if _, err := b.catalogClient.ReadFile(b.file, writer); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+
+	if err := tmpFile.Sync(); err != nil { // ensure data flushed before rename
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	if err := os.Rename(tmpFile.Name(), dstPath); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+
+	slog.Info("Successfully downloaded file", "filename", b.fileName)
+	return nil
+}
@@ -0,0 +1,66 @@
+package publicdomain
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// TestCatalogBook_Download writes the book and keeps existing files.
+func TestCatalogBook_Download(t *testing.T) {
+	fake := &fakeCatalog{files: map[string]string{"gutenberg:84": "FRANK"}}
+	ref := BookRef{CatalogGutenberg, "84"}
+	file, _ := fake.Resolve(ref, false)
+	b := NewCatalogBook(ref, file, fake)
+	if b.FileName() != "84.epub" {
+		t.Fatalf("unexpected file name %q", b.FileName())
+	}
+
+	dst := filepath.Join(t.TempDir(), "new")
+	if err := b.Download(dst, false); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dst, "84.epub")); err != nil || string(data) != "FRANK" {
+		t.Fatalf("unexpected file: %q %v", data, err)
+	}
+
+	fake.files["gutenberg:84"] = "NEW"
+	if err := b.Download(dst, false); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "84.epub")); string(data) != "FRANK" {
+		t.Fatalf("existing file must be kept")
+	}
+
+	fake.readErr = errors.New("boom")
+	if err := b.Download(dst, true); err == nil {
+		t.Fatalf("expected read error")
+	}
+	if entries, _ := os.ReadDir(dst); len(entries) != 1 {
+		t.Fatalf("expected no temporary leftovers, got %d entries", len(entries))
+	}
+}
+
+// TestCatalogBook_DryRun ensures nothing is written.
+func TestCatalogBook_DryRun(t *testing.T) {
+	old := util.DryRun
+	t.Cleanup(func() { util.DryRun = old })
+	util.DryRun = true
+
+	fake := &fakeCatalog{files: map[string]string{"gutenberg:84": "FRANK"}}
+	ref := BookRef{CatalogGutenberg, "84"}
+	file, _ := fake.Resolve(ref, false)
+	b := NewCatalogBook(ref, file, fake)
+	dst := t.TempDir()
+	for _, folder := range []string{dst, filepath.Join(dst, "missing")} {
+		if err := b.Download(folder, true); err != nil {
+			t.Fatalf("dry-run: %v", err)
+		}
+	}
+	if entries, _ := os.ReadDir(dst); len(entries) != 0 {
+		t.Fatalf("dry-run must not write")
+	}
+}
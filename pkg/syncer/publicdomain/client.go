@@ -0,0 +1,186 @@
+package publicdomain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// CatalogAPI is the minimal contract used by the syncer and book logic. It
+// enables injecting a fake in tests.
+type CatalogAPI interface {
+	Connect(ctx context.Context, timeout time.Duration) error
+	Disconnect() error
+	Resolve(ref BookRef, preferKepub bool) (BookFile, error)
+	ReadFile(file BookFile, w io.Writer) (int64, error)
+}
+
+// BookFile is a downloadable EPUB of a book.
+type BookFile struct {
+	URL      string
+	FileName string
+	Size     int64
+}
+
+// Package-level errors
+var (
+	ErrCatalogDisconnected = fmt.Errorf("not connected to the catalog")
+	ErrBookNotFound        = errors.New("no EPUB download found")
+)
+
+// CatalogClient downloads books from Project Gutenberg and Standard Ebooks,
+// or from mirrors serving the same paths.
+type CatalogClient struct {
+	gutenbergURL      *url.URL
+	standardEbooksURL *url.URL
+
+	// ctx bounds every request of the session
+	ctx    context.Context
+	client *http.Client
+}
+
+func NewCatalogClient(gutenbergURL string, standardEbooksURL string) (*CatalogClient, error) {
+	gu, err := parseBaseURL(gutenbergURL)
+	if err != nil {
+		return nil, err
+	}
+	su, err := parseBaseURL(standardEbooksURL)
+	if err != nil {
+		return nil, err
+	}
+	return &CatalogClient{gutenbergURL: gu, standardEbooksURL: su}, nil
+}
+
+func parseBaseURL(baseURL string) (*url.URL, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid catalog url %s: %w", baseURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid catalog url %s: scheme must be http or https", baseURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	return u, nil
+}
+
+// Connect prepares the HTTP client used for all requests. The timeout bounds
+// connecting and waiting for responses, not the transfer of books.
+func (c *CatalogClient) Connect(ctx context.Context, timeout time.Duration) error {
+	slog.Debug("Initiating catalog connection", "gutenberg", c.gutenbergURL.Host, "standard_ebooks", c.standardEbooksURL.Host)
+	c.ctx = ctx
+	c.client = util.NewHTTPClient(timeout)
+	return nil
+}
+
+// Disconnect releases idle connections held by the HTTP client.
+func (c *CatalogClient) Disconnect() error {
+	if c.client != nil {
+		slog.Debug("Disconnecting catalog connection")
+		c.client.CloseIdleConnections()
+		c.client = nil
+	}
+	return nil
+}
+
+// Resolve finds the best available EPUB of a book. The candidates are tried
+// in order of preference; the first one the server has is returned.
+func (c *CatalogClient) Resolve(ref BookRef, preferKepub bool) (BookFile, error) {
+	if c.client == nil {
+		return BookFile{}, ErrCatalogDisconnected
+	}
+
+	for _, file := range c.candidates(ref, preferKepub) {
+		resp, err := c.request(http.MethodHead, file.URL)
+		if err != nil {
+			return BookFile{}, err
+		}
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+			slog.Debug("Download not available", "book", ref, "url", file.URL)
+			continue
+		case resp.StatusCode != http.StatusOK:
+			return BookFile{}, fmt.Errorf("failed to check %s: %s", file.URL, resp.Status)
+		}
+		if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == "text/html" {
+			// A landing page instead of the book, e.g. on a misconfigured mirror
+			slog.Debug("Download is a web page, skipping", "book", ref, "url", file.URL)
+			continue
+		}
+		file.Size = max(resp.ContentLength, 0)
+		return file, nil
+	}
+	return BookFile{}, fmt.Errorf("%w for %s", ErrBookNotFound, ref)
+}
+
+// ReadFile streams a book to w.
+func (c *CatalogClient) ReadFile(file BookFile, w io.Writer) (int64, error) {
+	if c.client == nil {
+		return 0, ErrCatalogDisconnected
+	}
+	resp, err := c.request(http.MethodGet, file.URL)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed to download %s: %s", file.URL, resp.Status)
+	}
+	return io.Copy(w, resp.Body)
+}
+
+// candidates lists the downloads of a book, best first. Gutenberg offers
+// EPUB3 and EPUB2 with images and EPUB2 without; Standard Ebooks offers a
+// compatible EPUB, an advanced EPUB3 and a KEPUB for Kobo devices.
+func (c *CatalogClient) candidates(ref BookRef, preferKepub bool) []BookFile {
+	var names []string
+	var folder *url.URL
+	switch ref.Catalog {
+	case CatalogGutenberg:
+		u := *c.gutenbergURL
+		u.Path = path.Join(u.Path, "cache/epub", ref.ID)
+		folder = &u
+		names = []string{"pg" + ref.ID + "-images-3.epub", "pg" + ref.ID + "-images.epub", "pg" + ref.ID + ".epub"}
+	case CatalogStandardEbooks:
+		u := *c.standardEbooksURL
+		u.Path = path.Join(u.Path, "ebooks", ref.ID, "downloads")
+		// Standard Ebooks serves a thank-you page instead of the file without it
+		u.RawQuery = "source=download"
+		folder = &u
+		base := strings.ReplaceAll(ref.ID, "/", "_")
+		names = []string{base + ".epub", base + "_advanced.epub", base + ".kepub.epub"}
+		if preferKepub {
+			names = append([]string{base + ".kepub.epub"}, names[:2]...)
+		}
+	default:
+		return nil
+	}
+
+	files := make([]BookFile, 0, len(names))
+	for _, name := range names {
+		u := *folder
+		u.Path = path.Join(u.Path, name)
+		files = append(files, BookFile{URL: u.String(), FileName: name})
+	}
+	return files
+}
+
+func (c *CatalogClient) request(method string, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(c.ctx, method, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.client.Do(req)
+}
@@ -0,0 +1,148 @@
+package publicdomain
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestCatalogClient(t *testing.T, srv *catalogServer) *CatalogClient {
+	t.Helper()
+	c, err := NewCatalogClient(srv.URL+"/", srv.URL)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	if err := c.Connect(context.Background(), 5*time.Second); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Disconnect() })
+	return c
+}
+
+// TestNewCatalogClient rejects invalid URLs.
+func TestNewCatalogClient(t *testing.T) {
+	if _, err := NewCatalogClient("ftp://gutenberg", "https://standardebooks.org"); err == nil {
+		t.Fatalf("expected error for gutenberg url")
+	}
+	if _, err := NewCatalogClient("https://www.gutenberg.org", "://bad"); err == nil {
+		t.Fatalf("expected error for standard ebooks url")
+	}
+}
+
+// TestCatalogClient_Resolve_Gutenberg falls back to older EPUB variants.
+func TestCatalogClient_Resolve_Gutenberg(t *testing.T) {
+	srv := newCatalogServer(t, map[string]string{
+		"/cache/epub/1342/pg1342-images-3.epub": "EPUB3",
+		"/cache/epub/1342/pg1342.epub":          "EPUB2",
+		"/cache/epub/10/pg10.epub":              "OLD",
+	})
+	c := newTestCatalogClient(t, srv)
+
+	file, err := c.Resolve(BookRef{CatalogGutenberg, "1342"}, true)
+	if err != nil || file.FileName != "pg1342-images-3.epub" || file.Size != 5 {
+		t.Fatalf("resolve: %+v %v", file, err)
+	}
+	file, err = c.Resolve(BookRef{CatalogGutenberg, "10"}, false)
+	if err != nil || file.FileName != "pg10.epub" {
+		t.Fatalf("resolve fallback: %+v %v", file, err)
+	}
+	var buf bytes.Buffer
+	if n, err := c.ReadFile(file, &buf); err != nil || n != 3 || buf.String() != "OLD" {
+		t.Fatalf("read: %v %d %q", err, n, buf.String())
+	}
+
+	if _, err := c.Resolve(BookRef{CatalogGutenberg, "99"}, false); !errors.Is(err, ErrBookNotFound) {
+		t.Fatalf("expected ErrBookNotFound, got %v", err)
+	}
+	if _, err := c.ReadFile(BookFile{URL: srv.URL + "/missing.epub"}, &buf); err == nil {
+		t.Fatalf("expected download error")
+	}
+}
+
+// TestCatalogClient_Resolve_StandardEbooks prefers the KEPUB when asked to.
+func TestCatalogClient_Resolve_StandardEbooks(t *testing.T) {
+	dl := "/ebooks/mary-shelley/frankenstein/downloads/"
+	srv := newCatalogServer(t, map[string]string{
+		dl + "mary-shelley_frankenstein.epub":       "EPUB",
+		dl + "mary-shelley_frankenstein.kepub.epub": "KEPUB",
+	})
+	c := newTestCatalogClient(t, srv)
+	ref := BookRef{CatalogStandardEbooks, "mary-shelley/frankenstein"}
+
+	file, err := c.Resolve(ref, false)
+	if err != nil || file.FileName != "mary-shelley_frankenstein.epub" || !strings.HasSuffix(file.URL, "?source=download") {
+		t.Fatalf("resolve: %+v %v", file, err)
+	}
+	file, err = c.Resolve(ref, true)
+	if err != nil || file.FileName != "mary-shelley_frankenstein.kepub.epub" {
+		t.Fatalf("resolve kepub: %+v %v", file, err)
+	}
+	var buf bytes.Buffer
+	if _, err := c.ReadFile(file, &buf); err != nil || buf.String() != "KEPUB" {
+		t.Fatalf("read: %v %q", err, buf.String())
+	}
+}
+
+// TestCatalogClient_Resolve_Errors covers web pages, server errors and a closed client.
+func TestCatalogClient_Resolve_Errors(t *testing.T) {
+	dl := "/ebooks/a/b/downloads/"
+	srv := newCatalogServer(t, map[string]string{
+		dl + "a_b.epub":                   "<html>Landing page</html>",
+		dl + "a_b_advanced.epub":          "EPUB3",
+		"/cache/epub/1/pg1-images-3.epub": "!500",
+	})
+	c := newTestCatalogClient(t, srv)
+
+	// Downloads answered with a web page are skipped
+	file, err := c.Resolve(BookRef{CatalogStandardEbooks, "a/b"}, false)
+	if err != nil || file.FileName != "a_b_advanced.epub" {
+		t.Fatalf("resolve: %+v %v", file, err)
+	}
+	if _, err := c.Resolve(BookRef{CatalogGutenberg, "1"}, false); err == nil || errors.Is(err, ErrBookNotFound) {
+		t.Fatalf("expected server error, got %v", err)
+	}
+	if got := c.candidates(BookRef{"unknown", "1"}, false); len(got) != 0 {
+		t.Fatalf("unexpected candidates: %v", got)
+	}
+
+	_ = c.Disconnect()
+	if _, err := c.Resolve(BookRef{CatalogGutenberg, "1"}, false); !errors.Is(err, ErrCatalogDisconnected) {
+		t.Fatalf("expected ErrCatalogDisconnected, got %v", err)
+	}
+	if _, err := c.ReadFile(BookFile{}, &bytes.Buffer{}); !errors.Is(err, ErrCatalogDisconnected) {
+		t.Fatalf("expected ErrCatalogDisconnected, got %v", err)
+	}
+}
+
+// TestCatalogClient_SlowDownload verifies books may take longer than the
+// connect timeout to download and are stopped by the session context.
+func TestCatalogClient_SlowDownload(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for range 3 {
+			_, _ = w.Write([]byte("chunk"))
+			w.(http.Flusher).Flush()
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c, _ := NewCatalogClient(srv.URL, srv.URL)
+	if err := c.Connect(ctx, 50*time.Millisecond); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := c.ReadFile(BookFile{URL: srv.URL + "/book.epub"}, &buf); err != nil || buf.String() != "chunkchunkchunk" {
+		t.Fatalf("slow download: %q %v", buf.String(), err)
+	}
+
+	cancel()
+	if _, err := c.ReadFile(BookFile{URL: srv.URL + "/book.epub"}, &buf); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package publicdomain
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Catalogs a book can come from
+const (
+	CatalogGutenberg      = "gutenberg"
+	CatalogStandardEbooks = "standardebooks"
+)
+
+// BookRef identifies a book in a catalog. For Project Gutenberg the ID is the
+// ebook number, for Standard Ebooks the path below /ebooks/, e.g.
+// "jane-austen/pride-and-prejudice".
+type BookRef struct {
+	Catalog string
+	ID      string
+}
+
+// String returns the reference as "catalog:id", used as key in the state.
+func (r BookRef) String() string {
+	return r.Catalog + ":" + r.ID
+}
+
+var (
+	gutenbergIDPattern = regexp.MustCompile(`^0*[1-9][0-9]*$`)
+	seSlugPattern      = regexp.MustCompile(`^[a-z0-9-]+(/[a-z0-9_-]+)+$`)
+)
+
+// ParseBookRef accepts a Gutenberg ebook number, a Gutenberg ebook URL
+// ("https://www.gutenberg.org/ebooks/1342") or a Standard Ebooks URL
+// ("https://standardebooks.org/ebooks/jane-austen/pride-and-prejudice").
+// Only the path of a URL is used, so URLs of mirrors work as well.
+func ParseBookRef(s string) (BookRef, error) {
+	s = strings.TrimSpace(s)
+	if gutenbergIDPattern.MatchString(s) {
+		return BookRef{Catalog: CatalogGutenberg, ID: strings.TrimLeft(s, "0")}, nil
+	}
+
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme == "" {
+		return BookRef{}, fmt.Errorf("invalid book %q: expected a Gutenberg id or an ebook URL", s)
+	}
+	p, ok := strings.CutPrefix(strings.Trim(u.Path, "/"), "ebooks/")
+	switch {
+	case !ok:
+	case gutenbergIDPattern.MatchString(p):
+		return BookRef{Catalog: CatalogGutenberg, ID: strings.TrimLeft(p, "0")}, nil
+	case seSlugPattern.MatchString(p):
+		return BookRef{Catalog: CatalogStandardEbooks, ID: p}, nil
+	}
+	return BookRef{}, fmt.Errorf("invalid book %q: not a Gutenberg or Standard Ebooks ebook URL", s)
+}
+
+// ParseBookRefs parses the configured books and the lines of the list file.
+// Empty lines and lines starting with # are ignored; duplicates are dropped.
+func ParseBookRefs(books []string, listFile string) ([]BookRef, error) {
+	entries := append([]string(nil), books...)
+	if listFile != "" {
+		lines, err := readListFile(listFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read book list %s: %w", listFile, err)
+		}
+		entries = append(entries, lines...)
+	}
+
+	var refs []BookRef
+	seen := map[BookRef]bool{}
+	for _, entry := range entries {
+		ref, err := ParseBookRef(entry)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+	return refs, nil
+}
+
+func readListFile(listFile string) ([]string, error) {
+	f, err := os.Open(listFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
@@ -0,0 +1,57 @@
+package publicdomain
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestParseBookRef accepts ids and ebook URLs of both catalogs.
+func TestParseBookRef(t *testing.T) {
+	for in, want := range map[string]BookRef{
+		"1342":                                  {CatalogGutenberg, "1342"},
+		" 084 ":                                 {CatalogGutenberg, "84"},
+		"https://www.gutenberg.org/ebooks/1342": {CatalogGutenberg, "1342"},
+		"http://mirror.local/ebooks/2701/":      {CatalogGutenberg, "2701"},
+		"https://standardebooks.org/ebooks/jane-austen/pride-and-prejudice":                     {CatalogStandardEbooks, "jane-austen/pride-and-prejudice"},
+		"https://standardebooks.org/ebooks/leo-tolstoy/anna-karenina/louise-maude_aylmer-maude": {CatalogStandardEbooks, "leo-tolstoy/anna-karenina/louise-maude_aylmer-maude"},
+	} {
+		got, err := ParseBookRef(in)
+		if err != nil || got != want {
+			t.Fatalf("%q: got %+v %v, want %+v", in, got, err, want)
+		}
+	}
+
+	for _, in := range []string{"", "0", "abc", "https://www.gutenberg.org/files/1342", "https://standardebooks.org/ebooks/jane-austen", "https://standardebooks.org/ebooks/Jane/Pride"} {
+		if _, err := ParseBookRef(in); err == nil {
+			t.Fatalf("%q: expected error", in)
+		}
+	}
+}
+
+// TestParseBookRefs merges the config and the list file and drops duplicates.
+func TestParseBookRefs(t *testing.T) {
+	listFile := filepath.Join(t.TempDir(), "books.txt")
+	if err := os.WriteFile(listFile, []byte("# Book club 2026\n\n84\nhttps://standardebooks.org/ebooks/mary-shelley/frankenstein\n1342\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	refs, err := ParseBookRefs([]string{"1342", "https://www.gutenberg.org/ebooks/2701"}, listFile)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	var keys []string
+	for _, r := range refs {
+		keys = append(keys, r.String())
+	}
+	if !slices.Equal(keys, []string{"gutenberg:1342", "gutenberg:2701", "gutenberg:84", "standardebooks:mary-shelley/frankenstein"}) {
+		t.Fatalf("unexpected refs: %v", keys)
+	}
+
+	if _, err := ParseBookRefs(nil, filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Fatalf("expected error for missing list file")
+	}
+	if _, err := ParseBookRefs([]string{"nonsense"}, ""); err == nil {
+		t.Fatalf("expected error for invalid entry")
+	}
+}
@@ -0,0 +1,136 @@
+package publicdomain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// publicDomainState is persisted between runs to remember downloaded books.
+type publicDomainState struct {
+	Fetched []string `json:"fetched"`
+}
+
+type PublicDomainSyncer struct {
+	config *config.PublicDomainConfig
+}
+
+func NewPublicDomainSyncer(catalogConfig *config.PublicDomainConfig) *PublicDomainSyncer {
+	if catalogConfig.GutenbergURL == "" {
+		catalogConfig.GutenbergURL = "https://www.gutenberg.org"
+	}
+	if catalogConfig.StandardEbooksURL == "" {
+		catalogConfig.StandardEbooksURL = "https://standardebooks.org"
+	}
+
+	return &PublicDomainSyncer{
+		config: catalogConfig,
+	}
+}
+
+func (s *PublicDomainSyncer) Run(targetFolder string, validExtensions []string, overwriteExistingFiles bool) error {
+	return s.RunContext(context.Background(), targetFolder, validExtensions, overwriteExistingFiles)
+}
+
+func (s *PublicDomainSyncer) RunContext(ctx context.Context, targetFolder string, validExtensions []string, overwriteExistingFiles bool) (err error) {
+	if s.config.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(s.config.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	// Books are only downloaded as EPUB
+	if len(validExtensions) > 0 && !slices.ContainsFunc(validExtensions, func(e string) bool { return strings.EqualFold(e, ".epub") }) {
+		return fmt.Errorf("public domain books are downloaded as .epub, which is not in valid_extensions")
+	}
+
+	refs, err := ParseBookRefs(s.config.Books, s.config.BookListFile)
+	if err != nil {
+		return err
+	}
+
+	// Load the books downloaded during previous runs
+	statePath := s.config.StateFile
+	if statePath == "" {
+		statePath = util.DefaultStatePath(targetFolder, "public_domain", s.config.GutenbergURL+"|"+s.config.StandardEbooksURL)
+	}
+	var state publicDomainState
+	if err := util.LoadState(statePath, &state); err != nil {
+		return fmt.Errorf("could not load public domain state from %s: %w", statePath, err)
+	}
+	fetched := map[string]bool{}
+	for _, key := range state.Fetched {
+		fetched[key] = true
+	}
+
+	var pending []BookRef
+	for _, ref := range refs {
+		if fetched[ref.String()] {
+			slog.Debug("Skipping previously downloaded book", "book", ref)
+			continue
+		}
+		pending = append(pending, ref)
+	}
+	slog.Info("Found books to download", "count", len(pending), "listed", len(refs))
+	if len(pending) == 0 {
+		return nil
+	}
+
+	// Connect to the catalogs
+	catalogClient, err := newCatalogClient(s.config)
+	if err != nil {
+		return err
+	}
+	if err := catalogConnect(ctx, catalogClient, 60*time.Second); err != nil {
+		return fmt.Errorf("could not connect to the catalogs: %w", err)
+	}
+	defer catalogClient.Disconnect()
+
+	// The state is saved even when a later book fails
+	defer func() {
+		if saveErr := util.SaveState(statePath, &state); saveErr != nil && err == nil {
+			err = fmt.Errorf("could not save public domain state to %s: %w", statePath, saveErr)
+		}
+	}()
+
+	// Download all books that were not fetched before
+	for _, ref := range pending {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		file, err := catalogResolve(catalogClient, ref, s.config.PreferKepub)
+		if errors.Is(err, ErrBookNotFound) {
+			// A wrong id in a shared list should not block the other books
+			slog.Warn("Could not find an EPUB for book, skipping", "book", ref)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("could not resolve book %s: %w", ref, err)
+		}
+
+		book := NewCatalogBook(ref, file, catalogClient)
+		if err := catalogDownload(book, targetFolder, overwriteExistingFiles); err != nil {
+			return err
+		}
+
+		fetched[ref.String()] = true
+		state.Fetched = append(state.Fetched, ref.String())
+	}
+
+	return nil
+}
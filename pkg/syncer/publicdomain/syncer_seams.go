@@ -0,0 +1,27 @@
+// syncer_seams.go: Test seams for the public domain catalog syncer.
+//
+// This file defines small function variables that production code uses by default,
+// but tests can override to substitute fakes. Keeping these seams in a separate
+// file keeps the main implementation clean while preserving simple overrides in tests.
+package publicdomain
+
+import (
+	"context"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+// test hooks (seams) for dependency injection in tests
+var (
+	newCatalogClient = func(cfg *config.PublicDomainConfig) (CatalogAPI, error) {
+		return NewCatalogClient(cfg.GutenbergURL, cfg.StandardEbooksURL)
+	}
+	catalogConnect = func(ctx context.Context, c CatalogAPI, timeout time.Duration) error { return c.Connect(ctx, timeout) }
+	catalogResolve = func(c CatalogAPI, ref BookRef, preferKepub bool) (BookFile, error) {
+		return c.Resolve(ref, preferKepub)
+	}
+	catalogDownload = func(b *CatalogBook, dst string, overwrite bool) error {
+		return b.Download(dst, overwrite)
+	}
+)
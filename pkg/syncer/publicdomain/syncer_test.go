@@ -0,0 +1,146 @@
+package publicdomain
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+func withFakeCatalog(t *testing.T, f *fakeCatalog) {
+	t.Helper()
+	orig := newCatalogClient
+	t.Cleanup(func() { newCatalogClient = orig })
+	newCatalogClient = func(cfg *config.PublicDomainConfig) (CatalogAPI, error) { return f, nil }
+}
+
+// TestNewPublicDomainSyncer_Defaults ensures the public catalogs are used by default.
+func TestNewPublicDomainSyncer_Defaults(t *testing.T) {
+	cfg := &config.PublicDomainConfig{}
+	NewPublicDomainSyncer(cfg)
+	if cfg.GutenbergURL != "https://www.gutenberg.org" || cfg.StandardEbooksURL != "https://standardebooks.org" {
+		t.Fatalf("unexpected defaults: %+v", cfg)
+	}
+}
+
+// TestPublicDomainSyncer_Run_EndToEnd downloads from a local mirror and skips books fetched before.
+func TestPublicDomainSyncer_Run_EndToEnd(t *testing.T) {
+	srv := newCatalogServer(t, map[string]string{
+		"/cache/epub/84/pg84-images-3.epub": "FRANK",
+		"/ebooks/jane-austen/pride-and-prejudice/downloads/jane-austen_pride-and-prejudice.kepub.epub": "PRIDE",
+	})
+	listFile := filepath.Join(t.TempDir(), "books.txt")
+	if err := os.WriteFile(listFile, []byte("https://standardebooks.org/ebooks/jane-austen/pride-and-prejudice\n999\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.PublicDomainConfig{
+		Books:             []string{"84"},
+		BookListFile:      listFile,
+		GutenbergURL:      srv.URL,
+		StandardEbooksURL: srv.URL,
+		PreferKepub:       true,
+	}
+
+	dst := t.TempDir()
+	if err := NewPublicDomainSyncer(cfg).Run(dst, []string{".EPUB"}, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for name, want := range map[string]string{"pg84-images-3.epub": "FRANK", "jane-austen-pride-and-prejudice.kepub.epub": "PRIDE"} {
+		if data, err := os.ReadFile(filepath.Join(dst, name)); err != nil || string(data) != want {
+			t.Fatalf("%s: %q %v", name, data, err)
+		}
+	}
+
+	// Downloaded books are remembered; the missing book is looked up again
+	srv.requests = nil
+	_ = os.Remove(filepath.Join(dst, "pg84-images-3.epub"))
+	if err := NewPublicDomainSyncer(cfg).Run(dst, []string{".epub"}, false); err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "pg84-images-3.epub")); !os.IsNotExist(err) {
+		t.Fatalf("previously fetched book downloaded again")
+	}
+	for _, r := range srv.requests {
+		if !slices.Contains([]string{"/cache/epub/999/pg999-images-3.epub", "/cache/epub/999/pg999-images.epub", "/cache/epub/999/pg999.epub"}, r[len("HEAD "):]) {
+			t.Fatalf("unexpected request %s", r)
+		}
+	}
+}
+
+// TestPublicDomainSyncer_Run_Errors covers invalid extensions and books, client, resolve and download errors.
+func TestPublicDomainSyncer_Run_Errors(t *testing.T) {
+	if err := NewPublicDomainSyncer(&config.PublicDomainConfig{Books: []string{"84"}}).Run(t.TempDir(), []string{".pdf"}, false); err == nil {
+		t.Fatalf("expected error without .epub in valid extensions")
+	}
+	if err := NewPublicDomainSyncer(&config.PublicDomainConfig{Books: []string{"nonsense"}}).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected error for invalid book")
+	}
+	if err := NewPublicDomainSyncer(&config.PublicDomainConfig{Books: []string{"84"}, GutenbergURL: "ftp://mirror"}).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected client error")
+	}
+
+	f := &fakeCatalog{files: map[string]string{"gutenberg:1": "ONE", "gutenberg:2": "TWO"}, resolveErr: errors.New("x")}
+	withFakeCatalog(t, f)
+	cfg := &config.PublicDomainConfig{Books: []string{"1", "2"}}
+	if err := NewPublicDomainSyncer(cfg).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected resolve error")
+	}
+
+	// The state keeps the first book when the second one fails
+	f.resolveErr = nil
+	origDownload := catalogDownload
+	t.Cleanup(func() { catalogDownload = origDownload })
+	catalogDownload = func(b *CatalogBook, dst string, overwrite bool) error {
+		if b.ref.ID == "2" {
+			return errors.New("x")
+		}
+		return nil
+	}
+	dst := t.TempDir()
+	if err := NewPublicDomainSyncer(cfg).Run(dst, nil, false); err == nil {
+		t.Fatalf("expected download error")
+	}
+	f.resolved = nil
+	catalogDownload = func(b *CatalogBook, dst string, overwrite bool) error { return nil }
+	if err := NewPublicDomainSyncer(cfg).Run(dst, nil, false); err != nil {
+		t.Fatalf("third run: %v", err)
+	}
+	if !slices.Equal(f.resolved, []string{"gutenberg:2"}) {
+		t.Fatalf("expected only the failed book retried, got %v", f.resolved)
+	}
+
+	origConnect := catalogConnect
+	t.Cleanup(func() { catalogConnect = origConnect })
+	catalogConnect = func(ctx context.Context, c CatalogAPI, timeout time.Duration) error { return errors.New("x") }
+	if err := NewPublicDomainSyncer(cfg).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected connect error")
+	}
+}
+
+// TestPublicDomainSyncer_Run_Cancelled stops before starting and between books.
+func TestPublicDomainSyncer_Run_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := NewPublicDomainSyncer(&config.PublicDomainConfig{Books: []string{"1"}}).RunContext(ctx, t.TempDir(), nil, false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	f := &fakeCatalog{files: map[string]string{"gutenberg:1": "ONE", "gutenberg:2": "TWO"}}
+	withFakeCatalog(t, f)
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	origDownload := catalogDownload
+	t.Cleanup(func() { catalogDownload = origDownload })
+	catalogDownload = func(b *CatalogBook, dst string, overwrite bool) error {
+		cancel()
+		return nil
+	}
+	if err := NewPublicDomainSyncer(&config.PublicDomainConfig{Books: []string{"1", "2"}}).RunContext(ctx, t.TempDir(), nil, false); !errors.Is(err, context.Canceled) || len(f.resolved) != 1 {
+		t.Fatalf("expected cancellation after the first book, got %v %v", err, f.resolved)
+	}
+}
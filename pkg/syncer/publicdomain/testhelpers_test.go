@@ -0,0 +1,94 @@
+package publicdomain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// catalogServer is an httptest stand-in for a Gutenberg or Standard Ebooks
+// mirror serving the given files by path. Like Standard Ebooks, it answers
+// /ebooks/ downloads without ?source=download with a web page. Contents
+// starting with "<html" are served as web pages, and "!500" as a server error.
+type catalogServer struct {
+	URL string
+
+	mu       sync.Mutex
+	files    map[string]string
+	requests []string
+}
+
+func newCatalogServer(t *testing.T, files map[string]string) *catalogServer {
+	t.Helper()
+	s := &catalogServer{files: files}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+		content, ok := s.files[r.URL.Path]
+		s.mu.Unlock()
+		switch {
+		case !ok:
+			http.NotFound(w, r)
+		case content == "!500":
+			http.Error(w, "broken", http.StatusInternalServerError)
+		case strings.HasPrefix(r.URL.Path, "/ebooks/") && r.URL.Query().Get("source") != "download":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = io.WriteString(w, "<html>Thank you for downloading</html>")
+		case strings.HasPrefix(content, "<html"):
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = io.WriteString(w, content)
+		default:
+			w.Header().Set("Content-Type", "application/epub+zip")
+			w.Header().Set("Content-Length", fmt.Sprint(len(content)))
+			if r.Method != http.MethodHead {
+				_, _ = io.WriteString(w, content)
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	s.URL = srv.URL
+	return s
+}
+
+// fakeCatalog is an in-memory CatalogAPI keyed by the string form of a BookRef.
+type fakeCatalog struct {
+	files      map[string]string
+	resolveErr error
+	readErr    error
+	resolved   []string
+}
+
+func (f *fakeCatalog) Connect(context.Context, time.Duration) error { return nil }
+func (f *fakeCatalog) Disconnect() error                            { return nil }
+
+func (f *fakeCatalog) Resolve(ref BookRef, preferKepub bool) (BookFile, error) {
+	f.resolved = append(f.resolved, ref.String())
+	if f.resolveErr != nil {
+		return BookFile{}, f.resolveErr
+	}
+	content, ok := f.files[ref.String()]
+	if !ok {
+		return BookFile{}, fmt.Errorf("%w for %s", ErrBookNotFound, ref)
+	}
+	return BookFile{URL: "http://fake/" + ref.ID, FileName: strings.ReplaceAll(ref.ID, "/", "_") + ".epub", Size: int64(len(content))}, nil
+}
+
+func (f *fakeCatalog) ReadFile(file BookFile, w io.Writer) (int64, error) {
+	if f.readErr != nil {
+		return 0, f.readErr
+	}
+	for key, content := range f.files {
+		if strings.HasSuffix(file.URL, "/"+strings.SplitN(key, ":", 2)[1]) {
+			n, err := io.WriteString(w, content)
+			return int64(n), err
+		}
+	}
+	return 0, errors.New("no such file")
+}
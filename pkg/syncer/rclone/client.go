@@ -0,0 +1,213 @@
+package rclone
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+	"github.com/go-playground/sensitive"
+)
+
+// RcloneAPI is the minimal contract used by the syncer and file logic. It
+// enables injecting a fake in tests.
+type RcloneAPI interface {
+	Connect(ctx context.Context, timeout time.Duration) error
+	Disconnect() error
+	ListFiles() ([]RcloneItem, error)
+	ReadFile(remotePath string, w io.Writer) (int64, error)
+	DeleteFile(remotePath string) error
+	Host() string
+}
+
+// RcloneItem is a file below the configured folder, as listed by
+// operations/list. Path is relative to the folder.
+type RcloneItem struct {
+	Path  string `json:"Path"`
+	Name  string `json:"Name"`
+	Size  int64  `json:"Size"`
+	IsDir bool   `json:"IsDir"`
+}
+
+// Package-level errors
+var (
+	ErrRcloneDisconnected = fmt.Errorf("not connected to rclone")
+)
+
+// RcloneClient talks to the remote control API of "rclone rcd". All paths
+// are relative to fs, the remote joined with the configured folder.
+type RcloneClient struct {
+	baseURL  *url.URL
+	username string
+	password *sensitive.String
+	fs       string
+
+	// ctx bounds every request of the session
+	ctx    context.Context
+	client *http.Client
+}
+
+func NewRcloneClient(rcURL string, username string, password *sensitive.String, remote string, folder string) (*RcloneClient, error) {
+	u, err := url.Parse(rcURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rclone url %s: %w", rcURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid rclone url %s: scheme must be http or https", rcURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	return &RcloneClient{
+		baseURL:  u,
+		username: username,
+		password: password,
+		fs:       joinRemote(remote, folder),
+	}, nil
+}
+
+// joinRemote appends a folder to an rclone remote, e.g. "gdrive:" and
+// "Books" become "gdrive:Books".
+func joinRemote(remote string, folder string) string {
+	folder = strings.Trim(folder, "/")
+	if folder == "" {
+		return remote
+	}
+	if strings.HasSuffix(remote, ":") || strings.HasSuffix(remote, "/") {
+		return remote + folder
+	}
+	return remote + "/" + folder
+}
+
+// Connect prepares the HTTP client and checks that rclone answers. The timeout
+// bounds connecting and waiting for responses, not the transfer of files.
+func (c *RcloneClient) Connect(ctx context.Context, timeout time.Duration) error {
+	slog.Debug("Initiating rclone connection", "host", c.Host(), "fs", c.fs)
+
+	c.ctx = ctx
+	c.client = util.NewHTTPClient(timeout)
+	var version struct {
+		Version string `json:"version"`
+	}
+	if err := c.call("core/version", map[string]any{}, &version); err != nil {
+		c.client = nil
+		return err
+	}
+	slog.Debug("Connected to rclone", "host", c.Host(), "version", version.Version)
+	return nil
+}
+
+func (c *RcloneClient) Disconnect() error {
+	if c.client == nil {
+		return nil
+	}
+	slog.Debug("Disconnecting rclone connection", "host", c.Host())
+	c.client.CloseIdleConnections()
+	c.client = nil
+	return nil
+}
+
+func (c *RcloneClient) Host() string {
+	return c.baseURL.Host
+}
+
+// ListFiles returns all files below the folder, recursively.
+func (c *RcloneClient) ListFiles() ([]RcloneItem, error) {
+	var result struct {
+		List []RcloneItem `json:"list"`
+	}
+	params := map[string]any{
+		"fs":     c.fs,
+		"remote": "",
+		"opt":    map[string]any{"recurse": true, "filesOnly": true, "noMimeType": true},
+	}
+	if err := c.call("operations/list", params, &result); err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", c.fs, err)
+	}
+	return result.List, nil
+}
+
+// ReadFile streams a file through the rc serve endpoint, which serves
+// "[fs]/path" when rclone runs with --rc-serve.
+func (c *RcloneClient) ReadFile(remotePath string, w io.Writer) (int64, error) {
+	u := *c.baseURL
+	u.Path = c.baseURL.Path + "/[" + c.fs + "]/" + strings.TrimPrefix(remotePath, "/")
+	resp, err := c.do(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return 0, fmt.Errorf("failed to download %s: %s (is rclone running with --rc-serve?)", remotePath, resp.Status)
+	default:
+		return 0, fmt.Errorf("failed to download %s: %s", remotePath, resp.Status)
+	}
+	return io.Copy(w, resp.Body)
+}
+
+// DeleteFile removes a file from the remote.
+func (c *RcloneClient) DeleteFile(remotePath string) error {
+	return c.call("operations/deletefile", map[string]any{"fs": c.fs, "remote": remotePath}, nil)
+}
+
+// call invokes an rc method with JSON parameters and decodes the result into v.
+func (c *RcloneClient) call(method string, params any, v any) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	u := *c.baseURL
+	u.Path = path.Join(c.baseURL.Path, method)
+	resp, err := c.do(http.MethodPost, u.String(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// rclone describes failures in a JSON body
+		var rcErr struct {
+			Error string `json:"error"`
+		}
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		if json.Unmarshal(msg, &rcErr) == nil && rcErr.Error != "" {
+			return fmt.Errorf("rclone %s failed: %s: %s", method, resp.Status, rcErr.Error)
+		}
+		return fmt.Errorf("rclone %s failed: %s: %s", method, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if v == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (c *RcloneClient) do(method string, rawURL string, body io.Reader) (*http.Response, error) {
+	if c.client == nil {
+		return nil, ErrRcloneDisconnected
+	}
+	req, err := http.NewRequestWithContext(c.ctx, method, rawURL, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.username != "" {
+		var password string
+		if c.password != nil {
+			password = string(*c.password)
+		}
+		req.SetBasicAuth(c.username, password)
+	}
+	return c.client.Do(req)
+}
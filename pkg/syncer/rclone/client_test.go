@@ -0,0 +1,128 @@
+package rclone
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/sensitive"
+)
+
+func newTestClient(t *testing.T, srv *rcServer, remote, folder string) *RcloneClient {
+	t.Helper()
+	password := sensitive.String(testPassword)
+	c, err := NewRcloneClient(srv.URL+"/", testUsername, &password, remote, folder)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	if err := c.Connect(context.Background(), 5*time.Second); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Disconnect() })
+	return c
+}
+
+// TestJoinRemote appends folders to remotes with and without a path.
+func TestJoinRemote(t *testing.T) {
+	for _, tc := range []struct{ remote, folder, want string }{
+		{"gdrive:", "", "gdrive:"},
+		{"gdrive:", "/Books/", "gdrive:Books"},
+		{"gdrive:Media", "Books", "gdrive:Media/Books"},
+		{"/srv/", "books", "/srv/books"},
+	} {
+		if got := joinRemote(tc.remote, tc.folder); got != tc.want {
+			t.Fatalf("joinRemote(%q, %q) = %q, want %q", tc.remote, tc.folder, got, tc.want)
+		}
+	}
+}
+
+// TestNewRcloneClient rejects invalid URLs.
+func TestNewRcloneClient(t *testing.T) {
+	for _, u := range []string{"ftp://nas:5572", "://bad"} {
+		if _, err := NewRcloneClient(u, "", nil, "gdrive:", ""); err == nil {
+			t.Fatalf("%s: expected error", u)
+		}
+	}
+}
+
+// TestRcloneClient_Connect requires valid credentials.
+func TestRcloneClient_Connect(t *testing.T) {
+	srv := newRcServer(t, "gdrive:Books", nil)
+	newTestClient(t, srv, "gdrive:", "Books")
+
+	c, _ := NewRcloneClient(srv.URL, testUsername, nil, "gdrive:", "Books")
+	if err := c.Connect(context.Background(), 5*time.Second); err == nil || !strings.Contains(err.Error(), "authentication required") {
+		t.Fatalf("expected authentication error, got %v", err)
+	}
+	if _, err := c.ListFiles(); !errors.Is(err, ErrRcloneDisconnected) {
+		t.Fatalf("expected ErrRcloneDisconnected, got %v", err)
+	}
+}
+
+// TestRcloneClient_ListReadDelete lists, streams and deletes files of the remote folder.
+func TestRcloneClient_ListReadDelete(t *testing.T) {
+	srv := newRcServer(t, "gdrive:Books", map[string]string{"a.epub": "A", "Sci Fi/b c.epub": "BC"})
+	c := newTestClient(t, srv, "gdrive:", "Books")
+
+	items, err := c.ListFiles()
+	if err != nil || len(items) != 2 || items[0].Path != "Sci Fi/b c.epub" || items[0].Size != 2 {
+		t.Fatalf("list: %v %+v", err, items)
+	}
+
+	var buf bytes.Buffer
+	if n, err := c.ReadFile("Sci Fi/b c.epub", &buf); err != nil || n != 2 || buf.String() != "BC" {
+		t.Fatalf("read: %v %d %q", err, n, buf.String())
+	}
+	if _, err := c.ReadFile("missing.epub", &buf); err == nil || !strings.Contains(err.Error(), "--rc-serve") {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+
+	if err := c.DeleteFile("a.epub"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := c.DeleteFile("a.epub"); err == nil || !strings.Contains(err.Error(), "object not found") {
+		t.Fatalf("expected rclone error, got %v", err)
+	}
+
+	wrong := newTestClient(t, srv, "gdrive:", "Other")
+	if _, err := wrong.ListFiles(); err == nil || !strings.Contains(err.Error(), "directory not found") {
+		t.Fatalf("expected list error, got %v", err)
+	}
+}
+
+// TestRcloneClient_SlowDownload verifies files may take longer than the
+// connect timeout to download and are stopped by the session context.
+func TestRcloneClient_SlowDownload(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/core/version" {
+			_, _ = w.Write([]byte(`{"version":"v1.68.0"}`))
+			return
+		}
+		for range 3 {
+			_, _ = w.Write([]byte("chunk"))
+			w.(http.Flusher).Flush()
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c, _ := NewRcloneClient(srv.URL, "", nil, "books:", "")
+	if err := c.Connect(ctx, 50*time.Millisecond); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := c.ReadFile("book.epub", &buf); err != nil || buf.String() != "chunkchunkchunk" {
+		t.Fatalf("slow download: %q %v", buf.String(), err)
+	}
+
+	cancel()
+	if _, err := c.ReadFile("book.epub", &buf); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+}
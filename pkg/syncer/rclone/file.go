@@ -0,0 +1,117 @@
+package rclone
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+type RcloneFile struct {
+	item   RcloneItem
+	client RcloneAPI
+
+	subFolder string
+}
+
+func NewRcloneFile(item RcloneItem, client RcloneAPI) *RcloneFile {
+	f := &RcloneFile{
+		item:   item,
+		client: client,
+	}
+	if dir := path.Dir(item.Path); dir != "." && dir != "/" {
+		f.subFolder = filepath.FromSlash(dir)
+	}
+	return f
+}
+
+func (f *RcloneFile) Download(dstFolder string, overwriteExistingFile bool, keepFolderStructure bool, deleteSourceFile bool) error {
+	// Create folder structure if required
+	if keepFolderStructure && f.subFolder != "" {
+		if !filepath.IsLocal(f.subFolder) {
+			// The path comes from rclone, never write outside the target folder
+			return fmt.Errorf("invalid file path from rclone: %s", f.item.Path)
+		}
+		dstFolder = filepath.Join(dstFolder, f.subFolder)
+	}
+
+	safeFileName := util.SafeFileName(path.Base(f.item.Path))
+	dstPath := filepath.Join(dstFolder, safeFileName)
+
+	// Create folder structure if required
+	if _, err := os.Stat(dstFolder); os.IsNotExist(err) {
+		if util.DryRun {
+			slog.Info("[dry-run] Would create local folder", "folder", dstFolder)
+			return nil
+		}
+		slog.Info("Creating local folder", "folder", dstFolder)
+		if err := os.MkdirAll(dstFolder, 0755); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("Downloading file from rclone", "host", f.client.Host(), "file", f.item.Path, "destination", dstPath)
+
+	// Check if the file already exists
+	_, err := os.Stat(dstPath)
+	if !os.IsNotExist(err) {
+		if !overwriteExistingFile {
+			slog.Warn("File already exists, skipping download", "file", dstPath)
+			return nil
+		}
+
+		slog.Info("Overwriting existing file", "file", dstPath)
+	}
+
+	// Download the file
+	if util.DryRun {
+		slog.Info("[dry-run] Would download file", "source", f.item.Path, "destination", dstPath)
+		return nil
+	}
+	tmpFile, err := os.CreateTemp(dstFolder, "bookshift-")
+	if err != nil {
+		return err
+	}
+	defer tmpFile.Close()
+
+	slog.Debug("Downloading to temporary file", "file", tmpFile.Name())
+	writer := util.NewFileWriter(tmpFile, f.item.Size, true)
+	if _, err := f.client.ReadFile(f.item.Path, writer); err != nil {
+		os.Remove(tmpFile.Name())
+		return fmt.Errorf("failed to read %s from rclone: %w", f.item.Path, err)
+	}
+
+	if err := tmpFile.Sync(); err != nil { // ensure data flushed before rename
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	if err := os.Rename(tmpFile.Name(), dstPath); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+
+	// Delete the source file if requested
+	if deleteSourceFile {
+		if util.DryRun {
+			slog.Info("[dry-run] Would delete file through rclone", "file", f.item.Path)
+		} else {
+			if err := f.Delete(); err != nil {
+				return err
+			}
+		}
+	}
+
+	slog.Info("Successfully downloaded file", "filename", safeFileName)
+	return nil
+}
+
+func (f *RcloneFile) Delete() error {
+	if err := f.client.DeleteFile(f.item.Path); err != nil {
+		return fmt.Errorf("failed to delete the file %s: (%w)", f.item.Path, err)
+	}
+	slog.Info("Deleted file through rclone", "host", f.client.Host(), "file", f.item.Path)
+	return nil
+}
@@ -0,0 +1,76 @@
+package rclone
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// TestRcloneFile_Download_KeepStructureAndDelete writes into the sub folder and deletes the file.
+func TestRcloneFile_Download_KeepStructureAndDelete(t *testing.T) {
+	fake := &memoryRclone{files: map[string]string{"sub/a.epub": "DATA"}}
+	f := NewRcloneFile(RcloneItem{Path: "sub/a.epub", Size: 4}, fake)
+
+	dst := t.TempDir()
+	if err := f.Download(dst, false, true, true); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if got, err := os.ReadFile(filepath.Join(dst, "sub", "a.epub")); err != nil || string(got) != "DATA" {
+		t.Fatalf("read: %v %q", err, string(got))
+	}
+	if len(fake.deleted) != 1 || fake.deleted[0] != "sub/a.epub" {
+		t.Fatalf("expected delete, got %v", fake.deleted)
+	}
+}
+
+// TestRcloneFile_Download_ExistingAndErrors covers skip-existing, unsafe paths and failed reads.
+func TestRcloneFile_Download_ExistingAndErrors(t *testing.T) {
+	fake := &memoryRclone{files: map[string]string{"a.epub": "NEW"}}
+	dst := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dst, "a.epub"), []byte("OLD"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f := NewRcloneFile(RcloneItem{Path: "a.epub"}, fake)
+	if err := f.Download(dst, false, false, true); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dst, "a.epub")); string(got) != "OLD" || len(fake.deleted) != 0 {
+		t.Fatalf("existing file must be kept and the source left alone")
+	}
+
+	unsafe := NewRcloneFile(RcloneItem{Path: "../x/b.epub"}, fake)
+	if err := unsafe.Download(dst, true, true, false); err == nil {
+		t.Fatalf("expected error for path outside the target folder")
+	}
+
+	fake.readErr = errors.New("boom")
+	if err := f.Download(dst, true, false, false); err == nil {
+		t.Fatalf("expected read error")
+	}
+	if entries, _ := os.ReadDir(dst); len(entries) != 1 {
+		t.Fatalf("expected no temporary leftovers, got %d entries", len(entries))
+	}
+}
+
+// TestRcloneFile_Download_DryRun ensures nothing is written or deleted.
+func TestRcloneFile_Download_DryRun(t *testing.T) {
+	old := util.DryRun
+	t.Cleanup(func() { util.DryRun = old })
+	util.DryRun = true
+
+	fake := &memoryRclone{files: map[string]string{"sub/a.epub": "A"}}
+	f := NewRcloneFile(RcloneItem{Path: "sub/a.epub"}, fake)
+	dst := t.TempDir()
+	for _, keep := range []bool{false, true} {
+		if err := f.Download(dst, true, keep, true); err != nil {
+			t.Fatalf("dry-run: %v", err)
+		}
+	}
+	if entries, _ := os.ReadDir(dst); len(entries) != 0 || len(fake.deleted) != 0 {
+		t.Fatalf("dry-run must not write or delete")
+	}
+}
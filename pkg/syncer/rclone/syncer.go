@@ -0,0 +1,95 @@
+package rclone
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+type RcloneSyncer struct {
+	config *config.RcloneConfig
+}
+
+func NewRcloneSyncer(rcloneConfig *config.RcloneConfig) *RcloneSyncer {
+	return &RcloneSyncer{
+		config: rcloneConfig,
+	}
+}
+
+func (s *RcloneSyncer) Run(targetFolder string, validExtensions []string, overwriteExistingFiles bool) error {
+	return s.RunContext(context.Background(), targetFolder, validExtensions, overwriteExistingFiles)
+}
+
+func (s *RcloneSyncer) RunContext(ctx context.Context, targetFolder string, validExtensions []string, overwriteExistingFiles bool) error {
+	if s.config.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(s.config.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	// Connect to rclone
+	rcloneClient, err := newRcloneClient(s.config)
+	if err != nil {
+		return err
+	}
+	if err := rcloneConnect(ctx, rcloneClient, 60*time.Second); err != nil {
+		return fmt.Errorf("could not connect to rclone %s: %w", s.config.URL, err)
+	}
+	defer rcloneClient.Disconnect()
+
+	// Fetch all files below the folder
+	items, err := rcloneListFiles(rcloneClient)
+	if err != nil {
+		return fmt.Errorf("could not list files of %s on rclone %s: %w", s.config.Remote, s.config.URL, err)
+	}
+	allFiles := filterItems(items, validExtensions, rcloneClient)
+	slog.Info("Found files on rclone remote", "host", rcloneClient.Host(), "remote", s.config.Remote, "count", len(allFiles))
+
+	// Download all files
+	for _, f := range allFiles {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		if err := rcloneDownload(f,
+			targetFolder,
+			overwriteExistingFiles,
+			s.config.KeepFolderStructure,
+			s.config.RemoveFilesAfterDownload,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// filterItems keeps the files with a valid extension, compared
+// case-insensitively.
+func filterItems(items []RcloneItem, validExtensions []string, client RcloneAPI) []*RcloneFile {
+	lowerExts := make([]string, 0, len(validExtensions))
+	for _, e := range validExtensions {
+		lowerExts = append(lowerExts, strings.ToLower(e))
+	}
+
+	var files []*RcloneFile
+	for _, item := range items {
+		if item.IsDir || !slices.Contains(lowerExts, strings.ToLower(path.Ext(item.Path))) {
+			continue
+		}
+		files = append(files, NewRcloneFile(item, client))
+	}
+	return files
+}
@@ -0,0 +1,25 @@
+// syncer_seams.go: Test seams for the rclone syncer.
+//
+// This file defines small function variables that production code uses by default,
+// but tests can override to substitute fakes. Keeping these seams in a separate
+// file keeps the main implementation clean while preserving simple overrides in tests.
+package rclone
+
+import (
+	"context"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+// test hooks (seams) for dependency injection in tests
+var (
+	newRcloneClient = func(cfg *config.RcloneConfig) (RcloneAPI, error) {
+		return NewRcloneClient(cfg.URL, cfg.Username, cfg.Password, cfg.Remote, cfg.Folder)
+	}
+	rcloneConnect   = func(ctx context.Context, c RcloneAPI, timeout time.Duration) error { return c.Connect(ctx, timeout) }
+	rcloneListFiles = func(c RcloneAPI) ([]RcloneItem, error) { return c.ListFiles() }
+	rcloneDownload  = func(f *RcloneFile, dst string, overwrite, keep, del bool) error {
+		return f.Download(dst, overwrite, keep, del)
+	}
+)
@@ -0,0 +1,100 @@
+package rclone
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/go-playground/sensitive"
+)
+
+// TestRcloneSyncer_Run_EndToEnd syncs from the fake rc server and deletes the files.
+func TestRcloneSyncer_Run_EndToEnd(t *testing.T) {
+	srv := newRcServer(t, "gdrive:Books", map[string]string{
+		"a.EPUB":          "A",
+		"Sci Fi/b c.epub": "BC",
+		"notes.txt":       "N",
+	})
+	password := sensitive.String(testPassword)
+	cfg := &config.RcloneConfig{
+		URL:                      srv.URL,
+		Username:                 testUsername,
+		Password:                 &password,
+		Remote:                   "gdrive:",
+		Folder:                   "Books",
+		KeepFolderStructure:      true,
+		RemoveFilesAfterDownload: true,
+	}
+	dst := t.TempDir()
+	if err := NewRcloneSyncer(cfg).Run(dst, []string{".epub"}, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for name, want := range map[string]string{"a.epub": "A", filepath.Join("Sci Fi", "b-c.epub"): "BC"} {
+		if data, err := os.ReadFile(filepath.Join(dst, name)); err != nil || string(data) != want {
+			t.Fatalf("%s: %q %v", name, data, err)
+		}
+	}
+	slices.Sort(srv.deleted)
+	if !slices.Equal(srv.deleted, []string{"Sci Fi/b c.epub", "a.EPUB"}) {
+		t.Fatalf("unexpected deletes: %v", srv.deleted)
+	}
+}
+
+// TestRcloneSyncer_Run_Errors ensures client, connect, list and download errors abort the run.
+func TestRcloneSyncer_Run_Errors(t *testing.T) {
+	if err := NewRcloneSyncer(&config.RcloneConfig{URL: "ftp://nas", Remote: "gdrive:"}).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected client error")
+	}
+
+	origNew, origConnect, origList, origDownload := newRcloneClient, rcloneConnect, rcloneListFiles, rcloneDownload
+	t.Cleanup(func() {
+		newRcloneClient, rcloneConnect, rcloneListFiles, rcloneDownload = origNew, origConnect, origList, origDownload
+	})
+	newRcloneClient = func(cfg *config.RcloneConfig) (RcloneAPI, error) {
+		return &memoryRclone{files: map[string]string{"a.epub": "A"}}, nil
+	}
+	cfg := &config.RcloneConfig{URL: "http://nas:5572", Remote: "gdrive:"}
+
+	rcloneConnect = func(ctx context.Context, c RcloneAPI, timeout time.Duration) error { return errors.New("x") }
+	if err := NewRcloneSyncer(cfg).Run(t.TempDir(), []string{".epub"}, false); err == nil {
+		t.Fatalf("expected connect error")
+	}
+	rcloneConnect = origConnect
+
+	rcloneListFiles = func(c RcloneAPI) ([]RcloneItem, error) { return nil, errors.New("x") }
+	if err := NewRcloneSyncer(cfg).Run(t.TempDir(), []string{".epub"}, false); err == nil {
+		t.Fatalf("expected list error")
+	}
+	rcloneListFiles = origList
+
+	rcloneDownload = func(f *RcloneFile, dst string, overwrite, keep, del bool) error { return errors.New("x") }
+	if err := NewRcloneSyncer(cfg).Run(t.TempDir(), []string{".epub"}, false); err == nil {
+		t.Fatalf("expected download error")
+	}
+}
+
+// TestRcloneSyncer_RunContext_Cancelled ensures cancellation stops the run.
+func TestRcloneSyncer_RunContext_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := NewRcloneSyncer(&config.RcloneConfig{URL: "http://nas:5572", Remote: "gdrive:"}).RunContext(ctx, t.TempDir(), nil, false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+// TestFilterItems ensures extensions match case-insensitively and folders are skipped.
+func TestFilterItems(t *testing.T) {
+	files := filterItems([]RcloneItem{
+		{Path: "a.EPUB"},
+		{Path: "b.txt"},
+		{Path: "folder.epub", IsDir: true},
+	}, []string{".Epub"}, &memoryRclone{})
+	if len(files) != 1 || files[0].item.Path != "a.EPUB" {
+		t.Fatalf("unexpected files: %+v", files)
+	}
+}
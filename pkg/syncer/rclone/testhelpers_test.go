@@ -0,0 +1,144 @@
+package rclone
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testUsername = "rc"
+	testPassword = "secret"
+)
+
+// rcServer is an httptest stand-in for "rclone rcd --rc-serve" with a single
+// fs. Files are keyed by their path relative to fs.
+type rcServer struct {
+	URL string
+
+	mu      sync.Mutex
+	fs      string
+	files   map[string]string
+	deleted []string
+	serve   bool
+}
+
+func newRcServer(t *testing.T, fs string, files map[string]string) *rcServer {
+	t.Helper()
+	s := &rcServer{fs: fs, files: files, serve: true}
+
+	rcError := func(w http.ResponseWriter, status int, msg string) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(map[string]any{"error": msg, "status": status})
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != testUsername || pass != testPassword {
+			rcError(w, http.StatusUnauthorized, "authentication required")
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if r.Method == http.MethodGet {
+			prefix := "/[" + s.fs + "]/"
+			content, ok := s.files[strings.TrimPrefix(r.URL.Path, prefix)]
+			if !s.serve || !strings.HasPrefix(r.URL.Path, prefix) || !ok {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = io.WriteString(w, content)
+			return
+		}
+
+		var params struct {
+			Fs     string `json:"fs"`
+			Remote string `json:"remote"`
+			Opt    struct {
+				Recurse   bool `json:"recurse"`
+				FilesOnly bool `json:"filesOnly"`
+			} `json:"opt"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			rcError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		switch r.URL.Path {
+		case "/core/version":
+			writeJSON(w, map[string]any{"version": "v1.68.0"})
+		case "/operations/list":
+			if params.Fs != s.fs || params.Remote != "" || !params.Opt.Recurse || !params.Opt.FilesOnly {
+				rcError(w, http.StatusInternalServerError, "directory not found")
+				return
+			}
+			list := []RcloneItem{}
+			for p, content := range s.files {
+				list = append(list, RcloneItem{Path: p, Name: p[strings.LastIndex(p, "/")+1:], Size: int64(len(content))})
+			}
+			sort.Slice(list, func(i, j int) bool { return list[i].Path < list[j].Path })
+			writeJSON(w, map[string]any{"list": list})
+		case "/operations/deletefile":
+			if _, ok := s.files[params.Remote]; params.Fs != s.fs || !ok {
+				rcError(w, http.StatusInternalServerError, "object not found")
+				return
+			}
+			delete(s.files, params.Remote)
+			s.deleted = append(s.deleted, params.Remote)
+			writeJSON(w, map[string]any{})
+		default:
+			rcError(w, http.StatusNotFound, "couldn't find method")
+		}
+	}))
+	t.Cleanup(srv.Close)
+	s.URL = srv.URL
+	return s
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// memoryRclone is an in-memory RcloneAPI.
+type memoryRclone struct {
+	files   map[string]string
+	deleted []string
+	readErr error
+}
+
+func (m *memoryRclone) Connect(context.Context, time.Duration) error { return nil }
+func (m *memoryRclone) Disconnect() error                            { return nil }
+func (m *memoryRclone) Host() string                                 { return "memory" }
+
+func (m *memoryRclone) ListFiles() ([]RcloneItem, error) {
+	var items []RcloneItem
+	for p, content := range m.files {
+		items = append(items, RcloneItem{Path: p, Size: int64(len(content))})
+	}
+	return items, nil
+}
+
+func (m *memoryRclone) ReadFile(remotePath string, w io.Writer) (int64, error) {
+	if m.readErr != nil {
+		return 0, m.readErr
+	}
+	content, ok := m.files[remotePath]
+	if !ok {
+		return 0, errors.New("no such file")
+	}
+	return io.Copy(w, bytes.NewBufferString(content))
+}
+
+func (m *memoryRclone) DeleteFile(remotePath string) error {
+	m.deleted = append(m.deleted, remotePath)
+	return nil
+}
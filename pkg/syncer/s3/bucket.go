@@ -0,0 +1,67 @@
+package s3
+
+import (
+	"path"
+	"slices"
+	"strings"
+)
+
+type S3Bucket struct {
+	Prefix string
+
+	s3Client S3API
+}
+
+func NewS3Bucket(prefix string, conn S3API) *S3Bucket {
+	return &S3Bucket{
+		Prefix:   normalizePrefix(prefix),
+		s3Client: conn,
+	}
+}
+
+// FetchFiles lists every object below the bucket prefix that matches validExtensions.
+// Object storage is flat, so "folders" are derived from the key relative to the prefix.
+func (b *S3Bucket) FetchFiles(validExtensions []string) ([]S3File, error) {
+	objects, err := b.s3Client.ListObjects(b.Prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build a lower-cased set of valid extensions for case-insensitive match
+	lowerExts := make([]string, 0, len(validExtensions))
+	for _, e := range validExtensions {
+		lowerExts = append(lowerExts, strings.ToLower(e))
+	}
+
+	var allFiles []S3File
+	for _, object := range objects {
+		// Skip folder placeholder objects
+		if strings.HasSuffix(object.Key, "/") {
+			continue
+		}
+
+		extension := strings.ToLower(path.Ext(object.Key))
+		if len(lowerExts) > 0 && !slices.Contains(lowerExts, extension) {
+			continue
+		}
+
+		subFolder := path.Dir(strings.TrimPrefix(object.Key, b.Prefix))
+		if subFolder == "." {
+			subFolder = ""
+		}
+
+		allFiles = append(allFiles, *NewS3File(subFolder, &object, b))
+	}
+
+	return allFiles, nil
+}
+
+// normalizePrefix strips leading slashes and makes sure a non-empty prefix ends
+// with a slash, so "books" does not also match "books-old/".
+func normalizePrefix(prefix string) string {
+	prefix = strings.TrimLeft(prefix, "/")
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return prefix
+}
@@ -0,0 +1,49 @@
+package s3
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestNormalizePrefix verifies prefixes are anchored to a "folder".
+func TestNormalizePrefix(t *testing.T) {
+	tests := map[string]string{"": "", "/": "", "books": "books/", "/books/": "books/", "a/b": "a/b/"}
+	for in, want := range tests {
+		if got := normalizePrefix(in); got != want {
+			t.Fatalf("normalizePrefix(%q)=%q, want %q", in, got, want)
+		}
+	}
+}
+
+// TestS3Bucket_FetchFiles filters by extension and derives sub folders from the key.
+func TestS3Bucket_FetchFiles(t *testing.T) {
+	fake := &recS3{objects: []S3ObjectInfo{
+		{Key: "books/"},
+		{Key: "books/a.EPUB", Size: 1},
+		{Key: "books/sub/deep/b.kepub", Size: 2},
+		{Key: "books/c.txt", Size: 3},
+		{Key: "books-old/d.epub", Size: 4},
+	}}
+
+	files, err := NewS3Bucket("books", fake).FetchFiles([]string{".epub", ".kepub"})
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("want 2 files, got %d", len(files))
+	}
+	if files[0].s3Object.Key != "books/a.EPUB" || files[0].subFolder != "" {
+		t.Fatalf("unexpected first file: %+v", files[0])
+	}
+	if files[1].subFolder != "sub/deep" {
+		t.Fatalf("unexpected sub folder: %q", files[1].subFolder)
+	}
+}
+
+// TestS3Bucket_FetchFiles_Error ensures listing errors propagate.
+func TestS3Bucket_FetchFiles_Error(t *testing.T) {
+	fake := &recS3{listErr: errors.New("boom")}
+	if _, err := NewS3Bucket("", fake).FetchFiles(nil); err == nil {
+		t.Fatalf("expected error")
+	}
+}
@@ -0,0 +1,264 @@
+package s3
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+	"github.com/go-playground/sensitive"
+)
+
+// S3API is the minimal contract used by bucket and file logic.
+// It enables injecting a fake in tests.
+type S3API interface {
+	Connect(ctx context.Context, timeout time.Duration) error
+	Disconnect() error
+	ListObjects(prefix string) ([]S3ObjectInfo, error)
+	ReadObject(key string, w io.Writer) (int64, error)
+	DeleteObject(key string) error
+	Host() string
+}
+
+// S3ObjectInfo describes a single object returned by a bucket listing.
+type S3ObjectInfo struct {
+	Key  string
+	Size int64
+}
+
+type S3Client struct {
+	endpoint        *url.URL
+	region          string
+	bucket          string
+	accessKeyID     string
+	secretAccessKey *sensitive.String
+	usePathStyle    bool
+
+	// ctx bounds every request of the session
+	ctx    context.Context
+	client *http.Client
+}
+
+// Package-level errors
+var (
+	ErrS3Disconnected = fmt.Errorf("not connected to the S3 endpoint")
+)
+
+// s3Now returns the timestamp used for request signing. Overridden in tests.
+var s3Now = time.Now
+
+// listBucketResult mirrors the subset of a ListObjectsV2 response that is used.
+type listBucketResult struct {
+	Contents []struct {
+		Key  string `xml:"Key"`
+		Size int64  `xml:"Size"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// s3Error mirrors the error document returned by S3 compatible servers.
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func NewS3Client(endpoint string, region string, bucket string, accessKeyID string, secretAccessKey *sensitive.String, usePathStyle bool) (*S3Client, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint %s: %w", endpoint, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid S3 endpoint %s: scheme must be http or https", endpoint)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	return &S3Client{
+		endpoint:        u,
+		region:          region,
+		bucket:          bucket,
+		accessKeyID:     accessKeyID,
+		secretAccessKey: secretAccessKey,
+		usePathStyle:    usePathStyle,
+	}, nil
+}
+
+// Connect prepares the HTTP client and verifies the bucket is reachable with the configured credentials.
+func (c *S3Client) Connect(ctx context.Context, timeout time.Duration) error {
+	slog.Debug("Initiating S3 connection", "host", c.Host(), "bucket", c.bucket)
+
+	c.ctx = ctx
+	c.client = util.NewHTTPClient(timeout)
+
+	resp, err := c.do(http.MethodGet, "", url.Values{"list-type": {"2"}, "max-keys": {"0"}})
+	if err != nil {
+		c.client = nil
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		c.client = nil
+		return responseError(resp)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// Disconnect releases idle connections held by the HTTP client.
+func (c *S3Client) Disconnect() error {
+	slog.Debug("Disconnecting S3 connection", "host", c.Host())
+
+	if c.client != nil {
+		c.client.CloseIdleConnections()
+		c.client = nil
+	}
+	return nil
+}
+
+// ListObjects returns every object below prefix, following continuation tokens.
+func (c *S3Client) ListObjects(prefix string) ([]S3ObjectInfo, error) {
+	if c.client == nil {
+		return nil, ErrS3Disconnected
+	}
+
+	var objects []S3ObjectInfo
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}}
+		if prefix != "" {
+			query.Set("prefix", prefix)
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		resp, err := c.do(http.MethodGet, "", query)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			err := responseError(resp)
+			resp.Body.Close()
+			return nil, fmt.Errorf("failed to list objects with prefix %q: %w", prefix, err)
+		}
+
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse object listing: %w", err)
+		}
+
+		for _, o := range result.Contents {
+			objects = append(objects, S3ObjectInfo{Key: o.Key, Size: o.Size})
+		}
+
+		if !result.IsTruncated {
+			break
+		}
+		if result.NextContinuationToken == "" {
+			return nil, fmt.Errorf("truncated object listing without continuation token")
+		}
+		token = result.NextContinuationToken
+	}
+	return objects, nil
+}
+
+// ReadObject streams an object to the provided writer.
+func (c *S3Client) ReadObject(key string, w io.Writer) (int64, error) {
+	if c.client == nil {
+		return 0, ErrS3Disconnected
+	}
+
+	resp, err := c.do(http.MethodGet, key, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed to download %s: %w", key, responseError(resp))
+	}
+	return io.Copy(w, resp.Body)
+}
+
+// DeleteObject removes an object from the bucket.
+func (c *S3Client) DeleteObject(key string) error {
+	if c.client == nil {
+		return ErrS3Disconnected
+	}
+
+	resp, err := c.do(http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return responseError(resp)
+	}
+	return nil
+}
+
+func (c *S3Client) Host() string {
+	return c.endpoint.Host
+}
+
+// objectURL returns the URL of key (or of the bucket itself when key is empty)
+// using either path-style or virtual-hosted-style addressing.
+func (c *S3Client) objectURL(key string) *url.URL {
+	u := *c.endpoint
+	if c.usePathStyle {
+		u.Path = path.Join(c.endpoint.Path, "/", c.bucket)
+	} else {
+		u.Host = c.bucket + "." + c.endpoint.Host
+	}
+	if key != "" {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + key
+	} else if !c.usePathStyle || u.Path == "" {
+		u.Path += "/"
+	}
+	return &u
+}
+
+// do builds, signs and sends a request for an object key or the bucket itself.
+func (c *S3Client) do(method string, key string, query url.Values) (*http.Response, error) {
+	u := c.objectURL(key)
+	if query != nil {
+		u.RawQuery = canonicalQuery(query)
+	}
+
+	req, err := http.NewRequestWithContext(c.ctx, method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.accessKeyID != "" {
+		var secret string
+		if c.secretAccessKey != nil {
+			secret = string(*c.secretAccessKey)
+		}
+		req.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+		signV4(req, c.accessKeyID, secret, c.region, "s3", emptyPayloadHash, s3Now())
+	}
+
+	return c.client.Do(req)
+}
+
+// responseError converts a non-success response into an error, including the S3 error code when present.
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	var e s3Error
+	if xml.Unmarshal(body, &e) == nil && e.Code != "" {
+		return fmt.Errorf("unexpected response from S3 endpoint: %s: %s (%s)", resp.Status, e.Code, e.Message)
+	}
+	return fmt.Errorf("unexpected response from S3 endpoint: %s", resp.Status)
+}
@@ -0,0 +1,175 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/sensitive"
+)
+
+func newTestClient(t *testing.T, endpoint, secret string) *S3Client {
+	t.Helper()
+	s := sensitive.String(secret)
+	c, err := NewS3Client(endpoint, "us-east-1", testBucket, testAccessKey, &s, true)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	return c
+}
+
+// TestNewS3Client_InvalidEndpoint ensures unsupported schemes are rejected.
+func TestNewS3Client_InvalidEndpoint(t *testing.T) {
+	if _, err := NewS3Client("ftp://minio", "us-east-1", "b", "", nil, true); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+// TestS3Client_ObjectURL covers path-style and virtual-hosted-style addressing.
+func TestS3Client_ObjectURL(t *testing.T) {
+	path, _ := NewS3Client("https://minio.local:9000/", "us-east-1", "books", "", nil, true)
+	if got := path.objectURL("").String(); got != "https://minio.local:9000/books" {
+		t.Fatalf("unexpected bucket url: %s", got)
+	}
+	if got := path.objectURL("a/My Book.epub").String(); got != "https://minio.local:9000/books/a/My%20Book.epub" {
+		t.Fatalf("unexpected object url: %s", got)
+	}
+
+	vhost, _ := NewS3Client("https://s3.eu-west-1.amazonaws.com", "eu-west-1", "books", "", nil, false)
+	if got := vhost.objectURL("").String(); got != "https://books.s3.eu-west-1.amazonaws.com/" {
+		t.Fatalf("unexpected bucket url: %s", got)
+	}
+	if got := vhost.objectURL("a.epub").String(); got != "https://books.s3.eu-west-1.amazonaws.com/a.epub" {
+		t.Fatalf("unexpected object url: %s", got)
+	}
+}
+
+// TestS3Client_NotConnected ensures operations fail before Connect.
+func TestS3Client_NotConnected(t *testing.T) {
+	c, _ := NewS3Client("http://h", "us-east-1", "b", "", nil, true)
+	if _, err := c.ListObjects(""); err != ErrS3Disconnected {
+		t.Fatalf("expected ErrS3Disconnected, got %v", err)
+	}
+	if _, err := c.ReadObject("k", &bytes.Buffer{}); err != ErrS3Disconnected {
+		t.Fatalf("expected ErrS3Disconnected, got %v", err)
+	}
+	if err := c.DeleteObject("k"); err != ErrS3Disconnected {
+		t.Fatalf("expected ErrS3Disconnected, got %v", err)
+	}
+}
+
+// TestS3Client_ListReadDelete exercises paging, signed reads and deletes against the fake server.
+func TestS3Client_ListReadDelete(t *testing.T) {
+	fake, endpoint := newFakeS3(t, map[string]string{
+		"library/a.epub":           "A",
+		"library/sub/My Book.epub": "BB",
+		"library/c+d.kepub":        "C",
+		"library/e.pdf":            "E",
+		"other/x.epub":             "X",
+	})
+	c := newTestClient(t, endpoint, testSecretKey)
+	if err := c.Connect(context.Background(), 5*time.Second); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Disconnect() })
+
+	fake.listings = 0
+	objects, err := c.ListObjects("library/")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(objects) != 4 {
+		t.Fatalf("want 4 objects, got %+v", objects)
+	}
+	if fake.listings != 2 {
+		t.Fatalf("expected listing to follow the continuation token, got %d pages", fake.listings)
+	}
+
+	var buf bytes.Buffer
+	if _, err := c.ReadObject("library/sub/My Book.epub", &buf); err != nil || buf.String() != "BB" {
+		t.Fatalf("read: %v %q", err, buf.String())
+	}
+	buf.Reset()
+	if _, err := c.ReadObject("library/c+d.kepub", &buf); err != nil || buf.String() != "C" {
+		t.Fatalf("read: %v %q", err, buf.String())
+	}
+	if _, err := c.ReadObject("library/missing.epub", &buf); err == nil || !strings.Contains(err.Error(), "NoSuchKey") {
+		t.Fatalf("expected NoSuchKey, got %v", err)
+	}
+
+	if err := c.DeleteObject("library/a.epub"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, ok := fake.objects["library/a.epub"]; ok {
+		t.Fatalf("expected object deleted")
+	}
+}
+
+// TestS3Client_Connect_BadCredentials ensures signature errors are surfaced with the S3 error code.
+func TestS3Client_Connect_BadCredentials(t *testing.T) {
+	_, endpoint := newFakeS3(t, map[string]string{})
+	c := newTestClient(t, endpoint, "wrong")
+	err := c.Connect(context.Background(), 5*time.Second)
+	if err == nil || !strings.Contains(err.Error(), "SignatureDoesNotMatch") {
+		t.Fatalf("expected signature error, got %v", err)
+	}
+}
+
+// TestS3Client_Anonymous ensures requests are unsigned without an access key.
+func TestS3Client_Anonymous(t *testing.T) {
+	fake, endpoint := newFakeS3(t, map[string]string{"a.epub": "A"})
+	fake.anon = true
+	c, _ := NewS3Client(endpoint, "us-east-1", testBucket, "", nil, true)
+	if err := c.Connect(context.Background(), 5*time.Second); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if objects, err := c.ListObjects(""); err != nil || len(objects) != 1 {
+		t.Fatalf("list: %v %+v", err, objects)
+	}
+}
+
+// TestS3Client_Connect_NoSuchBucket ensures a missing bucket fails the connection check.
+func TestS3Client_Connect_NoSuchBucket(t *testing.T) {
+	_, endpoint := newFakeS3(t, map[string]string{})
+	s := sensitive.String(testSecretKey)
+	c, _ := NewS3Client(endpoint, "us-east-1", "missing", testAccessKey, &s, true)
+	if err := c.Connect(context.Background(), 5*time.Second); err == nil || !strings.Contains(err.Error(), "NoSuchBucket") {
+		t.Fatalf("expected NoSuchBucket, got %v", err)
+	}
+}
+
+// TestS3Client_SlowDownload verifies objects may take longer than the connect
+// timeout to download and are stopped by the session context.
+func TestS3Client_SlowDownload(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("list-type") {
+			return
+		}
+		for range 3 {
+			_, _ = w.Write([]byte("chunk"))
+			w.(http.Flusher).Flush()
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c, _ := NewS3Client(srv.URL, "us-east-1", testBucket, "", nil, true)
+	if err := c.Connect(ctx, 50*time.Millisecond); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := c.ReadObject("book.epub", &buf); err != nil || buf.String() != "chunkchunkchunk" {
+		t.Fatalf("slow download: %q %v", buf.String(), err)
+	}
+
+	cancel()
+	if _, err := c.ReadObject("book.epub", &buf); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package s3
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+type S3File struct {
+	s3Bucket *S3Bucket
+	s3Object *S3ObjectInfo
+
+	subFolder string
+}
+
+func NewS3File(subFolder string, object *S3ObjectInfo, s3Bucket *S3Bucket) *S3File {
+	return &S3File{
+		s3Bucket: s3Bucket,
+		s3Object: object,
+
+		subFolder: subFolder,
+	}
+}
+
+func (f *S3File) Download(dstFolder string, dstFileName string, overwriteExistingFile bool, keepFolderStructure bool, deleteSourceFile bool) error {
+	// Create folder structure if required
+	if keepFolderStructure {
+		dstFolder = filepath.Join(dstFolder, filepath.FromSlash(f.subFolder))
+	}
+
+	// If no destination filename is provided, use the object name by default
+	if dstFileName == "" {
+		dstFileName = path.Base(f.s3Object.Key)
+	}
+	safeFileName := util.SafeFileName(dstFileName)
+	dstPath := filepath.Join(dstFolder, safeFileName)
+
+	// Create folder structure if required
+	if _, err := os.Stat(dstFolder); os.IsNotExist(err) {
+		if util.DryRun {
+			slog.Info("[dry-run] Would create local folder", "folder", dstFolder)
+			return nil
+		}
+		slog.Info("Creating local folder", "folder", dstFolder)
+		if err := os.MkdirAll(dstFolder, 0755); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("Downloading object from S3 bucket", "host", f.s3Bucket.s3Client.Host(), "key", f.s3Object.Key, "destination", dstPath)
+
+	// Check if the file already exists
+	_, err := os.Stat(dstPath)
+	if !os.IsNotExist(err) {
+		if !overwriteExistingFile {
+			slog.Warn("File already exists, skipping download", "file", dstPath)
+			return nil
+		}
+
+		slog.Info("Overwriting existing file", "file", dstPath)
+	}
+
+	// Download the file
+	if util.DryRun {
+		slog.Info("[dry-run] Would download object", "source", f.s3Object.Key, "destination", dstPath)
+		return nil
+	}
+	tmpFile, err := os.CreateTemp(dstFolder, "bookshift-")
+	if err != nil {
+		return err
+	}
+	defer tmpFile.Close()
+
+	slog.Debug("Downloading to temporary file", "file", tmpFile.Name())
+	writer := util.NewFileWriter(tmpFile, f.s3Object.Size, true)
+	if _, err := f.s3Bucket.s3Client.ReadObject(f.s3Object.Key, writer); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+
+	if err := tmpFile.Sync(); err != nil { // ensure data flushed before rename
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	if err := os.Rename(tmpFile.Name(), dstPath); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+
+	// Delete the source object if requested
+	if deleteSourceFile {
+		if util.DryRun {
+			slog.Info("[dry-run] Would delete object from S3 bucket", "key", f.s3Object.Key)
+		} else {
+			if err := f.Delete(); err != nil {
+				return err
+			}
+		}
+	}
+
+	slog.Info("Successfully downloaded file", "filename", safeFileName)
+	return nil
+}
+
+func (f *S3File) Delete() error {
+	if err := f.s3Bucket.s3Client.DeleteObject(f.s3Object.Key); err != nil {
+		return fmt.Errorf("failed to delete the object %s: (%w)", f.s3Object.Key, err)
+	}
+	slog.Info("Deleted object from S3 bucket", "host", f.s3Bucket.s3Client.Host(), "key", f.s3Object.Key)
+	return nil
+}
@@ -0,0 +1,74 @@
+package s3
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// TestS3File_Download_KeepStructureAndDelete writes into the sub folder and removes the object.
+func TestS3File_Download_KeepStructureAndDelete(t *testing.T) {
+	fake := &recS3{reads: map[string]string{"books/sub/a.epub": "DATA"}}
+	bucket := NewS3Bucket("books", fake)
+	f := NewS3File("sub", &S3ObjectInfo{Key: "books/sub/a.epub", Size: 4}, bucket)
+
+	dst := t.TempDir()
+	if err := f.Download(dst, "", false, true, true); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if got, err := os.ReadFile(filepath.Join(dst, "sub", "a.epub")); err != nil || string(got) != "DATA" {
+		t.Fatalf("read: %v %q", err, string(got))
+	}
+	if len(fake.deleted) != 1 || fake.deleted[0] != "books/sub/a.epub" {
+		t.Fatalf("expected delete, got %v", fake.deleted)
+	}
+}
+
+// TestS3File_Download_ExistingAndErrors covers skip-existing and failed reads.
+func TestS3File_Download_ExistingAndErrors(t *testing.T) {
+	fake := &recS3{reads: map[string]string{"a.epub": "NEW"}}
+	bucket := NewS3Bucket("", fake)
+	dst := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dst, "a.epub"), []byte("OLD"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f := NewS3File("", &S3ObjectInfo{Key: "a.epub"}, bucket)
+	if err := f.Download(dst, "", false, false, true); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dst, "a.epub")); string(got) != "OLD" {
+		t.Fatalf("existing file must be kept")
+	}
+	if len(fake.deleted) != 0 {
+		t.Fatalf("skipped object must not be deleted")
+	}
+
+	missing := NewS3File("", &S3ObjectInfo{Key: "missing.epub"}, bucket)
+	if err := missing.Download(dst, "", true, false, false); err == nil {
+		t.Fatalf("expected error")
+	}
+	entries, _ := os.ReadDir(dst)
+	if len(entries) != 1 {
+		t.Fatalf("expected no temporary leftovers, got %d entries", len(entries))
+	}
+}
+
+// TestS3File_Download_DryRun ensures nothing is written or deleted.
+func TestS3File_Download_DryRun(t *testing.T) {
+	old := util.DryRun
+	t.Cleanup(func() { util.DryRun = old })
+	util.DryRun = true
+
+	fake := &recS3{reads: map[string]string{"a.epub": "A"}}
+	f := NewS3File("", &S3ObjectInfo{Key: "a.epub"}, NewS3Bucket("", fake))
+	dst := t.TempDir()
+	if err := f.Download(dst, "", true, false, true); err != nil {
+		t.Fatalf("dry-run: %v", err)
+	}
+	if entries, _ := os.ReadDir(dst); len(entries) != 0 || len(fake.deleted) != 0 {
+		t.Fatalf("dry-run must not write or delete")
+	}
+}
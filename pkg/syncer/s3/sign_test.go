@@ -0,0 +1,43 @@
+package s3
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// TestSignV4_GetVanilla checks the signer against the "get-vanilla" case of the AWS SigV4 test suite.
+func TestSignV4_GetVanilla(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	ts := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	signV4(req, "AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "us-east-1", "service", emptyPayloadHash, ts)
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Fatalf("unexpected authorization:\n got %s\nwant %s", got, want)
+	}
+	if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+		t.Fatalf("unexpected date: %s", got)
+	}
+}
+
+// TestCanonicalQuery verifies sorting and RFC 3986 encoding of query parameters.
+func TestCanonicalQuery(t *testing.T) {
+	q := url.Values{"prefix": {"my books/"}, "list-type": {"2"}, "continuation-token": {"a+b="}}
+	want := "continuation-token=a%2Bb%3D&list-type=2&prefix=my%20books%2F"
+	if got := canonicalQuery(q); got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+// TestCanonicalURI verifies path segments are encoded individually.
+func TestCanonicalURI(t *testing.T) {
+	u, _ := url.Parse("https://h/bucket/My%20Books/a+b.epub")
+	if got := canonicalURI(u); got != "/bucket/My%20Books/a%2Bb.epub" {
+		t.Fatalf("unexpected uri: %s", got)
+	}
+	if got := canonicalURI(&url.URL{}); got != "/" {
+		t.Fatalf("unexpected empty uri: %s", got)
+	}
+}
@@ -0,0 +1,82 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+type S3Syncer struct {
+	config *config.S3Config
+}
+
+func NewS3Syncer(bucketConfig *config.S3Config) *S3Syncer {
+	if bucketConfig.Region == "" {
+		bucketConfig.Region = "us-east-1"
+	}
+	if bucketConfig.Endpoint == "" {
+		bucketConfig.Endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", bucketConfig.Region)
+	}
+
+	return &S3Syncer{
+		config: bucketConfig,
+	}
+}
+
+func (s *S3Syncer) Run(targetFolder string, validExtensions []string, overwriteExistingFiles bool) error {
+	return s.RunContext(context.Background(), targetFolder, validExtensions, overwriteExistingFiles)
+}
+
+func (s *S3Syncer) RunContext(ctx context.Context, targetFolder string, validExtensions []string, overwriteExistingFiles bool) error {
+	if s.config.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(s.config.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	// Connect to the S3 endpoint
+	s3Client, err := newS3Client(s.config)
+	if err != nil {
+		return err
+	}
+	if err := s3Connect(ctx, s3Client, 30*time.Second); err != nil {
+		return fmt.Errorf("could not connect to bucket %s on S3 endpoint %s: %w", s.config.Bucket, s.config.Endpoint, err)
+	}
+	defer s3Client.Disconnect()
+
+	// Instantiate an S3 Bucket (via hook)
+	s3Bucket := s3NewBucket(s.config.Prefix, s3Client)
+
+	// Fetch all objects below the prefix
+	allFiles, err := s3FetchFiles(s3Bucket, validExtensions)
+	if err != nil {
+		return fmt.Errorf("could not list objects with prefix %s in bucket %s: %w", s.config.Prefix, s.config.Bucket, err)
+	}
+
+	// Download all files
+	for i := range allFiles {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		if err := s3Download(&allFiles[i],
+			targetFolder,
+			"",
+			overwriteExistingFiles,
+			s.config.KeepFolderStructure,
+			s.config.RemoveFilesAfterDownload,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,28 @@
+// syncer_seams.go: Test seams for the S3 syncer.
+//
+// This file defines small function variables that production code uses by default,
+// but tests can override to substitute fakes. Keeping these seams in a separate
+// file keeps the main implementation clean while preserving simple overrides in tests.
+package s3
+
+import (
+	"context"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+// test hooks (seams) for dependency injection in tests
+var (
+	newS3Client = func(cfg *config.S3Config) (S3API, error) {
+		return NewS3Client(cfg.Endpoint, cfg.Region, cfg.Bucket, cfg.AccessKeyID, cfg.SecretAccessKey, cfg.UsePathStyle)
+	}
+	s3Connect    = func(ctx context.Context, c S3API, timeout time.Duration) error { return c.Connect(ctx, timeout) }
+	s3NewBucket  = func(prefix string, conn S3API) *S3Bucket { return NewS3Bucket(prefix, conn) }
+	s3FetchFiles = func(b *S3Bucket, valid []string) ([]S3File, error) {
+		return b.FetchFiles(valid)
+	}
+	s3Download = func(sf *S3File, dst, name string, overwrite, keep, del bool) error {
+		return sf.Download(dst, name, overwrite, keep, del)
+	}
+)
@@ -0,0 +1,106 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/go-playground/sensitive"
+)
+
+// TestNewS3Syncer_Defaults ensures region and AWS endpoint defaults are applied.
+func TestNewS3Syncer_Defaults(t *testing.T) {
+	cfg := &config.S3Config{Bucket: "b"}
+	NewS3Syncer(cfg)
+	if cfg.Region != "us-east-1" || cfg.Endpoint != "https://s3.us-east-1.amazonaws.com" {
+		t.Fatalf("unexpected defaults: %+v", cfg)
+	}
+
+	cfg = &config.S3Config{Bucket: "b", Region: "garage", Endpoint: "http://garage:3900"}
+	NewS3Syncer(cfg)
+	if cfg.Region != "garage" || cfg.Endpoint != "http://garage:3900" {
+		t.Fatalf("explicit settings must be kept: %+v", cfg)
+	}
+}
+
+// TestS3Syncer_Run_EndToEnd syncs from the fake S3 server and removes the objects.
+func TestS3Syncer_Run_EndToEnd(t *testing.T) {
+	fake, endpoint := newFakeS3(t, map[string]string{
+		"library/a.epub":     "A",
+		"library/sub/b.epub": "BB",
+		"library/c.txt":      "C",
+		"other/d.epub":       "D",
+	})
+	secret := sensitive.String(testSecretKey)
+	cfg := &config.S3Config{
+		Endpoint:                 endpoint,
+		Bucket:                   testBucket,
+		Prefix:                   "/library",
+		AccessKeyID:              testAccessKey,
+		SecretAccessKey:          &secret,
+		UsePathStyle:             true,
+		KeepFolderStructure:      true,
+		RemoveFilesAfterDownload: true,
+	}
+	dst := t.TempDir()
+	if err := NewS3Syncer(cfg).Run(dst, []string{".epub"}, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for _, p := range []string{"a.epub", filepath.Join("sub", "b.epub")} {
+		if _, err := os.Stat(filepath.Join(dst, p)); err != nil {
+			t.Fatalf("expected %s downloaded: %v", p, err)
+		}
+	}
+	if _, ok := fake.objects["library/a.epub"]; ok {
+		t.Fatalf("expected library/a.epub removed")
+	}
+	for _, k := range []string{"library/c.txt", "other/d.epub"} {
+		if _, ok := fake.objects[k]; !ok {
+			t.Fatalf("%s must remain", k)
+		}
+	}
+}
+
+// TestS3Syncer_Run_Errors ensures client, connect, list and download errors abort the run.
+func TestS3Syncer_Run_Errors(t *testing.T) {
+	if err := NewS3Syncer(&config.S3Config{Endpoint: "ftp://x", Bucket: "b"}).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected client error")
+	}
+
+	origConnect := s3Connect
+	t.Cleanup(func() { s3Connect = origConnect })
+	s3Connect = func(ctx context.Context, c S3API, timeout time.Duration) error { return errors.New("x") }
+	if err := NewS3Syncer(&config.S3Config{Endpoint: "http://h", Bucket: "b"}).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected connect error")
+	}
+	s3Connect = func(ctx context.Context, c S3API, timeout time.Duration) error { return nil }
+
+	origClient, origDownload := newS3Client, s3Download
+	t.Cleanup(func() { newS3Client, s3Download = origClient, origDownload })
+	fake := &recS3{listErr: errors.New("list")}
+	newS3Client = func(cfg *config.S3Config) (S3API, error) { return fake, nil }
+	if err := NewS3Syncer(&config.S3Config{Bucket: "b"}).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected list error")
+	}
+
+	fake.listErr = nil
+	fake.objects = []S3ObjectInfo{{Key: "a.epub"}}
+	s3Download = func(sf *S3File, dst, name string, overwrite, keep, del bool) error { return errors.New("z") }
+	if err := NewS3Syncer(&config.S3Config{Bucket: "b"}).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected download error")
+	}
+}
+
+// TestS3Syncer_RunContext_Cancel verifies that a canceled context stops the run.
+func TestS3Syncer_RunContext_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cfg := &config.S3Config{Endpoint: "http://h", Bucket: "b"}
+	if err := NewS3Syncer(cfg).RunContext(ctx, t.TempDir(), nil, false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled, got %v", err)
+	}
+}
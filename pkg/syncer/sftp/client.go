@@ -0,0 +1,216 @@
+package sftp
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/sensitive"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Package-level errors
+var (
+	ErrSftpDisconnected = fmt.Errorf("not connected to the SFTP server")
+	ErrSftpNoHostKey    = fmt.Errorf("either known_hosts_file or host_key_fingerprint must be configured")
+)
+
+// SftpAPI is the minimal contract used by folder and file logic.
+// It enables injecting a fake in tests.
+type SftpAPI interface {
+	Connect(timeout time.Duration) error
+	Disconnect() error
+	ReadDir(path string) ([]os.FileInfo, error)
+	ReadFile(path string, w io.Writer) (int64, error)
+	DeleteFile(path string) error
+	Hostname() string
+}
+
+// sftpLowLevel captures the minimal calls used from the underlying SSH/SFTP clients.
+type sftpLowLevel interface {
+	ReadDir(path string) ([]os.FileInfo, error)
+	Open(path string) (io.ReadCloser, error)
+	Remove(path string) error
+	Close() error
+}
+
+type SftpClient struct {
+	Host                 string
+	Port                 int
+	Username             string
+	Password             *sensitive.String
+	PrivateKeyFile       string
+	PrivateKeyPassphrase *sensitive.String
+	KnownHostsFile       string
+	HostKeyFingerprint   string
+
+	client sftpLowLevel
+}
+
+// realSftp bundles the SFTP session with the SSH connection carrying it.
+type realSftp struct {
+	ssh  *ssh.Client
+	sftp *sftp.Client
+}
+
+func (r *realSftp) ReadDir(p string) ([]os.FileInfo, error) { return r.sftp.ReadDir(p) }
+func (r *realSftp) Open(p string) (io.ReadCloser, error)    { return r.sftp.Open(p) }
+func (r *realSftp) Remove(p string) error                   { return r.sftp.Remove(p) }
+func (r *realSftp) Close() error {
+	err := r.sftp.Close()
+	if cerr := r.ssh.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// dial hook for the low-level client (overridable in tests)
+var sftpDial = func(addr string, cfg *ssh.ClientConfig) (sftpLowLevel, error) {
+	sshClient, err := ssh.Dial("tcp", addr, cfg)
+	if err != nil {
+		return nil, err
+	}
+	sftpClient, err := sftp.NewClient(sshClient)
+	if err != nil {
+		sshClient.Close()
+		return nil, err
+	}
+	return &realSftp{ssh: sshClient, sftp: sftpClient}, nil
+}
+
+// Connect establishes an SSH connection, verifies the host key and opens an SFTP session.
+func (c *SftpClient) Connect(timeout time.Duration) error {
+	slog.Debug("Initiating SFTP connection", "host", c.Host)
+
+	hostKeyCallback, err := c.hostKeyCallback()
+	if err != nil {
+		return err
+	}
+	auth, err := c.authMethods()
+	if err != nil {
+		return err
+	}
+
+	client, err := sftpDial(net.JoinHostPort(c.Host, strconv.Itoa(c.Port)), &ssh.ClientConfig{
+		User:            c.Username,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         timeout,
+	})
+	if err != nil {
+		return err
+	}
+
+	c.client = client
+	return nil
+}
+
+// Disconnects from the SFTP server by closing the session and SSH connection.
+func (c *SftpClient) Disconnect() error {
+	slog.Debug("Disconnecting SFTP connection", "host", c.Host)
+
+	if c.client == nil {
+		return nil
+	}
+	err := c.client.Close()
+	c.client = nil
+	return err
+}
+
+// ReadDir lists the entries of a remote directory.
+func (c *SftpClient) ReadDir(path string) ([]os.FileInfo, error) {
+	if c.client == nil {
+		return nil, ErrSftpDisconnected
+	}
+	return c.client.ReadDir(path)
+}
+
+// ReadFile streams a remote file to the provided writer.
+func (c *SftpClient) ReadFile(path string, w io.Writer) (int64, error) {
+	if c.client == nil {
+		return 0, ErrSftpDisconnected
+	}
+	f, err := c.client.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return io.Copy(w, f)
+}
+
+// DeleteFile removes a remote file.
+func (c *SftpClient) DeleteFile(path string) error {
+	if c.client == nil {
+		return ErrSftpDisconnected
+	}
+	return c.client.Remove(path)
+}
+
+// authMethods builds the SSH authentication methods from the configured password and/or private key.
+func (c *SftpClient) authMethods() ([]ssh.AuthMethod, error) {
+	var methods []ssh.AuthMethod
+
+	if c.PrivateKeyFile != "" {
+		pemBytes, err := os.ReadFile(c.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read private key: %w", err)
+		}
+		var signer ssh.Signer
+		if c.PrivateKeyPassphrase != nil && *c.PrivateKeyPassphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(pemBytes, []byte(*c.PrivateKeyPassphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey(pemBytes)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key: %w", err)
+		}
+		methods = append(methods, ssh.PublicKeys(signer))
+	}
+
+	if c.Password != nil && *c.Password != "" {
+		methods = append(methods, ssh.Password(string(*c.Password)))
+	}
+
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("either password or private_key_file must be configured")
+	}
+	return methods, nil
+}
+
+// hostKeyCallback pins the server key to the configured fingerprint, or verifies it against a known_hosts file.
+func (c *SftpClient) hostKeyCallback() (ssh.HostKeyCallback, error) {
+	if c.HostKeyFingerprint != "" {
+		want := c.HostKeyFingerprint
+		if !strings.HasPrefix(want, "SHA256:") {
+			want = "SHA256:" + want
+		}
+		return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			got := ssh.FingerprintSHA256(key)
+			if got != want {
+				return fmt.Errorf("host key fingerprint mismatch for %s: got %s", hostname, got)
+			}
+			return nil
+		}, nil
+	}
+
+	if c.KnownHostsFile != "" {
+		callback, err := knownhosts.New(c.KnownHostsFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load known_hosts file: %w", err)
+		}
+		return callback, nil
+	}
+
+	return nil, ErrSftpNoHostKey
+}
+
+func (c *SftpClient) Hostname() string {
+	return c.Host
+}
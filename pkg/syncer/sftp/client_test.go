@@ -0,0 +1,123 @@
+package sftp
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-playground/sensitive"
+	"golang.org/x/crypto/ssh"
+)
+
+// TestSftpClient_NotConnectedErrors ensures client methods error when not connected.
+func TestSftpClient_NotConnectedErrors(t *testing.T) {
+	c := &SftpClient{Host: "h"}
+	if _, err := c.ReadDir("/"); err == nil {
+		t.Fatalf("expected error")
+	}
+	if _, err := c.ReadFile("/x", nil); err == nil {
+		t.Fatalf("expected error")
+	}
+	if err := c.DeleteFile("/x"); err == nil {
+		t.Fatalf("expected error")
+	}
+	if err := c.Disconnect(); err != nil {
+		t.Fatalf("disconnect without connection: %v", err)
+	}
+	if c.Hostname() != "h" {
+		t.Fatalf("hostname mismatch")
+	}
+}
+
+// TestSftpClient_Connect_RequiresHostKeyPinning ensures connecting without host key verification is refused.
+func TestSftpClient_Connect_RequiresHostKeyPinning(t *testing.T) {
+	orig := sftpDial
+	t.Cleanup(func() { sftpDial = orig })
+	sftpDial = func(addr string, cfg *ssh.ClientConfig) (sftpLowLevel, error) {
+		t.Fatalf("dial must not be attempted")
+		return nil, nil
+	}
+	pw := sensitive.String("pw")
+	c := &SftpClient{Host: "h", Port: 22, Username: "u", Password: &pw}
+	if err := c.Connect(time.Second); !errors.Is(err, ErrSftpNoHostKey) {
+		t.Fatalf("expected ErrSftpNoHostKey, got %v", err)
+	}
+}
+
+// TestSftpClient_Connect_RequiresCredentials ensures a password or key must be configured.
+func TestSftpClient_Connect_RequiresCredentials(t *testing.T) {
+	c := &SftpClient{Host: "h", Port: 22, Username: "u", HostKeyFingerprint: "SHA256:x"}
+	if err := c.Connect(time.Second); err == nil {
+		t.Fatalf("expected missing credentials error")
+	}
+}
+
+// TestSftpClient_Connect_Fingerprint connects with a pinned fingerprint and rejects a wrong one.
+func TestSftpClient_Connect_Fingerprint(t *testing.T) {
+	srv := startTestServer(t, nil)
+	dir := t.TempDir()
+	writeRemote(t, dir, "a.epub", "abc")
+
+	pw := sensitive.String("pw")
+	c := &SftpClient{Host: srv.host, Port: srv.port, Username: "u", Password: &pw, HostKeyFingerprint: srv.fingerprint}
+	if err := c.Connect(5 * time.Second); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Disconnect() })
+
+	var buf bytes.Buffer
+	if n, err := c.ReadFile(filepath.Join(dir, "a.epub"), &buf); err != nil || n != 3 || buf.String() != "abc" {
+		t.Fatalf("read n=%d err=%v body=%q", n, err, buf.String())
+	}
+	if err := c.DeleteFile(filepath.Join(dir, "a.epub")); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a.epub")); !os.IsNotExist(err) {
+		t.Fatalf("expected remote file removed")
+	}
+
+	bad := &SftpClient{Host: srv.host, Port: srv.port, Username: "u", Password: &pw, HostKeyFingerprint: "SHA256:AAAA"}
+	if err := bad.Connect(5 * time.Second); err == nil {
+		t.Fatalf("expected fingerprint mismatch")
+	}
+}
+
+// TestSftpClient_Connect_KnownHostsAndKey authenticates with an encrypted private key and known_hosts.
+func TestSftpClient_Connect_KnownHostsAndKey(t *testing.T) {
+	keyFile, pub := writeClientKey(t, "secret")
+	srv := startTestServer(t, pub)
+
+	pass := sensitive.String("secret")
+	c := &SftpClient{
+		Host: srv.host, Port: srv.port, Username: "u",
+		PrivateKeyFile: keyFile, PrivateKeyPassphrase: &pass,
+		KnownHostsFile: srv.knownHostsFile(t),
+	}
+	if err := c.Connect(5 * time.Second); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if err := c.Disconnect(); err != nil {
+		t.Fatalf("disconnect: %v", err)
+	}
+
+	wrong := sensitive.String("nope")
+	c.PrivateKeyPassphrase = &wrong
+	if err := c.Connect(5 * time.Second); err == nil {
+		t.Fatalf("expected passphrase error")
+	}
+}
+
+// TestSftpClient_Connect_UnknownHost ensures hosts missing from known_hosts are rejected.
+func TestSftpClient_Connect_UnknownHost(t *testing.T) {
+	srv := startTestServer(t, nil)
+	other := startTestServer(t, nil)
+
+	pw := sensitive.String("pw")
+	c := &SftpClient{Host: srv.host, Port: srv.port, Username: "u", Password: &pw, KnownHostsFile: other.knownHostsFile(t)}
+	if err := c.Connect(5 * time.Second); err == nil {
+		t.Fatalf("expected host key verification error")
+	}
+}
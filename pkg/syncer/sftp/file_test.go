@@ -0,0 +1,81 @@
+package sftp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// TestSftpFile_Download_KeepFolderStructureAndDelete verifies subfolders are kept and the source removed.
+func TestSftpFile_Download_KeepFolderStructureAndDelete(t *testing.T) {
+	fake := &fakeSftp{reads: map[string]string{"/r/a/b/x.epub": "DATA"}}
+	sf := NewSftpFile("/r", "a/b", fakeFileInfo{name: "x.epub", size: 4}, NewSftpFolder("/r", fake))
+
+	dst := t.TempDir()
+	if err := sf.Download(dst, "", true, true, true); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if b, err := os.ReadFile(filepath.Join(dst, "a", "b", "x.epub")); err != nil || string(b) != "DATA" {
+		t.Fatalf("read: %v %q", err, string(b))
+	}
+	if len(fake.deleted) != 1 || fake.deleted[0] != "/r/a/b/x.epub" {
+		t.Fatalf("expected source deleted, got %v", fake.deleted)
+	}
+}
+
+// TestSftpFile_Download_SkipWhenExists verifies existing files are kept when overwrite is false.
+func TestSftpFile_Download_SkipWhenExists(t *testing.T) {
+	fake := &fakeSftp{reads: map[string]string{"/r/y.epub": "NEW"}}
+	sf := NewSftpFile("/r", "", fakeFileInfo{name: "y.epub", size: 3}, NewSftpFolder("/r", fake))
+
+	dst := t.TempDir()
+	p := filepath.Join(dst, "y.epub")
+	if err := os.WriteFile(p, []byte("OLD"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := sf.Download(dst, "", false, false, true); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if b, _ := os.ReadFile(p); string(b) != "OLD" {
+		t.Fatalf("file was overwritten: %q", string(b))
+	}
+	if len(fake.deleted) != 0 {
+		t.Fatalf("skipped file must not delete source")
+	}
+}
+
+// TestSftpFile_Download_ReadError ensures read errors are returned and no temp file is left.
+func TestSftpFile_Download_ReadError(t *testing.T) {
+	sf := NewSftpFile("/r", "", fakeFileInfo{name: "z.epub"}, NewSftpFolder("/r", &fakeSftp{}))
+
+	dst := t.TempDir()
+	if err := sf.Download(dst, "", true, false, false); err == nil {
+		t.Fatalf("expected read error")
+	}
+	if entries, _ := os.ReadDir(dst); len(entries) != 0 {
+		t.Fatalf("expected no leftovers, got %d entries", len(entries))
+	}
+}
+
+// TestSftpFile_Download_DryRun ensures no files are written or deleted in dry-run mode.
+func TestSftpFile_Download_DryRun(t *testing.T) {
+	old := util.DryRun
+	t.Cleanup(func() { util.DryRun = old })
+	util.DryRun = true
+
+	fake := &fakeSftp{reads: map[string]string{"/r/a.epub": "A"}}
+	sf := NewSftpFile("/r", "", fakeFileInfo{name: "a.epub"}, NewSftpFolder("/r", fake))
+
+	dst := t.TempDir()
+	if err := sf.Download(dst, "", true, false, true); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "a.epub")); !os.IsNotExist(err) {
+		t.Fatalf("dry-run must not write files")
+	}
+	if len(fake.deleted) != 0 {
+		t.Fatalf("dry-run must not delete")
+	}
+}
@@ -0,0 +1,75 @@
+package sftp
+
+import (
+	"log/slog"
+	"os"
+	"path"
+	"slices"
+	"strings"
+)
+
+type SftpFolder struct {
+	Folder string
+
+	sftpClient SftpAPI
+}
+
+func NewSftpFolder(folder string, conn SftpAPI) *SftpFolder {
+	return &SftpFolder{
+		Folder:     folder,
+		sftpClient: conn,
+	}
+}
+
+func (s *SftpFolder) FetchFiles(folder string, validExtensions []string, recurse bool) ([]SftpFile, error) {
+	allFiles, err := s.fetchAllFiles(folder, "", validExtensions, recurse)
+	if err != nil {
+		return nil, err
+	}
+
+	return allFiles, nil
+}
+
+func (s *SftpFolder) fetchAllFiles(rootFolder string, folder string, validExtensions []string, recurse bool) ([]SftpFile, error) {
+	var allFiles []SftpFile
+
+	if folder == "" {
+		folder = rootFolder
+	}
+
+	files, err := s.sftpClient.ReadDir(folder)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build a lower-cased set of valid extensions for case-insensitive match
+	lowerExts := make([]string, 0, len(validExtensions))
+	for _, e := range validExtensions {
+		lowerExts = append(lowerExts, strings.ToLower(e))
+	}
+
+	for _, file := range files {
+		fullPath := path.Join(folder, file.Name())
+		isLink := file.Mode()&os.ModeSymlink != 0
+		if recurse && file.IsDir() && !isLink {
+			tmpFiles, err := s.fetchAllFiles(rootFolder, fullPath, validExtensions, recurse)
+			if err != nil {
+				slog.Warn("Failed to list files in directory", "directory", fullPath, "error", err)
+				continue
+			}
+			allFiles = append(allFiles, tmpFiles...)
+		} else if file.Mode().IsRegular() {
+			extension := strings.ToLower(path.Ext(fullPath))
+			if len(lowerExts) > 0 && !slices.Contains(lowerExts, extension) {
+				continue
+			}
+
+			parentFolder := path.Dir(fullPath)
+			_, subFolder, _ := strings.Cut(parentFolder, rootFolder)
+
+			allFiles = append(allFiles, *NewSftpFile(rootFolder, subFolder, file, s))
+		}
+	}
+
+	return allFiles, nil
+}
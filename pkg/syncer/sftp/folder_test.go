@@ -0,0 +1,38 @@
+package sftp
+
+import (
+	"os"
+	"testing"
+)
+
+// TestSftpFolderFetchFiles_Recurse verifies recursion, extension filtering and symlink skipping.
+func TestSftpFolderFetchFiles_Recurse(t *testing.T) {
+	fake := &fakeSftp{dirs: map[string][]os.FileInfo{
+		"/root": {
+			fakeFileInfo{name: "a.EPUB", size: 1},
+			fakeFileInfo{name: "skip.txt", size: 1},
+			fakeFileInfo{name: "sub", mode: os.ModeDir},
+			fakeFileInfo{name: "link", mode: os.ModeDir | os.ModeSymlink},
+			fakeFileInfo{name: "locked", mode: os.ModeDir},
+		},
+		"/root/sub": {fakeFileInfo{name: "b.kepub", size: 2}},
+	}}
+
+	files, err := NewSftpFolder("/root", fake).FetchFiles("/root", []string{".epub", ".kepub"}, true)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("want 2, got %d", len(files))
+	}
+	if files[1].subFolder != "/sub" || files[1].remotePath != "/root/sub/b.kepub" {
+		t.Fatalf("unexpected nested file: %+v", files[1])
+	}
+}
+
+// TestSftpFolderFetchFiles_RootError ensures a failing root listing is returned.
+func TestSftpFolderFetchFiles_RootError(t *testing.T) {
+	if _, err := NewSftpFolder("/nope", &fakeSftp{}).FetchFiles("/nope", nil, true); err == nil {
+		t.Fatalf("expected error")
+	}
+}
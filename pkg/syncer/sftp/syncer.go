@@ -0,0 +1,77 @@
+package sftp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+type SftpSyncer struct {
+	config *config.SftpConfig
+}
+
+func NewSftpSyncer(serverConfig *config.SftpConfig) *SftpSyncer {
+	// Set default port
+	if !(serverConfig.Port > 0) {
+		serverConfig.Port = 22
+	}
+
+	return &SftpSyncer{
+		config: serverConfig,
+	}
+}
+
+func (s *SftpSyncer) Run(targetFolder string, validExtensions []string, overwriteExistingFiles bool) error {
+	return s.RunContext(context.Background(), targetFolder, validExtensions, overwriteExistingFiles)
+}
+
+func (s *SftpSyncer) RunContext(ctx context.Context, targetFolder string, validExtensions []string, overwriteExistingFiles bool) error {
+	if s.config.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(s.config.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	// Connect to the SFTP server
+	sftpClient := newSftpClient(s.config)
+	if err := sftpConnect(sftpClient, 10*time.Second); err != nil {
+		return fmt.Errorf("could not connect to SFTP server %s: %w", s.config.Host, err)
+	}
+	defer sftpClient.Disconnect()
+
+	// Instantiate an SFTP Folder (via hook)
+	sftpFolder := sftpNewFolder(s.config.Folder, sftpClient)
+
+	// Fetch all files in the folder
+	allFiles, err := sftpFetchFiles(sftpFolder, s.config.Folder, validExtensions, true)
+	if err != nil {
+		return fmt.Errorf("could not fetch files from folder %s on SFTP server %s: %w", s.config.Folder, s.config.Host, err)
+	}
+
+	// Download all files
+	for i := range allFiles {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		if err := sftpDownload(&allFiles[i],
+			targetFolder,
+			"",
+			overwriteExistingFiles,
+			s.config.KeepFolderStructure,
+			s.config.RemoveFilesAfterDownload,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
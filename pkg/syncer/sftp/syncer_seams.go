@@ -0,0 +1,36 @@
+// syncer_seams.go: Test seams for the SFTP syncer.
+//
+// This file defines small function variables that production code uses by default,
+// but tests can override to substitute fakes. Keeping these seams in a separate
+// file keeps the main implementation clean while preserving simple overrides in tests.
+package sftp
+
+import (
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+// test hooks (seams) for dependency injection in tests
+var (
+	newSftpClient = func(cfg *config.SftpConfig) SftpAPI {
+		return &SftpClient{
+			Host:                 cfg.Host,
+			Port:                 cfg.Port,
+			Username:             cfg.Username,
+			Password:             cfg.Password,
+			PrivateKeyFile:       cfg.PrivateKeyFile,
+			PrivateKeyPassphrase: cfg.PrivateKeyPassphrase,
+			KnownHostsFile:       cfg.KnownHostsFile,
+			HostKeyFingerprint:   cfg.HostKeyFingerprint,
+		}
+	}
+	sftpConnect    = func(c SftpAPI, timeout time.Duration) error { return c.Connect(timeout) }
+	sftpNewFolder  = func(folder string, conn SftpAPI) *SftpFolder { return NewSftpFolder(folder, conn) }
+	sftpFetchFiles = func(f *SftpFolder, folder string, valid []string, recurse bool) ([]SftpFile, error) {
+		return f.FetchFiles(folder, valid, recurse)
+	}
+	sftpDownload = func(sf *SftpFile, dst, name string, overwrite, keep, del bool) error {
+		return sf.Download(dst, name, overwrite, keep, del)
+	}
+)
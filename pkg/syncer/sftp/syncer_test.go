@@ -0,0 +1,97 @@
+package sftp
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/go-playground/sensitive"
+)
+
+// TestNewSftpSyncer_DefaultPort ensures default SFTP port is set when unspecified.
+func TestNewSftpSyncer_DefaultPort(t *testing.T) {
+	cfg := &config.SftpConfig{}
+	NewSftpSyncer(cfg)
+	if cfg.Port != 22 {
+		t.Fatalf("want 22, got %d", cfg.Port)
+	}
+}
+
+// TestSftpSyncer_Run_EndToEnd syncs from an in-process SSH server and removes the sources.
+func TestSftpSyncer_Run_EndToEnd(t *testing.T) {
+	srv := startTestServer(t, nil)
+	remote := t.TempDir()
+	writeRemote(t, remote, "a.epub", "A")
+	writeRemote(t, remote, "sub/b.kepub", "BB")
+	writeRemote(t, remote, "c.txt", "C")
+
+	pw := sensitive.String("pw")
+	cfg := &config.SftpConfig{
+		Host: srv.host, Port: srv.port, Username: "u", Password: &pw,
+		HostKeyFingerprint:       srv.fingerprint,
+		Folder:                   remote,
+		KeepFolderStructure:      true,
+		RemoveFilesAfterDownload: true,
+	}
+	dst := t.TempDir()
+	if err := NewSftpSyncer(cfg).Run(dst, []string{".epub", ".kepub"}, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for _, p := range []string{"a.epub", filepath.Join("sub", "b.kepub")} {
+		if _, err := os.Stat(filepath.Join(dst, p)); err != nil {
+			t.Fatalf("expected %s downloaded: %v", p, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(remote, "a.epub")); !os.IsNotExist(err) {
+		t.Fatalf("expected remote a.epub removed")
+	}
+	if _, err := os.Stat(filepath.Join(remote, "c.txt")); err != nil {
+		t.Fatalf("unmatched file must remain: %v", err)
+	}
+}
+
+// TestSftpSyncer_Run_ConnectError ensures connect errors abort the run.
+func TestSftpSyncer_Run_ConnectError(t *testing.T) {
+	orig := sftpConnect
+	t.Cleanup(func() { sftpConnect = orig })
+	sftpConnect = func(c SftpAPI, timeout time.Duration) error { return errors.New("x") }
+
+	cfg := &config.SftpConfig{Host: "h", Folder: "/"}
+	if err := NewSftpSyncer(cfg).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected connect error")
+	}
+}
+
+// TestSftpSyncer_Run_FetchAndDownloadErrors ensures listing and download errors abort the run.
+func TestSftpSyncer_Run_FetchAndDownloadErrors(t *testing.T) {
+	origNew, origDL := newSftpClient, sftpDownload
+	t.Cleanup(func() { newSftpClient, sftpDownload = origNew, origDL })
+
+	fake := &fakeSftp{dirs: map[string][]os.FileInfo{"/r": {fakeFileInfo{name: "a.epub"}}}}
+	newSftpClient = func(cfg *config.SftpConfig) SftpAPI { return fake }
+
+	cfg := &config.SftpConfig{Host: "h", Folder: "/missing"}
+	if err := NewSftpSyncer(cfg).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected fetch error")
+	}
+
+	sftpDownload = func(sf *SftpFile, dst, name string, overwrite, keep, del bool) error { return errors.New("z") }
+	cfg.Folder = "/r"
+	if err := NewSftpSyncer(cfg).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected download error")
+	}
+}
+
+// TestSftpSyncer_RunContext_Cancel verifies that a canceled context stops the run.
+func TestSftpSyncer_RunContext_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cfg := &config.SftpConfig{Host: "h", Folder: "/"}
+	if err := NewSftpSyncer(cfg).RunContext(ctx, t.TempDir(), nil, false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled, got %v", err)
+	}
+}
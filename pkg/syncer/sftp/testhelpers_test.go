@@ -0,0 +1,204 @@
+package sftp
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// testServer is an in-process SSH server exposing the sftp subsystem.
+type testServer struct {
+	host        string
+	port        int
+	fingerprint string
+	hostKey     ssh.PublicKey
+}
+
+// startTestServer accepts password "pw" for any user and the optional client key.
+func startTestServer(t *testing.T, clientKey ssh.PublicKey) *testServer {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, pw []byte) (*ssh.Permissions, error) {
+			if string(pw) == "pw" {
+				return nil, nil
+			}
+			return nil, errors.New("denied")
+		},
+		PublicKeyCallback: func(c ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if clientKey != nil && string(key.Marshal()) == string(clientKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, errors.New("denied")
+		},
+	}
+	cfg.AddHostKey(signer)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			nc, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveConn(nc, cfg)
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	return &testServer{
+		host:        addr.IP.String(),
+		port:        addr.Port,
+		fingerprint: ssh.FingerprintSHA256(signer.PublicKey()),
+		hostKey:     signer.PublicKey(),
+	}
+}
+
+func serveConn(nc net.Conn, cfg *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(nc, cfg)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for nch := range chans {
+		if nch.ChannelType() != "session" {
+			_ = nch.Reject(ssh.UnknownChannelType, "unsupported")
+			continue
+		}
+		ch, chReqs, err := nch.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			for req := range chReqs {
+				ok := req.Type == "subsystem" && string(req.Payload[4:]) == "sftp"
+				_ = req.Reply(ok, nil)
+				if ok {
+					srv, err := sftp.NewServer(ch)
+					if err != nil {
+						ch.Close()
+						return
+					}
+					_ = srv.Serve()
+					ch.Close()
+					return
+				}
+			}
+		}()
+	}
+}
+
+// knownHostsFile writes a known_hosts file trusting the server key.
+func (s *testServer) knownHostsFile(t *testing.T) string {
+	t.Helper()
+	line := knownhosts.Line([]string{knownhosts.Normalize(net.JoinHostPort(s.host, strconv.Itoa(s.port)))}, s.hostKey)
+	p := filepath.Join(t.TempDir(), "known_hosts")
+	if err := os.WriteFile(p, []byte(line+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// writeClientKey generates a client key pair and writes the private key to disk.
+func writeClientKey(t *testing.T, passphrase string) (string, ssh.PublicKey) {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var block *pem.Block
+	if passphrase != "" {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(priv, "", []byte(passphrase))
+	} else {
+		block, err = ssh.MarshalPrivateKey(priv, "")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(p, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p, signer.PublicKey()
+}
+
+// writeRemote creates a file below dir.
+func writeRemote(t *testing.T, dir, name, content string) {
+	t.Helper()
+	p := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+}
+
+// fakeFileInfo is a minimal os.FileInfo for fakes.
+type fakeFileInfo struct {
+	name string
+	size int64
+	mode os.FileMode
+}
+
+func (f fakeFileInfo) Name() string       { return f.name }
+func (f fakeFileInfo) Size() int64        { return f.size }
+func (f fakeFileInfo) Mode() os.FileMode  { return f.mode }
+func (f fakeFileInfo) ModTime() time.Time { return time.Time{} }
+func (f fakeFileInfo) IsDir() bool        { return f.mode.IsDir() }
+func (f fakeFileInfo) Sys() any           { return nil }
+
+// fakeSftp implements SftpAPI over in-memory listings and records deletions.
+type fakeSftp struct {
+	dirs    map[string][]os.FileInfo
+	reads   map[string]string
+	deleted []string
+}
+
+func (f *fakeSftp) Connect(timeout time.Duration) error { return nil }
+func (f *fakeSftp) Disconnect() error                   { return nil }
+func (f *fakeSftp) Hostname() string                    { return "fake" }
+func (f *fakeSftp) ReadDir(p string) ([]os.FileInfo, error) {
+	if l, ok := f.dirs[p]; ok {
+		return l, nil
+	}
+	return nil, errors.New("permission denied")
+}
+func (f *fakeSftp) ReadFile(p string, w io.Writer) (int64, error) {
+	s, ok := f.reads[p]
+	if !ok {
+		return 0, errors.New("not found")
+	}
+	n, err := w.Write([]byte(s))
+	return int64(n), err
+}
+func (f *fakeSftp) DeleteFile(p string) error { f.deleted = append(f.deleted, p); return nil }
@@ -0,0 +1,281 @@
+package wallabag
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+	"github.com/go-playground/sensitive"
+)
+
+// WallabagAPI is the minimal contract used by the syncer and entry logic. It
+// enables injecting a fake in tests.
+type WallabagAPI interface {
+	Connect(ctx context.Context, timeout time.Duration) error
+	Disconnect() error
+	Entries(filter EntryFilter) ([]Entry, error)
+	ExportEntry(id int, w io.Writer) (int64, error)
+	ArchiveEntry(id int) error
+	Host() string
+}
+
+// EntryFilter selects the entries to export. Entries must carry all tags.
+type EntryFilter struct {
+	Tags            []string
+	Starred         bool
+	IncludeArchived bool
+}
+
+// Entry is a saved article.
+type Entry struct {
+	ID     int    `json:"id"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+	Domain string `json:"domain_name"`
+}
+
+// Package-level errors
+var (
+	ErrWallabagDisconnected = fmt.Errorf("not connected to Wallabag")
+)
+
+// entriesPageSize is the number of entries requested per page.
+var entriesPageSize = 30
+
+// WallabagClient talks to the Wallabag REST API. It obtains an OAuth access
+// token with the password grant when a username is set, and with the client
+// credentials grant otherwise, and renews the token when it expires.
+type WallabagClient struct {
+	baseURL      *url.URL
+	clientID     string
+	clientSecret *sensitive.String
+	username     string
+	password     *sensitive.String
+
+	// ctx bounds every request of the session
+	ctx         context.Context
+	client      *http.Client
+	accessToken string
+	expires     time.Time
+}
+
+func NewWallabagClient(baseURL string, clientID string, clientSecret *sensitive.String, username string, password *sensitive.String) (*WallabagClient, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid wallabag url %s: %w", baseURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid wallabag url %s: scheme must be http or https", baseURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	return &WallabagClient{
+		baseURL:      u,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		username:     username,
+		password:     password,
+	}, nil
+}
+
+// Connect prepares the HTTP client and obtains an access token. The timeout
+// bounds connecting and waiting for responses, not the transfer of exports.
+func (c *WallabagClient) Connect(ctx context.Context, timeout time.Duration) error {
+	slog.Debug("Initiating Wallabag connection", "host", c.Host())
+
+	c.ctx = ctx
+	c.client = util.NewHTTPClient(timeout)
+	if err := c.authenticate(); err != nil {
+		c.client = nil
+		return fmt.Errorf("could not log in to Wallabag: %w", err)
+	}
+	return nil
+}
+
+func (c *WallabagClient) Disconnect() error {
+	if c.client == nil {
+		return nil
+	}
+	slog.Debug("Disconnecting Wallabag connection", "host", c.Host())
+	c.client.CloseIdleConnections()
+	c.client = nil
+	c.accessToken = ""
+	return nil
+}
+
+func (c *WallabagClient) Host() string {
+	return c.baseURL.Host
+}
+
+// Entries returns all entries matching the filter, oldest first.
+func (c *WallabagClient) Entries(filter EntryFilter) ([]Entry, error) {
+	query := url.Values{
+		"sort":    {"created"},
+		"order":   {"asc"},
+		"perPage": {strconv.Itoa(entriesPageSize)},
+		"detail":  {"metadata"},
+	}
+	if !filter.IncludeArchived {
+		query.Set("archive", "0")
+	}
+	if filter.Starred {
+		query.Set("starred", "1")
+	}
+	if len(filter.Tags) > 0 {
+		query.Set("tags", strings.Join(filter.Tags, ","))
+	}
+
+	var entries []Entry
+	for page := 1; ; page++ {
+		query.Set("page", strconv.Itoa(page))
+		var result struct {
+			Pages    int `json:"pages"`
+			Embedded struct {
+				Items []Entry `json:"items"`
+			} `json:"_embedded"`
+		}
+		if err := c.doJSON(http.MethodGet, "/api/entries.json", query, nil, &result); err != nil {
+			return nil, fmt.Errorf("failed to list entries: %w", err)
+		}
+		entries = append(entries, result.Embedded.Items...)
+		if page >= result.Pages || len(result.Embedded.Items) == 0 {
+			return entries, nil
+		}
+	}
+}
+
+// ExportEntry streams the EPUB export of an entry to w.
+func (c *WallabagClient) ExportEntry(id int, w io.Writer) (int64, error) {
+	resp, err := c.do(http.MethodGet, path.Join("/api/entries", strconv.Itoa(id), "export.epub"), nil, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed to export entry %d: %s", id, resp.Status)
+	}
+	return io.Copy(w, resp.Body)
+}
+
+// ArchiveEntry marks an entry as read.
+func (c *WallabagClient) ArchiveEntry(id int) error {
+	if err := c.doJSON(http.MethodPatch, "/api/entries/"+strconv.Itoa(id)+".json", nil, map[string]int{"archive": 1}, nil); err != nil {
+		return fmt.Errorf("failed to archive entry %d: %w", id, err)
+	}
+	return nil
+}
+
+// authenticate requests a new access token.
+func (c *WallabagClient) authenticate() error {
+	form := url.Values{"client_id": {c.clientID}}
+	if c.clientSecret != nil {
+		form.Set("client_secret", string(*c.clientSecret))
+	}
+	if c.username != "" {
+		form.Set("grant_type", "password")
+		form.Set("username", c.username)
+		if c.password != nil {
+			form.Set("password", string(*c.password))
+		}
+	} else {
+		form.Set("grant_type", "client_credentials")
+	}
+
+	u := *c.baseURL
+	u.Path = path.Join(c.baseURL.Path, "/oauth/v2/token")
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodPost, u.String(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected response: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return fmt.Errorf("invalid token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return fmt.Errorf("no access token in response")
+	}
+	c.accessToken = token.AccessToken
+	c.expires = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	return nil
+}
+
+// do sends an authenticated request for a path relative to the base URL,
+// with an optional JSON body. An expired token is renewed first.
+func (c *WallabagClient) do(method string, p string, query url.Values, body any) (*http.Response, error) {
+	if c.client == nil {
+		return nil, ErrWallabagDisconnected
+	}
+	if !c.expires.IsZero() && time.Now().Add(time.Minute).After(c.expires) {
+		slog.Debug("Renewing Wallabag access token", "host", c.Host())
+		if err := c.authenticate(); err != nil {
+			return nil, fmt.Errorf("could not renew Wallabag access token: %w", err)
+		}
+	}
+
+	u := *c.baseURL
+	u.Path = path.Join(c.baseURL.Path, p)
+	if query != nil {
+		u.RawQuery = query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(c.ctx, method, u.String(), reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.client.Do(req)
+}
+
+// doJSON sends a request and decodes a JSON response into v.
+func (c *WallabagClient) doJSON(method string, p string, query url.Values, body any, v any) error {
+	resp, err := c.do(method, p, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected response: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if v == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,165 @@
+package wallabag
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/sensitive"
+)
+
+func newTestClient(t *testing.T, srv *wallabagServer, username string) *WallabagClient {
+	t.Helper()
+	secret, password := sensitive.String(testClientSecret), sensitive.String(testPassword)
+	c, err := NewWallabagClient(srv.URL+"/", testClientID, &secret, username, &password)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	if err := c.Connect(context.Background(), 5*time.Second); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Disconnect() })
+	return c
+}
+
+// TestNewWallabagClient rejects invalid URLs.
+func TestNewWallabagClient(t *testing.T) {
+	for _, u := range []string{"ftp://wallabag", "://bad"} {
+		if _, err := NewWallabagClient(u, testClientID, nil, "", nil); err == nil {
+			t.Fatalf("%s: expected error", u)
+		}
+	}
+}
+
+// TestWallabagClient_Connect covers both grants and rejected credentials.
+func TestWallabagClient_Connect(t *testing.T) {
+	srv := newWallabagServer(t)
+	newTestClient(t, srv, testUsername)
+	newTestClient(t, srv, "")
+	if !slices.Equal(srv.grants, []string{"password", "client_credentials"}) {
+		t.Fatalf("unexpected grants: %v", srv.grants)
+	}
+
+	wrong := sensitive.String("wrong")
+	c, _ := NewWallabagClient(srv.URL, testClientID, &wrong, "", nil)
+	if err := c.Connect(context.Background(), 5*time.Second); err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Fatalf("expected login error, got %v", err)
+	}
+	if _, err := c.Entries(EntryFilter{}); !errors.Is(err, ErrWallabagDisconnected) {
+		t.Fatalf("expected ErrWallabagDisconnected, got %v", err)
+	}
+}
+
+// TestWallabagClient_Entries pages through the entries and applies the filter.
+func TestWallabagClient_Entries(t *testing.T) {
+	srv := newWallabagServer(t,
+		&testEntry{ID: 1, Title: "One", Tags: []string{"kobo"}},
+		&testEntry{ID: 2, Title: "Two", Tags: []string{"kobo", "long"}, Starred: true},
+		&testEntry{ID: 3, Title: "Three", Archived: true, Tags: []string{"kobo", "long"}},
+		&testEntry{ID: 4, Title: "Four"},
+	)
+	old := entriesPageSize
+	t.Cleanup(func() { entriesPageSize = old })
+	entriesPageSize = 2
+	c := newTestClient(t, srv, testUsername)
+
+	ids := func(filter EntryFilter) []int {
+		t.Helper()
+		entries, err := c.Entries(filter)
+		if err != nil {
+			t.Fatalf("entries: %v", err)
+		}
+		var ids []int
+		for _, e := range entries {
+			ids = append(ids, e.ID)
+		}
+		return ids
+	}
+	if got := ids(EntryFilter{}); !slices.Equal(got, []int{1, 2, 4}) {
+		t.Fatalf("unread: %v", got)
+	}
+	if got := ids(EntryFilter{Tags: []string{"kobo", "long"}, IncludeArchived: true}); !slices.Equal(got, []int{2, 3}) {
+		t.Fatalf("tagged: %v", got)
+	}
+	if got := ids(EntryFilter{Starred: true}); !slices.Equal(got, []int{2}) {
+		t.Fatalf("starred: %v", got)
+	}
+	if got := ids(EntryFilter{Tags: []string{"missing"}}); len(got) != 0 {
+		t.Fatalf("expected no entries, got %v", got)
+	}
+}
+
+// TestWallabagClient_ExportAndArchive exports an entry and archives it.
+func TestWallabagClient_ExportAndArchive(t *testing.T) {
+	srv := newWallabagServer(t, &testEntry{ID: 7, Title: "Seven", Content: "EPUB"})
+	c := newTestClient(t, srv, testUsername)
+
+	var buf bytes.Buffer
+	if n, err := c.ExportEntry(7, &buf); err != nil || n != 4 || buf.String() != "EPUB" {
+		t.Fatalf("export: %v %d %q", err, n, buf.String())
+	}
+	if _, err := c.ExportEntry(8, &buf); err == nil {
+		t.Fatalf("expected error for missing entry")
+	}
+
+	if err := c.ArchiveEntry(7); err != nil {
+		t.Fatalf("archive: %v", err)
+	}
+	if !srv.entry("7").Archived {
+		t.Fatalf("expected entry archived")
+	}
+	if err := c.ArchiveEntry(8); err == nil {
+		t.Fatalf("expected error archiving missing entry")
+	}
+}
+
+// TestWallabagClient_RenewsToken requests a new token once the current one expires.
+func TestWallabagClient_RenewsToken(t *testing.T) {
+	srv := newWallabagServer(t, &testEntry{ID: 1, Title: "One"})
+	srv.expiresIn = 30
+	c := newTestClient(t, srv, testUsername)
+	if _, err := c.Entries(EntryFilter{}); err != nil {
+		t.Fatalf("entries: %v", err)
+	}
+	if srv.tokens != 2 {
+		t.Fatalf("expected the token renewed, got %d tokens", srv.tokens)
+	}
+}
+
+// TestWallabagClient_SlowDownload verifies exports may take longer than the
+// connect timeout to download and are stopped by the session context.
+func TestWallabagClient_SlowDownload(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/oauth/v2/token" {
+			_, _ = w.Write([]byte(`{"access_token":"token","expires_in":3600}`))
+			return
+		}
+		for range 3 {
+			_, _ = w.Write([]byte("chunk"))
+			w.(http.Flusher).Flush()
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c, _ := NewWallabagClient(srv.URL, testClientID, nil, "", nil)
+	if err := c.Connect(ctx, 50*time.Millisecond); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := c.ExportEntry(1, &buf); err != nil || buf.String() != "chunkchunkchunk" {
+		t.Fatalf("slow download: %q %v", buf.String(), err)
+	}
+
+	cancel()
+	if _, err := c.ExportEntry(1, &buf); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package wallabag
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+type WallabagEntry struct {
+	entry    Entry
+	fileName string
+
+	wallabagClient WallabagAPI
+}
+
+// NewWallabagEntry names the local file after the title of the entry.
+func NewWallabagEntry(entry Entry, conn WallabagAPI) *WallabagEntry {
+	name := entry.Title
+	if strings.TrimSpace(name) == "" {
+		name = fmt.Sprintf("wallabag-%d", entry.ID)
+	}
+	return &WallabagEntry{
+		entry:          entry,
+		fileName:       util.SafeFileName(strings.ReplaceAll(name, "/", " ") + ".epub"),
+		wallabagClient: conn,
+	}
+}
+
+// FileName returns the local file name of the entry.
+func (e *WallabagEntry) FileName() string {
+	return e.fileName
+}
+
+func (e *WallabagEntry) Download(dstFolder string, overwriteExistingFile bool, archiveEntry bool) error {
+	dstPath := filepath.Join(dstFolder, e.fileName)
+
+	// Create folder structure if required
+	if _, err := os.Stat(dstFolder); os.IsNotExist(err) {
+		if util.DryRun {
+			slog.Info("[dry-run] Would create local folder", "folder", dstFolder)
+			return nil
+		}
+		slog.Info("Creating local folder", "folder", dstFolder)
+		if err := os.MkdirAll(dstFolder, 0755); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("Exporting entry from Wallabag", "host", e.wallabagClient.Host(), "id", e.entry.ID, "title", e.entry.Title, "destination", dstPath)
+
+	// Check if the file already exists
+	_, err := os.Stat(dstPath)
+	if !os.IsNotExist(err) {
+		if !overwriteExistingFile {
+			slog.Warn("File already exists, skipping download", "file", dstPath)
+			return nil
+		}
+
+		slog.Info("Overwriting existing file", "file", dstPath)
+	}
+
+	// Download the file
+	if util.DryRun {
+		slog.Info("[dry-run] Would export entry", "id", e.entry.ID, "destination", dstPath)
+		return nil
+	}
+	tmpFile, err := os.CreateTemp(dstFolder, "bookshift-")
+	if err != nil {
+		return err
+	}
+	defer tmpFile.Close()
+
+	slog.Debug("Downloading to temporary file", "file", tmpFile.Name())
+	writer := util.NewFileWriter(tmpFile, 0, true)
+	if _, err := e.wallabagClient.ExportEntry(e.entry.ID, writer); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+
+	if err := tmpFile.Sync(); err != nil { // ensure data flushed before rename
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	if err := os.Rename(tmpFile.Name(), dstPath); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+
+	// Archive the entry if requested
+	if archiveEntry {
+		if util.DryRun {
+			slog.Info("[dry-run] Would archive entry on Wallabag", "id", e.entry.ID)
+		} else {
+			if err := e.wallabagClient.ArchiveEntry(e.entry.ID); err != nil {
+				return err
+			}
+			slog.Info("Archived entry on Wallabag", "host", e.wallabagClient.Host(), "id", e.entry.ID)
+		}
+	}
+
+	slog.Info("Successfully downloaded file", "filename", e.fileName)
+	return nil
+}
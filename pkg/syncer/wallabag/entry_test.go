@@ -0,0 +1,78 @@
+package wallabag
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// TestNewWallabagEntry_FileName names files after the title.
+func TestNewWallabagEntry_FileName(t *testing.T) {
+	for title, want := range map[string]string{
+		"Go 1.25: What's new": "go-1.25-whats-new.epub",
+		"AC/DC on tour":       "ac-dc-on-tour.epub",
+		"  ":                  "wallabag-3.epub",
+	} {
+		if got := NewWallabagEntry(Entry{ID: 3, Title: title}, &fakeWallabag{}).FileName(); got != want {
+			t.Fatalf("%q: got %q, want %q", title, got, want)
+		}
+	}
+}
+
+// TestWallabagEntry_Download exports the entry, archives it and keeps existing files.
+func TestWallabagEntry_Download(t *testing.T) {
+	fake := &fakeWallabag{content: map[int]string{1: "EPUB"}}
+	e := NewWallabagEntry(Entry{ID: 1, Title: "One"}, fake)
+	dst := filepath.Join(t.TempDir(), "new")
+	if err := e.Download(dst, false, true); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dst, "one.epub")); err != nil || string(data) != "EPUB" {
+		t.Fatalf("unexpected file: %q %v", data, err)
+	}
+	if !slices.Equal(fake.archived, []int{1}) {
+		t.Fatalf("expected entry archived, got %v", fake.archived)
+	}
+
+	fake.content[1] = "NEW"
+	if err := e.Download(dst, false, true); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "one.epub")); string(data) != "EPUB" {
+		t.Fatalf("existing file must be kept")
+	}
+	if len(fake.archived) != 1 {
+		t.Fatalf("skipped entry must not be archived")
+	}
+
+	fake.exportErr = errors.New("boom")
+	if err := e.Download(dst, true, true); err == nil {
+		t.Fatalf("expected export error")
+	}
+	if entries, _ := os.ReadDir(dst); len(entries) != 1 {
+		t.Fatalf("expected no temporary leftovers, got %d entries", len(entries))
+	}
+}
+
+// TestWallabagEntry_DryRun ensures nothing is written or archived.
+func TestWallabagEntry_DryRun(t *testing.T) {
+	old := util.DryRun
+	t.Cleanup(func() { util.DryRun = old })
+	util.DryRun = true
+
+	fake := &fakeWallabag{content: map[int]string{1: "EPUB"}}
+	e := NewWallabagEntry(Entry{ID: 1, Title: "One"}, fake)
+	dst := t.TempDir()
+	for _, folder := range []string{dst, filepath.Join(dst, "missing")} {
+		if err := e.Download(folder, true, true); err != nil {
+			t.Fatalf("dry-run: %v", err)
+		}
+	}
+	if entries, _ := os.ReadDir(dst); len(entries) != 0 || len(fake.archived) != 0 {
+		t.Fatalf("dry-run must not write or archive")
+	}
+}
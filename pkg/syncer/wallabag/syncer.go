@@ -0,0 +1,116 @@
+package wallabag
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// wallabagState is persisted between runs to remember exported entries.
+type wallabagState struct {
+	Exported []int `json:"exported"`
+}
+
+type WallabagSyncer struct {
+	config *config.WallabagConfig
+}
+
+func NewWallabagSyncer(wallabagConfig *config.WallabagConfig) *WallabagSyncer {
+	return &WallabagSyncer{
+		config: wallabagConfig,
+	}
+}
+
+func (s *WallabagSyncer) Run(targetFolder string, validExtensions []string, overwriteExistingFiles bool) error {
+	return s.RunContext(context.Background(), targetFolder, validExtensions, overwriteExistingFiles)
+}
+
+func (s *WallabagSyncer) RunContext(ctx context.Context, targetFolder string, validExtensions []string, overwriteExistingFiles bool) (err error) {
+	if s.config.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(s.config.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	// Entries are only exported as EPUB
+	if len(validExtensions) > 0 && !slices.ContainsFunc(validExtensions, func(e string) bool { return strings.EqualFold(e, ".epub") }) {
+		return fmt.Errorf("wallabag entries are exported as .epub, which is not in valid_extensions")
+	}
+
+	// Load the entries exported during previous runs
+	statePath := s.config.StateFile
+	if statePath == "" {
+		statePath = util.DefaultStatePath(targetFolder, "wallabag", s.config.URL)
+	}
+	var state wallabagState
+	if err := util.LoadState(statePath, &state); err != nil {
+		return fmt.Errorf("could not load wallabag state from %s: %w", statePath, err)
+	}
+	exported := map[int]bool{}
+	for _, id := range state.Exported {
+		exported[id] = true
+	}
+
+	// Connect to Wallabag
+	wallabagClient, err := newWallabagClient(s.config)
+	if err != nil {
+		return err
+	}
+	if err := wallabagConnect(ctx, wallabagClient, 60*time.Second); err != nil {
+		return fmt.Errorf("could not connect to Wallabag %s: %w", s.config.URL, err)
+	}
+	defer wallabagClient.Disconnect()
+
+	filter := EntryFilter{
+		Tags:            s.config.Tags,
+		Starred:         s.config.Starred,
+		IncludeArchived: s.config.IncludeArchived,
+	}
+	entries, err := wallabagEntries(wallabagClient, filter)
+	if err != nil {
+		return fmt.Errorf("could not list entries on Wallabag %s: %w", s.config.URL, err)
+	}
+	slog.Info("Found entries on Wallabag", "host", wallabagClient.Host(), "count", len(entries))
+
+	// The state is saved even when a later entry fails
+	defer func() {
+		if saveErr := util.SaveState(statePath, &state); saveErr != nil && err == nil {
+			err = fmt.Errorf("could not save wallabag state to %s: %w", statePath, saveErr)
+		}
+	}()
+
+	// Export all entries that were not exported before
+	for _, entry := range entries {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		if exported[entry.ID] {
+			slog.Debug("Skipping previously exported entry", "id", entry.ID, "title", entry.Title)
+			continue
+		}
+
+		e := NewWallabagEntry(entry, wallabagClient)
+		if err := wallabagDownload(e, targetFolder, overwriteExistingFiles, s.config.ArchiveAfterDownload); err != nil {
+			return err
+		}
+
+		exported[entry.ID] = true
+		state.Exported = append(state.Exported, entry.ID)
+	}
+
+	return nil
+}
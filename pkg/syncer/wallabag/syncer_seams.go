@@ -0,0 +1,25 @@
+// syncer_seams.go: Test seams for the Wallabag syncer.
+//
+// This file defines small function variables that production code uses by default,
+// but tests can override to substitute fakes. Keeping these seams in a separate
+// file keeps the main implementation clean while preserving simple overrides in tests.
+package wallabag
+
+import (
+	"context"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+// test hooks (seams) for dependency injection in tests
+var (
+	newWallabagClient = func(cfg *config.WallabagConfig) (WallabagAPI, error) {
+		return NewWallabagClient(cfg.URL, cfg.ClientID, cfg.ClientSecret, cfg.Username, cfg.Password)
+	}
+	wallabagConnect  = func(ctx context.Context, c WallabagAPI, timeout time.Duration) error { return c.Connect(ctx, timeout) }
+	wallabagEntries  = func(c WallabagAPI, filter EntryFilter) ([]Entry, error) { return c.Entries(filter) }
+	wallabagDownload = func(e *WallabagEntry, dst string, overwrite, archive bool) error {
+		return e.Download(dst, overwrite, archive)
+	}
+)
@@ -0,0 +1,125 @@
+package wallabag
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+	"github.com/go-playground/sensitive"
+)
+
+func withFakeWallabag(t *testing.T, f *fakeWallabag) {
+	t.Helper()
+	orig := newWallabagClient
+	t.Cleanup(func() { newWallabagClient = orig })
+	newWallabagClient = func(cfg *config.WallabagConfig) (WallabagAPI, error) { return f, nil }
+}
+
+// TestWallabagSyncer_Run_EndToEnd exports unread entries from a Wallabag stand-in and archives them.
+func TestWallabagSyncer_Run_EndToEnd(t *testing.T) {
+	srv := newWallabagServer(t,
+		&testEntry{ID: 1, Title: "One", Content: "E1"},
+		&testEntry{ID: 2, Title: "Two", Content: "E2", Archived: true},
+	)
+	secret, password := sensitive.String(testClientSecret), sensitive.String(testPassword)
+	cfg := &config.WallabagConfig{URL: srv.URL, ClientID: testClientID, ClientSecret: &secret, Username: testUsername, Password: &password, ArchiveAfterDownload: true}
+
+	dst := t.TempDir()
+	if err := NewWallabagSyncer(cfg).Run(dst, []string{".EPUB", ".pdf"}, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dst, "one.epub")); err != nil || string(data) != "E1" {
+		t.Fatalf("unexpected file: %q %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "two.epub")); !os.IsNotExist(err) {
+		t.Fatalf("archived entry was exported")
+	}
+	if !srv.entry("1").Archived {
+		t.Fatalf("expected entry archived after download")
+	}
+}
+
+// TestWallabagSyncer_Run_State skips entries exported during earlier runs.
+func TestWallabagSyncer_Run_State(t *testing.T) {
+	f := &fakeWallabag{
+		entries: []Entry{{ID: 1, Title: "One"}, {ID: 2, Title: "Two"}},
+		content: map[int]string{1: "E1"},
+	}
+	withFakeWallabag(t, f)
+	cfg := &config.WallabagConfig{URL: "http://wallabag"}
+	dst := t.TempDir()
+
+	// The first entry is remembered although the second one fails
+	if err := NewWallabagSyncer(cfg).Run(dst, nil, false); err == nil {
+		t.Fatalf("expected export error")
+	}
+	_ = os.Remove(filepath.Join(dst, "one.epub"))
+
+	f.content[2] = "E2"
+	if err := NewWallabagSyncer(cfg).Run(dst, nil, false); err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "one.epub")); !os.IsNotExist(err) {
+		t.Fatalf("previously exported entry exported again")
+	}
+	if _, err := os.Stat(filepath.Join(dst, "two.epub")); err != nil {
+		t.Fatalf("expected second entry: %v", err)
+	}
+	if len(f.archived) != 0 {
+		t.Fatalf("entries must only be archived when configured, got %v", f.archived)
+	}
+}
+
+// TestWallabagSyncer_Run_Errors covers invalid extensions, client, connect and list errors.
+func TestWallabagSyncer_Run_Errors(t *testing.T) {
+	cfg := &config.WallabagConfig{URL: "ftp://wallabag"}
+	if err := NewWallabagSyncer(cfg).Run(t.TempDir(), []string{".pdf"}, false); err == nil {
+		t.Fatalf("expected error without .epub in valid extensions")
+	}
+	if err := NewWallabagSyncer(cfg).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected client error")
+	}
+
+	f := &fakeWallabag{entriesErr: errors.New("x")}
+	withFakeWallabag(t, f)
+	if err := NewWallabagSyncer(&config.WallabagConfig{URL: "http://wallabag"}).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected list error")
+	}
+
+	origConnect := wallabagConnect
+	t.Cleanup(func() { wallabagConnect = origConnect })
+	wallabagConnect = func(ctx context.Context, c WallabagAPI, timeout time.Duration) error { return errors.New("x") }
+	if err := NewWallabagSyncer(&config.WallabagConfig{URL: "http://wallabag"}).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected connect error")
+	}
+}
+
+// TestWallabagSyncer_Run_Cancelled stops before connecting and between entries.
+func TestWallabagSyncer_Run_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := NewWallabagSyncer(&config.WallabagConfig{URL: "http://wallabag"}).RunContext(ctx, t.TempDir(), nil, false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	f := &fakeWallabag{entries: []Entry{{ID: 1}, {ID: 2}}, content: map[int]string{1: "E1", 2: "E2"}}
+	withFakeWallabag(t, f)
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	var exported []int
+	origDownload := wallabagDownload
+	t.Cleanup(func() { wallabagDownload = origDownload })
+	wallabagDownload = func(e *WallabagEntry, dst string, overwrite, archive bool) error {
+		exported = append(exported, e.entry.ID)
+		cancel()
+		return nil
+	}
+	if err := NewWallabagSyncer(&config.WallabagConfig{URL: "http://wallabag"}).RunContext(ctx, t.TempDir(), nil, false); !errors.Is(err, context.Canceled) || !slices.Equal(exported, []int{1}) {
+		t.Fatalf("expected cancellation after the first entry, got %v %v", err, exported)
+	}
+}
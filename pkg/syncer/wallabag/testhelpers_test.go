@@ -0,0 +1,199 @@
+package wallabag
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testClientID     = "client-id"
+	testClientSecret = "client-secret"
+	testUsername     = "reader"
+	testPassword     = "password"
+)
+
+// testEntry is an entry served by the Wallabag stand-in.
+type testEntry struct {
+	ID       int
+	Title    string
+	Tags     []string
+	Archived bool
+	Starred  bool
+	Content  string
+}
+
+// wallabagServer is an httptest Wallabag stand-in. It issues a new access
+// token for every token request and records the requests it receives.
+type wallabagServer struct {
+	URL string
+
+	mu        sync.Mutex
+	entries   []*testEntry
+	tokens    int
+	expiresIn int
+	grants    []string
+	requests  []string
+}
+
+func newWallabagServer(t *testing.T, entries ...*testEntry) *wallabagServer {
+	t.Helper()
+	s := &wallabagServer{entries: entries, expiresIn: 3600}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /oauth/v2/token", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		grant := r.PostFormValue("grant_type")
+		s.grants = append(s.grants, grant)
+		ok := r.PostFormValue("client_id") == testClientID && r.PostFormValue("client_secret") == testClientSecret
+		if grant == "password" {
+			ok = ok && r.PostFormValue("username") == testUsername && r.PostFormValue("password") == testPassword
+		}
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, map[string]string{"error": "invalid_grant"})
+			return
+		}
+		s.tokens++
+		writeJSON(w, map[string]any{"access_token": "token-" + strconv.Itoa(s.tokens), "expires_in": s.expiresIn, "token_type": "bearer"})
+	})
+	mux.HandleFunc("GET /api/entries.json", s.authorized(s.listEntries))
+	mux.HandleFunc("GET /api/entries/{id}/export.epub", s.authorized(func(w http.ResponseWriter, r *http.Request) {
+		e := s.entry(r.PathValue("id"))
+		if e == nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/epub+zip")
+		_, _ = io.WriteString(w, e.Content)
+	}))
+	mux.HandleFunc("PATCH /api/entries/{id}", s.authorized(func(w http.ResponseWriter, r *http.Request) {
+		e := s.entry(strings.TrimSuffix(r.PathValue("id"), ".json"))
+		var body struct {
+			Archive int `json:"archive"`
+		}
+		if e == nil || json.NewDecoder(r.Body).Decode(&body) != nil {
+			http.NotFound(w, r)
+			return
+		}
+		s.mu.Lock()
+		e.Archived = body.Archive == 1
+		s.mu.Unlock()
+		writeJSON(w, map[string]any{"id": e.ID})
+	}))
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	s.URL = srv.URL
+	return s
+}
+
+// authorized rejects requests without the latest access token.
+func (s *wallabagServer) authorized(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery)
+		valid := s.tokens > 0 && r.Header.Get("Authorization") == "Bearer token-"+strconv.Itoa(s.tokens)
+		s.mu.Unlock()
+		if !valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		h(w, r)
+	}
+}
+
+func (s *wallabagServer) listEntries(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	q := r.URL.Query()
+	var matching []map[string]any
+	for _, e := range s.entries {
+		if q.Get("archive") == "0" && e.Archived || q.Get("starred") == "1" && !e.Starred {
+			continue
+		}
+		if tags := q.Get("tags"); tags != "" && !allTags(e.Tags, strings.Split(tags, ",")) {
+			continue
+		}
+		matching = append(matching, map[string]any{"id": e.ID, "title": e.Title, "url": "https://example.com/" + strconv.Itoa(e.ID), "domain_name": "example.com", "is_archived": 0})
+	}
+
+	perPage, _ := strconv.Atoi(q.Get("perPage"))
+	page, _ := strconv.Atoi(q.Get("page"))
+	pages := max(1, (len(matching)+perPage-1)/perPage)
+	if page > pages {
+		http.NotFound(w, r)
+		return
+	}
+	items := matching[min((page-1)*perPage, len(matching)):min(page*perPage, len(matching))]
+	writeJSON(w, map[string]any{"page": page, "limit": perPage, "pages": pages, "total": len(matching), "_embedded": map[string]any{"items": items}})
+}
+
+func (s *wallabagServer) entry(id string) *testEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.entries {
+		if strconv.Itoa(e.ID) == id {
+			return e
+		}
+	}
+	return nil
+}
+
+func allTags(have, want []string) bool {
+	for _, tag := range want {
+		if !slices.Contains(have, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// fakeWallabag is an in-memory WallabagAPI.
+type fakeWallabag struct {
+	entries    []Entry
+	content    map[int]string
+	archived   []int
+	entriesErr error
+	exportErr  error
+}
+
+func (f *fakeWallabag) Connect(context.Context, time.Duration) error { return nil }
+func (f *fakeWallabag) Disconnect() error                            { return nil }
+func (f *fakeWallabag) Host() string                                 { return "fake" }
+
+func (f *fakeWallabag) Entries(EntryFilter) ([]Entry, error) {
+	return f.entries, f.entriesErr
+}
+
+func (f *fakeWallabag) ExportEntry(id int, w io.Writer) (int64, error) {
+	if f.exportErr != nil {
+		return 0, f.exportErr
+	}
+	content, ok := f.content[id]
+	if !ok {
+		return 0, errors.New("no such entry")
+	}
+	n, err := io.WriteString(w, content)
+	return int64(n), err
+}
+
+func (f *fakeWallabag) ArchiveEntry(id int) error {
+	f.archived = append(f.archived, id)
+	return nil
+}
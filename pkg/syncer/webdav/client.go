@@ -0,0 +1,248 @@
+package webdav
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+	"github.com/go-playground/sensitive"
+)
+
+// WebdavAPI is the minimal contract used by folder and file logic.
+// It enables injecting a fake in tests.
+type WebdavAPI interface {
+	Connect(ctx context.Context, timeout time.Duration) error
+	Disconnect() error
+	ReadDir(folder string) ([]WebdavFileInfo, error)
+	ReadFile(filePath string, w io.Writer) (int64, error)
+	DeleteFile(filePath string) error
+	Host() string
+}
+
+// WebdavFileInfo describes a single entry returned by a PROPFIND listing.
+type WebdavFileInfo struct {
+	Name  string
+	Size  int64
+	IsDir bool
+}
+
+type WebdavClient struct {
+	baseURL     *url.URL
+	username    string
+	password    *sensitive.String
+	bearerToken *sensitive.String
+
+	// ctx bounds every request of the session
+	ctx    context.Context
+	client *http.Client
+}
+
+// Package-level errors
+var (
+	ErrWebdavDisconnected = fmt.Errorf("not connected to the WebDAV server")
+)
+
+// propfindBody requests only the properties needed to list a collection.
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:"><d:prop><d:resourcetype/><d:getcontentlength/></d:prop></d:propfind>`
+
+// multistatus mirrors the subset of a RFC 4918 PROPFIND response that is used.
+type multistatus struct {
+	Responses []struct {
+		Href     string `xml:"DAV: href"`
+		Propstat []struct {
+			Prop struct {
+				ResourceType struct {
+					Collection *struct{} `xml:"DAV: collection"`
+				} `xml:"DAV: resourcetype"`
+				ContentLength string `xml:"DAV: getcontentlength"`
+			} `xml:"DAV: prop"`
+			Status string `xml:"DAV: status"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+}
+
+func NewWebdavClient(baseURL string, username string, password *sensitive.String, bearerToken *sensitive.String) (*WebdavClient, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WebDAV url %s: %w", baseURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid WebDAV url %s: scheme must be http or https", baseURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	return &WebdavClient{
+		baseURL:     u,
+		username:    username,
+		password:    password,
+		bearerToken: bearerToken,
+	}, nil
+}
+
+// Connect prepares the HTTP client and verifies the server root is reachable with the configured credentials.
+// The timeout bounds connecting and waiting for responses, not the transfer of file contents.
+func (c *WebdavClient) Connect(ctx context.Context, timeout time.Duration) error {
+	slog.Debug("Initiating WebDAV connection", "host", c.Host())
+
+	c.ctx = ctx
+	c.client = util.NewHTTPClient(timeout)
+
+	resp, err := c.do("PROPFIND", "/", map[string]string{"Depth": "0"}, strings.NewReader(propfindBody))
+	if err != nil {
+		c.client = nil
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusMultiStatus && resp.StatusCode != http.StatusOK {
+		c.client = nil
+		return fmt.Errorf("unexpected response from WebDAV server: %s", resp.Status)
+	}
+	return nil
+}
+
+// Disconnect releases idle connections held by the HTTP client.
+func (c *WebdavClient) Disconnect() error {
+	slog.Debug("Disconnecting WebDAV connection", "host", c.Host())
+
+	if c.client != nil {
+		c.client.CloseIdleConnections()
+		c.client = nil
+	}
+	return nil
+}
+
+// ReadDir lists the direct children of a remote collection.
+func (c *WebdavClient) ReadDir(folder string) ([]WebdavFileInfo, error) {
+	if c.client == nil {
+		return nil, ErrWebdavDisconnected
+	}
+
+	resp, err := c.do("PROPFIND", folder, map[string]string{"Depth": "1"}, strings.NewReader(propfindBody))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, fmt.Errorf("failed to list %s: %s", folder, resp.Status)
+	}
+
+	var ms multistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, fmt.Errorf("failed to parse listing of %s: %w", folder, err)
+	}
+
+	self := path.Clean(path.Join(c.baseURL.Path, "/", folder))
+	var entries []WebdavFileInfo
+	for _, r := range ms.Responses {
+		hrefPath := r.Href
+		if u, err := url.Parse(r.Href); err == nil {
+			hrefPath = u.Path
+		}
+		hrefPath = path.Clean(hrefPath)
+		if hrefPath == self {
+			continue
+		}
+
+		entry := WebdavFileInfo{Name: path.Base(hrefPath)}
+		for _, ps := range r.Propstat {
+			if !strings.Contains(ps.Status, " 200 ") {
+				continue
+			}
+			if ps.Prop.ResourceType.Collection != nil {
+				entry.IsDir = true
+			}
+			if ps.Prop.ContentLength != "" {
+				entry.Size, _ = strconv.ParseInt(ps.Prop.ContentLength, 10, 64)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// ReadFile streams a remote file to the provided writer.
+func (c *WebdavClient) ReadFile(filePath string, w io.Writer) (int64, error) {
+	if c.client == nil {
+		return 0, ErrWebdavDisconnected
+	}
+
+	resp, err := c.do(http.MethodGet, filePath, nil, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed to download %s: %s", filePath, resp.Status)
+	}
+	return io.Copy(w, resp.Body)
+}
+
+// DeleteFile removes a remote file.
+func (c *WebdavClient) DeleteFile(filePath string) error {
+	if c.client == nil {
+		return ErrWebdavDisconnected
+	}
+
+	resp, err := c.do(http.MethodDelete, filePath, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+	return nil
+}
+
+func (c *WebdavClient) Host() string {
+	return c.baseURL.Host
+}
+
+// do builds and sends an authenticated request for a path relative to the base URL.
+func (c *WebdavClient) do(method string, remotePath string, headers map[string]string, body io.Reader) (*http.Response, error) {
+	u := *c.baseURL
+	u.Path = path.Join(c.baseURL.Path, "/", remotePath)
+	if method == "PROPFIND" && !strings.HasSuffix(u.Path, "/") {
+		// Collections are addressed with a trailing slash to avoid redirects
+		u.Path += "/"
+	}
+
+	req, err := http.NewRequestWithContext(c.ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if method == "PROPFIND" {
+		req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	switch {
+	case c.bearerToken != nil && *c.bearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+string(*c.bearerToken))
+	case c.username != "":
+		var password string
+		if c.password != nil {
+			password = string(*c.password)
+		}
+		req.SetBasicAuth(c.username, password)
+	}
+
+	return c.client.Do(req)
+}
@@ -0,0 +1,165 @@
+package webdav
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-playground/sensitive"
+)
+
+// TestNewWebdavClient_InvalidURL ensures unsupported schemes are rejected.
+func TestNewWebdavClient_InvalidURL(t *testing.T) {
+	if _, err := NewWebdavClient("ftp://host/dav", "", nil, nil); err == nil {
+		t.Fatalf("expected error for ftp scheme")
+	}
+	if _, err := NewWebdavClient("://bad", "", nil, nil); err == nil {
+		t.Fatalf("expected parse error")
+	}
+}
+
+// TestWebdavClient_NotConnectedErrors ensures client methods error when not connected.
+func TestWebdavClient_NotConnectedErrors(t *testing.T) {
+	c, err := NewWebdavClient("https://cloud.example/remote.php/dav", "", nil, nil)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if _, err := c.ReadDir("/"); err == nil {
+		t.Fatalf("expected error")
+	}
+	if _, err := c.ReadFile("/x", nil); err == nil {
+		t.Fatalf("expected error")
+	}
+	if err := c.DeleteFile("/x"); err == nil {
+		t.Fatalf("expected error")
+	}
+	if c.Host() != "cloud.example" {
+		t.Fatalf("host mismatch: %s", c.Host())
+	}
+}
+
+// TestWebdavClient_ReadDir lists files and collections from an in-process server.
+func TestWebdavClient_ReadDir(t *testing.T) {
+	dir := t.TempDir()
+	writeRemote(t, dir, "books/a.epub", "abc")
+	writeRemote(t, dir, "books/sub/b.kepub", "defg")
+	c := mustConnect(t, newTestServer(t, dir))
+
+	entries, err := c.ReadDir("/books")
+	if err != nil {
+		t.Fatalf("readdir: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("want 2 entries, got %+v", entries)
+	}
+	for _, e := range entries {
+		switch e.Name {
+		case "a.epub":
+			if e.IsDir || e.Size != 3 {
+				t.Fatalf("unexpected file entry: %+v", e)
+			}
+		case "sub":
+			if !e.IsDir {
+				t.Fatalf("expected collection: %+v", e)
+			}
+		default:
+			t.Fatalf("unexpected entry: %+v", e)
+		}
+	}
+}
+
+// TestWebdavClient_ReadFileAndDelete streams a file and removes it afterwards.
+func TestWebdavClient_ReadFileAndDelete(t *testing.T) {
+	dir := t.TempDir()
+	writeRemote(t, dir, "a.epub", "content")
+	c := mustConnect(t, newTestServer(t, dir))
+
+	var buf bytes.Buffer
+	n, err := c.ReadFile("/a.epub", &buf)
+	if err != nil || n != 7 || buf.String() != "content" {
+		t.Fatalf("read n=%d err=%v body=%q", n, err, buf.String())
+	}
+	if err := c.DeleteFile("/a.epub"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a.epub")); !os.IsNotExist(err) {
+		t.Fatalf("expected remote file removed, got %v", err)
+	}
+	if _, err := c.ReadFile("/a.epub", &buf); err == nil {
+		t.Fatalf("expected error reading removed file")
+	}
+}
+
+// TestWebdavClient_BasicAuth verifies credentials are sent and rejected credentials fail Connect.
+func TestWebdavClient_BasicAuth(t *testing.T) {
+	url := newAuthTestServer(t, t.TempDir(), "alice", "secret")
+
+	pw := sensitive.String("secret")
+	c, _ := NewWebdavClient(url, "alice", &pw, nil)
+	if err := c.Connect(context.Background(), 5*time.Second); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+
+	bad := sensitive.String("wrong")
+	c2, _ := NewWebdavClient(url, "alice", &bad, nil)
+	if err := c2.Connect(context.Background(), 5*time.Second); err == nil {
+		t.Fatalf("expected auth error")
+	}
+}
+
+// TestWebdavClient_BearerToken verifies bearer tokens take precedence over basic auth.
+func TestWebdavClient_BearerToken(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusMultiStatus)
+	}))
+	t.Cleanup(srv.Close)
+
+	tok := sensitive.String("tkn")
+	c, _ := NewWebdavClient(srv.URL, "alice", nil, &tok)
+	if err := c.Connect(context.Background(), 5*time.Second); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if got != "Bearer tkn" {
+		t.Fatalf("unexpected Authorization header: %q", got)
+	}
+}
+
+// TestWebdavClient_SlowDownload verifies downloads may take longer than the
+// connect timeout and are stopped by the session context.
+func TestWebdavClient_SlowDownload(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PROPFIND" {
+			w.WriteHeader(http.StatusMultiStatus)
+			return
+		}
+		for range 3 {
+			_, _ = w.Write([]byte("chunk"))
+			w.(http.Flusher).Flush()
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c, _ := NewWebdavClient(srv.URL, "", nil, nil)
+	if err := c.Connect(ctx, 50*time.Millisecond); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := c.ReadFile("/book.epub", &buf); err != nil || buf.String() != "chunkchunkchunk" {
+		t.Fatalf("slow download: %q %v", buf.String(), err)
+	}
+
+	cancel()
+	if _, err := c.ReadFile("/book.epub", &buf); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package webdav
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bjw-s-labs/bookshift/pkg/util"
+)
+
+// TestWebdavFile_Download_KeepFolderStructureAndDelete verifies subfolders are kept and the source removed.
+func TestWebdavFile_Download_KeepFolderStructureAndDelete(t *testing.T) {
+	fake := &recWebdav{reads: map[string]string{"/r/a/b/x.epub": "DATA"}}
+	folder := &WebdavFolder{Folder: "/r", webdavClient: fake}
+	wf := NewWebdavFile("/r", "a/b", &WebdavFileInfo{Name: "x.epub", Size: 4}, folder)
+
+	dst := t.TempDir()
+	if err := wf.Download(dst, "", true, true, true); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(dst, "a", "b", "x.epub"))
+	if err != nil || string(b) != "DATA" {
+		t.Fatalf("read: %v %q", err, string(b))
+	}
+	if len(fake.deleted) != 1 || fake.deleted[0] != "/r/a/b/x.epub" {
+		t.Fatalf("expected source deleted, got %v", fake.deleted)
+	}
+}
+
+// TestWebdavFile_Download_SkipWhenExists verifies existing files are kept when overwrite is false.
+func TestWebdavFile_Download_SkipWhenExists(t *testing.T) {
+	fake := &recWebdav{reads: map[string]string{"/r/y.epub": "NEW"}}
+	folder := &WebdavFolder{Folder: "/r", webdavClient: fake}
+	wf := NewWebdavFile("/r", "", &WebdavFileInfo{Name: "y.epub", Size: 3}, folder)
+
+	dst := t.TempDir()
+	p := filepath.Join(dst, "y.epub")
+	if err := os.WriteFile(p, []byte("OLD"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := wf.Download(dst, "", false, false, true); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if b, _ := os.ReadFile(p); string(b) != "OLD" {
+		t.Fatalf("file was overwritten: %q", string(b))
+	}
+	if len(fake.deleted) != 0 {
+		t.Fatalf("skipped file must not delete source")
+	}
+}
+
+// TestWebdavFile_Download_ReadErrorCleansUp ensures failed transfers leave no temp files behind.
+func TestWebdavFile_Download_ReadErrorCleansUp(t *testing.T) {
+	fake := &recWebdav{readErr: errors.New("boom")}
+	folder := &WebdavFolder{Folder: "/r", webdavClient: fake}
+	wf := NewWebdavFile("/r", "", &WebdavFileInfo{Name: "z.epub"}, folder)
+
+	dst := t.TempDir()
+	if err := wf.Download(dst, "", true, false, false); err == nil {
+		t.Fatalf("expected read error")
+	}
+	entries, _ := os.ReadDir(dst)
+	if len(entries) != 0 {
+		t.Fatalf("expected no leftovers, got %d entries", len(entries))
+	}
+}
+
+// TestWebdavFile_Download_DryRun ensures no files are written or deleted in dry-run mode.
+func TestWebdavFile_Download_DryRun(t *testing.T) {
+	old := util.DryRun
+	t.Cleanup(func() { util.DryRun = old })
+	util.DryRun = true
+
+	fake := &recWebdav{reads: map[string]string{"/r/a.epub": "A"}}
+	folder := &WebdavFolder{Folder: "/r", webdavClient: fake}
+	wf := NewWebdavFile("/r", "", &WebdavFileInfo{Name: "a.epub"}, folder)
+
+	dst := t.TempDir()
+	if err := wf.Download(dst, "", true, false, true); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "a.epub")); !os.IsNotExist(err) {
+		t.Fatalf("dry-run must not write files")
+	}
+	if len(fake.deleted) != 0 {
+		t.Fatalf("dry-run must not delete")
+	}
+}
@@ -0,0 +1,39 @@
+package webdav
+
+import (
+	"testing"
+)
+
+// TestWebdavFolderFetchFiles_Recurse verifies recursive traversal and extension filtering.
+func TestWebdavFolderFetchFiles_Recurse(t *testing.T) {
+	dir := t.TempDir()
+	writeRemote(t, dir, "books/a.EPUB", "a")
+	writeRemote(t, dir, "books/skip.txt", "x")
+	writeRemote(t, dir, "books/sub/b.kepub", "b")
+	c := mustConnect(t, newTestServer(t, dir))
+
+	folder := NewWebdavFolder("/books", c)
+	files, err := folder.FetchFiles("/books", []string{".epub", ".kepub"}, true)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("want 2, got %d", len(files))
+	}
+	subs := map[string]string{}
+	for _, f := range files {
+		subs[f.webdavFile.Name] = f.subFolder
+	}
+	if subs["b.kepub"] != "/sub" || subs["a.EPUB"] != "" {
+		t.Fatalf("unexpected subfolders: %v", subs)
+	}
+}
+
+// TestWebdavFolderFetchFiles_MissingFolder ensures listing errors are returned.
+func TestWebdavFolderFetchFiles_MissingFolder(t *testing.T) {
+	c := mustConnect(t, newTestServer(t, t.TempDir()))
+	folder := NewWebdavFolder("/nope", c)
+	if _, err := folder.FetchFiles("/nope", nil, true); err == nil {
+		t.Fatalf("expected error for missing folder")
+	}
+}
@@ -0,0 +1,75 @@
+package webdav
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+type WebdavSyncer struct {
+	config *config.WebdavConfig
+}
+
+func NewWebdavSyncer(serverConfig *config.WebdavConfig) *WebdavSyncer {
+	return &WebdavSyncer{
+		config: serverConfig,
+	}
+}
+
+func (s *WebdavSyncer) Run(targetFolder string, validExtensions []string, overwriteExistingFiles bool) error {
+	return s.RunContext(context.Background(), targetFolder, validExtensions, overwriteExistingFiles)
+}
+
+func (s *WebdavSyncer) RunContext(ctx context.Context, targetFolder string, validExtensions []string, overwriteExistingFiles bool) error {
+	if s.config.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(s.config.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	// Connect to the WebDAV server
+	webdavClient, err := newWebdavClient(s.config)
+	if err != nil {
+		return err
+	}
+	if err := webdavConnect(ctx, webdavClient, 30*time.Second); err != nil {
+		return fmt.Errorf("could not connect to WebDAV server %s: %w", s.config.URL, err)
+	}
+	defer webdavClient.Disconnect()
+
+	// Instantiate a WebDAV Folder (via hook)
+	webdavFolder := webdavNewFolder(s.config.Folder, webdavClient)
+
+	// Fetch all files in the folder
+	allFiles, err := webdavFetchFiles(webdavFolder, s.config.Folder, validExtensions, true)
+	if err != nil {
+		return fmt.Errorf("could not fetch files from folder %s on WebDAV server %s: %w", s.config.Folder, s.config.URL, err)
+	}
+
+	// Download all files
+	for i := range allFiles {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		if err := webdavDownload(&allFiles[i],
+			targetFolder,
+			"",
+			overwriteExistingFiles,
+			s.config.KeepFolderStructure,
+			s.config.RemoveFilesAfterDownload,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,28 @@
+// syncer_seams.go: Test seams for the WebDAV syncer.
+//
+// This file defines small function variables that production code uses by default,
+// but tests can override to substitute fakes. Keeping these seams in a separate
+// file keeps the main implementation clean while preserving simple overrides in tests.
+package webdav
+
+import (
+	"context"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+// test hooks (seams) for dependency injection in tests
+var (
+	newWebdavClient = func(cfg *config.WebdavConfig) (WebdavAPI, error) {
+		return NewWebdavClient(cfg.URL, cfg.Username, cfg.Password, cfg.BearerToken)
+	}
+	webdavConnect    = func(ctx context.Context, c WebdavAPI, timeout time.Duration) error { return c.Connect(ctx, timeout) }
+	webdavNewFolder  = func(folder string, conn WebdavAPI) *WebdavFolder { return NewWebdavFolder(folder, conn) }
+	webdavFetchFiles = func(f *WebdavFolder, folder string, valid []string, recurse bool) ([]WebdavFile, error) {
+		return f.FetchFiles(folder, valid, recurse)
+	}
+	webdavDownload = func(wf *WebdavFile, dst, name string, overwrite, keep, del bool) error {
+		return wf.Download(dst, name, overwrite, keep, del)
+	}
+)
@@ -0,0 +1,87 @@
+package webdav
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bjw-s-labs/bookshift/pkg/config"
+)
+
+// TestWebdavSyncer_Run_EndToEnd syncs from an in-process WebDAV server and removes the sources.
+func TestWebdavSyncer_Run_EndToEnd(t *testing.T) {
+	remote := t.TempDir()
+	writeRemote(t, remote, "books/a.epub", "A")
+	writeRemote(t, remote, "books/sub/b.kepub", "BB")
+	writeRemote(t, remote, "books/c.txt", "C")
+
+	cfg := &config.WebdavConfig{
+		URL:                      newTestServer(t, remote),
+		Folder:                   "/books",
+		KeepFolderStructure:      true,
+		RemoveFilesAfterDownload: true,
+	}
+	dst := t.TempDir()
+	if err := NewWebdavSyncer(cfg).Run(dst, []string{".epub", ".kepub"}, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for _, p := range []string{"a.epub", filepath.Join("sub", "b.kepub")} {
+		if _, err := os.Stat(filepath.Join(dst, p)); err != nil {
+			t.Fatalf("expected %s downloaded: %v", p, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(remote, "books", "a.epub")); !os.IsNotExist(err) {
+		t.Fatalf("expected remote a.epub removed")
+	}
+	if _, err := os.Stat(filepath.Join(remote, "books", "c.txt")); err != nil {
+		t.Fatalf("unmatched file must remain: %v", err)
+	}
+}
+
+// TestWebdavSyncer_Run_InvalidURL ensures client construction errors propagate.
+func TestWebdavSyncer_Run_InvalidURL(t *testing.T) {
+	cfg := &config.WebdavConfig{URL: "ftp://nope", Folder: "/"}
+	if err := NewWebdavSyncer(cfg).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+// TestWebdavSyncer_Run_ConnectError ensures connect errors abort the run.
+func TestWebdavSyncer_Run_ConnectError(t *testing.T) {
+	orig := webdavConnect
+	t.Cleanup(func() { webdavConnect = orig })
+	webdavConnect = func(ctx context.Context, c WebdavAPI, timeout time.Duration) error { return errors.New("x") }
+
+	cfg := &config.WebdavConfig{URL: "http://h", Folder: "/"}
+	if err := NewWebdavSyncer(cfg).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected connect error")
+	}
+}
+
+// TestWebdavSyncer_Run_DownloadError ensures download errors abort the run.
+func TestWebdavSyncer_Run_DownloadError(t *testing.T) {
+	remote := t.TempDir()
+	writeRemote(t, remote, "a.epub", "A")
+
+	orig := webdavDownload
+	t.Cleanup(func() { webdavDownload = orig })
+	webdavDownload = func(wf *WebdavFile, dst, name string, overwrite, keep, del bool) error { return errors.New("z") }
+
+	cfg := &config.WebdavConfig{URL: newTestServer(t, remote), Folder: "/"}
+	if err := NewWebdavSyncer(cfg).Run(t.TempDir(), nil, false); err == nil {
+		t.Fatalf("expected download error")
+	}
+}
+
+// TestWebdavSyncer_RunContext_Cancel verifies that a canceled context stops the run.
+func TestWebdavSyncer_RunContext_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cfg := &config.WebdavConfig{URL: "http://h", Folder: "/"}
+	if err := NewWebdavSyncer(cfg).RunContext(ctx, t.TempDir(), nil, false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled, got %v", err)
+	}
+}
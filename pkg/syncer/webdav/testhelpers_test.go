@@ -0,0 +1,97 @@
+package webdav
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	xwebdav "golang.org/x/net/webdav"
+)
+
+// newTestServer serves dir through an in-process WebDAV handler and returns its URL.
+func newTestServer(t *testing.T, dir string) string {
+	t.Helper()
+	h := &xwebdav.Handler{
+		FileSystem: xwebdav.Dir(dir),
+		LockSystem: xwebdav.NewMemLS(),
+	}
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+// newAuthTestServer wraps the WebDAV handler with a basic auth check.
+func newAuthTestServer(t *testing.T, dir, user, pass string) string {
+	t.Helper()
+	h := &xwebdav.Handler{
+		FileSystem: xwebdav.Dir(dir),
+		LockSystem: xwebdav.NewMemLS(),
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, ok := r.BasicAuth(); !ok || u != user || p != pass {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+// writeRemote creates a file below the served directory.
+func writeRemote(t *testing.T, dir, name, content string) {
+	t.Helper()
+	p := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+}
+
+// recWebdav implements WebdavAPI for file operations and records deletions.
+type recWebdav struct {
+	reads   map[string]string
+	readErr error
+	deleted []string
+}
+
+func (f *recWebdav) Connect(ctx context.Context, timeout time.Duration) error { return nil }
+func (f *recWebdav) Disconnect() error                                        { return nil }
+func (f *recWebdav) ReadDir(folder string) ([]WebdavFileInfo, error) {
+	return nil, errors.New("unused")
+}
+func (f *recWebdav) DeleteFile(p string) error { f.deleted = append(f.deleted, p); return nil }
+func (f *recWebdav) Host() string              { return "fake" }
+func (f *recWebdav) ReadFile(p string, w io.Writer) (int64, error) {
+	if f.readErr != nil {
+		return 0, f.readErr
+	}
+	s, ok := f.reads[p]
+	if !ok {
+		return 0, errors.New("not found")
+	}
+	n, err := w.Write([]byte(s))
+	return int64(n), err
+}
+
+// mustConnect returns a connected client for url.
+func mustConnect(t *testing.T, url string) *WebdavClient {
+	t.Helper()
+	c, err := NewWebdavClient(url, "", nil, nil)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if err := c.Connect(context.Background(), 5*time.Second); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Disconnect() })
+	return c
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
 )
 
 // CountFilesInFolder returns the number of files in a folder
@@ -47,3 +48,22 @@ func CountFilesInFolder(folder string, validExtensions []string, recurse bool) (
 
 	return count, nil
 }
+
+// FolderSpace returns the free and total bytes of the filesystem holding the
+// folder. A folder that does not exist yet is measured at its nearest
+// existing parent.
+func FolderSpace(folder string) (free uint64, total uint64, err error) {
+	folder = filepath.Clean(folder)
+	for {
+		var st syscall.Statfs_t
+		err = syscall.Statfs(folder, &st)
+		if err == nil {
+			return st.Bavail * uint64(st.Bsize), st.Blocks * uint64(st.Bsize), nil
+		}
+		parent := filepath.Dir(folder)
+		if !os.IsNotExist(err) || parent == folder {
+			return 0, 0, err
+		}
+		folder = parent
+	}
+}
@@ -33,3 +33,15 @@ func TestCountFilesInFolder(t *testing.T) {
 		t.Fatalf("CountFilesInFolder=%d, want 2", cnt)
 	}
 }
+
+// TestFolderSpace measures existing folders and the nearest parent of missing ones.
+func TestFolderSpace(t *testing.T) {
+	dir := t.TempDir()
+	free, total, err := FolderSpace(dir)
+	if err != nil || total == 0 || free > total {
+		t.Fatalf("unexpected space: %d/%d %v", free, total, err)
+	}
+	if _, missingTotal, err := FolderSpace(filepath.Join(dir, "missing", "books")); err != nil || missingTotal != total {
+		t.Fatalf("unexpected space for missing folder: %d %v", missingTotal, err)
+	}
+}
@@ -0,0 +1,24 @@
+package util
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// NewHTTPTransport returns a transport where timeout bounds connecting, the
+// TLS handshake and waiting for the response headers. Reading the body is not
+// bounded, so large downloads over slow links are not cut off; use a request
+// context to bound a whole run.
+func NewHTTPTransport(timeout time.Duration) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = timeout
+	transport.ResponseHeaderTimeout = timeout
+	return transport
+}
+
+// NewHTTPClient returns an HTTP client using NewHTTPTransport.
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{Transport: NewHTTPTransport(timeout)}
+}
@@ -0,0 +1,42 @@
+package util
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestNewHTTPClient_BodyNotBounded verifies slow bodies are read past the
+// timeout while slow response headers fail.
+func TestNewHTTPClient_BodyNotBounded(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow-headers" {
+			time.Sleep(300 * time.Millisecond)
+			return
+		}
+		for range 3 {
+			_, _ = io.WriteString(w, "chunk")
+			w.(http.Flusher).Flush()
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	client := NewHTTPClient(100 * time.Millisecond)
+	resp, err := client.Get(srv.URL + "/slow-body")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil || string(data) != strings.Repeat("chunk", 3) {
+		t.Fatalf("unexpected body %q: %v", data, err)
+	}
+
+	if _, err := client.Get(srv.URL + "/slow-headers"); err == nil {
+		t.Fatalf("expected response header timeout")
+	}
+}
@@ -0,0 +1,36 @@
+package util
+
+import (
+	"mime"
+	"strings"
+)
+
+// bookMimeTypes maps e-book MIME types to the file extension they are stored with.
+var bookMimeTypes = map[string]string{
+	"application/epub+zip":           ".epub",
+	"application/kepub+zip":          ".kepub",
+	"application/x-kobo-epub+zip":    ".kepub",
+	"application/pdf":                ".pdf",
+	"application/x-mobipocket-ebook": ".mobi",
+	"application/x-mobi8-ebook":      ".azw3",
+	"application/vnd.amazon.ebook":   ".azw",
+	"application/x-cbz":              ".cbz",
+	"application/vnd.comicbook+zip":  ".cbz",
+	"application/x-cbr":              ".cbr",
+	"application/vnd.comicbook-rar":  ".cbr",
+	"application/x-cb7":              ".cb7",
+	"application/x-fictionbook+xml":  ".fb2",
+	"text/fb2+xml":                   ".fb2",
+	"application/rtf":                ".rtf",
+	"text/plain":                     ".txt",
+}
+
+// ExtensionForMimeType returns the file extension for an e-book MIME type,
+// ignoring parameters and case. It returns an empty string for unknown types.
+func ExtensionForMimeType(mimeType string) string {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		mediaType = strings.TrimSpace(strings.Split(mimeType, ";")[0])
+	}
+	return bookMimeTypes[strings.ToLower(mediaType)]
+}
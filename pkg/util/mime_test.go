@@ -0,0 +1,23 @@
+package util
+
+import "testing"
+
+// TestExtensionForMimeType verifies common e-book types map to extensions and parameters are ignored.
+func TestExtensionForMimeType(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"application/epub+zip", ".epub"},
+		{"Application/EPUB+zip; charset=binary", ".epub"},
+		{"application/kepub+zip", ".kepub"},
+		{"application/vnd.comicbook+zip", ".cbz"},
+		{"text/html", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := ExtensionForMimeType(tt.in); got != tt.want {
+			t.Fatalf("ExtensionForMimeType(%q)=%q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package util
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// stateFolderName is the hidden folder below the target folder holding source state files.
+const stateFolderName = ".bookshift"
+
+// DefaultStatePath returns the state file location for a source, derived from
+// the target folder, the source type and a value identifying the source (e.g. its URL).
+func DefaultStatePath(targetFolder string, sourceType string, identity string) string {
+	sum := sha1.Sum([]byte(identity))
+	return filepath.Join(targetFolder, stateFolderName, sourceType+"-"+hex.EncodeToString(sum[:8])+".json")
+}
+
+// LoadState reads JSON state from path into v. A missing file leaves v untouched.
+func LoadState(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// SaveState atomically writes v as JSON to path, creating parent folders as needed.
+// Nothing is written in dry-run mode.
+func SaveState(path string, v any) error {
+	if DryRun {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	folder := filepath.Dir(path)
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		return err
+	}
+	tmpFile, err := os.CreateTemp(folder, "bookshift-state-")
+	if err != nil {
+		return err
+	}
+	defer tmpFile.Close()
+
+	if _, err := tmpFile.Write(data); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	if err := tmpFile.Sync(); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	if err := os.Rename(tmpFile.Name(), path); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	return nil
+}
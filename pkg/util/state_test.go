@@ -0,0 +1,68 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type sampleState struct {
+	Seen []string `json:"seen"`
+}
+
+// TestState_RoundTrip verifies that saved state is loaded back and missing files are ignored.
+func TestState_RoundTrip(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "nested", "state.json")
+
+	var s sampleState
+	if err := LoadState(p, &s); err != nil || len(s.Seen) != 0 {
+		t.Fatalf("missing file: %v %+v", err, s)
+	}
+
+	if err := SaveState(p, sampleState{Seen: []string{"a", "b"}}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	var got sampleState
+	if err := LoadState(p, &got); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(got.Seen) != 2 || got.Seen[1] != "b" {
+		t.Fatalf("unexpected state: %+v", got)
+	}
+}
+
+// TestState_DryRunAndCorrupt ensures dry-run skips writes and corrupt files surface an error.
+func TestState_DryRunAndCorrupt(t *testing.T) {
+	old := DryRun
+	t.Cleanup(func() { DryRun = old })
+	DryRun = true
+
+	p := filepath.Join(t.TempDir(), "state.json")
+	if err := SaveState(p, sampleState{}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if _, err := os.Stat(p); !os.IsNotExist(err) {
+		t.Fatalf("dry-run must not write state")
+	}
+
+	if err := os.WriteFile(p, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var s sampleState
+	if err := LoadState(p, &s); err == nil {
+		t.Fatalf("expected error for corrupt state")
+	}
+}
+
+// TestDefaultStatePath ensures state paths are stable, hidden and distinct per identity.
+func TestDefaultStatePath(t *testing.T) {
+	a := DefaultStatePath("/books", "opds", "https://a")
+	b := DefaultStatePath("/books", "opds", "https://b")
+	if a == b || a != DefaultStatePath("/books", "opds", "https://a") {
+		t.Fatalf("unexpected paths %q %q", a, b)
+	}
+	if !strings.HasPrefix(a, filepath.Join("/books", ".bookshift", "opds-")) || filepath.Ext(a) != ".json" {
+		t.Fatalf("unexpected path %q", a)
+	}
+}